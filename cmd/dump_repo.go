@@ -0,0 +1,80 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations"
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/urfave/cli"
+)
+
+// CmdDumpRepository represents the available dump repository sub-command.
+var CmdDumpRepository = cli.Command{
+	Name:  "dump-repo",
+	Usage: "Dump a repository with its issues, pull requests, releases and wiki",
+	Description: `Dump-repo writes one repository with its issues, comments, pull requests, reviews,
+labels, milestones, releases and wiki into a zip file which can be restored with restore-repo`,
+	Action: runDumpRepository,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "owner_name",
+			Usage: "Owner name of the repository to dump",
+		},
+		cli.StringFlag{
+			Name:  "repo_name",
+			Usage: "Name of the repository to dump",
+		},
+		cli.StringFlag{
+			Name:  "file, f",
+			Value: fmt.Sprintf("gitea-repo-dump-%d.zip", time.Now().Unix()),
+			Usage: "Name of the dump file which will be created.",
+		},
+	},
+}
+
+func runDumpRepository(ctx *cli.Context) error {
+	if !ctx.IsSet("owner_name") || !ctx.IsSet("repo_name") {
+		return errors.New("owner_name and repo_name have to be given")
+	}
+
+	if err := initDB(); err != nil {
+		return err
+	}
+	if err := storage.Init(); err != nil {
+		return err
+	}
+
+	repo, err := models.GetRepositoryByOwnerAndName(ctx.String("owner_name"), ctx.String("repo_name"))
+	if err != nil {
+		return err
+	}
+
+	fileName := ctx.String("file")
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	log.Info("Dumping repository %s to %s", repo.FullName(), fileName)
+	if err := migrations.DumpRepositoryArchive(context.Background(), repo, f); err != nil {
+		if err1 := os.Remove(fileName); err1 != nil {
+			log.Error("Remove %s: %v", fileName, err1)
+		}
+		return err
+	}
+
+	fmt.Printf("Repository %s dumped to %s\n", repo.FullName(), fileName)
+	return nil
+}
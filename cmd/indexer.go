@@ -0,0 +1,76 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	code_indexer "code.gitea.io/gitea/modules/indexer/code"
+	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/urfave/cli"
+)
+
+var (
+	// CmdIndexer represents the available indexer sub-command.
+	CmdIndexer = cli.Command{
+		Name:  "indexer",
+		Usage: "Manage the issue and code indexers",
+		Subcommands: []cli.Command{
+			subcmdIndexerRebuild,
+		},
+	}
+
+	subcmdIndexerRebuild = cli.Command{
+		Name:  "rebuild",
+		Usage: "Drop and rebuild an Elasticsearch index",
+		Description: `Rebuild drops the index of the configured Elasticsearch indexer and indexes
+all issues or the default branches of all repositories again. Bleve indexes can't
+be rebuilt while Gitea is running, remove their directory while Gitea is stopped instead.`,
+		Action: runIndexerRebuild,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "type, t",
+				Usage: "Indexer to rebuild: 'issues' or 'code'",
+			},
+		},
+	}
+)
+
+func runIndexerRebuild(ctx *cli.Context) error {
+	if err := initDB(); err != nil {
+		return err
+	}
+	setting.NewServices()
+
+	var (
+		count int
+		what  string
+		err   error
+	)
+	switch tp := ctx.String("type"); tp {
+	case "issues":
+		what = "issues"
+		count, err = issue_indexer.RebuildIssueIndexer(context.Background())
+	case "code":
+		if !setting.Indexer.RepoIndexerEnabled {
+			return fmt.Errorf("the code indexer is disabled")
+		}
+		what = "repositories"
+		count, err = code_indexer.RebuildIndexer(context.Background())
+	default:
+		return fmt.Errorf("unsupported indexer type %q, it has to be issues or code", tp)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Info("Index rebuilt, %d %s indexed", count, what)
+	fmt.Printf("Index rebuilt, %d %s indexed\n", count, what)
+	return nil
+}
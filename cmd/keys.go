@@ -17,7 +17,7 @@ import (
 // CmdKeys represents the available keys sub-command
 var CmdKeys = cli.Command{
 	Name:   "keys",
-	Usage:  "This command queries the Gitea database to get the authorized command for a given ssh key fingerprint",
+	Usage:  "This command queries the Gitea database to get the authorized command for a given ssh key fingerprint or certificate",
 	Action: runKeys,
 	Flags: []cli.Flag{
 		cli.StringFlag{
@@ -64,6 +64,16 @@ func runKeys(c *cli.Context) error {
 
 	setup("keys.log", false)
 
+	// Certificates are looked up when running as AuthorizedPrincipalsCommand
+	if strings.HasSuffix(strings.TrimSpace(c.String("type")), "-cert-v01@openssh.com") {
+		principalString, err := private.AuthorizedPrincipalsByCertificate(content)
+		if err != nil {
+			return err
+		}
+		fmt.Println(strings.TrimSpace(principalString))
+		return nil
+	}
+
 	authorizedString, err := private.AuthorizedPublicKeyByContent(content)
 	if err != nil {
 		return err
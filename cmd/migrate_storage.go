@@ -0,0 +1,145 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/urfave/cli"
+)
+
+// CmdMigrateStorage represents the available migrate-storage sub-command.
+var CmdMigrateStorage = cli.Command{
+	Name:  "migrate-storage",
+	Usage: "Migrate the storage",
+	Description: `Migrate-storage copies all files of one kind from the currently configured storage
+to another storage. Change the configuration to the new storage afterwards.`,
+	Action: runMigrateStorage,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "type, t",
+			Usage: "Kind of files to migrate: 'attachments', 'lfs', 'avatars', 'repo-avatars' or 'packages'",
+		},
+		cli.StringFlag{
+			Name:  "storage, s",
+			Value: setting.LocalStorageType,
+			Usage: "New storage type: 'local' or 'minio'",
+		},
+		cli.StringFlag{
+			Name:  "path, p",
+			Usage: "New storage directory if the storage type is local (leave blank for the configured path)",
+		},
+		cli.StringFlag{
+			Name:  "minio-endpoint",
+			Usage: "Minio storage endpoint",
+		},
+		cli.StringFlag{
+			Name:  "minio-access-key-id",
+			Usage: "Minio storage accessKeyID",
+		},
+		cli.StringFlag{
+			Name:  "minio-secret-access-key",
+			Usage: "Minio storage secretAccessKey",
+		},
+		cli.StringFlag{
+			Name:  "minio-bucket",
+			Usage: "Minio storage bucket",
+		},
+		cli.StringFlag{
+			Name:  "minio-location",
+			Usage: "Minio storage location to create bucket",
+		},
+		cli.StringFlag{
+			Name:  "minio-base-path",
+			Usage: "Minio storage basepath on the bucket",
+		},
+		cli.BoolFlag{
+			Name:  "minio-use-ssl",
+			Usage: "Enable SSL for minio",
+		},
+	},
+}
+
+// migrateStorageTypes returns the configured storage of every kind of files migrate-storage can migrate
+func migrateStorageTypes() map[string]*setting.Storage {
+	return map[string]*setting.Storage{
+		"attachments":  &setting.AttachmentStorage,
+		"lfs":          &setting.LFSStorage,
+		"avatars":      &setting.AvatarStorage,
+		"repo-avatars": &setting.RepoAvatarStorage,
+		"packages":     &setting.PackageStorage,
+	}
+}
+
+func runMigrateStorage(ctx *cli.Context) error {
+	setting.NewContext()
+
+	tp := ctx.String("type")
+	srcCfg, ok := migrateStorageTypes()[tp]
+	if !ok {
+		return fmt.Errorf("unsupported storage type %q, it has to be one of attachments, lfs, avatars, repo-avatars or packages", tp)
+	}
+
+	dstCfg := *srcCfg
+	dstCfg.Type = strings.ToLower(ctx.String("storage"))
+	if ctx.IsSet("path") {
+		dstCfg.Path = ctx.String("path")
+	}
+	for flag, value := range map[string]*string{
+		"minio-endpoint":          &dstCfg.Minio.Endpoint,
+		"minio-access-key-id":     &dstCfg.Minio.AccessKeyID,
+		"minio-secret-access-key": &dstCfg.Minio.SecretAccessKey,
+		"minio-bucket":            &dstCfg.Minio.Bucket,
+		"minio-location":          &dstCfg.Minio.Location,
+		"minio-base-path":         &dstCfg.Minio.BasePath,
+	} {
+		if ctx.IsSet(flag) {
+			*value = ctx.String(flag)
+		}
+	}
+	if ctx.IsSet("minio-use-ssl") {
+		dstCfg.Minio.UseSSL = ctx.Bool("minio-use-ssl")
+	}
+	if reflect.DeepEqual(*srcCfg, dstCfg) {
+		return fmt.Errorf("the new storage of %s is the configured one", tp)
+	}
+
+	src, err := storage.NewStorage(*srcCfg)
+	if err != nil {
+		return fmt.Errorf("configured storage of %s: %v", tp, err)
+	}
+	dst, err := storage.NewStorage(dstCfg)
+	if err != nil {
+		return fmt.Errorf("new storage of %s: %v", tp, err)
+	}
+
+	count, err := migrateStorage(dst, src)
+	if err != nil {
+		return err
+	}
+
+	log.Info("%d files of %s migrated to the %s storage", count, tp, dstCfg.Type)
+	fmt.Printf("%d files of %s migrated to the %s storage\n", count, tp, dstCfg.Type)
+	return nil
+}
+
+// migrateStorage copies all objects of src to the same paths in dst
+func migrateStorage(dst, src storage.ObjectStorage) (int, error) {
+	var count int
+	err := src.IterateObjects(func(p string, obj storage.Object) error {
+		if _, err := dst.Save(p, obj); err != nil {
+			return fmt.Errorf("migrate %s: %v", p, err)
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
@@ -0,0 +1,43 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitea-migrate-storage-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src, err := storage.NewLocalStorage(filepath.Join(dir, "src"))
+	assert.NoError(t, err)
+	dst, err := storage.NewLocalStorage(filepath.Join(dir, "dst"))
+	assert.NoError(t, err)
+
+	for _, p := range []string{"a/0/a0b1", "c/d/cd23", "avatar"} {
+		_, err := src.Save(p, strings.NewReader("content of "+p))
+		assert.NoError(t, err)
+	}
+
+	count, err := migrateStorage(dst, src)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, count)
+
+	for _, p := range []string{"a/0/a0b1", "c/d/cd23", "avatar"} {
+		bs, err := ioutil.ReadFile(filepath.Join(dir, "dst", filepath.FromSlash(p)))
+		assert.NoError(t, err)
+		assert.EqualValues(t, "content of "+p, string(bs))
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"code.gitea.io/gitea/modules/private"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/urfave/cli"
+)
+
+// CmdRestoreRepository represents the available restore a repository sub-command.
+var CmdRestoreRepository = cli.Command{
+	Name:  "restore-repo",
+	Usage: "Restore a repository dumped by dump-repo",
+	Description: `Restore-repo asks the running Gitea server to create a repository from a file
+written by dump-repo, together with its issues, pull requests, releases and wiki`,
+	Action: runRestoreRepository,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "file, f",
+			Usage: "Path of the dump file to restore",
+		},
+		cli.StringFlag{
+			Name:  "owner_name",
+			Usage: "Owner of the restored repository",
+		},
+		cli.StringFlag{
+			Name:  "repo_name",
+			Usage: "Name of the restored repository",
+		},
+	},
+}
+
+func runRestoreRepository(ctx *cli.Context) error {
+	if !ctx.IsSet("file") || !ctx.IsSet("owner_name") || !ctx.IsSet("repo_name") {
+		return errors.New("file, owner_name and repo_name have to be given")
+	}
+
+	// the file is read by the server, which may run in another working directory
+	file, err := filepath.Abs(ctx.String("file"))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(file); err != nil {
+		return err
+	}
+
+	setting.NewContext()
+
+	statusCode, msg := private.RestoreRepo(private.RestoreRepoOptions{
+		File:      file,
+		OwnerName: ctx.String("owner_name"),
+		RepoName:  ctx.String("repo_name"),
+	})
+	if statusCode != http.StatusOK {
+		fail("Failed to restore repository", msg)
+	}
+
+	fmt.Println(msg)
+	return nil
+}
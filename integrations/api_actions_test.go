@@ -0,0 +1,183 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/repofiles"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testWorkflow = `name: CI
+on:
+  push:
+    branches: [master]
+jobs:
+  test:
+    name: Test
+    runs-on: linux
+    steps:
+      - run: make test
+`
+
+func addRunnerAuthHeader(req *http.Request, token string) *http.Request {
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req
+}
+
+func TestActions(t *testing.T) {
+	onGiteaRun(t, testActions)
+}
+
+func testActions(t *testing.T, u *url.URL) {
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+	resp, err := repofiles.CreateOrUpdateRepoFile(repo, user, &repofiles.UpdateRepoFileOptions{
+		OldBranch: repo.DefaultBranch,
+		TreePath:  ".gitea/workflows/ci.yml",
+		Message:   "Add workflow",
+		Content:   testWorkflow,
+		IsNewFile: true,
+	})
+	assert.NoError(t, err)
+	commitSHA := resp.Commit.SHA
+
+	// The workflows are detected by a queue
+	var run *models.ActionRun
+	for i := 0; i < 50 && run == nil; i++ {
+		runs, _, err := models.FindActionRuns(&models.FindActionRunOptions{RepoID: repo.ID, CommitSHA: commitSHA})
+		assert.NoError(t, err)
+		if len(runs) > 0 {
+			run = runs[0]
+		} else {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	if !assert.NotNil(t, run) {
+		return
+	}
+	assert.Equal(t, "CI", run.Title)
+	assert.Equal(t, "push", run.Event)
+	assert.Equal(t, "refs/heads/master", run.Ref)
+
+	var runnerToken string
+
+	t.Run("RegisterRunner", func(t *testing.T) {
+		req := NewRequestWithJSON(t, "POST", "/api/actions/runners/register", &api.RegisterActionRunnerOption{
+			Token:  "invalid",
+			Name:   "runner",
+			Labels: []string{"linux"},
+		})
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequestWithJSON(t, "POST", "/api/actions/runners/register", &api.RegisterActionRunnerOption{
+			Token:  setting.Actions.RegistrationToken,
+			Name:   "runner",
+			Labels: []string{"linux"},
+		})
+		resp := MakeRequest(t, req, http.StatusCreated)
+
+		var runner *api.ActionRunner
+		DecodeJSON(t, resp, &runner)
+		assert.Equal(t, "runner", runner.Name)
+		assert.Equal(t, []string{"linux"}, runner.Labels)
+		assert.NotEmpty(t, runner.Token)
+		runnerToken = runner.Token
+	})
+
+	var job *api.ActionJob
+
+	t.Run("FetchJob", func(t *testing.T) {
+		req := NewRequest(t, "POST", "/api/actions/runners/fetch")
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = addRunnerAuthHeader(NewRequest(t, "POST", "/api/actions/runners/fetch"), "invalid-token")
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = addRunnerAuthHeader(NewRequest(t, "POST", "/api/actions/runners/fetch"), runnerToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		DecodeJSON(t, resp, &job)
+		assert.Equal(t, run.ID, job.RunID)
+		assert.Equal(t, "Test", job.Name)
+		assert.Equal(t, repo.FullName(), job.Repository)
+		assert.Equal(t, commitSHA, job.CommitSHA)
+		assert.Equal(t, commitSHA, job.Env["GITEA_SHA"])
+		assert.Len(t, job.Steps, 1)
+		assert.Equal(t, "make test", job.Steps[0].Run)
+
+		req = addRunnerAuthHeader(NewRequest(t, "POST", "/api/actions/runners/fetch"), runnerToken)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		statuses, err := models.GetLatestCommitStatus(repo, commitSHA, 0)
+		assert.NoError(t, err)
+		assert.Len(t, statuses, 1)
+		assert.Equal(t, models.CommitStatusPending, statuses[0].State)
+	})
+
+	jobURL := fmt.Sprintf("/api/actions/jobs/%d", job.ID)
+	logURL := fmt.Sprintf("/%s/actions/runs/%d/jobs/%d/logs", repo.FullName(), run.Index, job.ID)
+
+	t.Run("AppendLog", func(t *testing.T) {
+		req := NewRequestWithBody(t, "POST", jobURL+"/logs", strings.NewReader("hello\n"))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		for _, line := range []string{"hello\n", "world\n"} {
+			req = addRunnerAuthHeader(NewRequestWithBody(t, "POST", jobURL+"/logs", strings.NewReader(line)), runnerToken)
+			MakeRequest(t, req, http.StatusNoContent)
+		}
+
+		req = NewRequest(t, "GET", logURL)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, "hello\nworld\n", resp.Body.String())
+	})
+
+	t.Run("FinishJob", func(t *testing.T) {
+		req := addRunnerAuthHeader(NewRequestWithJSON(t, "POST", jobURL+"/finish", &api.FinishActionJobOption{Status: "unknown"}), runnerToken)
+		MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		req = addRunnerAuthHeader(NewRequestWithJSON(t, "POST", jobURL+"/finish", &api.FinishActionJobOption{Status: "success"}), runnerToken)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		req = addRunnerAuthHeader(NewRequestWithJSON(t, "POST", jobURL+"/finish", &api.FinishActionJobOption{Status: "success"}), runnerToken)
+		MakeRequest(t, req, http.StatusConflict)
+
+		req = addRunnerAuthHeader(NewRequestWithBody(t, "POST", jobURL+"/logs", strings.NewReader("late\n")), runnerToken)
+		MakeRequest(t, req, http.StatusConflict)
+
+		run = models.AssertExistsAndLoadBean(t, &models.ActionRun{ID: run.ID}).(*models.ActionRun)
+		assert.Equal(t, models.ActionStatusSuccess, run.Status)
+
+		statuses, err := models.GetLatestCommitStatus(repo, commitSHA, 0)
+		assert.NoError(t, err)
+		assert.Len(t, statuses, 1)
+		assert.Equal(t, models.CommitStatusSuccess, statuses[0].State)
+		assert.Equal(t, "CI / Test (push)", statuses[0].Context)
+	})
+
+	t.Run("View", func(t *testing.T) {
+		req := NewRequestf(t, "GET", "/%s/actions", repo.FullName())
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), run.Title)
+
+		req = NewRequestf(t, "GET", "/%s/actions/runs/%d", repo.FullName(), run.Index)
+		resp = MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), "Test")
+
+		req = NewRequestf(t, "GET", "/%s/actions/runs/%d", repo.FullName(), run.Index+1)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+}
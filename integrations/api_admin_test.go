@@ -5,8 +5,13 @@
 package integrations
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"code.gitea.io/gitea/models"
@@ -144,3 +149,158 @@ func TestAPIListUsersNonAdmin(t *testing.T) {
 	req := NewRequestf(t, "GET", "/api/v1/admin/users?token=%s", token)
 	session.MakeRequest(t, req, http.StatusForbidden)
 }
+
+func TestAPIAdminExportImportRepo(t *testing.T) {
+	defer prepareTestEnv(t)()
+	// user1 is an admin user
+	session := loginUser(t, "user1")
+	token := getTokenForLoggedInUser(t, session)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+	req := NewRequestf(t, "GET", "/api/v1/admin/repos/user2/repo1/export?token=%s", token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	archive := resp.Body.Bytes()
+	assert.NotEmpty(t, archive)
+
+	req = NewRequestf(t, "GET", "/api/v1/admin/repos/user2/not-a-repo/export?token=%s", token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+
+	importArchive := func(name string, expectedStatus int) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, err := writer.CreateFormFile("archive", "repo1.zip")
+		assert.NoError(t, err)
+		_, err = part.Write(archive)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+
+		req := NewRequestWithBody(t, "POST", fmt.Sprintf("/api/v1/admin/users/user2/repos/import?name=%s&token=%s", name, token), body)
+		req.Header.Add("Content-Type", writer.FormDataContentType())
+		return session.MakeRequest(t, req, expectedStatus)
+	}
+
+	resp = importArchive("repo1-imported", http.StatusCreated)
+	var apiRepo api.Repository
+	DecodeJSON(t, resp, &apiRepo)
+	assert.EqualValues(t, "repo1-imported", apiRepo.Name)
+
+	imported := models.AssertExistsAndLoadBean(t, &models.Repository{ID: apiRepo.ID}).(*models.Repository)
+	assert.EqualValues(t, repo.NumIssues, imported.NumIssues)
+	assert.EqualValues(t, repo.NumPulls, imported.NumPulls)
+	assert.EqualValues(t, models.RepositoryReady, imported.Status)
+
+	importArchive("repo1-imported", http.StatusConflict)
+
+	// only site admins may export repositories
+	session = loginUser(t, "user2")
+	token = getTokenForLoggedInUser(t, session)
+	req = NewRequestf(t, "GET", "/api/v1/admin/repos/user2/repo1/export?token=%s", token)
+	session.MakeRequest(t, req, http.StatusForbidden)
+}
+
+func TestAPIAdminAuditLog(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	// a failed and a successful sign in of user2
+	req := NewRequest(t, "GET", "/user/login")
+	resp := MakeRequest(t, req, http.StatusOK)
+	doc := NewHTMLParser(t, resp.Body)
+	req = NewRequestWithValues(t, "POST", "/user/login", map[string]string{
+		"_csrf":     doc.GetCSRF(),
+		"user_name": "user2",
+		"password":  "wrong password",
+	})
+	MakeRequest(t, req, http.StatusOK)
+	loginUserWithPassword(t, "user2", userPassword)
+
+	// user1 is an admin user
+	session := loginUserWithPassword(t, "user1", userPassword)
+	token := getTokenForLoggedInUser(t, session)
+
+	req = NewRequestf(t, "GET", "/api/v1/admin/audit?actor=user2&token=%s", token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var events []*api.AuditEvent
+	DecodeJSON(t, resp, &events)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, string(models.AuditActionUserLogin), events[0].Action)
+		assert.Equal(t, "user", events[0].TargetType)
+		assert.Equal(t, "user2", events[0].TargetName)
+	}
+
+	req = NewRequestf(t, "GET", "/api/v1/admin/audit?action=user_login_failed&target=user2&token=%s", token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &events)
+	if assert.Len(t, events, 1) {
+		assert.EqualValues(t, 0, events[0].ActorID)
+	}
+
+	// creating the token has been audited as well
+	req = NewRequestf(t, "GET", "/api/v1/admin/audit?action=access_token_create&actor=user1&token=%s", token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &events)
+	assert.Len(t, events, 1)
+
+	req = NewRequestf(t, "GET", "/api/v1/admin/audit?action=unknown&token=%s", token)
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	req = NewRequest(t, "GET", "/admin/audit?actor=user2")
+	session.MakeRequest(t, req, http.StatusOK)
+
+	req = NewRequest(t, "GET", "/admin/audit/export?target_type=user&target=user2")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	lines := strings.Split(strings.TrimSpace(resp.Body.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		var event api.AuditEvent
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+		assert.Equal(t, string(models.AuditActionUserLoginFailed), event.Action)
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+		assert.Equal(t, string(models.AuditActionUserLogin), event.Action)
+	}
+
+	// the audit log is only accessible for site admins
+	userSession := loginUser(t, "user2")
+	req = NewRequestf(t, "GET", "/api/v1/admin/audit?token=%s", getTokenForLoggedInUser(t, userSession))
+	userSession.MakeRequest(t, req, http.StatusForbidden)
+	req = NewRequest(t, "GET", "/admin/audit/export")
+	userSession.MakeRequest(t, req, http.StatusForbidden)
+}
+
+func TestBasicAuthAuditLog(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	// a failed and a successful API request with the password of user2
+	req := NewRequest(t, "GET", "/api/v1/user")
+	req.RemoteAddr = "192.0.2.10:1234"
+	req.SetBasicAuth("user2", "wrong password")
+	MakeRequest(t, req, http.StatusUnauthorized)
+	event := models.AssertExistsAndLoadBean(t, &models.AuditEvent{Action: models.AuditActionUserLoginFailed, TargetName: "user2"}).(*models.AuditEvent)
+	assert.EqualValues(t, 0, event.ActorID)
+	assert.Equal(t, "192.0.2.10", event.IPAddress)
+
+	req = NewRequest(t, "GET", "/api/v1/user")
+	req.RemoteAddr = "192.0.2.10:1234"
+	req = AddBasicAuthHeader(req, "user2")
+	MakeRequest(t, req, http.StatusOK)
+	event = models.AssertExistsAndLoadBean(t, &models.AuditEvent{Action: models.AuditActionUserLogin, ActorName: "user2"}).(*models.AuditEvent)
+	assert.EqualValues(t, 2, event.TargetID)
+	assert.Equal(t, "192.0.2.10", event.IPAddress)
+
+	// an invalid token is not stored in the audit log
+	req = NewRequest(t, "GET", "/api/v1/user")
+	req.SetBasicAuth("0123456789abcdef0123456789abcdef01234567", "x-oauth-basic")
+	MakeRequest(t, req, http.StatusUnauthorized)
+	events, _, err := models.SearchAuditEvents(&models.SearchAuditEventsOptions{Action: models.AuditActionUserLoginFailed, PageSize: 50})
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	for _, e := range events {
+		assert.NotContains(t, e.TargetName, "0123456789abcdef")
+		assert.NotContains(t, e.Description, "0123456789abcdef")
+	}
+
+	// git over HTTP with the password of user2
+	models.AssertNotExistsBean(t, &models.AuditEvent{Action: models.AuditActionUserLoginFailed, TargetName: "user5"})
+	req = NewRequest(t, "GET", "/user2/repo2.git/info/refs?service=git-upload-pack")
+	req.SetBasicAuth("user5", "wrong password")
+	MakeRequest(t, req, http.StatusUnauthorized)
+	models.AssertExistsAndLoadBean(t, &models.AuditEvent{Action: models.AuditActionUserLoginFailed, TargetName: "user5"})
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIOrgLabels(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	org := models.AssertExistsAndLoadBean(t, &models.User{ID: 3}).(*models.User)
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	urlStr := fmt.Sprintf("/api/v1/orgs/%s/labels?token=%s", org.Name, token)
+
+	req := NewRequest(t, "GET", urlStr)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var apiLabels []*api.Label
+	DecodeJSON(t, resp, &apiLabels)
+	assert.Len(t, apiLabels, models.GetCount(t, &models.Label{OrgID: org.ID}))
+
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.CreateLabelOption{
+		Name:  "triage",
+		Color: "#aa0000",
+	})
+	resp = session.MakeRequest(t, req, http.StatusCreated)
+	apiLabel := new(api.Label)
+	DecodeJSON(t, resp, apiLabel)
+	label := models.AssertExistsAndLoadBean(t, &models.Label{ID: apiLabel.ID, OrgID: org.ID}).(*models.Label)
+	assert.EqualValues(t, 0, label.RepoID)
+
+	newName := "needs-triage"
+	req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("/api/v1/orgs/%s/labels/%d?token=%s", org.Name, label.ID, token), &api.EditLabelOption{
+		Name: &newName,
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertExistsAndLoadBean(t, &models.Label{ID: label.ID, Name: newName})
+
+	// a repo label is not reachable through the organization
+	req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/orgs/%s/labels/1?token=%s", org.Name, token))
+	session.MakeRequest(t, req, http.StatusNotFound)
+
+	// the label can be applied to issues of every repository of the organization
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 3}).(*models.Repository)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID}).(*models.Issue)
+	req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/%s/%s/issues/%d/labels?token=%s", org.Name, repo.Name, issue.Index, token), &api.IssueLabelsOption{
+		Labels: []int64{label.ID},
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertExistsAndLoadBean(t, &models.IssueLabel{IssueID: issue.ID, LabelID: label.ID})
+
+	// but not to issues of repositories outside of it
+	otherIssue := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: 1, Index: 1}).(*models.Issue)
+	req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/issues/%d/labels?token=%s", otherIssue.Index, token), &api.IssueLabelsOption{
+		Labels: []int64{label.ID},
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertNotExistsBean(t, &models.IssueLabel{IssueID: otherIssue.ID, LabelID: label.ID})
+
+	// only owners of the organization may manage its labels
+	session4 := loginUser(t, "user4")
+	token4 := getTokenForLoggedInUser(t, session4)
+	req = NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/orgs/%s/labels/%d?token=%s", org.Name, label.ID, token4))
+	session4.MakeRequest(t, req, http.StatusForbidden)
+
+	req = NewRequest(t, "DELETE", fmt.Sprintf("/api/v1/orgs/%s/labels/%d?token=%s", org.Name, label.ID, token))
+	session.MakeRequest(t, req, http.StatusNoContent)
+	models.AssertNotExistsBean(t, &models.Label{ID: label.ID})
+	models.AssertNotExistsBean(t, &models.IssueLabel{LabelID: label.ID})
+}
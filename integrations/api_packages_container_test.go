@@ -0,0 +1,184 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageContainer(t *testing.T) {
+	defer prepareTestEnv(t)()
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	image := "test/image"
+	tag := "latest"
+	url := fmt.Sprintf("/v2/%s/%s", user.Name, image)
+
+	sha256Digest := func(data []byte) string {
+		sum := sha256.Sum256(data)
+		return "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	blobContent := []byte("layer content")
+	blobDigest := sha256Digest(blobContent)
+	configContent := []byte(`{"architecture":"amd64","os":"linux"}`)
+	configDigest := sha256Digest(configContent)
+	manifestContent := []byte(`{"schemaVersion":2,"mediaType":"` + container_module.ContentTypeDockerManifest + `","config":{"mediaType":"application/vnd.docker.container.image.v1+json","digest":"` + configDigest + `","size":` + fmt.Sprint(len(configContent)) + `},"layers":[{"mediaType":"application/vnd.docker.image.rootfs.diff.tar.gzip","digest":"` + blobDigest + `","size":` + fmt.Sprint(len(blobContent)) + `}]}`)
+	manifestDigest := sha256Digest(manifestContent)
+
+	var userToken, anonymousToken string
+
+	t.Run("Authenticate", func(t *testing.T) {
+		req := NewRequest(t, "GET", "/v2")
+		resp := MakeRequest(t, req, http.StatusUnauthorized)
+		assert.Equal(t, `Bearer realm="`+setting.AppURL+`v2/token",service="container_registry",scope="*"`, resp.Header().Get("WWW-Authenticate"))
+
+		var tokenResponse struct {
+			Token string `json:"token"`
+		}
+
+		req = NewRequest(t, "GET", "/v2/token")
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &tokenResponse)
+		anonymousToken = "Bearer " + tokenResponse.Token
+
+		req = NewRequest(t, "GET", "/v2/token")
+		req = AddBasicAuthHeader(req, user.Name)
+		resp = MakeRequest(t, req, http.StatusOK)
+		DecodeJSON(t, resp, &tokenResponse)
+		userToken = "Bearer " + tokenResponse.Token
+
+		req = NewRequest(t, "GET", "/v2")
+		req.Header.Set("Authorization", userToken)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", "/v2")
+		req.Header.Set("Authorization", "Bearer invalid")
+		MakeRequest(t, req, http.StatusUnauthorized)
+	})
+
+	t.Run("UploadBlob", func(t *testing.T) {
+		req := NewRequestWithBody(t, "POST", fmt.Sprintf("%s/blobs/uploads?digest=%s", url, blobDigest), bytes.NewReader(blobContent))
+		req.Header.Set("Authorization", anonymousToken)
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "POST", fmt.Sprintf("%s/blobs/uploads?digest=%s", url, blobDigest), bytes.NewReader(blobContent))
+		req.Header.Set("Authorization", userToken)
+		resp := MakeRequest(t, req, http.StatusCreated)
+		assert.Equal(t, blobDigest, resp.Header().Get("Docker-Content-Digest"))
+		assert.Equal(t, fmt.Sprintf("%s/blobs/%s", setting.AppSubURL+url, blobDigest), resp.Header().Get("Location"))
+	})
+
+	t.Run("UploadBlobChunked", func(t *testing.T) {
+		req := NewRequest(t, "POST", url+"/blobs/uploads")
+		req.Header.Set("Authorization", userToken)
+		resp := MakeRequest(t, req, http.StatusAccepted)
+		uploadURL := resp.Header().Get("Location")
+		assert.NotEmpty(t, uploadURL)
+		uploadURL = strings.TrimPrefix(uploadURL, setting.AppSubURL)
+
+		req = NewRequestWithBody(t, "PATCH", uploadURL, bytes.NewReader(configContent[:10]))
+		req.Header.Set("Authorization", userToken)
+		req.Header.Set("Content-Range", "0-9")
+		resp = MakeRequest(t, req, http.StatusAccepted)
+		assert.Equal(t, "0-9", resp.Header().Get("Range"))
+
+		req = NewRequestWithBody(t, "PATCH", uploadURL, bytes.NewReader(configContent[10:]))
+		req.Header.Set("Authorization", userToken)
+		req.Header.Set("Content-Range", "5-9")
+		MakeRequest(t, req, http.StatusRequestedRangeNotSatisfiable)
+
+		req = NewRequestWithBody(t, "PATCH", uploadURL, bytes.NewReader(configContent[10:]))
+		req.Header.Set("Authorization", userToken)
+		MakeRequest(t, req, http.StatusAccepted)
+
+		req = NewRequestWithBody(t, "PUT", fmt.Sprintf("%s?digest=%s", uploadURL, configDigest), bytes.NewReader(nil))
+		req.Header.Set("Authorization", userToken)
+		resp = MakeRequest(t, req, http.StatusCreated)
+		assert.Equal(t, configDigest, resp.Header().Get("Docker-Content-Digest"))
+	})
+
+	t.Run("HeadBlob", func(t *testing.T) {
+		req := NewRequest(t, "HEAD", fmt.Sprintf("%s/blobs/%s", url, blobDigest))
+		req.Header.Set("Authorization", anonymousToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, fmt.Sprint(len(blobContent)), resp.Header().Get("Content-Length"))
+
+		req = NewRequest(t, "HEAD", fmt.Sprintf("%s/blobs/%s", url, sha256Digest([]byte("missing"))))
+		req.Header.Set("Authorization", anonymousToken)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("UploadManifest", func(t *testing.T) {
+		req := NewRequestWithBody(t, "PUT", fmt.Sprintf("%s/manifests/%s", url, tag), bytes.NewReader(manifestContent))
+		req.Header.Set("Authorization", userToken)
+		req.Header.Set("Content-Type", container_module.ContentTypeDockerManifest)
+		resp := MakeRequest(t, req, http.StatusCreated)
+		assert.Equal(t, manifestDigest, resp.Header().Get("Docker-Content-Digest"))
+
+		p, err := models.GetPackageByName(user.ID, models.PackageTypeContainer, image)
+		assert.NoError(t, err)
+		pv, err := models.GetPackageVersionByName(p.ID, tag)
+		assert.NoError(t, err)
+		assert.False(t, pv.IsInternal)
+	})
+
+	t.Run("GetManifest", func(t *testing.T) {
+		for _, reference := range []string{tag, manifestDigest} {
+			req := NewRequest(t, "GET", fmt.Sprintf("%s/manifests/%s", url, reference))
+			req.Header.Set("Authorization", anonymousToken)
+			resp := MakeRequest(t, req, http.StatusOK)
+			assert.Equal(t, manifestContent, resp.Body.Bytes())
+			assert.Equal(t, container_module.ContentTypeDockerManifest, resp.Header().Get("Content-Type"))
+			assert.Equal(t, manifestDigest, resp.Header().Get("Docker-Content-Digest"))
+		}
+	})
+
+	t.Run("GetTagsList", func(t *testing.T) {
+		req := NewRequest(t, "GET", url+"/tags/list")
+		req.Header.Set("Authorization", anonymousToken)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var tagsList struct {
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+		}
+		DecodeJSON(t, resp, &tagsList)
+		assert.Equal(t, user.LowerName+"/"+image, tagsList.Name)
+		assert.Equal(t, []string{tag}, tagsList.Tags)
+	})
+
+	t.Run("DeleteManifest", func(t *testing.T) {
+		req := NewRequest(t, "DELETE", fmt.Sprintf("%s/manifests/%s", url, manifestDigest))
+		req.Header.Set("Authorization", userToken)
+		MakeRequest(t, req, http.StatusAccepted)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("%s/manifests/%s", url, tag))
+		req.Header.Set("Authorization", anonymousToken)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("DeleteBlob", func(t *testing.T) {
+		req := NewRequest(t, "DELETE", fmt.Sprintf("%s/blobs/%s", url, blobDigest))
+		req.Header.Set("Authorization", userToken)
+		MakeRequest(t, req, http.StatusAccepted)
+
+		req = NewRequest(t, "HEAD", fmt.Sprintf("%s/blobs/%s", url, blobDigest))
+		req.Header.Set("Authorization", userToken)
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+}
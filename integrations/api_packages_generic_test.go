@@ -0,0 +1,148 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageGeneric(t *testing.T) {
+	defer prepareTestEnv(t)()
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	packageName := "te-st_pac.kage"
+	packageVersion := "1.0.3"
+	filename := "fi-le_na.me"
+	content := []byte{1, 2, 3}
+
+	url := fmt.Sprintf("/api/packages/%s/generic/%s/%s/%s", user.Name, packageName, packageVersion, filename)
+
+	t.Run("Upload", func(t *testing.T) {
+		req := NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		req = AddBasicAuthHeader(req, "user4")
+		MakeRequest(t, req, http.StatusForbidden)
+
+		req = NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		req = NewRequestWithBody(t, "PUT", url, bytes.NewReader(content))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusConflict)
+
+		p, err := models.GetPackageByName(user.ID, models.PackageTypeGeneric, packageName)
+		assert.NoError(t, err)
+		pv, err := models.GetPackageVersionByName(p.ID, packageVersion)
+		assert.NoError(t, err)
+		pfs, err := models.GetPackageFilesByVersionID(pv.ID)
+		assert.NoError(t, err)
+		assert.Len(t, pfs, 1)
+		assert.Equal(t, filename, pfs[0].Name)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		req := NewRequest(t, "GET", url)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, content, resp.Body.Bytes())
+
+		req = NewRequest(t, "GET", url+"_missing")
+		MakeRequest(t, req, http.StatusNotFound)
+	})
+
+	t.Run("API", func(t *testing.T) {
+		session := loginUser(t, user.Name)
+		token := getTokenForLoggedInUser(t, session)
+
+		req := NewRequest(t, "GET", fmt.Sprintf("/api/v1/packages/%s?type=generic", user.Name))
+		resp := MakeRequest(t, req, http.StatusOK)
+		var apiPackages []*api.Package
+		DecodeJSON(t, resp, &apiPackages)
+		assert.Len(t, apiPackages, 1)
+		assert.Equal(t, packageName, apiPackages[0].Name)
+		assert.Equal(t, packageVersion, apiPackages[0].Version)
+		assert.Equal(t, "generic", apiPackages[0].Type)
+		assert.EqualValues(t, 1, apiPackages[0].Downloads)
+
+		req = NewRequest(t, "GET", fmt.Sprintf("/api/v1/packages/%s?type=invalid", user.Name))
+		MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		apiURL := fmt.Sprintf("/api/v1/packages/%s/generic/%s/%s", user.Name, packageName, packageVersion)
+		req = NewRequest(t, "GET", apiURL+"/files")
+		resp = MakeRequest(t, req, http.StatusOK)
+		var apiFiles []*api.PackageFile
+		DecodeJSON(t, resp, &apiFiles)
+		assert.Len(t, apiFiles, 1)
+		assert.Equal(t, filename, apiFiles[0].Name)
+		assert.EqualValues(t, len(content), apiFiles[0].Size)
+
+		linkURL := fmt.Sprintf("/api/v1/packages/%s/generic/%s/-/link/repo1?token=%s", user.Name, packageName, token)
+		req = NewRequest(t, "POST", linkURL)
+		MakeRequest(t, req, http.StatusCreated)
+		p, err := models.GetPackageByName(user.ID, models.PackageTypeGeneric, packageName)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, p.RepoID)
+
+		req = NewRequest(t, "POST", fmt.Sprintf("/api/v1/packages/%s/generic/%s/-/unlink?token=%s", user.Name, packageName, token))
+		MakeRequest(t, req, http.StatusNoContent)
+		p, err = models.GetPackageByName(user.ID, models.PackageTypeGeneric, packageName)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, p.RepoID)
+
+		req = NewRequest(t, "DELETE", apiURL)
+		MakeRequest(t, req, http.StatusUnauthorized)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		req := NewRequest(t, "DELETE", url)
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusNoContent)
+
+		req = NewRequest(t, "GET", url)
+		MakeRequest(t, req, http.StatusNotFound)
+
+		_, err := models.GetPackageByName(user.ID, models.PackageTypeGeneric, packageName)
+		assert.True(t, models.IsErrPackageNotExist(err))
+	})
+}
+
+func TestPackageSiteAdminTokenScope(t *testing.T) {
+	defer prepareTestEnv(t)()
+	admin := models.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	createToken := func(name string, scopes ...string) string {
+		req := NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/users/%s/tokens", admin.Name), map[string]interface{}{
+			"name":   name,
+			"scopes": scopes,
+		})
+		req = AddBasicAuthHeader(req, admin.Name)
+		resp := MakeRequest(t, req, http.StatusCreated)
+		var token api.AccessToken
+		DecodeJSON(t, resp, &token)
+		return token.Token
+	}
+
+	url := fmt.Sprintf("/api/packages/%s/generic/admin-package/1.0.0/file.bin", user.Name)
+
+	// without the admin scope the site admin has no rights on the packages of other users
+	req := NewRequestWithBody(t, "PUT", url, bytes.NewReader([]byte{1}))
+	req.SetBasicAuth(createToken("package-only", "package"), "x-oauth-basic")
+	MakeRequest(t, req, http.StatusForbidden)
+
+	req = NewRequestWithBody(t, "PUT", url, bytes.NewReader([]byte{1}))
+	req.SetBasicAuth(createToken("package-admin", "package", "admin"), "x-oauth-basic")
+	MakeRequest(t, req, http.StatusCreated)
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageMaven(t *testing.T) {
+	defer prepareTestEnv(t)()
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	groupID := "com.gitea"
+	artifactID := "test-project"
+	packageName := groupID + ":" + artifactID
+	packageVersion := "1.0.1"
+
+	root := fmt.Sprintf("/api/packages/%s/maven/%s/%s", user.Name, strings.Replace(groupID, ".", "/", -1), artifactID)
+
+	putFile := func(t *testing.T, path, content string, expectedStatus int) {
+		req := NewRequestWithBody(t, "PUT", root+path, strings.NewReader(content))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, expectedStatus)
+	}
+
+	t.Run("Upload", func(t *testing.T) {
+		putFile(t, fmt.Sprintf("/%s/%s", packageVersion, "any-file.jar"), "test", http.StatusCreated)
+		putFile(t, "/maven-metadata.xml", "test", http.StatusOK)
+
+		p, err := models.GetPackageByName(user.ID, models.PackageTypeMaven, packageName)
+		assert.NoError(t, err)
+		pv, err := models.GetPackageVersionByName(p.ID, packageVersion)
+		assert.NoError(t, err)
+		pfs, err := models.GetPackageFilesByVersionID(pv.ID)
+		assert.NoError(t, err)
+		assert.Len(t, pfs, 1)
+		assert.Equal(t, "any-file.jar", pfs[0].Name)
+		assert.False(t, pfs[0].IsLead)
+	})
+
+	t.Run("UploadExists", func(t *testing.T) {
+		putFile(t, fmt.Sprintf("/%s/%s", packageVersion, "any-file.jar"), "test", http.StatusConflict)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s", root, packageVersion, "any-file.jar"))
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, []byte("test"), resp.Body.Bytes())
+	})
+
+	t.Run("Checksum", func(t *testing.T) {
+		sum := md5.Sum([]byte("test"))
+		checksum := hex.EncodeToString(sum[:])
+
+		putFile(t, fmt.Sprintf("/%s/%s.md5", packageVersion, "any-file.jar"), checksum, http.StatusOK)
+		putFile(t, fmt.Sprintf("/%s/%s.md5", packageVersion, "any-file.jar"), "invalid", http.StatusBadRequest)
+
+		req := NewRequest(t, "GET", fmt.Sprintf("%s/%s/%s.md5", root, packageVersion, "any-file.jar"))
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, checksum, resp.Body.String())
+	})
+
+	pomContent := `<?xml version="1.0"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <groupId>` + groupID + `</groupId>
+  <artifactId>` + artifactID + `</artifactId>
+  <version>` + packageVersion + `</version>
+  <name>Test Project</name>
+  <description>Gitea test project</description>
+</project>`
+
+	t.Run("UploadPOM", func(t *testing.T) {
+		putFile(t, fmt.Sprintf("/%s/%s.pom", packageVersion, artifactID), pomContent, http.StatusCreated)
+
+		p, err := models.GetPackageByName(user.ID, models.PackageTypeMaven, packageName)
+		assert.NoError(t, err)
+		pv, err := models.GetPackageVersionByName(p.ID, packageVersion)
+		assert.NoError(t, err)
+		assert.Contains(t, pv.MetadataJSON, "Gitea test project")
+
+		pf, err := models.GetPackageFileByName(pv.ID, artifactID+".pom")
+		assert.NoError(t, err)
+		assert.True(t, pf.IsLead)
+	})
+
+	t.Run("DownloadMetadata", func(t *testing.T) {
+		req := NewRequest(t, "GET", root+"/maven-metadata.xml")
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		body := resp.Body.String()
+		assert.Contains(t, body, "<groupId>"+groupID+"</groupId>")
+		assert.Contains(t, body, "<artifactId>"+artifactID+"</artifactId>")
+		assert.Contains(t, body, "<version>"+packageVersion+"</version>")
+
+		req = NewRequest(t, "GET", root+"/maven-metadata.xml.md5")
+		resp = MakeRequest(t, req, http.StatusOK)
+		sum := md5.Sum([]byte(body))
+		assert.Equal(t, hex.EncodeToString(sum[:]), resp.Body.String())
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/packages/npm"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageNpm(t *testing.T) {
+	defer prepareTestEnv(t)()
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	packageName := "@scope/test-package"
+	packageVersion := "1.0.1-pre"
+	packageDescription := "Test Description"
+	filename := "test-package-1.0.1-pre.tgz"
+	data := []byte("H4sIAAAAAAAA/ytITM5OTE/VL4DQelnF+XkMVAYGBgZmJiYK2MRBwNDcSIHB2NTMwNDQzMwAqA7IBALGpmbGoOQgAAyNtcYIAQAA")
+	sum := sha1.Sum(data)
+	shasum := hex.EncodeToString(sum[:])
+
+	upload := `{
+		"_id": "` + packageName + `",
+		"name": "` + packageName + `",
+		"description": "` + packageDescription + `",
+		"dist-tags": {"latest": "` + packageVersion + `"},
+		"versions": {
+			"` + packageVersion + `": {
+				"name": "` + packageName + `",
+				"version": "` + packageVersion + `",
+				"description": "` + packageDescription + `",
+				"author": {"name": "Package Author"},
+				"dist": {"shasum": "` + shasum + `"}
+			}
+		},
+		"_attachments": {
+			"` + filename + `": {
+				"content_type": "application/octet-stream",
+				"data": "` + base64.StdEncoding.EncodeToString(data) + `",
+				"length": ` + fmt.Sprint(len(data)) + `
+			}
+		}
+	}`
+
+	root := fmt.Sprintf("/api/packages/%s/npm/%s", user.Name, strings.Replace(packageName, "/", "%2f", -1))
+	tagsRoot := fmt.Sprintf("/api/packages/%s/npm/-/package/%s/dist-tags", user.Name, packageName)
+	filenameURL := fmt.Sprintf("%s/-/%s/%s", root, packageVersion, filename)
+
+	t.Run("Upload", func(t *testing.T) {
+		req := NewRequestWithBody(t, "PUT", root, strings.NewReader(upload))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusCreated)
+
+		p, err := models.GetPackageByName(user.ID, models.PackageTypeNpm, packageName)
+		assert.NoError(t, err)
+		pv, err := models.GetPackageVersionByName(p.ID, packageVersion)
+		assert.NoError(t, err)
+		pfs, err := models.GetPackageFilesByVersionID(pv.ID)
+		assert.NoError(t, err)
+		assert.Len(t, pfs, 1)
+		assert.Equal(t, filename, pfs[0].Name)
+		assert.True(t, pfs[0].IsLead)
+	})
+
+	t.Run("UploadExists", func(t *testing.T) {
+		req := NewRequestWithBody(t, "PUT", root, strings.NewReader(upload))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusBadRequest)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		req := NewRequest(t, "GET", filenameURL)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.Equal(t, data, resp.Body.Bytes())
+	})
+
+	t.Run("PackageMetadata", func(t *testing.T) {
+		req := NewRequest(t, "GET", fmt.Sprintf("/api/packages/%s/npm/%s", user.Name, packageName))
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result npm.PackageMetadata
+		DecodeJSON(t, resp, &result)
+
+		assert.Equal(t, packageName, result.ID)
+		assert.Equal(t, packageName, result.Name)
+		assert.Equal(t, packageDescription, result.Description)
+		assert.Contains(t, result.DistTags, "latest")
+		assert.Equal(t, packageVersion, result.DistTags["latest"])
+		assert.Len(t, result.Versions, 1)
+		pmv := result.Versions[packageVersion]
+		assert.Equal(t, packageName, pmv.Name)
+		assert.Equal(t, shasum, pmv.Dist.Shasum)
+		assert.Equal(t, fmt.Sprintf("%sapi/packages/%s/npm/%s/-/%s/%s", setting.AppURL, user.Name, packageName, packageVersion, filename), pmv.Dist.Tarball)
+	})
+
+	t.Run("AddTag", func(t *testing.T) {
+		test := func(t *testing.T, status int, tag, version string) {
+			req := NewRequestWithBody(t, "PUT", tagsRoot+"/"+tag, strings.NewReader(`"`+version+`"`))
+			req = AddBasicAuthHeader(req, user.Name)
+			MakeRequest(t, req, status)
+		}
+
+		test(t, http.StatusBadRequest, "1.0", packageVersion)
+		test(t, http.StatusBadRequest, "v1.0", packageVersion)
+		test(t, http.StatusNotFound, "tag", "1.2")
+		test(t, http.StatusOK, "tag", packageVersion)
+	})
+
+	t.Run("ListTags", func(t *testing.T) {
+		req := NewRequest(t, "GET", tagsRoot)
+		resp := MakeRequest(t, req, http.StatusOK)
+
+		var result map[string]string
+		DecodeJSON(t, resp, &result)
+
+		assert.Len(t, result, 2)
+		assert.Equal(t, packageVersion, result["latest"])
+		assert.Equal(t, packageVersion, result["tag"])
+	})
+
+	t.Run("DeleteTag", func(t *testing.T) {
+		req := NewRequest(t, "DELETE", tagsRoot+"/tag")
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		req = NewRequest(t, "GET", tagsRoot)
+		resp := MakeRequest(t, req, http.StatusOK)
+		var result map[string]string
+		DecodeJSON(t, resp, &result)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		req := NewRequest(t, "DELETE", fmt.Sprintf("%s/-rev/1", root))
+		MakeRequest(t, req, http.StatusUnauthorized)
+
+		req = NewRequest(t, "DELETE", fmt.Sprintf("%s/-rev/1", root))
+		req = AddBasicAuthHeader(req, user.Name)
+		MakeRequest(t, req, http.StatusOK)
+
+		_, err := models.GetPackageByName(user.ID, models.PackageTypeNpm, packageName)
+		assert.True(t, models.IsErrPackageNotExist(err))
+	})
+}
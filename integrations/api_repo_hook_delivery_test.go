@@ -0,0 +1,58 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIRepoHookDeliveries(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	req := NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/hooks/1/deliveries?token=%s", token)
+	resp := MakeRequest(t, req, http.StatusOK)
+	var deliveries []*api.HookDelivery
+	DecodeJSON(t, resp, &deliveries)
+	if assert.Len(t, deliveries, 1) {
+		assert.EqualValues(t, 1, deliveries[0].ID)
+		assert.Equal(t, "uuid1", deliveries[0].UUID)
+		assert.True(t, deliveries[0].Delivered)
+	}
+
+	req = NewRequestf(t, "POST", "/api/v1/repos/user2/repo1/hooks/1/deliveries/1/redeliver?token=%s", token)
+	resp = MakeRequest(t, req, http.StatusAccepted)
+	var delivery api.HookDelivery
+	DecodeJSON(t, resp, &delivery)
+	assert.NotEqual(t, "uuid1", delivery.UUID)
+	models.AssertExistsAndLoadBean(t, &models.HookTask{ID: delivery.ID, HookID: 1})
+
+	// deliveries of other hooks cannot be redelivered
+	req = NewRequestf(t, "POST", "/api/v1/repos/user2/repo1/hooks/2/deliveries/1/redeliver?token=%s", token)
+	MakeRequest(t, req, http.StatusNotFound)
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/hooks/1/deliveries/replay?token="+token, &api.ReplayHookDeliveriesOption{
+		Since:  time.Unix(0, 0),
+		Before: time.Now(),
+	})
+	resp = MakeRequest(t, req, http.StatusAccepted)
+	DecodeJSON(t, resp, &deliveries)
+	assert.Len(t, deliveries, 1)
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/hooks/1/deliveries/replay?token="+token, &api.ReplayHookDeliveriesOption{
+		Since:  time.Now(),
+		Before: time.Unix(0, 0),
+	})
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
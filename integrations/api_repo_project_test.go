@@ -0,0 +1,203 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIRepoProjects(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	baseURL := "/api/v1/repos/user2/repo1/projects"
+
+	req := NewRequestf(t, "GET", "%s?token=%s", baseURL, token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var projects []*api.Project
+	DecodeJSON(t, resp, &projects)
+	if assert.Len(t, projects, 1) {
+		assert.EqualValues(t, 1, projects[0].ID)
+		assert.EqualValues(t, 2, projects[0].OpenIssues)
+		assert.EqualValues(t, 1, projects[0].ClosedIssues)
+	}
+
+	req = NewRequestWithJSON(t, "POST", baseURL+"?token="+token, &api.CreateProjectOption{
+		Title:         "Kanban",
+		BoardTemplate: "unknown",
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	req = NewRequestWithJSON(t, "POST", baseURL+"?token="+token, &api.CreateProjectOption{
+		Title:         "Kanban",
+		BoardTemplate: string(models.ProjectBoardTemplateBasicKanban),
+	})
+	resp = session.MakeRequest(t, req, http.StatusCreated)
+	var project *api.Project
+	DecodeJSON(t, resp, &project)
+	assert.Equal(t, "Kanban", project.Title)
+	assert.Equal(t, api.StateOpen, project.State)
+
+	req = NewRequestf(t, "GET", "%s/%d/boards?token=%s", baseURL, project.ID, token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var boards []*api.ProjectBoard
+	DecodeJSON(t, resp, &boards)
+	assert.Len(t, boards, 3)
+
+	closed := string(api.StateClosed)
+	req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("%s/%d?token=%s", baseURL, project.ID, token), &api.EditProjectOption{
+		State: &closed,
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &project)
+	assert.Equal(t, api.StateClosed, project.State)
+	assert.NotNil(t, project.Closed)
+
+	req = NewRequestf(t, "DELETE", "%s/%d?token=%s", baseURL, project.ID, token)
+	session.MakeRequest(t, req, http.StatusNoContent)
+	models.AssertNotExistsBean(t, &models.Project{ID: project.ID})
+
+	// Projects of other repositories are not found
+	req = NewRequestf(t, "GET", "/api/v1/repos/user3/repo3/projects/1?token=%s", token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
+
+func TestAPIRepoProjectIssues(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	projectURL := "/api/v1/repos/user2/repo1/projects/1"
+
+	position := 0
+	req := NewRequestWithJSON(t, "POST", projectURL+"/issues?token="+token, &api.MoveProjectIssueOption{
+		IssueID:  3,
+		BoardID:  1,
+		Position: &position,
+	})
+	session.MakeRequest(t, req, http.StatusNoContent)
+
+	req = NewRequestf(t, "GET", "%s/boards/1/issues?token=%s", projectURL, token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var issues []*api.Issue
+	DecodeJSON(t, resp, &issues)
+	if assert.Len(t, issues, 2) {
+		assert.EqualValues(t, 3, issues[0].ID)
+		assert.EqualValues(t, 1, issues[1].ID)
+	}
+
+	// Issues of other repositories can not be added
+	req = NewRequestWithJSON(t, "POST", projectURL+"/issues?token="+token, &api.MoveProjectIssueOption{
+		IssueID: 4,
+		BoardID: 1,
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	// Closing an issue moves it to the done board
+	closed := string(api.StateClosed)
+	req = NewRequestWithJSON(t, "PATCH", "/api/v1/repos/user2/repo1/issues/1?token="+token, &api.EditIssueOption{
+		State: &closed,
+	})
+	session.MakeRequest(t, req, http.StatusCreated)
+	models.AssertExistsAndLoadBean(t, &models.ProjectIssue{IssueID: 1, ProjectID: 1, ProjectBoardID: 3})
+
+	req = NewRequestf(t, "DELETE", "%s/issues/1?token=%s", projectURL, token)
+	session.MakeRequest(t, req, http.StatusNoContent)
+	req = NewRequestf(t, "DELETE", "%s/issues/1?token=%s", projectURL, token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+
+	// Only writers can change the project
+	session = loginUser(t, "user4")
+	token = getTokenForLoggedInUser(t, session)
+	req = NewRequestWithJSON(t, "POST", projectURL+"/boards?token="+token, &api.CreateProjectBoardOption{
+		Title: "Review",
+	})
+	session.MakeRequest(t, req, http.StatusForbidden)
+}
+
+func TestAPIOrgProjects(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	req := NewRequestf(t, "GET", "/api/v1/orgs/user3/projects?token=%s", token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var projects []*api.Project
+	DecodeJSON(t, resp, &projects)
+	if assert.Len(t, projects, 1) {
+		assert.EqualValues(t, 3, projects[0].ID)
+	}
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/orgs/user3/projects/3/boards?token="+token, &api.CreateProjectBoardOption{
+		Title:  "Done",
+		IsDone: true,
+	})
+	resp = session.MakeRequest(t, req, http.StatusCreated)
+	var board *api.ProjectBoard
+	DecodeJSON(t, resp, &board)
+	assert.True(t, board.IsDone)
+
+	// Issues of all repositories of the organization can be added
+	req = NewRequestWithJSON(t, "POST", "/api/v1/orgs/user3/projects/3/issues?token="+token, &api.MoveProjectIssueOption{
+		IssueID: 6,
+		BoardID: board.ID,
+	})
+	session.MakeRequest(t, req, http.StatusNoContent)
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/orgs/user3/projects/3/issues?token="+token, &api.MoveProjectIssueOption{
+		IssueID: 1,
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	// Repository projects are not part of the organization
+	req = NewRequestf(t, "GET", "/api/v1/orgs/user3/projects/1?token=%s", token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+
+	// Only members can change the projects of the organization
+	session = loginUser(t, "user5")
+	token = getTokenForLoggedInUser(t, session)
+	req = NewRequestWithJSON(t, "POST", "/api/v1/orgs/user3/projects?token="+token, &api.CreateProjectOption{
+		Title: "Roadmap",
+	})
+	session.MakeRequest(t, req, http.StatusForbidden)
+}
+
+func TestRepoProjectsView(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+
+	req := NewRequest(t, "GET", "/user2/repo1/projects")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "First project")
+
+	req = NewRequest(t, "GET", "/user2/repo1/projects/1")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "In Progress")
+
+	req = NewRequest(t, "GET", "/user2/repo1/projects/new")
+	session.MakeRequest(t, req, http.StatusOK)
+
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/projects/1/issues/move", map[string]string{
+		"_csrf":    GetCSRF(t, session, "/user2/repo1/projects/1"),
+		"issue_id": "2",
+		"board_id": "3",
+		"position": "0",
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertExistsAndLoadBean(t, &models.ProjectIssue{IssueID: 2, ProjectID: 1, ProjectBoardID: 3})
+
+	req = NewRequest(t, "GET", "/user2/repo1/projects/100")
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
@@ -10,6 +10,9 @@ import (
 
 	"code.gitea.io/gitea/models"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
 )
 
 // TestAPICreateAndDeleteToken tests that token that was just created can be deleted
@@ -48,3 +51,96 @@ func TestAPIDeleteMissingToken(t *testing.T) {
 	req = AddBasicAuthHeader(req, user.Name)
 	MakeRequest(t, req, http.StatusNotFound)
 }
+
+// TestAPITokenScopes ensures that the scopes and the expiry of a token are enforced
+func TestAPITokenScopes(t *testing.T) {
+	defer prepareTestEnv(t)()
+	admin := models.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+
+	req := NewRequestWithJSON(t, "POST", "/api/v1/users/user1/tokens", map[string]interface{}{
+		"name":   "test-key-scopes",
+		"scopes": []string{"sudo"},
+	})
+	req = AddBasicAuthHeader(req, admin.Name)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/users/user1/tokens", map[string]interface{}{
+		"name":   "test-key-scopes",
+		"scopes": []string{"repo:read"},
+	})
+	req = AddBasicAuthHeader(req, admin.Name)
+	resp := MakeRequest(t, req, http.StatusCreated)
+	var readToken api.AccessToken
+	DecodeJSON(t, resp, &readToken)
+	assert.Equal(t, []string{"repo:read"}, readToken.Scopes)
+	assert.Nil(t, readToken.ExpiresAt)
+
+	// every token may read the authenticated user
+	req = NewRequestf(t, "GET", "/api/v1/user?token=%s", readToken.Token)
+	MakeRequest(t, req, http.StatusOK)
+
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1?token=%s", readToken.Token)
+	MakeRequest(t, req, http.StatusOK)
+	req = NewRequestWithJSON(t, "PATCH", "/api/v1/repos/user2/repo1?token="+readToken.Token, &api.EditRepoOption{})
+	MakeRequest(t, req, http.StatusForbidden)
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/issues?token=%s", readToken.Token)
+	MakeRequest(t, req, http.StatusForbidden)
+
+	// the site admin rights require the admin scope
+	req = NewRequestf(t, "GET", "/api/v1/admin/orgs?token=%s", readToken.Token)
+	MakeRequest(t, req, http.StatusForbidden)
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo2?token=%s", readToken.Token)
+	MakeRequest(t, req, http.StatusNotFound)
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo2?token=%s", getTokenForLoggedInUser(t, loginUser(t, admin.Name)))
+	MakeRequest(t, req, http.StatusOK)
+
+	req = NewRequestf(t, "GET", "/api/v1/users/user1/tokens")
+	req = AddBasicAuthHeader(req, admin.Name)
+	resp = MakeRequest(t, req, http.StatusOK)
+	var tokens []*api.AccessToken
+	DecodeJSON(t, resp, &tokens)
+	for _, token := range tokens {
+		if token.ID == readToken.ID {
+			assert.NotNil(t, token.LastUsedAt)
+		}
+	}
+
+	// an expired token can not be used anymore
+	token := models.AssertExistsAndLoadBean(t, &models.AccessToken{ID: readToken.ID}).(*models.AccessToken)
+	token.ExpiresUnix = timeutil.TimeStampNow().Add(-60)
+	assert.NoError(t, models.UpdateAccessToken(token))
+	req = NewRequestf(t, "GET", "/api/v1/user?token=%s", readToken.Token)
+	MakeRequest(t, req, http.StatusUnauthorized)
+}
+
+// TestAPITokenCreateWithToken ensures that a token can not create a token with more scopes than itself
+func TestAPITokenCreateWithToken(t *testing.T) {
+	defer prepareTestEnv(t)()
+	admin := models.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+
+	req := NewRequestWithJSON(t, "POST", "/api/v1/users/user1/tokens", map[string]interface{}{
+		"name":   "test-key-user",
+		"scopes": []string{"user"},
+	})
+	req = AddBasicAuthHeader(req, admin.Name)
+	resp := MakeRequest(t, req, http.StatusCreated)
+	var userToken api.AccessToken
+	DecodeJSON(t, resp, &userToken)
+
+	for _, scopes := range [][]string{{"all"}, {}, {"admin"}, {"user", "repo:read"}} {
+		req = NewRequestWithJSON(t, "POST", "/api/v1/users/user1/tokens", map[string]interface{}{
+			"name":   "test-key-escalated",
+			"scopes": scopes,
+		})
+		req.SetBasicAuth(userToken.Token, "x-oauth-basic")
+		MakeRequest(t, req, http.StatusForbidden)
+	}
+	models.AssertNotExistsBean(t, &models.AccessToken{UID: admin.ID, Name: "test-key-escalated"})
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/users/user1/tokens", map[string]interface{}{
+		"name":   "test-key-user-2",
+		"scopes": []string{"user"},
+	})
+	req.SetBasicAuth(userToken.Token, "x-oauth-basic")
+	MakeRequest(t, req, http.StatusCreated)
+}
@@ -0,0 +1,167 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/repofiles"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	bugReportTemplate = `---
+name: Bug Report
+about: Something does not work
+title: "[Bug] "
+labels: label1
+assignees: [user2]
+---
+**Steps to reproduce**
+`
+	featureRequestForm = `name: Feature Request
+about: Suggest an idea
+labels: [label2]
+body:
+- type: markdown
+  attributes:
+    value: Thanks for taking the time!
+- type: textarea
+  id: description
+  attributes:
+    label: Description
+  validations:
+    required: true
+- type: dropdown
+  id: priority
+  attributes:
+    label: Priority
+    options: [Low, High]
+- type: checkboxes
+  id: terms
+  attributes:
+    label: Terms
+    options:
+    - label: I searched for duplicates
+      required: true
+`
+)
+
+func addRepoFile(t *testing.T, user *models.User, repo *models.Repository, treePath, content string) {
+	_, err := repofiles.CreateOrUpdateRepoFile(repo, user, &repofiles.UpdateRepoFileOptions{
+		OldBranch: repo.DefaultBranch,
+		TreePath:  treePath,
+		Content:   content,
+		IsNewFile: true,
+	})
+	assert.NoError(t, err)
+}
+
+func TestIssueTemplates(t *testing.T) {
+	onGiteaRun(t, testIssueTemplates)
+}
+
+func testIssueTemplates(t *testing.T, u *url.URL) {
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	addRepoFile(t, user, repo, ".gitea/ISSUE_TEMPLATE/bug.md", bugReportTemplate)
+	addRepoFile(t, user, repo, ".gitea/ISSUE_TEMPLATE/feature.yaml", featureRequestForm)
+	addRepoFile(t, user, repo, ".gitea/ISSUE_TEMPLATE/invalid.md", "no front matter")
+
+	session := loginUser(t, user.Name)
+
+	t.Run("Chooser", func(t *testing.T) {
+		req := NewRequest(t, "GET", "/user2/repo1/issues/new")
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		items := htmlDoc.doc.Find(".issue-templates .item")
+		assert.EqualValues(t, 2, items.Length())
+		assert.Equal(t, "Bug Report", strings.TrimSpace(items.First().Find(".header").Text()))
+		link, _ := items.First().Find("a").Attr("href")
+		assert.Equal(t, "/user2/repo1/issues/new?template=bug.md", link)
+
+		req = NewRequest(t, "GET", "/user2/repo1/issues/new?blank=true")
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc = NewHTMLParser(t, resp.Body)
+		assert.EqualValues(t, 1, htmlDoc.doc.Find("#content").Length())
+		assert.EqualValues(t, 0, htmlDoc.doc.Find("input[name=template_file]").Length())
+	})
+
+	t.Run("Markdown", func(t *testing.T) {
+		req := NewRequest(t, "GET", "/user2/repo1/issues/new?template=bug.md")
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		assert.Equal(t, "**Steps to reproduce**", strings.TrimSpace(htmlDoc.doc.Find("#content").Text()))
+		htmlDoc.AssertElement(t, "input[name=title][value=\"[Bug] \"]", true)
+		htmlDoc.AssertElement(t, "#label_ids[value=\"1\"]", true)
+		htmlDoc.AssertElement(t, "#assignee_ids[value=\"2\"]", true)
+	})
+
+	t.Run("Form", func(t *testing.T) {
+		req := NewRequest(t, "GET", "/user2/repo1/issues/new?template=feature.yaml")
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		htmlDoc.AssertElement(t, "#content", false)
+		htmlDoc.AssertElement(t, "textarea[name=form-field-description]", true)
+		htmlDoc.AssertElement(t, "select[name=form-field-priority]", true)
+		htmlDoc.AssertElement(t, "#label_ids[value=\"2\"]", true)
+		link, _ := htmlDoc.doc.Find("form.ui.form").Attr("action")
+
+		// a required checkbox is missing
+		req = NewRequestWithValues(t, "POST", link, map[string]string{
+			"_csrf":                  htmlDoc.GetCSRF(),
+			"title":                  "New feature",
+			"template_file":          "feature.yaml",
+			"form-field-description": "Please add it",
+		})
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc = NewHTMLParser(t, resp.Body)
+		assert.Equal(t, "Please add it", htmlDoc.doc.Find("textarea[name=form-field-description]").Text())
+
+		req = NewRequestWithValues(t, "POST", link, map[string]string{
+			"_csrf":                  htmlDoc.GetCSRF(),
+			"title":                  "New feature",
+			"template_file":          "feature.yaml",
+			"form-field-description": "Please add it",
+			"form-field-priority":    "High",
+			"form-field-terms":       "0",
+		})
+		resp = session.MakeRequest(t, req, http.StatusFound)
+		assert.Contains(t, test.RedirectURL(resp), "/user2/repo1/issues/")
+
+		issue := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID, Title: "New feature"}).(*models.Issue)
+		assert.Equal(t, "### Description\n\nPlease add it\n\n### Priority\n\nHigh\n\n### Terms\n\n- [x] I searched for duplicates", issue.Content)
+	})
+
+	t.Run("PullRequest", func(t *testing.T) {
+		addRepoFile(t, user, repo, ".gitea/PULL_REQUEST_TEMPLATE/default.md", "---\nname: Default\n---\n**Changes**\n")
+		_, err := repofiles.CreateOrUpdateRepoFile(repo, user, &repofiles.UpdateRepoFileOptions{
+			OldBranch: repo.DefaultBranch,
+			NewBranch: "template-test",
+			TreePath:  "template-test.txt",
+			Content:   "changes",
+			IsNewFile: true,
+		})
+		assert.NoError(t, err)
+
+		req := NewRequest(t, "GET", "/user2/repo1/compare/master...template-test")
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc := NewHTMLParser(t, resp.Body)
+		link, _ := htmlDoc.doc.Find(".show-form-container .menu a.item").Attr("href")
+		assert.Equal(t, "/user2/repo1/compare/master...template-test?template=default.md", link)
+
+		req = NewRequest(t, "GET", link)
+		resp = session.MakeRequest(t, req, http.StatusOK)
+		htmlDoc = NewHTMLParser(t, resp.Body)
+		assert.Equal(t, "**Changes**", strings.TrimSpace(htmlDoc.doc.Find("#content").Text()))
+		htmlDoc.AssertElement(t, "input[name=template_file][value=\"default.md\"]", true)
+	})
+}
@@ -151,6 +151,15 @@ func testLinksAsUser(userName string, t *testing.T) {
 	}
 }
 
+func TestStorageDirectoryNotFound(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	for _, link := range []string{"/avatars/", "/repo-avatars/"} {
+		req := NewRequest(t, "GET", link)
+		MakeRequest(t, req, http.StatusNotFound)
+	}
+}
+
 func TestLinksLogin(t *testing.T) {
 	defer prepareTestEnv(t)()
 
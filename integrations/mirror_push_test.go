@@ -0,0 +1,111 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	api "code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushMirror(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		ctx := NewAPITestContext(t, "user2", "repo1-push-mirror")
+		t.Run("CreateMirrorTarget", doAPICreateRepository(ctx, true))
+
+		u.Path = ctx.GitPath()
+		u.User = url.UserPassword("user2", userPassword)
+
+		mirrorsURL := fmt.Sprintf("/api/v1/repos/user2/repo1/push_mirrors?token=%s", ctx.Token)
+		req := NewRequestWithJSON(t, "POST", mirrorsURL, &api.CreatePushMirrorOption{
+			RemoteAddress: "ftp://example.com/repo.git",
+		})
+		ctx.Session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+		req = NewRequestWithJSON(t, "POST", mirrorsURL, &api.CreatePushMirrorOption{
+			RemoteAddress: u.String(),
+			Interval:      "0",
+			SyncOnCommit:  true,
+		})
+		resp := ctx.Session.MakeRequest(t, req, http.StatusCreated)
+		var pushMirror api.PushMirror
+		DecodeJSON(t, resp, &pushMirror)
+		assert.NotContains(t, pushMirror.RemoteAddress, userPassword)
+		assert.Empty(t, pushMirror.Interval)
+		assert.True(t, pushMirror.SyncOnCommit)
+
+		// the repository is pushed to the mirror once it has been added
+		var m *models.PushMirror
+		for i := 0; i < 100; i++ {
+			m = models.AssertExistsAndLoadBean(t, &models.PushMirror{ID: pushMirror.ID}).(*models.PushMirror)
+			if m.LastUpdateUnix != 0 {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		assert.NotZero(t, m.LastUpdateUnix)
+		assert.Empty(t, m.LastError)
+		models.AssertExistsAndLoadBean(t, &models.PushMirrorSync{PushMirrorID: m.ID, IsSucceed: true})
+
+		srcRepo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+		mirrorRepo := models.AssertExistsAndLoadBean(t, &models.Repository{OwnerID: 2, Name: ctx.Reponame}).(*models.Repository)
+		srcCommitID, err := git.NewCommand("rev-parse", "master").RunInDir(srcRepo.RepoPath())
+		assert.NoError(t, err)
+		mirrorCommitID, err := git.NewCommand("rev-parse", "master").RunInDir(mirrorRepo.RepoPath())
+		assert.NoError(t, err)
+		assert.Equal(t, srcCommitID, mirrorCommitID)
+
+		req = NewRequest(t, "GET", mirrorsURL)
+		resp = ctx.Session.MakeRequest(t, req, http.StatusOK)
+		var pushMirrors []*api.PushMirror
+		DecodeJSON(t, resp, &pushMirrors)
+		assert.Len(t, pushMirrors, 1)
+		assert.NotNil(t, pushMirrors[0].LastUpdate)
+
+		// a failed sync is recorded in the history without the credentials of the remote
+		failingURL := *u
+		failingURL.User = url.UserPassword("user2", "wrong-password")
+		req = NewRequestWithJSON(t, "POST", mirrorsURL, &api.CreatePushMirrorOption{
+			RemoteAddress: failingURL.String(),
+			Interval:      "0",
+		})
+		resp = ctx.Session.MakeRequest(t, req, http.StatusCreated)
+		var failingMirror api.PushMirror
+		DecodeJSON(t, resp, &failingMirror)
+		for i := 0; i < 100; i++ {
+			m = models.AssertExistsAndLoadBean(t, &models.PushMirror{ID: failingMirror.ID}).(*models.PushMirror)
+			if m.LastUpdateUnix != 0 {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		assert.NotEmpty(t, m.LastError)
+		failedSync := models.AssertExistsAndLoadBean(t, &models.PushMirrorSync{PushMirrorID: m.ID}).(*models.PushMirrorSync)
+		assert.False(t, failedSync.IsSucceed)
+		assert.Equal(t, m.LastError, failedSync.Error)
+		assert.NotContains(t, failedSync.Error, "wrong-password")
+
+		req = NewRequest(t, "GET", "/user2/repo1/settings")
+		resp = ctx.Session.MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), pushMirror.RemoteAddress)
+		assert.Contains(t, resp.Body.String(), "push-mirror-sync-history")
+		assert.NotContains(t, resp.Body.String(), "wrong-password")
+
+		mirrorURL := fmt.Sprintf("/api/v1/repos/user2/repo1/push_mirrors/%d?token=%s", pushMirror.ID, ctx.Token)
+		req = NewRequest(t, "DELETE", mirrorURL)
+		ctx.Session.MakeRequest(t, req, http.StatusNoContent)
+		req = NewRequest(t, "GET", mirrorURL)
+		ctx.Session.MakeRequest(t, req, http.StatusNotFound)
+		models.AssertNotExistsBean(t, &models.PushMirror{ID: pushMirror.ID})
+	})
+}
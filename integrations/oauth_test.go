@@ -5,11 +5,18 @@
 package integrations
 
 import (
+	"crypto/rsa"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
 	"testing"
 
+	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/setting"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -241,3 +248,117 @@ func TestRefreshTokenInvalidation(t *testing.T) {
 	MakeRequest(t, refreshReq, 200)
 	MakeRequest(t, refreshReq, 400)
 }
+
+func TestOpenIDConnect(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	req := NewRequest(t, "GET", "/.well-known/openid-configuration")
+	resp := MakeRequest(t, req, 200)
+	var discovery map[string]interface{}
+	DecodeJSON(t, resp, &discovery)
+	assert.Equal(t, setting.AppURL, discovery["issuer"])
+	assert.Equal(t, setting.AppURL+"login/oauth/keys", discovery["jwks_uri"])
+
+	// authorize the application to use the OpenID Connect scopes
+	authorize := "/login/oauth/authorize?client_id=da7da3ba-9a13-4167-856f-3899de0b0138&redirect_uri=a&response_type=code&state=thestate" +
+		"&scope=" + url.QueryEscape("openid profile email groups") + "&nonce=thenonce"
+	session := loginUser(t, "user4")
+	resp = session.MakeRequest(t, NewRequest(t, "GET", authorize), 200)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	req = NewRequestWithValues(t, "POST", "/login/oauth/grant", map[string]string{
+		"_csrf":        htmlDoc.GetCSRF(),
+		"client_id":    "da7da3ba-9a13-4167-856f-3899de0b0138",
+		"redirect_uri": "a",
+		"state":        "thestate",
+		"scope":        "openid profile email groups",
+		"nonce":        "thenonce",
+	})
+	resp = session.MakeRequest(t, req, 302)
+	redirect, err := resp.Result().Location()
+	assert.NoError(t, err)
+
+	req = NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     "da7da3ba-9a13-4167-856f-3899de0b0138",
+		"client_secret": "4MK8Na6R55smdCY0WuCCumZ6hjRPnGY5saWVRHHjJiA=",
+		"redirect_uri":  "a",
+		"code":          redirect.Query().Get("code"),
+	})
+	resp = MakeRequest(t, req, 200)
+	var tokens struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		IDToken      string `json:"id_token"`
+	}
+	DecodeJSON(t, resp, &tokens)
+	assert.NotEmpty(t, tokens.IDToken)
+
+	// verify the id_token with the published keys
+	resp = MakeRequest(t, NewRequest(t, "GET", "/login/oauth/keys"), 200)
+	var jwks struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	DecodeJSON(t, resp, &jwks)
+	assert.Len(t, jwks.Keys, 1)
+	parsed, err := jwt.ParseWithClaims(tokens.IDToken, &models.OIDCToken{}, func(token *jwt.Token) (interface{}, error) {
+		for _, jwk := range jwks.Keys {
+			if jwk["kid"] != token.Header["kid"] {
+				continue
+			}
+			n, err := base64.RawURLEncoding.DecodeString(jwk["n"])
+			assert.NoError(t, err)
+			e, err := base64.RawURLEncoding.DecodeString(jwk["e"])
+			assert.NoError(t, err)
+			return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+		}
+		return nil, fmt.Errorf("unknown key id %v", token.Header["kid"])
+	})
+	assert.NoError(t, err)
+	claims := parsed.Claims.(*models.OIDCToken)
+	assert.Equal(t, setting.AppURL, claims.Issuer)
+	assert.Equal(t, "da7da3ba-9a13-4167-856f-3899de0b0138", claims.Audience)
+	assert.Equal(t, "4", claims.Subject)
+	assert.Equal(t, "thenonce", claims.Nonce)
+	assert.Equal(t, "user4", claims.PreferredUsername)
+	assert.Equal(t, "user4@example.com", claims.Email)
+	assert.Contains(t, claims.Groups, "user3")
+
+	// the userinfo endpoint returns the same claims
+	req = NewRequest(t, "GET", "/login/oauth/userinfo")
+	req.Header.Add("Authorization", "Bearer "+tokens.AccessToken)
+	resp = MakeRequest(t, req, 200)
+	var userInfo models.OIDCToken
+	DecodeJSON(t, resp, &userInfo)
+	assert.Equal(t, "4", userInfo.Subject)
+	assert.Equal(t, "user4@example.com", userInfo.Email)
+	assert.Equal(t, claims.Groups, userInfo.Groups)
+
+	// the nonce belongs to the authorization request, a refreshed id_token must not repeat it
+	req = NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+		"grant_type":    "refresh_token",
+		"client_id":     "da7da3ba-9a13-4167-856f-3899de0b0138",
+		"client_secret": "4MK8Na6R55smdCY0WuCCumZ6hjRPnGY5saWVRHHjJiA=",
+		"redirect_uri":  "a",
+		"refresh_token": tokens.RefreshToken,
+	})
+	resp = MakeRequest(t, req, 200)
+	DecodeJSON(t, resp, &tokens)
+	assert.NotEmpty(t, tokens.IDToken)
+	parsed, _, err = new(jwt.Parser).ParseUnverified(tokens.IDToken, &models.OIDCToken{})
+	assert.NoError(t, err)
+	assert.Equal(t, "4", parsed.Claims.(*models.OIDCToken).Subject)
+	assert.Empty(t, parsed.Claims.(*models.OIDCToken).Nonce)
+
+	req = NewRequest(t, "GET", "/login/oauth/userinfo")
+	resp = MakeRequest(t, req, 400)
+	assert.Contains(t, resp.Header().Get("WWW-Authenticate"), "invalid_request")
+	req = NewRequest(t, "GET", "/login/oauth/userinfo")
+	req.Header.Add("Authorization", "Bearer invalid")
+	MakeRequest(t, req, 401)
+
+	// the granted scopes do not have to be authorized again
+	resp = session.MakeRequest(t, NewRequest(t, "GET", authorize), 302)
+	redirect, err = resp.Result().Location()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, redirect.Query().Get("code"))
+}
@@ -110,3 +110,33 @@ func TestPrivateOrg(t *testing.T) {
 	req = NewRequest(t, "GET", "/privated_org/private_repo_on_private_org")
 	session.MakeRequest(t, req, http.StatusOK)
 }
+
+func TestOrgLabels(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	req := NewRequest(t, "GET", "/org/user3/settings/labels")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+
+	req = NewRequestWithValues(t, "POST", "/org/user3/settings/labels/new", map[string]string{
+		"_csrf": htmlDoc.GetCSRF(),
+		"title": "org-wide",
+		"color": "#123456",
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+
+	// the label is listed in the settings of the organization and on every repository of it
+	req = NewRequest(t, "GET", "/org/user3/settings/labels")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "org-wide")
+
+	req = NewRequest(t, "GET", "/user3/repo3/labels")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "org-wide")
+
+	// members who do not own the organization cannot manage its labels
+	session = loginUser(t, "user4")
+	req = NewRequest(t, "GET", "/org/user3/settings/labels")
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/git"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/test"
@@ -339,3 +340,168 @@ func TestCantMergeUnrelated(t *testing.T) {
 		assert.True(t, models.IsErrMergeUnrelatedHistories(err), "Merge error is not a unrelated histories error")
 	})
 }
+
+func TestPullAutoMergeWhenChecksSucceed(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		session := loginUser(t, "user1")
+		testRepoFork(t, session, "user2", "repo1", "user1", "repo1")
+		testEditFile(t, session, "user1", "repo1", "master", "README.md", "Hello, World (Edited)\n")
+
+		resp := testPullCreate(t, session, "user1", "repo1", "master", "This is a pull title")
+		elem := strings.Split(test.RedirectURL(resp), "/")
+		assert.EqualValues(t, "pulls", elem[3])
+
+		baseRepo := models.AssertExistsAndLoadBean(t, &models.Repository{OwnerName: "user2", Name: "repo1"}).(*models.Repository)
+		pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{BaseRepoID: baseRepo.ID, HeadBranch: "master"}, models.Cond("has_merged = ?", false)).(*models.PullRequest)
+		assert.NoError(t, models.UpdateProtectBranch(baseRepo, &models.ProtectedBranch{
+			RepoID:              baseRepo.ID,
+			BranchName:          "master",
+			EnableStatusCheck:   true,
+			StatusCheckContexts: []string{"ci"},
+		}, models.WhitelistOptions{}))
+
+		// wait until the pull request has been checked
+		for i := 0; i < 100 && pr.Status == models.PullRequestStatusChecking; i++ {
+			time.Sleep(100 * time.Millisecond)
+			pr = models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: pr.ID}).(*models.PullRequest)
+		}
+		assert.True(t, pr.CanAutoMerge())
+
+		token := getTokenForLoggedInUser(t, session)
+		mergeURL := fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d/merge?token=%s", pr.Index, token)
+		scheduleAutoMerge := func(style models.MergeStyle, expectedStatus int) {
+			req := NewRequestWithJSON(t, http.MethodPost, mergeURL, &auth.MergePullRequestForm{
+				Do:                     string(style),
+				MergeWhenChecksSucceed: true,
+			})
+			session.MakeRequest(t, req, expectedStatus)
+		}
+		scheduleAutoMerge(models.MergeStyleSquash, http.StatusCreated)
+		models.AssertExistsAndLoadBean(t, &models.PullAutoMerge{PullID: pr.ID, MergeStyle: models.MergeStyleSquash})
+		scheduleAutoMerge(models.MergeStyleSquash, http.StatusConflict)
+
+		req := NewRequest(t, http.MethodDelete, mergeURL)
+		session.MakeRequest(t, req, http.StatusNoContent)
+		models.AssertNotExistsBean(t, &models.PullAutoMerge{PullID: pr.ID})
+		req = NewRequest(t, http.MethodDelete, mergeURL)
+		session.MakeRequest(t, req, http.StatusNotFound)
+
+		scheduleAutoMerge(models.MergeStyleSquash, http.StatusCreated)
+
+		headGitRepo, err := git.OpenRepository(models.RepoPath("user1", "repo1"))
+		assert.NoError(t, err)
+		sha, err := headGitRepo.GetBranchCommitID("master")
+		headGitRepo.Close()
+		assert.NoError(t, err)
+
+		req = NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/repos/user2/repo1/statuses/%s?token=%s", sha, token), &api.CreateStatusOption{
+			State:   api.StatusSuccess,
+			Context: "ci",
+		})
+		session.MakeRequest(t, req, http.StatusCreated)
+
+		// the pull request is merged in the background once the status check succeeds
+		scheduled := true
+		for i := 0; i < 100 && scheduled; i++ {
+			time.Sleep(100 * time.Millisecond)
+			scheduled, _, err = models.GetScheduledAutoMergeByPullID(pr.ID)
+			assert.NoError(t, err)
+		}
+		assert.False(t, scheduled)
+		pr = models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: pr.ID}).(*models.PullRequest)
+		assert.True(t, pr.HasMerged)
+	})
+}
+
+func TestPullMergeQueue(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		session := loginUser(t, "user1")
+		testRepoFork(t, session, "user2", "repo1", "user1", "repo1")
+		testEditFileToNewBranch(t, session, "user1", "repo1", "master", "conflict", "README.md", "Hello, World (Conflict)\n")
+		testEditFile(t, session, "user1", "repo1", "master", "README.md", "Hello, World (Edited)\n")
+		testPullCreate(t, session, "user1", "repo1", "master", "This is a pull title")
+		testPullCreate(t, session, "user1", "repo1", "conflict", "This is a conflicting pull")
+
+		baseRepo := models.AssertExistsAndLoadBean(t, &models.Repository{OwnerName: "user2", Name: "repo1"}).(*models.Repository)
+		pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{BaseRepoID: baseRepo.ID, HeadBranch: "master"}, models.Cond("has_merged = ?", false)).(*models.PullRequest)
+		conflictPR := models.AssertExistsAndLoadBean(t, &models.PullRequest{BaseRepoID: baseRepo.ID, HeadBranch: "conflict"}).(*models.PullRequest)
+		assert.NoError(t, models.UpdateProtectBranch(baseRepo, &models.ProtectedBranch{
+			RepoID:              baseRepo.ID,
+			BranchName:          "master",
+			EnableStatusCheck:   true,
+			StatusCheckContexts: []string{"ci"},
+			EnableMergeQueue:    true,
+		}, models.WhitelistOptions{}))
+
+		// wait until the pull requests have been checked
+		for _, p := range []*models.PullRequest{pr, conflictPR} {
+			for i := 0; i < 100 && p.Status == models.PullRequestStatusChecking; i++ {
+				time.Sleep(100 * time.Millisecond)
+				p = models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: p.ID}).(*models.PullRequest)
+			}
+			assert.True(t, p.CanAutoMerge())
+		}
+
+		token := getTokenForLoggedInUser(t, session)
+		merge := func(pr *models.PullRequest, expectedStatus int) {
+			req := NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/repos/user2/repo1/pulls/%d/merge?token=%s", pr.Index, token), &auth.MergePullRequestForm{
+				Do: string(models.MergeStyleMerge),
+			})
+			session.MakeRequest(t, req, expectedStatus)
+		}
+		merge(pr, http.StatusAccepted)
+		merge(pr, http.StatusConflict)
+		models.AssertExistsAndLoadBean(t, &models.Comment{Type: models.CommentTypeMergeQueueAdded, IssueID: pr.IssueID, Content: "merge"})
+
+		// the pull request is merged speculatively in the background
+		var entry *models.PullMergeQueue
+		for i := 0; i < 100 && (entry == nil || entry.MergeCommitID == ""); i++ {
+			time.Sleep(100 * time.Millisecond)
+			_, entry, _ = models.GetMergeQueueEntryByPullID(pr.ID)
+		}
+		if !assert.NotNil(t, entry) || !assert.NotEmpty(t, entry.MergeCommitID) {
+			return
+		}
+
+		baseGitRepo, err := git.OpenRepository(baseRepo.RepoPath())
+		assert.NoError(t, err)
+		defer baseGitRepo.Close()
+		masterSHA, err := baseGitRepo.GetBranchCommitID("master")
+		assert.NoError(t, err)
+		assert.Equal(t, masterSHA, entry.ParentCommitID)
+		refSHA, err := baseGitRepo.GetRefCommitID(pr.GetMergeQueueRefName())
+		assert.NoError(t, err)
+		assert.Equal(t, entry.MergeCommitID, refSHA)
+
+		// the second pull request conflicts with the first one and is ejected
+		merge(conflictPR, http.StatusAccepted)
+		inQueue := true
+		for i := 0; i < 100 && inQueue; i++ {
+			time.Sleep(100 * time.Millisecond)
+			inQueue, _, err = models.GetMergeQueueEntryByPullID(conflictPR.ID)
+			assert.NoError(t, err)
+		}
+		assert.False(t, inQueue)
+		models.AssertExistsAndLoadBean(t, &models.Comment{Type: models.CommentTypeMergeQueueRemoved, IssueID: conflictPR.IssueID, Content: string(models.MergeQueueRemoveReasonConflict)})
+
+		req := NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/repos/user2/repo1/statuses/%s?token=%s", entry.MergeCommitID, token), &api.CreateStatusOption{
+			State:   api.StatusSuccess,
+			Context: "ci",
+		})
+		session.MakeRequest(t, req, http.StatusCreated)
+
+		// the branch is fast-forwarded once the status check succeeds
+		inQueue = true
+		for i := 0; i < 100 && inQueue; i++ {
+			time.Sleep(100 * time.Millisecond)
+			inQueue, _, err = models.GetMergeQueueEntryByPullID(pr.ID)
+			assert.NoError(t, err)
+		}
+		pr = models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: pr.ID}).(*models.PullRequest)
+		assert.True(t, pr.HasMerged)
+		assert.Equal(t, entry.MergeCommitID, pr.MergedCommitID)
+		masterSHA, err = baseGitRepo.GetBranchCommitID("master")
+		assert.NoError(t, err)
+		assert.Equal(t, entry.MergeCommitID, masterSHA)
+	})
+}
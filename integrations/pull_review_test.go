@@ -5,7 +5,18 @@ package integrations
 
 import (
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/repofiles"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/unknwon/com"
+	"github.com/unknwon/i18n"
 )
 
 func TestPullView_ReviewerMissed(t *testing.T) {
@@ -18,3 +29,141 @@ func TestPullView_ReviewerMissed(t *testing.T) {
 	req = NewRequest(t, "GET", "/user2/repo1/pulls/3")
 	session.MakeRequest(t, req, http.StatusOK)
 }
+
+func TestPullCodeOwners(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		user2 := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		user4 := models.AssertExistsAndLoadBean(t, &models.User{ID: 4}).(*models.User)
+		repo1 := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+		_, err := repofiles.CreateOrUpdateRepoFile(repo1, user2, &repofiles.UpdateRepoFileOptions{
+			OldBranch: repo1.DefaultBranch,
+			TreePath:  ".gitea/CODEOWNERS",
+			Content:   "# owners of the readme\nREADME.md @user4 @nonexistent\n",
+			IsNewFile: true,
+		})
+		assert.NoError(t, err)
+
+		session := loginUser(t, "user1")
+		testRepoFork(t, session, "user2", "repo1", "user1", "repo1")
+		testEditFile(t, session, "user1", "repo1", "master", "README.md", "Hello, World (Edited)\n")
+		resp := testPullCreate(t, session, "user1", "repo1", "master", "This is a pull title")
+		elem := strings.Split(test.RedirectURL(resp), "/")
+		assert.EqualValues(t, "pulls", elem[3])
+
+		pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{BaseRepoID: repo1.ID, HeadBranch: "master"}, models.Cond("has_merged = ?", false)).(*models.PullRequest)
+		models.AssertExistsAndLoadBean(t, &models.Review{IssueID: pr.IssueID, ReviewerID: user4.ID, Type: models.ReviewTypeRequest})
+		models.AssertExistsAndLoadBean(t, &models.Comment{IssueID: pr.IssueID, AssigneeID: user4.ID, Type: models.CommentTypeReviewRequest})
+
+		assert.NoError(t, models.UpdateProtectBranch(repo1, &models.ProtectedBranch{
+			RepoID:                  repo1.ID,
+			BranchName:              "master",
+			RequireCodeOwnerReviews: true,
+		}, models.WhitelistOptions{}))
+		assert.NoError(t, pr.LoadIssue())
+		assert.NoError(t, pr.Issue.LoadRepo())
+		assert.True(t, models.IsErrNotAllowedToMerge(pr.CheckUserAllowedToMerge(user2)))
+
+		req := NewRequest(t, "GET", test.RedirectURL(resp))
+		resp = loginUser(t, "user2").MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), i18n.Tr("en", "repo.pulls.blocked_by_code_owners"))
+
+		_, _, err = models.SubmitReview(user4, pr.Issue, models.ReviewTypeApprove, "", "", false)
+		assert.NoError(t, err)
+		models.AssertNotExistsBean(t, &models.Review{IssueID: pr.IssueID, ReviewerID: user4.ID, Type: models.ReviewTypeRequest})
+		assert.NoError(t, pr.CheckUserAllowedToMerge(user2))
+	})
+}
+
+func TestPullReviewSuggestions(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, giteaURL *url.URL) {
+		author := loginUser(t, "user1")
+		testRepoFork(t, author, "user2", "repo1", "user1", "repo1")
+		testEditFile(t, author, "user1", "repo1", "master", "README.md", "Hello, World (Edited)\nSecond line\n")
+		resp := testPullCreate(t, author, "user1", "repo1", "master", "Suggestions")
+		prLink := test.RedirectURL(resp)
+		filesLink := prLink + "/files"
+
+		// the repository owner suggests a change
+		reviewer := loginUser(t, "user2")
+		req := NewRequestWithValues(t, "POST", filesLink+"/reviews/comments", map[string]string{
+			"_csrf":   GetCSRF(t, reviewer, filesLink),
+			"content": "Nicer:\n```suggestion\nHello, Suggestion\n```",
+			"side":    "proposed",
+			"line":    "1",
+			"path":    "README.md",
+		})
+		reviewer.MakeRequest(t, req, http.StatusFound)
+		comment := models.AssertExistsAndLoadBean(t, &models.Comment{Type: models.CommentTypeCode, PosterID: 2, TreePath: "README.md", Line: 1}).(*models.Comment)
+		assert.True(t, comment.CanApplySuggestion())
+
+		// the suggestion of a pending review can not be applied before the review is submitted
+		req = NewRequestWithValues(t, "POST", filesLink+"/reviews/comments", map[string]string{
+			"_csrf":     GetCSRF(t, reviewer, filesLink),
+			"content":   "```suggestion\nSecret line\n```",
+			"side":      "proposed",
+			"line":      "2",
+			"path":      "README.md",
+			"is_review": "true",
+		})
+		reviewer.MakeRequest(t, req, http.StatusFound)
+		pending := models.AssertExistsAndLoadBean(t, &models.Comment{Type: models.CommentTypeCode, PosterID: 2, TreePath: "README.md", Line: 2}).(*models.Comment)
+		review := models.AssertExistsAndLoadBean(t, &models.Review{ID: pending.ReviewID}).(*models.Review)
+		assert.EqualValues(t, models.ReviewTypePending, review.Type)
+
+		req = NewRequestWithValues(t, "POST", filesLink+"/suggestions", map[string]string{
+			"_csrf":       GetCSRF(t, author, filesLink),
+			"comment_ids": com.ToStr(pending.ID),
+		})
+		author.MakeRequest(t, req, http.StatusFound)
+		assert.Contains(t, author.GetCookie("macaron_flash").Value, "error")
+
+		req = NewRequest(t, "GET", "/user1/repo1/raw/branch/master/README.md")
+		resp = author.MakeRequest(t, req, http.StatusOK)
+		assert.EqualValues(t, "Hello, World (Edited)\nSecond line\n", resp.Body.String())
+		pending = models.AssertExistsAndLoadBean(t, &models.Comment{ID: pending.ID}).(*models.Comment)
+		assert.False(t, pending.IsSuggestionApplied())
+
+		// the reviewer can not push to the fork
+		req = NewRequestWithValues(t, "POST", filesLink+"/suggestions", map[string]string{
+			"_csrf":       GetCSRF(t, reviewer, filesLink),
+			"comment_ids": com.ToStr(comment.ID),
+		})
+		reviewer.MakeRequest(t, req, http.StatusNotFound)
+
+		req = NewRequest(t, "GET", filesLink)
+		resp = author.MakeRequest(t, req, http.StatusOK)
+		assert.Contains(t, resp.Body.String(), i18n.Tr("en", "repo.pulls.suggestion.apply"))
+		assert.Contains(t, resp.Body.String(), "-Hello, World (Edited)\n+Hello, Suggestion")
+
+		req = NewRequestWithValues(t, "POST", filesLink+"/suggestions", map[string]string{
+			"_csrf":       GetCSRF(t, author, filesLink),
+			"comment_ids": com.ToStr(comment.ID),
+		})
+		resp = author.MakeRequest(t, req, http.StatusFound)
+		assert.EqualValues(t, filesLink, test.RedirectURL(resp))
+
+		req = NewRequest(t, "GET", "/user1/repo1/raw/branch/master/README.md")
+		resp = author.MakeRequest(t, req, http.StatusOK)
+		assert.EqualValues(t, "Hello, Suggestion\nSecond line\n", resp.Body.String())
+
+		comment = models.AssertExistsAndLoadBean(t, &models.Comment{ID: comment.ID}).(*models.Comment)
+		assert.True(t, comment.IsSuggestionApplied())
+
+		gitRepo, err := git.OpenRepository(models.RepoPath("user1", "repo1"))
+		assert.NoError(t, err)
+		defer gitRepo.Close()
+		commit, err := gitRepo.GetBranchCommit("master")
+		assert.NoError(t, err)
+		assert.EqualValues(t, comment.SuggestionCommitSHA, commit.ID.String())
+		assert.Contains(t, commit.CommitMessage, "Apply suggestion from code review")
+		assert.Contains(t, commit.CommitMessage, "Co-authored-by: User Two <user2@noreply.example.org>")
+
+		// an applied suggestion can not be applied twice
+		req = NewRequestWithValues(t, "POST", filesLink+"/suggestions", map[string]string{
+			"_csrf":       GetCSRF(t, author, filesLink),
+			"comment_ids": com.ToStr(comment.ID),
+		})
+		author.MakeRequest(t, req, http.StatusFound)
+		assert.Contains(t, author.GetCookie("macaron_flash").Value, "error")
+	})
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/repofiles"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoHighlight(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+		createFile := func(treePath, content string) string {
+			resp, err := repofiles.CreateOrUpdateRepoFile(repo, user, &repofiles.UpdateRepoFileOptions{
+				OldBranch: repo.DefaultBranch,
+				TreePath:  treePath,
+				Content:   content,
+				IsNewFile: true,
+			})
+			assert.NoError(t, err)
+			return resp.Commit.SHA
+		}
+		createFile(".gitattributes", "*.tmpl linguist-language=Go\n")
+		createFile("main.tmpl", "package main\n")
+		commitID := createFile("main.go", "package main\n\nvar a = \"<b>\"\n")
+
+		highlighted := `<span class="kn">package</span> <span class="nx">main</span>`
+		session := loginUser(t, user.Name)
+		for _, link := range []string{
+			"/user2/repo1/src/branch/master/main.go",
+			"/user2/repo1/src/branch/master/main.tmpl",
+			"/user2/repo1/blame/branch/master/main.go",
+			"/user2/repo1/commit/" + commitID,
+		} {
+			req := NewRequest(t, "GET", link)
+			resp := session.MakeRequest(t, req, http.StatusOK)
+			body := resp.Body.String()
+			assert.Contains(t, body, highlighted, link)
+			assert.NotContains(t, body, `"<b>"`, link)
+		}
+	})
+}
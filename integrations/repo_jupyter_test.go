@@ -0,0 +1,56 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	_ "code.gitea.io/gitea/modules/markup/jupyter"
+	"code.gitea.io/gitea/modules/repofiles"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoJupyterNotebook(t *testing.T) {
+	onGiteaRun(t, func(t *testing.T, u *url.URL) {
+		user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+		notebook := func(title string) string {
+			return `{"cells": [{"cell_type": "markdown", "metadata": {}, "source": ["# ` + title + `"]},
+{"cell_type": "code", "execution_count": 1, "metadata": {}, "source": ["import os"], "outputs": []}],
+"metadata": {"language_info": {"name": "python"}}, "nbformat": 4, "nbformat_minor": 2}`
+		}
+		_, err := repofiles.CreateOrUpdateRepoFile(repo, user, &repofiles.UpdateRepoFileOptions{
+			OldBranch: repo.DefaultBranch,
+			TreePath:  "notebook.ipynb",
+			Content:   notebook("Before"),
+			IsNewFile: true,
+		})
+		assert.NoError(t, err)
+		resp, err := repofiles.CreateOrUpdateRepoFile(repo, user, &repofiles.UpdateRepoFileOptions{
+			OldBranch:    repo.DefaultBranch,
+			TreePath:     "notebook.ipynb",
+			FromTreePath: "notebook.ipynb",
+			Content:      notebook("After"),
+		})
+		assert.NoError(t, err)
+
+		session := loginUser(t, user.Name)
+		req := NewRequest(t, "GET", "/user2/repo1/src/branch/master/notebook.ipynb")
+		body := session.MakeRequest(t, req, http.StatusOK).Body.String()
+		assert.Contains(t, body, `<h1 id="user-content-after">After</h1>`)
+		assert.Contains(t, body, `<pre class="chroma"><span class="kn">import</span> <span class="nn">os</span></pre>`)
+
+		req = NewRequest(t, "GET", "/user2/repo1/commit/"+resp.Commit.SHA)
+		htmlDoc := NewHTMLParser(t, session.MakeRequest(t, req, http.StatusOK).Body)
+		assert.EqualValues(t, 1, htmlDoc.doc.Find(".rich-diff-toggle").Length())
+		assert.Contains(t, htmlDoc.doc.Find(".markup-diff td.removed h1").Text(), "Before")
+		assert.Contains(t, htmlDoc.doc.Find(".markup-diff td.added h1").Text(), "After")
+	})
+}
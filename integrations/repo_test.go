@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/setting"
 
 	"github.com/PuerkitoBio/goquery"
@@ -119,3 +120,21 @@ func TestViewAsRepoAdmin(t *testing.T) {
 		assert.Equal(t, expectedNoDescription, noDescription.HasClass("no-description"))
 	}
 }
+
+func TestDownloadRepoArchive(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	for _, archiveType := range git.SupportedArchiveTypes() {
+		req := NewRequestf(t, "GET", "/user2/repo1/archive/master.%s", archiveType)
+		resp := MakeRequest(t, req, http.StatusOK)
+		assert.NotEmpty(t, resp.Body.Bytes(), archiveType.String())
+		assert.Contains(t, resp.Header().Get("Content-Disposition"), "repo1-master."+archiveType.String())
+
+		// the second download is served from the cache
+		req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/archive/master.%s", archiveType)
+		MakeRequest(t, req, http.StatusOK)
+	}
+
+	req := NewRequest(t, "GET", "/user2/repo1/archive/master.tar.bz2")
+	MakeRequest(t, req, http.StatusNotFound)
+}
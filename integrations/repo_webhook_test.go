@@ -0,0 +1,40 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoWebhookRedeliver(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	session := loginUser(t, "user2")
+	req := NewRequest(t, "GET", "/user2/repo1/settings/hooks/1")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	link, exists := htmlDoc.doc.Find(".redeliver-delivery").Attr("data-link")
+	assert.True(t, exists)
+	assert.Equal(t, "/user2/repo1/settings/hooks/1/deliveries/1/redeliver", link)
+
+	req = NewRequestWithValues(t, "POST", link, map[string]string{
+		"_csrf": GetCSRF(t, session, "/user2/repo1/settings/hooks/1"),
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	assert.EqualValues(t, 2, models.GetCount(t, &models.HookTask{HookID: 1}))
+
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/settings/hooks/1/replay", map[string]string{
+		"_csrf": GetCSRF(t, session, "/user2/repo1/settings/hooks/1"),
+		"since": "2020-02-01",
+		"until": "2020-02-01",
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+	assert.EqualValues(t, 3, models.GetCount(t, &models.HookTask{HookID: 1}))
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/private"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ssh"
+)
+
+func newSSHCASigner(t *testing.T) ssh.Signer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	assert.NoError(t, err)
+	return signer
+}
+
+func doWriteSSHCertificate(keyFile string, ca ssh.Signer, validFor time.Duration, principals ...string) func(*testing.T) {
+	return func(t *testing.T) {
+		content, err := ioutil.ReadFile(keyFile + ".pub")
+		assert.NoError(t, err)
+		pubKey, _, _, _, err := ssh.ParseAuthorizedKey(content)
+		assert.NoError(t, err)
+
+		now := time.Now()
+		cert := &ssh.Certificate{
+			Key:             pubKey,
+			KeyId:           "integration-test",
+			CertType:        ssh.UserCert,
+			ValidPrincipals: principals,
+			ValidAfter:      uint64(now.Add(-time.Hour).Unix()),
+			ValidBefore:     uint64(now.Add(validFor).Unix()),
+		}
+		assert.NoError(t, cert.SignCert(rand.Reader, ca))
+		assert.NoError(t, ioutil.WriteFile(keyFile+"-cert.pub", ssh.MarshalAuthorizedKey(cert), 0600))
+	}
+}
+
+func TestSSHCertificate(t *testing.T) {
+	onGiteaRun(t, testSSHCertificate)
+}
+
+func testSSHCertificate(t *testing.T, u *url.URL) {
+	ca := newSSHCASigner(t)
+	oldKeys := setting.SSH.TrustedUserCAKeysParsed
+	setting.SSH.TrustedUserCAKeysParsed = []ssh.PublicKey{ca.PublicKey()}
+	defer func() {
+		setting.SSH.TrustedUserCAKeysParsed = oldKeys
+	}()
+
+	ctx := NewAPITestContext(t, "user2", "ssh-certificate-test-repo")
+	t.Run("CreateRepository", doAPICreateRepository(ctx, false))
+	sshURL := createSSHUrl(ctx.GitPath(), u)
+
+	withKeyFile(t, "ssh-certificate-test", func(keyFile string) {
+		t.Run("AuthorizedPrincipals", func(t *testing.T) {
+			t.Run("WriteCertificate", doWriteSSHCertificate(keyFile, ca, time.Hour, "ops", "user2"))
+			content, err := ioutil.ReadFile(keyFile + "-cert.pub")
+			assert.NoError(t, err)
+
+			line, err := private.AuthorizedPrincipalsByCertificate(strings.TrimSpace(string(content)))
+			assert.NoError(t, err)
+			assert.True(t, strings.HasSuffix(line, " user2"))
+			assert.Contains(t, line, "serv user-2\"")
+
+			results, err := private.ServCommand(0, 2, "user2", ctx.Reponame, models.AccessModeWrite, "git-receive-pack")
+			assert.NoError(t, err)
+			assert.EqualValues(t, 2, results.UserID)
+			assert.Equal(t, "user2", results.UserName)
+			assert.False(t, results.IsDeployKey)
+
+			_, err = private.ServCommand(0, 4, "user2", ctx.Reponame, models.AccessModeWrite, "git-receive-pack")
+			assert.Error(t, err)
+
+			t.Run("WriteCertificate", doWriteSSHCertificate(keyFile, ca, -time.Minute, "user2"))
+			content, err = ioutil.ReadFile(keyFile + "-cert.pub")
+			assert.NoError(t, err)
+			_, err = private.AuthorizedPrincipalsByCertificate(strings.TrimSpace(string(content)))
+			assert.Error(t, err)
+		})
+
+		t.Run("UntrustedAuthority", func(t *testing.T) {
+			dstPath, err := ioutil.TempDir("", ctx.Reponame)
+			assert.NoError(t, err)
+			defer os.RemoveAll(dstPath)
+
+			t.Run("WriteCertificate", doWriteSSHCertificate(keyFile, newSSHCASigner(t), time.Hour, "user2"))
+			t.Run("FailToClone", doGitCloneFail(dstPath, sshURL))
+		})
+
+		t.Run("Expired", func(t *testing.T) {
+			dstPath, err := ioutil.TempDir("", ctx.Reponame)
+			assert.NoError(t, err)
+			defer os.RemoveAll(dstPath)
+
+			t.Run("WriteCertificate", doWriteSSHCertificate(keyFile, ca, -time.Minute, "user2"))
+			t.Run("FailToClone", doGitCloneFail(dstPath, sshURL))
+		})
+
+		t.Run("UnknownPrincipal", func(t *testing.T) {
+			dstPath, err := ioutil.TempDir("", ctx.Reponame)
+			assert.NoError(t, err)
+			defer os.RemoveAll(dstPath)
+
+			t.Run("WriteCertificate", doWriteSSHCertificate(keyFile, ca, time.Hour, "no-such-user"))
+			t.Run("FailToClone", doGitCloneFail(dstPath, sshURL))
+		})
+
+		t.Run("CloneAndPush", func(t *testing.T) {
+			dstPath, err := ioutil.TempDir("", ctx.Reponame)
+			assert.NoError(t, err)
+			defer os.RemoveAll(dstPath)
+
+			t.Run("WriteCertificate", doWriteSSHCertificate(keyFile, ca, time.Hour, "ops", "user2"))
+			t.Run("Clone", doGitClone(dstPath, sshURL))
+			t.Run("AddChanges", doAddChangesToCheckout(dstPath, "CHANGES.md"))
+			t.Run("Push", doGitPushTestRepository(dstPath, "origin", "master"))
+		})
+	})
+}
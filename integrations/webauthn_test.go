@@ -0,0 +1,143 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/webauthn/webauthntest"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func enrollTwoFactor(t *testing.T, uid int64) {
+	twofa := &models.TwoFactor{UID: uid}
+	assert.NoError(t, twofa.SetSecret("JBSWY3DPEHPK3PXP"))
+	_, err := twofa.GenerateScratchToken()
+	assert.NoError(t, err)
+	assert.NoError(t, models.NewTwoFactor(twofa))
+}
+
+func doWebAuthnLogin(t *testing.T, userName string, authenticator *webauthntest.Authenticator, expectedStatus int) *TestSession {
+	session := loginUserWithPassword(t, userName, userPassword)
+	csrf := GetCSRF(t, session, "/user/webauthn")
+
+	req := NewRequest(t, "GET", "/user/webauthn/assertion")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var options protocol.CredentialAssertion
+	DecodeJSON(t, resp, &options)
+
+	assertion, err := authenticator.Login(&options, setting.WebAuthn.Origin)
+	assert.NoError(t, err)
+	req = NewRequestWithJSON(t, "POST", "/user/webauthn/assertion", assertion)
+	req.Header.Add("X-Csrf-Token", csrf)
+	session.MakeRequest(t, req, expectedStatus)
+	return session
+}
+
+func TestWebAuthn(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	session := loginUser(t, user.Name)
+	enrollTwoFactor(t, user.ID)
+
+	authenticator, err := webauthntest.NewAuthenticator()
+	assert.NoError(t, err)
+
+	t.Run("Register", func(t *testing.T) {
+		csrf := GetCSRF(t, session, "/user/settings/security")
+		req := NewRequestWithValues(t, "POST", "/user/settings/security/webauthn/request_register", map[string]string{
+			"_csrf": csrf,
+			"name":  "Software Key",
+		})
+		resp := session.MakeRequest(t, req, http.StatusOK)
+		var options protocol.CredentialCreation
+		DecodeJSON(t, resp, &options)
+		assert.Equal(t, setting.WebAuthn.RPID, options.Response.RelyingParty.ID)
+
+		credential, err := authenticator.Register(&options, setting.WebAuthn.Origin)
+		assert.NoError(t, err)
+		req = NewRequestWithJSON(t, "POST", "/user/settings/security/webauthn/register", credential)
+		req.Header.Add("X-Csrf-Token", csrf)
+		session.MakeRequest(t, req, http.StatusOK)
+
+		models.AssertExistsAndLoadBean(t, &models.WebAuthnCredential{UserID: user.ID, Name: "Software Key"})
+
+		// the name must be unique
+		req = NewRequestWithValues(t, "POST", "/user/settings/security/webauthn/request_register", map[string]string{
+			"_csrf": csrf,
+			"name":  "Software Key",
+		})
+		session.MakeRequest(t, req, http.StatusConflict)
+	})
+
+	t.Run("LoginRedirect", func(t *testing.T) {
+		req := NewRequest(t, "GET", "/user/login")
+		resp := MakeRequest(t, req, http.StatusOK)
+		doc := NewHTMLParser(t, resp.Body)
+		req = NewRequestWithValues(t, "POST", "/user/login", map[string]string{
+			"_csrf":     doc.GetCSRF(),
+			"user_name": user.Name,
+			"password":  userPassword,
+		})
+		resp = MakeRequest(t, req, http.StatusFound)
+		assert.Equal(t, "/user/webauthn", resp.Header().Get("Location"))
+	})
+
+	t.Run("Login", func(t *testing.T) {
+		session := doWebAuthnLogin(t, user.Name, authenticator, http.StatusOK)
+		session.MakeRequest(t, NewRequest(t, "GET", "/user/settings"), http.StatusOK)
+
+		models.AssertExistsAndLoadBean(t, &models.WebAuthnCredential{UserID: user.ID, SignCount: authenticator.SignCount})
+	})
+
+	t.Run("ClonedAuthenticator", func(t *testing.T) {
+		cloned := *authenticator
+		cloned.SignCount--
+		doWebAuthnLogin(t, user.Name, &cloned, http.StatusUnauthorized)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		cred := models.AssertExistsAndLoadBean(t, &models.WebAuthnCredential{UserID: user.ID, Name: "Software Key"}).(*models.WebAuthnCredential)
+		req := NewRequestWithValues(t, "POST", "/user/settings/security/webauthn/delete", map[string]string{
+			"_csrf": GetCSRF(t, session, "/user/settings/security"),
+			"id":    strconv.FormatInt(cred.ID, 10),
+		})
+		session.MakeRequest(t, req, http.StatusOK)
+		models.AssertNotExistsBean(t, &models.WebAuthnCredential{ID: cred.ID})
+	})
+}
+
+func TestWebAuthnLegacyU2F(t *testing.T) {
+	defer prepareTestEnv(t)()
+
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	enrollTwoFactor(t, user.ID)
+
+	// a key registered with the U2F API, as left behind by the migration
+	authenticator, err := webauthntest.NewAuthenticator()
+	assert.NoError(t, err)
+	authenticator.AppID = setting.WebAuthn.LegacyAppID
+	authenticator.SignCount = 5
+	models.AssertSuccessfulInsert(t, &models.WebAuthnCredential{
+		Name:            "U2F Key",
+		UserID:          user.ID,
+		CredentialID:    base64.RawURLEncoding.EncodeToString(authenticator.CredentialID),
+		PublicKey:       authenticator.PublicKeyCOSE(),
+		AttestationType: "fido-u2f",
+		SignCount:       authenticator.SignCount,
+		LegacyU2F:       true,
+	})
+
+	session := doWebAuthnLogin(t, user.Name, authenticator, http.StatusOK)
+	session.MakeRequest(t, NewRequest(t, "GET", "/user/settings"), http.StatusOK)
+}
@@ -18,6 +18,7 @@ import (
 
 	// register supported doc types
 	_ "code.gitea.io/gitea/modules/markup/csv"
+	_ "code.gitea.io/gitea/modules/markup/jupyter"
 	_ "code.gitea.io/gitea/modules/markup/markdown"
 	_ "code.gitea.io/gitea/modules/markup/orgmode"
 
@@ -68,6 +69,10 @@ arguments - which can alternatively be run by running the subcommand web.`
 		cmd.CmdMigrate,
 		cmd.CmdKeys,
 		cmd.CmdConvert,
+		cmd.CmdDumpRepository,
+		cmd.CmdRestoreRepository,
+		cmd.CmdMigrateStorage,
+		cmd.CmdIndexer,
 	}
 	// Now adjust these commands to add our global configuration options
 
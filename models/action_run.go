@@ -0,0 +1,528 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// ActionStatus represents the status of a workflow run or job
+type ActionStatus int
+
+// Enumerate all the statuses of runs and jobs
+const (
+	ActionStatusWaiting   ActionStatus = iota + 1 // 1 waits for a runner
+	ActionStatusRunning                           // 2 is executed by a runner
+	ActionStatusSuccess                           // 3
+	ActionStatusFailure                           // 4
+	ActionStatusCancelled                         // 5
+)
+
+var actionStatusNames = map[ActionStatus]string{
+	ActionStatusWaiting:   "waiting",
+	ActionStatusRunning:   "running",
+	ActionStatusSuccess:   "success",
+	ActionStatusFailure:   "failure",
+	ActionStatusCancelled: "cancelled",
+}
+
+// String returns the name of the status
+func (s ActionStatus) String() string {
+	return actionStatusNames[s]
+}
+
+// IsDone returns true if the status is final
+func (s ActionStatus) IsDone() bool {
+	return s == ActionStatusSuccess || s == ActionStatusFailure || s == ActionStatusCancelled
+}
+
+// CommitStatus returns the commit status state which represents the status
+func (s ActionStatus) CommitStatus() CommitStatusState {
+	switch s {
+	case ActionStatusSuccess:
+		return CommitStatusSuccess
+	case ActionStatusFailure:
+		return CommitStatusFailure
+	case ActionStatusCancelled:
+		return CommitStatusError
+	default:
+		return CommitStatusPending
+	}
+}
+
+// ParseActionStatus returns the status with the given name or 0
+func ParseActionStatus(name string) ActionStatus {
+	for s, n := range actionStatusNames {
+		if n == name {
+			return s
+		}
+	}
+	return 0
+}
+
+// ActionRun represents a single execution of a workflow file
+type ActionRun struct {
+	ID            int64       `xorm:"pk autoincr"`
+	RepoID        int64       `xorm:"UNIQUE(repo_index) INDEX NOT NULL"`
+	Repo          *Repository `xorm:"-"`
+	Index         int64       `xorm:"UNIQUE(repo_index) NOT NULL"`
+	Title         string
+	WorkflowID    string `xorm:"NOT NULL"`
+	TriggerUserID int64  `xorm:"NOT NULL"`
+	TriggerUser   *User  `xorm:"-"`
+	Event         string `xorm:"NOT NULL"`
+	Ref           string
+	CommitSHA     string             `xorm:"INDEX NOT NULL"`
+	Status        ActionStatus       `xorm:"INDEX NOT NULL"`
+	StartedUnix   timeutil.TimeStamp `xorm:"INDEX"`
+	StoppedUnix   timeutil.TimeStamp
+	CreatedUnix   timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix   timeutil.TimeStamp `xorm:"updated"`
+}
+
+// LoadAttributes loads the repository and the trigger user of the run
+func (run *ActionRun) LoadAttributes() error {
+	return run.loadAttributes(x)
+}
+
+func (run *ActionRun) loadAttributes(e Engine) (err error) {
+	if run.Repo == nil {
+		if run.Repo, err = getRepositoryByID(e, run.RepoID); err != nil {
+			return fmt.Errorf("getRepositoryByID [%d]: %v", run.RepoID, err)
+		}
+	}
+	if run.TriggerUser == nil {
+		if run.TriggerUser, err = getUserByID(e, run.TriggerUserID); err != nil {
+			if !IsErrUserNotExist(err) {
+				return fmt.Errorf("getUserByID [%d]: %v", run.TriggerUserID, err)
+			}
+			run.TriggerUser = NewGhostUser()
+		}
+	}
+	return nil
+}
+
+// Link returns the relative link to the run, the repository must be loaded
+func (run *ActionRun) Link() string {
+	return fmt.Sprintf("%s/actions/runs/%d", run.Repo.Link(), run.Index)
+}
+
+// HTMLURL returns the absolute url of the run, the repository must be loaded
+func (run *ActionRun) HTMLURL() string {
+	return fmt.Sprintf("%s/actions/runs/%d", run.Repo.HTMLURL(), run.Index)
+}
+
+// ShortCommitSHA returns the abbreviated commit id
+func (run *ActionRun) ShortCommitSHA() string {
+	return base.ShortSha(run.CommitSHA)
+}
+
+// RefName returns the name of the ref without its prefix
+func (run *ActionRun) RefName() string {
+	return strings.TrimPrefix(strings.TrimPrefix(run.Ref, git.BranchPrefix), git.TagPrefix)
+}
+
+// Duration returns the time the run has taken so far
+func (run *ActionRun) Duration() time.Duration {
+	return calcActionDuration(run.StartedUnix, run.StoppedUnix)
+}
+
+func calcActionDuration(started, stopped timeutil.TimeStamp) time.Duration {
+	if started == 0 {
+		return 0
+	}
+	if stopped == 0 {
+		stopped = timeutil.TimeStampNow()
+	}
+	return time.Duration(stopped-started) * time.Second
+}
+
+// ActionRunJob represents a job of a workflow run which is executed on one runner
+type ActionRunJob struct {
+	ID     int64      `xorm:"pk autoincr"`
+	RunID  int64      `xorm:"INDEX NOT NULL"`
+	Run    *ActionRun `xorm:"-"`
+	RepoID int64      `xorm:"INDEX NOT NULL"`
+	JobID  string     `xorm:"NOT NULL"`
+	Name   string
+	RunsOn []string `xorm:"TEXT JSON"`
+	// Payload is the JSON encoded job definition sent to the runner
+	Payload     string             `xorm:"LONGTEXT"`
+	Status      ActionStatus       `xorm:"INDEX NOT NULL"`
+	RunnerID    int64              `xorm:"INDEX"`
+	StartedUnix timeutil.TimeStamp `xorm:"INDEX"`
+	StoppedUnix timeutil.TimeStamp
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+}
+
+// LoadRun loads the run of the job together with its attributes
+func (job *ActionRunJob) LoadRun() (err error) {
+	if job.Run == nil {
+		if job.Run, err = GetActionRunByID(job.RunID); err != nil {
+			return err
+		}
+	}
+	return job.Run.LoadAttributes()
+}
+
+// Duration returns the time the job has taken so far
+func (job *ActionRunJob) Duration() time.Duration {
+	return calcActionDuration(job.StartedUnix, job.StoppedUnix)
+}
+
+// LogPath returns the path of the log file of the job
+func (job *ActionRunJob) LogPath() string {
+	return filepath.Join(actionLogsPath(job.RepoID), fmt.Sprintf("%d.log", job.ID))
+}
+
+func actionLogsPath(repoID int64) string {
+	return filepath.Join(setting.Actions.LogPath, fmt.Sprint(repoID))
+}
+
+// ErrActionRunNotExist represents a "ActionRunNotExist" kind of error.
+type ErrActionRunNotExist struct {
+	ID     int64
+	RepoID int64
+	Index  int64
+}
+
+// IsErrActionRunNotExist checks if an error is a ErrActionRunNotExist.
+func IsErrActionRunNotExist(err error) bool {
+	_, ok := err.(ErrActionRunNotExist)
+	return ok
+}
+
+func (err ErrActionRunNotExist) Error() string {
+	return fmt.Sprintf("run does not exist [id: %d, repo_id: %d, index: %d]", err.ID, err.RepoID, err.Index)
+}
+
+// ErrActionRunJobNotExist represents a "ActionRunJobNotExist" kind of error.
+type ErrActionRunJobNotExist struct {
+	ID int64
+}
+
+// IsErrActionRunJobNotExist checks if an error is a ErrActionRunJobNotExist.
+func IsErrActionRunJobNotExist(err error) bool {
+	_, ok := err.(ErrActionRunJobNotExist)
+	return ok
+}
+
+func (err ErrActionRunJobNotExist) Error() string {
+	return fmt.Sprintf("job does not exist [id: %d]", err.ID)
+}
+
+// CreateActionRun inserts the run with the next index of the repository and its jobs
+func CreateActionRun(run *ActionRun, jobs []*ActionRunJob) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	run.Status = ActionStatusWaiting
+	if _, err := sess.SetExpr("`index`", "coalesce(MAX(`index`),0)+1").
+		Where("repo_id=?", run.RepoID).
+		Insert(run); err != nil {
+		return err
+	}
+	inserted := new(ActionRun)
+	if _, err := sess.ID(run.ID).Get(inserted); err != nil {
+		return err
+	}
+	run.Index = inserted.Index
+
+	for _, job := range jobs {
+		job.RunID = run.ID
+		job.Run = run
+		job.RepoID = run.RepoID
+		job.Status = ActionStatusWaiting
+	}
+	if len(jobs) > 0 {
+		if _, err := sess.Insert(jobs); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+// GetActionRunByID returns the run with the given id
+func GetActionRunByID(id int64) (*ActionRun, error) {
+	run := new(ActionRun)
+	has, err := x.ID(id).Get(run)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrActionRunNotExist{ID: id}
+	}
+	return run, nil
+}
+
+// GetActionRunByIndex returns the run of the repository with the given index
+func GetActionRunByIndex(repoID, index int64) (*ActionRun, error) {
+	run := &ActionRun{
+		RepoID: repoID,
+		Index:  index,
+	}
+	has, err := x.Get(run)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrActionRunNotExist{RepoID: repoID, Index: index}
+	}
+	return run, nil
+}
+
+// FindActionRunOptions are the options for FindActionRuns
+type FindActionRunOptions struct {
+	RepoID    int64
+	CommitSHA string
+	Page      int
+	PageSize  int
+}
+
+func (opts *FindActionRunOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.CommitSHA != "" {
+		cond = cond.And(builder.Eq{"commit_sha": opts.CommitSHA})
+	}
+	return cond
+}
+
+// FindActionRuns returns the runs matching the options, newest first, and their total count
+func FindActionRuns(opts *FindActionRunOptions) ([]*ActionRun, int64, error) {
+	cond := opts.toConds()
+
+	count, err := x.Where(cond).Count(new(ActionRun))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess := x.Where(cond).Desc("id")
+	if opts.PageSize > 0 {
+		if opts.Page <= 0 {
+			opts.Page = 1
+		}
+		sess.Limit(opts.PageSize, (opts.Page-1)*opts.PageSize)
+	}
+	runs := make([]*ActionRun, 0, opts.PageSize)
+	return runs, count, sess.Find(&runs)
+}
+
+// GetActionRunJobByID returns the job with the given id
+func GetActionRunJobByID(id int64) (*ActionRunJob, error) {
+	job := new(ActionRunJob)
+	has, err := x.ID(id).Get(job)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrActionRunJobNotExist{ID: id}
+	}
+	return job, nil
+}
+
+// GetActionRunJobs returns the jobs of the run
+func GetActionRunJobs(runID int64) ([]*ActionRunJob, error) {
+	jobs := make([]*ActionRunJob, 0, 5)
+	return jobs, x.Where("run_id = ?", runID).Asc("id").Find(&jobs)
+}
+
+// PickActionRunJob assigns the oldest waiting job the runner is able to execute to the runner.
+// It returns nil if there is no such job.
+func PickActionRunJob(runner *ActionRunner) (*ActionRunJob, error) {
+	const batchSize = 50
+	for start := 0; ; start += batchSize {
+		jobs := make([]*ActionRunJob, 0, batchSize)
+		if err := x.Where("status = ?", ActionStatusWaiting).
+			Asc("id").
+			Limit(batchSize, start).
+			Find(&jobs); err != nil {
+			return nil, err
+		}
+
+		for _, job := range jobs {
+			if !runner.CanRun(job.RunsOn) {
+				continue
+			}
+			picked, err := assignActionRunJob(job, runner)
+			if err != nil {
+				return nil, err
+			} else if picked {
+				return job, nil
+			}
+		}
+
+		if len(jobs) < batchSize {
+			return nil, nil
+		}
+	}
+}
+
+// assignActionRunJob tries to assign the job to the runner. It returns false if
+// another runner has picked the job in the meantime.
+func assignActionRunJob(job *ActionRunJob, runner *ActionRunner) (bool, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return false, err
+	}
+
+	job.Status = ActionStatusRunning
+	job.RunnerID = runner.ID
+	job.StartedUnix = timeutil.TimeStampNow()
+	affected, err := sess.
+		Where("id = ? AND status = ?", job.ID, ActionStatusWaiting).
+		Cols("status", "runner_id", "started_unix").
+		Update(job)
+	if err != nil {
+		return false, err
+	} else if affected != 1 {
+		return false, nil
+	}
+
+	if _, err := sess.
+		Where("id = ? AND status = ?", job.RunID, ActionStatusWaiting).
+		Cols("status", "started_unix").
+		Update(&ActionRun{Status: ActionStatusRunning, StartedUnix: job.StartedUnix}); err != nil {
+		return false, err
+	}
+	return true, sess.Commit()
+}
+
+// FinishActionRunJob stores the final status of the job and updates the status of its run
+func FinishActionRunJob(job *ActionRunJob, status ActionStatus) error {
+	if !status.IsDone() {
+		return fmt.Errorf("invalid final status: %v", status)
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	job.Status = status
+	job.StoppedUnix = timeutil.TimeStampNow()
+	if job.StartedUnix == 0 {
+		job.StartedUnix = job.StoppedUnix
+	}
+	if _, err := sess.ID(job.ID).Cols("status", "started_unix", "stopped_unix").Update(job); err != nil {
+		return err
+	}
+
+	if err := updateActionRunStatus(sess, job.RunID); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// updateActionRunStatus derives the status of the run from the statuses of its jobs
+func updateActionRunStatus(e Engine, runID int64) error {
+	jobs := make([]*ActionRunJob, 0, 5)
+	if err := e.Where("run_id = ?", runID).Find(&jobs); err != nil {
+		return err
+	}
+
+	run := new(ActionRun)
+	if has, err := e.ID(runID).Get(run); err != nil {
+		return err
+	} else if !has {
+		return ErrActionRunNotExist{ID: runID}
+	}
+
+	run.Status = aggregateActionStatus(jobs)
+	if run.Status.IsDone() {
+		run.StoppedUnix = timeutil.TimeStampNow()
+		if run.StartedUnix == 0 {
+			run.StartedUnix = run.StoppedUnix
+		}
+	}
+	_, err := e.ID(runID).Cols("status", "started_unix", "stopped_unix").Update(run)
+	return err
+}
+
+func aggregateActionStatus(jobs []*ActionRunJob) ActionStatus {
+	var waiting, done, failed, cancelled int
+	for _, job := range jobs {
+		switch job.Status {
+		case ActionStatusWaiting:
+			waiting++
+		case ActionStatusFailure:
+			failed++
+			done++
+		case ActionStatusCancelled:
+			cancelled++
+			done++
+		case ActionStatusSuccess:
+			done++
+		}
+	}
+
+	switch {
+	case done < len(jobs) && waiting == len(jobs):
+		return ActionStatusWaiting
+	case done < len(jobs):
+		return ActionStatusRunning
+	case failed > 0:
+		return ActionStatusFailure
+	case cancelled > 0:
+		return ActionStatusCancelled
+	default:
+		return ActionStatusSuccess
+	}
+}
+
+// CancelActionRun cancels all unfinished jobs of the run and returns them
+func CancelActionRun(run *ActionRun) ([]*ActionRunJob, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	jobs := make([]*ActionRunJob, 0, 5)
+	if err := sess.Where("run_id = ?", run.ID).
+		In("status", ActionStatusWaiting, ActionStatusRunning).
+		Find(&jobs); err != nil {
+		return nil, err
+	}
+
+	now := timeutil.TimeStampNow()
+	for _, job := range jobs {
+		job.Status = ActionStatusCancelled
+		job.StoppedUnix = now
+		if job.StartedUnix == 0 {
+			job.StartedUnix = now
+		}
+		if _, err := sess.ID(job.ID).Cols("status", "started_unix", "stopped_unix").Update(job); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := updateActionRunStatus(sess, run.ID); err != nil {
+		return nil, err
+	}
+	return jobs, sess.Commit()
+}
+
+func deleteActionRunsOfRepo(e Engine, repoID int64) error {
+	return deleteBeans(e,
+		&ActionRunJob{RepoID: repoID},
+		&ActionRun{RepoID: repoID},
+	)
+}
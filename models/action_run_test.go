@@ -0,0 +1,140 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestActionRun(t *testing.T, repoID int64, jobLabels ...[]string) (*ActionRun, []*ActionRunJob) {
+	run := &ActionRun{
+		RepoID:        repoID,
+		Title:         "CI",
+		WorkflowID:    "ci.yml",
+		TriggerUserID: 2,
+		Event:         "push",
+		Ref:           "refs/heads/master",
+		CommitSHA:     "65f1bf27bc3bf70f64657658635e66094edbcb4d",
+	}
+	jobs := make([]*ActionRunJob, 0, len(jobLabels))
+	for _, labels := range jobLabels {
+		jobs = append(jobs, &ActionRunJob{
+			JobID:  "build",
+			Name:   "build",
+			RunsOn: labels,
+		})
+	}
+	assert.NoError(t, CreateActionRun(run, jobs))
+	return run, jobs
+}
+
+func TestCreateActionRun(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	run1, jobs := createTestActionRun(t, 1, []string{"linux"}, []string{"windows"})
+	run2, _ := createTestActionRun(t, 1, []string{"linux"})
+
+	assert.EqualValues(t, 1, run1.Index)
+	assert.EqualValues(t, 2, run2.Index)
+	assert.Equal(t, ActionStatusWaiting, run1.Status)
+	assert.Len(t, jobs, 2)
+
+	run, err := GetActionRunByIndex(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, run2.ID, run.ID)
+
+	_, err = GetActionRunByIndex(2, 1)
+	assert.True(t, IsErrActionRunNotExist(err))
+
+	runs, count, err := FindActionRuns(&FindActionRunOptions{RepoID: 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	assert.Len(t, runs, 2)
+	assert.Equal(t, run2.ID, runs[0].ID)
+}
+
+func TestPickAndFinishActionRunJob(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	run, _ := createTestActionRun(t, 1, []string{"linux"}, []string{"windows"})
+
+	runner := &ActionRunner{Name: "runner", Labels: []string{"linux", "docker"}}
+	assert.NoError(t, NewActionRunner(runner))
+	assert.NotEmpty(t, runner.Token)
+
+	r, err := GetActionRunnerByToken(runner.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, runner.ID, r.ID)
+
+	_, err = GetActionRunnerByToken("0123456789")
+	assert.True(t, IsErrActionRunnerNotExist(err))
+
+	job, err := PickActionRunJob(runner)
+	assert.NoError(t, err)
+	assert.NotNil(t, job)
+	assert.Equal(t, []string{"linux"}, job.RunsOn)
+	assert.Equal(t, ActionStatusRunning, job.Status)
+	assert.Equal(t, runner.ID, job.RunnerID)
+
+	run = AssertExistsAndLoadBean(t, &ActionRun{ID: run.ID}).(*ActionRun)
+	assert.Equal(t, ActionStatusRunning, run.Status)
+
+	// The windows job can not be run by the runner
+	job2, err := PickActionRunJob(runner)
+	assert.NoError(t, err)
+	assert.Nil(t, job2)
+
+	assert.NoError(t, FinishActionRunJob(job, ActionStatusSuccess))
+	run = AssertExistsAndLoadBean(t, &ActionRun{ID: run.ID}).(*ActionRun)
+	assert.Equal(t, ActionStatusRunning, run.Status)
+
+	cancelled, err := CancelActionRun(run)
+	assert.NoError(t, err)
+	assert.Len(t, cancelled, 1)
+	assert.Equal(t, []string{"windows"}, cancelled[0].RunsOn)
+
+	run = AssertExistsAndLoadBean(t, &ActionRun{ID: run.ID}).(*ActionRun)
+	assert.Equal(t, ActionStatusCancelled, run.Status)
+	assert.NotZero(t, run.StoppedUnix)
+}
+
+func TestDeleteActionRunner(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	createTestActionRun(t, 1, nil)
+
+	runner := &ActionRunner{Name: "runner"}
+	assert.NoError(t, NewActionRunner(runner))
+
+	job, err := PickActionRunJob(runner)
+	assert.NoError(t, err)
+	assert.NotNil(t, job)
+
+	assert.NoError(t, DeleteActionRunner(runner.ID))
+	AssertNotExistsBean(t, &ActionRunner{ID: runner.ID})
+
+	job = AssertExistsAndLoadBean(t, &ActionRunJob{ID: job.ID}).(*ActionRunJob)
+	assert.Equal(t, ActionStatusWaiting, job.Status)
+	assert.Zero(t, job.RunnerID)
+}
+
+func TestAggregateActionStatus(t *testing.T) {
+	jobs := func(statuses ...ActionStatus) []*ActionRunJob {
+		res := make([]*ActionRunJob, 0, len(statuses))
+		for _, s := range statuses {
+			res = append(res, &ActionRunJob{Status: s})
+		}
+		return res
+	}
+
+	assert.Equal(t, ActionStatusWaiting, aggregateActionStatus(jobs(ActionStatusWaiting, ActionStatusWaiting)))
+	assert.Equal(t, ActionStatusRunning, aggregateActionStatus(jobs(ActionStatusWaiting, ActionStatusSuccess)))
+	assert.Equal(t, ActionStatusRunning, aggregateActionStatus(jobs(ActionStatusRunning, ActionStatusFailure)))
+	assert.Equal(t, ActionStatusSuccess, aggregateActionStatus(jobs(ActionStatusSuccess, ActionStatusSuccess)))
+	assert.Equal(t, ActionStatusFailure, aggregateActionStatus(jobs(ActionStatusCancelled, ActionStatusFailure)))
+	assert.Equal(t, ActionStatusCancelled, aggregateActionStatus(jobs(ActionStatusCancelled, ActionStatusSuccess)))
+}
@@ -0,0 +1,147 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	gouuid "github.com/satori/go.uuid"
+)
+
+// ActionRunner represents a runner which executes the jobs of workflows
+type ActionRunner struct {
+	ID             int64    `xorm:"pk autoincr"`
+	UUID           string   `xorm:"uuid CHAR(36) UNIQUE NOT NULL"`
+	Name           string   `xorm:"NOT NULL"`
+	Labels         []string `xorm:"TEXT JSON"`
+	Token          string   `xorm:"-"`
+	TokenHash      string   `xorm:"UNIQUE"`
+	TokenSalt      string
+	TokenLastEight string             `xorm:"INDEX token_last_eight"`
+	LastOnlineUnix timeutil.TimeStamp `xorm:"INDEX"`
+	CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// IsOnline returns true if the runner has asked for jobs recently
+func (r *ActionRunner) IsOnline() bool {
+	return r.LastOnlineUnix.AddDuration(time.Minute) > timeutil.TimeStampNow()
+}
+
+// CanRun tests if the runner has all labels required by a job
+func (r *ActionRunner) CanRun(labels []string) bool {
+	for _, required := range labels {
+		found := false
+		for _, label := range r.Labels {
+			if label == required {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrActionRunnerNotExist represents a "ActionRunnerNotExist" kind of error.
+type ErrActionRunnerNotExist struct {
+	ID int64
+}
+
+// IsErrActionRunnerNotExist checks if an error is a ErrActionRunnerNotExist.
+func IsErrActionRunnerNotExist(err error) bool {
+	_, ok := err.(ErrActionRunnerNotExist)
+	return ok
+}
+
+func (err ErrActionRunnerNotExist) Error() string {
+	return fmt.Sprintf("runner does not exist [id: %d]", err.ID)
+}
+
+// NewActionRunner creates a new runner and generates its token
+func NewActionRunner(r *ActionRunner) error {
+	salt, err := generate.GetRandomString(10)
+	if err != nil {
+		return err
+	}
+	r.UUID = gouuid.NewV4().String()
+	r.TokenSalt = salt
+	r.Token = base.EncodeSha1(gouuid.NewV4().String())
+	r.TokenHash = hashToken(r.Token, r.TokenSalt)
+	r.TokenLastEight = r.Token[len(r.Token)-8:]
+	_, err = x.Insert(r)
+	return err
+}
+
+// GetActionRunnerByID returns the runner with the given id
+func GetActionRunnerByID(id int64) (*ActionRunner, error) {
+	r := new(ActionRunner)
+	has, err := x.ID(id).Get(r)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrActionRunnerNotExist{ID: id}
+	}
+	return r, nil
+}
+
+// GetActionRunnerByToken returns the runner which owns the token
+func GetActionRunnerByToken(token string) (*ActionRunner, error) {
+	if len(token) < 8 {
+		return nil, ErrActionRunnerNotExist{}
+	}
+
+	var runners []*ActionRunner
+	if err := x.Where("token_last_eight = ?", token[len(token)-8:]).Find(&runners); err != nil {
+		return nil, err
+	}
+	for _, r := range runners {
+		if subtle.ConstantTimeCompare([]byte(r.TokenHash), []byte(hashToken(token, r.TokenSalt))) == 1 {
+			return r, nil
+		}
+	}
+	return nil, ErrActionRunnerNotExist{}
+}
+
+// GetActionRunners returns all runners
+func GetActionRunners() ([]*ActionRunner, error) {
+	runners := make([]*ActionRunner, 0, 10)
+	return runners, x.Asc("id").Find(&runners)
+}
+
+// UpdateActionRunnerLastOnline marks the runner as online
+func UpdateActionRunnerLastOnline(r *ActionRunner) error {
+	r.LastOnlineUnix = timeutil.TimeStampNow()
+	_, err := x.ID(r.ID).Cols("last_online_unix").Update(r)
+	return err
+}
+
+// DeleteActionRunner removes the runner. Jobs which are still running on it are
+// returned to the queue.
+func DeleteActionRunner(id int64) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.ID(id).Delete(new(ActionRunner)); err != nil {
+		return err
+	}
+	if _, err := sess.
+		Where("runner_id = ? AND status = ?", id, ActionStatusRunning).
+		Cols("runner_id", "status", "started_unix").
+		Update(&ActionRunJob{Status: ActionStatusWaiting}); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
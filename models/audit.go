@@ -0,0 +1,211 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// AuditAction represents the kind of a security relevant action
+type AuditAction string
+
+// Audited actions
+const (
+	AuditActionUserLogin       AuditAction = "user_login"
+	AuditActionUserLoginFailed AuditAction = "user_login_failed"
+	AuditActionUserSiteAdmin   AuditAction = "user_site_admin"
+
+	AuditActionAccessTokenCreate AuditAction = "access_token_create"
+	AuditActionAccessTokenDelete AuditAction = "access_token_delete"
+	AuditActionPublicKeyAdd      AuditAction = "public_key_add"
+	AuditActionPublicKeyDelete   AuditAction = "public_key_delete"
+	AuditActionGPGKeyAdd         AuditAction = "gpg_key_add"
+	AuditActionGPGKeyDelete      AuditAction = "gpg_key_delete"
+	AuditActionDeployKeyAdd      AuditAction = "deploy_key_add"
+	AuditActionDeployKeyDelete   AuditAction = "deploy_key_delete"
+	AuditActionTwoFactorEnable   AuditAction = "two_factor_enable"
+	AuditActionTwoFactorDisable  AuditAction = "two_factor_disable"
+
+	AuditActionRepoCollaboratorAdd    AuditAction = "repo_collaborator_add"
+	AuditActionRepoCollaboratorAccess AuditAction = "repo_collaborator_access"
+	AuditActionRepoCollaboratorRemove AuditAction = "repo_collaborator_remove"
+	AuditActionRepoTeamAdd            AuditAction = "repo_team_add"
+	AuditActionRepoTeamRemove         AuditAction = "repo_team_remove"
+	AuditActionTeamMemberAdd          AuditAction = "team_member_add"
+	AuditActionTeamMemberRemove       AuditAction = "team_member_remove"
+	AuditActionTeamUpdate             AuditAction = "team_update"
+	AuditActionTeamDelete             AuditAction = "team_delete"
+	AuditActionOrgMemberRemove        AuditAction = "org_member_remove"
+
+	AuditActionProtectedBranchUpdate AuditAction = "protected_branch_update"
+	AuditActionProtectedBranchRemove AuditAction = "protected_branch_remove"
+
+	AuditActionRepoTransfer   AuditAction = "repo_transfer"
+	AuditActionRepoDelete     AuditAction = "repo_delete"
+	AuditActionRepoVisibility AuditAction = "repo_visibility"
+)
+
+// AllAuditActions contains all audited actions
+var AllAuditActions = []AuditAction{
+	AuditActionUserLogin,
+	AuditActionUserLoginFailed,
+	AuditActionUserSiteAdmin,
+	AuditActionAccessTokenCreate,
+	AuditActionAccessTokenDelete,
+	AuditActionPublicKeyAdd,
+	AuditActionPublicKeyDelete,
+	AuditActionGPGKeyAdd,
+	AuditActionGPGKeyDelete,
+	AuditActionDeployKeyAdd,
+	AuditActionDeployKeyDelete,
+	AuditActionTwoFactorEnable,
+	AuditActionTwoFactorDisable,
+	AuditActionRepoCollaboratorAdd,
+	AuditActionRepoCollaboratorAccess,
+	AuditActionRepoCollaboratorRemove,
+	AuditActionRepoTeamAdd,
+	AuditActionRepoTeamRemove,
+	AuditActionTeamMemberAdd,
+	AuditActionTeamMemberRemove,
+	AuditActionTeamUpdate,
+	AuditActionTeamDelete,
+	AuditActionOrgMemberRemove,
+	AuditActionProtectedBranchUpdate,
+	AuditActionProtectedBranchRemove,
+	AuditActionRepoTransfer,
+	AuditActionRepoDelete,
+	AuditActionRepoVisibility,
+}
+
+// AuditTargetType represents the kind of object an audited action is applied to
+type AuditTargetType string
+
+// Audit target types
+const (
+	AuditTargetUser       AuditTargetType = "user"
+	AuditTargetRepository AuditTargetType = "repository"
+	AuditTargetTeam       AuditTargetType = "team"
+)
+
+// AllAuditTargetTypes contains all audit target types
+var AllAuditTargetTypes = []AuditTargetType{
+	AuditTargetUser,
+	AuditTargetRepository,
+	AuditTargetTeam,
+}
+
+// AuditEvent represents a security relevant action. The names of the actor and the target
+// are stored as well, so the event stays meaningful after they have been renamed or deleted.
+// Audit events are never updated, they are only deleted after the retention period.
+type AuditEvent struct {
+	ID          int64           `xorm:"pk autoincr"`
+	Action      AuditAction     `xorm:"INDEX NOT NULL"`
+	ActorID     int64           `xorm:"INDEX"`
+	ActorName   string          `xorm:"INDEX"`
+	IPAddress   string          `xorm:"ip_address"`
+	TargetType  AuditTargetType `xorm:"INDEX"`
+	TargetID    int64           `xorm:"INDEX"`
+	TargetName  string
+	Description string             `xorm:"TEXT"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// TrStr returns the locale key of the action
+func (e *AuditEvent) TrStr() string {
+	return "admin.audit.action." + string(e.Action)
+}
+
+// CreateAuditEvent stores the audit event
+func CreateAuditEvent(e *AuditEvent) error {
+	_, err := x.Insert(e)
+	return err
+}
+
+// SearchAuditEventsOptions contains the filters to search audit events
+type SearchAuditEventsOptions struct {
+	Action     AuditAction
+	ActorName  string
+	TargetType AuditTargetType
+	TargetName string
+	Since      timeutil.TimeStamp
+	Before     timeutil.TimeStamp
+	Page       int
+	PageSize   int
+}
+
+func (opts *SearchAuditEventsOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	if len(opts.Action) > 0 {
+		cond = cond.And(builder.Eq{"action": opts.Action})
+	}
+	if len(opts.ActorName) > 0 {
+		cond = cond.And(builder.Eq{"actor_name": opts.ActorName})
+	}
+	if len(opts.TargetType) > 0 {
+		cond = cond.And(builder.Eq{"target_type": opts.TargetType})
+	}
+	if len(opts.TargetName) > 0 {
+		cond = cond.And(builder.Like{"LOWER(target_name)", strings.ToLower(opts.TargetName)})
+	}
+	if opts.Since > 0 {
+		cond = cond.And(builder.Gte{"created_unix": opts.Since})
+	}
+	if opts.Before > 0 {
+		cond = cond.And(builder.Lt{"created_unix": opts.Before})
+	}
+	return cond
+}
+
+// SearchAuditEvents returns the audit events matching the options, the newest first, and the total count
+func SearchAuditEvents(opts *SearchAuditEventsOptions) ([]*AuditEvent, int64, error) {
+	cond := opts.toConds()
+	count, err := x.Where(cond).Count(new(AuditEvent))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = setting.UI.Admin.NoticePagingNum
+	}
+	events := make([]*AuditEvent, 0, opts.PageSize)
+	return events, count, x.Where(cond).
+		Desc("id").
+		Limit(opts.PageSize, (opts.Page-1)*opts.PageSize).
+		Find(&events)
+}
+
+// IterateAuditEvents calls f for every audit event matching the options, the oldest first
+func IterateAuditEvents(opts *SearchAuditEventsOptions, f func(*AuditEvent) error) error {
+	return x.Where(opts.toConds()).
+		Asc("id").
+		Iterate(new(AuditEvent), func(idx int, bean interface{}) error {
+			return f(bean.(*AuditEvent))
+		})
+}
+
+// DeleteOldAuditEvents deletes the audit events older than the retention period
+func DeleteOldAuditEvents(ctx context.Context) {
+	log.Trace("Doing: DeleteOldAuditEvents")
+
+	olderThan := timeutil.TimeStamp(time.Now().Add(-setting.Cron.AuditLogCleanup.OlderThan).Unix())
+	deleted, err := x.Where("created_unix < ?", olderThan).Delete(new(AuditEvent))
+	if err != nil {
+		log.Error("DeleteOldAuditEvents: %v", err)
+		return
+	}
+
+	log.Trace("Finished: DeleteOldAuditEvents: %d audit events deleted", deleted)
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditEvent_TrStr(t *testing.T) {
+	event := &AuditEvent{Action: AuditActionRepoDelete}
+	assert.Equal(t, "admin.audit.action.repo_delete", event.TrStr())
+}
+
+func TestSearchAuditEvents(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, CreateAuditEvent(&AuditEvent{
+		Action:     AuditActionUserLogin,
+		ActorID:    2,
+		ActorName:  "user2",
+		IPAddress:  "127.0.0.1",
+		TargetType: AuditTargetUser,
+		TargetID:   2,
+		TargetName: "user2",
+	}))
+	assert.NoError(t, CreateAuditEvent(&AuditEvent{
+		Action:     AuditActionRepoDelete,
+		ActorID:    1,
+		ActorName:  "user1",
+		TargetType: AuditTargetRepository,
+		TargetID:   1,
+		TargetName: "user2/repo1",
+	}))
+
+	events, count, err := SearchAuditEvents(&SearchAuditEventsOptions{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	if assert.Len(t, events, 2) {
+		// the newest event comes first
+		assert.Equal(t, AuditActionRepoDelete, events[0].Action)
+		assert.Equal(t, AuditActionUserLogin, events[1].Action)
+	}
+
+	events, count, err = SearchAuditEvents(&SearchAuditEventsOptions{ActorName: "user2"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "127.0.0.1", events[0].IPAddress)
+	}
+
+	_, count, err = SearchAuditEvents(&SearchAuditEventsOptions{
+		TargetType: AuditTargetRepository,
+		TargetName: "User2/Repo1",
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+
+	_, count, err = SearchAuditEvents(&SearchAuditEventsOptions{Since: timeutil.TimeStampNow().Add(3600)})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+
+	var actions []AuditAction
+	assert.NoError(t, IterateAuditEvents(&SearchAuditEventsOptions{}, func(e *AuditEvent) error {
+		actions = append(actions, e.Action)
+		return nil
+	}))
+	assert.Equal(t, []AuditAction{AuditActionUserLogin, AuditActionRepoDelete}, actions)
+}
+
+func TestDeleteOldAuditEvents(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	old := &AuditEvent{
+		Action:      AuditActionUserLogin,
+		ActorName:   "user2",
+		CreatedUnix: timeutil.TimeStamp(time.Now().Add(-366 * 24 * time.Hour).Unix()),
+	}
+	_, err := x.NoAutoTime().Insert(old)
+	assert.NoError(t, err)
+	recent := &AuditEvent{Action: AuditActionUserLogin, ActorName: "user2"}
+	assert.NoError(t, CreateAuditEvent(recent))
+
+	DeleteOldAuditEvents(context.Background())
+
+	AssertNotExistsBean(t, &AuditEvent{ID: old.ID})
+	AssertExistsAndLoadBean(t, &AuditEvent{ID: recent.ID})
+}
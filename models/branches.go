@@ -46,6 +46,8 @@ type ProtectedBranch struct {
 	RequiredApprovals         int64    `xorm:"NOT NULL DEFAULT 0"`
 	BlockOnRejectedReviews    bool     `xorm:"NOT NULL DEFAULT false"`
 	DismissStaleApprovals     bool     `xorm:"NOT NULL DEFAULT false"`
+	RequireCodeOwnerReviews   bool     `xorm:"NOT NULL DEFAULT false"`
+	EnableMergeQueue          bool     `xorm:"NOT NULL DEFAULT false"`
 
 	CreatedUnix timeutil.TimeStamp `xorm:"created"`
 	UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
@@ -189,6 +191,20 @@ func (protectBranch *ProtectedBranch) MergeBlockedByRejectedReview(pr *PullReque
 	return rejectExist
 }
 
+// MergeBlockedByCodeOwners returns true if merge is blocked because a changed file isn't approved by its code owners
+func (protectBranch *ProtectedBranch) MergeBlockedByCodeOwners(pr *PullRequest) bool {
+	if !protectBranch.RequireCodeOwnerReviews {
+		return false
+	}
+	approved, err := pr.IsApprovedByCodeOwners(protectBranch.DismissStaleApprovals)
+	if err != nil {
+		log.Error("MergeBlockedByCodeOwners: %v", err)
+		return true
+	}
+
+	return !approved
+}
+
 // GetProtectedBranchByRepoID getting protected branch by repo ID
 func GetProtectedBranchByRepoID(repoID int64) ([]*ProtectedBranch, error) {
 	protectedBranches := make([]*ProtectedBranch, 0)
@@ -363,7 +379,7 @@ func (repo *Repository) IsProtectedBranchForMerging(pr *PullRequest, branchName
 	if err != nil {
 		return true, err
 	} else if has {
-		return !protectedBranch.CanUserMerge(doer.ID) || !protectedBranch.HasEnoughApprovals(pr) || protectedBranch.MergeBlockedByRejectedReview(pr), nil
+		return !protectedBranch.CanUserMerge(doer.ID) || !protectedBranch.HasEnoughApprovals(pr) || protectedBranch.MergeBlockedByRejectedReview(pr) || protectedBranch.MergeBlockedByCodeOwners(pr), nil
 	}
 
 	return false, nil
@@ -201,6 +201,21 @@ func (err ErrUserHasOrgs) Error() string {
 	return fmt.Sprintf("user still has membership of organizations [uid: %d]", err.UID)
 }
 
+// ErrUserOwnPackages represents a "UserOwnPackages" kind of error.
+type ErrUserOwnPackages struct {
+	UID int64
+}
+
+// IsErrUserOwnPackages checks if an error is a ErrUserOwnPackages.
+func IsErrUserOwnPackages(err error) bool {
+	_, ok := err.(ErrUserOwnPackages)
+	return ok
+}
+
+func (err ErrUserOwnPackages) Error() string {
+	return fmt.Sprintf("user still has ownership of packages [uid: %d]", err.UID)
+}
+
 // ErrUserNotAllowedCreateOrg represents a "UserNotAllowedCreateOrg" kind of error.
 type ErrUserNotAllowedCreateOrg struct {
 }
@@ -304,6 +319,22 @@ func (err ErrKeyUnableVerify) Error() string {
 	return fmt.Sprintf("Unable to verify key content [result: %s]", err.Result)
 }
 
+// ErrSSHCertificateNotTrusted represents a "SSHCertificateNotTrusted" kind of error.
+type ErrSSHCertificateNotTrusted struct {
+	KeyID  string
+	Reason string
+}
+
+// IsErrSSHCertificateNotTrusted checks if an error is a ErrSSHCertificateNotTrusted.
+func IsErrSSHCertificateNotTrusted(err error) bool {
+	_, ok := err.(ErrSSHCertificateNotTrusted)
+	return ok
+}
+
+func (err ErrSSHCertificateNotTrusted) Error() string {
+	return fmt.Sprintf("SSH certificate is not trusted [key_id: %s, reason: %s]", err.KeyID, err.Reason)
+}
+
 // ErrKeyNotExist represents a "KeyNotExist" kind of error.
 type ErrKeyNotExist struct {
 	ID int64
@@ -549,6 +580,36 @@ func (err ErrAccessTokenEmpty) Error() string {
 	return fmt.Sprintf("access token is empty")
 }
 
+// ErrAccessTokenExpired represents a "AccessTokenExpired" kind of error.
+type ErrAccessTokenExpired struct {
+	ID int64
+}
+
+// IsErrAccessTokenExpired checks if an error is a ErrAccessTokenExpired.
+func IsErrAccessTokenExpired(err error) bool {
+	_, ok := err.(ErrAccessTokenExpired)
+	return ok
+}
+
+func (err ErrAccessTokenExpired) Error() string {
+	return fmt.Sprintf("access token has expired [id: %d]", err.ID)
+}
+
+// ErrInvalidAccessTokenScope represents a "InvalidAccessTokenScope" kind of error.
+type ErrInvalidAccessTokenScope struct {
+	Scope string
+}
+
+// IsErrInvalidAccessTokenScope checks if an error is a ErrInvalidAccessTokenScope.
+func IsErrInvalidAccessTokenScope(err error) bool {
+	_, ok := err.(ErrInvalidAccessTokenScope)
+	return ok
+}
+
+func (err ErrInvalidAccessTokenScope) Error() string {
+	return fmt.Sprintf("invalid access token scope [scope: %s]", err.Scope)
+}
+
 // ________                            .__                __  .__
 // \_____  \_______  _________    ____ |__|____________ _/  |_|__| ____   ____
 //  /   |   \_  __ \/ ___\__  \  /    \|  \___   /\__  \\   __\  |/  _ \ /    \
@@ -1060,6 +1121,22 @@ func (err ErrSHAOrCommitIDNotProvided) Error() string {
 	return fmt.Sprintf("a SHA or commmit ID must be proved when updating a file")
 }
 
+// ErrSuggestionNotApplicable represents a "SuggestionNotApplicable" kind of error.
+type ErrSuggestionNotApplicable struct {
+	CommentID int64
+	Reason    string
+}
+
+// IsErrSuggestionNotApplicable checks if an error is a ErrSuggestionNotApplicable.
+func IsErrSuggestionNotApplicable(err error) bool {
+	_, ok := err.(ErrSuggestionNotApplicable)
+	return ok
+}
+
+func (err ErrSuggestionNotApplicable) Error() string {
+	return fmt.Sprintf("suggestion can not be applied [comment_id: %d, reason: %s]", err.CommentID, err.Reason)
+}
+
 //  __      __      ___.   .__                   __
 // /  \    /  \ ____\_ |__ |  |__   ____   ____ |  | __
 // \   \/\/   // __ \| __ \|  |  \ /  _ \ /  _ \|  |/ /
@@ -1082,6 +1159,22 @@ func (err ErrWebhookNotExist) Error() string {
 	return fmt.Sprintf("webhook does not exist [id: %d]", err.ID)
 }
 
+// ErrHookTaskNotExist represents a "HookTaskNotExist" kind of error.
+type ErrHookTaskNotExist struct {
+	ID     int64
+	HookID int64
+}
+
+// IsErrHookTaskNotExist checks if an error is a ErrHookTaskNotExist.
+func IsErrHookTaskNotExist(err error) bool {
+	_, ok := err.(ErrHookTaskNotExist)
+	return ok
+}
+
+func (err ErrHookTaskNotExist) Error() string {
+	return fmt.Sprintf("hook task does not exist [id: %d, hook_id: %d]", err.ID, err.HookID)
+}
+
 // .___
 // |   | ______ ________ __   ____
 // |   |/  ___//  ___/  |  \_/ __ \
@@ -1266,6 +1359,53 @@ func (err ErrPullRequestAlreadyExists) Error() string {
 		err.ID, err.IssueID, err.HeadRepoID, err.BaseRepoID, err.HeadBranch, err.BaseBranch)
 }
 
+// ErrPullRequestAlreadyScheduledToAutoMerge represents a "PullRequestAlreadyScheduledToAutoMerge"-error
+type ErrPullRequestAlreadyScheduledToAutoMerge struct {
+	PullID int64
+}
+
+// IsErrPullRequestAlreadyScheduledToAutoMerge checks if an error is a ErrPullRequestAlreadyScheduledToAutoMerge.
+func IsErrPullRequestAlreadyScheduledToAutoMerge(err error) bool {
+	_, ok := err.(ErrPullRequestAlreadyScheduledToAutoMerge)
+	return ok
+}
+
+// Error does pretty-printing :D
+func (err ErrPullRequestAlreadyScheduledToAutoMerge) Error() string {
+	return fmt.Sprintf("pull request is already scheduled to auto merge when checks succeed [pull_id: %d]", err.PullID)
+}
+
+// ErrPullRequestAlreadyInMergeQueue represents a "PullRequestAlreadyInMergeQueue"-error
+type ErrPullRequestAlreadyInMergeQueue struct {
+	PullID int64
+}
+
+// IsErrPullRequestAlreadyInMergeQueue checks if an error is a ErrPullRequestAlreadyInMergeQueue.
+func IsErrPullRequestAlreadyInMergeQueue(err error) bool {
+	_, ok := err.(ErrPullRequestAlreadyInMergeQueue)
+	return ok
+}
+
+func (err ErrPullRequestAlreadyInMergeQueue) Error() string {
+	return fmt.Sprintf("pull request is already in the merge queue [pull_id: %d]", err.PullID)
+}
+
+// ErrMergeQueueNotEnabled represents a "MergeQueueNotEnabled"-error
+type ErrMergeQueueNotEnabled struct {
+	RepoID int64
+	Branch string
+}
+
+// IsErrMergeQueueNotEnabled checks if an error is a ErrMergeQueueNotEnabled.
+func IsErrMergeQueueNotEnabled(err error) bool {
+	_, ok := err.(ErrMergeQueueNotEnabled)
+	return ok
+}
+
+func (err ErrMergeQueueNotEnabled) Error() string {
+	return fmt.Sprintf("merge queue is not enabled for the branch [repo_id: %d, branch: %s]", err.RepoID, err.Branch)
+}
+
 // ErrPullRequestHeadRepoMissing represents a "ErrPullRequestHeadRepoMissing" error
 type ErrPullRequestHeadRepoMissing struct {
 	ID         int64
@@ -1486,6 +1626,22 @@ func (err ErrLabelNotExist) Error() string {
 	return fmt.Sprintf("label does not exist [label_id: %d, repo_id: %d]", err.LabelID, err.RepoID)
 }
 
+// ErrOrgLabelNotExist represents a "OrgLabelNotExist" kind of error.
+type ErrOrgLabelNotExist struct {
+	LabelID int64
+	OrgID   int64
+}
+
+// IsErrOrgLabelNotExist checks if an error is a ErrOrgLabelNotExist.
+func IsErrOrgLabelNotExist(err error) bool {
+	_, ok := err.(ErrOrgLabelNotExist)
+	return ok
+}
+
+func (err ErrOrgLabelNotExist) Error() string {
+	return fmt.Sprintf("label does not exist [label_id: %d, org_id: %d]", err.LabelID, err.OrgID)
+}
+
 //    _____  .__.__                   __
 //   /     \ |__|  |   ____   _______/  |_  ____   ____   ____
 //  /  \ /  \|  |  | _/ __ \ /  ___/\   __\/  _ \ /    \_/ __ \
@@ -1714,18 +1870,18 @@ func (err ErrExternalLoginUserNotExist) Error() string {
 // |______/ \_______ \___  /    |____|_  /\___  >___  /|__/____  > |__|  |__|  (____  /__| |__|\____/|___|  /
 // \/   \/            \/     \/_____/         \/                   \/                    \/
 
-// ErrU2FRegistrationNotExist represents a "ErrU2FRegistrationNotExist" kind of error.
-type ErrU2FRegistrationNotExist struct {
+// ErrWebAuthnCredentialNotExist represents a "ErrWebAuthnCredentialNotExist" kind of error.
+type ErrWebAuthnCredentialNotExist struct {
 	ID int64
 }
 
-func (err ErrU2FRegistrationNotExist) Error() string {
-	return fmt.Sprintf("U2F registration does not exist [id: %d]", err.ID)
+func (err ErrWebAuthnCredentialNotExist) Error() string {
+	return fmt.Sprintf("WebAuthn credential does not exist [id: %d]", err.ID)
 }
 
-// IsErrU2FRegistrationNotExist checks if an error is a ErrU2FRegistrationNotExist.
-func IsErrU2FRegistrationNotExist(err error) bool {
-	_, ok := err.(ErrU2FRegistrationNotExist)
+// IsErrWebAuthnCredentialNotExist checks if an error is a ErrWebAuthnCredentialNotExist.
+func IsErrWebAuthnCredentialNotExist(err error) bool {
+	_, ok := err.(ErrWebAuthnCredentialNotExist)
 	return ok
 }
 
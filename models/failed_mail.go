@@ -0,0 +1,63 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// FailedMail represents a mail which could not be sent after all attempts
+type FailedMail struct {
+	ID         int64  `xorm:"pk autoincr"`
+	Recipients string `xorm:"TEXT"`
+	Subject    string `xorm:"TEXT"`
+	Info       string `xorm:"TEXT"`
+	Attempts   int
+	LastError  string `xorm:"TEXT"`
+	// Content is the serialized message, it is used to send the mail again
+	Content     string             `xorm:"LONGTEXT"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// CreateFailedMail stores a mail which could not be sent
+func CreateFailedMail(mail *FailedMail) error {
+	_, err := x.Insert(mail)
+	return err
+}
+
+// CountFailedMails returns number of failed mails.
+func CountFailedMails() int64 {
+	count, _ := x.Count(new(FailedMail))
+	return count
+}
+
+// FailedMails returns failed mails in given page.
+func FailedMails(page, pageSize int) ([]*FailedMail, error) {
+	mails := make([]*FailedMail, 0, pageSize)
+	return mails, x.
+		Limit(pageSize, (page-1)*pageSize).
+		Desc("id").
+		Find(&mails)
+}
+
+// GetFailedMailsByIDs returns the failed mails with the given IDs.
+func GetFailedMailsByIDs(ids []int64) ([]*FailedMail, error) {
+	mails := make([]*FailedMail, 0, len(ids))
+	if len(ids) == 0 {
+		return mails, nil
+	}
+	return mails, x.In("id", ids).Asc("id").Find(&mails)
+}
+
+// DeleteFailedMailsByIDs deletes failed mails by given IDs.
+func DeleteFailedMailsByIDs(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := x.
+		In("id", ids).
+		Delete(new(FailedMail))
+	return err
+}
@@ -0,0 +1,38 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailedMails(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	for _, subject := range []string{"first", "second", "third"} {
+		assert.NoError(t, CreateFailedMail(&FailedMail{Recipients: "test@gitea.com", Subject: subject, Attempts: 5}))
+	}
+	assert.EqualValues(t, 3, CountFailedMails())
+
+	mails, err := FailedMails(1, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, mails, 2) {
+		assert.Equal(t, "third", mails[0].Subject)
+		assert.Equal(t, "second", mails[1].Subject)
+	}
+
+	mails, err = GetFailedMailsByIDs([]int64{1, 3, 4})
+	assert.NoError(t, err)
+	if assert.Len(t, mails, 2) {
+		assert.Equal(t, "first", mails[0].Subject)
+		assert.Equal(t, "third", mails[1].Subject)
+	}
+
+	assert.NoError(t, DeleteFailedMailsByIDs([]int64{1, 2}))
+	assert.EqualValues(t, 1, CountFailedMails())
+	AssertExistsAndLoadBean(t, &FailedMail{ID: 3})
+}
@@ -87,6 +87,16 @@ const (
 	CommentTypeChangeTargetBranch
 	// Delete time manual for time tracking
 	CommentTypeDeleteTimeManual
+	// Pull request scheduled to be merged when all checks succeed
+	CommentTypePRScheduledToAutoMerge
+	// Scheduled merge of a pull request cancelled
+	CommentTypePRUnScheduledToAutoMerge
+	// Review requested from a user or team
+	CommentTypeReviewRequest
+	// Pull request added to the merge queue of its target branch
+	CommentTypeMergeQueueAdded
+	// Pull request removed from the merge queue of its target branch
+	CommentTypeMergeQueueRemoved
 )
 
 // CommentTag defines comment tag type
@@ -119,6 +129,8 @@ type Comment struct {
 	AssigneeID       int64
 	RemovedAssignee  bool
 	Assignee         *User `xorm:"-"`
+	AssigneeTeamID   int64 `xorm:"NOT NULL DEFAULT 0"`
+	AssigneeTeam     *Team `xorm:"-"`
 	OldTitle         string
 	NewTitle         string
 	OldRef           string
@@ -151,6 +163,9 @@ type Comment struct {
 	ReviewID    int64   `xorm:"index"`
 	Invalidated bool
 
+	// Commit in which the suggestion of a code comment was applied
+	SuggestionCommitSHA string `xorm:"VARCHAR(40)"`
+
 	// Reference an issue or pull from another comment, issue or PR
 	// All information is about the origin of the reference
 	RefRepoID    int64                 `xorm:"index"` // Repo where the referencing
@@ -417,6 +432,22 @@ func (c *Comment) LoadAssigneeUser() error {
 	return nil
 }
 
+// LoadAssigneeTeam if comment.Type is CommentTypeReviewRequest, then load the requested team
+func (c *Comment) LoadAssigneeTeam() error {
+	var err error
+
+	if c.AssigneeTeamID > 0 && c.AssigneeTeam == nil {
+		c.AssigneeTeam, err = getTeamByID(x, c.AssigneeTeamID)
+		if err != nil {
+			if !IsErrTeamNotExist(err) {
+				return err
+			}
+			c.AssigneeTeam = &Team{Name: "Ghost"}
+		}
+	}
+	return nil
+}
+
 // LoadDepIssueDetails loads Dependent Issue Details
 func (c *Comment) LoadDepIssueDetails() (err error) {
 	if c.DependentIssueID <= 0 || c.DependentIssue != nil {
@@ -534,6 +565,7 @@ func createComment(e *xorm.Session, opts *CreateCommentOptions) (_ *Comment, err
 		MilestoneID:      opts.MilestoneID,
 		RemovedAssignee:  opts.RemovedAssignee,
 		AssigneeID:       opts.AssigneeID,
+		AssigneeTeamID:   opts.AssigneeTeamID,
 		CommitID:         opts.CommitID,
 		CommitSHA:        opts.CommitSHA,
 		Line:             opts.LineNum,
@@ -695,6 +727,7 @@ type CreateCommentOptions struct {
 	OldMilestoneID   int64
 	MilestoneID      int64
 	AssigneeID       int64
+	AssigneeTeamID   int64
 	RemovedAssignee  bool
 	OldTitle         string
 	NewTitle         string
@@ -938,7 +971,7 @@ func fetchCodeCommentsByReview(e Engine, issue *Issue, currentUser *User, review
 			comment.Review = re
 		}
 
-		comment.RenderedContent = string(markdown.Render([]byte(comment.Content), issue.Repo.Link(),
+		comment.RenderedContent = string(markdown.Render([]byte(comment.ContentWithSuggestionDiffs()), issue.Repo.Link(),
 			issue.Repo.ComposeMetas()))
 		if pathToLineToComment[comment.TreePath] == nil {
 			pathToLineToComment[comment.TreePath] = make(map[int64][]*Comment)
@@ -0,0 +1,110 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// suggestionBlock matches a fenced suggestion block of a code comment
+var suggestionBlock = regexp.MustCompile("(?m)^```suggestion[ \t]*\r?\n((?s:.*?))^```[ \t]*\r?$")
+
+// Suggestion returns the lines proposed by the first suggestion block of a code
+// comment to replace the commented line.
+func (c *Comment) Suggestion() (string, bool) {
+	if c.Type != CommentTypeCode || c.Line <= 0 {
+		return "", false
+	}
+	m := suggestionBlock.FindStringSubmatch(c.Content)
+	if m == nil {
+		return "", false
+	}
+	return strings.Replace(m[1], "\r\n", "\n", -1), true
+}
+
+// HasSuggestion returns true if the code comment contains a suggestion
+func (c *Comment) HasSuggestion() bool {
+	_, ok := c.Suggestion()
+	return ok
+}
+
+// IsSuggestionApplied returns true if the suggestion of the comment was committed
+func (c *Comment) IsSuggestionApplied() bool {
+	return c.SuggestionCommitSHA != ""
+}
+
+// CanApplySuggestion returns true if the comment has a suggestion which still
+// matches the code of the pull request and was not applied yet
+func (c *Comment) CanApplySuggestion() bool {
+	return !c.Invalidated && !c.IsSuggestionApplied() && c.HasSuggestion()
+}
+
+// IsReviewSubmitted returns true if the loaded review of the comment is not pending anymore,
+// the suggestions of pending reviews are only visible to their poster and can not be applied
+func (c *Comment) IsReviewSubmitted() bool {
+	return c.Review != nil && c.Review.Type != ReviewTypePending
+}
+
+// CommentedLine returns the content of the commented line as it was shown when
+// the comment was made. It is taken from the last line of the patch of the comment.
+func (c *Comment) CommentedLine() (string, bool) {
+	if c.Patch == "" {
+		return "", false
+	}
+	lines := strings.Split(strings.TrimRight(c.Patch, "\n"), "\n")
+	last := strings.TrimSuffix(lines[len(lines)-1], "\r")
+	if len(last) == 0 || (last[0] != '+' && last[0] != ' ') {
+		return "", false
+	}
+	return last[1:], true
+}
+
+// SuggestionLines splits a suggestion into the lines which replace the commented line
+func SuggestionLines(suggestion string) []string {
+	if suggestion == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(suggestion, "\n"), "\n")
+}
+
+// ContentWithSuggestionDiffs returns the content of the comment with its
+// suggestion blocks replaced by diffs against the commented line, ready to be
+// rendered as markdown.
+func (c *Comment) ContentWithSuggestionDiffs() string {
+	if c.Type != CommentTypeCode || c.Line <= 0 {
+		return c.Content
+	}
+	original, hasOriginal := c.CommentedLine()
+	return suggestionBlock.ReplaceAllStringFunc(c.Content, func(block string) string {
+		suggestion := strings.Replace(suggestionBlock.FindStringSubmatch(block)[1], "\r\n", "\n", -1)
+
+		var diff strings.Builder
+		diff.WriteString("```diff\n")
+		if hasOriginal {
+			diff.WriteString("-" + original + "\n")
+		}
+		for _, line := range SuggestionLines(suggestion) {
+			diff.WriteString("+" + line + "\n")
+		}
+		diff.WriteString("```")
+		return diff.String()
+	})
+}
+
+// UpdateCommentsSuggestionCommit records the commit in which the suggestions of
+// the given comments were applied. An empty commitSHA resets the comments.
+func UpdateCommentsSuggestionCommit(comments []*Comment, commitSHA string) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+		comment.SuggestionCommitSHA = commitSHA
+	}
+	_, err := x.In("id", ids).Cols("suggestion_commit_sha").Update(&Comment{SuggestionCommitSHA: commitSHA})
+	return err
+}
@@ -0,0 +1,60 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentSuggestion(t *testing.T) {
+	comment := &Comment{
+		Type:    CommentTypeCode,
+		Line:    4,
+		Content: "Better:\r\n```suggestion\r\nfoo := bar()\r\nbaz(foo)\r\n```\r\n",
+		Patch:   "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,4 @@\n package main\n \n-func main() {}\n+baz(bar())",
+	}
+	suggestion, ok := comment.Suggestion()
+	assert.True(t, ok)
+	assert.Equal(t, "foo := bar()\nbaz(foo)\n", suggestion)
+	assert.Equal(t, []string{"foo := bar()", "baz(foo)"}, SuggestionLines(suggestion))
+	assert.True(t, comment.CanApplySuggestion())
+
+	line, ok := comment.CommentedLine()
+	assert.True(t, ok)
+	assert.Equal(t, "baz(bar())", line)
+	assert.Equal(t, "Better:\r\n```diff\n-baz(bar())\n+foo := bar()\n+baz(foo)\n```\n", comment.ContentWithSuggestionDiffs())
+
+	// an empty suggestion removes the line
+	comment.Content = "```suggestion\n```"
+	suggestion, ok = comment.Suggestion()
+	assert.True(t, ok)
+	assert.Empty(t, SuggestionLines(suggestion))
+	assert.Equal(t, "```diff\n-baz(bar())\n```", comment.ContentWithSuggestionDiffs())
+
+	comment.SuggestionCommitSHA = "65f1bf27bc3bf70f64657658635e66094edbcb4d"
+	assert.False(t, comment.CanApplySuggestion())
+
+	// suggestions are only supported on the proposed side
+	comment = &Comment{Type: CommentTypeCode, Line: -4, Content: "```suggestion\nfoo\n```"}
+	assert.False(t, comment.HasSuggestion())
+	assert.Equal(t, comment.Content, comment.ContentWithSuggestionDiffs())
+
+	comment = &Comment{Type: CommentTypeCode, Line: 4, Content: "```go\nfoo\n```"}
+	assert.False(t, comment.HasSuggestion())
+}
+
+func TestUpdateCommentsSuggestionCommit(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	comment := AssertExistsAndLoadBean(t, &Comment{ID: 4}).(*Comment)
+	assert.NoError(t, UpdateCommentsSuggestionCommit([]*Comment{comment}, "65f1bf27bc3bf70f64657658635e66094edbcb4d"))
+	AssertExistsAndLoadBean(t, &Comment{ID: 4, SuggestionCommitSHA: "65f1bf27bc3bf70f64657658635e66094edbcb4d"})
+
+	assert.NoError(t, UpdateCommentsSuggestionCommit([]*Comment{comment}, ""))
+	comment = AssertExistsAndLoadBean(t, &Comment{ID: 4}).(*Comment)
+	assert.Empty(t, comment.SuggestionCommitSHA)
+}
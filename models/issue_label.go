@@ -59,20 +59,25 @@ func GetLabelTemplateFile(name string) ([][3]string, error) {
 	return list, nil
 }
 
-// Label represents a label of repository for issues.
+// Label represents a label of repository for issues, labels of an organization
+// can be used in all repositories of the organization.
 type Label struct {
 	ID              int64 `xorm:"pk autoincr"`
 	RepoID          int64 `xorm:"INDEX"`
+	OrgID           int64 `xorm:"INDEX"`
 	Name            string
 	Description     string
 	Color           string `xorm:"VARCHAR(7)"`
 	NumIssues       int
 	NumClosedIssues int
-	NumOpenIssues   int    `xorm:"-"`
-	IsChecked       bool   `xorm:"-"`
-	QueryString     string `xorm:"-"`
-	IsSelected      bool   `xorm:"-"`
-	IsExcluded      bool   `xorm:"-"`
+	NumOpenIssues   int `xorm:"-"`
+	// NumRepoIssues and NumOpenRepoIssues count the issues of a single repository with a label of its organization
+	NumRepoIssues     int    `xorm:"-"`
+	NumOpenRepoIssues int    `xorm:"-"`
+	IsChecked         bool   `xorm:"-"`
+	QueryString       string `xorm:"-"`
+	IsSelected        bool   `xorm:"-"`
+	IsExcluded        bool   `xorm:"-"`
 }
 
 // APIFormat converts a Label to the api.Label format
@@ -90,6 +95,24 @@ func (label *Label) CalOpenIssues() {
 	label.NumOpenIssues = label.NumIssues - label.NumClosedIssues
 }
 
+// BelongsToOrg returns true if the label is shared by the repositories of an organization
+func (label *Label) BelongsToOrg() bool {
+	return label.OrgID > 0
+}
+
+// BelongsToRepo returns true if the label belongs to a single repository
+func (label *Label) BelongsToRepo() bool {
+	return label.RepoID > 0
+}
+
+// CanBeUsedInRepo returns true if the label can be added to issues of the repository
+func (label *Label) CanBeUsedInRepo(repo *Repository) bool {
+	if label.BelongsToOrg() {
+		return label.OrgID == repo.OwnerID
+	}
+	return label.RepoID == repo.ID
+}
+
 // LoadSelectedLabelsAfterClick calculates the set of selected labels when a label is clicked
 func (label *Label) LoadSelectedLabelsAfterClick(currentSelectedLabels []int64) {
 	var labelQuerySlice []string
@@ -151,7 +174,7 @@ func LoadLabelsFormatted(labelTemplate string) (string, error) {
 	return strings.Join(labels, ", "), err
 }
 
-func initalizeLabels(e Engine, repoID int64, labelTemplate string) error {
+func initalizeLabels(e Engine, id int64, labelTemplate string, isOrg bool) error {
 	list, err := GetLabelTemplateFile(labelTemplate)
 	if err != nil {
 		return ErrIssueLabelTemplateLoad{labelTemplate, err}
@@ -160,11 +183,15 @@ func initalizeLabels(e Engine, repoID int64, labelTemplate string) error {
 	labels := make([]*Label, len(list))
 	for i := 0; i < len(list); i++ {
 		labels[i] = &Label{
-			RepoID:      repoID,
 			Name:        list[i][0],
 			Description: list[i][2],
 			Color:       list[i][1],
 		}
+		if isOrg {
+			labels[i].OrgID = id
+		} else {
+			labels[i].RepoID = id
+		}
 	}
 	for _, label := range labels {
 		if err = newLabel(e, label); err != nil {
@@ -174,9 +201,9 @@ func initalizeLabels(e Engine, repoID int64, labelTemplate string) error {
 	return nil
 }
 
-// InitalizeLabels adds a label set to a repository using a template
-func InitalizeLabels(repoID int64, labelTemplate string) error {
-	return initalizeLabels(x, repoID, labelTemplate)
+// InitalizeLabels adds a label set to a repository or an organization using a template
+func InitalizeLabels(id int64, labelTemplate string, isOrg bool) error {
+	return initalizeLabels(x, id, labelTemplate, isOrg)
 }
 
 func newLabel(e Engine, label *Label) error {
@@ -184,7 +211,7 @@ func newLabel(e Engine, label *Label) error {
 	return err
 }
 
-// NewLabel creates a new label for a repository
+// NewLabel creates a new label for a repository or an organization
 func NewLabel(label *Label) error {
 	return newLabel(x, label)
 }
@@ -287,6 +314,40 @@ func GetLabelInRepoByID(repoID, labelID int64) (*Label, error) {
 	return getLabelInRepoByID(x, repoID, labelID)
 }
 
+// GetLabelsInRepoOrOrgByIDs returns a list of labels by IDs which can be used in the given
+// repository, that are its own labels and the labels of the organization it belongs to.
+// It silently ignores label IDs that cannot be used in the repository.
+func GetLabelsInRepoOrOrgByIDs(repo *Repository, labelIDs []int64) ([]*Label, error) {
+	labels := make([]*Label, 0, len(labelIDs))
+	return labels, x.
+		Where(repoOrOrgLabelsCond(repo)).
+		In("id", labelIDs).
+		Asc("name").
+		Find(&labels)
+}
+
+// GetLabelIDsInRepoOrOrgByNames returns a list of labelIDs by names which can be used in
+// the given repository.
+// it silently ignores label names that cannot be used in the repository.
+func GetLabelIDsInRepoOrOrgByNames(repo *Repository, labelNames []string) ([]int64, error) {
+	labelIDs := make([]int64, 0, len(labelNames))
+	return labelIDs, x.Table("label").
+		Where(repoOrOrgLabelsCond(repo)).
+		In("name", labelNames).
+		Asc("name").
+		Cols("id").
+		Find(&labelIDs)
+}
+
+// repoOrOrgLabelsCond returns the condition for the labels which can be used in the repository
+func repoOrOrgLabelsCond(repo *Repository) builder.Cond {
+	cond := builder.NewCond().Or(builder.Eq{"repo_id": repo.ID})
+	if repo.OwnerID > 0 {
+		cond = cond.Or(builder.Eq{"org_id": repo.OwnerID})
+	}
+	return cond
+}
+
 // GetLabelsInRepoByIDs returns a list of labels by IDs in given repository,
 // it silently ignores label IDs that do not belong to the repository.
 func GetLabelsInRepoByIDs(repoID int64, labelIDs []int64) ([]*Label, error) {
@@ -299,8 +360,11 @@ func GetLabelsInRepoByIDs(repoID int64, labelIDs []int64) ([]*Label, error) {
 }
 
 func getLabelsByRepoID(e Engine, repoID int64, sortType string) ([]*Label, error) {
+	return findLabels(e.Where("repo_id = ?", repoID), sortType)
+}
+
+func findLabels(sess *xorm.Session, sortType string) ([]*Label, error) {
 	labels := make([]*Label, 0, 10)
-	sess := e.Where("repo_id = ?", repoID)
 
 	switch sortType {
 	case "reversealphabetically":
@@ -321,6 +385,158 @@ func GetLabelsByRepoID(repoID int64, sortType string) ([]*Label, error) {
 	return getLabelsByRepoID(x, repoID, sortType)
 }
 
+// getLabelInOrgByName returns a label by Name in given organization.
+func getLabelInOrgByName(e Engine, orgID int64, labelName string) (*Label, error) {
+	if len(labelName) == 0 || orgID <= 0 {
+		return nil, ErrOrgLabelNotExist{0, orgID}
+	}
+
+	l := &Label{
+		Name:  labelName,
+		OrgID: orgID,
+	}
+	has, err := e.Get(l)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrOrgLabelNotExist{0, l.OrgID}
+	}
+	return l, nil
+}
+
+// getLabelInOrgByID returns a label by ID in given organization.
+func getLabelInOrgByID(e Engine, orgID, labelID int64) (*Label, error) {
+	if labelID <= 0 || orgID <= 0 {
+		return nil, ErrOrgLabelNotExist{labelID, orgID}
+	}
+
+	l := &Label{
+		ID:    labelID,
+		OrgID: orgID,
+	}
+	has, err := e.Get(l)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrOrgLabelNotExist{l.ID, l.OrgID}
+	}
+	return l, nil
+}
+
+// GetLabelInOrgByName returns a label by name in given organization.
+func GetLabelInOrgByName(orgID int64, labelName string) (*Label, error) {
+	return getLabelInOrgByName(x, orgID, labelName)
+}
+
+// GetLabelInOrgByID returns a label by ID in given organization.
+func GetLabelInOrgByID(orgID, labelID int64) (*Label, error) {
+	return getLabelInOrgByID(x, orgID, labelID)
+}
+
+// GetLabelsByOrgID returns all labels that belong to given organization by ID.
+func GetLabelsByOrgID(orgID int64, sortType string) ([]*Label, error) {
+	if orgID <= 0 {
+		return nil, ErrOrgLabelNotExist{0, orgID}
+	}
+	return findLabels(x.Where("org_id = ?", orgID), sortType)
+}
+
+// CalOrgLabelsRepoIssues counts the issues of the repository with each of the labels of its
+// organization, the counts of the labels themselves include the issues of all repositories.
+func CalOrgLabelsRepoIssues(repoID int64, labels []*Label) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	labelIDs := make([]int64, 0, len(labels))
+	labelMap := make(map[int64]*Label, len(labels))
+	for _, l := range labels {
+		l.NumRepoIssues = 0
+		l.NumOpenRepoIssues = 0
+		labelIDs = append(labelIDs, l.ID)
+		labelMap[l.ID] = l
+	}
+
+	counts := make([]*struct {
+		LabelID  int64
+		IsClosed bool
+		Num      int
+	}, 0, len(labels))
+	if err := x.Table("issue_label").
+		Select("issue_label.label_id, issue.is_closed, COUNT(*) AS num").
+		Join("INNER", "issue", "issue.id = issue_label.issue_id").
+		Where("issue.repo_id = ?", repoID).
+		In("issue_label.label_id", labelIDs).
+		GroupBy("issue_label.label_id, issue.is_closed").
+		Find(&counts); err != nil {
+		return err
+	}
+	for _, c := range counts {
+		l := labelMap[c.LabelID]
+		l.NumRepoIssues += c.Num
+		if !c.IsClosed {
+			l.NumOpenRepoIssues += c.Num
+		}
+	}
+	return nil
+}
+
+// deleteOrgLabelsOfRepoIssues removes the labels of the organization from the issues of the
+// repository, they cannot be used anymore once the repository leaves the organization.
+func deleteOrgLabelsOfRepoIssues(e Engine, orgID, repoID int64) error {
+	labels := make([]*Label, 0, 10)
+	if err := e.Where("org_id = ?", orgID).
+		In("id", builder.Select("issue_label.label_id").From("issue_label").
+			InnerJoin("issue", "issue.id = issue_label.issue_id").
+			Where(builder.Eq{"issue.repo_id": repoID})).
+		Find(&labels); err != nil {
+		return err
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+
+	labelIDs := make([]int64, 0, len(labels))
+	for _, l := range labels {
+		labelIDs = append(labelIDs, l.ID)
+	}
+	if _, err := e.In("label_id", labelIDs).
+		In("issue_id", builder.Select("id").From("issue").Where(builder.Eq{"repo_id": repoID})).
+		Delete(new(IssueLabel)); err != nil {
+		return err
+	}
+	for _, l := range labels {
+		if err := updateLabel(e, l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteLabelsOfOrg deletes all labels of an organization, the organization must not own
+// repositories anymore.
+func deleteLabelsOfOrg(e Engine, orgID int64) error {
+	if _, err := e.In("label_id", builder.Select("id").From("label").Where(builder.Eq{"org_id": orgID})).
+		Delete(new(IssueLabel)); err != nil {
+		return err
+	}
+	_, err := e.Where("org_id = ?", orgID).Delete(new(Label))
+	return err
+}
+
+// GetLabelsUsableInRepo returns all labels which can be used in the repository, its own labels
+// followed by the labels of the organization it belongs to.
+func GetLabelsUsableInRepo(repo *Repository) ([]*Label, error) {
+	labels, err := getLabelsByRepoID(x, repo.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	orgLabels, err := findLabels(x.Where("org_id = ?", repo.OwnerID), "")
+	if err != nil {
+		return nil, err
+	}
+	return append(labels, orgLabels...), nil
+}
+
 func getLabelsByIssueID(e Engine, issueID int64) ([]*Label, error) {
 	var labels []*Label
 	return labels, e.Where("issue_label.issue_id = ?", issueID).
@@ -357,8 +573,8 @@ func UpdateLabel(l *Label) error {
 	return updateLabel(x, l)
 }
 
-// DeleteLabel delete a label of given repository.
-func DeleteLabel(repoID, labelID int64) error {
+// DeleteRepoLabel delete a label of given repository.
+func DeleteRepoLabel(repoID, labelID int64) error {
 	_, err := GetLabelInRepoByID(repoID, labelID)
 	if err != nil {
 		if IsErrLabelNotExist(err) {
@@ -366,14 +582,29 @@ func DeleteLabel(repoID, labelID int64) error {
 		}
 		return err
 	}
+	return deleteLabel(labelID)
+}
 
+// DeleteOrgLabel delete a label of given organization.
+func DeleteOrgLabel(orgID, labelID int64) error {
+	_, err := GetLabelInOrgByID(orgID, labelID)
+	if err != nil {
+		if IsErrOrgLabelNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return deleteLabel(labelID)
+}
+
+func deleteLabel(labelID int64) error {
 	sess := x.NewSession()
 	defer sess.Close()
-	if err = sess.Begin(); err != nil {
+	if err := sess.Begin(); err != nil {
 		return err
 	}
 
-	if _, err = sess.ID(labelID).Delete(new(Label)); err != nil {
+	if _, err := sess.ID(labelID).Delete(new(Label)); err != nil {
 		return err
 	} else if _, err = sess.
 		Where("label_id = ?", labelID).
@@ -382,7 +613,7 @@ func DeleteLabel(repoID, labelID int64) error {
 	}
 
 	// Clear label id in comment table
-	if _, err = sess.Where("label_id = ?", labelID).Cols("label_id").Update(&Comment{}); err != nil {
+	if _, err := sess.Where("label_id = ?", labelID).Cols("label_id").Update(&Comment{}); err != nil {
 		return err
 	}
 
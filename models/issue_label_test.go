@@ -144,6 +144,96 @@ func TestGetLabelsByRepoID(t *testing.T) {
 	testSuccess(1, "default", []int64{1, 2})
 }
 
+func TestLabel_CanBeUsedInRepo(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	repo1 := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	repo3 := AssertExistsAndLoadBean(t, &Repository{ID: 3}).(*Repository)
+
+	label := AssertExistsAndLoadBean(t, &Label{ID: 1}).(*Label)
+	assert.True(t, label.CanBeUsedInRepo(repo1))
+	assert.False(t, label.CanBeUsedInRepo(repo3))
+
+	label = AssertExistsAndLoadBean(t, &Label{ID: 3}).(*Label)
+	assert.False(t, label.CanBeUsedInRepo(repo1))
+	assert.True(t, label.CanBeUsedInRepo(repo3))
+}
+
+func TestGetLabelInOrgByName(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	label, err := GetLabelInOrgByName(3, "orglabel3")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, label.ID)
+	assert.Equal(t, "orglabel3", label.Name)
+
+	_, err = GetLabelInOrgByName(3, "label1")
+	assert.True(t, IsErrOrgLabelNotExist(err))
+
+	_, err = GetLabelInOrgByName(NonexistentID, "orglabel3")
+	assert.True(t, IsErrOrgLabelNotExist(err))
+}
+
+func TestGetLabelInOrgByID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	label, err := GetLabelInOrgByID(3, 3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, label.ID)
+
+	_, err = GetLabelInOrgByID(3, 1)
+	assert.True(t, IsErrOrgLabelNotExist(err))
+
+	_, err = GetLabelInOrgByID(NonexistentID, NonexistentID)
+	assert.True(t, IsErrOrgLabelNotExist(err))
+}
+
+func TestGetLabelsByOrgID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	testSuccess := func(orgID int64, sortType string, expectedIssueIDs []int64) {
+		labels, err := GetLabelsByOrgID(orgID, sortType)
+		assert.NoError(t, err)
+		assert.Len(t, labels, len(expectedIssueIDs))
+		for i, label := range labels {
+			assert.EqualValues(t, expectedIssueIDs[i], label.ID)
+		}
+	}
+	testSuccess(3, "leastissues", []int64{4, 3})
+	testSuccess(3, "mostissues", []int64{3, 4})
+	testSuccess(3, "reversealphabetically", []int64{4, 3})
+	testSuccess(3, "default", []int64{3, 4})
+	testSuccess(NonexistentID, "default", []int64{})
+}
+
+func TestGetLabelsUsableInRepo(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 3}).(*Repository)
+	labels, err := GetLabelsUsableInRepo(repo)
+	assert.NoError(t, err)
+	if assert.Len(t, labels, 2) {
+		assert.EqualValues(t, 3, labels[0].ID)
+		assert.EqualValues(t, 4, labels[1].ID)
+	}
+
+	labels, err = GetLabelsInRepoOrOrgByIDs(repo, []int64{1, 3, NonexistentID})
+	assert.NoError(t, err)
+	if assert.Len(t, labels, 1) {
+		assert.EqualValues(t, 3, labels[0].ID)
+	}
+}
+
+func TestCalOrgLabelsRepoIssues(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	labels, err := GetLabelsByOrgID(3, "default")
+	assert.NoError(t, err)
+	assert.NoError(t, CalOrgLabelsRepoIssues(3, labels))
+	if assert.Len(t, labels, 2) {
+		assert.EqualValues(t, 1, labels[0].NumRepoIssues)
+		assert.EqualValues(t, 1, labels[0].NumOpenRepoIssues)
+		assert.EqualValues(t, 0, labels[1].NumRepoIssues)
+	}
+
+	assert.NoError(t, CalOrgLabelsRepoIssues(5, labels))
+	assert.EqualValues(t, 0, labels[0].NumRepoIssues)
+}
+
 func TestGetLabelsByIssueID(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	labels, err := GetLabelsByIssueID(1)
@@ -171,13 +261,33 @@ func TestUpdateLabel(t *testing.T) {
 func TestDeleteLabel(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	label := AssertExistsAndLoadBean(t, &Label{ID: 1}).(*Label)
-	assert.NoError(t, DeleteLabel(label.RepoID, label.ID))
+	assert.NoError(t, DeleteRepoLabel(label.RepoID, label.ID))
 	AssertNotExistsBean(t, &Label{ID: label.ID, RepoID: label.RepoID})
 
-	assert.NoError(t, DeleteLabel(label.RepoID, label.ID))
+	assert.NoError(t, DeleteRepoLabel(label.RepoID, label.ID))
 	AssertNotExistsBean(t, &Label{ID: label.ID, RepoID: label.RepoID})
 
-	assert.NoError(t, DeleteLabel(NonexistentID, NonexistentID))
+	assert.NoError(t, DeleteRepoLabel(NonexistentID, NonexistentID))
+	CheckConsistencyFor(t, &Label{}, &Repository{})
+}
+
+func TestDeleteOrgLabel(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	// a label of another owner is left untouched
+	assert.NoError(t, DeleteOrgLabel(1, 3))
+	AssertExistsAndLoadBean(t, &Label{ID: 3})
+
+	// repository 3 cannot delete the labels of organization 3
+	assert.NoError(t, DeleteRepoLabel(3, 3))
+	AssertExistsAndLoadBean(t, &Label{ID: 3})
+
+	// nor can organization 1 delete the labels of repository 1
+	assert.NoError(t, DeleteOrgLabel(1, 1))
+	AssertExistsAndLoadBean(t, &Label{ID: 1})
+
+	assert.NoError(t, DeleteOrgLabel(3, 3))
+	AssertNotExistsBean(t, &Label{ID: 3})
+	AssertNotExistsBean(t, &IssueLabel{LabelID: 3})
 	CheckConsistencyFor(t, &Label{}, &Repository{})
 }
 
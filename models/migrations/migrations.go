@@ -292,6 +292,42 @@ var migrations = []Migration{
 	NewMigration("Add block on rejected reviews branch protection", addBlockOnRejectedReviews),
 	// v118 -> v119
 	NewMigration("Add commit id and stale to reviews", addReviewCommitAndStale),
+	// v119 -> v120
+	NewMigration("Add package tables", addPackageTables),
+	// v120 -> v121
+	NewMigration("Add actions tables", addActionsTables),
+	// v121 -> v122
+	NewMigration("Add projects tables", addProjectsTables),
+	// v122 -> v123
+	NewMigration("Add original author to reviews", addReviewMigrateInfo),
+	// v123 -> v124
+	NewMigration("Add pull request auto merge table", addPullAutoMergeTable),
+	// v124 -> v125
+	NewMigration("Add code owner review requests", addCodeOwnerReviewRequests),
+	// v125 -> v126
+	NewMigration("Add push mirror table", addPushMirrorTable),
+	// v126 -> v127
+	NewMigration("Add scope and expiry to access tokens", addScopeAndExpiryToAccessTokens),
+	// v127 -> v128
+	NewMigration("Add scope column to oauth2_grant and nonce column to oauth2_authorization_code", addOAuth2ScopeAndNonceColumns),
+	// v128 -> v129
+	NewMigration("Add OAuth2 signing key table", addOAuth2SigningKeyTable),
+	// v129 -> v130
+	NewMigration("Add audit event table", addAuditEventTable),
+	// v130 -> v131
+	NewMigration("Migrate U2F registrations to WebAuthn credentials", addWebAuthnCredentialTable),
+	// v131 -> v132
+	NewMigration("Add suggestion commit to code comments", addSuggestionCommitSHAToComment),
+	// v132 -> v133
+	NewMigration("Add merge queue for protected branches", addMergeQueue),
+	// v133 -> v134
+	NewMigration("Add table to store failed mails", addFailedMailTable),
+	// v134 -> v135
+	NewMigration("Add webhook delivery attempts", addHookTaskAttempts),
+	// v135 -> v136
+	NewMigration("Add org_id to labels to share them across repositories", addOrgIDLabelColumn),
+	// v136 -> v137
+	NewMigration("Add push mirror sync history", addPushMirrorSyncTable),
 }
 
 // Migrate database to current version
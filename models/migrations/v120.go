@@ -0,0 +1,60 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addActionsTables(x *xorm.Engine) error {
+	type ActionRunner struct {
+		ID             int64    `xorm:"pk autoincr"`
+		UUID           string   `xorm:"uuid CHAR(36) UNIQUE NOT NULL"`
+		Name           string   `xorm:"NOT NULL"`
+		Labels         []string `xorm:"TEXT JSON"`
+		TokenHash      string   `xorm:"UNIQUE"`
+		TokenSalt      string
+		TokenLastEight string             `xorm:"INDEX token_last_eight"`
+		LastOnlineUnix timeutil.TimeStamp `xorm:"INDEX"`
+		CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+	}
+
+	type ActionRun struct {
+		ID            int64 `xorm:"pk autoincr"`
+		RepoID        int64 `xorm:"UNIQUE(repo_index) INDEX NOT NULL"`
+		Index         int64 `xorm:"UNIQUE(repo_index) NOT NULL"`
+		Title         string
+		WorkflowID    string `xorm:"NOT NULL"`
+		TriggerUserID int64  `xorm:"NOT NULL"`
+		Event         string `xorm:"NOT NULL"`
+		Ref           string
+		CommitSHA     string             `xorm:"INDEX NOT NULL"`
+		Status        int                `xorm:"INDEX NOT NULL"`
+		StartedUnix   timeutil.TimeStamp `xorm:"INDEX"`
+		StoppedUnix   timeutil.TimeStamp
+		CreatedUnix   timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix   timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	type ActionRunJob struct {
+		ID          int64  `xorm:"pk autoincr"`
+		RunID       int64  `xorm:"INDEX NOT NULL"`
+		RepoID      int64  `xorm:"INDEX NOT NULL"`
+		JobID       string `xorm:"NOT NULL"`
+		Name        string
+		RunsOn      []string           `xorm:"TEXT JSON"`
+		Payload     string             `xorm:"LONGTEXT"`
+		Status      int                `xorm:"INDEX NOT NULL"`
+		RunnerID    int64              `xorm:"INDEX"`
+		StartedUnix timeutil.TimeStamp `xorm:"INDEX"`
+		StoppedUnix timeutil.TimeStamp
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"updated"`
+	}
+
+	return x.Sync2(new(ActionRunner), new(ActionRun), new(ActionRunJob))
+}
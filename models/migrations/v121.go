@@ -0,0 +1,97 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addProjectsTables(x *xorm.Engine) error {
+	type Project struct {
+		ID          int64  `xorm:"pk autoincr"`
+		Title       string `xorm:"INDEX NOT NULL"`
+		Description string `xorm:"TEXT"`
+		RepoID      int64  `xorm:"INDEX"`
+		OwnerID     int64  `xorm:"INDEX"`
+		CreatorID   int64  `xorm:"NOT NULL"`
+		Type        uint8
+		IsClosed    bool `xorm:"INDEX"`
+
+		CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+		ClosedDateUnix timeutil.TimeStamp
+	}
+
+	type ProjectBoard struct {
+		ID        int64  `xorm:"pk autoincr"`
+		ProjectID int64  `xorm:"INDEX NOT NULL"`
+		Title     string `xorm:"NOT NULL"`
+		IsDefault bool   `xorm:"NOT NULL DEFAULT false"`
+		IsDone    bool   `xorm:"NOT NULL DEFAULT false"`
+		Sorting   int    `xorm:"NOT NULL DEFAULT 0"`
+
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	type ProjectIssue struct {
+		ID             int64 `xorm:"pk autoincr"`
+		IssueID        int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		ProjectID      int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+		ProjectBoardID int64 `xorm:"INDEX NOT NULL DEFAULT 0"`
+		Sorting        int64 `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	if err := x.Sync2(new(Project), new(ProjectBoard), new(ProjectIssue)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	// TeamUnit see models/org_team.go
+	type TeamUnit struct {
+		ID     int64 `xorm:"pk autoincr"`
+		OrgID  int64 `xorm:"INDEX"`
+		TeamID int64 `xorm:"UNIQUE(s)"`
+		Type   int   `xorm:"UNIQUE(s)"`
+	}
+
+	const (
+		unitTypeIssues   = 2
+		unitTypeProjects = 8
+	)
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	// Teams with access to the issues get access to the projects as well
+	const batchSize = 100
+	for start := 0; ; start += batchSize {
+		units := make([]*TeamUnit, 0, batchSize)
+		if err := sess.Where("type = ?", unitTypeIssues).OrderBy("id").Limit(batchSize, start).Find(&units); err != nil {
+			return err
+		}
+		if len(units) == 0 {
+			break
+		}
+
+		for _, u := range units {
+			if _, err := sess.Insert(&TeamUnit{
+				OrgID:  u.OrgID,
+				TeamID: u.TeamID,
+				Type:   unitTypeProjects,
+			}); err != nil {
+				return fmt.Errorf("Insert team unit: %v", err)
+			}
+		}
+	}
+
+	return sess.Commit()
+}
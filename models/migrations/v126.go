@@ -0,0 +1,31 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addScopeAndExpiryToAccessTokens(x *xorm.Engine) error {
+	type AccessToken struct {
+		Scope        string             `xorm:"NOT NULL DEFAULT 'all'"`
+		ExpiresUnix  timeutil.TimeStamp `xorm:"INDEX NOT NULL DEFAULT 0"`
+		LastUsedUnix timeutil.TimeStamp `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	if err := x.Sync2(new(AccessToken)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	// the update time of a token was its last use
+	if _, err := x.Exec("UPDATE access_token SET last_used_unix = updated_unix WHERE updated_unix > created_unix"); err != nil {
+		return fmt.Errorf("update last_used_unix: %v", err)
+	}
+	return nil
+}
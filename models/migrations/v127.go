@@ -0,0 +1,38 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"xorm.io/xorm"
+)
+
+// OAuth2GrantV127 describes the scope column of the oauth2_grant table
+type OAuth2GrantV127 struct {
+	Scope string `xorm:"TEXT"`
+}
+
+// TableName will be invoked by XORM to customize the table name
+func (*OAuth2GrantV127) TableName() string {
+	return "oauth2_grant"
+}
+
+// OAuth2AuthorizationCodeV127 describes the nonce column of the oauth2_authorization_code table
+type OAuth2AuthorizationCodeV127 struct {
+	Nonce string `xorm:"TEXT"`
+}
+
+// TableName will be invoked by XORM to customize the table name
+func (*OAuth2AuthorizationCodeV127) TableName() string {
+	return "oauth2_authorization_code"
+}
+
+func addOAuth2ScopeAndNonceColumns(x *xorm.Engine) error {
+	if err := x.Sync2(new(OAuth2GrantV127), new(OAuth2AuthorizationCodeV127)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
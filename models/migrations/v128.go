@@ -0,0 +1,33 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+// OAuth2SigningKeyV128 describes the oauth2_signing_key table
+type OAuth2SigningKeyV128 struct {
+	ID          int64              `xorm:"pk autoincr"`
+	KeyID       string             `xorm:"UNIQUE NOT NULL"`
+	PrivateKey  string             `xorm:"TEXT NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// TableName will be invoked by XORM to customize the table name
+func (*OAuth2SigningKeyV128) TableName() string {
+	return "oauth2_signing_key"
+}
+
+func addOAuth2SigningKeyTable(x *xorm.Engine) error {
+	if err := x.Sync2(new(OAuth2SigningKeyV128)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"crypto/elliptic"
+	"encoding/base64"
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+type u2fRegistrationV130 struct {
+	ID          int64 `xorm:"pk autoincr"`
+	Name        string
+	UserID      int64 `xorm:"INDEX"`
+	Raw         []byte
+	Counter     uint32             `xorm:"BIGINT"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+func (reg u2fRegistrationV130) TableName() string {
+	return "u2f_registration"
+}
+
+type webAuthnCredentialV130 struct {
+	ID              int64 `xorm:"pk autoincr"`
+	Name            string
+	UserID          int64  `xorm:"INDEX"`
+	CredentialID    string `xorm:"INDEX VARCHAR(410)"`
+	PublicKey       []byte
+	AttestationType string
+	AAGUID          []byte
+	SignCount       uint32             `xorm:"BIGINT"`
+	LegacyU2F       bool               `xorm:"'legacy_u2f' NOT NULL DEFAULT false"`
+	CreatedUnix     timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix     timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+func (cred webAuthnCredentialV130) TableName() string {
+	return "webauthn_credential"
+}
+
+// u2fPublicKeyToCOSE extracts the public key and key handle of a raw U2F
+// registration response and encodes the key as ES256 COSE_Key.
+func u2fPublicKeyToCOSE(raw []byte) (publicKey, keyHandle []byte, err error) {
+	// 0x05 | public key (65 bytes) | key handle length | key handle | ...
+	if len(raw) < 67 || raw[0] != 0x05 {
+		return nil, nil, fmt.Errorf("invalid registration data")
+	}
+	point := raw[1:66]
+	if x, _ := elliptic.Unmarshal(elliptic.P256(), point); x == nil {
+		return nil, nil, fmt.Errorf("invalid public key")
+	}
+	handleLength := int(raw[66])
+	if len(raw) < 67+handleLength {
+		return nil, nil, fmt.Errorf("invalid key handle")
+	}
+
+	// {1: 2, 3: -7, -1: 1, -2: x, -3: y}
+	publicKey = []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21, 0x58, 0x20}
+	publicKey = append(publicKey, point[1:33]...)
+	publicKey = append(publicKey, 0x22, 0x58, 0x20)
+	publicKey = append(publicKey, point[33:65]...)
+	return publicKey, raw[67 : 67+handleLength], nil
+}
+
+func addWebAuthnCredentialTable(x *xorm.Engine) error {
+	if err := x.Sync2(new(webAuthnCredentialV130)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	exist, err := x.IsTableExist(new(u2fRegistrationV130))
+	if err != nil {
+		return err
+	} else if !exist {
+		return nil
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	regs := make([]*u2fRegistrationV130, 0, 50)
+	if err := sess.Find(&regs); err != nil {
+		return fmt.Errorf("Find: %v", err)
+	}
+	for _, reg := range regs {
+		publicKey, keyHandle, err := u2fPublicKeyToCOSE(reg.Raw)
+		if err != nil {
+			// keep the registrations rather than dropping keys users can't log in without
+			return fmt.Errorf("unable to migrate U2F registration %d of user %d: %v", reg.ID, reg.UserID, err)
+		}
+		if _, err := sess.NoAutoTime().Insert(&webAuthnCredentialV130{
+			Name:            reg.Name,
+			UserID:          reg.UserID,
+			CredentialID:    base64.RawURLEncoding.EncodeToString(keyHandle),
+			PublicKey:       publicKey,
+			AttestationType: "fido-u2f",
+			SignCount:       reg.Counter,
+			LegacyU2F:       true,
+			CreatedUnix:     reg.CreatedUnix,
+			UpdatedUnix:     reg.UpdatedUnix,
+		}); err != nil {
+			return fmt.Errorf("Insert: %v", err)
+		}
+	}
+
+	if err := sess.DropTable(new(u2fRegistrationV130)); err != nil {
+		return fmt.Errorf("DropTable: %v", err)
+	}
+	return sess.Commit()
+}
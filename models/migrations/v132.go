@@ -0,0 +1,40 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addMergeQueue(x *xorm.Engine) error {
+	type ProtectedBranch struct {
+		EnableMergeQueue bool `xorm:"NOT NULL DEFAULT false"`
+	}
+
+	type PullMergeQueue struct {
+		ID             int64              `xorm:"pk autoincr"`
+		PullID         int64              `xorm:"UNIQUE"`
+		RepoID         int64              `xorm:"INDEX(s)"`
+		BaseBranch     string             `xorm:"INDEX(s)"`
+		DoerID         int64              `xorm:"NOT NULL"`
+		MergeStyle     string             `xorm:"varchar(30)"`
+		Message        string             `xorm:"LONGTEXT"`
+		ParentCommitID string             `xorm:"VARCHAR(40)"`
+		MergeCommitID  string             `xorm:"VARCHAR(40)"`
+		CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+	}
+
+	if err := x.Sync2(new(ProtectedBranch)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	if err := x.Sync2(new(PullMergeQueue)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
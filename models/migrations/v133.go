@@ -0,0 +1,31 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addFailedMailTable(x *xorm.Engine) error {
+	type FailedMail struct {
+		ID          int64  `xorm:"pk autoincr"`
+		Recipients  string `xorm:"TEXT"`
+		Subject     string `xorm:"TEXT"`
+		Info        string `xorm:"TEXT"`
+		Attempts    int
+		LastError   string             `xorm:"TEXT"`
+		Content     string             `xorm:"LONGTEXT"`
+		CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	}
+
+	if err := x.Sync2(new(FailedMail)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
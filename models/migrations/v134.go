@@ -0,0 +1,47 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addHookTaskAttempts(x *xorm.Engine) error {
+	type Webhook struct {
+		FailureCount int `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	type HookTask struct {
+		Attempts        int                `xorm:"NOT NULL DEFAULT 0"`
+		NextAttemptUnix timeutil.TimeStamp `xorm:"INDEX NOT NULL DEFAULT 0"`
+	}
+
+	type HookTaskAttempt struct {
+		ID              int64 `xorm:"pk autoincr"`
+		HookTaskID      int64 `xorm:"INDEX"`
+		Attempt         int
+		IsSucceed       bool
+		ResponseContent string `xorm:"TEXT"`
+		Delivered       int64
+	}
+
+	if err := x.Sync2(new(Webhook)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	if err := x.Sync2(new(HookTask)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	if err := x.Sync2(new(HookTaskAttempt)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	// Tasks delivered before count as delivered in one attempt
+	_, err := x.Exec("UPDATE hook_task SET attempts = 1 WHERE is_delivered = ?", true)
+	return err
+}
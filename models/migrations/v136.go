@@ -0,0 +1,29 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+func addPushMirrorSyncTable(x *xorm.Engine) error {
+	type PushMirrorSync struct {
+		ID           int64 `xorm:"pk autoincr"`
+		RepoID       int64 `xorm:"INDEX"`
+		PushMirrorID int64 `xorm:"INDEX"`
+		IsSucceed    bool
+		Error        string             `xorm:"TEXT"`
+		CreatedUnix  timeutil.TimeStamp `xorm:"created"`
+	}
+
+	if err := x.Sync2(new(PushMirrorSync)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -107,13 +107,32 @@ func init() {
 		new(LFSLock),
 		new(Reaction),
 		new(IssueAssignees),
-		new(U2FRegistration),
+		new(WebAuthnCredential),
 		new(TeamUnit),
 		new(Review),
 		new(OAuth2Application),
 		new(OAuth2AuthorizationCode),
 		new(OAuth2Grant),
+		new(OAuth2SigningKey),
+		new(AuditEvent),
 		new(Task),
+		new(Package),
+		new(PackageVersion),
+		new(PackageFile),
+		new(PackageBlob),
+		new(PackageBlobUpload),
+		new(ActionRunner),
+		new(ActionRun),
+		new(ActionRunJob),
+		new(Project),
+		new(ProjectBoard),
+		new(ProjectIssue),
+		new(PullAutoMerge),
+		new(PullMergeQueue),
+		new(PushMirror),
+		new(PushMirrorSync),
+		new(FailedMail),
+		new(HookTaskAttempt),
 	)
 
 	gonicNames := []string{"SSL", "UID"}
@@ -9,6 +9,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 
 	"code.gitea.io/gitea/modules/secret"
@@ -102,15 +103,16 @@ func (app *OAuth2Application) getGrantByUserID(e Engine, userID int64) (grant *O
 	return grant, nil
 }
 
-// CreateGrant generates a grant for an user
-func (app *OAuth2Application) CreateGrant(userID int64) (*OAuth2Grant, error) {
-	return app.createGrant(x, userID)
+// CreateGrant generates a grant for an user with the space separated scopes
+func (app *OAuth2Application) CreateGrant(userID int64, scope string) (*OAuth2Grant, error) {
+	return app.createGrant(x, userID, scope)
 }
 
-func (app *OAuth2Application) createGrant(e Engine, userID int64) (*OAuth2Grant, error) {
+func (app *OAuth2Application) createGrant(e Engine, userID int64, scope string) (*OAuth2Grant, error) {
 	grant := &OAuth2Grant{
 		ApplicationID: app.ID,
 		UserID:        userID,
+		Scope:         scope,
 	}
 	_, err := e.Insert(grant)
 	if err != nil {
@@ -263,6 +265,7 @@ type OAuth2AuthorizationCode struct {
 	CodeChallenge       string
 	CodeChallengeMethod string
 	RedirectURI         string
+	Nonce               string             `xorm:"TEXT"`
 	ValidUntil          timeutil.TimeStamp `xorm:"index"`
 }
 
@@ -347,6 +350,7 @@ type OAuth2Grant struct {
 	Application   *OAuth2Application `xorm:"-"`
 	ApplicationID int64              `xorm:"INDEX unique(user_application)"`
 	Counter       int64              `xorm:"NOT NULL DEFAULT 1"`
+	Scope         string             `xorm:"TEXT"`
 	CreatedUnix   timeutil.TimeStamp `xorm:"created"`
 	UpdatedUnix   timeutil.TimeStamp `xorm:"updated"`
 }
@@ -356,12 +360,13 @@ func (grant *OAuth2Grant) TableName() string {
 	return "oauth2_grant"
 }
 
-// GenerateNewAuthorizationCode generates a new authorization code for a grant and saves it to the databse
-func (grant *OAuth2Grant) GenerateNewAuthorizationCode(redirectURI, codeChallenge, codeChallengeMethod string) (*OAuth2AuthorizationCode, error) {
-	return grant.generateNewAuthorizationCode(x, redirectURI, codeChallenge, codeChallengeMethod)
+// GenerateNewAuthorizationCode generates a new authorization code for a grant and saves it to the databse.
+// The nonce of the authorization request is stored with the code and returned in the id_token of the code exchange.
+func (grant *OAuth2Grant) GenerateNewAuthorizationCode(redirectURI, codeChallenge, codeChallengeMethod, nonce string) (*OAuth2AuthorizationCode, error) {
+	return grant.generateNewAuthorizationCode(x, redirectURI, codeChallenge, codeChallengeMethod, nonce)
 }
 
-func (grant *OAuth2Grant) generateNewAuthorizationCode(e Engine, redirectURI, codeChallenge, codeChallengeMethod string) (code *OAuth2AuthorizationCode, err error) {
+func (grant *OAuth2Grant) generateNewAuthorizationCode(e Engine, redirectURI, codeChallenge, codeChallengeMethod, nonce string) (code *OAuth2AuthorizationCode, err error) {
 	var codeSecret string
 	if codeSecret, err = secret.New(); err != nil {
 		return &OAuth2AuthorizationCode{}, err
@@ -373,6 +378,7 @@ func (grant *OAuth2Grant) generateNewAuthorizationCode(e Engine, redirectURI, co
 		Code:                codeSecret,
 		CodeChallenge:       codeChallenge,
 		CodeChallengeMethod: codeChallengeMethod,
+		Nonce:               nonce,
 	}
 	if _, err := e.Insert(code); err != nil {
 		return nil, err
@@ -380,6 +386,33 @@ func (grant *OAuth2Grant) generateNewAuthorizationCode(e Engine, redirectURI, co
 	return code, nil
 }
 
+// ScopeContains returns true if the space separated scopes of the grant contain the scope
+func (grant *OAuth2Grant) ScopeContains(scope string) bool {
+	for _, s := range strings.Fields(grant.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeContainsAll returns true if the grant contains all of the space separated scopes
+func (grant *OAuth2Grant) ScopeContainsAll(scopes string) bool {
+	for _, s := range strings.Fields(scopes) {
+		if !grant.ScopeContains(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// UpdateScope replaces the scopes of the grant
+func (grant *OAuth2Grant) UpdateScope(scope string) error {
+	grant.Scope = scope
+	_, err := x.ID(grant.ID).Cols("scope").Update(grant)
+	return err
+}
+
 // IncreaseCounter increases the counter and updates the grant
 func (grant *OAuth2Grant) IncreaseCounter() error {
 	return grant.increaseCount(x)
@@ -500,3 +533,35 @@ func (token *OAuth2Token) SignToken() (string, error) {
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS512, token)
 	return jwtToken.SignedString(setting.OAuth2.JWTSecretBytes)
 }
+
+// OIDCToken represents the claims of an OpenID Connect id_token, the claims of
+// the scopes profile, email and groups are only set if the scope is granted.
+type OIDCToken struct {
+	jwt.StandardClaims
+	Nonce string `json:"nonce,omitempty"`
+
+	// Scope profile
+	Name              string `json:"name,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Profile           string `json:"profile,omitempty"`
+	Picture           string `json:"picture,omitempty"`
+	Website           string `json:"website,omitempty"`
+	Locale            string `json:"locale,omitempty"`
+	UpdatedAt         int64  `json:"updated_at,omitempty"`
+
+	// Scope email
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified,omitempty"`
+
+	// Scope groups
+	Groups []string `json:"groups,omitempty"`
+}
+
+// SignToken signs the id_token with the current signing key
+func (token *OIDCToken) SignToken() (string, error) {
+	key, err := GetCurrentOAuth2SigningKey()
+	if err != nil {
+		return "", err
+	}
+	return key.SignToken(token)
+}
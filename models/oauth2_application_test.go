@@ -94,11 +94,12 @@ func TestOAuth2Application_GetGrantByUserID(t *testing.T) {
 func TestOAuth2Application_CreateGrant(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	app := AssertExistsAndLoadBean(t, &OAuth2Application{ID: 1}).(*OAuth2Application)
-	grant, err := app.CreateGrant(2)
+	grant, err := app.CreateGrant(2, "openid profile")
 	assert.NoError(t, err)
 	assert.NotNil(t, grant)
 	assert.Equal(t, int64(2), grant.UserID)
 	assert.Equal(t, int64(1), grant.ApplicationID)
+	assert.Equal(t, "openid profile", grant.Scope)
 }
 
 //////////////////// Grant
@@ -114,6 +115,25 @@ func TestGetOAuth2GrantByID(t *testing.T) {
 	assert.Nil(t, grant)
 }
 
+func TestOAuth2Grant_ScopeContains(t *testing.T) {
+	grant := &OAuth2Grant{Scope: "openid  profile groups"}
+	assert.True(t, grant.ScopeContains("openid"))
+	assert.True(t, grant.ScopeContains("groups"))
+	assert.False(t, grant.ScopeContains("email"))
+	assert.False(t, grant.ScopeContains(""))
+
+	assert.True(t, grant.ScopeContainsAll(""))
+	assert.True(t, grant.ScopeContainsAll("profile openid"))
+	assert.False(t, grant.ScopeContainsAll("openid email"))
+}
+
+func TestOAuth2Grant_UpdateScope(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	grant := AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1}).(*OAuth2Grant)
+	assert.NoError(t, grant.UpdateScope("openid email"))
+	AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1, Scope: "openid email"})
+}
+
 func TestOAuth2Grant_IncreaseCounter(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	grant := AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1, Counter: 1}).(*OAuth2Grant)
@@ -125,10 +145,11 @@ func TestOAuth2Grant_IncreaseCounter(t *testing.T) {
 func TestOAuth2Grant_GenerateNewAuthorizationCode(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	grant := AssertExistsAndLoadBean(t, &OAuth2Grant{ID: 1}).(*OAuth2Grant)
-	code, err := grant.GenerateNewAuthorizationCode("https://example2.com/callback", "CjvyTLSdR47G5zYenDA-eDWW4lRrO8yvjcWwbD_deOg", "S256")
+	code, err := grant.GenerateNewAuthorizationCode("https://example2.com/callback", "CjvyTLSdR47G5zYenDA-eDWW4lRrO8yvjcWwbD_deOg", "S256", "thenonce")
 	assert.NoError(t, err)
 	assert.NotNil(t, code)
 	assert.True(t, len(code.Code) > 32) // secret length > 32
+	AssertExistsAndLoadBean(t, &OAuth2AuthorizationCode{ID: code.ID, Nonce: "thenonce"})
 }
 
 func TestOAuth2Grant_TableName(t *testing.T) {
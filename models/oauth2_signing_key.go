@@ -0,0 +1,176 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// OAuth2SigningKey represents a RSA key to sign OpenID Connect id_tokens.
+// The newest key is used for signing, older keys are kept to verify tokens signed before a rotation.
+type OAuth2SigningKey struct {
+	ID          int64              `xorm:"pk autoincr"`
+	KeyID       string             `xorm:"UNIQUE NOT NULL"`
+	PrivateKey  string             `xorm:"TEXT NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+
+	privateKey *rsa.PrivateKey `xorm:"-"`
+}
+
+// TableName sets the table name to `oauth2_signing_key`
+func (key *OAuth2SigningKey) TableName() string {
+	return "oauth2_signing_key"
+}
+
+func (key *OAuth2SigningKey) rsaPrivateKey() (*rsa.PrivateKey, error) {
+	if key.privateKey != nil {
+		return key.privateKey, nil
+	}
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("invalid private key of signing key %s", key.KeyID)
+	}
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key.privateKey = privateKey
+	return privateKey, nil
+}
+
+// SignToken signs the claims with the RS256 algorithm, the key id is set in the header
+func (key *OAuth2SigningKey) SignToken(claims jwt.Claims) (string, error) {
+	privateKey, err := key.rsaPrivateKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.KeyID
+	return token.SignedString(privateKey)
+}
+
+// PublicKey returns the public key to verify the signed tokens
+func (key *OAuth2SigningKey) PublicKey() (*rsa.PublicKey, error) {
+	privateKey, err := key.rsaPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return &privateKey.PublicKey, nil
+}
+
+// JWK returns the public key as JSON Web Key (RFC 7517)
+func (key *OAuth2SigningKey) JWK() (map[string]string, error) {
+	publicKey, err := key.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"kty": "RSA",
+		"alg": jwt.SigningMethodRS256.Alg(),
+		"use": "sig",
+		"kid": key.KeyID,
+		"n":   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+	}, nil
+}
+
+// CreateOAuth2SigningKey generates a new signing key which is used for signing from now on
+func CreateOAuth2SigningKey() (*OAuth2SigningKey, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	keyID := sha256.Sum256(publicKeyBytes)
+
+	key := &OAuth2SigningKey{
+		KeyID: base64.RawURLEncoding.EncodeToString(keyID[:]),
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+		})),
+		privateKey: privateKey,
+	}
+	if _, err := x.Insert(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// GetOAuth2SigningKeys returns all signing keys, the newest key first
+func GetOAuth2SigningKeys() ([]*OAuth2SigningKey, error) {
+	keys := make([]*OAuth2SigningKey, 0, 2)
+	return keys, x.Desc("id").Find(&keys)
+}
+
+// GetCurrentOAuth2SigningKey returns the newest signing key, a key is created if there is none yet
+func GetCurrentOAuth2SigningKey() (*OAuth2SigningKey, error) {
+	key := new(OAuth2SigningKey)
+	has, err := x.Desc("id").Get(key)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return CreateOAuth2SigningKey()
+	}
+	return key, nil
+}
+
+// RotateOAuth2SigningKeys creates a new signing key if the current key is older than the configured age
+// and deletes the keys which have been replaced for longer than the lifetime of the tokens signed by them.
+func RotateOAuth2SigningKeys(ctx context.Context) {
+	log.Trace("Doing: RotateOAuth2SigningKeys")
+
+	if err := rotateOAuth2SigningKeys(setting.Cron.RotateOAuth2SigningKeys.OlderThan,
+		time.Duration(setting.OAuth2.AccessTokenExpirationTime)*time.Second); err != nil {
+		log.Error("RotateOAuth2SigningKeys: %v", err)
+		return
+	}
+
+	log.Trace("Finished: RotateOAuth2SigningKeys")
+}
+
+func rotateOAuth2SigningKeys(olderThan, tokenLifetime time.Duration) error {
+	keys, err := GetOAuth2SigningKeys()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if len(keys) == 0 || keys[0].CreatedUnix.AsTime().Add(olderThan).Before(now) {
+		key, err := CreateOAuth2SigningKey()
+		if err != nil {
+			return err
+		}
+		keys = append([]*OAuth2SigningKey{key}, keys...)
+	}
+
+	// a key is replaced by the next newer one, the tokens signed by it expire within the token lifetime
+	for i := 1; i < len(keys); i++ {
+		if keys[i-1].CreatedUnix.AsTime().Add(tokenLifetime).Before(now) {
+			if _, err := x.ID(keys[i].ID).Delete(new(OAuth2SigningKey)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCurrentOAuth2SigningKey(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	key, err := GetCurrentOAuth2SigningKey()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, key.KeyID)
+
+	// the key is only created once
+	key2, err := GetCurrentOAuth2SigningKey()
+	assert.NoError(t, err)
+	assert.Equal(t, key.KeyID, key2.KeyID)
+
+	signed, err := key2.SignToken(&OIDCToken{
+		StandardClaims: jwt.StandardClaims{Subject: "1"},
+		Nonce:          "thenonce",
+	})
+	assert.NoError(t, err)
+
+	publicKey, err := key.PublicKey()
+	assert.NoError(t, err)
+	parsed, err := jwt.ParseWithClaims(signed, &OIDCToken{}, func(token *jwt.Token) (interface{}, error) {
+		assert.Equal(t, key.KeyID, token.Header["kid"])
+		return publicKey, nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, parsed.Valid)
+	assert.Equal(t, "thenonce", parsed.Claims.(*OIDCToken).Nonce)
+
+	jwk, err := key.JWK()
+	assert.NoError(t, err)
+	assert.Equal(t, "RS256", jwk["alg"])
+	assert.Equal(t, key.KeyID, jwk["kid"])
+	assert.Equal(t, "AQAB", jwk["e"])
+}
+
+func TestRotateOAuth2SigningKeys(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	assert.NoError(t, rotateOAuth2SigningKeys(time.Hour, time.Hour))
+	keys, err := GetOAuth2SigningKeys()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	// the current key is not old enough to be replaced
+	assert.NoError(t, rotateOAuth2SigningKeys(time.Hour, time.Hour))
+	keys, err = GetOAuth2SigningKeys()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	// the replaced key is kept while tokens signed by it are valid
+	_, err = x.Exec("UPDATE oauth2_signing_key SET created_unix = created_unix - 7200")
+	assert.NoError(t, err)
+	assert.NoError(t, rotateOAuth2SigningKeys(time.Hour, time.Hour))
+	keys, err = GetOAuth2SigningKeys()
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	current := keys[0]
+
+	_, err = x.Exec("UPDATE oauth2_signing_key SET created_unix = created_unix - 4000 WHERE id = ?", current.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, rotateOAuth2SigningKeys(2*time.Hour, time.Hour))
+	keys, err = GetOAuth2SigningKeys()
+	assert.NoError(t, err)
+	if assert.Len(t, keys, 1) {
+		assert.Equal(t, current.KeyID, keys[0].KeyID)
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// PackageType specifies the registry protocol of a package
+type PackageType int
+
+// enumerates all the package types
+const (
+	PackageTypeGeneric PackageType = iota + 1
+	PackageTypeNpm
+	PackageTypeMaven
+	PackageTypeContainer
+)
+
+var packageTypeNames = map[PackageType]string{
+	PackageTypeGeneric:   "generic",
+	PackageTypeNpm:       "npm",
+	PackageTypeMaven:     "maven",
+	PackageTypeContainer: "container",
+}
+
+// Name returns the name of the package type
+func (pt PackageType) Name() string {
+	return packageTypeNames[pt]
+}
+
+// ParsePackageType returns the package type of the given name, or 0 if it is unknown
+func ParsePackageType(name string) PackageType {
+	name = strings.ToLower(name)
+	for pt, n := range packageTypeNames {
+		if n == name {
+			return pt
+		}
+	}
+	return 0
+}
+
+// Package represents a package owned by a user or an organization.
+// The package may optionally be linked to a repository of the same owner.
+type Package struct {
+	ID          int64              `xorm:"pk autoincr"`
+	OwnerID     int64              `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Owner       *User              `xorm:"-"`
+	RepoID      int64              `xorm:"INDEX"`
+	Repo        *Repository        `xorm:"-"`
+	Type        PackageType        `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Name        string             `xorm:"NOT NULL"`
+	LowerName   string             `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// LoadAttributes loads the owner and the linked repository of the package
+func (p *Package) LoadAttributes() error {
+	return p.loadAttributes(x)
+}
+
+func (p *Package) loadAttributes(e Engine) (err error) {
+	if p.Owner == nil {
+		if p.Owner, err = getUserByID(e, p.OwnerID); err != nil {
+			return fmt.Errorf("getUserByID [%d]: %v", p.OwnerID, err)
+		}
+	}
+	if p.Repo == nil && p.RepoID > 0 {
+		if p.Repo, err = getRepositoryByID(e, p.RepoID); err != nil {
+			return fmt.Errorf("getRepositoryByID [%d]: %v", p.RepoID, err)
+		}
+	}
+	return nil
+}
+
+// ErrPackageNotExist represents a "PackageNotExist" kind of error.
+type ErrPackageNotExist struct {
+	ID      int64
+	OwnerID int64
+	Type    PackageType
+	Name    string
+}
+
+// IsErrPackageNotExist checks if an error is a ErrPackageNotExist.
+func IsErrPackageNotExist(err error) bool {
+	_, ok := err.(ErrPackageNotExist)
+	return ok
+}
+
+func (err ErrPackageNotExist) Error() string {
+	return fmt.Sprintf("package does not exist [id: %d, owner_id: %d, type: %s, name: %s]", err.ID, err.OwnerID, err.Type.Name(), err.Name)
+}
+
+// GetPackageByID returns the package with the given id
+func GetPackageByID(id int64) (*Package, error) {
+	return getPackageByID(x, id)
+}
+
+func getPackageByID(e Engine, id int64) (*Package, error) {
+	p := new(Package)
+	has, err := e.ID(id).Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageNotExist{ID: id}
+	}
+	return p, nil
+}
+
+// GetPackageByName returns the package of the owner with the given type and name
+func GetPackageByName(ownerID int64, packageType PackageType, name string) (*Package, error) {
+	return getPackageByName(x, ownerID, packageType, name)
+}
+
+func getPackageByName(e Engine, ownerID int64, packageType PackageType, name string) (*Package, error) {
+	p := &Package{
+		OwnerID:   ownerID,
+		Type:      packageType,
+		LowerName: strings.ToLower(name),
+	}
+	has, err := e.Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageNotExist{OwnerID: ownerID, Type: packageType, Name: name}
+	}
+	return p, nil
+}
+
+// getOrInsertPackage returns the existing package with the same owner, type and name
+// or inserts the given package if there is none.
+func getOrInsertPackage(e Engine, p *Package) (*Package, error) {
+	p.LowerName = strings.ToLower(p.Name)
+
+	existing, err := getPackageByName(e, p.OwnerID, p.Type, p.Name)
+	if err == nil {
+		return existing, nil
+	} else if !IsErrPackageNotExist(err) {
+		return nil, err
+	}
+
+	if _, err = e.Insert(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetPackageRepositoryLink links the package to the repository, a repository id of 0 removes the link
+func SetPackageRepositoryLink(packageID, repoID int64) error {
+	_, err := x.ID(packageID).Cols("repo_id").Update(&Package{RepoID: repoID})
+	return err
+}
+
+func unlinkRepositoryFromAllPackages(e Engine, repoID int64) error {
+	_, err := e.Where("repo_id = ?", repoID).Cols("repo_id").Update(&Package{})
+	return err
+}
+
+func countPackagesByOwner(e Engine, ownerID int64) (int64, error) {
+	return e.Where("owner_id = ?", ownerID).Count(new(Package))
+}
+
+// deletePackageIfUnused removes the package if it has no versions left
+func deletePackageIfUnused(e Engine, packageID int64) error {
+	count, err := e.Where("package_id = ?", packageID).Count(new(PackageVersion))
+	if err != nil {
+		return err
+	} else if count > 0 {
+		return nil
+	}
+	_, err = e.ID(packageID).Delete(new(Package))
+	return err
+}
+
+// PackageSearchOptions are options for SearchPackageVersions
+type PackageSearchOptions struct {
+	OwnerID   int64
+	RepoID    int64
+	Type      PackageType
+	PackageID int64
+	Query     string
+	Page      int
+	PageSize  int
+}
+
+func (opts *PackageSearchOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	cond = cond.And(builder.Eq{"package_version.is_internal": false})
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"package.owner_id": opts.OwnerID})
+	}
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"package.repo_id": opts.RepoID})
+	}
+	if opts.Type > 0 {
+		cond = cond.And(builder.Eq{"package.type": opts.Type})
+	}
+	if opts.PackageID > 0 {
+		cond = cond.And(builder.Eq{"package.id": opts.PackageID})
+	}
+	if len(opts.Query) > 0 {
+		cond = cond.And(builder.Like{"package.lower_name", strings.ToLower(opts.Query)})
+	}
+	return cond
+}
+
+// SearchPackageVersions returns the public versions of the packages matching the options
+// together with the total count of matches
+func SearchPackageVersions(opts *PackageSearchOptions) ([]*PackageVersion, int64, error) {
+	cond := opts.toConds()
+
+	count, err := x.Join("INNER", "package", "package.id = package_version.package_id").
+		Where(cond).
+		Count(new(PackageVersion))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess := x.Join("INNER", "package", "package.id = package_version.package_id").
+		Where(cond).
+		Desc("package_version.created_unix").
+		Desc("package_version.id")
+	if opts.PageSize > 0 {
+		if opts.Page <= 0 {
+			opts.Page = 1
+		}
+		sess.Limit(opts.PageSize, (opts.Page-1)*opts.PageSize)
+	}
+
+	pvs := make([]*PackageVersion, 0, opts.PageSize)
+	if err = sess.Find(&pvs); err != nil {
+		return nil, 0, err
+	}
+	return pvs, count, nil
+}
+
+// GetPackageAccessMode returns the access mode the user has on the packages of the owner.
+// Packages follow the visibility of their owner: everybody who can see the owner can read
+// its packages, while writing requires being the owner, an organization owner or a member
+// of a team with write access.
+func GetPackageAccessMode(owner, doer *User) (AccessMode, error) {
+	return getPackageAccessMode(x, owner, doer)
+}
+
+func getPackageAccessMode(e Engine, owner, doer *User) (AccessMode, error) {
+	if doer != nil && (doer.IsAdmin || doer.ID == owner.ID) {
+		return AccessModeOwner, nil
+	}
+
+	mode := AccessModeNone
+	switch owner.Visibility {
+	case structs.VisibleTypePublic:
+		mode = AccessModeRead
+	case structs.VisibleTypeLimited:
+		if doer != nil {
+			mode = AccessModeRead
+		}
+	}
+
+	if doer == nil || !owner.IsOrganization() {
+		return mode, nil
+	}
+
+	isOwner, err := isOrganizationOwner(e, owner.ID, doer.ID)
+	if err != nil {
+		return AccessModeNone, err
+	} else if isOwner {
+		return AccessModeOwner, nil
+	}
+
+	teams, err := owner.getUserTeams(e, doer.ID)
+	if err != nil {
+		return AccessModeNone, err
+	}
+	for _, t := range teams {
+		teamMode := t.Authorize
+		if teamMode > AccessModeWrite {
+			teamMode = AccessModeWrite
+		}
+		if teamMode > mode {
+			mode = teamMode
+		}
+		if mode < AccessModeRead {
+			mode = AccessModeRead
+		}
+	}
+	return mode, nil
+}
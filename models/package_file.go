@@ -0,0 +1,292 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	gouuid "github.com/satori/go.uuid"
+)
+
+// PackageFile represents a file of a package version. The content is
+// stored in a PackageBlob which may be shared between several files.
+type PackageFile struct {
+	ID        int64        `xorm:"pk autoincr"`
+	VersionID int64        `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	BlobID    int64        `xorm:"INDEX NOT NULL"`
+	Blob      *PackageBlob `xorm:"-"`
+	Name      string       `xorm:"NOT NULL"`
+	LowerName string       `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	// IsLead marks the main file of a version, like the manifest of a container image.
+	IsLead      bool               `xorm:"NOT NULL DEFAULT false"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// LoadBlob loads the blob of the file
+func (pf *PackageFile) LoadBlob() error {
+	return pf.loadBlob(x)
+}
+
+func (pf *PackageFile) loadBlob(e Engine) (err error) {
+	if pf.Blob == nil {
+		pf.Blob, err = getPackageBlobByID(e, pf.BlobID)
+	}
+	return err
+}
+
+// APIFormat converts a PackageFile to api.PackageFile, the blob must be loaded
+func (pf *PackageFile) APIFormat() *api.PackageFile {
+	return &api.PackageFile{
+		ID:         pf.ID,
+		Size:       pf.Blob.Size,
+		Name:       pf.Name,
+		HashMD5:    pf.Blob.HashMD5,
+		HashSHA1:   pf.Blob.HashSHA1,
+		HashSHA256: pf.Blob.HashSHA256,
+		HashSHA512: pf.Blob.HashSHA512,
+	}
+}
+
+// ErrPackageFileNotExist represents a "PackageFileNotExist" kind of error.
+type ErrPackageFileNotExist struct {
+	VersionID int64
+	Name      string
+}
+
+// IsErrPackageFileNotExist checks if an error is a ErrPackageFileNotExist.
+func IsErrPackageFileNotExist(err error) bool {
+	_, ok := err.(ErrPackageFileNotExist)
+	return ok
+}
+
+func (err ErrPackageFileNotExist) Error() string {
+	return fmt.Sprintf("package file does not exist [version_id: %d, name: %s]", err.VersionID, err.Name)
+}
+
+// ErrPackageFileAlreadyExist represents a "PackageFileAlreadyExist" kind of error.
+type ErrPackageFileAlreadyExist struct {
+	VersionID int64
+	Name      string
+}
+
+// IsErrPackageFileAlreadyExist checks if an error is a ErrPackageFileAlreadyExist.
+func IsErrPackageFileAlreadyExist(err error) bool {
+	_, ok := err.(ErrPackageFileAlreadyExist)
+	return ok
+}
+
+func (err ErrPackageFileAlreadyExist) Error() string {
+	return fmt.Sprintf("package file already exists [version_id: %d, name: %s]", err.VersionID, err.Name)
+}
+
+// AddPackageFile adds a file with the given name and blob to the version
+func AddPackageFile(versionID, blobID int64, name string, isLead bool) (*PackageFile, error) {
+	pf := &PackageFile{
+		VersionID: versionID,
+		LowerName: strings.ToLower(name),
+	}
+	has, err := x.Get(pf)
+	if err != nil {
+		return nil, err
+	} else if has {
+		return nil, ErrPackageFileAlreadyExist{VersionID: versionID, Name: name}
+	}
+
+	pf.BlobID = blobID
+	pf.Name = name
+	pf.IsLead = isLead
+	if _, err = x.Insert(pf); err != nil {
+		return nil, err
+	}
+	return pf, nil
+}
+
+// GetPackageFilesByVersionID returns all files of the version
+func GetPackageFilesByVersionID(versionID int64) ([]*PackageFile, error) {
+	pfs := make([]*PackageFile, 0, 5)
+	return pfs, x.Where("version_id = ?", versionID).Asc("id").Find(&pfs)
+}
+
+// GetPackageFileByName returns the file of the version with the given name
+func GetPackageFileByName(versionID int64, name string) (*PackageFile, error) {
+	pf := &PackageFile{
+		VersionID: versionID,
+		LowerName: strings.ToLower(name),
+	}
+	has, err := x.Get(pf)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageFileNotExist{VersionID: versionID, Name: name}
+	}
+	return pf, nil
+}
+
+// GetPackageFileByBlobHash returns a file of any version of the package whose
+// blob has the given sha256 hash. If leadOnly is set only lead files are searched.
+func GetPackageFileByBlobHash(packageID int64, hashSHA256 string, leadOnly bool) (*PackageFile, error) {
+	sess := x.
+		Join("INNER", "package_version", "package_version.id = package_file.version_id").
+		Join("INNER", "package_blob", "package_blob.id = package_file.blob_id").
+		Where("package_version.package_id = ? AND package_blob.hash_sha256 = ?", packageID, strings.ToLower(hashSHA256))
+	if leadOnly {
+		sess.And("package_file.is_lead = ?", true)
+	}
+
+	pf := new(PackageFile)
+	has, err := sess.Get(pf)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageFileNotExist{Name: hashSHA256}
+	}
+	return pf, nil
+}
+
+// DeletePackageFile removes the file from its version
+func DeletePackageFile(pf *PackageFile) error {
+	_, err := x.ID(pf.ID).Delete(new(PackageFile))
+	return err
+}
+
+// PackageBlob represents the content of one or more package files.
+// Blobs are deduplicated by their sha256 hash.
+type PackageBlob struct {
+	ID          int64              `xorm:"pk autoincr"`
+	Size        int64              `xorm:"NOT NULL DEFAULT 0"`
+	HashMD5     string             `xorm:"hash_md5 char(32) NOT NULL"`
+	HashSHA1    string             `xorm:"hash_sha1 char(40) NOT NULL"`
+	HashSHA256  string             `xorm:"hash_sha256 char(64) UNIQUE INDEX NOT NULL"`
+	HashSHA512  string             `xorm:"hash_sha512 char(128) NOT NULL"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// ErrPackageBlobNotExist represents a "PackageBlobNotExist" kind of error.
+type ErrPackageBlobNotExist struct {
+	ID         int64
+	HashSHA256 string
+}
+
+// IsErrPackageBlobNotExist checks if an error is a ErrPackageBlobNotExist.
+func IsErrPackageBlobNotExist(err error) bool {
+	_, ok := err.(ErrPackageBlobNotExist)
+	return ok
+}
+
+func (err ErrPackageBlobNotExist) Error() string {
+	return fmt.Sprintf("package blob does not exist [id: %d, hash_sha256: %s]", err.ID, err.HashSHA256)
+}
+
+// GetPackageBlobByID returns the blob with the given id
+func GetPackageBlobByID(id int64) (*PackageBlob, error) {
+	return getPackageBlobByID(x, id)
+}
+
+func getPackageBlobByID(e Engine, id int64) (*PackageBlob, error) {
+	pb := new(PackageBlob)
+	has, err := e.ID(id).Get(pb)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageBlobNotExist{ID: id}
+	}
+	return pb, nil
+}
+
+// GetOrInsertPackageBlob returns the existing blob with the same sha256 hash or inserts the given blob.
+// The returned bool is true if the blob existed already.
+func GetOrInsertPackageBlob(pb *PackageBlob) (*PackageBlob, bool, error) {
+	existing := &PackageBlob{HashSHA256: pb.HashSHA256}
+	has, err := x.Get(existing)
+	if err != nil {
+		return nil, false, err
+	} else if has {
+		return existing, true, nil
+	}
+	if _, err = x.Insert(pb); err != nil {
+		return nil, false, err
+	}
+	return pb, false, nil
+}
+
+// GetUnreferencedPackageBlobs returns all blobs which are not used by any package file
+func GetUnreferencedPackageBlobs() ([]*PackageBlob, error) {
+	pbs := make([]*PackageBlob, 0, 10)
+	return pbs, x.
+		Where("NOT EXISTS (SELECT 1 FROM package_file WHERE package_file.blob_id = package_blob.id)").
+		Find(&pbs)
+}
+
+// DeletePackageBlobByID removes the blob with the given id
+func DeletePackageBlobByID(id int64) error {
+	_, err := x.ID(id).Delete(new(PackageBlob))
+	return err
+}
+
+// PackageBlobUpload represents an unfinished chunked upload of a blob
+type PackageBlobUpload struct {
+	ID            string             `xorm:"pk"`
+	BytesReceived int64              `xorm:"NOT NULL DEFAULT 0"`
+	CreatedUnix   timeutil.TimeStamp `xorm:"created"`
+	UpdatedUnix   timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// ErrPackageBlobUploadNotExist represents a "PackageBlobUploadNotExist" kind of error.
+type ErrPackageBlobUploadNotExist struct {
+	ID string
+}
+
+// IsErrPackageBlobUploadNotExist checks if an error is a ErrPackageBlobUploadNotExist.
+func IsErrPackageBlobUploadNotExist(err error) bool {
+	_, ok := err.(ErrPackageBlobUploadNotExist)
+	return ok
+}
+
+func (err ErrPackageBlobUploadNotExist) Error() string {
+	return fmt.Sprintf("package blob upload does not exist [id: %s]", err.ID)
+}
+
+// CreatePackageBlobUpload starts a new blob upload
+func CreatePackageBlobUpload() (*PackageBlobUpload, error) {
+	pbu := &PackageBlobUpload{
+		ID: strings.ToLower(gouuid.NewV4().String()),
+	}
+	_, err := x.Insert(pbu)
+	return pbu, err
+}
+
+// GetPackageBlobUploadByID returns the blob upload with the given id
+func GetPackageBlobUploadByID(id string) (*PackageBlobUpload, error) {
+	pbu := new(PackageBlobUpload)
+	has, err := x.ID(id).Get(pbu)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageBlobUploadNotExist{ID: id}
+	}
+	return pbu, nil
+}
+
+// UpdatePackageBlobUpload stores the number of bytes received so far
+func UpdatePackageBlobUpload(pbu *PackageBlobUpload) error {
+	_, err := x.ID(pbu.ID).Cols("bytes_received").Update(pbu)
+	return err
+}
+
+// DeletePackageBlobUploadByID removes the blob upload with the given id
+func DeletePackageBlobUploadByID(id string) error {
+	_, err := x.ID(id).Delete(new(PackageBlobUpload))
+	return err
+}
+
+// GetPackageBlobUploadsOlderThan returns all blob uploads which were not updated since the given time
+func GetPackageBlobUploadsOlderThan(olderThan timeutil.TimeStamp) ([]*PackageBlobUpload, error) {
+	pbus := make([]*PackageBlobUpload, 0, 10)
+	return pbus, x.Where("updated_unix < ?", olderThan).Find(&pbus)
+}
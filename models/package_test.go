@@ -0,0 +1,98 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPackageAccessMode(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	admin := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	user2 := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	user5 := AssertExistsAndLoadBean(t, &User{ID: 5}).(*User)
+	org3 := AssertExistsAndLoadBean(t, &User{ID: 3}).(*User)
+	limitedOrg := AssertExistsAndLoadBean(t, &User{ID: 22}).(*User)
+	privateOrg := AssertExistsAndLoadBean(t, &User{ID: 23}).(*User)
+
+	cases := []struct {
+		Owner    *User
+		Doer     *User
+		Expected AccessMode
+	}{
+		{user2, nil, AccessModeRead},
+		{user2, user2, AccessModeOwner},
+		{user2, user5, AccessModeRead},
+		{user2, admin, AccessModeOwner},
+		{org3, user2, AccessModeOwner},
+		{org3, user5, AccessModeRead},
+		{limitedOrg, nil, AccessModeNone},
+		{limitedOrg, user5, AccessModeRead},
+		{privateOrg, nil, AccessModeNone},
+		{privateOrg, user5, AccessModeNone},
+		{privateOrg, admin, AccessModeOwner},
+	}
+	for _, c := range cases {
+		mode, err := GetPackageAccessMode(c.Owner, c.Doer)
+		assert.NoError(t, err)
+		assert.Equal(t, c.Expected, mode, "owner: %s", c.Owner.Name)
+	}
+}
+
+func TestCreateAndSearchPackageVersions(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	user2 := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	opts := &PackageCreationOptions{
+		Owner:   user2,
+		Creator: user2,
+		Type:    PackageTypeGeneric,
+		Name:    "Test-Package",
+		Version: "1.0.0",
+	}
+	pv, err := CreatePackageVersion(opts)
+	assert.NoError(t, err)
+	assert.NotNil(t, pv)
+
+	_, err = CreatePackageVersion(opts)
+	assert.True(t, IsErrPackageVersionAlreadyExist(err))
+
+	opts.Version = "1.1.0"
+	pv2, created, err := GetOrCreatePackageVersion(opts)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, pv.PackageID, pv2.PackageID)
+
+	opts.Version = "@internal"
+	opts.IsInternal = true
+	_, err = CreatePackageVersion(opts)
+	assert.NoError(t, err)
+
+	p, err := GetPackageByName(user2.ID, PackageTypeGeneric, "test-package")
+	assert.NoError(t, err)
+	assert.Equal(t, "Test-Package", p.Name)
+
+	pvs, count, err := SearchPackageVersions(&PackageSearchOptions{OwnerID: user2.ID})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+	assert.Len(t, pvs, 2)
+
+	_, count, err = SearchPackageVersions(&PackageSearchOptions{OwnerID: user2.ID, Type: PackageTypeNpm})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, count)
+
+	_, count, err = SearchPackageVersions(&PackageSearchOptions{OwnerID: user2.ID, Query: "test"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	assert.NoError(t, DeletePackageVersion(pv))
+	_, count, err = SearchPackageVersions(&PackageSearchOptions{OwnerID: user2.ID})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+}
@@ -0,0 +1,271 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// PackageVersion represents a single version of a package
+type PackageVersion struct {
+	ID           int64    `xorm:"pk autoincr"`
+	PackageID    int64    `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	Package      *Package `xorm:"-"`
+	CreatorID    int64    `xorm:"NOT NULL DEFAULT 0"`
+	Creator      *User    `xorm:"-"`
+	Version      string   `xorm:"NOT NULL"`
+	LowerVersion string   `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	// IsInternal marks versions which are only used to hold files of
+	// a package and which are never shown to users, like unreferenced
+	// container blobs.
+	IsInternal    bool               `xorm:"INDEX NOT NULL DEFAULT false"`
+	MetadataJSON  string             `xorm:"TEXT"`
+	DownloadCount int64              `xorm:"NOT NULL DEFAULT 0"`
+	CreatedUnix   timeutil.TimeStamp `xorm:"INDEX created"`
+}
+
+// LoadAttributes loads the package and the creator of the version
+func (pv *PackageVersion) LoadAttributes() error {
+	return pv.loadAttributes(x)
+}
+
+func (pv *PackageVersion) loadAttributes(e Engine) (err error) {
+	if pv.Package == nil {
+		if pv.Package, err = getPackageByID(e, pv.PackageID); err != nil {
+			return err
+		}
+	}
+	if err = pv.Package.loadAttributes(e); err != nil {
+		return err
+	}
+	if pv.Creator == nil {
+		if pv.Creator, err = getUserByID(e, pv.CreatorID); err != nil {
+			if !IsErrUserNotExist(err) {
+				return fmt.Errorf("getUserByID [%d]: %v", pv.CreatorID, err)
+			}
+			pv.Creator = NewGhostUser()
+		}
+	}
+	return nil
+}
+
+// APIFormat converts a PackageVersion to api.Package, the attributes must be loaded
+func (pv *PackageVersion) APIFormat(doer *User) *api.Package {
+	p := &api.Package{
+		ID:        pv.ID,
+		Owner:     pv.Package.Owner.APIFormat(),
+		Creator:   pv.Creator.APIFormat(),
+		Type:      pv.Package.Type.Name(),
+		Name:      pv.Package.Name,
+		Version:   pv.Version,
+		Downloads: pv.DownloadCount,
+		CreatedAt: pv.CreatedUnix.AsTime(),
+	}
+	if pv.Package.Repo != nil {
+		if perm, err := GetUserRepoPermission(pv.Package.Repo, doer); err == nil && perm.HasAccess() {
+			p.Repository = pv.Package.Repo.APIFormat(perm.AccessMode)
+		}
+	}
+	return p
+}
+
+// ErrPackageVersionNotExist represents a "PackageVersionNotExist" kind of error.
+type ErrPackageVersionNotExist struct {
+	ID        int64
+	PackageID int64
+	Version   string
+}
+
+// IsErrPackageVersionNotExist checks if an error is a ErrPackageVersionNotExist.
+func IsErrPackageVersionNotExist(err error) bool {
+	_, ok := err.(ErrPackageVersionNotExist)
+	return ok
+}
+
+func (err ErrPackageVersionNotExist) Error() string {
+	return fmt.Sprintf("package version does not exist [id: %d, package_id: %d, version: %s]", err.ID, err.PackageID, err.Version)
+}
+
+// ErrPackageVersionAlreadyExist represents a "PackageVersionAlreadyExist" kind of error.
+type ErrPackageVersionAlreadyExist struct {
+	PackageID int64
+	Version   string
+}
+
+// IsErrPackageVersionAlreadyExist checks if an error is a ErrPackageVersionAlreadyExist.
+func IsErrPackageVersionAlreadyExist(err error) bool {
+	_, ok := err.(ErrPackageVersionAlreadyExist)
+	return ok
+}
+
+func (err ErrPackageVersionAlreadyExist) Error() string {
+	return fmt.Sprintf("package version already exists [package_id: %d, version: %s]", err.PackageID, err.Version)
+}
+
+// GetPackageVersionByID returns the package version with the given id
+func GetPackageVersionByID(id int64) (*PackageVersion, error) {
+	return getPackageVersionByID(x, id)
+}
+
+func getPackageVersionByID(e Engine, id int64) (*PackageVersion, error) {
+	pv := new(PackageVersion)
+	has, err := e.ID(id).Get(pv)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageVersionNotExist{ID: id}
+	}
+	return pv, nil
+}
+
+// GetPackageVersionByName returns the version of the package with the given version string
+func GetPackageVersionByName(packageID int64, version string) (*PackageVersion, error) {
+	return getPackageVersionByName(x, packageID, version)
+}
+
+func getPackageVersionByName(e Engine, packageID int64, version string) (*PackageVersion, error) {
+	pv := &PackageVersion{
+		PackageID:    packageID,
+		LowerVersion: strings.ToLower(version),
+	}
+	has, err := e.Get(pv)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPackageVersionNotExist{PackageID: packageID, Version: version}
+	}
+	return pv, nil
+}
+
+// GetPackageVersions returns all public versions of the package, oldest first
+func GetPackageVersions(packageID int64) ([]*PackageVersion, error) {
+	pvs := make([]*PackageVersion, 0, 10)
+	return pvs, x.
+		Where("package_id = ? AND is_internal = ?", packageID, false).
+		Asc("created_unix", "id").
+		Find(&pvs)
+}
+
+// UpdatePackageVersionMetadata updates the metadata of the package version
+func UpdatePackageVersionMetadata(pv *PackageVersion) error {
+	_, err := x.ID(pv.ID).Cols("metadata_json").Update(pv)
+	return err
+}
+
+// IncreasePackageVersionDownloadCount increases the download counter of the version
+func IncreasePackageVersionDownloadCount(versionID int64) error {
+	_, err := x.Exec("UPDATE `package_version` SET download_count = download_count + 1 WHERE id = ?", versionID)
+	return err
+}
+
+// PackageCreationOptions holds the information needed to create a package version
+type PackageCreationOptions struct {
+	Owner        *User
+	Creator      *User
+	Type         PackageType
+	Name         string
+	Version      string
+	IsInternal   bool
+	MetadataJSON string
+}
+
+// GetOrCreatePackageVersion returns the existing version of the package described by the
+// options or creates the package and the version if they don't exist yet.
+// The returned bool is true if the version was created.
+func GetOrCreatePackageVersion(opts *PackageCreationOptions) (*PackageVersion, bool, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, false, err
+	}
+
+	pv, created, err := getOrCreatePackageVersion(sess, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	return pv, created, sess.Commit()
+}
+
+// CreatePackageVersion creates the package version described by the options
+// and returns ErrPackageVersionAlreadyExist if it exists already.
+func CreatePackageVersion(opts *PackageCreationOptions) (*PackageVersion, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	pv, created, err := getOrCreatePackageVersion(sess, opts)
+	if err != nil {
+		return nil, err
+	} else if !created {
+		return nil, ErrPackageVersionAlreadyExist{PackageID: pv.PackageID, Version: pv.Version}
+	}
+	return pv, sess.Commit()
+}
+
+func getOrCreatePackageVersion(e Engine, opts *PackageCreationOptions) (*PackageVersion, bool, error) {
+	p, err := getOrInsertPackage(e, &Package{
+		OwnerID: opts.Owner.ID,
+		Owner:   opts.Owner,
+		Type:    opts.Type,
+		Name:    opts.Name,
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("getOrInsertPackage: %v", err)
+	}
+
+	pv, err := getPackageVersionByName(e, p.ID, opts.Version)
+	if err == nil {
+		pv.Package = p
+		return pv, false, nil
+	} else if !IsErrPackageVersionNotExist(err) {
+		return nil, false, err
+	}
+
+	pv = &PackageVersion{
+		PackageID:    p.ID,
+		Package:      p,
+		CreatorID:    opts.Creator.ID,
+		Creator:      opts.Creator,
+		Version:      opts.Version,
+		LowerVersion: strings.ToLower(opts.Version),
+		IsInternal:   opts.IsInternal,
+		MetadataJSON: opts.MetadataJSON,
+	}
+	if _, err = e.Insert(pv); err != nil {
+		return nil, false, err
+	}
+	if _, err = e.ID(p.ID).Cols("updated_unix").Update(p); err != nil {
+		return nil, false, err
+	}
+	return pv, true, nil
+}
+
+// DeletePackageVersion removes the version and its files. The package is removed as well
+// if this was its last version. Blobs are left in place and have to be cleaned up with
+// DeleteUnreferencedPackageBlobs.
+func DeletePackageVersion(pv *PackageVersion) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Where("version_id = ?", pv.ID).Delete(new(PackageFile)); err != nil {
+		return err
+	}
+	if _, err := sess.ID(pv.ID).Delete(new(PackageVersion)); err != nil {
+		return err
+	}
+	if err := deletePackageIfUnused(sess, pv.PackageID); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
@@ -0,0 +1,347 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+)
+
+// ProjectType represents the owner type of a project
+type ProjectType uint8
+
+const (
+	// ProjectTypeRepository is a project of a single repository
+	ProjectTypeRepository ProjectType = iota + 1
+	// ProjectTypeOrganization is a project of an organization which can contain issues of all its repositories
+	ProjectTypeOrganization
+)
+
+// ProjectBoardTemplate is the set of boards a new project is created with
+type ProjectBoardTemplate string
+
+const (
+	// ProjectBoardTemplateNone creates a project without boards
+	ProjectBoardTemplateNone ProjectBoardTemplate = "none"
+	// ProjectBoardTemplateBasicKanban creates the boards "To Do", "In Progress" and "Done"
+	ProjectBoardTemplateBasicKanban ProjectBoardTemplate = "basic_kanban"
+)
+
+// IsValid checks if the template is known
+func (t ProjectBoardTemplate) IsValid() bool {
+	return t == "" || t == ProjectBoardTemplateNone || t == ProjectBoardTemplateBasicKanban
+}
+
+// Project represents a kanban board of a repository or an organization
+type Project struct {
+	ID          int64       `xorm:"pk autoincr"`
+	Title       string      `xorm:"INDEX NOT NULL"`
+	Description string      `xorm:"TEXT"`
+	RepoID      int64       `xorm:"INDEX"`
+	Repo        *Repository `xorm:"-"`
+	OwnerID     int64       `xorm:"INDEX"`
+	Owner       *User       `xorm:"-"`
+	CreatorID   int64       `xorm:"NOT NULL"`
+	Type        ProjectType
+	IsClosed    bool `xorm:"INDEX"`
+
+	RenderedContent string `xorm:"-"`
+
+	CreatedUnix    timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix    timeutil.TimeStamp `xorm:"INDEX updated"`
+	ClosedDateUnix timeutil.TimeStamp
+}
+
+// ErrProjectNotExist represents a "ProjectNotExist" kind of error.
+type ErrProjectNotExist struct {
+	ID int64
+}
+
+// IsErrProjectNotExist checks if an error is a ErrProjectNotExist
+func IsErrProjectNotExist(err error) bool {
+	_, ok := err.(ErrProjectNotExist)
+	return ok
+}
+
+func (err ErrProjectNotExist) Error() string {
+	return fmt.Sprintf("project does not exist [id: %d]", err.ID)
+}
+
+// State returns string representation of the project status.
+func (p *Project) State() api.StateType {
+	if p.IsClosed {
+		return api.StateClosed
+	}
+	return api.StateOpen
+}
+
+// LoadAttributes loads the repository or the owner of the project
+func (p *Project) LoadAttributes() (err error) {
+	return p.loadAttributes(x)
+}
+
+func (p *Project) loadAttributes(e Engine) (err error) {
+	switch p.Type {
+	case ProjectTypeRepository:
+		if p.Repo == nil {
+			if p.Repo, err = getRepositoryByID(e, p.RepoID); err != nil {
+				return fmt.Errorf("getRepositoryByID [%d]: %v", p.RepoID, err)
+			}
+		}
+	case ProjectTypeOrganization:
+		if p.Owner == nil {
+			if p.Owner, err = getUserByID(e, p.OwnerID); err != nil {
+				return fmt.Errorf("getUserByID [%d]: %v", p.OwnerID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Link returns the relative link to the project board view, the attributes must be loaded
+func (p *Project) Link() string {
+	if p.Type == ProjectTypeRepository && p.Repo != nil {
+		return fmt.Sprintf("%s/projects/%d", p.Repo.Link(), p.ID)
+	}
+	return ""
+}
+
+// CanContainIssue checks if issues of the repository can be added to the project
+func (p *Project) CanContainIssue(issue *Issue) (bool, error) {
+	switch p.Type {
+	case ProjectTypeRepository:
+		return issue.RepoID == p.RepoID, nil
+	case ProjectTypeOrganization:
+		if err := issue.LoadRepo(); err != nil {
+			return false, err
+		}
+		return issue.Repo.OwnerID == p.OwnerID, nil
+	}
+	return false, nil
+}
+
+// APIFormat returns the project in API format
+func (p *Project) APIFormat() *api.Project {
+	apiProject := &api.Project{
+		ID:          p.ID,
+		Title:       p.Title,
+		Description: p.Description,
+		State:       p.State(),
+		Created:     p.CreatedUnix.AsTime(),
+		Updated:     p.UpdatedUnix.AsTime(),
+	}
+	if p.IsClosed {
+		apiProject.Closed = p.ClosedDateUnix.AsTimePtr()
+	}
+	return apiProject
+}
+
+// NewProject creates a new project together with the boards of the template
+func NewProject(p *Project, template ProjectBoardTemplate) error {
+	if !template.IsValid() {
+		return fmt.Errorf("invalid board template: %s", template)
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Insert(p); err != nil {
+		return err
+	}
+
+	if template == ProjectBoardTemplateBasicKanban {
+		boards := []*ProjectBoard{
+			{ProjectID: p.ID, Title: "To Do", IsDefault: true, Sorting: 1},
+			{ProjectID: p.ID, Title: "In Progress", Sorting: 2},
+			{ProjectID: p.ID, Title: "Done", IsDone: true, Sorting: 3},
+		}
+		if _, err := sess.Insert(boards); err != nil {
+			return err
+		}
+	}
+
+	return sess.Commit()
+}
+
+func getProjectByID(e Engine, id int64) (*Project, error) {
+	p := new(Project)
+	has, err := e.ID(id).Get(p)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectNotExist{ID: id}
+	}
+	return p, nil
+}
+
+// GetProjectByID returns the project with the given id
+func GetProjectByID(id int64) (*Project, error) {
+	return getProjectByID(x, id)
+}
+
+// GetProjectByRepoID returns the project of the repository with the given id
+func GetProjectByRepoID(repoID, id int64) (*Project, error) {
+	p, err := getProjectByID(x, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Type != ProjectTypeRepository || p.RepoID != repoID {
+		return nil, ErrProjectNotExist{ID: id}
+	}
+	return p, nil
+}
+
+// GetProjectByOwnerID returns the organization project with the given id
+func GetProjectByOwnerID(ownerID, id int64) (*Project, error) {
+	p, err := getProjectByID(x, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Type != ProjectTypeOrganization || p.OwnerID != ownerID {
+		return nil, ErrProjectNotExist{ID: id}
+	}
+	return p, nil
+}
+
+// FindProjectsOptions represents the options to search projects
+type FindProjectsOptions struct {
+	RepoID   int64
+	OwnerID  int64
+	Type     ProjectType
+	IsClosed *bool
+	Page     int
+	PageSize int
+}
+
+func (opts *FindProjectsOptions) toConds() builder.Cond {
+	cond := builder.NewCond()
+	if opts.RepoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": opts.RepoID})
+	}
+	if opts.OwnerID > 0 {
+		cond = cond.And(builder.Eq{"owner_id": opts.OwnerID})
+	}
+	if opts.Type > 0 {
+		cond = cond.And(builder.Eq{"type": opts.Type})
+	}
+	if opts.IsClosed != nil {
+		cond = cond.And(builder.Eq{"is_closed": *opts.IsClosed})
+	}
+	return cond
+}
+
+// FindProjects returns the projects matching the options, the newest first, and the total count
+func FindProjects(opts *FindProjectsOptions) ([]*Project, int64, error) {
+	cond := opts.toConds()
+
+	count, err := x.Where(cond).Count(new(Project))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sess := x.Where(cond).Desc("id")
+	if opts.PageSize > 0 {
+		page := opts.Page
+		if page <= 0 {
+			page = 1
+		}
+		sess = sess.Limit(opts.PageSize, (page-1)*opts.PageSize)
+	}
+
+	projects := make([]*Project, 0, opts.PageSize)
+	return projects, count, sess.Find(&projects)
+}
+
+// CountProjects returns the number of projects matching the options
+func CountProjects(opts *FindProjectsOptions) (int64, error) {
+	return x.Where(opts.toConds()).Count(new(Project))
+}
+
+// UpdateProject updates the title and the description of the project
+func UpdateProject(p *Project) error {
+	_, err := x.ID(p.ID).Cols("title", "description").Update(p)
+	return err
+}
+
+// ChangeProjectStatus closes or reopens the project
+func ChangeProjectStatus(p *Project, isClosed bool) error {
+	p.IsClosed = isClosed
+	if isClosed {
+		p.ClosedDateUnix = timeutil.TimeStampNow()
+	} else {
+		p.ClosedDateUnix = 0
+	}
+	_, err := x.ID(p.ID).Cols("is_closed", "closed_date_unix").Update(p)
+	return err
+}
+
+// DeleteProjectByID removes the project with its boards and cards
+func DeleteProjectByID(id int64) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if err := deleteProjectByID(sess, id); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+func deleteProjectByID(e Engine, id int64) error {
+	if _, err := e.Where("project_id = ?", id).Delete(new(ProjectIssue)); err != nil {
+		return err
+	}
+	if _, err := e.Where("project_id = ?", id).Delete(new(ProjectBoard)); err != nil {
+		return err
+	}
+	_, err := e.ID(id).Delete(new(Project))
+	return err
+}
+
+// deleteProjectsOfRepo removes the projects of the repository and the cards of its issues in organization projects
+func deleteProjectsOfRepo(e Engine, repoID int64) error {
+	projectIDs := make([]int64, 0, 5)
+	if err := e.Table("project").
+		Where("repo_id = ? AND type = ?", repoID, ProjectTypeRepository).
+		Cols("id").
+		Find(&projectIDs); err != nil {
+		return err
+	}
+	for _, id := range projectIDs {
+		if err := deleteProjectByID(e, id); err != nil {
+			return err
+		}
+	}
+
+	_, err := e.Where(builder.In("issue_id", builder.Select("id").From("issue").Where(builder.Eq{"repo_id": repoID}))).
+		Delete(new(ProjectIssue))
+	return err
+}
+
+// deleteProjectsOfOwner removes the organization projects of the owner
+func deleteProjectsOfOwner(e Engine, ownerID int64) error {
+	projectIDs := make([]int64, 0, 5)
+	if err := e.Table("project").
+		Where("owner_id = ? AND type = ?", ownerID, ProjectTypeOrganization).
+		Cols("id").
+		Find(&projectIDs); err != nil {
+		return err
+	}
+	for _, id := range projectIDs {
+		if err := deleteProjectByID(e, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// ProjectBoard is a column of a project. Issues of a project which are not placed
+// on any board have the board id 0 and are shown as uncategorized.
+type ProjectBoard struct {
+	ID        int64  `xorm:"pk autoincr"`
+	ProjectID int64  `xorm:"INDEX NOT NULL"`
+	Title     string `xorm:"NOT NULL"`
+	// IsDefault marks the board reopened issues are moved to
+	IsDefault bool `xorm:"NOT NULL DEFAULT false"`
+	// IsDone marks the board closed issues are moved to
+	IsDone  bool `xorm:"NOT NULL DEFAULT false"`
+	Sorting int  `xorm:"NOT NULL DEFAULT 0"`
+
+	Issues []*Issue `xorm:"-"`
+
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// ErrProjectBoardNotExist represents a "ProjectBoardNotExist" kind of error.
+type ErrProjectBoardNotExist struct {
+	ID int64
+}
+
+// IsErrProjectBoardNotExist checks if an error is a ErrProjectBoardNotExist
+func IsErrProjectBoardNotExist(err error) bool {
+	_, ok := err.(ErrProjectBoardNotExist)
+	return ok
+}
+
+func (err ErrProjectBoardNotExist) Error() string {
+	return fmt.Sprintf("project board does not exist [id: %d]", err.ID)
+}
+
+// APIFormat returns the board in API format
+func (b *ProjectBoard) APIFormat() *api.ProjectBoard {
+	return &api.ProjectBoard{
+		ID:        b.ID,
+		Title:     b.Title,
+		IsDefault: b.IsDefault,
+		IsDone:    b.IsDone,
+		Sorting:   b.Sorting,
+	}
+}
+
+// LoadIssues loads the issues on the board ordered by their position
+func (b *ProjectBoard) LoadIssues() (err error) {
+	b.Issues, err = getProjectBoardIssues(x, b.ProjectID, b.ID)
+	return err
+}
+
+// resetProjectBoardFlags makes sure only the given board of the project is marked as default or done board
+func resetProjectBoardFlags(e Engine, b *ProjectBoard) error {
+	if b.IsDefault {
+		if _, err := e.Where("project_id = ? AND id <> ?", b.ProjectID, b.ID).
+			Cols("is_default").
+			Update(&ProjectBoard{IsDefault: false}); err != nil {
+			return err
+		}
+	}
+	if b.IsDone {
+		if _, err := e.Where("project_id = ? AND id <> ?", b.ProjectID, b.ID).
+			Cols("is_done").
+			Update(&ProjectBoard{IsDone: false}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewProjectBoard adds a board after the existing boards of the project
+func NewProjectBoard(b *ProjectBoard) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	var maxSorting int
+	if _, err := sess.Table("project_board").
+		Select("coalesce(MAX(sorting),0)").
+		Where("project_id = ?", b.ProjectID).
+		Get(&maxSorting); err != nil {
+		return err
+	}
+	b.Sorting = maxSorting + 1
+
+	if _, err := sess.Insert(b); err != nil {
+		return err
+	}
+	if err := resetProjectBoardFlags(sess, b); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+func getProjectBoard(e Engine, projectID, boardID int64) (*ProjectBoard, error) {
+	b := new(ProjectBoard)
+	has, err := e.Where("project_id = ? AND id = ?", projectID, boardID).Get(b)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectBoardNotExist{ID: boardID}
+	}
+	return b, nil
+}
+
+// GetProjectBoard returns the board of the project
+func GetProjectBoard(projectID, boardID int64) (*ProjectBoard, error) {
+	return getProjectBoard(x, projectID, boardID)
+}
+
+func getProjectBoards(e Engine, projectID int64) ([]*ProjectBoard, error) {
+	boards := make([]*ProjectBoard, 0, 5)
+	return boards, e.Where("project_id = ?", projectID).Asc("sorting", "id").Find(&boards)
+}
+
+// GetProjectBoards returns the boards of the project in their display order
+func GetProjectBoards(projectID int64) ([]*ProjectBoard, error) {
+	return getProjectBoards(x, projectID)
+}
+
+// UpdateProjectBoard updates the title, the sorting and the flags of the board
+func UpdateProjectBoard(b *ProjectBoard) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.ID(b.ID).Cols("title", "is_default", "is_done", "sorting").Update(b); err != nil {
+		return err
+	}
+	if err := resetProjectBoardFlags(sess, b); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// DeleteProjectBoard removes the board, its issues become uncategorized
+func DeleteProjectBoard(b *ProjectBoard) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Where("project_board_id = ?", b.ID).
+		Cols("project_board_id").
+		Update(&ProjectIssue{ProjectBoardID: 0}); err != nil {
+		return err
+	}
+	if _, err := sess.ID(b.ID).Delete(new(ProjectBoard)); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
@@ -0,0 +1,235 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+)
+
+// ProjectIssue places an issue or a pull request on a board of a project
+type ProjectIssue struct {
+	ID             int64 `xorm:"pk autoincr"`
+	IssueID        int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	ProjectID      int64 `xorm:"UNIQUE(s) INDEX NOT NULL"`
+	ProjectBoardID int64 `xorm:"INDEX NOT NULL DEFAULT 0"`
+	Sorting        int64 `xorm:"NOT NULL DEFAULT 0"`
+}
+
+// ErrProjectIssueNotExist represents a "ProjectIssueNotExist" kind of error.
+type ErrProjectIssueNotExist struct {
+	ProjectID int64
+	IssueID   int64
+}
+
+// IsErrProjectIssueNotExist checks if an error is a ErrProjectIssueNotExist
+func IsErrProjectIssueNotExist(err error) bool {
+	_, ok := err.(ErrProjectIssueNotExist)
+	return ok
+}
+
+func (err ErrProjectIssueNotExist) Error() string {
+	return fmt.Sprintf("issue is not part of the project [project_id: %d, issue_id: %d]", err.ProjectID, err.IssueID)
+}
+
+// ErrProjectIssueNotAllowed represents an issue which can not be added to a project
+type ErrProjectIssueNotAllowed struct {
+	ProjectID int64
+	IssueID   int64
+}
+
+// IsErrProjectIssueNotAllowed checks if an error is a ErrProjectIssueNotAllowed
+func IsErrProjectIssueNotAllowed(err error) bool {
+	_, ok := err.(ErrProjectIssueNotAllowed)
+	return ok
+}
+
+func (err ErrProjectIssueNotAllowed) Error() string {
+	return fmt.Sprintf("issue can not be added to the project [project_id: %d, issue_id: %d]", err.ProjectID, err.IssueID)
+}
+
+func getProjectBoardIssues(e Engine, projectID, boardID int64) ([]*Issue, error) {
+	issues := make([]*Issue, 0, 10)
+	if err := e.Join("INNER", "project_issue", "project_issue.issue_id = issue.id").
+		Where("project_issue.project_id = ? AND project_issue.project_board_id = ?", projectID, boardID).
+		Asc("project_issue.sorting", "project_issue.id").
+		Find(&issues); err != nil {
+		return nil, err
+	}
+	return issues, IssueList(issues).loadAttributes(e)
+}
+
+// GetUncategorizedProjectIssues returns the issues of the project which are not placed on a board
+func GetUncategorizedProjectIssues(projectID int64) ([]*Issue, error) {
+	return getProjectBoardIssues(x, projectID, 0)
+}
+
+// CountProjectIssues returns the number of open and closed issues of the project
+func CountProjectIssues(projectID int64) (open, closed int64, err error) {
+	type result struct {
+		IsClosed bool
+		Count    int64
+	}
+	results := make([]*result, 0, 2)
+	if err = x.Table("project_issue").
+		Join("INNER", "issue", "project_issue.issue_id = issue.id").
+		Where("project_issue.project_id = ?", projectID).
+		GroupBy("issue.is_closed").
+		Select("issue.is_closed AS is_closed, COUNT(*) AS count").
+		Find(&results); err != nil {
+		return 0, 0, err
+	}
+	for _, r := range results {
+		if r.IsClosed {
+			closed = r.Count
+		} else {
+			open = r.Count
+		}
+	}
+	return open, closed, nil
+}
+
+// GetProjectsByIssueID returns the projects the issue is part of
+func GetProjectsByIssueID(issueID int64) ([]*Project, error) {
+	projects := make([]*Project, 0, 2)
+	return projects, x.Join("INNER", "project_issue", "project_issue.project_id = project.id").
+		Where("project_issue.issue_id = ?", issueID).
+		Asc("project.id").
+		Find(&projects)
+}
+
+// MoveProjectIssue places the issue at the position of the board. The issue is added to the
+// project if it is not part of it yet. A negative position appends the issue to the board.
+func MoveProjectIssue(p *Project, issue *Issue, boardID int64, position int) error {
+	allowed, err := p.CanContainIssue(issue)
+	if err != nil {
+		return err
+	} else if !allowed {
+		return ErrProjectIssueNotAllowed{ProjectID: p.ID, IssueID: issue.ID}
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if boardID != 0 {
+		if _, err := getProjectBoard(sess, p.ID, boardID); err != nil {
+			return err
+		}
+	}
+
+	if err := moveProjectIssue(sess, p.ID, issue.ID, boardID, position); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+func moveProjectIssue(e Engine, projectID, issueID, boardID int64, position int) error {
+	pi := new(ProjectIssue)
+	has, err := e.Where("project_id = ? AND issue_id = ?", projectID, issueID).Get(pi)
+	if err != nil {
+		return err
+	}
+	if !has {
+		pi = &ProjectIssue{
+			ProjectID: projectID,
+			IssueID:   issueID,
+		}
+		if _, err := e.Insert(pi); err != nil {
+			return err
+		}
+	}
+
+	cards := make([]*ProjectIssue, 0, 10)
+	if err := e.Where("project_id = ? AND project_board_id = ? AND id <> ?", projectID, boardID, pi.ID).
+		Asc("sorting", "id").
+		Find(&cards); err != nil {
+		return err
+	}
+
+	if position < 0 || position > len(cards) {
+		position = len(cards)
+	}
+	pi.ProjectBoardID = boardID
+	cards = append(cards[:position], append([]*ProjectIssue{pi}, cards[position:]...)...)
+
+	for i, card := range cards {
+		if card.ID != pi.ID && card.Sorting == int64(i) {
+			continue
+		}
+		card.Sorting = int64(i)
+		if _, err := e.ID(card.ID).Cols("project_board_id", "sorting").Update(card); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveIssueFromProject removes the issue from the project
+func RemoveIssueFromProject(projectID, issueID int64) error {
+	affected, err := x.Where("project_id = ? AND issue_id = ?", projectID, issueID).Delete(new(ProjectIssue))
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrProjectIssueNotExist{ProjectID: projectID, IssueID: issueID}
+	}
+	return nil
+}
+
+// MoveProjectIssueOnStatusChange moves the cards of a closed issue to the done board of their
+// projects. The cards of a reopened issue are moved from the done board to the default board.
+func MoveProjectIssueOnStatusChange(issue *Issue) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	cards := make([]*ProjectIssue, 0, 2)
+	if err := sess.Where("issue_id = ?", issue.ID).Find(&cards); err != nil {
+		return err
+	}
+
+	for _, card := range cards {
+		boards, err := getProjectBoards(sess, card.ProjectID)
+		if err != nil {
+			return err
+		}
+
+		var current, target *ProjectBoard
+		for _, b := range boards {
+			if b.ID == card.ProjectBoardID {
+				current = b
+			}
+			if issue.IsClosed && b.IsDone {
+				target = b
+			} else if !issue.IsClosed && b.IsDefault {
+				target = b
+			}
+		}
+
+		if issue.IsClosed {
+			if target == nil || target == current {
+				continue
+			}
+		} else {
+			// Only issues which have been moved to the done board by closing them are moved back
+			if current == nil || !current.IsDone {
+				continue
+			}
+		}
+
+		var targetID int64
+		if target != nil {
+			targetID = target.ID
+		}
+		if err := moveProjectIssue(sess, card.ProjectID, issue.ID, targetID, -1); err != nil {
+			return err
+		}
+	}
+
+	return sess.Commit()
+}
@@ -0,0 +1,145 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProject(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	p := &Project{
+		RepoID:    1,
+		Title:     "New project",
+		CreatorID: 2,
+		Type:      ProjectTypeRepository,
+	}
+	assert.NoError(t, NewProject(p, ProjectBoardTemplateBasicKanban))
+	AssertExistsAndLoadBean(t, &Project{ID: p.ID, RepoID: 1})
+
+	boards, err := GetProjectBoards(p.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, boards, 3) {
+		assert.Equal(t, "To Do", boards[0].Title)
+		assert.True(t, boards[0].IsDefault)
+		assert.Equal(t, "Done", boards[2].Title)
+		assert.True(t, boards[2].IsDone)
+	}
+
+	assert.Error(t, NewProject(&Project{RepoID: 1, Title: "Invalid"}, "unknown"))
+}
+
+func TestFindProjects(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	isClosed := false
+	projects, count, err := FindProjects(&FindProjectsOptions{RepoID: 1, Type: ProjectTypeRepository, IsClosed: &isClosed})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	if assert.Len(t, projects, 1) {
+		assert.EqualValues(t, 1, projects[0].ID)
+	}
+
+	projects, count, err = FindProjects(&FindProjectsOptions{OwnerID: 3, Type: ProjectTypeOrganization})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	assert.Len(t, projects, 1)
+
+	_, err = GetProjectByRepoID(2, 1)
+	assert.True(t, IsErrProjectNotExist(err))
+	_, err = GetProjectByOwnerID(3, 1)
+	assert.True(t, IsErrProjectNotExist(err))
+}
+
+func TestCountProjectIssues(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	open, closed, err := CountProjectIssues(1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, open)
+	assert.EqualValues(t, 1, closed)
+}
+
+func TestMoveProjectIssue(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	p := AssertExistsAndLoadBean(t, &Project{ID: 1}).(*Project)
+	issue1 := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	issue3 := AssertExistsAndLoadBean(t, &Issue{ID: 3}).(*Issue)
+
+	// Add a new issue in front of the first card of the board
+	assert.NoError(t, MoveProjectIssue(p, issue3, 1, 0))
+	board := AssertExistsAndLoadBean(t, &ProjectBoard{ID: 1}).(*ProjectBoard)
+	assert.NoError(t, board.LoadIssues())
+	if assert.Len(t, board.Issues, 2) {
+		assert.EqualValues(t, 3, board.Issues[0].ID)
+		assert.EqualValues(t, 1, board.Issues[1].ID)
+	}
+
+	// Move an issue to another board
+	assert.NoError(t, MoveProjectIssue(p, issue1, 2, -1))
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 1, ProjectID: 1, ProjectBoardID: 2, Sorting: 1})
+
+	// Issues of other repositories can not be added
+	issue4 := AssertExistsAndLoadBean(t, &Issue{ID: 4}).(*Issue)
+	assert.True(t, IsErrProjectIssueNotAllowed(MoveProjectIssue(p, issue4, 1, -1)))
+
+	// Boards of other projects can not be used
+	assert.True(t, IsErrProjectBoardNotExist(MoveProjectIssue(p, issue1, 4, -1)))
+
+	assert.NoError(t, RemoveIssueFromProject(1, 1))
+	AssertNotExistsBean(t, &ProjectIssue{IssueID: 1, ProjectID: 1})
+	assert.True(t, IsErrProjectIssueNotExist(RemoveIssueFromProject(1, 1)))
+}
+
+func TestOrganizationProjectCanContainIssue(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	p := AssertExistsAndLoadBean(t, &Project{ID: 3}).(*Project)
+
+	canContain, err := p.CanContainIssue(AssertExistsAndLoadBean(t, &Issue{ID: 6}).(*Issue))
+	assert.NoError(t, err)
+	assert.True(t, canContain)
+
+	canContain, err = p.CanContainIssue(AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue))
+	assert.NoError(t, err)
+	assert.False(t, canContain)
+}
+
+func TestMoveProjectIssueOnStatusChange(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	issue.IsClosed = true
+	assert.NoError(t, MoveProjectIssueOnStatusChange(issue))
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 1, ProjectID: 1, ProjectBoardID: 3})
+
+	issue.IsClosed = false
+	assert.NoError(t, MoveProjectIssueOnStatusChange(issue))
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 1, ProjectID: 1, ProjectBoardID: 1})
+
+	// Reopened issues which are not on the done board stay on their board
+	issue = AssertExistsAndLoadBean(t, &Issue{ID: 2}).(*Issue)
+	assert.NoError(t, MoveProjectIssueOnStatusChange(issue))
+	AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 2, ProjectID: 1, ProjectBoardID: 2})
+}
+
+func TestDeleteProjectBoard(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	board := AssertExistsAndLoadBean(t, &ProjectBoard{ID: 2}).(*ProjectBoard)
+	assert.NoError(t, DeleteProjectBoard(board))
+	AssertNotExistsBean(t, &ProjectBoard{ID: 2})
+	card := AssertExistsAndLoadBean(t, &ProjectIssue{IssueID: 2, ProjectID: 1}).(*ProjectIssue)
+	assert.Zero(t, card.ProjectBoardID)
+
+	assert.NoError(t, DeleteProjectByID(1))
+	AssertNotExistsBean(t, &Project{ID: 1})
+	AssertNotExistsBean(t, &ProjectBoard{ProjectID: 1})
+	AssertNotExistsBean(t, &ProjectIssue{ProjectID: 1})
+}
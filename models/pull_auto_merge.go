@@ -0,0 +1,131 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// PullAutoMerge represents a pull request scheduled to be merged when all checks succeed
+type PullAutoMerge struct {
+	ID          int64              `xorm:"pk autoincr"`
+	PullID      int64              `xorm:"UNIQUE"`
+	DoerID      int64              `xorm:"NOT NULL"`
+	Doer        *User              `xorm:"-"`
+	MergeStyle  MergeStyle         `xorm:"varchar(30)"`
+	Message     string             `xorm:"LONGTEXT"`
+	CreatedUnix timeutil.TimeStamp `xorm:"created"`
+}
+
+func (m *PullAutoMerge) loadDoer(e Engine) (err error) {
+	if m.Doer == nil {
+		m.Doer, err = getUserByID(e, m.DoerID)
+	}
+	return err
+}
+
+// createAutoMergeComment adds a comment of the given type to the issue of the pull request
+func createAutoMergeComment(e *xorm.Session, typ CommentType, doer *User, pr *PullRequest, style MergeStyle) error {
+	if err := pr.loadIssue(e); err != nil {
+		return err
+	}
+	if err := pr.Issue.loadRepo(e); err != nil {
+		return err
+	}
+	_, err := createComment(e, &CreateCommentOptions{
+		Type:    typ,
+		Doer:    doer,
+		Repo:    pr.Issue.Repo,
+		Issue:   pr.Issue,
+		Content: string(style),
+	})
+	return err
+}
+
+// ScheduleAutoMerge schedules the pull request to be merged by doer with the merge style when all checks succeed
+func ScheduleAutoMerge(doer *User, pr *PullRequest, style MergeStyle, message string) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if exist, err := sess.Exist(&PullAutoMerge{PullID: pr.ID}); err != nil {
+		return err
+	} else if exist {
+		return ErrPullRequestAlreadyScheduledToAutoMerge{PullID: pr.ID}
+	}
+
+	if _, err := sess.Insert(&PullAutoMerge{
+		PullID:     pr.ID,
+		DoerID:     doer.ID,
+		MergeStyle: style,
+		Message:    message,
+	}); err != nil {
+		return err
+	}
+	if err := createAutoMergeComment(sess, CommentTypePRScheduledToAutoMerge, doer, pr, style); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetScheduledAutoMergeByPullID returns the scheduled merge of the pull request with its doer
+func GetScheduledAutoMergeByPullID(pullID int64) (bool, *PullAutoMerge, error) {
+	autoMerge := &PullAutoMerge{}
+	exist, err := x.Where("pull_id = ?", pullID).Get(autoMerge)
+	if err != nil || !exist {
+		return false, nil, err
+	}
+	if err = autoMerge.loadDoer(x); err != nil {
+		return false, nil, err
+	}
+	return true, autoMerge, nil
+}
+
+// GetScheduledAutoMergePullIDsByBaseRepo returns the ids of the open pull requests into the repository
+// which are scheduled to be merged when all checks succeed
+func GetScheduledAutoMergePullIDsByBaseRepo(repoID int64) ([]int64, error) {
+	ids := make([]int64, 0, 10)
+	return ids, x.Table("pull_auto_merge").
+		Join("INNER", "pull_request", "pull_request.id = pull_auto_merge.pull_id").
+		Where(builder.Eq{"pull_request.base_repo_id": repoID, "pull_request.has_merged": false}).
+		Cols("pull_auto_merge.pull_id").
+		Find(&ids)
+}
+
+// GetScheduledAutoMergePullIDs returns the ids of all pull requests scheduled to be merged
+// when all checks succeed
+func GetScheduledAutoMergePullIDs() ([]int64, error) {
+	ids := make([]int64, 0, 10)
+	return ids, x.Table("pull_auto_merge").Cols("pull_id").Find(&ids)
+}
+
+// RemoveScheduledAutoMerge cancels the scheduled merge of the pull request, the cancellation is
+// recorded as comment of doer unless doer is nil. It returns false if no merge was scheduled.
+func RemoveScheduledAutoMerge(doer *User, pr *PullRequest) (bool, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return false, err
+	}
+
+	autoMerge := &PullAutoMerge{}
+	if exist, err := sess.Where("pull_id = ?", pr.ID).Get(autoMerge); err != nil || !exist {
+		return false, err
+	}
+	if _, err := sess.ID(autoMerge.ID).Delete(&PullAutoMerge{}); err != nil {
+		return false, err
+	}
+	if doer != nil {
+		if err := createAutoMergeComment(sess, CommentTypePRUnScheduledToAutoMerge, doer, pr, autoMerge.MergeStyle); err != nil {
+			return false, err
+		}
+	}
+	return true, sess.Commit()
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleAutoMerge(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+
+	assert.NoError(t, ScheduleAutoMerge(doer, pr, MergeStyleSquash, "squashed"))
+	AssertExistsAndLoadBean(t, &Comment{Type: CommentTypePRScheduledToAutoMerge, IssueID: pr.IssueID, PosterID: doer.ID, Content: "squash"})
+
+	err := ScheduleAutoMerge(doer, pr, MergeStyleMerge, "")
+	assert.True(t, IsErrPullRequestAlreadyScheduledToAutoMerge(err))
+
+	scheduled, autoMerge, err := GetScheduledAutoMergeByPullID(pr.ID)
+	assert.NoError(t, err)
+	assert.True(t, scheduled)
+	assert.EqualValues(t, MergeStyleSquash, autoMerge.MergeStyle)
+	assert.EqualValues(t, "squashed", autoMerge.Message)
+	assert.EqualValues(t, doer.ID, autoMerge.Doer.ID)
+
+	ids, err := GetScheduledAutoMergePullIDsByBaseRepo(pr.BaseRepoID)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []int64{pr.ID}, ids)
+	ids, err = GetScheduledAutoMergePullIDsByBaseRepo(10)
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	removed, err := RemoveScheduledAutoMerge(doer, pr)
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	AssertExistsAndLoadBean(t, &Comment{Type: CommentTypePRUnScheduledToAutoMerge, IssueID: pr.IssueID, PosterID: doer.ID})
+	AssertNotExistsBean(t, &PullAutoMerge{PullID: pr.ID})
+
+	removed, err = RemoveScheduledAutoMerge(doer, pr)
+	assert.NoError(t, err)
+	assert.False(t, removed)
+	scheduled, _, err = GetScheduledAutoMergeByPullID(pr.ID)
+	assert.NoError(t, err)
+	assert.False(t, scheduled)
+}
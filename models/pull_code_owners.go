@@ -0,0 +1,251 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"code.gitea.io/gitea/modules/codeowners"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+)
+
+// maxCodeOwnersFileSize is the maximum size of a CODEOWNERS file which is read
+const maxCodeOwnersFileSize = 512 * 1024
+
+// CodeOwners represents the users and teams owning a file changed by a pull request
+type CodeOwners struct {
+	Path  string
+	Users []*User
+	Teams []*Team
+}
+
+// isApprovedBy returns true if one of the users is an owner or a member of an owning team
+func (owners *CodeOwners) isApprovedBy(e Engine, userIDs []int64) (bool, error) {
+	teamIDs := make([]int64, 0, len(owners.Teams))
+	for _, team := range owners.Teams {
+		teamIDs = append(teamIDs, team.ID)
+	}
+	for _, userID := range userIDs {
+		for _, user := range owners.Users {
+			if user.ID == userID {
+				return true, nil
+			}
+		}
+		if len(teamIDs) > 0 {
+			if inTeam, err := isUserInTeams(e, userID, teamIDs); err != nil || inTeam {
+				return inTeam, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// codeOwnersResolver resolves the owners listed in a CODEOWNERS file of a repository to users
+// and teams which can access the pull requests of the repository, owners which can't are ignored
+type codeOwnersResolver struct {
+	e     Engine
+	repo  *Repository
+	users map[string]*User
+	teams map[string]*Team
+}
+
+func (r *codeOwnersResolver) resolve(owner string) (*User, *Team, error) {
+	if user, ok := r.users[owner]; ok {
+		return user, nil, nil
+	} else if team, ok := r.teams[owner]; ok {
+		return nil, team, nil
+	}
+
+	user, team, err := r.lookup(owner)
+	if err != nil {
+		return nil, nil, err
+	}
+	r.users[owner] = user
+	r.teams[owner] = team
+	return user, team, nil
+}
+
+func (r *codeOwnersResolver) lookup(owner string) (*User, *Team, error) {
+	if !strings.HasPrefix(owner, "@") {
+		user, err := GetUserByEmail(owner)
+		if err != nil {
+			if IsErrUserNotExist(err) {
+				return nil, nil, nil
+			}
+			return nil, nil, err
+		}
+		return r.checkUser(user)
+	}
+
+	owner = strings.TrimPrefix(owner, "@")
+	if idx := strings.IndexByte(owner, '/'); idx >= 0 {
+		if err := r.repo.getOwner(r.e); err != nil {
+			return nil, nil, err
+		}
+		if !r.repo.Owner.IsOrganization() || !strings.EqualFold(owner[:idx], r.repo.Owner.Name) {
+			return nil, nil, nil
+		}
+		team, err := getTeam(r.e, r.repo.OwnerID, owner[idx+1:])
+		if err != nil {
+			if IsErrTeamNotExist(err) {
+				return nil, nil, nil
+			}
+			return nil, nil, err
+		}
+		if !team.IncludesAllRepositories && !team.hasRepository(r.e, r.repo.ID) {
+			return nil, nil, nil
+		}
+		return nil, team, nil
+	}
+
+	user, err := getUserByName(r.e, owner)
+	if err != nil {
+		if IsErrUserNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	return r.checkUser(user)
+}
+
+func (r *codeOwnersResolver) checkUser(user *User) (*User, *Team, error) {
+	if user.IsOrganization() || !user.IsActive || user.ProhibitLogin {
+		return nil, nil, nil
+	}
+	perm, err := getUserRepoPermission(r.e, r.repo, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !perm.CanRead(UnitTypePullRequests) {
+		return nil, nil, nil
+	}
+	return user, nil, nil
+}
+
+// readCodeOwnersRules reads the rules of the CODEOWNERS file of the commit, it returns nil
+// if the commit has no CODEOWNERS file
+func readCodeOwnersRules(commit *git.Commit) ([]*codeowners.Rule, error) {
+	for _, name := range codeowners.FileNames {
+		blob, err := commit.GetBlobByPath(name)
+		if err != nil {
+			if git.IsErrNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		dataRc, err := blob.DataAsync()
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(io.LimitReader(dataRc, maxCodeOwnersFileSize))
+		dataRc.Close()
+		if err != nil {
+			return nil, err
+		}
+		return codeowners.Parse(string(content)), nil
+	}
+	return nil, nil
+}
+
+// GetCodeOwners returns the owners of the files changed by the pull request according to the
+// CODEOWNERS file of the base branch. Files without owners are omitted.
+func (pr *PullRequest) GetCodeOwners() ([]*CodeOwners, error) {
+	if err := pr.GetBaseRepo(); err != nil {
+		return nil, err
+	}
+
+	gitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		return nil, fmt.Errorf("OpenRepository: %v", err)
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetBranchCommit(pr.BaseBranch)
+	if err != nil {
+		return nil, fmt.Errorf("GetBranchCommit: %v", err)
+	}
+	rules, err := readCodeOwnersRules(commit)
+	if err != nil {
+		return nil, fmt.Errorf("readCodeOwnersRules: %v", err)
+	} else if len(rules) == 0 {
+		return nil, nil
+	}
+
+	files, err := gitRepo.GetFilesChangedBetween(pr.BaseBranch, pr.GetGitRefName())
+	if err != nil {
+		return nil, fmt.Errorf("GetFilesChangedBetween: %v", err)
+	}
+
+	resolver := &codeOwnersResolver{
+		e:     x,
+		repo:  pr.BaseRepo,
+		users: make(map[string]*User),
+		teams: make(map[string]*Team),
+	}
+	owners := make([]*CodeOwners, 0, len(files))
+	for _, file := range files {
+		fileOwners := &CodeOwners{Path: file}
+		for _, owner := range codeowners.Owners(rules, file) {
+			user, team, err := resolver.resolve(owner)
+			if err != nil {
+				return nil, err
+			}
+			if user != nil {
+				fileOwners.Users = append(fileOwners.Users, user)
+			} else if team != nil {
+				fileOwners.Teams = append(fileOwners.Teams, team)
+			}
+		}
+		if len(fileOwners.Users) > 0 || len(fileOwners.Teams) > 0 {
+			owners = append(owners, fileOwners)
+		}
+	}
+	return owners, nil
+}
+
+// IsApprovedByCodeOwners returns true if every file changed by the pull request which has code
+// owners is approved by one of them. Only the latest review of each reviewer counts.
+func (pr *PullRequest) IsApprovedByCodeOwners(dismissStaleApprovals bool) (bool, error) {
+	owners, err := pr.GetCodeOwners()
+	if err != nil {
+		return false, err
+	} else if len(owners) == 0 {
+		return true, nil
+	}
+
+	reviews := make([]*Review, 0, 10)
+	if err := x.Where("issue_id = ? AND reviewer_id > 0", pr.IssueID).
+		In("type", ReviewTypeApprove, ReviewTypeReject).
+		Asc("id").
+		Find(&reviews); err != nil {
+		return false, err
+	}
+	latest := make(map[int64]*Review, len(reviews))
+	for _, review := range reviews {
+		latest[review.ReviewerID] = review
+	}
+	approverIDs := make([]int64, 0, len(latest))
+	for reviewerID, review := range latest {
+		if review.Type == ReviewTypeApprove && (!dismissStaleApprovals || !review.Stale) {
+			approverIDs = append(approverIDs, reviewerID)
+		}
+	}
+
+	for _, fileOwners := range owners {
+		approved, err := fileOwners.isApprovedBy(x, approverIDs)
+		if err != nil {
+			return false, err
+		} else if !approved {
+			log.Trace("File %s of pull request %d is not approved by its code owners", fileOwners.Path, pr.ID)
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// MergeQueueRemoveReason is the reason why a pull request left the merge queue without being merged
+type MergeQueueRemoveReason string
+
+const (
+	// MergeQueueRemoveReasonDequeued means the pull request was removed by a user
+	MergeQueueRemoveReasonDequeued MergeQueueRemoveReason = "dequeued"
+	// MergeQueueRemoveReasonConflict means the pull request conflicts with the pull requests ahead of it
+	MergeQueueRemoveReasonConflict MergeQueueRemoveReason = "conflict"
+	// MergeQueueRemoveReasonChecksFailed means the required status checks of the speculative merge failed
+	MergeQueueRemoveReasonChecksFailed MergeQueueRemoveReason = "checks_failed"
+	// MergeQueueRemoveReasonNewCommits means commits were pushed to the pull request
+	MergeQueueRemoveReasonNewCommits MergeQueueRemoveReason = "new_commits"
+	// MergeQueueRemoveReasonNotAllowed means the user who added the pull request is not allowed to merge it anymore
+	MergeQueueRemoveReasonNotAllowed MergeQueueRemoveReason = "not_allowed"
+	// MergeQueueRemoveReasonClosed means the pull request was closed
+	MergeQueueRemoveReasonClosed MergeQueueRemoveReason = "closed"
+	// MergeQueueRemoveReasonTargetChanged means the target branch of the pull request was changed
+	MergeQueueRemoveReasonTargetChanged MergeQueueRemoveReason = "target_changed"
+	// MergeQueueRemoveReasonDisabled means the merge queue of the branch was disabled
+	MergeQueueRemoveReasonDisabled MergeQueueRemoveReason = "disabled"
+	// MergeQueueRemoveReasonFailed means the pull request could not be merged
+	MergeQueueRemoveReasonFailed MergeQueueRemoveReason = "failed"
+)
+
+// PullMergeQueue represents a pull request waiting in the merge queue of its protected target
+// branch. The pull requests of a queue are merged in the order they were added.
+type PullMergeQueue struct {
+	ID         int64      `xorm:"pk autoincr"`
+	PullID     int64      `xorm:"UNIQUE"`
+	RepoID     int64      `xorm:"INDEX(s)"`
+	BaseBranch string     `xorm:"INDEX(s)"`
+	DoerID     int64      `xorm:"NOT NULL"`
+	Doer       *User      `xorm:"-"`
+	MergeStyle MergeStyle `xorm:"varchar(30)"`
+	Message    string     `xorm:"LONGTEXT"`
+	// ParentCommitID is the commit the speculative merge was made on, either the head
+	// of the target branch or the speculative merge of the previous pull request
+	ParentCommitID string `xorm:"VARCHAR(40)"`
+	// MergeCommitID is the speculative merge whose status checks are awaited
+	MergeCommitID string             `xorm:"VARCHAR(40)"`
+	CreatedUnix   timeutil.TimeStamp `xorm:"created"`
+}
+
+func (q *PullMergeQueue) loadDoer(e Engine) (err error) {
+	if q.Doer == nil {
+		q.Doer, err = getUserByID(e, q.DoerID)
+	}
+	return err
+}
+
+// GetMergeQueueRefName returns the ref the speculative merge of the pull request is pushed to
+func (pr *PullRequest) GetMergeQueueRefName() string {
+	return fmt.Sprintf("refs/merge-queue/%d", pr.Index)
+}
+
+// createMergeQueueComment adds a comment of the given type to the issue of the pull request
+func createMergeQueueComment(e *xorm.Session, typ CommentType, doer *User, pr *PullRequest, content string) error {
+	if err := pr.loadIssue(e); err != nil {
+		return err
+	}
+	if err := pr.Issue.loadRepo(e); err != nil {
+		return err
+	}
+	_, err := createComment(e, &CreateCommentOptions{
+		Type:    typ,
+		Doer:    doer,
+		Repo:    pr.Issue.Repo,
+		Issue:   pr.Issue,
+		Content: content,
+	})
+	return err
+}
+
+// AddToMergeQueue adds the pull request to the end of the merge queue of its target branch,
+// it will be merged by doer with the merge style
+func AddToMergeQueue(doer *User, pr *PullRequest, style MergeStyle, message string) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if exist, err := sess.Exist(&PullMergeQueue{PullID: pr.ID}); err != nil {
+		return err
+	} else if exist {
+		return ErrPullRequestAlreadyInMergeQueue{PullID: pr.ID}
+	}
+
+	if _, err := sess.Insert(&PullMergeQueue{
+		PullID:     pr.ID,
+		RepoID:     pr.BaseRepoID,
+		BaseBranch: pr.BaseBranch,
+		DoerID:     doer.ID,
+		MergeStyle: style,
+		Message:    message,
+	}); err != nil {
+		return err
+	}
+	if err := createMergeQueueComment(sess, CommentTypeMergeQueueAdded, doer, pr, string(style)); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetMergeQueueEntryByPullID returns the merge queue entry of the pull request with its doer
+func GetMergeQueueEntryByPullID(pullID int64) (bool, *PullMergeQueue, error) {
+	entry := &PullMergeQueue{}
+	exist, err := x.Where("pull_id = ?", pullID).Get(entry)
+	if err != nil || !exist {
+		return false, nil, err
+	}
+	if err = entry.loadDoer(x); err != nil {
+		return false, nil, err
+	}
+	return true, entry, nil
+}
+
+// GetMergeQueue returns the entries of the merge queue of the branch in the order they are merged
+func GetMergeQueue(repoID int64, branch string) ([]*PullMergeQueue, error) {
+	entries := make([]*PullMergeQueue, 0, 10)
+	if err := x.Where("repo_id = ? AND base_branch = ?", repoID, branch).Asc("id").Find(&entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if err := entry.loadDoer(x); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// GetMergeQueuePosition returns the position of the entry in its merge queue, starting at 1
+func GetMergeQueuePosition(entry *PullMergeQueue) (int64, error) {
+	count, err := x.Where("repo_id = ? AND base_branch = ? AND id < ?", entry.RepoID, entry.BaseBranch, entry.ID).Count(&PullMergeQueue{})
+	return count + 1, err
+}
+
+// GetMergeQueueBranches returns the branches of the repository whose merge queue is not empty,
+// all repositories are searched if repoID is 0
+func GetMergeQueueBranches(repoID int64) ([]*PullMergeQueue, error) {
+	cond := builder.NewCond()
+	if repoID > 0 {
+		cond = cond.And(builder.Eq{"repo_id": repoID})
+	}
+	branches := make([]*PullMergeQueue, 0, 10)
+	return branches, x.Where(cond).Distinct("repo_id", "base_branch").Find(&branches)
+}
+
+// UpdateMergeQueueCommits stores the speculative merge of the entry
+func UpdateMergeQueueCommits(entry *PullMergeQueue) error {
+	_, err := x.ID(entry.ID).Cols("parent_commit_id", "merge_commit_id").Update(entry)
+	return err
+}
+
+// RemoveFromMergeQueue removes the pull request from the merge queue. Unless doer is nil, the
+// removal is recorded as comment of doer with the reason. It returns false if the pull request
+// was not in the merge queue.
+func RemoveFromMergeQueue(doer *User, pr *PullRequest, reason MergeQueueRemoveReason) (bool, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return false, err
+	}
+
+	entry := &PullMergeQueue{}
+	if exist, err := sess.Where("pull_id = ?", pr.ID).Get(entry); err != nil || !exist {
+		return false, err
+	}
+	if _, err := sess.ID(entry.ID).Delete(&PullMergeQueue{}); err != nil {
+		return false, err
+	}
+	if doer != nil {
+		if err := createMergeQueueComment(sess, CommentTypeMergeQueueRemoved, doer, pr, string(reason)); err != nil {
+			return false, err
+		}
+	}
+	return true, sess.Commit()
+}
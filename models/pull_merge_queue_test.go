@@ -0,0 +1,79 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeQueue(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	pr1 := AssertExistsAndLoadBean(t, &PullRequest{ID: 1}).(*PullRequest)
+	pr2 := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+	assert.Equal(t, pr1.BaseRepoID, pr2.BaseRepoID)
+	assert.Equal(t, pr1.BaseBranch, pr2.BaseBranch)
+
+	assert.NoError(t, AddToMergeQueue(doer, pr2, MergeStyleRebase, ""))
+	assert.NoError(t, AddToMergeQueue(doer, pr1, MergeStyleSquash, "squashed"))
+	AssertExistsAndLoadBean(t, &Comment{Type: CommentTypeMergeQueueAdded, IssueID: pr1.IssueID, PosterID: doer.ID, Content: "squash"})
+
+	err := AddToMergeQueue(doer, pr1, MergeStyleMerge, "")
+	assert.True(t, IsErrPullRequestAlreadyInMergeQueue(err))
+
+	entries, err := GetMergeQueue(pr1.BaseRepoID, pr1.BaseBranch)
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 2) {
+		assert.EqualValues(t, pr2.ID, entries[0].PullID)
+		assert.EqualValues(t, pr1.ID, entries[1].PullID)
+		assert.EqualValues(t, doer.ID, entries[1].Doer.ID)
+	}
+
+	exist, entry, err := GetMergeQueueEntryByPullID(pr1.ID)
+	assert.NoError(t, err)
+	assert.True(t, exist)
+	assert.EqualValues(t, MergeStyleSquash, entry.MergeStyle)
+	assert.EqualValues(t, "squashed", entry.Message)
+	position, err := GetMergeQueuePosition(entry)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, position)
+
+	entry.ParentCommitID = "65f1bf27bc3bf70f64657658635e66094edbcb4d"
+	entry.MergeCommitID = "985f0301dba5e7b34be866819cd15ad3d8f508ee"
+	assert.NoError(t, UpdateMergeQueueCommits(entry))
+	AssertExistsAndLoadBean(t, &PullMergeQueue{PullID: pr1.ID, MergeCommitID: entry.MergeCommitID})
+
+	branches, err := GetMergeQueueBranches(pr1.BaseRepoID)
+	assert.NoError(t, err)
+	if assert.Len(t, branches, 1) {
+		assert.EqualValues(t, pr1.BaseRepoID, branches[0].RepoID)
+		assert.EqualValues(t, pr1.BaseBranch, branches[0].BaseBranch)
+	}
+	branches, err = GetMergeQueueBranches(10)
+	assert.NoError(t, err)
+	assert.Empty(t, branches)
+
+	removed, err := RemoveFromMergeQueue(doer, pr2, MergeQueueRemoveReasonChecksFailed)
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	AssertExistsAndLoadBean(t, &Comment{Type: CommentTypeMergeQueueRemoved, IssueID: pr2.IssueID, PosterID: doer.ID, Content: "checks_failed"})
+	AssertNotExistsBean(t, &PullMergeQueue{PullID: pr2.ID})
+
+	position, err = GetMergeQueuePosition(entry)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, position)
+
+	removed, err = RemoveFromMergeQueue(nil, pr2, MergeQueueRemoveReasonDequeued)
+	assert.NoError(t, err)
+	assert.False(t, removed)
+	removed, err = RemoveFromMergeQueue(nil, pr1, MergeQueueRemoveReasonDequeued)
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	exist, _, err = GetMergeQueueEntryByPullID(pr1.ID)
+	assert.NoError(t, err)
+	assert.False(t, exist)
+}
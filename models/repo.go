@@ -32,6 +32,7 @@ import (
 	"code.gitea.io/gitea/modules/markup"
 	"code.gitea.io/gitea/modules/options"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/sync"
@@ -340,6 +341,10 @@ func (repo *Repository) innerAPIFormat(e Engine, mode AccessMode, isParent bool)
 			ExternalWikiURL: config.ExternalWikiURL,
 		}
 	}
+	hasProjects := false
+	if _, err := repo.getUnit(e, UnitTypeProjects); err == nil {
+		hasProjects = true
+	}
 	hasPullRequests := false
 	ignoreWhitespaceConflicts := false
 	allowMerge := false
@@ -390,6 +395,7 @@ func (repo *Repository) innerAPIFormat(e Engine, mode AccessMode, isParent bool)
 		HasWiki:                   hasWiki,
 		ExternalWiki:              externalWiki,
 		HasPullRequests:           hasPullRequests,
+		HasProjects:               hasProjects,
 		IgnoreWhitespaceConflicts: ignoreWhitespaceConflicts,
 		AllowMerge:                allowMerge,
 		AllowRebase:               allowRebase,
@@ -1400,7 +1406,7 @@ func CreateRepository(doer, u *User, opts CreateRepoOptions) (_ *Repository, err
 
 		// Initialize Issue Labels if selected
 		if len(opts.IssueLabels) > 0 {
-			if err = initalizeLabels(sess, repo.ID, opts.IssueLabels); err != nil {
+			if err = initalizeLabels(sess, repo.ID, opts.IssueLabels, false); err != nil {
 				return nil, fmt.Errorf("initalizeLabels: %v", err)
 			}
 		}
@@ -1517,6 +1523,9 @@ func TransferOwnership(doer *User, newOwnerName string, repo *Repository) error
 		if err = oldOwner.removeOrgRepo(sess, repo.ID); err != nil {
 			return fmt.Errorf("removeOrgRepo: %v", err)
 		}
+		if err = deleteOrgLabelsOfRepoIssues(sess, oldOwner.ID, repo.ID); err != nil {
+			return fmt.Errorf("deleteOrgLabelsOfRepoIssues: %v", err)
+		}
 	}
 
 	if newOwner.IsOrganization() {
@@ -1805,6 +1814,10 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 				return err
 			}
 		}
+		// Keep the issue counts of the labels shared with the other repositories up to date
+		if err = deleteOrgLabelsOfRepoIssues(sess, org.ID, repoID); err != nil {
+			return fmt.Errorf("deleteOrgLabelsOfRepoIssues: %v", err)
+		}
 	}
 
 	attachments := make([]*Attachment, 0, 20)
@@ -1815,7 +1828,12 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 	}
 	releaseAttachments := make([]string, 0, len(attachments))
 	for i := 0; i < len(attachments); i++ {
-		releaseAttachments = append(releaseAttachments, attachments[i].LocalPath())
+		releaseAttachments = append(releaseAttachments, attachments[i].RelativePath())
+	}
+
+	if _, err = sess.In("pull_id", builder.Select("id").From("pull_request").Where(builder.Eq{"base_repo_id": repoID})).
+		Delete(&PullAutoMerge{}); err != nil {
+		return err
 	}
 
 	if err = deleteBeans(sess,
@@ -1824,6 +1842,8 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		&Watch{RepoID: repoID},
 		&Star{RepoID: repoID},
 		&Mirror{RepoID: repoID},
+		&PushMirror{RepoID: repoID},
+		&PushMirrorSync{RepoID: repoID},
 		&Milestone{RepoID: repoID},
 		&Release{RepoID: repoID},
 		&Collaboration{RepoID: repoID},
@@ -1841,6 +1861,18 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err = unlinkRepositoryFromAllPackages(sess, repoID); err != nil {
+		return fmt.Errorf("unlinkRepositoryFromAllPackages: %v", err)
+	}
+
+	if err = deleteActionRunsOfRepo(sess, repoID); err != nil {
+		return fmt.Errorf("deleteActionRunsOfRepo: %v", err)
+	}
+
+	if err = deleteProjectsOfRepo(sess, repoID); err != nil {
+		return fmt.Errorf("deleteProjectsOfRepo: %v", err)
+	}
+
 	deleteCond := builder.Select("id").From("issue").Where(builder.Eq{"repo_id": repoID})
 	// Delete comments and attachments
 	if _, err = sess.In("issue_id", deleteCond).
@@ -1876,7 +1908,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 	}
 	attachmentPaths := make([]string, 0, len(attachments))
 	for j := range attachments {
-		attachmentPaths = append(attachmentPaths, attachments[j].LocalPath())
+		attachmentPaths = append(attachmentPaths, attachments[j].RelativePath())
 	}
 
 	if _, err = sess.In("issue_id", deleteCond).
@@ -1926,8 +1958,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 			continue
 		}
 
-		oidPath := filepath.Join(setting.LFS.ContentPath, v.Oid[0:2], v.Oid[2:4], v.Oid[4:len(v.Oid)])
-		removeAllWithNotice(sess, "Delete orphaned LFS file", oidPath)
+		removeStorageWithNotice(sess, storage.LFS, "Delete orphaned LFS file", v.RelativePath())
 	}
 
 	if _, err := sess.Delete(&LFSMetaObject{RepositoryID: repoID}); err != nil {
@@ -1957,20 +1988,19 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 
 	// Remove issue attachment files.
 	for i := range attachmentPaths {
-		removeAllWithNotice(x, "Delete issue attachment", attachmentPaths[i])
+		removeStorageWithNotice(x, storage.Attachments, "Delete issue attachment", attachmentPaths[i])
 	}
 
 	// Remove release attachment files.
 	for i := range releaseAttachments {
-		removeAllWithNotice(x, "Delete release attachment", releaseAttachments[i])
+		removeStorageWithNotice(x, storage.Attachments, "Delete release attachment", releaseAttachments[i])
 	}
 
+	removeAllWithNotice(x, "Delete action logs", actionLogsPath(repoID))
+
 	if len(repo.Avatar) > 0 {
-		avatarPath := repo.CustomAvatarPath()
-		if com.IsExist(avatarPath) {
-			if err := os.Remove(avatarPath); err != nil {
-				return fmt.Errorf("Failed to remove %s: %v", avatarPath, err)
-			}
+		if err := storage.RepoAvatars.Delete(repo.CustomAvatarRelativePath()); err != nil {
+			return fmt.Errorf("Failed to remove %s: %v", repo.Avatar, err)
 		}
 	}
 
@@ -2118,7 +2148,7 @@ func deleteOldRepositoryArchives(ctx context.Context, idx int, bean interface{})
 	repo := bean.(*Repository)
 	basePath := filepath.Join(repo.RepoPath(), "archives")
 
-	for _, ty := range []string{"zip", "targz"} {
+	for _, ty := range []string{"zip", "targz", "tarxz", "tarzst"} {
 		select {
 		case <-ctx.Done():
 			return fmt.Errorf("Aborted due to shutdown:\nin delete of old repository archives %v\nat delete file %s", repo, ty)
@@ -2591,13 +2621,9 @@ func (repo *Repository) GetUserFork(userID int64) (*Repository, error) {
 	return &forkedRepo, nil
 }
 
-// CustomAvatarPath returns repository custom avatar file path.
-func (repo *Repository) CustomAvatarPath() string {
-	// Avatar empty by default
-	if len(repo.Avatar) == 0 {
-		return ""
-	}
-	return filepath.Join(setting.RepositoryAvatarUploadPath, repo.Avatar)
+// CustomAvatarRelativePath returns repository custom avatar path in the repository avatar storage.
+func (repo *Repository) CustomAvatarRelativePath() string {
+	return repo.Avatar
 }
 
 // generateRandomAvatar generates a random avatar for repository.
@@ -2611,18 +2637,13 @@ func (repo *Repository) generateRandomAvatar(e Engine) error {
 	}
 
 	repo.Avatar = idToString
-	if err = os.MkdirAll(filepath.Dir(repo.CustomAvatarPath()), os.ModePerm); err != nil {
-		return fmt.Errorf("MkdirAll: %v", err)
-	}
-	fw, err := os.Create(repo.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("Create: %v", err)
-	}
-	defer fw.Close()
-
-	if err = png.Encode(fw, img); err != nil {
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, img); err != nil {
 		return fmt.Errorf("Encode: %v", err)
 	}
+	if _, err = storage.RepoAvatars.Save(repo.CustomAvatarRelativePath(), &buf); err != nil {
+		return fmt.Errorf("Save: %v", err)
+	}
 	log.Info("New random avatar created for repository: %d", repo.ID)
 
 	if _, err := e.ID(repo.ID).Cols("avatar").NoAutoTime().Update(repo); err != nil {
@@ -2654,8 +2675,8 @@ func (repo *Repository) RelAvatarLink() string {
 
 func (repo *Repository) relAvatarLink(e Engine) string {
 	// If no avatar - path is empty
-	avatarPath := repo.CustomAvatarPath()
-	if len(avatarPath) == 0 || !com.IsFile(avatarPath) {
+	avatarPath := repo.CustomAvatarRelativePath()
+	if len(avatarPath) == 0 {
 		switch mode := setting.RepositoryAvatarFallback; mode {
 		case "image":
 			return setting.RepositoryAvatarFallbackImage
@@ -2697,7 +2718,7 @@ func (repo *Repository) UploadAvatar(data []byte) error {
 		return err
 	}
 
-	oldAvatarPath := repo.CustomAvatarPath()
+	oldAvatarPath := repo.CustomAvatarRelativePath()
 
 	// Users can upload the same image to other repo - prefix it with ID
 	// Then repo will be removed - only it avatar file will be removed
@@ -2706,22 +2727,16 @@ func (repo *Repository) UploadAvatar(data []byte) error {
 		return fmt.Errorf("UploadAvatar: Update repository avatar: %v", err)
 	}
 
-	if err := os.MkdirAll(setting.RepositoryAvatarUploadPath, os.ModePerm); err != nil {
-		return fmt.Errorf("UploadAvatar: Failed to create dir %s: %v", setting.RepositoryAvatarUploadPath, err)
-	}
-
-	fw, err := os.Create(repo.CustomAvatarPath())
-	if err != nil {
-		return fmt.Errorf("UploadAvatar: Create file: %v", err)
-	}
-	defer fw.Close()
-
-	if err = png.Encode(fw, *m); err != nil {
+	var buf bytes.Buffer
+	if err = png.Encode(&buf, *m); err != nil {
 		return fmt.Errorf("UploadAvatar: Encode png: %v", err)
 	}
+	if _, err := storage.RepoAvatars.Save(repo.CustomAvatarRelativePath(), &buf); err != nil {
+		return fmt.Errorf("UploadAvatar: Failed to save repo avatar %s: %v", repo.Avatar, err)
+	}
 
-	if len(oldAvatarPath) > 0 && oldAvatarPath != repo.CustomAvatarPath() {
-		if err := os.Remove(oldAvatarPath); err != nil {
+	if len(oldAvatarPath) > 0 && oldAvatarPath != repo.CustomAvatarRelativePath() {
+		if err := storage.RepoAvatars.Delete(oldAvatarPath); err != nil {
 			return fmt.Errorf("UploadAvatar: Failed to remove old repo avatar %s: %v", oldAvatarPath, err)
 		}
 	}
@@ -2737,7 +2752,7 @@ func (repo *Repository) DeleteAvatar() error {
 		return nil
 	}
 
-	avatarPath := repo.CustomAvatarPath()
+	avatarPath := repo.CustomAvatarRelativePath()
 	log.Trace("DeleteAvatar[%d]: %s", repo.ID, avatarPath)
 
 	sess := x.NewSession()
@@ -2751,13 +2766,8 @@ func (repo *Repository) DeleteAvatar() error {
 		return fmt.Errorf("DeleteAvatar: Update repository avatar: %v", err)
 	}
 
-	if _, err := os.Stat(avatarPath); err == nil {
-		if err := os.Remove(avatarPath); err != nil {
-			return fmt.Errorf("DeleteAvatar: Failed to remove %s: %v", avatarPath, err)
-		}
-	} else {
-		// // Schrodinger: file may or may not exist. See err for details.
-		log.Trace("DeleteAvatar[%d]: %v", err)
+	if err := storage.RepoAvatars.Delete(avatarPath); err != nil {
+		return fmt.Errorf("DeleteAvatar: Failed to remove %s: %v", avatarPath, err)
 	}
 	return sess.Commit()
 }
@@ -0,0 +1,187 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+
+	"xorm.io/xorm"
+)
+
+// ErrPushMirrorNotExist push mirror does not exist error
+var ErrPushMirrorNotExist = errors.New("PushMirror does not exist")
+
+// PushMirror represents a remote the repository is pushed to.
+// The address and the credentials of the remote are stored in the git config of the repository.
+type PushMirror struct {
+	ID           int64       `xorm:"pk autoincr"`
+	RepoID       int64       `xorm:"INDEX"`
+	Repo         *Repository `xorm:"-"`
+	RemoteName   string      `xorm:"NOT NULL"`
+	Interval     time.Duration
+	SyncOnCommit bool `xorm:"NOT NULL DEFAULT true"`
+
+	CreatedUnix    timeutil.TimeStamp `xorm:"created"`
+	LastUpdateUnix timeutil.TimeStamp `xorm:"INDEX last_update"`
+	LastError      string             `xorm:"TEXT"`
+
+	SyncHistory []*PushMirrorSync `xorm:"-"`
+}
+
+// pushMirrorSyncHistoryLength is the number of syncs kept in the history of a push mirror
+const pushMirrorSyncHistoryLength = 10
+
+// PushMirrorSync records a sync of a push mirror,
+// the error is sanitized and does not contain the credentials of the remote
+type PushMirrorSync struct {
+	ID           int64 `xorm:"pk autoincr"`
+	RepoID       int64 `xorm:"INDEX"`
+	PushMirrorID int64 `xorm:"INDEX"`
+	IsSucceed    bool
+	Error        string             `xorm:"TEXT"`
+	CreatedUnix  timeutil.TimeStamp `xorm:"created"`
+}
+
+// AfterLoad is invoked from XORM after setting the values of all fields of this object.
+func (m *PushMirror) AfterLoad(session *xorm.Session) {
+	if m == nil {
+		return
+	}
+
+	var err error
+	m.Repo, err = getRepositoryByID(session, m.RepoID)
+	if err != nil {
+		log.Error("getRepositoryByID[%d]: %v", m.ID, err)
+	}
+}
+
+// InsertPushMirror inserts a push mirror to database
+func InsertPushMirror(m *PushMirror) error {
+	_, err := x.Insert(m)
+	return err
+}
+
+// UpdatePushMirror updates the push mirror
+func UpdatePushMirror(m *PushMirror) error {
+	_, err := x.ID(m.ID).AllCols().Update(m)
+	return err
+}
+
+// UpdatePushMirrorWithSync updates the push mirror and records the sync in its history,
+// only the latest syncs are kept
+func UpdatePushMirrorWithSync(m *PushMirror, sync *PushMirrorSync) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.ID(m.ID).AllCols().Update(m); err != nil {
+		return err
+	}
+	sync.RepoID = m.RepoID
+	sync.PushMirrorID = m.ID
+	if _, err := sess.Insert(sync); err != nil {
+		return err
+	}
+
+	var ids []int64
+	if err := sess.Table("push_mirror_sync").Cols("id").Where("push_mirror_id = ?", m.ID).
+		Desc("id").Limit(pushMirrorSyncHistoryLength).Find(&ids); err != nil {
+		return err
+	}
+	if _, err := sess.Where("push_mirror_id = ?", m.ID).NotIn("id", ids).Delete(new(PushMirrorSync)); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// LoadPushMirrorSyncHistory loads the sync history of the push mirrors, the latest sync first
+func LoadPushMirrorSyncHistory(mirrors []*PushMirror) error {
+	if len(mirrors) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(mirrors))
+	mirrorMap := make(map[int64]*PushMirror, len(mirrors))
+	for _, m := range mirrors {
+		ids = append(ids, m.ID)
+		mirrorMap[m.ID] = m
+		m.SyncHistory = nil
+	}
+
+	syncs := make([]*PushMirrorSync, 0, len(mirrors)*pushMirrorSyncHistoryLength)
+	if err := x.In("push_mirror_id", ids).Desc("id").Find(&syncs); err != nil {
+		return err
+	}
+	for _, s := range syncs {
+		mirrorMap[s.PushMirrorID].SyncHistory = append(mirrorMap[s.PushMirrorID].SyncHistory, s)
+	}
+	return nil
+}
+
+// DeletePushMirrorByID deletes a push mirror and its sync history by its id
+func DeletePushMirrorByID(id int64) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.ID(id).Delete(&PushMirror{}); err != nil {
+		return err
+	}
+	if _, err := sess.Where("push_mirror_id = ?", id).Delete(new(PushMirrorSync)); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetPushMirrorByID returns the push mirror with the id
+func GetPushMirrorByID(id int64) (*PushMirror, error) {
+	m := &PushMirror{}
+	has, err := x.ID(id).Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPushMirrorNotExist
+	}
+	return m, nil
+}
+
+// GetPushMirrorByRepoIDAndRemoteName returns the push mirror of a repository with the remote name
+func GetPushMirrorByRepoIDAndRemoteName(repoID int64, remoteName string) (*PushMirror, error) {
+	m := &PushMirror{}
+	has, err := x.Where("repo_id = ? AND remote_name = ?", repoID, remoteName).Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPushMirrorNotExist
+	}
+	return m, nil
+}
+
+// GetPushMirrorsByRepoID returns all push mirrors of a repository
+func GetPushMirrorsByRepoID(repoID int64) ([]*PushMirror, error) {
+	mirrors := make([]*PushMirror, 0, 5)
+	return mirrors, x.Where("repo_id = ?", repoID).Asc("id").Find(&mirrors)
+}
+
+// GetPushMirrorsSyncedOnCommit returns the push mirrors of a repository which are synced after each push
+func GetPushMirrorsSyncedOnCommit(repoID int64) ([]*PushMirror, error) {
+	mirrors := make([]*PushMirror, 0, 5)
+	return mirrors, x.Where("repo_id = ? AND sync_on_commit = ?", repoID, true).Find(&mirrors)
+}
+
+// PushMirrorsIterate iterates all push mirrors which have to be synced.
+func PushMirrorsIterate(f func(idx int, bean interface{}) error) error {
+	return x.
+		Where("last_update + (`interval` / ?) <= ?", time.Second, time.Now().Unix()).
+		And("`interval` != 0").
+		Iterate(new(PushMirror), f)
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushMirrorSyncHistory(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	m := &PushMirror{RepoID: 1, RemoteName: "remote_mirror_test"}
+	assert.NoError(t, InsertPushMirror(m))
+
+	for i := 0; i < pushMirrorSyncHistoryLength+2; i++ {
+		sync := &PushMirrorSync{IsSucceed: i%2 == 0}
+		if !sync.IsSucceed {
+			sync.Error = fmt.Sprintf("error %d", i)
+		}
+		m.LastError = sync.Error
+		assert.NoError(t, UpdatePushMirrorWithSync(m, sync))
+	}
+	AssertCount(t, &PushMirrorSync{PushMirrorID: m.ID}, pushMirrorSyncHistoryLength)
+
+	mirrors, err := GetPushMirrorsByRepoID(1)
+	assert.NoError(t, err)
+	assert.Len(t, mirrors, 1)
+	assert.NoError(t, LoadPushMirrorSyncHistory(mirrors))
+	history := mirrors[0].SyncHistory
+	if assert.Len(t, history, pushMirrorSyncHistoryLength) {
+		// the latest sync comes first, the oldest syncs have been removed
+		assert.False(t, history[0].IsSucceed)
+		assert.Equal(t, fmt.Sprintf("error %d", pushMirrorSyncHistoryLength+1), history[0].Error)
+		assert.True(t, history[1].IsSucceed)
+		assert.Empty(t, history[1].Error)
+		assert.Equal(t, "error 3", history[len(history)-2].Error)
+		assert.True(t, history[len(history)-1].IsSucceed)
+		assert.EqualValues(t, 1, history[0].RepoID)
+	}
+
+	assert.NoError(t, DeletePushMirrorByID(m.ID))
+	AssertNotExistsBean(t, &PushMirror{ID: m.ID})
+	AssertNotExistsBean(t, &PushMirrorSync{PushMirrorID: m.ID})
+}
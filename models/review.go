@@ -7,6 +7,7 @@ package models
 import (
 	"strings"
 
+	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
 
 	"xorm.io/builder"
@@ -27,6 +28,8 @@ const (
 	ReviewTypeComment
 	// ReviewTypeReject gives feedback blocking merge
 	ReviewTypeReject
+	// ReviewTypeRequest requests a review from a user or team
+	ReviewTypeRequest
 )
 
 // Icon returns the corresponding icon for the review type
@@ -36,6 +39,8 @@ func (rt ReviewType) Icon() string {
 		return "eye"
 	case ReviewTypeReject:
 		return "x"
+	case ReviewTypeRequest:
+		return "primitive-dot"
 	case ReviewTypeComment, ReviewTypeUnknown:
 		return "comment"
 	default:
@@ -45,13 +50,17 @@ func (rt ReviewType) Icon() string {
 
 // Review represents collection of code comments giving feedback for a PR
 type Review struct {
-	ID         int64 `xorm:"pk autoincr"`
-	Type       ReviewType
-	Reviewer   *User  `xorm:"-"`
-	ReviewerID int64  `xorm:"index"`
-	Issue      *Issue `xorm:"-"`
-	IssueID    int64  `xorm:"index"`
-	Content    string `xorm:"TEXT"`
+	ID               int64 `xorm:"pk autoincr"`
+	Type             ReviewType
+	Reviewer         *User `xorm:"-"`
+	ReviewerID       int64 `xorm:"index"`
+	ReviewerTeamID   int64 `xorm:"NOT NULL DEFAULT 0"`
+	ReviewerTeam     *Team `xorm:"-"`
+	OriginalAuthor   string
+	OriginalAuthorID int64
+	Issue            *Issue `xorm:"-"`
+	IssueID          int64  `xorm:"index"`
+	Content          string `xorm:"TEXT"`
 	// Official is a review made by an assigned approver (counts towards approval)
 	Official bool   `xorm:"NOT NULL DEFAULT false"`
 	CommitID string `xorm:"VARCHAR(40)"`
@@ -62,6 +71,8 @@ type Review struct {
 
 	// CodeComments are the initial code comments of the review
 	CodeComments CodeComments `xorm:"-"`
+
+	Comments []*Comment `xorm:"-"`
 }
 
 func (r *Review) loadCodeComments(e Engine) (err error) {
@@ -94,6 +105,14 @@ func (r *Review) LoadReviewer() error {
 	return r.loadReviewer(x)
 }
 
+func (r *Review) loadReviewerTeam(e Engine) (err error) {
+	if r.ReviewerTeamID == 0 {
+		return nil
+	}
+	r.ReviewerTeam, err = getTeamByID(e, r.ReviewerTeamID)
+	return
+}
+
 func (r *Review) loadAttributes(e Engine) (err error) {
 	if err = r.loadReviewer(e); err != nil {
 		return
@@ -273,6 +292,12 @@ func SubmitReview(doer *User, issue *Issue, reviewType ReviewType, content, comm
 
 	var official = false
 
+	// a review of the doer fulfills the review requested from the doer
+	if _, err := sess.Where("issue_id = ? AND reviewer_id = ? AND type = ?", issue.ID, doer.ID, ReviewTypeRequest).
+		Delete(new(Review)); err != nil {
+		return nil, nil, err
+	}
+
 	review, err := getCurrentReview(sess, doer, issue)
 	if err != nil {
 		if !IsErrReviewNotExist(err) {
@@ -365,8 +390,8 @@ func GetReviewersByIssueID(issueID int64) (reviews []*Review, err error) {
 	}
 
 	// Get latest review of each reviwer, sorted in order they were made
-	if err := sess.SQL("SELECT * FROM review WHERE id IN (SELECT max(id) as id FROM review WHERE issue_id = ? AND type in (?, ?) GROUP BY issue_id, reviewer_id) ORDER BY review.updated_unix ASC",
-		issueID, ReviewTypeApprove, ReviewTypeReject).
+	if err := sess.SQL("SELECT * FROM review WHERE id IN (SELECT max(id) as id FROM review WHERE issue_id = ? AND reviewer_team_id = 0 AND type in (?, ?, ?) GROUP BY issue_id, reviewer_id) ORDER BY review.updated_unix ASC",
+		issueID, ReviewTypeApprove, ReviewTypeReject, ReviewTypeRequest).
 		Find(&reviewsUnfiltered); err != nil {
 		return nil, err
 	}
@@ -385,6 +410,100 @@ func GetReviewersByIssueID(issueID int64) (reviews []*Review, err error) {
 	return reviews, nil
 }
 
+// GetTeamReviewRequestsByIssueID gets the reviews requested from teams for a pull request
+func GetTeamReviewRequestsByIssueID(issueID int64) ([]*Review, error) {
+	reviews := make([]*Review, 0, 5)
+	if err := x.Where("issue_id = ? AND reviewer_team_id > 0 AND type = ?", issueID, ReviewTypeRequest).
+		Asc("id").
+		Find(&reviews); err != nil {
+		return nil, err
+	}
+
+	// skip requests of deleted teams
+	requests := make([]*Review, 0, len(reviews))
+	for _, review := range reviews {
+		if err := review.loadReviewerTeam(x); err != nil {
+			if !IsErrTeamNotExist(err) {
+				return nil, err
+			}
+		} else {
+			requests = append(requests, review)
+		}
+	}
+	return requests, nil
+}
+
+// AddReviewRequest requests a review of the pull request from reviewer. Nothing is done if the
+// reviewer was already requested or has reviewed the pull request, the returned comment is nil then.
+func AddReviewRequest(issue *Issue, reviewer, doer *User) (*Comment, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if exist, err := sess.Where("issue_id = ? AND reviewer_id = ?", issue.ID, reviewer.ID).
+		In("type", ReviewTypeApprove, ReviewTypeComment, ReviewTypeReject, ReviewTypeRequest).
+		Exist(new(Review)); err != nil || exist {
+		return nil, err
+	}
+
+	if _, err := createReview(sess, CreateReviewOptions{
+		Type:     ReviewTypeRequest,
+		Issue:    issue,
+		Reviewer: reviewer,
+	}); err != nil {
+		return nil, err
+	}
+
+	comment, err := createComment(sess, &CreateCommentOptions{
+		Type:       CommentTypeReviewRequest,
+		Doer:       doer,
+		Repo:       issue.Repo,
+		Issue:      issue,
+		AssigneeID: reviewer.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, sess.Commit()
+}
+
+// AddTeamReviewRequest requests a review of the pull request from the team. Nothing is done if a
+// review was already requested from the team, the returned comment is nil then.
+func AddTeamReviewRequest(issue *Issue, reviewer *Team, doer *User) (*Comment, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	if exist, err := sess.Exist(&Review{IssueID: issue.ID, ReviewerTeamID: reviewer.ID, Type: ReviewTypeRequest}); err != nil || exist {
+		return nil, err
+	}
+
+	review := &Review{
+		Type:           ReviewTypeRequest,
+		IssueID:        issue.ID,
+		ReviewerTeamID: reviewer.ID,
+	}
+	if _, err := sess.Insert(review); err != nil {
+		return nil, err
+	}
+
+	comment, err := createComment(sess, &CreateCommentOptions{
+		Type:           CommentTypeReviewRequest,
+		Doer:           doer,
+		Repo:           issue.Repo,
+		Issue:          issue,
+		AssigneeTeamID: reviewer.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, sess.Commit()
+}
+
 // MarkReviewsAsStale marks existing reviews as stale
 func MarkReviewsAsStale(issueID int64) (err error) {
 	_, err = x.Exec("UPDATE `review` SET stale=? WHERE issue_id=?", true, issueID)
@@ -398,3 +517,23 @@ func MarkReviewsAsNotStale(issueID int64, commitID string) (err error) {
 
 	return
 }
+
+// UpdateReviewsMigrationsByType updates reviews' migrations information via given git service type and original id and poster id
+func UpdateReviewsMigrationsByType(tp structs.GitServiceType, originalAuthorID string, posterID int64) error {
+	_, err := x.Table("review").
+		Where(builder.In("issue_id",
+			builder.Select("issue.id").
+				From("issue").
+				InnerJoin("repository", "issue.repo_id = repository.id").
+				Where(builder.Eq{
+					"repository.original_service_type": tp,
+				}),
+		)).
+		And("review.original_author_id = ?", originalAuthorID).
+		Update(map[string]interface{}{
+			"reviewer_id":        posterID,
+			"original_author":    "",
+			"original_author_id": 0,
+		})
+	return err
+}
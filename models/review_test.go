@@ -48,7 +48,8 @@ func TestReviewType_Icon(t *testing.T) {
 	assert.Equal(t, "x", ReviewTypeReject.Icon())
 	assert.Equal(t, "comment", ReviewTypeComment.Icon())
 	assert.Equal(t, "comment", ReviewTypeUnknown.Icon())
-	assert.Equal(t, "comment", ReviewType(4).Icon())
+	assert.Equal(t, "primitive-dot", ReviewTypeRequest.Icon())
+	assert.Equal(t, "comment", ReviewType(5).Icon())
 }
 
 func TestFindReviews(t *testing.T) {
@@ -132,3 +133,73 @@ func TestGetReviewersByIssueID(t *testing.T) {
 		assert.Equal(t, expectedReviews[i].UpdatedUnix, review.UpdatedUnix)
 	}
 }
+
+func TestAddReviewRequest(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 8}).(*Issue)
+	assert.NoError(t, issue.LoadRepo())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	reviewer := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+
+	comment, err := AddReviewRequest(issue, reviewer, doer)
+	assert.NoError(t, err)
+	if assert.NotNil(t, comment) {
+		assert.EqualValues(t, CommentTypeReviewRequest, comment.Type)
+		assert.EqualValues(t, reviewer.ID, comment.AssigneeID)
+	}
+	AssertExistsAndLoadBean(t, &Review{IssueID: issue.ID, ReviewerID: reviewer.ID, Type: ReviewTypeRequest})
+
+	// the review was requested already
+	comment, err = AddReviewRequest(issue, reviewer, doer)
+	assert.NoError(t, err)
+	assert.Nil(t, comment)
+
+	reviews, err := GetReviewersByIssueID(issue.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, reviews, 1) {
+		assert.EqualValues(t, ReviewTypeRequest, reviews[0].Type)
+		assert.EqualValues(t, reviewer.ID, reviews[0].Reviewer.ID)
+	}
+
+	// a review fulfills the request
+	_, _, err = SubmitReview(reviewer, issue, ReviewTypeApprove, "", "", false)
+	assert.NoError(t, err)
+	AssertNotExistsBean(t, &Review{IssueID: issue.ID, ReviewerID: reviewer.ID, Type: ReviewTypeRequest})
+
+	// reviewers are not requested again
+	comment, err = AddReviewRequest(issue, reviewer, doer)
+	assert.NoError(t, err)
+	assert.Nil(t, comment)
+}
+
+func TestAddTeamReviewRequest(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 8}).(*Issue)
+	assert.NoError(t, issue.LoadRepo())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	team := AssertExistsAndLoadBean(t, &Team{ID: 2}).(*Team)
+
+	comment, err := AddTeamReviewRequest(issue, team, doer)
+	assert.NoError(t, err)
+	if assert.NotNil(t, comment) {
+		assert.EqualValues(t, team.ID, comment.AssigneeTeamID)
+		assert.NoError(t, comment.LoadAssigneeTeam())
+		assert.EqualValues(t, team.Name, comment.AssigneeTeam.Name)
+	}
+
+	comment, err = AddTeamReviewRequest(issue, team, doer)
+	assert.NoError(t, err)
+	assert.Nil(t, comment)
+
+	requests, err := GetTeamReviewRequestsByIssueID(issue.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, requests, 1) {
+		assert.EqualValues(t, team.ID, requests[0].ReviewerTeam.ID)
+	}
+
+	reviews, err := GetReviewersByIssueID(issue.ID)
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 0)
+}
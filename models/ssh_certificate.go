@@ -0,0 +1,72 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const tplPrincipal = `command="%s --config='%s' serv user-%d",no-port-forwarding,no-X11-forwarding,no-agent-forwarding,no-pty %s`
+
+// AuthorizedPrincipalString returns the line OpenSSH expects from an
+// AuthorizedPrincipalsCommand to let the given principal log in as the user.
+func AuthorizedPrincipalString(u *User, principal string) string {
+	return fmt.Sprintf(tplPrincipal, setting.AppPath, setting.CustomConf, u.ID, principal)
+}
+
+// IsTrustedUserCAKey returns true if the key is one of the configured trusted user certificate authorities.
+func IsTrustedUserCAKey(key ssh.PublicKey) bool {
+	marshaled := key.Marshal()
+	for _, caKey := range setting.SSH.TrustedUserCAKeysParsed {
+		if bytes.Equal(caKey.Marshal(), marshaled) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetUserBySSHCertificate checks that the certificate is a user certificate
+// signed by a trusted certificate authority and currently inside its validity
+// window, then returns the first of its principals that names an active user.
+func GetUserBySSHCertificate(cert *ssh.Certificate) (*User, string, error) {
+	return getUserBySSHCertificate(cert, time.Now)
+}
+
+func getUserBySSHCertificate(cert *ssh.Certificate, clock func() time.Time) (*User, string, error) {
+	if cert.CertType != ssh.UserCert {
+		return nil, "", ErrSSHCertificateNotTrusted{cert.KeyId, "not a user certificate"}
+	}
+	if !IsTrustedUserCAKey(cert.SignatureKey) {
+		return nil, "", ErrSSHCertificateNotTrusted{cert.KeyId, "not signed by a trusted certificate authority"}
+	}
+
+	checker := &ssh.CertChecker{
+		Clock: clock,
+	}
+	for _, principal := range cert.ValidPrincipals {
+		if err := checker.CheckCert(principal, cert); err != nil {
+			return nil, "", ErrSSHCertificateNotTrusted{cert.KeyId, err.Error()}
+		}
+
+		u, err := GetUserByName(principal)
+		if err != nil {
+			if IsErrUserNotExist(err) {
+				continue
+			}
+			return nil, "", err
+		}
+		if u.IsOrganization() || !u.IsActive || u.ProhibitLogin {
+			continue
+		}
+		return u, principal, nil
+	}
+	return nil, "", ErrSSHCertificateNotTrusted{cert.KeyId, "no principal matches an active user"}
+}
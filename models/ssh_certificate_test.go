@@ -0,0 +1,93 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestSSHSigner(t *testing.T) ssh.Signer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(priv)
+	assert.NoError(t, err)
+	return signer
+}
+
+func newTestSSHCertificate(t *testing.T, ca ssh.Signer, certType uint32, validAfter, validBefore time.Time, principals ...string) *ssh.Certificate {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	key, err := ssh.NewPublicKey(pub)
+	assert.NoError(t, err)
+
+	cert := &ssh.Certificate{
+		Key:             key,
+		KeyId:           "test",
+		CertType:        certType,
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(validAfter.Unix()),
+		ValidBefore:     uint64(validBefore.Unix()),
+	}
+	assert.NoError(t, cert.SignCert(rand.Reader, ca))
+	return cert
+}
+
+func TestGetUserBySSHCertificate(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	ca := newTestSSHSigner(t)
+	untrusted := newTestSSHSigner(t)
+
+	oldKeys := setting.SSH.TrustedUserCAKeysParsed
+	setting.SSH.TrustedUserCAKeysParsed = []ssh.PublicKey{ca.PublicKey()}
+	defer func() {
+		setting.SSH.TrustedUserCAKeysParsed = oldKeys
+	}()
+
+	now := time.Now()
+	hourAgo, inAnHour := now.Add(-time.Hour), now.Add(time.Hour)
+
+	// the first principal naming an active user wins
+	cert := newTestSSHCertificate(t, ca, ssh.UserCert, hourAgo, inAnHour, "nobody", "user9", "user3", "user2", "user4")
+	u, principal, err := GetUserBySSHCertificate(cert)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, u.ID)
+	assert.Equal(t, "user2", principal)
+
+	// validity window is respected
+	_, _, err = GetUserBySSHCertificate(newTestSSHCertificate(t, ca, ssh.UserCert, hourAgo.Add(-time.Hour), hourAgo, "user2"))
+	assert.True(t, IsErrSSHCertificateNotTrusted(err))
+	_, _, err = GetUserBySSHCertificate(newTestSSHCertificate(t, ca, ssh.UserCert, inAnHour, inAnHour.Add(time.Hour), "user2"))
+	assert.True(t, IsErrSSHCertificateNotTrusted(err))
+	_, _, err = getUserBySSHCertificate(cert, func() time.Time { return inAnHour.Add(time.Second) })
+	assert.True(t, IsErrSSHCertificateNotTrusted(err))
+
+	// signed by an unknown authority
+	_, _, err = GetUserBySSHCertificate(newTestSSHCertificate(t, untrusted, ssh.UserCert, hourAgo, inAnHour, "user2"))
+	assert.True(t, IsErrSSHCertificateNotTrusted(err))
+
+	// host certificates cannot be used to log in
+	_, _, err = GetUserBySSHCertificate(newTestSSHCertificate(t, ca, ssh.HostCert, hourAgo, inAnHour, "user2"))
+	assert.True(t, IsErrSSHCertificateNotTrusted(err))
+
+	// inactive users and organizations do not match, nor do certificates without principals
+	_, _, err = GetUserBySSHCertificate(newTestSSHCertificate(t, ca, ssh.UserCert, hourAgo, inAnHour, "user9", "user3"))
+	assert.True(t, IsErrSSHCertificateNotTrusted(err))
+	_, _, err = GetUserBySSHCertificate(newTestSSHCertificate(t, ca, ssh.UserCert, hourAgo, inAnHour))
+	assert.True(t, IsErrSSHCertificateNotTrusted(err))
+
+	// no trusted authorities configured
+	setting.SSH.TrustedUserCAKeysParsed = nil
+	_, _, err = GetUserBySSHCertificate(cert)
+	assert.True(t, IsErrSSHCertificateNotTrusted(err))
+}
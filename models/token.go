@@ -24,18 +24,26 @@ type AccessToken struct {
 	Token          string `xorm:"-"`
 	TokenHash      string `xorm:"UNIQUE"` // sha256 of token
 	TokenSalt      string
-	TokenLastEight string `xorm:"token_last_eight"`
+	TokenLastEight string           `xorm:"token_last_eight"`
+	Scope          AccessTokenScope `xorm:"NOT NULL DEFAULT 'all'"`
 
 	CreatedUnix       timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix       timeutil.TimeStamp `xorm:"INDEX updated"`
+	ExpiresUnix       timeutil.TimeStamp `xorm:"INDEX NOT NULL DEFAULT 0"`
+	LastUsedUnix      timeutil.TimeStamp `xorm:"NOT NULL DEFAULT 0"`
 	HasRecentActivity bool               `xorm:"-"`
 	HasUsed           bool               `xorm:"-"`
 }
 
 // AfterLoad is invoked from XORM after setting the values of all fields of this object.
 func (t *AccessToken) AfterLoad() {
-	t.HasUsed = t.UpdatedUnix > t.CreatedUnix
-	t.HasRecentActivity = t.UpdatedUnix.AddDuration(7*24*time.Hour) > timeutil.TimeStampNow()
+	t.HasUsed = t.LastUsedUnix > 0
+	t.HasRecentActivity = t.LastUsedUnix.AddDuration(7*24*time.Hour) > timeutil.TimeStampNow()
+}
+
+// IsExpired returns true if the token has an expiry date which has passed
+func (t *AccessToken) IsExpired() bool {
+	return t.ExpiresUnix != 0 && t.ExpiresUnix <= timeutil.TimeStampNow()
 }
 
 // NewAccessToken creates new access token.
@@ -48,11 +56,15 @@ func NewAccessToken(t *AccessToken) error {
 	t.Token = base.EncodeSha1(gouuid.NewV4().String())
 	t.TokenHash = hashToken(t.Token, t.TokenSalt)
 	t.TokenLastEight = t.Token[len(t.Token)-8:]
+	if len(t.Scope) == 0 {
+		t.Scope = AccessTokenScopeAll
+	}
 	_, err = x.Insert(t)
 	return err
 }
 
-// GetAccessTokenBySHA returns access token by given token value
+// GetAccessTokenBySHA returns access token by given token value,
+// an expired token results in ErrAccessTokenExpired.
 func GetAccessTokenBySHA(token string) (*AccessToken, error) {
 	if token == "" {
 		return nil, ErrAccessTokenEmpty{}
@@ -71,6 +83,9 @@ func GetAccessTokenBySHA(token string) (*AccessToken, error) {
 	for _, t := range tokens {
 		tempHash := hashToken(token, t.TokenSalt)
 		if subtle.ConstantTimeCompare([]byte(t.TokenHash), []byte(tempHash)) == 1 {
+			if t.IsExpired() {
+				return nil, ErrAccessTokenExpired{t.ID}
+			}
 			return &t, nil
 		}
 	}
@@ -86,6 +101,13 @@ func ListAccessTokens(uid int64) ([]*AccessToken, error) {
 		Find(&tokens)
 }
 
+// UpdateAccessTokenLastUsed records that the access token has just been used.
+func UpdateAccessTokenLastUsed(t *AccessToken) error {
+	t.LastUsedUnix = timeutil.TimeStampNow()
+	_, err := x.ID(t.ID).Cols("last_used_unix").NoAutoTime().Update(t)
+	return err
+}
+
 // UpdateAccessToken updates information of access token.
 func UpdateAccessToken(t *AccessToken) error {
 	_, err := x.ID(t.ID).AllCols().Update(t)
@@ -0,0 +1,122 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"strings"
+)
+
+// AccessTokenScope represents the comma separated scopes of an access token
+type AccessTokenScope string
+
+// Access token scopes
+const (
+	// AccessTokenScopeAll grants all rights of the user, it is the scope of tokens created without scopes
+	AccessTokenScopeAll AccessTokenScope = "all"
+
+	AccessTokenScopeRepoRead  AccessTokenScope = "repo:read"
+	AccessTokenScopeRepoWrite AccessTokenScope = "repo:write"
+	AccessTokenScopeIssue     AccessTokenScope = "issue"
+	AccessTokenScopePackage   AccessTokenScope = "package"
+	AccessTokenScopeUser      AccessTokenScope = "user"
+	AccessTokenScopeAdmin     AccessTokenScope = "admin"
+)
+
+// AllAccessTokenScopes contains the scopes which can be granted to an access token
+var AllAccessTokenScopes = []AccessTokenScope{
+	AccessTokenScopeRepoRead,
+	AccessTokenScopeRepoWrite,
+	AccessTokenScopeIssue,
+	AccessTokenScopePackage,
+	AccessTokenScopeUser,
+	AccessTokenScopeAdmin,
+}
+
+// ParseAccessTokenScope validates the scopes and returns them as AccessTokenScope,
+// no scopes grant all rights of the user.
+func ParseAccessTokenScope(scopes []string) (AccessTokenScope, error) {
+	parsed := make([]string, 0, len(scopes))
+	seen := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if len(scope) == 0 {
+			continue
+		}
+		if AccessTokenScope(scope) == AccessTokenScopeAll {
+			return AccessTokenScopeAll, nil
+		}
+		if !isValidAccessTokenScope(AccessTokenScope(scope)) {
+			return "", ErrInvalidAccessTokenScope{scope}
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			parsed = append(parsed, scope)
+		}
+	}
+	if len(parsed) == 0 {
+		return AccessTokenScopeAll, nil
+	}
+	return AccessTokenScope(strings.Join(parsed, ",")), nil
+}
+
+func isValidAccessTokenScope(scope AccessTokenScope) bool {
+	for _, s := range AllAccessTokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the single scopes
+func (s AccessTokenScope) List() []AccessTokenScope {
+	if len(s) == 0 {
+		return nil
+	}
+	parts := strings.Split(string(s), ",")
+	scopes := make([]AccessTokenScope, len(parts))
+	for i := range parts {
+		scopes[i] = AccessTokenScope(parts[i])
+	}
+	return scopes
+}
+
+// DescKey returns the locale key of the description of a single scope
+func (s AccessTokenScope) DescKey() string {
+	return "settings.token_scope_desc." + strings.Replace(string(s), ":", "_", -1)
+}
+
+// Has returns true if the scopes grant the scope,
+// the repo:write scope includes the repo:read scope.
+func (s AccessTokenScope) Has(scope AccessTokenScope) bool {
+	if s.hasExact(AccessTokenScopeAll) || s.hasExact(scope) {
+		return true
+	}
+	return scope == AccessTokenScopeRepoRead && s.hasExact(AccessTokenScopeRepoWrite)
+}
+
+// Covers returns true if the scopes grant every scope of other,
+// only the all scope covers the all scope.
+func (s AccessTokenScope) Covers(other AccessTokenScope) bool {
+	for _, scope := range other.List() {
+		if scope == AccessTokenScopeAll {
+			if !s.hasExact(AccessTokenScopeAll) {
+				return false
+			}
+		} else if !s.Has(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s AccessTokenScope) hasExact(scope AccessTokenScope) bool {
+	for _, own := range s.List() {
+		if own == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAccessTokenScope(t *testing.T) {
+	kases := []struct {
+		scopes []string
+		scope  AccessTokenScope
+	}{
+		{nil, AccessTokenScopeAll},
+		{[]string{"", " "}, AccessTokenScopeAll},
+		{[]string{"repo:read", "all"}, AccessTokenScopeAll},
+		{[]string{"repo:read"}, "repo:read"},
+		{[]string{" issue ", "repo:write", "issue"}, "issue,repo:write"},
+	}
+	for _, kase := range kases {
+		scope, err := ParseAccessTokenScope(kase.scopes)
+		assert.NoError(t, err)
+		assert.Equal(t, kase.scope, scope)
+	}
+
+	_, err := ParseAccessTokenScope([]string{"repo:read", "sudo"})
+	assert.Error(t, err)
+	assert.True(t, IsErrInvalidAccessTokenScope(err))
+}
+
+func TestAccessTokenScope_Has(t *testing.T) {
+	assert.True(t, AccessTokenScopeAll.Has(AccessTokenScopeAdmin))
+	assert.True(t, AccessTokenScopeAll.Has(AccessTokenScopeRepoWrite))
+
+	scope := AccessTokenScope("repo:write,package")
+	assert.True(t, scope.Has(AccessTokenScopeRepoWrite))
+	assert.True(t, scope.Has(AccessTokenScopeRepoRead))
+	assert.True(t, scope.Has(AccessTokenScopePackage))
+	assert.False(t, scope.Has(AccessTokenScopeIssue))
+	assert.False(t, scope.Has(AccessTokenScopeAdmin))
+
+	scope = AccessTokenScope("repo:read")
+	assert.True(t, scope.Has(AccessTokenScopeRepoRead))
+	assert.False(t, scope.Has(AccessTokenScopeRepoWrite))
+}
+
+func TestAccessTokenScope_Covers(t *testing.T) {
+	assert.True(t, AccessTokenScopeAll.Covers(AccessTokenScopeAll))
+	assert.True(t, AccessTokenScopeAll.Covers("admin,user"))
+
+	scope := AccessTokenScope("repo:write,user")
+	assert.True(t, scope.Covers("repo:read,user"))
+	assert.True(t, scope.Covers("repo:write"))
+	assert.False(t, scope.Covers("user,admin"))
+	assert.False(t, scope.Covers(AccessTokenScopeAll))
+
+	scope = AccessTokenScope("admin,repo:read,repo:write,issue,package,user")
+	assert.False(t, scope.Covers(AccessTokenScopeAll))
+}
@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"code.gitea.io/gitea/modules/timeutil"
 )
 
 func TestNewAccessToken(t *testing.T) {
@@ -27,6 +29,26 @@ func TestNewAccessToken(t *testing.T) {
 	assert.Error(t, NewAccessToken(invalidToken))
 }
 
+func TestNewAccessTokenWithScope(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	token := &AccessToken{
+		UID:  3,
+		Name: "Token C",
+	}
+	assert.NoError(t, NewAccessToken(token))
+	AssertExistsAndLoadBean(t, &AccessToken{ID: token.ID, Scope: AccessTokenScopeAll})
+
+	token = &AccessToken{
+		UID:   3,
+		Name:  "Token D",
+		Scope: "repo:read,issue",
+	}
+	assert.NoError(t, NewAccessToken(token))
+	loaded, err := GetAccessTokenBySHA(token.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, AccessTokenScope("repo:read,issue"), loaded.Scope)
+}
+
 func TestGetAccessTokenBySHA(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	token, err := GetAccessTokenBySHA("d2c6c1ba3890b309189a8e618c72a162e4efbf36")
@@ -45,6 +67,41 @@ func TestGetAccessTokenBySHA(t *testing.T) {
 	assert.True(t, IsErrAccessTokenEmpty(err))
 }
 
+func TestGetAccessTokenBySHAExpired(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	token := &AccessToken{
+		UID:         3,
+		Name:        "Token E",
+		ExpiresUnix: timeutil.TimeStampNow().Add(-60),
+	}
+	assert.NoError(t, NewAccessToken(token))
+	_, err := GetAccessTokenBySHA(token.Token)
+	assert.Error(t, err)
+	assert.True(t, IsErrAccessTokenExpired(err))
+
+	token = &AccessToken{
+		UID:         3,
+		Name:        "Token F",
+		ExpiresUnix: timeutil.TimeStampNow().Add(3600),
+	}
+	assert.NoError(t, NewAccessToken(token))
+	_, err = GetAccessTokenBySHA(token.Token)
+	assert.NoError(t, err)
+}
+
+func TestUpdateAccessTokenLastUsed(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	token, err := GetAccessTokenBySHA("4c6f36e6cf498e2a448662f915d932c09c5a146c")
+	assert.NoError(t, err)
+	assert.False(t, token.HasUsed)
+
+	assert.NoError(t, UpdateAccessTokenLastUsed(token))
+	token = AssertExistsAndLoadBean(t, &AccessToken{ID: token.ID}).(*AccessToken)
+	assert.True(t, token.HasUsed)
+	assert.True(t, token.HasRecentActivity)
+	assert.EqualValues(t, 946687980, token.UpdatedUnix)
+}
+
 func TestListAccessTokens(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	tokens, err := ListAccessTokens(1)
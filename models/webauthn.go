@@ -0,0 +1,161 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/base64"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/timeutil"
+	wa "code.gitea.io/gitea/modules/webauthn"
+
+	"github.com/duo-labs/webauthn/webauthn"
+)
+
+// WebAuthnCredential represents a security key or platform authenticator
+// registered by a user as second factor
+type WebAuthnCredential struct {
+	ID              int64 `xorm:"pk autoincr"`
+	Name            string
+	UserID          int64  `xorm:"INDEX"`
+	CredentialID    string `xorm:"INDEX VARCHAR(410)"`
+	PublicKey       []byte
+	AttestationType string
+	AAGUID          []byte
+	SignCount       uint32 `xorm:"BIGINT"`
+	// LegacyU2F is set for keys migrated from U2F registrations
+	LegacyU2F   bool               `xorm:"'legacy_u2f' NOT NULL DEFAULT false"`
+	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
+}
+
+// TableName returns a better table name for WebAuthnCredential
+func (cred WebAuthnCredential) TableName() string {
+	return "webauthn_credential"
+}
+
+// ToCredential converts the db entry to a webauthn.Credential
+func (cred *WebAuthnCredential) ToCredential() (*webauthn.Credential, error) {
+	id, err := base64.RawURLEncoding.DecodeString(cred.CredentialID)
+	if err != nil {
+		return nil, err
+	}
+	return &webauthn.Credential{
+		ID:              id,
+		PublicKey:       cred.PublicKey,
+		AttestationType: cred.AttestationType,
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    cred.AAGUID,
+			SignCount: cred.SignCount,
+		},
+	}, nil
+}
+
+func (cred *WebAuthnCredential) updateSignCount(e Engine) error {
+	_, err := e.ID(cred.ID).Cols("sign_count").Update(cred)
+	return err
+}
+
+// UpdateSignCount will update the database value of the signature counter
+func (cred *WebAuthnCredential) UpdateSignCount() error {
+	return cred.updateSignCount(x)
+}
+
+// WebAuthnCredentialList is a list of *WebAuthnCredential
+type WebAuthnCredentialList []*WebAuthnCredential
+
+// ToWebAuthnUser returns the WebAuthn user of the given user with the credentials of the list
+func (list WebAuthnCredentialList) ToWebAuthnUser(u *User) *wa.User {
+	user := &wa.User{
+		ID:          u.ID,
+		Name:        u.Name,
+		DisplayName: u.DisplayName(),
+	}
+	for _, cred := range list {
+		c, err := cred.ToCredential()
+		if err != nil {
+			log.Error("parsing webauthn credential %d: %v", cred.ID, err)
+			continue
+		}
+		if cred.LegacyU2F {
+			user.LegacyCredentials = append(user.LegacyCredentials, *c)
+		} else {
+			user.Credentials = append(user.Credentials, *c)
+		}
+	}
+	return user
+}
+
+// ByCredentialID returns the credential of the list with the given raw credential ID
+func (list WebAuthnCredentialList) ByCredentialID(id []byte) *WebAuthnCredential {
+	encoded := base64.RawURLEncoding.EncodeToString(id)
+	for _, cred := range list {
+		if cred.CredentialID == encoded {
+			return cred
+		}
+	}
+	return nil
+}
+
+func getWebAuthnCredentialsByUID(e Engine, uid int64) (WebAuthnCredentialList, error) {
+	creds := make(WebAuthnCredentialList, 0)
+	return creds, e.Where("user_id = ?", uid).Find(&creds)
+}
+
+// GetWebAuthnCredentialsByUID returns all WebAuthn credentials of the given user
+func GetWebAuthnCredentialsByUID(uid int64) (WebAuthnCredentialList, error) {
+	return getWebAuthnCredentialsByUID(x, uid)
+}
+
+// HasWebAuthnCredentialsByUID returns whether the given user has WebAuthn credentials
+func HasWebAuthnCredentialsByUID(uid int64) (bool, error) {
+	return x.Where("user_id = ?", uid).Exist(new(WebAuthnCredential))
+}
+
+// GetWebAuthnCredentialByID returns WebAuthn credential by id
+func GetWebAuthnCredentialByID(id int64) (*WebAuthnCredential, error) {
+	return getWebAuthnCredentialByID(x, id)
+}
+
+func getWebAuthnCredentialByID(e Engine, id int64) (*WebAuthnCredential, error) {
+	cred := new(WebAuthnCredential)
+	if found, err := e.ID(id).Get(cred); err != nil {
+		return nil, err
+	} else if !found {
+		return nil, ErrWebAuthnCredentialNotExist{ID: id}
+	}
+	return cred, nil
+}
+
+func createWebAuthnCredential(e Engine, user *User, name string, c *webauthn.Credential) (*WebAuthnCredential, error) {
+	cred := &WebAuthnCredential{
+		UserID:          user.ID,
+		Name:            name,
+		CredentialID:    base64.RawURLEncoding.EncodeToString(c.ID),
+		PublicKey:       c.PublicKey,
+		AttestationType: c.AttestationType,
+		AAGUID:          c.Authenticator.AAGUID,
+		SignCount:       c.Authenticator.SignCount,
+	}
+	if _, err := e.InsertOne(cred); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// CreateWebAuthnCredential will create a new WebAuthnCredential from the given credential
+func CreateWebAuthnCredential(user *User, name string, cred *webauthn.Credential) (*WebAuthnCredential, error) {
+	return createWebAuthnCredential(x, user, name, cred)
+}
+
+// DeleteWebAuthnCredential will delete WebAuthnCredential
+func DeleteWebAuthnCredential(cred *WebAuthnCredential) error {
+	return deleteWebAuthnCredential(x, cred)
+}
+
+func deleteWebAuthnCredential(e Engine, cred *WebAuthnCredential) error {
+	_, err := e.Delete(cred)
+	return err
+}
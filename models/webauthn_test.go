@@ -0,0 +1,106 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/duo-labs/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWebAuthnCredentialByID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	res, err := GetWebAuthnCredentialByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "WebAuthn Key", res.Name)
+
+	_, err = GetWebAuthnCredentialByID(342432)
+	assert.Error(t, err)
+	assert.True(t, IsErrWebAuthnCredentialNotExist(err))
+}
+
+func TestGetWebAuthnCredentialsByUID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	res, err := GetWebAuthnCredentialsByUID(1)
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+	assert.Equal(t, "WebAuthn Key", res[0].Name)
+
+	has, err := HasWebAuthnCredentialsByUID(1)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	has, err = HasWebAuthnCredentialsByUID(2)
+	assert.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestWebAuthnCredential_TableName(t *testing.T) {
+	assert.Equal(t, "webauthn_credential", WebAuthnCredential{}.TableName())
+}
+
+func TestWebAuthnCredential_UpdateSignCount(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	cred := AssertExistsAndLoadBean(t, &WebAuthnCredential{ID: 1}).(*WebAuthnCredential)
+	cred.SignCount = 1
+	assert.NoError(t, cred.UpdateSignCount())
+	AssertExistsIf(t, true, &WebAuthnCredential{ID: 1, SignCount: 1})
+}
+
+func TestWebAuthnCredential_UpdateLargeSignCount(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	cred := AssertExistsAndLoadBean(t, &WebAuthnCredential{ID: 1}).(*WebAuthnCredential)
+	cred.SignCount = 0xffffffff
+	assert.NoError(t, cred.UpdateSignCount())
+	AssertExistsIf(t, true, &WebAuthnCredential{ID: 1, SignCount: 0xffffffff})
+}
+
+func TestWebAuthnCredentialList_ByCredentialID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	list, err := GetWebAuthnCredentialsByUID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, list[0], list.ByCredentialID([]byte("key-handle")))
+	assert.Nil(t, list.ByCredentialID([]byte("unknown")))
+}
+
+func TestWebAuthnCredentialList_ToWebAuthnUser(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	user := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+
+	list, err := GetWebAuthnCredentialsByUID(1)
+	assert.NoError(t, err)
+	list = append(list, &WebAuthnCredential{CredentialID: "bGVnYWN5", LegacyU2F: true})
+	waUser := list.ToWebAuthnUser(user)
+	assert.EqualValues(t, 1, waUser.ID)
+	assert.Equal(t, user.Name, waUser.WebAuthnName())
+	assert.Len(t, waUser.Credentials, 1)
+	assert.Equal(t, []byte("key-handle"), waUser.Credentials[0].ID)
+	assert.Len(t, waUser.LegacyCredentials, 1)
+	assert.Equal(t, []byte("legacy"), waUser.LegacyCredentials[0].ID)
+	assert.Len(t, waUser.WebAuthnCredentials(), 2)
+}
+
+func TestCreateWebAuthnCredential(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	user := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+
+	res, err := CreateWebAuthnCredential(user, "WebAuthn Created Key", &webauthn.Credential{ID: []byte("Test"), Authenticator: webauthn.Authenticator{SignCount: 3}})
+	assert.NoError(t, err)
+	assert.Equal(t, "WebAuthn Created Key", res.Name)
+	assert.Equal(t, "VGVzdA", res.CredentialID)
+
+	AssertExistsIf(t, true, &WebAuthnCredential{Name: "WebAuthn Created Key", UserID: user.ID, SignCount: 3})
+}
+
+func TestDeleteWebAuthnCredential(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	cred := AssertExistsAndLoadBean(t, &WebAuthnCredential{ID: 1}).(*WebAuthnCredential)
+
+	assert.NoError(t, DeleteWebAuthnCredential(cred))
+	AssertNotExistsBean(t, &WebAuthnCredential{ID: 1})
+}
@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/modules/timeutil"
 
 	gouuid "github.com/satori/go.uuid"
+	"xorm.io/builder"
 )
 
 // HookContentType is the content type of a web hook
@@ -105,6 +106,8 @@ type Webhook struct {
 	HookTaskType HookTaskType
 	Meta         string     `xorm:"TEXT"` // store hook-specific attributes
 	LastStatus   HookStatus // Last delivery status
+	// FailureCount is the number of consecutive hook tasks which failed in all attempts
+	FailureCount int `xorm:"NOT NULL DEFAULT 0"`
 
 	CreatedUnix timeutil.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix timeutil.TimeStamp `xorm:"INDEX updated"`
@@ -120,7 +123,11 @@ func (w *Webhook) AfterLoad() {
 
 // History returns history of webhook by given conditions.
 func (w *Webhook) History(page int) ([]*HookTask, error) {
-	return HookTasks(w.ID, page)
+	tasks, err := HookTasks(w.ID, page)
+	if err != nil {
+		return nil, err
+	}
+	return tasks, LoadHookTaskAttempts(tasks)
 }
 
 // UpdateEvent handles conversion from HookEvent to Events.
@@ -326,6 +333,10 @@ func getDefaultWebhooks(e Engine) ([]*Webhook, error) {
 
 // UpdateWebhook updates information of webhook.
 func UpdateWebhook(w *Webhook) error {
+	// Failed deliveries before the webhook has been edited do not count anymore
+	if w.IsActive {
+		w.FailureCount = 0
+	}
 	_, err := x.ID(w.ID).AllCols().Update(w)
 	return err
 }
@@ -336,6 +347,13 @@ func UpdateWebhookLastStatus(w *Webhook) error {
 	return err
 }
 
+// UpdateWebhookFailureCount updates the failure count of the webhook, it is deactivated
+// if the webhook is not active anymore.
+func UpdateWebhookFailureCount(w *Webhook) error {
+	_, err := x.ID(w.ID).Cols("failure_count", "is_active").Update(w)
+	return err
+}
+
 // deleteWebhook uses argument bean as query condition,
 // ID must be specified and do not assign unnecessary fields.
 func deleteWebhook(bean *Webhook) (err error) {
@@ -349,6 +367,8 @@ func deleteWebhook(bean *Webhook) (err error) {
 		return err
 	} else if count == 0 {
 		return ErrWebhookNotExist{ID: bean.ID}
+	} else if _, err = sess.In("hook_task_id", builder.Select("id").From("hook_task").Where(builder.Eq{"hook_id": bean.ID})).Delete(new(HookTaskAttempt)); err != nil {
+		return err
 	} else if _, err = sess.Delete(&HookTask{HookID: bean.ID}); err != nil {
 		return err
 	}
@@ -526,6 +546,12 @@ type HookTask struct {
 	Delivered       int64
 	DeliveredString string `xorm:"-"`
 
+	// Attempts is the number of attempts to deliver the task, failed deliveries are attempted
+	// again at NextAttemptUnix until the maximum number of attempts is reached
+	Attempts        int                `xorm:"NOT NULL DEFAULT 0"`
+	NextAttemptUnix timeutil.TimeStamp `xorm:"INDEX NOT NULL DEFAULT 0"`
+	AttemptHistory  []*HookTaskAttempt `xorm:"-"`
+
 	// History info.
 	IsSucceed       bool
 	RequestContent  string        `xorm:"TEXT"`
@@ -591,36 +617,138 @@ func CreateHookTask(t *HookTask) error {
 }
 
 func createHookTask(e Engine, t *HookTask) error {
-	data, err := t.Payloader.JSONPayload()
-	if err != nil {
-		return err
+	// A redelivered task already has its payload content
+	if t.Payloader != nil {
+		data, err := t.Payloader.JSONPayload()
+		if err != nil {
+			return err
+		}
+		t.PayloadContent = string(data)
 	}
 	t.UUID = gouuid.NewV4().String()
-	t.PayloadContent = string(data)
-	_, err = e.Insert(t)
+	_, err := e.Insert(t)
 	return err
 }
 
+// GetHookTaskByHookID returns the hook task of the webhook with the given ID
+func GetHookTaskByHookID(hookID, id int64) (*HookTask, error) {
+	t := &HookTask{}
+	has, err := x.Where("id = ? AND hook_id = ?", id, hookID).Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrHookTaskNotExist{ID: id, HookID: hookID}
+	}
+	return t, nil
+}
+
+// FindHookTasksDeliveredBetween returns the delivered tasks of the webhook whose last attempt
+// was in the given time range, the end is not included
+func FindHookTasksDeliveredBetween(hookID int64, since, before time.Time) ([]*HookTask, error) {
+	tasks := make([]*HookTask, 0, 10)
+	return tasks, x.
+		Where("hook_id = ? AND is_delivered = ?", hookID, true).
+		And("delivered >= ? AND delivered < ?", since.UnixNano(), before.UnixNano()).
+		Asc("id").
+		Find(&tasks)
+}
+
 // UpdateHookTask updates information of hook task.
 func UpdateHookTask(t *HookTask) error {
 	_, err := x.ID(t.ID).AllCols().Update(t)
 	return err
 }
 
-// FindUndeliveredHookTasks represents find the undelivered hook tasks
+// UpdateHookTaskWithAttempt updates information of hook task and records the attempt to deliver it.
+func UpdateHookTaskWithAttempt(t *HookTask, attempt *HookTaskAttempt) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.ID(t.ID).AllCols().Update(t); err != nil {
+		return err
+	}
+	attempt.HookTaskID = t.ID
+	if _, err := sess.Insert(attempt); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// FindUndeliveredHookTasks represents find the undelivered hook tasks which are due
 func FindUndeliveredHookTasks() ([]*HookTask, error) {
 	tasks := make([]*HookTask, 0, 10)
-	if err := x.Where("is_delivered=?", false).Find(&tasks); err != nil {
+	if err := x.Where("is_delivered=? AND next_attempt_unix<=?", false, timeutil.TimeStampNow()).Find(&tasks); err != nil {
 		return nil, err
 	}
 	return tasks, nil
 }
 
-// FindRepoUndeliveredHookTasks represents find the undelivered hook tasks of one repository
+// FindRepoUndeliveredHookTasks represents find the undelivered hook tasks of one repository which are due
 func FindRepoUndeliveredHookTasks(repoID int64) ([]*HookTask, error) {
 	tasks := make([]*HookTask, 0, 5)
-	if err := x.Where("repo_id=? AND is_delivered=?", repoID, false).Find(&tasks); err != nil {
+	if err := x.Where("repo_id=? AND is_delivered=? AND next_attempt_unix<=?", repoID, false, timeutil.TimeStampNow()).Find(&tasks); err != nil {
 		return nil, err
 	}
 	return tasks, nil
 }
+
+// HookTaskAttempt records an attempt to deliver a hook task
+type HookTaskAttempt struct {
+	ID              int64 `xorm:"pk autoincr"`
+	HookTaskID      int64 `xorm:"INDEX"`
+	Attempt         int
+	IsSucceed       bool
+	ResponseContent string        `xorm:"TEXT"`
+	ResponseInfo    *HookResponse `xorm:"-"`
+	Delivered       int64
+	DeliveredString string `xorm:"-"`
+}
+
+// BeforeInsert will be invoked by XORM before inserting a record
+func (a *HookTaskAttempt) BeforeInsert() {
+	if a.ResponseInfo != nil {
+		p, err := json.Marshal(a.ResponseInfo)
+		if err != nil {
+			log.Error("Marshal [%d]: %v", a.HookTaskID, err)
+		}
+		a.ResponseContent = string(p)
+	}
+}
+
+// AfterLoad updates the attempt object upon setting a column
+func (a *HookTaskAttempt) AfterLoad() {
+	a.DeliveredString = time.Unix(0, a.Delivered).Format("2006-01-02 15:04:05 MST")
+
+	if len(a.ResponseContent) > 0 {
+		a.ResponseInfo = &HookResponse{}
+		if err := json.Unmarshal([]byte(a.ResponseContent), a.ResponseInfo); err != nil {
+			log.Error("Unmarshal ResponseContent[%d]: %v", a.ID, err)
+		}
+	}
+}
+
+// LoadHookTaskAttempts loads the attempts to deliver the hook tasks
+func LoadHookTaskAttempts(tasks []*HookTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(tasks))
+	taskMap := make(map[int64]*HookTask, len(tasks))
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+		taskMap[t.ID] = t
+		t.AttemptHistory = nil
+	}
+
+	attempts := make([]*HookTaskAttempt, 0, len(tasks))
+	if err := x.In("hook_task_id", ids).Asc("id").Find(&attempts); err != nil {
+		return err
+	}
+	for _, a := range attempts {
+		taskMap[a.HookTaskID].AttemptHistory = append(taskMap[a.HookTaskID].AttemptHistory, a)
+	}
+	return nil
+}
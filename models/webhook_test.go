@@ -7,6 +7,7 @@ package models
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	api "code.gitea.io/gitea/modules/structs"
 
@@ -241,3 +242,72 @@ func TestUpdateHookTask(t *testing.T) {
 	assert.NoError(t, UpdateHookTask(hook))
 	AssertExistsAndLoadBean(t, hook)
 }
+
+func TestGetHookTaskByHookID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	hookTask, err := GetHookTaskByHookID(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "uuid1", hookTask.UUID)
+
+	_, err = GetHookTaskByHookID(2, 1)
+	assert.True(t, IsErrHookTaskNotExist(err))
+}
+
+func TestFindHookTasksDeliveredBetween(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	now := time.Now()
+	hookTask := AssertExistsAndLoadBean(t, &HookTask{ID: 1}).(*HookTask)
+	hookTask.Delivered = now.UnixNano()
+	assert.NoError(t, UpdateHookTask(hookTask))
+
+	hookTasks, err := FindHookTasksDeliveredBetween(1, now.Add(-time.Hour), now.Add(time.Hour))
+	assert.NoError(t, err)
+	if assert.Len(t, hookTasks, 1) {
+		assert.EqualValues(t, 1, hookTasks[0].ID)
+	}
+
+	hookTasks, err = FindHookTasksDeliveredBetween(1, now.Add(time.Second), now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, hookTasks, 0)
+}
+
+func TestUpdateHookTaskWithAttempt(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	hookTask := AssertExistsAndLoadBean(t, &HookTask{ID: 1}).(*HookTask)
+	hookTask.Attempts = 2
+	hookTask.IsSucceed = true
+	assert.NoError(t, UpdateHookTaskWithAttempt(hookTask, &HookTaskAttempt{
+		Attempt:      2,
+		IsSucceed:    true,
+		ResponseInfo: &HookResponse{Status: 200},
+	}))
+	AssertExistsAndLoadBean(t, &HookTask{ID: 1, Attempts: 2})
+	AssertExistsAndLoadBean(t, &HookTaskAttempt{HookTaskID: 1, Attempt: 2})
+
+	hookTasks := []*HookTask{hookTask}
+	assert.NoError(t, LoadHookTaskAttempts(hookTasks))
+	if assert.Len(t, hookTask.AttemptHistory, 1) {
+		assert.True(t, hookTask.AttemptHistory[0].IsSucceed)
+		assert.Equal(t, 200, hookTask.AttemptHistory[0].ResponseInfo.Status)
+	}
+}
+
+func TestUpdateWebhookFailureCount(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	hook := AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
+	hook.FailureCount = 3
+	hook.IsActive = false
+	assert.NoError(t, UpdateWebhookFailureCount(hook))
+	hook = AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
+	assert.EqualValues(t, 3, hook.FailureCount)
+	assert.False(t, hook.IsActive)
+
+	// Activating the webhook again resets the failure count
+	hook.IsActive = true
+	assert.NoError(t, UpdateWebhook(hook))
+	hook = AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
+	assert.EqualValues(t, 0, hook.FailureCount)
+}
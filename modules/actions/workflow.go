@@ -0,0 +1,272 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"code.gitea.io/gitea/modules/git"
+
+	"github.com/gobwas/glob"
+	"gopkg.in/yaml.v2"
+)
+
+// WorkflowsDir is the directory of the repository which contains the workflow files
+const WorkflowsDir = ".gitea/workflows"
+
+// Event is the type of an event which can trigger a workflow
+type Event string
+
+const (
+	// EventPush is triggered by pushes to branches and tags
+	EventPush Event = "push"
+	// EventPullRequest is triggered by new and synchronized pull requests
+	EventPullRequest Event = "pull_request"
+	// EventMergeGroup is triggered by speculative merges of the merge queue of a protected branch
+	EventMergeGroup Event = "merge_group"
+)
+
+var (
+	// ErrNoJobs is returned for workflows without jobs
+	ErrNoJobs = errors.New("workflow has no jobs")
+	// ErrNoEvents is returned for workflows without valid events
+	ErrNoEvents = errors.New("workflow has no events")
+)
+
+// Labels is a list of runner labels which can be written as single string or as list
+type Labels []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (l *Labels) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single string
+	if err := unmarshal(&single); err == nil {
+		*l = Labels{single}
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// EventFilter restricts the refs a workflow is triggered for
+type EventFilter struct {
+	Branches []string `yaml:"branches"`
+	Tags     []string `yaml:"tags"`
+}
+
+// Step is a single command of a job
+type Step struct {
+	Name             string            `yaml:"name" json:"name"`
+	Run              string            `yaml:"run" json:"run"`
+	WorkingDirectory string            `yaml:"working-directory" json:"working_directory,omitempty"`
+	Env              map[string]string `yaml:"env" json:"env,omitempty"`
+}
+
+// Job is a list of steps which are executed on one runner
+type Job struct {
+	ID     string            `yaml:"-" json:"id"`
+	Name   string            `yaml:"name" json:"name"`
+	RunsOn Labels            `yaml:"runs-on" json:"runs_on"`
+	Env    map[string]string `yaml:"env" json:"env,omitempty"`
+	Steps  []*Step           `yaml:"steps" json:"steps"`
+}
+
+// Workflow is a parsed workflow file
+type Workflow struct {
+	Name   string
+	Events map[Event]*EventFilter
+	Jobs   []*Job
+}
+
+type rawWorkflow struct {
+	Name string            `yaml:"name"`
+	On   interface{}       `yaml:"on"`
+	Env  map[string]string `yaml:"env"`
+	Jobs yaml.MapSlice     `yaml:"jobs"`
+}
+
+// ParseWorkflow parses the content of a workflow file
+func ParseWorkflow(content []byte) (*Workflow, error) {
+	var raw rawWorkflow
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	events, err := parseEvents(raw.On)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Workflow{
+		Name:   raw.Name,
+		Events: events,
+		Jobs:   make([]*Job, 0, len(raw.Jobs)),
+	}
+
+	for _, item := range raw.Jobs {
+		id, ok := item.Key.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid job id: %v", item.Key)
+		}
+		data, err := yaml.Marshal(item.Value)
+		if err != nil {
+			return nil, err
+		}
+		job := &Job{}
+		if err := yaml.UnmarshalStrict(data, job); err != nil {
+			return nil, fmt.Errorf("job %s: %v", id, err)
+		}
+		job.ID = id
+		if job.Name == "" {
+			job.Name = id
+		}
+		// variables of the workflow are inherited by all jobs
+		for k, v := range raw.Env {
+			if _, ok := job.Env[k]; !ok {
+				if job.Env == nil {
+					job.Env = make(map[string]string, len(raw.Env))
+				}
+				job.Env[k] = v
+			}
+		}
+		if len(job.RunsOn) == 0 {
+			return nil, fmt.Errorf("job %s: runs-on is required", id)
+		}
+		if len(job.Steps) == 0 {
+			return nil, fmt.Errorf("job %s: no steps", id)
+		}
+		for i, step := range job.Steps {
+			if step == nil || strings.TrimSpace(step.Run) == "" {
+				return nil, fmt.Errorf("job %s: step %d has no command", id, i+1)
+			}
+		}
+		w.Jobs = append(w.Jobs, job)
+	}
+	if len(w.Jobs) == 0 {
+		return nil, ErrNoJobs
+	}
+	return w, nil
+}
+
+// parseEvents parses the three possible forms of the "on" key:
+// a single event, a list of events or a map of events with filters
+func parseEvents(on interface{}) (map[Event]*EventFilter, error) {
+	events := make(map[Event]*EventFilter)
+	switch v := on.(type) {
+	case string:
+		events[Event(v)] = &EventFilter{}
+	case []interface{}:
+		for _, e := range v {
+			name, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid event: %v", e)
+			}
+			events[Event(name)] = &EventFilter{}
+		}
+	case map[interface{}]interface{}:
+		for k, f := range v {
+			name, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid event: %v", k)
+			}
+			filter := &EventFilter{}
+			if f != nil {
+				data, err := yaml.Marshal(f)
+				if err != nil {
+					return nil, err
+				}
+				if err := yaml.UnmarshalStrict(data, filter); err != nil {
+					return nil, fmt.Errorf("event %s: %v", name, err)
+				}
+			}
+			events[Event(name)] = filter
+		}
+	}
+
+	for e := range events {
+		if e != EventPush && e != EventPullRequest && e != EventMergeGroup {
+			delete(events, e)
+		}
+	}
+	if len(events) == 0 {
+		return nil, ErrNoEvents
+	}
+	return events, nil
+}
+
+// Matches tests if the workflow is triggered by the event. For push events ref is the full
+// name of the pushed ref, for pull requests and merge groups it is the name of the base branch.
+func (w *Workflow) Matches(event Event, ref string) bool {
+	filter, ok := w.Events[event]
+	if !ok {
+		return false
+	}
+
+	switch event {
+	case EventPush:
+		if strings.HasPrefix(ref, git.TagPrefix) {
+			if len(filter.Tags) == 0 {
+				return len(filter.Branches) == 0
+			}
+			return matchesAny(filter.Tags, strings.TrimPrefix(ref, git.TagPrefix))
+		}
+		if len(filter.Branches) == 0 {
+			return len(filter.Tags) == 0
+		}
+		return matchesAny(filter.Branches, strings.TrimPrefix(ref, git.BranchPrefix))
+	case EventPullRequest, EventMergeGroup:
+		return len(filter.Branches) == 0 || matchesAny(filter.Branches, ref)
+	}
+	return false
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			continue
+		}
+		if g.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsWorkflowFile tests if the file name has a workflow extension
+func IsWorkflowFile(name string) bool {
+	ext := strings.ToLower(path.Ext(name))
+	return ext == ".yml" || ext == ".yaml"
+}
+
+// ListWorkflows returns the workflow files of the commit
+func ListWorkflows(commit *git.Commit) (git.Entries, error) {
+	tree, err := commit.SubTree(WorkflowsDir)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	entries, err := tree.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	workflows := make(git.Entries, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsRegular() && IsWorkflowFile(entry.Name()) {
+			workflows = append(workflows, entry)
+		}
+	}
+	return workflows, nil
+}
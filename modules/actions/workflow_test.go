@@ -0,0 +1,137 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWorkflow(t *testing.T) {
+	w, err := ParseWorkflow([]byte(`name: CI
+on:
+  push:
+    branches: [master, release/*]
+  pull_request:
+env:
+  GLOBAL: a
+jobs:
+  test:
+    runs-on: linux
+    env:
+      GLOBAL: b
+    steps:
+      - name: Test
+        run: make test
+  lint:
+    name: Linting
+    runs-on: [linux, amd64]
+    steps:
+      - run: make lint
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, "CI", w.Name)
+	assert.Len(t, w.Events, 2)
+	assert.Equal(t, []string{"master", "release/*"}, w.Events[EventPush].Branches)
+	assert.Len(t, w.Jobs, 2)
+
+	assert.Equal(t, "test", w.Jobs[0].ID)
+	assert.Equal(t, "test", w.Jobs[0].Name)
+	assert.Equal(t, Labels{"linux"}, w.Jobs[0].RunsOn)
+	assert.Equal(t, "b", w.Jobs[0].Env["GLOBAL"])
+	assert.Len(t, w.Jobs[0].Steps, 1)
+	assert.Equal(t, "Test", w.Jobs[0].Steps[0].Name)
+	assert.Equal(t, "make test", w.Jobs[0].Steps[0].Run)
+
+	assert.Equal(t, "lint", w.Jobs[1].ID)
+	assert.Equal(t, "Linting", w.Jobs[1].Name)
+	assert.Equal(t, Labels{"linux", "amd64"}, w.Jobs[1].RunsOn)
+	assert.Equal(t, "a", w.Jobs[1].Env["GLOBAL"])
+
+	w, err = ParseWorkflow([]byte("on: [push, issues]\njobs:\n  a:\n    runs-on: x\n    steps:\n      - run: echo"))
+	assert.NoError(t, err)
+	assert.Len(t, w.Events, 1)
+	assert.Contains(t, w.Events, EventPush)
+
+	_, err = ParseWorkflow([]byte("on: issues\njobs:\n  a:\n    runs-on: x\n    steps:\n      - run: echo"))
+	assert.Equal(t, ErrNoEvents, err)
+
+	_, err = ParseWorkflow([]byte("on: push\njobs: {}"))
+	assert.Equal(t, ErrNoJobs, err)
+
+	_, err = ParseWorkflow([]byte("on: push\njobs:\n  a:\n    steps:\n      - run: echo"))
+	assert.Error(t, err)
+
+	_, err = ParseWorkflow([]byte("on: push\njobs:\n  a:\n    runs-on: x\n    steps:\n      - name: empty"))
+	assert.Error(t, err)
+
+	_, err = ParseWorkflow([]byte("on: push\njobs:\n  a:\n    runs-on: x\n    unknown: 1\n    steps:\n      - run: echo"))
+	assert.Error(t, err)
+}
+
+func TestWorkflowMatches(t *testing.T) {
+	w, err := ParseWorkflow([]byte(`on:
+  push:
+    branches: [master, release/*]
+  pull_request:
+    branches: [master]
+jobs:
+  a:
+    runs-on: x
+    steps:
+      - run: echo
+`))
+	assert.NoError(t, err)
+
+	assert.True(t, w.Matches(EventPush, "refs/heads/master"))
+	assert.True(t, w.Matches(EventPush, "refs/heads/release/1.0"))
+	assert.False(t, w.Matches(EventPush, "refs/heads/release/1.0/fix"))
+	assert.False(t, w.Matches(EventPush, "refs/heads/feature"))
+	assert.False(t, w.Matches(EventPush, "refs/tags/v1.0"))
+	assert.True(t, w.Matches(EventPullRequest, "master"))
+	assert.False(t, w.Matches(EventPullRequest, "feature"))
+	assert.False(t, w.Matches(EventMergeGroup, "master"))
+
+	w, err = ParseWorkflow([]byte(`on:
+  merge_group:
+    branches: [master]
+jobs:
+  a:
+    runs-on: x
+    steps:
+      - run: echo
+`))
+	assert.NoError(t, err)
+	assert.True(t, w.Matches(EventMergeGroup, "master"))
+	assert.False(t, w.Matches(EventMergeGroup, "feature"))
+	assert.False(t, w.Matches(EventPullRequest, "master"))
+
+	w, err = ParseWorkflow([]byte(`on:
+  push:
+    tags: [v*]
+jobs:
+  a:
+    runs-on: x
+    steps:
+      - run: echo
+`))
+	assert.NoError(t, err)
+	assert.True(t, w.Matches(EventPush, "refs/tags/v1.0"))
+	assert.False(t, w.Matches(EventPush, "refs/heads/master"))
+	assert.False(t, w.Matches(EventPullRequest, "master"))
+
+	w, err = ParseWorkflow([]byte("on: push\njobs:\n  a:\n    runs-on: x\n    steps:\n      - run: echo"))
+	assert.NoError(t, err)
+	assert.True(t, w.Matches(EventPush, "refs/heads/any"))
+	assert.True(t, w.Matches(EventPush, "refs/tags/any"))
+}
+
+func TestIsWorkflowFile(t *testing.T) {
+	assert.True(t, IsWorkflowFile("ci.yml"))
+	assert.True(t, IsWorkflowFile("ci.YAML"))
+	assert.False(t, IsWorkflowFile("ci.json"))
+	assert.False(t, IsWorkflowFile("yml"))
+}
@@ -70,6 +70,10 @@ type MigrateRepoForm struct {
 	Issues       bool   `json:"issues"`
 	PullRequests bool   `json:"pull_requests"`
 	Releases     bool   `json:"releases"`
+	// the git service to migrate issues, pull requests and etc. from, it is
+	// detected from the clone address for github.com and gitlab.com
+	// enum: github,gitlab
+	Service string `json:"service"`
 }
 
 // Validate validates the fields
@@ -118,6 +122,14 @@ type RepoSettingForm struct {
 	Template       bool
 	EnablePrune    bool
 
+	// Push mirror settings
+	PushMirrorID           int64
+	PushMirrorAddress      string
+	PushMirrorUsername     string
+	PushMirrorPassword     string
+	PushMirrorInterval     string
+	PushMirrorSyncOnCommit bool
+
 	// Advanced settings
 	EnableWiki                       bool
 	EnableExternalWiki               bool
@@ -133,6 +145,7 @@ type RepoSettingForm struct {
 	PullsAllowRebase                 bool
 	PullsAllowRebaseMerge            bool
 	PullsAllowSquash                 bool
+	EnableProjects                   bool
 	EnableTimetracker                bool
 	AllowOnlyContributorsToTrackTime bool
 	EnableIssueDependencies          bool
@@ -173,6 +186,8 @@ type ProtectBranchForm struct {
 	ApprovalsWhitelistTeams  string
 	BlockOnRejectedReviews   bool
 	DismissStaleApprovals    bool
+	RequireCodeOwnerReviews  bool
+	EnableMergeQueue         bool
 }
 
 // Validate validates the fields
@@ -232,6 +247,17 @@ func (f *NewWebhookForm) Validate(ctx *macaron.Context, errs binding.Errors) bin
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// ReplayWebhookDeliveriesForm form for delivering the payloads of a time range again
+type ReplayWebhookDeliveriesForm struct {
+	Since string `binding:"Required"`
+	Until string `binding:"Required"`
+}
+
+// Validate validates the fields
+func (f *ReplayWebhookDeliveriesForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // NewGogshookForm form for creating gogs hook
 type NewGogshookForm struct {
 	PayloadURL  string `binding:"Required;ValidUrl"`
@@ -321,14 +347,15 @@ func (f *NewMSTeamsHookForm) Validate(ctx *macaron.Context, errs binding.Errors)
 
 // CreateIssueForm form for creating issue
 type CreateIssueForm struct {
-	Title       string `binding:"Required;MaxSize(255)"`
-	LabelIDs    string `form:"label_ids"`
-	AssigneeIDs string `form:"assignee_ids"`
-	Ref         string `form:"ref"`
-	MilestoneID int64
-	AssigneeID  int64
-	Content     string
-	Files       []string
+	Title        string `binding:"Required;MaxSize(255)"`
+	LabelIDs     string `form:"label_ids"`
+	AssigneeIDs  string `form:"assignee_ids"`
+	Ref          string `form:"ref"`
+	MilestoneID  int64
+	AssigneeID   int64
+	Content      string
+	Files        []string
+	TemplateFile string `form:"template_file"`
 }
 
 // Validate validates the fields
@@ -403,6 +430,41 @@ func (f *CreateMilestoneForm) Validate(ctx *macaron.Context, errs binding.Errors
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// CreateProjectForm form for creating a project
+type CreateProjectForm struct {
+	Title         string `binding:"Required;MaxSize(255)"`
+	Content       string
+	BoardTemplate string
+}
+
+// Validate validates the fields
+func (f *CreateProjectForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// EditProjectBoardForm form for creating or editing a project board
+type EditProjectBoardForm struct {
+	Title     string `binding:"Required;MaxSize(255)"`
+	IsDefault bool
+	IsDone    bool
+}
+
+// Validate validates the fields
+func (f *EditProjectBoardForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// AddProjectIssueForm form for adding an issue to a project board
+type AddProjectIssueForm struct {
+	Index   int64 `binding:"Required"`
+	BoardID int64
+}
+
+// Validate validates the fields
+func (f *AddProjectIssueForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // .____          ___.          .__
 // |    |   _____ \_ |__   ____ |  |
 // |    |   \__  \ | __ \_/ __ \|  |
@@ -445,9 +507,10 @@ func (f *InitializeLabelsForm) Validate(ctx *macaron.Context, errs binding.Error
 type MergePullRequestForm struct {
 	// required: true
 	// enum: merge,rebase,rebase-merge,squash
-	Do                string `binding:"Required;In(merge,rebase,rebase-merge,squash)"`
-	MergeTitleField   string
-	MergeMessageField string
+	Do                     string `binding:"Required;In(merge,rebase,rebase-merge,squash)"`
+	MergeTitleField        string
+	MergeMessageField      string
+	MergeWhenChecksSucceed bool
 }
 
 // Validate validates the fields
@@ -471,6 +534,17 @@ func (f *CodeCommentForm) Validate(ctx *macaron.Context, errs binding.Errors) bi
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// ApplySuggestionsForm for committing suggestions of code comments
+type ApplySuggestionsForm struct {
+	CommentIDs []int64 `form:"comment_ids" binding:"Required"`
+	Message    string
+}
+
+// Validate validates the fields
+func (f *ApplySuggestionsForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // SubmitReviewForm for submitting a finished code review
 type SubmitReviewForm struct {
 	Content  string
@@ -12,7 +12,7 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
-	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/services/audit"
 
 	"gitea.com/macaron/macaron"
 	"gitea.com/macaron/session"
@@ -101,11 +101,11 @@ func (b *Basic) VerifyAuthData(ctx *macaron.Context, sess session.Store) *models
 				return nil
 			}
 		}
-		token.UpdatedUnix = timeutil.TimeStampNow()
-		if err = models.UpdateAccessToken(token); err != nil {
-			log.Error("UpdateAccessToken:  %v", err)
+		if err = models.UpdateAccessTokenLastUsed(token); err != nil {
+			log.Error("UpdateAccessTokenLastUsed:  %v", err)
 		}
-	} else if !models.IsErrAccessTokenNotExist(err) && !models.IsErrAccessTokenEmpty(err) {
+		ctx.Data["ApiTokenScope"] = token.Scope
+	} else if !models.IsErrAccessTokenNotExist(err) && !models.IsErrAccessTokenEmpty(err) && !models.IsErrAccessTokenExpired(err) {
 		log.Error("GetAccessTokenBySha: %v", err)
 	}
 
@@ -115,8 +115,15 @@ func (b *Basic) VerifyAuthData(ctx *macaron.Context, sess session.Store) *models
 			if !models.IsErrUserNotExist(err) {
 				log.Error("UserSignIn: %v", err)
 			}
+			if isUsernameToken {
+				// the username may be an invalid access token, it must not be stored in the audit log
+				audit.Record(models.AuditActionUserLoginFailed, nil, ctx.RemoteAddr(), nil, "Failed basic authentication with an access token")
+			} else {
+				audit.Record(models.AuditActionUserLoginFailed, nil, ctx.RemoteAddr(), uname, "Failed basic authentication: %v", err)
+			}
 			return nil
 		}
+		audit.Record(models.AuditActionUserLogin, u, ctx.RemoteAddr(), u, "Signed in with basic authentication")
 	} else {
 		ctx.Data["IsApiToken"] = true
 	}
@@ -11,7 +11,7 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/log"
-	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/services/audit"
 
 	"gitea.com/macaron/macaron"
 	"gitea.com/macaron/session"
@@ -88,6 +88,8 @@ func (o *OAuth2) userIDFromToken(ctx *macaron.Context) int64 {
 		uid := CheckOAuthAccessToken(tokenSHA)
 		if uid != 0 {
 			ctx.Data["IsApiToken"] = true
+		} else {
+			audit.Record(models.AuditActionUserLoginFailed, nil, ctx.RemoteAddr(), nil, "Failed authentication with an invalid OAuth2 access token")
 		}
 		return uid
 	}
@@ -96,13 +98,14 @@ func (o *OAuth2) userIDFromToken(ctx *macaron.Context) int64 {
 		if models.IsErrAccessTokenNotExist(err) || models.IsErrAccessTokenEmpty(err) {
 			log.Error("GetAccessTokenBySHA: %v", err)
 		}
+		audit.Record(models.AuditActionUserLoginFailed, nil, ctx.RemoteAddr(), nil, "Failed authentication with an invalid access token")
 		return 0
 	}
-	t.UpdatedUnix = timeutil.TimeStampNow()
-	if err = models.UpdateAccessToken(t); err != nil {
-		log.Error("UpdateAccessToken: %v", err)
+	if err = models.UpdateAccessTokenLastUsed(t); err != nil {
+		log.Error("UpdateAccessTokenLastUsed: %v", err)
 	}
 	ctx.Data["IsApiToken"] = true
+	ctx.Data["ApiTokenScope"] = t.Scope
 	return t.UID
 }
 
@@ -0,0 +1,71 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	rules := Parse(`# comment
+
+*       @user1 @org3/team1
+*.js    @user2 # javascript
+/docs/  user5@example.com
+`)
+	if assert.Len(t, rules, 3) {
+		assert.EqualValues(t, "*", rules[0].Pattern)
+		assert.EqualValues(t, []string{"@user1", "@org3/team1"}, rules[0].Owners)
+		assert.EqualValues(t, []string{"@user2"}, rules[1].Owners)
+		assert.EqualValues(t, []string{"user5@example.com"}, rules[2].Owners)
+	}
+
+	assert.EqualValues(t, []string{"@user2"}, Owners(rules, "web_src/js/index.js"))
+	assert.EqualValues(t, []string{"user5@example.com"}, Owners(rules, "docs/content/index.md"))
+	assert.EqualValues(t, []string{"@user1", "@org3/team1"}, Owners(rules, "src/docs/index.md"))
+	assert.Nil(t, Owners(Parse("*.go @user1"), "README.md"))
+
+	// a rule without owners removes the ownership
+	assert.Empty(t, Owners(Parse("* @user1\n/vendor/"), "vendor/modules.txt"))
+}
+
+func TestRuleMatch(t *testing.T) {
+	kases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"*", "README.md", true},
+		{"*", "a/b/c.go", true},
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/web.go", true},
+		{"*.go", "cmd/web.go.tmpl", false},
+		{"apps/", "apps/main.go", true},
+		{"apps/", "src/apps/main.go", true},
+		{"apps/", "apps", false},
+		{"/docs/", "docs/index.md", true},
+		{"/docs/", "src/docs/index.md", false},
+		{"docs/*", "docs/index.md", true},
+		{"docs/*", "docs/content/index.md", false},
+		{"/build/logs", "build/logs/out.log", true},
+		{"/build/logs", "build/logs", true},
+		{"**/logs", "logs/out.log", true},
+		{"**/logs", "a/b/logs/out.log", true},
+		{"/src/**/test.go", "src/test.go", true},
+		{"/src/**/test.go", "src/a/b/test.go", true},
+		{"/src/**", "src/a/b/c.go", true},
+		{"/src/**", "lib/a.go", false},
+		{"READ?E.md", "README.md", true},
+		{"models/", "/models/repo.go", true},
+	}
+	for _, kase := range kases {
+		rules := Parse(kase.pattern + " @user1")
+		if assert.Len(t, rules, 1, kase.pattern) {
+			assert.Equal(t, kase.match, rules[0].Match(kase.path), "%s should match %s: %v", kase.pattern, kase.path, kase.match)
+		}
+	}
+}
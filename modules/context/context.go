@@ -43,13 +43,33 @@ type Context struct {
 	IsSigned    bool
 	IsBasicAuth bool
 
-	Repo *Repository
-	Org  *Organization
+	Repo    *Repository
+	Org     *Organization
+	Package *Package
 }
 
 // IsUserSiteAdmin returns true if current user is a site admin
+// and the access token of the request, if any, has the admin scope
 func (ctx *Context) IsUserSiteAdmin() bool {
-	return ctx.IsSigned && ctx.User.IsAdmin
+	return ctx.IsSigned && ctx.User.IsAdmin && ctx.TokenHasScope(models.AccessTokenScopeAdmin)
+}
+
+// PermissionDoer returns the signed in user whose permissions are checked, a site admin
+// only has the rights of a normal user if the access token of the request has no admin scope
+func (ctx *Context) PermissionDoer() *models.User {
+	if ctx.User == nil || !ctx.User.IsAdmin || ctx.IsUserSiteAdmin() {
+		return ctx.User
+	}
+	nonAdmin := *ctx.User
+	nonAdmin.IsAdmin = false
+	return &nonAdmin
+}
+
+// TokenHasScope returns true if the request is not authenticated by an access token
+// or the access token has the scope
+func (ctx *Context) TokenHasScope(scope models.AccessTokenScope) bool {
+	tokenScope, ok := ctx.Data["ApiTokenScope"].(models.AccessTokenScope)
+	return !ok || tokenScope.Has(scope)
 }
 
 // IsUserRepoOwner returns true if current user owns current repo
@@ -340,6 +360,7 @@ func Contexter() macaron.Handler {
 
 		ctx.Data["EnableSwagger"] = setting.API.EnableSwagger
 		ctx.Data["EnableOpenIDSignIn"] = setting.Service.EnableOpenIDSignIn
+		ctx.Data["EnableActions"] = setting.Actions.Enabled
 
 		c.Map(ctx)
 	}
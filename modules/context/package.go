@@ -0,0 +1,83 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"gitea.com/macaron/macaron"
+)
+
+// Package contains the owner and the access mode of the package registry request
+type Package struct {
+	Owner      *models.User
+	AccessMode models.AccessMode
+}
+
+// PackageAssignment loads the package owner of the ":username" parameter
+// and determines the access mode of the current user
+func PackageAssignment() macaron.Handler {
+	return func(ctx *Context) {
+		owner, err := models.GetUserByName(ctx.Params(":username"))
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				ctx.Error(http.StatusNotFound, "Not found")
+			} else {
+				ctx.Error(http.StatusInternalServerError, err.Error())
+			}
+			return
+		}
+		AssignPackageOwner(ctx, owner)
+	}
+}
+
+// AssignPackageOwner determines the access mode of the current user on the packages of the owner
+func AssignPackageOwner(ctx *Context, owner *models.User) {
+	ctx.Package = &Package{
+		Owner: owner,
+	}
+
+	if !VerifyPackageSignIn(ctx) {
+		return
+	}
+
+	if !ctx.IsSigned && setting.Service.RequireSignInView {
+		return
+	}
+
+	var err error
+	ctx.Package.AccessMode, err = models.GetPackageAccessMode(owner, ctx.PermissionDoer())
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, err.Error())
+		return
+	}
+}
+
+// VerifyPackageSignIn rejects a sign in with password by a user with two factor authentication
+// and access tokens without the package scope.
+// Package clients can't send a passcode, so these users have to use an access token instead.
+// It returns false if the request has been answered.
+func VerifyPackageSignIn(ctx *Context) bool {
+	if !ctx.TokenHasScope(models.AccessTokenScopePackage) {
+		ctx.Error(http.StatusForbidden, "The access token does not have the package scope")
+		return false
+	}
+	if !ctx.IsSigned || !ctx.IsBasicAuth || ctx.Data["IsApiToken"] == true {
+		return true
+	}
+
+	_, err := models.GetTwoFactorByUID(ctx.User.ID)
+	if err == nil {
+		ctx.Error(http.StatusUnauthorized, "Users with two factor authentication have to use an access token")
+		return false
+	} else if !models.IsErrTwoFactorNotEnrolled(err) {
+		ctx.Error(http.StatusInternalServerError, err.Error())
+		return false
+	}
+	return true
+}
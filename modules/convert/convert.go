@@ -50,6 +50,7 @@ func ToBranch(repo *models.Repository, b *git.Branch, c *git.Commit, bp *models.
 		RequiredApprovals:   bp.RequiredApprovals,
 		EnableStatusCheck:   bp.EnableStatusCheck,
 		StatusCheckContexts: bp.StatusCheckContexts,
+		EnableMergeQueue:    bp.EnableMergeQueue,
 		UserCanPush:         bp.CanUserPush(user.ID),
 		UserCanMerge:        bp.CanUserMerge(user.ID),
 	}
@@ -204,6 +205,30 @@ func ToHook(repoLink string, w *models.Webhook) *api.Hook {
 	}
 }
 
+// ToHookDelivery convert models.HookTask to api.HookDelivery
+func ToHookDelivery(t *models.HookTask) *api.HookDelivery {
+	d := &api.HookDelivery{
+		ID:        t.ID,
+		UUID:      t.UUID,
+		Event:     string(t.EventType),
+		URL:       t.URL,
+		Delivered: t.IsDelivered,
+		Succeeded: t.IsSucceed,
+		Attempts:  t.Attempts,
+	}
+	if t.ResponseInfo != nil {
+		d.StatusCode = t.ResponseInfo.Status
+	}
+	if t.Delivered > 0 {
+		lastAttempt := time.Unix(0, t.Delivered)
+		d.LastAttempt = &lastAttempt
+	}
+	if !t.IsDelivered {
+		d.NextAttempt = t.NextAttemptUnix.AsTimePtr()
+	}
+	return d
+}
+
 // ToGitHook convert git.Hook to api.GitHook
 func ToGitHook(h *git.Hook) *api.GitHook {
 	return &api.GitHook{
@@ -330,3 +355,59 @@ func ToTopicResponse(topic *models.Topic) *api.TopicResponse {
 		Updated:   topic.UpdatedUnix.AsTime(),
 	}
 }
+
+// ToPushMirror convert a models.PushMirror with its address to api.PushMirror
+func ToPushMirror(m *models.PushMirror, remoteAddress string) *api.PushMirror {
+	apiMirror := &api.PushMirror{
+		ID:            m.ID,
+		RemoteAddress: remoteAddress,
+		SyncOnCommit:  m.SyncOnCommit,
+		Created:       m.CreatedUnix.AsTime(),
+		LastError:     m.LastError,
+	}
+	if m.Interval != 0 {
+		apiMirror.Interval = m.Interval.String()
+	}
+	if m.LastUpdateUnix != 0 {
+		lastUpdate := m.LastUpdateUnix.AsTime()
+		apiMirror.LastUpdate = &lastUpdate
+	}
+	return apiMirror
+}
+
+// ToAccessToken convert models.AccessToken to api.AccessToken, the token value itself is not included
+func ToAccessToken(t *models.AccessToken) *api.AccessToken {
+	apiToken := &api.AccessToken{
+		ID:             t.ID,
+		Name:           t.Name,
+		TokenLastEight: t.TokenLastEight,
+	}
+	for _, scope := range t.Scope.List() {
+		apiToken.Scopes = append(apiToken.Scopes, string(scope))
+	}
+	if t.ExpiresUnix != 0 {
+		expiresAt := t.ExpiresUnix.AsTime()
+		apiToken.ExpiresAt = &expiresAt
+	}
+	if t.LastUsedUnix != 0 {
+		lastUsedAt := t.LastUsedUnix.AsTime()
+		apiToken.LastUsedAt = &lastUsedAt
+	}
+	return apiToken
+}
+
+// ToAuditEvent convert models.AuditEvent to api.AuditEvent
+func ToAuditEvent(e *models.AuditEvent) *api.AuditEvent {
+	return &api.AuditEvent{
+		ID:          e.ID,
+		Action:      string(e.Action),
+		ActorID:     e.ActorID,
+		ActorName:   e.ActorName,
+		IPAddress:   e.IPAddress,
+		TargetType:  string(e.TargetType),
+		TargetID:    e.TargetID,
+		TargetName:  e.TargetName,
+		Description: e.Description,
+		Created:     e.CreatedUnix.AsTime(),
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/sync"
 	mirror_service "code.gitea.io/gitea/services/mirror"
+	packages_service "code.gitea.io/gitea/services/packages"
 
 	"github.com/gogs/cron"
 )
@@ -28,6 +29,9 @@ const (
 	syncExternalUsers       = "sync_external_users"
 	deletedBranchesCleanup  = "deleted_branches_cleanup"
 	updateMigrationPosterID = "update_migration_post_id"
+	cleanupPackages         = "cleanup_packages"
+	rotateOAuth2SigningKeys = "rotate_oauth2_signing_keys"
+	auditLogCleanup         = "audit_log_cleanup"
 )
 
 var c = cron.New()
@@ -124,6 +128,42 @@ func NewContext() {
 		}
 	}
 
+	if setting.Packages.Enabled && setting.Cron.CleanupPackages.Enabled {
+		entry, err = c.AddFunc("Clean up packages", setting.Cron.CleanupPackages.Schedule, WithUnique(cleanupPackages, packages_service.Cleanup))
+		if err != nil {
+			log.Fatal("Cron[Clean up packages]: %v", err)
+		}
+		if setting.Cron.CleanupPackages.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(cleanupPackages, packages_service.Cleanup)()
+		}
+	}
+
+	if setting.OAuth2.Enable && setting.Cron.RotateOAuth2SigningKeys.Enabled {
+		entry, err = c.AddFunc("Rotate OAuth2 signing keys", setting.Cron.RotateOAuth2SigningKeys.Schedule, WithUnique(rotateOAuth2SigningKeys, models.RotateOAuth2SigningKeys))
+		if err != nil {
+			log.Fatal("Cron[Rotate OAuth2 signing keys]: %v", err)
+		}
+		if setting.Cron.RotateOAuth2SigningKeys.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(rotateOAuth2SigningKeys, models.RotateOAuth2SigningKeys)()
+		}
+	}
+
+	if setting.Cron.AuditLogCleanup.Enabled {
+		entry, err = c.AddFunc("Clean up old audit log events", setting.Cron.AuditLogCleanup.Schedule, WithUnique(auditLogCleanup, models.DeleteOldAuditEvents))
+		if err != nil {
+			log.Fatal("Cron[Clean up old audit log events]: %v", err)
+		}
+		if setting.Cron.AuditLogCleanup.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go WithUnique(auditLogCleanup, models.DeleteOldAuditEvents)()
+		}
+	}
+
 	entry, err = c.AddFunc("Update migrated repositories' issues and comments' posterid", setting.Cron.UpdateMigrationPosterID.Schedule, WithUnique(updateMigrationPosterID, migrations.UpdateMigrationPosterID))
 	if err != nil {
 		log.Fatal("Cron[Update migrated repositories]: %v", err)
@@ -6,8 +6,10 @@ package git
 
 import (
 	"fmt"
+	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // ArchiveType archive types
@@ -18,20 +20,94 @@ const (
 	ZIP ArchiveType = iota + 1
 	// TARGZ tar gz archive type
 	TARGZ
+	// TARXZ tar xz archive type, requires xz on the server
+	TARXZ
+	// TARZST tar zst archive type, requires zstd on the server
+	TARZST
 )
 
-// CreateArchive create archive content to the target path
-func (c *Commit) CreateArchive(target string, archiveType ArchiveType) error {
-	var format string
-	switch archiveType {
+// ArchiveTypes contains all supported archive types
+var ArchiveTypes = []ArchiveType{ZIP, TARGZ, TARXZ, TARZST}
+
+// String returns the format of the archive type as understood by git archive,
+// which is also the file extension without the leading dot
+func (a ArchiveType) String() string {
+	switch a {
 	case ZIP:
-		format = "zip"
+		return "zip"
 	case TARGZ:
-		format = "tar.gz"
-	default:
-		return fmt.Errorf("unknown format: %v", archiveType)
+		return "tar.gz"
+	case TARXZ:
+		return "tar.xz"
+	case TARZST:
+		return "tar.zst"
+	}
+	return "unknown"
+}
+
+// ParseArchiveName splits an archive file name like `v1.0.tar.gz` into the name and the archive type
+func ParseArchiveName(name string) (string, ArchiveType, bool) {
+	for _, archiveType := range ArchiveTypes {
+		if ext := "." + archiveType.String(); strings.HasSuffix(name, ext) {
+			return strings.TrimSuffix(name, ext), archiveType, true
+		}
 	}
+	return "", 0, false
+}
+
+// compressCommands contains the filters of the archive formats git does not compress itself
+var compressCommands = map[ArchiveType]string{
+	TARXZ:  "xz -c",
+	TARZST: "zstd -c -q",
+}
 
-	_, err := NewCommand("archive", "--prefix="+filepath.Base(strings.TrimSuffix(c.repo.Path, ".git"))+"/", "--format="+format, "-o", target, c.ID.String()).RunInDir(c.repo.Path)
+var (
+	supportedArchiveTypes     []ArchiveType
+	supportedArchiveTypesOnce sync.Once
+)
+
+// SupportedArchiveTypes returns the archive types whose compressor is installed on the server
+func SupportedArchiveTypes() []ArchiveType {
+	supportedArchiveTypesOnce.Do(func() {
+		for _, archiveType := range ArchiveTypes {
+			if command, ok := compressCommands[archiveType]; ok {
+				if _, err := exec.LookPath(strings.Fields(command)[0]); err != nil {
+					continue
+				}
+			}
+			supportedArchiveTypes = append(supportedArchiveTypes, archiveType)
+		}
+	})
+	return supportedArchiveTypes
+}
+
+// IsSupported returns whether archives of this type can be created on the server
+func (a ArchiveType) IsSupported() bool {
+	for _, archiveType := range SupportedArchiveTypes() {
+		if archiveType == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Label returns the name of the archive type shown to users
+func (a ArchiveType) Label() string {
+	return strings.ToUpper(a.String())
+}
+
+// CreateArchive create archive content to the target path
+func (c *Commit) CreateArchive(target string, archiveType ArchiveType) error {
+	if !archiveType.IsSupported() {
+		return fmt.Errorf("unsupported format: %v", archiveType)
+	}
+	format := archiveType.String()
+
+	args := make([]string, 0, 8)
+	if command, ok := compressCommands[archiveType]; ok {
+		args = append(args, "-c", "tar."+format+".command="+command)
+	}
+	args = append(args, "archive", "--prefix="+filepath.Base(strings.TrimSuffix(c.repo.Path, ".git"))+"/", "--format="+format, "-o", target, c.ID.String())
+	_, err := NewCommand(args...).RunInDir(c.repo.Path)
 	return err
 }
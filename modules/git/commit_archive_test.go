@@ -0,0 +1,60 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseArchiveName(t *testing.T) {
+	kases := []struct {
+		name        string
+		refName     string
+		archiveType ArchiveType
+	}{
+		{"master.zip", "master", ZIP},
+		{"v1.0.tar.gz", "v1.0", TARGZ},
+		{"release/1.x.tar.xz", "release/1.x", TARXZ},
+		{"8006ff9a.tar.zst", "8006ff9a", TARZST},
+	}
+	for _, kase := range kases {
+		refName, archiveType, ok := ParseArchiveName(kase.name)
+		assert.True(t, ok, kase.name)
+		assert.Equal(t, kase.refName, refName, kase.name)
+		assert.Equal(t, kase.archiveType, archiveType, kase.name)
+	}
+
+	_, _, ok := ParseArchiveName("master.tar.bz2")
+	assert.False(t, ok)
+}
+
+func TestCommit_CreateArchive(t *testing.T) {
+	bareRepo1Path := filepath.Join(testReposDir, "repo1_bare")
+	repo, err := OpenRepository(bareRepo1Path)
+	assert.NoError(t, err)
+	defer repo.Close()
+
+	commit, err := repo.GetCommit("8006ff9adbf0cb94da7dad9e537e53817f9fa5c0")
+	assert.NoError(t, err)
+
+	tmpDir, err := ioutil.TempDir("", "archive")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	// the compressors are not necessarily installed
+	for _, archiveType := range SupportedArchiveTypes() {
+		target := filepath.Join(tmpDir, "repo1."+archiveType.String())
+		assert.NoError(t, commit.CreateArchive(target, archiveType), archiveType.String())
+		info, err := os.Stat(target)
+		if assert.NoError(t, err) {
+			assert.True(t, info.Size() > 0)
+		}
+	}
+}
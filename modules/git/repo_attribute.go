@@ -7,6 +7,7 @@ package git
 import (
 	"bytes"
 	"fmt"
+	"os"
 
 	"github.com/mcuadros/go-version"
 )
@@ -17,6 +18,8 @@ type CheckAttributeOpts struct {
 	AllAttributes bool
 	Attributes    []string
 	Filenames     []string
+	// IndexFile is read instead of the index of the repository, see ReadTreeToTemporaryIndex
+	IndexFile string
 }
 
 // CheckAttribute return the Blame object of file
@@ -41,9 +44,13 @@ func (repo *Repository) CheckAttribute(opts CheckAttributeOpts) (map[string]map[
 		}
 	}
 
+	var env []string
 	// git check-attr --cached first appears in git 1.7.8
-	if opts.CachedOnly && version.Compare(binVersion, "1.7.8", ">=") {
+	if (opts.CachedOnly || len(opts.IndexFile) > 0) && version.Compare(binVersion, "1.7.8", ">=") {
 		cmdArgs = append(cmdArgs, "--cached")
+		if len(opts.IndexFile) > 0 {
+			env = append(os.Environ(), "GIT_INDEX_FILE="+opts.IndexFile)
+		}
 	}
 
 	cmdArgs = append(cmdArgs, "--")
@@ -56,7 +63,7 @@ func (repo *Repository) CheckAttribute(opts CheckAttributeOpts) (map[string]map[
 
 	cmd := NewCommand(cmdArgs...)
 
-	if err := cmd.RunInDirPipeline(repo.Path, stdOut, stdErr); err != nil {
+	if err := cmd.RunInDirTimeoutEnvPipeline(env, -1, repo.Path, stdOut, stdErr); err != nil {
 		return nil, fmt.Errorf("Failed to run check-attr: %v\n%s\n%s", err, stdOut.String(), stdErr.String())
 	}
 
@@ -82,3 +89,32 @@ func (repo *Repository) CheckAttribute(opts CheckAttributeOpts) (map[string]map[
 
 	return name2attribute2info, nil
 }
+
+// LinguistLanguages returns the languages that the linguist-language attribute in the
+// .gitattributes of a commit sets for the given files, files without one are left out.
+func (repo *Repository) LinguistLanguages(commitID string, filenames ...string) (map[string]string, error) {
+	indexFile, cancel, err := repo.ReadTreeToTemporaryIndex(commitID)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	name2attribute2info, err := repo.CheckAttribute(CheckAttributeOpts{
+		Attributes: []string{"linguist-language"},
+		Filenames:  filenames,
+		IndexFile:  indexFile,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	languages := make(map[string]string, len(name2attribute2info))
+	for name, attribute2info := range name2attribute2info {
+		switch language := attribute2info["linguist-language"]; language {
+		case "", "unspecified", "set", "unset":
+		default:
+			languages[name] = language
+		}
+	}
+	return languages, nil
+}
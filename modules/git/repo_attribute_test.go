@@ -0,0 +1,54 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepository_LinguistLanguages(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "linguist")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	files := map[string]string{
+		".gitattributes":        "*.tmpl linguist-language=Go\nsub/*.txt linguist-language=Python\n",
+		"main.tmpl":             "package main\n",
+		"README.txt":            "readme\n",
+		"sub/script.txt":        "print(1)\n",
+		"sub/.gitattributes":    "other.txt -linguist-language\n",
+		"sub/other.txt":         "other\n",
+		"sub/nested/script.txt": "print(2)\n",
+	}
+	for name, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(tmpDir, name)), os.ModePerm))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644))
+	}
+	_, err = NewCommand("init").RunInDir(tmpDir)
+	assert.NoError(t, err)
+	_, err = NewCommand("add", "--all").RunInDir(tmpDir)
+	assert.NoError(t, err)
+	_, err = NewCommand("-c", "user.name=Gitea", "-c", "user.email=gitea@example.com", "commit", "-m", "init").RunInDir(tmpDir)
+	assert.NoError(t, err)
+
+	repo, err := OpenRepository(tmpDir)
+	assert.NoError(t, err)
+	defer repo.Close()
+
+	// the attributes of the commit are used and not the ones of the working tree
+	assert.NoError(t, os.Remove(filepath.Join(tmpDir, ".gitattributes")))
+
+	languages, err := repo.LinguistLanguages("HEAD", "main.tmpl", "README.txt", "sub/script.txt", "sub/other.txt", "sub/nested/script.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"main.tmpl":      "Go",
+		"sub/script.txt": "Python",
+	}, languages)
+}
@@ -93,6 +93,21 @@ func (repo *Repository) GetCompareInfo(basePath, baseBranch, headBranch string)
 	return compareInfo, nil
 }
 
+// GetFilesChangedBetween returns the paths of the files changed by head since its merge base with base
+func (repo *Repository) GetFilesChangedBetween(base, head string) ([]string, error) {
+	stdout, err := NewCommand("diff", "--name-only", "-z", base+"..."+head).RunInDirBytes(repo.Path)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, 10)
+	for _, file := range strings.Split(string(stdout), "\x00") {
+		if len(file) > 0 {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
 // GetDiffOrPatch generates either diff or formatted patch data between given revisions
 func (repo *Repository) GetDiffOrPatch(base, head string, w io.Writer, formatted bool) error {
 	if formatted {
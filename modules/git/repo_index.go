@@ -6,11 +6,15 @@ package git
 
 import (
 	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 )
 
 // ReadTreeToIndex reads a treeish to the index
-func (repo *Repository) ReadTreeToIndex(treeish string) error {
+func (repo *Repository) ReadTreeToIndex(treeish string, indexFilename ...string) error {
 	if len(treeish) != 40 {
 		res, err := NewCommand("rev-parse", "--verify", treeish).RunInDir(repo.Path)
 		if err != nil {
@@ -24,17 +28,42 @@ func (repo *Repository) ReadTreeToIndex(treeish string) error {
 	if err != nil {
 		return err
 	}
-	return repo.readTreeToIndex(id)
+	return repo.readTreeToIndex(id, indexFilename...)
 }
 
-func (repo *Repository) readTreeToIndex(id SHA1) error {
-	_, err := NewCommand("read-tree", id.String()).RunInDir(repo.Path)
+func (repo *Repository) readTreeToIndex(id SHA1, indexFilename ...string) error {
+	var env []string
+	if len(indexFilename) > 0 {
+		env = append(os.Environ(), "GIT_INDEX_FILE="+indexFilename[0])
+	}
+	_, err := NewCommand("read-tree", id.String()).RunInDirWithEnv(repo.Path, env)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// ReadTreeToTemporaryIndex reads a treeish to a temporary index file, which is
+// removed again by calling cancel.
+func (repo *Repository) ReadTreeToTemporaryIndex(treeish string) (filename string, cancel context.CancelFunc, err error) {
+	tmpDir, err := ioutil.TempDir("", "index")
+	if err != nil {
+		return "", nil, err
+	}
+	cancel = func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			log("failed to remove temporary index directory %s: %v", tmpDir, err)
+		}
+	}
+
+	filename = filepath.Join(tmpDir, ".tmp-index")
+	if err := repo.ReadTreeToIndex(treeish, filename); err != nil {
+		cancel()
+		return "", nil, err
+	}
+	return filename, cancel, nil
+}
+
 // EmptyIndex empties the index
 func (repo *Repository) EmptyIndex() error {
 	_, err := NewCommand("read-tree", "--empty").RunInDir(repo.Path)
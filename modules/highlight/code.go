@@ -0,0 +1,208 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package highlight
+
+import (
+	"html"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/lexers"
+)
+
+// maxCachedLexers is the number of file names whose lexer is remembered,
+// diffs highlight every line on its own and matching all the lexers
+// against the file name each time would be expensive.
+const maxCachedLexers = 1000
+
+var (
+	lexerCache   = make(map[string]chroma.Lexer, maxCachedLexers)
+	lexerCacheMu sync.RWMutex
+)
+
+// Token is a piece of highlighted code along with the CSS class its token type is styled with.
+type Token struct {
+	Class string
+	Value string
+}
+
+// lexerByName returns the lexer of a language name, a highlight.mapping value or
+// a linguist language, nil if there is no such language.
+func lexerByName(name string) chroma.Lexer {
+	if len(name) == 0 {
+		return nil
+	}
+	if lexer := lexers.Get(name); lexer != nil {
+		return lexer
+	}
+	// linguist names languages with spaces where chroma uses dashes, e.g. "Protocol Buffer"
+	return lexers.Get(strings.Replace(name, " ", "-", -1))
+}
+
+// matchLexer returns the lexer for fileName, nil if it can't be guessed from the name.
+func matchLexer(fileName string) chroma.Lexer {
+	fileName = path.Base(fileName)
+
+	lexerCacheMu.RLock()
+	lexer, ok := lexerCache[fileName]
+	lexerCacheMu.RUnlock()
+	if ok {
+		return lexer
+	}
+
+	if name, ok := customMapping[strings.ToLower(path.Ext(fileName))]; ok {
+		lexer = lexerByName(name)
+	}
+	if lexer == nil {
+		lexer = lexers.Match(fileName)
+	}
+
+	lexerCacheMu.Lock()
+	if len(lexerCache) >= maxCachedLexers {
+		lexerCache = make(map[string]chroma.Lexer, maxCachedLexers)
+	}
+	lexerCache[fileName] = lexer
+	lexerCacheMu.Unlock()
+	return lexer
+}
+
+// getLexer returns the lexer to highlight code with. An explicit language, e.g. from a
+// linguist-language attribute, takes precedence over the file name, and the language
+// is only guessed from the content if analyse is set and the file name doesn't tell.
+func getLexer(fileName, language, code string, analyse bool) chroma.Lexer {
+	lexer := lexerByName(language)
+	if lexer == nil {
+		lexer = matchLexer(fileName)
+	}
+	if lexer == nil && analyse {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		return nil
+	}
+	return chroma.Coalesce(lexer)
+}
+
+// tokenClass returns the CSS class chroma uses for a token type, falling back to the
+// class of its category.
+func tokenClass(t chroma.TokenType) string {
+	for t != 0 {
+		if class, ok := chroma.StandardTypes[t]; ok {
+			return class
+		}
+		t = t.Parent()
+	}
+	return chroma.StandardTypes[t]
+}
+
+// TokenClasses returns all the CSS classes highlighted tokens can have, e.g. to
+// let them through the markup sanitizer.
+func TokenClasses() []string {
+	classes := make([]string, 0, len(chroma.StandardTypes))
+	for _, class := range chroma.StandardTypes {
+		if len(class) > 0 {
+			classes = append(classes, class)
+		}
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+func tokenize(lexer chroma.Lexer, code string) []Token {
+	if lexer == nil || int64(len(code)) > setting.Highlight.MaxFileSize {
+		return []Token{{Value: code}}
+	}
+
+	iterator, err := lexer.Tokenise(nil, code)
+	if err != nil {
+		log.Error("Unable to tokenize code with %s lexer: %v", lexer.Config().Name, err)
+		return []Token{{Value: code}}
+	}
+
+	var (
+		tokens = make([]Token, 0, 32)
+		length int
+	)
+	for token := iterator(); token != chroma.EOF; token = iterator() {
+		tokens = append(tokens, Token{Class: tokenClass(token.Type), Value: token.Value})
+		length += len(token.Value)
+	}
+
+	// Some lexers ensure that the code ends with a newline, which must not show up
+	if length == len(code)+1 && len(tokens) > 0 && strings.HasSuffix(tokens[len(tokens)-1].Value, "\n") {
+		last := &tokens[len(tokens)-1]
+		last.Value = last.Value[:len(last.Value)-1]
+		if len(last.Value) == 0 {
+			tokens = tokens[:len(tokens)-1]
+		}
+		length--
+	}
+	if length != len(code) {
+		log.Warn("The %s lexer changed the length of the code from %d to %d", lexer.Config().Name, len(code), length)
+		return []Token{{Value: code}}
+	}
+	return tokens
+}
+
+// Tokens splits a piece of code, such as a single line of a diff, into highlighted tokens.
+// Joining the values of the tokens gives back the code.
+func Tokens(fileName, language, code string) []Token {
+	return tokenize(getLexer(fileName, language, code, false), code)
+}
+
+// WriteToken writes a part of the value of a token as HTML.
+func WriteToken(buf *strings.Builder, class, value string) {
+	if len(class) == 0 {
+		buf.WriteString(html.EscapeString(value))
+		return
+	}
+	buf.WriteString(`<span class="`)
+	buf.WriteString(class)
+	buf.WriteString(`">`)
+	buf.WriteString(html.EscapeString(value))
+	buf.WriteString(`</span>`)
+}
+
+// Code returns a piece of code, such as a single line of a diff, as highlighted HTML.
+func Code(fileName, language, code string) string {
+	var buf strings.Builder
+	for _, token := range Tokens(fileName, language, code) {
+		WriteToken(&buf, token.Class, token.Value)
+	}
+	return buf.String()
+}
+
+// File returns every line of a file as highlighted HTML, without the newlines. Files
+// larger than the configured maximum size are escaped but not highlighted.
+func File(fileName, language string, code []byte) []string {
+	content := string(code)
+	var lexer chroma.Lexer
+	if int64(len(content)) <= setting.Highlight.MaxFileSize {
+		lexer = getLexer(fileName, language, content, true)
+	}
+	tokens := tokenize(lexer, content)
+
+	lines := make([]string, 0, strings.Count(content, "\n")+1)
+	var buf strings.Builder
+	for _, token := range tokens {
+		parts := strings.Split(token.Value, "\n")
+		for i, part := range parts {
+			if i > 0 {
+				lines = append(lines, buf.String())
+				buf.Reset()
+			}
+			if len(part) > 0 {
+				WriteToken(&buf, token.Class, part)
+			}
+		}
+	}
+	return append(lines, buf.String())
+}
@@ -115,6 +115,9 @@ var (
 		".yml":     "yaml",
 		".zsh":     "shell",
 	}
+
+	// Extensions mapped to languages in the configuration.
+	customMapping = map[string]string{}
 )
 
 // NewContext loads highlight map
@@ -122,6 +125,7 @@ func NewContext() {
 	keys := setting.Cfg.Section("highlight.mapping").Keys()
 	for i := range keys {
 		highlightMapping[keys[i].Name()] = keys[i].Value()
+		customMapping[keys[i].Name()] = keys[i].Value()
 	}
 }
 
@@ -0,0 +1,58 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package highlight
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFile(t *testing.T) {
+	code := "package main\n\n/* a comment\n   on two lines */\nvar a = \"<b>\"\n"
+	assert.Equal(t, []string{
+		`<span class="kn">package</span> <span class="nx">main</span>`,
+		``,
+		`<span class="cm">/* a comment</span>`,
+		`<span class="cm">   on two lines */</span>`,
+		`<span class="kd">var</span> <span class="nx">a</span> <span class="p">=</span> <span class="s">&#34;&lt;b&gt;&#34;</span>`,
+		``,
+	}, File("main.go", "", []byte(code)))
+
+	// without a trailing newline
+	assert.Equal(t, []string{`<span class="kn">package</span> <span class="nx">main</span>`}, File("main.go", "", []byte("package main")))
+
+	// the language is guessed from the content if the file name doesn't tell
+	assert.Equal(t, []string{
+		`<span class="cp">#!/bin/bash</span>`,
+		`<span class="nb">echo</span> <span class="nv">$HOME</span>`,
+	}, File("script", "", []byte("#!/bin/bash\necho $HOME")))
+}
+
+func TestFileLanguage(t *testing.T) {
+	assert.Equal(t, []string{`<span class="c1"># foo</span>`}, File("foo.txt", "Python", []byte("# foo")))
+	assert.Equal(t, []string{`<span class="c1"># foo</span>`}, File("foo.txt", "python", []byte("# foo")))
+	assert.Equal(t, []string{`# foo`}, File("foo.txt", "", []byte("# foo")))
+}
+
+func TestFileTooLarge(t *testing.T) {
+	defer func(size int64) {
+		setting.Highlight.MaxFileSize = size
+	}(setting.Highlight.MaxFileSize)
+	setting.Highlight.MaxFileSize = 10
+
+	assert.Equal(t, []string{`package main`, `var a = &#34;&lt;b&gt;&#34;`}, File("main.go", "", []byte("package main\nvar a = \"<b>\"")))
+}
+
+func TestCode(t *testing.T) {
+	assert.Equal(t, `<span class="nx">a</span> <span class="p">&lt;</span> <span class="mi">1</span>`, Code("main.go", "", "a < 1"))
+	assert.Equal(t, `a &lt; 1`, Code("a.unknown", "", "a < 1"))
+
+	customMapping[".tmpl"] = "go"
+	defer delete(customMapping, ".tmpl")
+	assert.Equal(t, `<span class="nx">a</span> <span class="p">&lt;</span> <span class="mi">1</span>`, Code("main.tmpl", "", "a < 1"))
+}
@@ -0,0 +1,246 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"encoding/json"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/indexer/elastic"
+)
+
+const (
+	esRepoIndexerLatestVersion = 1
+
+	// the highlight tags are chosen so they are unlikely to be part of indexed code
+	esHighlightPreTag  = "<gitea-highlight>"
+	esHighlightPostTag = "</gitea-highlight>"
+)
+
+var (
+	_ Indexer = &ElasticSearchIndexer{}
+)
+
+// ElasticSearchIndexer implements Indexer interface
+type ElasticSearchIndexer struct {
+	client      *elastic.Client
+	indexerName string
+}
+
+// NewElasticSearchIndexer creates a new elasticsearch indexer, created is true
+// if the index of the latest version did not exist yet
+func NewElasticSearchIndexer(url, indexerName string) (*ElasticSearchIndexer, bool, error) {
+	client, err := elastic.NewClient(url)
+	if err != nil {
+		return nil, false, err
+	}
+
+	indexer := &ElasticSearchIndexer{
+		client:      client,
+		indexerName: indexerName,
+	}
+	exists, err := indexer.init()
+	return indexer, !exists, err
+}
+
+const (
+	defaultMapping = `{
+		"mappings": {
+			"properties": {
+				"repo_id": {
+					"type": "long",
+					"index": true
+				},
+				"content": {
+					"type": "text",
+					"term_vector": "with_positions_offsets",
+					"index": true
+				}
+			}
+		}
+	}`
+)
+
+func (b *ElasticSearchIndexer) init() (bool, error) {
+	return b.client.InitVersionedIndex(b.indexerName, esRepoIndexerLatestVersion, json.RawMessage(defaultMapping))
+}
+
+// rebuild drops the index of the latest version and creates it again empty
+func (b *ElasticSearchIndexer) rebuild() error {
+	if err := b.client.DeleteIndex(elastic.VersionedIndexName(b.indexerName, esRepoIndexerLatestVersion)); err != nil {
+		return err
+	}
+	_, err := b.init()
+	return err
+}
+
+func (b *ElasticSearchIndexer) addUpdate(update fileUpdate, repo *models.Repository, bulk *elastic.BulkRequest) error {
+	if ok, err := isIndexableSize(update, repo); err != nil {
+		return err
+	} else if !ok {
+		return b.addDelete(update.Filename, repo, bulk)
+	}
+
+	content, ok, err := fileContent(update, repo)
+	if err != nil {
+		return err
+	} else if !ok {
+		return nil
+	}
+
+	if err := bulk.Index(filenameIndexerID(repo.ID, update.Filename), map[string]interface{}{
+		"repo_id": repo.ID,
+		"content": content,
+	}); err != nil {
+		return err
+	}
+	return b.flushIfFull(bulk)
+}
+
+func (b *ElasticSearchIndexer) addDelete(filename string, repo *models.Repository, bulk *elastic.BulkRequest) error {
+	if err := bulk.Delete(filenameIndexerID(repo.ID, filename)); err != nil {
+		return err
+	}
+	return b.flushIfFull(bulk)
+}
+
+func (b *ElasticSearchIndexer) flushIfFull(bulk *elastic.BulkRequest) error {
+	if bulk.Len() < maxBatchSize {
+		return nil
+	}
+	return b.client.Bulk(bulk)
+}
+
+// Index indexes the data
+func (b *ElasticSearchIndexer) Index(repoID int64) error {
+	repo, err := models.GetRepositoryByID(repoID)
+	if err != nil {
+		return err
+	}
+
+	sha, err := getDefaultBranchSha(repo)
+	if err != nil {
+		return err
+	}
+	changes, err := getRepoChanges(repo, sha)
+	if err != nil {
+		return err
+	} else if changes == nil {
+		return nil
+	}
+
+	bulk := b.client.NewBulkRequest(b.indexerName)
+	for _, update := range changes.Updates {
+		if err := b.addUpdate(update, repo, bulk); err != nil {
+			return err
+		}
+	}
+	for _, filename := range changes.RemovedFilenames {
+		if err := b.addDelete(filename, repo, bulk); err != nil {
+			return err
+		}
+	}
+	if err = b.client.Bulk(bulk); err != nil {
+		return err
+	}
+	return repo.UpdateIndexerStatus(sha)
+}
+
+// Delete deletes indexes by ids
+func (b *ElasticSearchIndexer) Delete(repoID int64) error {
+	return b.client.DeleteByQuery(b.indexerName, map[string]interface{}{
+		"term": map[string]interface{}{"repo_id": repoID},
+	})
+}
+
+// highlightIndices returns the start of the first and the end of the last highlighted
+// part of the content, highlighted is the content with highlight tags
+func highlightIndices(highlighted string) (int, int) {
+	var startIndex, endIndex int = -1, -1
+	offset := 0
+	for {
+		i := strings.Index(highlighted, esHighlightPreTag)
+		if i < 0 {
+			break
+		}
+		offset += i
+		if startIndex < 0 {
+			startIndex = offset
+		}
+		highlighted = highlighted[i+len(esHighlightPreTag):]
+
+		i = strings.Index(highlighted, esHighlightPostTag)
+		if i < 0 {
+			break
+		}
+		offset += i
+		endIndex = offset
+		highlighted = highlighted[i+len(esHighlightPostTag):]
+	}
+	return startIndex, endIndex
+}
+
+// Search searches for files in the specified repo.
+// Returns the matching file-paths
+func (b *ElasticSearchIndexer) Search(repoIDs []int64, keyword string, page, pageSize int) (int64, []*SearchResult, error) {
+	query := map[string]interface{}{
+		"must": map[string]interface{}{
+			"match_phrase": map[string]interface{}{"content": keyword},
+		},
+	}
+	if len(repoIDs) > 0 {
+		query["filter"] = map[string]interface{}{
+			"terms": map[string]interface{}{"repo_id": repoIDs},
+		}
+	}
+
+	result, err := b.client.Search(b.indexerName, map[string]interface{}{
+		"query": map[string]interface{}{"bool": query},
+		"sort":  []interface{}{map[string]string{"_score": "desc"}, map[string]string{"repo_id": "asc"}},
+		"from":  (page - 1) * pageSize,
+		"size":  pageSize,
+		"highlight": map[string]interface{}{
+			"pre_tags":  []string{esHighlightPreTag},
+			"post_tags": []string{esHighlightPostTag},
+			"fields": map[string]interface{}{
+				"content": map[string]interface{}{
+					"type":                "fvh",
+					"number_of_fragments": 0,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	searchResults := make([]*SearchResult, len(result.Hits))
+	for i, hit := range result.Hits {
+		var doc struct {
+			RepoID  int64  `json:"repo_id"`
+			Content string `json:"content"`
+		}
+		if err := json.Unmarshal(hit.Source, &doc); err != nil {
+			return 0, nil, err
+		}
+
+		var startIndex, endIndex int = -1, -1
+		if highlighted := hit.Highlight["content"]; len(highlighted) > 0 {
+			startIndex, endIndex = highlightIndices(highlighted[0])
+		}
+		searchResults[i] = &SearchResult{
+			RepoID:     doc.RepoID,
+			StartIndex: startIndex,
+			EndIndex:   endIndex,
+			Filename:   filenameOfIndexerID(hit.ID),
+			Content:    doc.Content,
+		}
+	}
+	return result.Total, searchResults, nil
+}
+
+// Close implements indexer
+func (b *ElasticSearchIndexer) Close() {}
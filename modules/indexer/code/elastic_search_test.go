@@ -0,0 +1,111 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package code
+
+import (
+	"context"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/indexer/internal/elastictest"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticSearchIndexAndSearch(t *testing.T) {
+	models.PrepareTestEnv(t)
+
+	server := elastictest.NewServer()
+	defer server.Close()
+
+	setting.Indexer.RepoIndexerEnabled = true
+	idx, created, err := NewElasticSearchIndexer(server.URL, "gitea_codes")
+	assert.NoError(t, err)
+	assert.True(t, created)
+	defer idx.Close()
+
+	assert.NoError(t, idx.Index(1))
+
+	total, res, err := idx.Search(nil, "Description", 1, 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	if assert.Len(t, res, 1) {
+		assert.EqualValues(t, 1, res[0].RepoID)
+		assert.EqualValues(t, "README.md", res[0].Filename)
+		assert.EqualValues(t, "Description", res[0].Content[res[0].StartIndex:res[0].EndIndex])
+	}
+
+	total, res, err = idx.Search([]int64{1}, "repo1", 1, 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	assert.Len(t, res, 1)
+
+	total, res, err = idx.Search([]int64{2}, "repo1", 1, 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, total)
+	assert.Len(t, res, 0)
+
+	total, _, err = idx.Search(nil, "non-exist", 1, 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, total)
+
+	assert.NoError(t, idx.Delete(1))
+	total, _, err = idx.Search(nil, "Description", 1, 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, total)
+
+	// the index exists already the next time
+	_, created, err = NewElasticSearchIndexer(server.URL, "gitea_codes")
+	assert.NoError(t, err)
+	assert.False(t, created)
+}
+
+func TestElasticSearchRebuildIndexer(t *testing.T) {
+	models.PrepareTestEnv(t)
+
+	server := elastictest.NewServer()
+	defer server.Close()
+
+	oldRepoType := setting.Indexer.RepoType
+	oldConnStr := setting.Indexer.RepoConnStr
+	oldIndexerName := setting.Indexer.RepoIndexerName
+	setting.Indexer.RepoType = "elasticsearch"
+	setting.Indexer.RepoConnStr = server.URL
+	setting.Indexer.RepoIndexerName = "gitea_codes"
+	defer func() {
+		setting.Indexer.RepoType = oldRepoType
+		setting.Indexer.RepoConnStr = oldConnStr
+		setting.Indexer.RepoIndexerName = oldIndexerName
+	}()
+
+	count, err := RebuildIndexer(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, count > 0)
+
+	idx, created, err := NewElasticSearchIndexer(server.URL, "gitea_codes")
+	assert.NoError(t, err)
+	assert.False(t, created)
+	total, res, err := idx.Search([]int64{1}, "repo1", 1, 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	if assert.Len(t, res, 1) {
+		assert.EqualValues(t, "README.md", res[0].Filename)
+	}
+
+	setting.Indexer.RepoType = "bleve"
+	_, err = RebuildIndexer(context.Background())
+	assert.Error(t, err)
+}
+
+func TestHighlightIndices(t *testing.T) {
+	start, end := highlightIndices("foo <gitea-highlight>bar</gitea-highlight> <gitea-highlight>baz</gitea-highlight> qux")
+	assert.EqualValues(t, 4, start)
+	assert.EqualValues(t, 11, end)
+
+	start, end = highlightIndices("nothing highlighted")
+	assert.EqualValues(t, -1, start)
+	assert.EqualValues(t, -1, end)
+}
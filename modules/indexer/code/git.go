@@ -5,10 +5,13 @@
 package code
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/charset"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
@@ -33,6 +36,34 @@ func getDefaultBranchSha(repo *models.Repository) (string, error) {
 	return strings.TrimSpace(stdout), nil
 }
 
+// isIndexableSize returns false if the file is larger than the maximum size of indexed files
+func isIndexableSize(update fileUpdate, repo *models.Repository) (bool, error) {
+	stdout, err := git.NewCommand("cat-file", "-s", update.BlobSha).
+		RunInDir(repo.RepoPath())
+	if err != nil {
+		return false, err
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(stdout), 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("Misformatted git cat-file output: %v", err)
+	}
+	return size <= setting.Indexer.MaxIndexerFileSize, nil
+}
+
+// fileContent returns the content of the file converted to UTF-8, ok is false
+// if the file is not a text file and should not be indexed
+func fileContent(update fileUpdate, repo *models.Repository) (content string, ok bool, err error) {
+	fileContents, err := git.NewCommand("cat-file", "blob", update.BlobSha).
+		RunInDirBytes(repo.RepoPath())
+	if err != nil {
+		return "", false, err
+	} else if !base.IsTextFile(fileContents) {
+		// FIXME: UTF-16 files will probably fail here
+		return "", false, nil
+	}
+	return string(charset.ToUTF8DropErrors(fileContents)), true, nil
+}
+
 // getRepoChanges returns changes to repo since last indexer update
 func getRepoChanges(repo *models.Repository, revision string) (*repoChanges, error) {
 	if err := repo.GetIndexerStatus(); err != nil {
@@ -51,18 +51,36 @@ func Init() {
 	waitChannel := make(chan time.Duration)
 	go func() {
 		start := time.Now()
-		log.Info("PID: %d Initializing Repository Indexer at: %s", os.Getpid(), setting.Indexer.RepoPath)
-		bleveIndexer, created, err := NewBleveIndexer(setting.Indexer.RepoPath)
-		if err != nil {
-			if bleveIndexer != nil {
-				bleveIndexer.Close()
+		var (
+			rIndexer Indexer
+			created  bool
+		)
+		switch setting.Indexer.RepoType {
+		case "elasticsearch":
+			log.Info("PID: %d Initializing Repository Indexer in Elastic Search index: %s", os.Getpid(), setting.Indexer.RepoIndexerName)
+			esIndexer, esCreated, err := NewElasticSearchIndexer(setting.Indexer.RepoConnStr, setting.Indexer.RepoIndexerName)
+			if err != nil {
+				cancel()
+				indexer.Close()
+				close(waitChannel)
+				log.Fatal("PID: %d Unable to initialize the Elastic Search Repository Indexer: %v", os.Getpid(), err)
+			}
+			rIndexer, created = esIndexer, esCreated
+		default:
+			log.Info("PID: %d Initializing Repository Indexer at: %s", os.Getpid(), setting.Indexer.RepoPath)
+			bleveIndexer, bleveCreated, err := NewBleveIndexer(setting.Indexer.RepoPath)
+			if err != nil {
+				if bleveIndexer != nil {
+					bleveIndexer.Close()
+				}
+				cancel()
+				indexer.Close()
+				close(waitChannel)
+				log.Fatal("PID: %d Unable to initialize the Repository Indexer at path: %s Error: %v", os.Getpid(), setting.Indexer.RepoPath, err)
 			}
-			cancel()
-			indexer.Close()
-			close(waitChannel)
-			log.Fatal("PID: %d Unable to initialize the Repository Indexer at path: %s Error: %v", os.Getpid(), setting.Indexer.RepoPath, err)
+			rIndexer, created = bleveIndexer, bleveCreated
 		}
-		indexer.set(bleveIndexer)
+		indexer.set(rIndexer)
 
 		go processRepoIndexerOperationQueue(indexer)
 
@@ -5,6 +5,8 @@
 package code
 
 import (
+	"context"
+	"fmt"
 	"os"
 
 	"code.gitea.io/gitea/models"
@@ -132,3 +134,53 @@ func populateRepoIndexer() {
 	}
 	log.Info("Done (re)populating the repo indexer with existing repositories")
 }
+
+// RebuildIndexer drops the code index and indexes the default branches of all
+// repositories again without going through the queue, it is only supported by
+// the elasticsearch indexer as the bleve index can't be opened while Gitea is running.
+func RebuildIndexer(ctx context.Context) (int, error) {
+	if setting.Indexer.RepoType != "elasticsearch" {
+		return 0, fmt.Errorf("rebuilding is not supported by the %s code indexer", setting.Indexer.RepoType)
+	}
+
+	esIndexer, _, err := NewElasticSearchIndexer(setting.Indexer.RepoConnStr, setting.Indexer.RepoIndexerName)
+	if err != nil {
+		return 0, err
+	}
+	defer esIndexer.Close()
+	if err := esIndexer.rebuild(); err != nil {
+		return 0, err
+	}
+	if err := models.DeleteAllRecords("repo_indexer_status"); err != nil {
+		return 0, err
+	}
+
+	maxRepoID, err := models.GetMaxID("repository")
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for maxRepoID > 0 {
+		ids, err := models.GetUnindexedRepos(maxRepoID, 0, 50)
+		if err != nil {
+			return count, err
+		} else if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			select {
+			case <-ctx.Done():
+				return count, ctx.Err()
+			default:
+			}
+			if err := esIndexer.Index(id); err != nil {
+				log.Error("Unable to index repository %d: %v", id, err)
+			} else {
+				count++
+			}
+			maxRepoID = id - 1
+		}
+	}
+	return count, nil
+}
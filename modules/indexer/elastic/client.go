@@ -0,0 +1,359 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package elastic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error represents an error response of Elasticsearch
+type Error struct {
+	StatusCode int
+	Type       string
+	Reason     string
+}
+
+func (err *Error) Error() string {
+	if err.Type == "" {
+		return fmt.Sprintf("elasticsearch: unexpected status %d", err.StatusCode)
+	}
+	return fmt.Sprintf("elasticsearch: %s: %s", err.Type, err.Reason)
+}
+
+// IsErrNotFound returns true if the error is an Elasticsearch not found error
+func IsErrNotFound(err error) bool {
+	esErr, ok := err.(*Error)
+	return ok && esErr.StatusCode == http.StatusNotFound
+}
+
+// Client is a minimal client of the Elasticsearch REST API
+type Client struct {
+	baseURL  *url.URL
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewClient creates a client for the Elasticsearch server at connStr,
+// credentials can be given as user info of the URL
+func NewClient(connStr string) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(connStr, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("elasticsearch: unsupported URL %q", connStr)
+	}
+
+	c := &Client{
+		client: &http.Client{Timeout: time.Minute},
+	}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+		u.User = nil
+	}
+	c.baseURL = u
+	return c, nil
+}
+
+// do sends a request to the API path, body is encoded as json unless it is a reader,
+// the json response is decoded into result if result is not nil
+func (c *Client) do(method, path string, body, result interface{}) error {
+	var r io.Reader
+	contentType := "application/json"
+	switch b := body.(type) {
+	case nil:
+	case *bytes.Buffer:
+		r = b
+		contentType = "application/x-ndjson"
+	default:
+		bs, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(bs)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL.String()+path, r)
+	if err != nil {
+		return err
+	}
+	if r != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		esErr := &Error{StatusCode: resp.StatusCode}
+		var errResp struct {
+			Error struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		}
+		if bs, err := ioutil.ReadAll(resp.Body); err == nil && json.Unmarshal(bs, &errResp) == nil {
+			esErr.Type, esErr.Reason = errResp.Error.Type, errResp.Error.Reason
+		}
+		return esErr
+	}
+	if result == nil || method == http.MethodHead {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// IndexExists returns true if the index or alias exists
+func (c *Client) IndexExists(index string) (bool, error) {
+	err := c.do(http.MethodHead, "/"+url.PathEscape(index), nil, nil)
+	if IsErrNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// CreateIndex creates an index with the given settings and mappings
+func (c *Client) CreateIndex(index string, definition interface{}) error {
+	return c.do(http.MethodPut, "/"+url.PathEscape(index), definition, nil)
+}
+
+// DeleteIndex deletes an index, deleting a missing index is not an error
+func (c *Client) DeleteIndex(index string) error {
+	err := c.do(http.MethodDelete, "/"+url.PathEscape(index), nil, nil)
+	if IsErrNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// Refresh makes all changes of the index visible to searches
+func (c *Client) Refresh(index string) error {
+	return c.do(http.MethodPost, "/"+url.PathEscape(index)+"/_refresh", nil, nil)
+}
+
+// AliasedIndexes returns the indexes the alias points to
+func (c *Client) AliasedIndexes(alias string) ([]string, error) {
+	var result map[string]interface{}
+	if err := c.do(http.MethodGet, "/_alias/"+url.PathEscape(alias), nil, &result); err != nil {
+		if IsErrNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	indexes := make([]string, 0, len(result))
+	for index := range result {
+		indexes = append(indexes, index)
+	}
+	sort.Strings(indexes)
+	return indexes, nil
+}
+
+// SetAlias points the alias to index only
+func (c *Client) SetAlias(alias, index string) error {
+	oldIndexes, err := c.AliasedIndexes(alias)
+	if err != nil {
+		return err
+	}
+
+	actions := make([]map[string]interface{}, 0, len(oldIndexes)+1)
+	for _, oldIndex := range oldIndexes {
+		if oldIndex != index {
+			actions = append(actions, map[string]interface{}{
+				"remove": map[string]string{"index": oldIndex, "alias": alias},
+			})
+		}
+	}
+	actions = append(actions, map[string]interface{}{
+		"add": map[string]string{"index": index, "alias": alias},
+	})
+	return c.do(http.MethodPost, "/_aliases", map[string]interface{}{"actions": actions}, nil)
+}
+
+// VersionedIndexName returns the name of the index of the given version of an alias
+func VersionedIndexName(alias string, version int) string {
+	return alias + ".v" + strconv.Itoa(version)
+}
+
+// InitVersionedIndex makes sure the index of the given version exists and the alias points to it,
+// indexes of older versions are removed. It returns true if the index existed already.
+func (c *Client) InitVersionedIndex(alias string, version int, definition interface{}) (bool, error) {
+	index := VersionedIndexName(alias, version)
+	exists, err := c.IndexExists(index)
+	if err != nil {
+		return false, err
+	}
+	if !exists {
+		if err := c.CreateIndex(index, definition); err != nil {
+			return false, err
+		}
+	}
+
+	oldIndexes, err := c.AliasedIndexes(alias)
+	if err != nil {
+		return false, err
+	}
+	if len(oldIndexes) != 1 || oldIndexes[0] != index {
+		if err := c.SetAlias(alias, index); err != nil {
+			return false, err
+		}
+	}
+	for _, oldIndex := range oldIndexes {
+		if oldIndex != index {
+			if err := c.DeleteIndex(oldIndex); err != nil {
+				return false, err
+			}
+		}
+	}
+	return exists, nil
+}
+
+// BulkRequest collects index and delete operations sent together to the bulk API
+type BulkRequest struct {
+	index string
+	buf   bytes.Buffer
+	count int
+}
+
+// NewBulkRequest creates a bulk request for the operations on index
+func (c *Client) NewBulkRequest(index string) *BulkRequest {
+	return &BulkRequest{index: index}
+}
+
+func (b *BulkRequest) add(action string, id string, doc interface{}) error {
+	line, err := json.Marshal(map[string]interface{}{
+		action: map[string]string{"_index": b.index, "_id": id},
+	})
+	if err != nil {
+		return err
+	}
+	b.buf.Write(line)
+	b.buf.WriteByte('\n')
+	if doc != nil {
+		if line, err = json.Marshal(doc); err != nil {
+			return err
+		}
+		b.buf.Write(line)
+		b.buf.WriteByte('\n')
+	}
+	b.count++
+	return nil
+}
+
+// Index adds or replaces the document with the id
+func (b *BulkRequest) Index(id string, doc interface{}) error {
+	return b.add("index", id, doc)
+}
+
+// Delete deletes the document with the id
+func (b *BulkRequest) Delete(id string) error {
+	return b.add("delete", id, nil)
+}
+
+// Len returns the number of operations of the request
+func (b *BulkRequest) Len() int {
+	return b.count
+}
+
+// Bulk sends the operations of the bulk request, deleting missing documents is not an error
+func (c *Client) Bulk(b *BulkRequest) error {
+	if b.count == 0 {
+		return nil
+	}
+
+	var result struct {
+		Errors bool                                `json:"errors"`
+		Items  []map[string]map[string]interface{} `json:"items"`
+	}
+	if err := c.do(http.MethodPost, "/_bulk?refresh=true", &b.buf, &result); err != nil {
+		return err
+	}
+	b.buf.Reset()
+	b.count = 0
+	if !result.Errors {
+		return nil
+	}
+
+	for _, item := range result.Items {
+		for action, res := range item {
+			status, _ := res["status"].(float64)
+			if status < 300 || (action == "delete" && status == http.StatusNotFound) {
+				continue
+			}
+			esErr := &Error{StatusCode: int(status)}
+			if e, ok := res["error"].(map[string]interface{}); ok {
+				esErr.Type, _ = e["type"].(string)
+				esErr.Reason, _ = e["reason"].(string)
+			}
+			return esErr
+		}
+	}
+	return nil
+}
+
+// DeleteByQuery deletes all documents of the index matching the query
+func (c *Client) DeleteByQuery(index string, query interface{}) error {
+	return c.do(http.MethodPost, "/"+url.PathEscape(index)+"/_delete_by_query?refresh=true", map[string]interface{}{
+		"query": query,
+	}, nil)
+}
+
+// SearchHit represents a document found by a search
+type SearchHit struct {
+	ID        string              `json:"_id"`
+	Score     float64             `json:"_score"`
+	Source    json.RawMessage     `json:"_source"`
+	Highlight map[string][]string `json:"highlight"`
+}
+
+// SearchResult represents the result of a search
+type SearchResult struct {
+	Total int64
+	Hits  []*SearchHit
+}
+
+// Search searches the documents of the index matching the search request
+func (c *Client) Search(index string, request interface{}) (*SearchResult, error) {
+	var result struct {
+		Hits struct {
+			Total json.RawMessage `json:"total"`
+			Hits  []*SearchHit    `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := c.do(http.MethodPost, "/"+url.PathEscape(index)+"/_search", request, &result); err != nil {
+		return nil, err
+	}
+
+	// the total is a number before Elasticsearch 7 and an object since then
+	var total struct {
+		Value int64 `json:"value"`
+	}
+	if err := json.Unmarshal(result.Hits.Total, &total); err != nil {
+		if err := json.Unmarshal(result.Hits.Total, &total.Value); err != nil {
+			return nil, err
+		}
+	}
+	return &SearchResult{
+		Total: total.Value,
+		Hits:  result.Hits.Hits,
+	}, nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package elastic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/indexer/internal/elastictest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitVersionedIndex(t *testing.T) {
+	server := elastictest.NewServer()
+	defer server.Close()
+
+	testInitVersionedIndex(t, server.URL, "test")
+}
+
+func TestBulkAndSearch(t *testing.T) {
+	server := elastictest.NewServer()
+	defer server.Close()
+
+	testBulkAndSearch(t, server.URL, "test")
+}
+
+// TestRealElasticsearch runs the client tests against the Elasticsearch server of
+// TEST_ELASTICSEARCH_URL, e.g. TEST_ELASTICSEARCH_URL=http://localhost:9200
+func TestRealElasticsearch(t *testing.T) {
+	serverURL := os.Getenv("TEST_ELASTICSEARCH_URL")
+	if serverURL == "" {
+		t.Skip("TEST_ELASTICSEARCH_URL is not set")
+	}
+
+	client, err := NewClient(serverURL)
+	assert.NoError(t, err)
+
+	// every run uses new indexes, they are removed afterwards
+	alias := fmt.Sprintf("gitea_test_%d", time.Now().UnixNano())
+	defer func() {
+		for _, name := range []string{alias + "_versioned", alias + "_search"} {
+			for version := 1; version <= 2; version++ {
+				if err := client.DeleteIndex(VersionedIndexName(name, version)); err != nil && !IsErrNotFound(err) {
+					t.Errorf("DeleteIndex: %v", err)
+				}
+			}
+		}
+	}()
+
+	t.Run("InitVersionedIndex", func(t *testing.T) {
+		testInitVersionedIndex(t, serverURL, alias+"_versioned")
+	})
+	t.Run("BulkAndSearch", func(t *testing.T) {
+		testBulkAndSearch(t, serverURL, alias+"_search")
+	})
+}
+
+func testInitVersionedIndex(t *testing.T, serverURL, alias string) {
+	client, err := NewClient(serverURL)
+	assert.NoError(t, err)
+
+	exists, err := client.InitVersionedIndex(alias, 1, nil)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	indexes, err := client.AliasedIndexes(alias)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{alias + ".v1"}, indexes)
+
+	exists, err = client.InitVersionedIndex(alias, 1, nil)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	// a new version replaces the index of the old version
+	exists, err = client.InitVersionedIndex(alias, 2, nil)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	indexes, err = client.AliasedIndexes(alias)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{alias + ".v2"}, indexes)
+	exists, err = client.IndexExists(alias + ".v1")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func testBulkAndSearch(t *testing.T, serverURL, alias string) {
+	client, err := NewClient(serverURL)
+	assert.NoError(t, err)
+	_, err = client.InitVersionedIndex(alias, 1, nil)
+	assert.NoError(t, err)
+
+	type doc struct {
+		RepoID  int64  `json:"repo_id"`
+		Content string `json:"content"`
+	}
+	bulk := client.NewBulkRequest(alias)
+	assert.NoError(t, bulk.Index("1", doc{1, "Hello World"}))
+	assert.NoError(t, bulk.Index("2", doc{1, "hello gitea"}))
+	assert.NoError(t, bulk.Index("3", doc{2, "world of gitea"}))
+	assert.NoError(t, bulk.Delete("4"))
+	assert.EqualValues(t, 4, bulk.Len())
+	assert.NoError(t, client.Bulk(bulk))
+	assert.EqualValues(t, 0, bulk.Len())
+
+	// search returns the hits by id, the order of hits with different scores is not checked
+	search := func(query map[string]interface{}) map[string]*SearchHit {
+		res, err := client.Search(alias, map[string]interface{}{
+			"query":     query,
+			"highlight": map[string]interface{}{"fields": map[string]interface{}{"content": map[string]interface{}{}}},
+		})
+		assert.NoError(t, err)
+		hits := make(map[string]*SearchHit, len(res.Hits))
+		for _, hit := range res.Hits {
+			hits[hit.ID] = hit
+		}
+		assert.EqualValues(t, len(hits), res.Total)
+		return hits
+	}
+	ids := func(hits map[string]*SearchHit) []string {
+		ids := make([]string, 0, len(hits))
+		for id := range hits {
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	assert.ElementsMatch(t, []string{"1", "2"}, ids(search(map[string]interface{}{
+		"match_phrase": map[string]interface{}{"content": "hello"},
+	})))
+	assert.ElementsMatch(t, []string{"3"}, ids(search(map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must":   map[string]interface{}{"match_phrase": map[string]interface{}{"content": "gitea"}},
+			"filter": map[string]interface{}{"terms": map[string]interface{}{"repo_id": []int64{2}}},
+		},
+	})))
+
+	hits := search(map[string]interface{}{
+		"match_phrase": map[string]interface{}{"content": "world"},
+	})
+	if assert.Len(t, hits, 2) && assert.Contains(t, hits, "1") && assert.Contains(t, hits, "3") {
+		assert.EqualValues(t, []string{"Hello <em>World</em>"}, hits["1"].Highlight["content"])
+		var d doc
+		assert.NoError(t, json.Unmarshal(hits["3"].Source, &d))
+		assert.EqualValues(t, doc{2, "world of gitea"}, d)
+	}
+
+	assert.NoError(t, client.DeleteByQuery(alias, map[string]interface{}{
+		"term": map[string]interface{}{"repo_id": 1},
+	}))
+	assert.ElementsMatch(t, []string{"3"}, ids(search(map[string]interface{}{"match_all": map[string]interface{}{}})))
+}
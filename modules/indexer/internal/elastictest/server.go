@@ -0,0 +1,583 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package elastictest provides an in-memory stand-in for an Elasticsearch server,
+// it must only be used by tests.
+package elastictest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// NewServer starts an in-memory stand-in for an Elasticsearch server.
+// It supports the subset of the API used by the indexers: index and alias management, bulk,
+// delete by query and searches with bool, term, terms, match_phrase and multi_match (phrase)
+// queries on lower cased words, highlighting, sorting by fields and paging.
+func NewServer() *httptest.Server {
+	s := &testServer{
+		indexes: make(map[string]map[string]map[string]interface{}),
+		aliases: make(map[string]string),
+	}
+	return httptest.NewServer(s)
+}
+
+type testServer struct {
+	lock    sync.Mutex
+	indexes map[string]map[string]map[string]interface{}
+	aliases map[string]string
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, typ, reason string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error":  map[string]string{"type": typ, "reason": reason},
+		"status": status,
+	})
+}
+
+func (s *testServer) resolve(name string) string {
+	if index, ok := s.aliases[name]; ok {
+		return index
+	}
+	return name
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "_alias" && req.Method == http.MethodGet:
+		index, ok := s.aliases[parts[1]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "aliases_not_found_exception", "alias ["+parts[1]+"] missing")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			index: map[string]interface{}{"aliases": map[string]interface{}{parts[1]: struct{}{}}},
+		})
+	case len(parts) == 1 && parts[0] == "_aliases" && req.Method == http.MethodPost:
+		s.updateAliases(w, req)
+	case len(parts) == 1 && parts[0] == "_bulk" && req.Method == http.MethodPost:
+		s.bulk(w, req)
+	case len(parts) == 1:
+		s.handleIndex(w, req, parts[0])
+	case len(parts) == 2:
+		docs, ok := s.indexes[s.resolve(parts[0])]
+		if !ok {
+			writeError(w, http.StatusNotFound, "index_not_found_exception", "no such index ["+parts[0]+"]")
+			return
+		}
+		switch parts[1] {
+		case "_refresh":
+			writeJSON(w, http.StatusOK, map[string]interface{}{})
+		case "_search":
+			s.search(w, req, docs)
+		case "_delete_by_query":
+			s.deleteByQuery(w, req, docs)
+		default:
+			writeError(w, http.StatusBadRequest, "illegal_argument_exception", "unsupported API "+parts[1])
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "illegal_argument_exception", "unsupported path "+req.URL.Path)
+	}
+}
+
+func (s *testServer) handleIndex(w http.ResponseWriter, req *http.Request, name string) {
+	_, exists := s.indexes[s.resolve(name)]
+	switch req.Method {
+	case http.MethodHead:
+		if exists {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case http.MethodPut:
+		if exists {
+			writeError(w, http.StatusBadRequest, "resource_already_exists_exception", "index ["+name+"] already exists")
+			return
+		}
+		s.indexes[name] = make(map[string]map[string]interface{})
+		writeJSON(w, http.StatusOK, map[string]interface{}{"acknowledged": true, "index": name})
+	case http.MethodDelete:
+		if _, ok := s.indexes[name]; !ok {
+			writeError(w, http.StatusNotFound, "index_not_found_exception", "no such index ["+name+"]")
+			return
+		}
+		delete(s.indexes, name)
+		for alias, index := range s.aliases {
+			if index == name {
+				delete(s.aliases, alias)
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"acknowledged": true})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "illegal_argument_exception", "unsupported method "+req.Method)
+	}
+}
+
+func (s *testServer) updateAliases(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		Actions []map[string]struct {
+			Index string `json:"index"`
+			Alias string `json:"alias"`
+		} `json:"actions"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "parse_exception", err.Error())
+		return
+	}
+	for _, action := range body.Actions {
+		for typ, a := range action {
+			if _, ok := s.indexes[a.Index]; !ok {
+				writeError(w, http.StatusNotFound, "index_not_found_exception", "no such index ["+a.Index+"]")
+				return
+			}
+			switch typ {
+			case "add":
+				s.aliases[a.Alias] = a.Index
+			case "remove":
+				delete(s.aliases, a.Alias)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"acknowledged": true})
+}
+
+func (s *testServer) bulk(w http.ResponseWriter, req *http.Request) {
+	var items []map[string]interface{}
+	hasErrors := false
+
+	scanner := bufio.NewScanner(req.Body)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var action map[string]struct {
+			Index string `json:"_index"`
+			ID    string `json:"_id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil {
+			writeError(w, http.StatusBadRequest, "parse_exception", err.Error())
+			return
+		}
+		for typ, meta := range action {
+			result := map[string]interface{}{"_index": meta.Index, "_id": meta.ID, "status": http.StatusOK}
+			docs, ok := s.indexes[s.resolve(meta.Index)]
+			switch typ {
+			case "index":
+				if !scanner.Scan() {
+					writeError(w, http.StatusBadRequest, "parse_exception", "missing document")
+					return
+				}
+				var doc map[string]interface{}
+				if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+					writeError(w, http.StatusBadRequest, "parse_exception", err.Error())
+					return
+				}
+				if !ok {
+					docs = make(map[string]map[string]interface{})
+					s.indexes[meta.Index] = docs
+				}
+				docs[meta.ID] = doc
+			case "delete":
+				if _, found := docs[meta.ID]; !found {
+					result["status"] = http.StatusNotFound
+					hasErrors = true
+				}
+				delete(docs, meta.ID)
+			default:
+				writeError(w, http.StatusBadRequest, "illegal_argument_exception", "unsupported bulk action "+typ)
+				return
+			}
+			items = append(items, map[string]interface{}{typ: result})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		writeError(w, http.StatusBadRequest, "parse_exception", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"errors": hasErrors, "items": items})
+}
+
+func (s *testServer) deleteByQuery(w http.ResponseWriter, req *http.Request, docs map[string]map[string]interface{}) {
+	var body struct {
+		Query map[string]interface{} `json:"query"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "parse_exception", err.Error())
+		return
+	}
+	deleted := 0
+	for id, doc := range docs {
+		if matchQuery(body.Query, doc) {
+			delete(docs, id)
+			deleted++
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"deleted": deleted})
+}
+
+type testHit struct {
+	id  string
+	doc map[string]interface{}
+}
+
+func (s *testServer) search(w http.ResponseWriter, req *http.Request, docs map[string]map[string]interface{}) {
+	var body struct {
+		Query     map[string]interface{} `json:"query"`
+		From      int                    `json:"from"`
+		Size      *int                   `json:"size"`
+		Sort      []interface{}          `json:"sort"`
+		Highlight struct {
+			PreTags  []string               `json:"pre_tags"`
+			PostTags []string               `json:"post_tags"`
+			Fields   map[string]interface{} `json:"fields"`
+		} `json:"highlight"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "parse_exception", err.Error())
+		return
+	}
+
+	var hits []testHit
+	for id, doc := range docs {
+		if body.Query == nil || matchQuery(body.Query, doc) {
+			hits = append(hits, testHit{id, doc})
+		}
+	}
+	sortHits(hits, body.Sort)
+
+	total := len(hits)
+	size := 10
+	if body.Size != nil {
+		size = *body.Size
+	}
+	if body.From < len(hits) {
+		hits = hits[body.From:]
+	} else {
+		hits = nil
+	}
+	if size < len(hits) {
+		hits = hits[:size]
+	}
+
+	preTag, postTag := "<em>", "</em>"
+	if len(body.Highlight.PreTags) > 0 {
+		preTag = body.Highlight.PreTags[0]
+	}
+	if len(body.Highlight.PostTags) > 0 {
+		postTag = body.Highlight.PostTags[0]
+	}
+	phrases := queryPhrases(body.Query)
+
+	results := make([]map[string]interface{}, 0, len(hits))
+	for _, hit := range hits {
+		result := map[string]interface{}{"_id": hit.id, "_score": 1.0, "_source": hit.doc}
+		highlight := make(map[string][]string)
+		for field := range body.Highlight.Fields {
+			value, ok := hit.doc[field].(string)
+			if !ok {
+				continue
+			}
+			if highlighted, ok := highlightPhrases(value, phrases, preTag, postTag); ok {
+				highlight[field] = []string{highlighted}
+			}
+		}
+		if len(highlight) > 0 {
+			result["highlight"] = highlight
+		}
+		results = append(results, result)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"hits": map[string]interface{}{
+			"total": map[string]interface{}{"value": total, "relation": "eq"},
+			"hits":  results,
+		},
+	})
+}
+
+func compareValues(a, b interface{}) int {
+	fa, aIsNum := a.(float64)
+	fb, bIsNum := b.(float64)
+	if aIsNum && bIsNum {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+// sortHits sorts the hits by the given source fields, ties are sorted by id.
+// As all hits have the same score sorting by _score is ignored.
+func sortHits(hits []testHit, sorts []interface{}) {
+	sort.Slice(hits, func(i, j int) bool {
+		for _, s := range sorts {
+			var field, order string
+			switch v := s.(type) {
+			case string:
+				field, order = v, "asc"
+			case map[string]interface{}:
+				for f, o := range v {
+					field = f
+					switch o := o.(type) {
+					case string:
+						order = o
+					case map[string]interface{}:
+						order, _ = o["order"].(string)
+					}
+				}
+			}
+			if field == "_score" {
+				continue
+			}
+			c := compareValues(hits[i].doc[field], hits[j].doc[field])
+			if order == "desc" {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		ni, errI := strconv.ParseInt(hits[i].id, 10, 64)
+		nj, errJ := strconv.ParseInt(hits[j].id, 10, 64)
+		if errI == nil && errJ == nil {
+			return ni < nj
+		}
+		return hits[i].id < hits[j].id
+	})
+}
+
+func asList(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	default:
+		return []interface{}{v}
+	}
+}
+
+func asStrings(v interface{}) []string {
+	var strs []string
+	for _, s := range asList(v) {
+		if s, ok := s.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+// matchQuery returns true if the document matches the query
+func matchQuery(q map[string]interface{}, doc map[string]interface{}) bool {
+	for typ, v := range q {
+		params, _ := v.(map[string]interface{})
+		switch typ {
+		case "match_all":
+		case "bool":
+			for _, clause := range append(asList(params["must"]), asList(params["filter"])...) {
+				if c, ok := clause.(map[string]interface{}); !ok || !matchQuery(c, doc) {
+					return false
+				}
+			}
+			for _, clause := range asList(params["must_not"]) {
+				if c, ok := clause.(map[string]interface{}); ok && matchQuery(c, doc) {
+					return false
+				}
+			}
+			if should := asList(params["should"]); len(should) > 0 {
+				matched := false
+				for _, clause := range should {
+					if c, ok := clause.(map[string]interface{}); ok && matchQuery(c, doc) {
+						matched = true
+						break
+					}
+				}
+				if !matched {
+					return false
+				}
+			}
+		case "term", "terms":
+			for field, values := range params {
+				if m, ok := values.(map[string]interface{}); ok {
+					values = m["value"]
+				}
+				matched := false
+				for _, value := range asList(values) {
+					for _, docValue := range asList(doc[field]) {
+						if compareValues(value, docValue) == 0 {
+							matched = true
+						}
+					}
+				}
+				if !matched {
+					return false
+				}
+			}
+		case "match_phrase":
+			for field, phrase := range params {
+				if m, ok := phrase.(map[string]interface{}); ok {
+					phrase = m["query"]
+				}
+				p, _ := phrase.(string)
+				if !matchPhrase(p, doc[field]) {
+					return false
+				}
+			}
+		case "multi_match":
+			p, _ := params["query"].(string)
+			matched := false
+			for _, field := range asStrings(params["fields"]) {
+				field = strings.SplitN(field, "^", 2)[0]
+				if matchPhrase(p, doc[field]) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// queryPhrases returns all the phrases searched by the query
+func queryPhrases(q map[string]interface{}) []string {
+	var phrases []string
+	for typ, v := range q {
+		params, _ := v.(map[string]interface{})
+		switch typ {
+		case "bool":
+			for _, clause := range append(asList(params["must"]), asList(params["should"])...) {
+				if c, ok := clause.(map[string]interface{}); ok {
+					phrases = append(phrases, queryPhrases(c)...)
+				}
+			}
+		case "match_phrase":
+			for _, phrase := range params {
+				if m, ok := phrase.(map[string]interface{}); ok {
+					phrase = m["query"]
+				}
+				if p, ok := phrase.(string); ok {
+					phrases = append(phrases, p)
+				}
+			}
+		case "multi_match":
+			if p, ok := params["query"].(string); ok {
+				phrases = append(phrases, p)
+			}
+		}
+	}
+	return phrases
+}
+
+type testToken struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits the text into lower cased words of letters and digits
+func tokenize(text string) []testToken {
+	var tokens []testToken
+	start := -1
+	for i, r := range text {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWordRune && start < 0 {
+			start = i
+		} else if !isWordRune && start >= 0 {
+			tokens = append(tokens, testToken{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, testToken{strings.ToLower(text[start:]), start, len(text)})
+	}
+	return tokens
+}
+
+// phraseMatches returns the indexes of the first tokens of all occurrences of the phrase
+func phraseMatches(phrase []testToken, tokens []testToken) []int {
+	if len(phrase) == 0 {
+		return nil
+	}
+	var matches []int
+	for i := 0; i+len(phrase) <= len(tokens); i++ {
+		matched := true
+		for j := range phrase {
+			if tokens[i+j].term != phrase[j].term {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			matches = append(matches, i)
+		}
+	}
+	return matches
+}
+
+func matchPhrase(phrase string, value interface{}) bool {
+	for _, text := range asStrings(value) {
+		if len(phraseMatches(tokenize(phrase), tokenize(text))) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// highlightPhrases surrounds every word of the occurrences of the phrases by the tags
+func highlightPhrases(text string, phrases []string, preTag, postTag string) (string, bool) {
+	tokens := tokenize(text)
+	highlighted := make([]bool, len(tokens))
+	found := false
+	for _, phrase := range phrases {
+		p := tokenize(phrase)
+		for _, i := range phraseMatches(p, tokens) {
+			for j := range p {
+				highlighted[i+j] = true
+				found = true
+			}
+		}
+	}
+	if !found {
+		return "", false
+	}
+
+	var sb strings.Builder
+	last := 0
+	for i, token := range tokens {
+		if !highlighted[i] {
+			continue
+		}
+		sb.WriteString(text[last:token.start])
+		sb.WriteString(preTag)
+		sb.WriteString(text[token.start:token.end])
+		sb.WriteString(postTag)
+		last = token.end
+	}
+	sb.WriteString(text[last:])
+	return sb.String(), true
+}
@@ -0,0 +1,161 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"code.gitea.io/gitea/modules/indexer/elastic"
+)
+
+const (
+	esIssueIndexerLatestVersion = 1
+)
+
+var (
+	_ Indexer = &ElasticSearchIndexer{}
+)
+
+// ElasticSearchIndexer implements Indexer interface
+type ElasticSearchIndexer struct {
+	client      *elastic.Client
+	indexerName string
+}
+
+// NewElasticSearchIndexer creates a new elasticsearch indexer
+func NewElasticSearchIndexer(url, indexerName string) (*ElasticSearchIndexer, error) {
+	client, err := elastic.NewClient(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ElasticSearchIndexer{
+		client:      client,
+		indexerName: indexerName,
+	}, nil
+}
+
+const (
+	defaultMapping = `{
+		"mappings": {
+			"properties": {
+				"id": {
+					"type": "long",
+					"index": true
+				},
+				"repo_id": {
+					"type": "long",
+					"index": true
+				},
+				"title": {
+					"type": "text",
+					"index": true
+				},
+				"content": {
+					"type": "text",
+					"index": true
+				},
+				"comments": {
+					"type" : "text",
+					"index": true
+				}
+			}
+		}
+	}`
+)
+
+// Init will initialize the indexer, the index of the latest version is created
+// when it does not exist and indexes of previous versions are removed
+func (b *ElasticSearchIndexer) Init() (bool, error) {
+	return b.client.InitVersionedIndex(b.indexerName, esIssueIndexerLatestVersion, json.RawMessage(defaultMapping))
+}
+
+// Rebuild drops the index of the latest version and creates it again empty
+func (b *ElasticSearchIndexer) Rebuild() error {
+	if err := b.client.DeleteIndex(elastic.VersionedIndexName(b.indexerName, esIssueIndexerLatestVersion)); err != nil {
+		return err
+	}
+	_, err := b.Init()
+	return err
+}
+
+// Index will save the index data
+func (b *ElasticSearchIndexer) Index(issues []*IndexerData) error {
+	bulk := b.client.NewBulkRequest(b.indexerName)
+	for _, issue := range issues {
+		if err := bulk.Index(strconv.FormatInt(issue.ID, 10), map[string]interface{}{
+			"id":       issue.ID,
+			"repo_id":  issue.RepoID,
+			"title":    issue.Title,
+			"content":  issue.Content,
+			"comments": issue.Comments,
+		}); err != nil {
+			return err
+		}
+	}
+	return b.client.Bulk(bulk)
+}
+
+// Delete deletes indexes by ids
+func (b *ElasticSearchIndexer) Delete(ids ...int64) error {
+	bulk := b.client.NewBulkRequest(b.indexerName)
+	for _, id := range ids {
+		if err := bulk.Delete(strconv.FormatInt(id, 10)); err != nil {
+			return err
+		}
+	}
+	return b.client.Bulk(bulk)
+}
+
+// Search searches for issues by given conditions.
+// Returns the matching issue IDs
+func (b *ElasticSearchIndexer) Search(keyword string, repoIDs []int64, limit, start int) (*SearchResult, error) {
+	query := map[string]interface{}{
+		"must": map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":  keyword,
+				"type":   "phrase",
+				"fields": []string{"title", "content", "comments"},
+			},
+		},
+	}
+	if len(repoIDs) > 0 {
+		query["filter"] = map[string]interface{}{
+			"terms": map[string]interface{}{"repo_id": repoIDs},
+		}
+	}
+
+	result, err := b.client.Search(b.indexerName, map[string]interface{}{
+		"query":        map[string]interface{}{"bool": query},
+		"sort":         []interface{}{map[string]string{"_score": "desc"}, map[string]string{"id": "asc"}},
+		"from":         start,
+		"size":         limit,
+		"track_scores": true,
+		"_source":      false,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ret = SearchResult{
+		Total: result.Total,
+		Hits:  make([]Match, 0, len(result.Hits)),
+	}
+	for _, hit := range result.Hits {
+		id, err := strconv.ParseInt(hit.ID, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ret.Hits = append(ret.Hits, Match{
+			ID:    id,
+			Score: hit.Score,
+		})
+	}
+	return &ret, nil
+}
+
+// Close implements indexer
+func (b *ElasticSearchIndexer) Close() {}
@@ -0,0 +1,73 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issues
+
+import (
+	"context"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/indexer/internal/elastictest"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticSearchIndexer(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	server := elastictest.NewServer()
+	defer server.Close()
+
+	oldIssueType := setting.Indexer.IssueType
+	oldConnStr := setting.Indexer.IssueConnStr
+	oldIndexerName := setting.Indexer.IssueIndexerName
+	setting.Indexer.IssueType = "elasticsearch"
+	setting.Indexer.IssueConnStr = server.URL
+	setting.Indexer.IssueIndexerName = "gitea_issues"
+	defer func() {
+		setting.Indexer.IssueType = oldIssueType
+		setting.Indexer.IssueConnStr = oldConnStr
+		setting.Indexer.IssueIndexerName = oldIndexerName
+	}()
+
+	count, err := RebuildIssueIndexer(context.Background())
+	assert.NoError(t, err)
+	assert.EqualValues(t, models.GetCount(t, &models.Issue{}), count)
+
+	indexer, err := NewElasticSearchIndexer(server.URL, "gitea_issues")
+	assert.NoError(t, err)
+	exist, err := indexer.Init()
+	assert.NoError(t, err)
+	assert.True(t, exist)
+
+	search := func(keyword string) []int64 {
+		res, err := indexer.Search(keyword, []int64{1}, 1000, 0)
+		assert.NoError(t, err)
+		ids := make([]int64, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+
+	assert.EqualValues(t, []int64{2}, search("issue2"))
+	assert.EqualValues(t, []int64{1}, search("first"))
+	assert.EqualValues(t, []int64{1, 2, 3, 5}, search("for"))
+	assert.EqualValues(t, []int64{1}, search("good"))
+
+	assert.NoError(t, indexer.Delete(2, 5))
+	assert.EqualValues(t, []int64{}, search("issue2"))
+	assert.EqualValues(t, []int64{1, 3}, search("for"))
+
+	// rebuilding drops the deleted issues from the index
+	_, err = RebuildIssueIndexer(context.Background())
+	assert.NoError(t, err)
+	assert.EqualValues(t, []int64{1, 2, 3, 5}, search("for"))
+
+	setting.Indexer.IssueType = "bleve"
+	_, err = RebuildIssueIndexer(context.Background())
+	assert.Error(t, err)
+}
@@ -100,7 +100,7 @@ func InitIssueIndexer(syncReindex bool) {
 
 	// Create the Queue
 	switch setting.Indexer.IssueType {
-	case "bleve":
+	case "bleve", "elasticsearch":
 		handler := func(data ...queue.Data) {
 			indexer := holder.get()
 			if indexer == nil {
@@ -162,6 +162,20 @@ func InitIssueIndexer(syncReindex bool) {
 				})
 				log.Debug("Created Bleve Indexer")
 			})
+		case "elasticsearch":
+			issueIndexer, err := NewElasticSearchIndexer(setting.Indexer.IssueConnStr, setting.Indexer.IssueIndexerName)
+			if err != nil {
+				holder.cancel()
+				log.Fatal("Unable to initialize Elastic Search Issue Indexer: %v", err)
+			}
+			exist, err := issueIndexer.Init()
+			if err != nil {
+				holder.cancel()
+				log.Fatal("Unable to initialize Elastic Search Issue Indexer: %v", err)
+			}
+			populate = !exist
+			holder.set(issueIndexer)
+			log.Debug("Created Elastic Search Indexer")
 		case "db":
 			issueIndexer := &DBIndexer{}
 			holder.set(issueIndexer)
@@ -213,13 +227,19 @@ func InitIssueIndexer(syncReindex bool) {
 
 // populateIssueIndexer populate the issue indexer with issue data
 func populateIssueIndexer(ctx context.Context) {
+	if err := forEachRepository(ctx, func(repo *models.Repository) error {
+		UpdateRepoIndexer(repo)
+		return nil
+	}); err != nil {
+		log.Warn("Issue Indexer population stopped before completion: %v", err)
+		return
+	}
+	log.Debug("Issue Indexer population complete")
+}
+
+// forEachRepository calls fn for all repositories until it returns an error or ctx is done
+func forEachRepository(ctx context.Context, fn func(repo *models.Repository) error) error {
 	for page := 1; ; page++ {
-		select {
-		case <-ctx.Done():
-			log.Warn("Issue Indexer population shutdown before completion")
-			return
-		default:
-		}
 		repos, _, err := models.SearchRepositoryByName(&models.SearchRepoOptions{
 			Page:        page,
 			PageSize:    models.RepositoryListDefaultPageSize,
@@ -228,67 +248,121 @@ func populateIssueIndexer(ctx context.Context) {
 			Collaborate: util.OptionalBoolFalse,
 		})
 		if err != nil {
-			log.Error("SearchRepositoryByName: %v", err)
-			continue
+			return fmt.Errorf("SearchRepositoryByName: %v", err)
 		}
 		if len(repos) == 0 {
-			log.Debug("Issue Indexer population complete")
-			return
+			return nil
 		}
 
 		for _, repo := range repos {
 			select {
 			case <-ctx.Done():
-				log.Info("Issue Indexer population shutdown before completion")
-				return
+				return ctx.Err()
 			default:
 			}
-			UpdateRepoIndexer(repo)
+			if err := fn(repo); err != nil {
+				return err
+			}
 		}
 	}
 }
 
-// UpdateRepoIndexer add/update all issues of the repositories
-func UpdateRepoIndexer(repo *models.Repository) {
+// repoIndexerData returns the indexer data of all issues of the repository
+func repoIndexerData(repo *models.Repository) ([]*IndexerData, error) {
 	is, err := models.Issues(&models.IssuesOptions{
 		RepoIDs:  []int64{repo.ID},
 		IsClosed: util.OptionalBoolNone,
 		IsPull:   util.OptionalBoolNone,
 	})
 	if err != nil {
-		log.Error("Issues: %v", err)
-		return
+		return nil, fmt.Errorf("Issues: %v", err)
 	}
 	if err = models.IssueList(is).LoadDiscussComments(); err != nil {
-		log.Error("LoadComments: %v", err)
-		return
+		return nil, fmt.Errorf("LoadComments: %v", err)
 	}
+	data := make([]*IndexerData, 0, len(is))
 	for _, issue := range is {
-		UpdateIssueIndexer(issue)
+		data = append(data, issueIndexerData(issue))
 	}
+	return data, nil
 }
 
-// UpdateIssueIndexer add/update an issue to the issue indexer
-func UpdateIssueIndexer(issue *models.Issue) {
+// UpdateRepoIndexer add/update all issues of the repositories
+func UpdateRepoIndexer(repo *models.Repository) {
+	data, err := repoIndexerData(repo)
+	if err != nil {
+		log.Error("Unable to get issues of %s: %v", repo.FullName(), err)
+		return
+	}
+	for _, indexerData := range data {
+		pushIndexerData(indexerData)
+	}
+}
+
+func issueIndexerData(issue *models.Issue) *IndexerData {
 	var comments []string
 	for _, comment := range issue.Comments {
 		if comment.Type == models.CommentTypeComment {
 			comments = append(comments, comment.Content)
 		}
 	}
-	indexerData := &IndexerData{
+	return &IndexerData{
 		ID:       issue.ID,
 		RepoID:   issue.RepoID,
 		Title:    issue.Title,
 		Content:  issue.Content,
 		Comments: comments,
 	}
+}
+
+func pushIndexerData(indexerData *IndexerData) {
 	log.Debug("Adding to channel: %v", indexerData)
 	if err := issueIndexerQueue.Push(indexerData); err != nil {
 		log.Error("Unable to push to issue indexer: %v: Error: %v", indexerData, err)
 	}
 }
 
+// UpdateIssueIndexer add/update an issue to the issue indexer
+func UpdateIssueIndexer(issue *models.Issue) {
+	pushIndexerData(issueIndexerData(issue))
+}
+
+// RebuildIssueIndexer drops the issue index and indexes all issues again without
+// going through the queue, it is only supported by the elasticsearch indexer
+// as the bleve index can't be opened while Gitea is running.
+func RebuildIssueIndexer(ctx context.Context) (int, error) {
+	if setting.Indexer.IssueType != "elasticsearch" {
+		return 0, fmt.Errorf("rebuilding is not supported by the %s issue indexer", setting.Indexer.IssueType)
+	}
+
+	indexer, err := NewElasticSearchIndexer(setting.Indexer.IssueConnStr, setting.Indexer.IssueIndexerName)
+	if err != nil {
+		return 0, err
+	}
+	defer indexer.Close()
+	if err := indexer.Rebuild(); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = forEachRepository(ctx, func(repo *models.Repository) error {
+		data, err := repoIndexerData(repo)
+		if err != nil {
+			return err
+		}
+		for len(data) > 0 {
+			n := util.Min(len(data), maxBatchSize)
+			if err := indexer.Index(data[:n]); err != nil {
+				return fmt.Errorf("unable to index issues of %s: %v", repo.FullName(), err)
+			}
+			data = data[n:]
+			count += n
+		}
+		return nil
+	})
+	return count, err
+}
+
 // DeleteRepoIssueIndexer deletes repo's all issues indexes
 func DeleteRepoIssueIndexer(repo *models.Repository) {
 	var ids []int64
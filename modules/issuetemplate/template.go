@@ -0,0 +1,313 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package issuetemplate parses the issue and pull request templates of repositories.
+package issuetemplate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FieldType is the type of an input of an issue form
+type FieldType string
+
+const (
+	// FieldTypeMarkdown is a text which is shown in the form but not submitted
+	FieldTypeMarkdown FieldType = "markdown"
+	// FieldTypeTextarea is a multi line text input
+	FieldTypeTextarea FieldType = "textarea"
+	// FieldTypeInput is a single line text input
+	FieldTypeInput FieldType = "input"
+	// FieldTypeDropdown is a selection of one or more options
+	FieldTypeDropdown FieldType = "dropdown"
+	// FieldTypeCheckboxes is a list of checkboxes
+	FieldTypeCheckboxes FieldType = "checkboxes"
+)
+
+var (
+	// ErrNoName is returned for templates without name
+	ErrNoName = errors.New("template has no name")
+	// ErrNoBody is returned for issue forms without fields
+	ErrNoBody = errors.New("issue form has no body")
+
+	validFieldID = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+)
+
+// ErrFieldRequired is returned when a required field of an issue form was not filled in
+type ErrFieldRequired struct {
+	Label string
+}
+
+func (err ErrFieldRequired) Error() string {
+	return fmt.Sprintf("field %q is required", err.Label)
+}
+
+// IsErrFieldRequired checks if an error is a ErrFieldRequired.
+func IsErrFieldRequired(err error) bool {
+	_, ok := err.(ErrFieldRequired)
+	return ok
+}
+
+// StringList is a list of strings which can be written as comma separated string or as list
+type StringList []string
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (l *StringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var single string
+	if err := unmarshal(&single); err == nil {
+		*l = nil
+		for _, s := range strings.Split(single, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				*l = append(*l, s)
+			}
+		}
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// Option is an option of a dropdown or checkboxes field
+type Option struct {
+	Label    string `yaml:"label"`
+	Required bool   `yaml:"required"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, options may be written as plain string
+func (o *Option) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var label string
+	if err := unmarshal(&label); err == nil {
+		*o = Option{Label: label}
+		return nil
+	}
+	type plain Option
+	return unmarshal((*plain)(o))
+}
+
+// FieldAttributes are the attributes of an issue form field
+type FieldAttributes struct {
+	Label       string   `yaml:"label"`
+	Description string   `yaml:"description"`
+	Placeholder string   `yaml:"placeholder"`
+	Value       string   `yaml:"value"`
+	Render      string   `yaml:"render"`
+	Multiple    bool     `yaml:"multiple"`
+	Options     []Option `yaml:"options"`
+}
+
+// FieldValidations are the validations of an issue form field
+type FieldValidations struct {
+	Required bool `yaml:"required"`
+}
+
+// Field is an input of an issue form
+type Field struct {
+	Type        FieldType        `yaml:"type"`
+	ID          string           `yaml:"id"`
+	Attributes  FieldAttributes  `yaml:"attributes"`
+	Validations FieldValidations `yaml:"validations"`
+}
+
+// InputName returns the name of the HTML input of the field
+func (f *Field) InputName() string {
+	return "form-field-" + f.ID
+}
+
+// IsSelected returns whether the option was selected in the submitted values
+func (f *Field) IsSelected(values url.Values, option string) bool {
+	for _, v := range values[f.InputName()] {
+		if v == option {
+			return true
+		}
+	}
+	return false
+}
+
+// IsChecked returns whether the checkbox with the given index was checked in the submitted values
+func (f *Field) IsChecked(values url.Values, index int) bool {
+	return f.IsSelected(values, strconv.Itoa(index))
+}
+
+// Template is an issue or pull request template. Markdown templates carry
+// their metadata as YAML front matter, YAML templates describe a form whose
+// answers are turned into the body of the issue.
+type Template struct {
+	Name      string     `yaml:"name"`
+	About     string     `yaml:"about"`
+	Title     string     `yaml:"title"`
+	Labels    StringList `yaml:"labels"`
+	Assignees StringList `yaml:"assignees"`
+	Fields    []*Field   `yaml:"body"`
+	Content   string     `yaml:"-"`
+	FileName  string     `yaml:"-"`
+}
+
+// IsForm returns whether the template is an issue form
+func (t *Template) IsForm() bool {
+	return len(t.Fields) > 0
+}
+
+// IsTemplateFile returns whether a file of a template directory may contain a template
+func IsTemplateFile(filename string) bool {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".md", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// Unmarshal parses the template stored in filename
+func Unmarshal(filename string, content []byte) (*Template, error) {
+	t := &Template{FileName: filename}
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".md":
+		frontMatter, body := splitFrontMatter(content)
+		if err := yaml.Unmarshal(frontMatter, t); err != nil {
+			return nil, err
+		}
+		t.Fields = nil
+		t.Content = string(body)
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(content, t); err != nil {
+			return nil, err
+		}
+		if !t.IsForm() {
+			return nil, ErrNoBody
+		}
+	default:
+		return nil, fmt.Errorf("unsupported template file %s", filename)
+	}
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// splitFrontMatter separates the YAML front matter from a markdown file
+func splitFrontMatter(content []byte) ([]byte, []byte) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	normalized := bytes.Replace(content, []byte("\r\n"), []byte("\n"), -1)
+	if !bytes.HasPrefix(normalized, []byte("---\n")) {
+		return nil, content
+	}
+	rest := normalized[4:]
+	end := bytes.Index(rest, []byte("\n---\n"))
+	if end < 0 {
+		if !bytes.HasSuffix(rest, []byte("\n---")) {
+			return nil, content
+		}
+		return rest[:len(rest)-4], nil
+	}
+	return rest[:end], bytes.TrimLeft(rest[end+5:], "\n")
+}
+
+func (t *Template) validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return ErrNoName
+	}
+
+	ids := make(map[string]bool, len(t.Fields))
+	for i, f := range t.Fields {
+		if f == nil {
+			return fmt.Errorf("field %d is empty", i)
+		}
+		if f.ID == "" {
+			f.ID = strconv.Itoa(i)
+		} else if !validFieldID.MatchString(f.ID) {
+			return fmt.Errorf("field %d has an invalid id %q", i, f.ID)
+		}
+		if ids[f.ID] {
+			return fmt.Errorf("field %d has a duplicated id %q", i, f.ID)
+		}
+		ids[f.ID] = true
+
+		switch f.Type {
+		case FieldTypeMarkdown:
+			if f.Attributes.Value == "" {
+				return fmt.Errorf("markdown field %d has no value", i)
+			}
+		case FieldTypeTextarea, FieldTypeInput:
+			if f.Attributes.Label == "" {
+				return fmt.Errorf("field %d has no label", i)
+			}
+		case FieldTypeDropdown, FieldTypeCheckboxes:
+			if f.Attributes.Label == "" {
+				return fmt.Errorf("field %d has no label", i)
+			}
+			if len(f.Attributes.Options) == 0 {
+				return fmt.Errorf("field %d has no options", i)
+			}
+		default:
+			return fmt.Errorf("field %d has an unknown type %q", i, f.Type)
+		}
+	}
+	return nil
+}
+
+// RenderToMarkdown turns the submitted answers of an issue form into the
+// markdown body of the issue
+func (t *Template) RenderToMarkdown(values url.Values) (string, error) {
+	var buf strings.Builder
+	for _, f := range t.Fields {
+		var answer string
+		switch f.Type {
+		case FieldTypeMarkdown:
+			continue
+		case FieldTypeTextarea, FieldTypeInput:
+			answer = strings.TrimSpace(values.Get(f.InputName()))
+			if answer == "" && f.Validations.Required {
+				return "", ErrFieldRequired{Label: f.Attributes.Label}
+			}
+			if answer != "" && f.Attributes.Render != "" {
+				answer = "```" + f.Attributes.Render + "\n" + answer + "\n```"
+			}
+		case FieldTypeDropdown:
+			var selected []string
+			for _, option := range f.Attributes.Options {
+				if f.IsSelected(values, option.Label) {
+					selected = append(selected, option.Label)
+				}
+			}
+			if !f.Attributes.Multiple && len(selected) > 1 {
+				selected = selected[:1]
+			}
+			if len(selected) == 0 && f.Validations.Required {
+				return "", ErrFieldRequired{Label: f.Attributes.Label}
+			}
+			answer = strings.Join(selected, ", ")
+		case FieldTypeCheckboxes:
+			lines := make([]string, 0, len(f.Attributes.Options))
+			for i, option := range f.Attributes.Options {
+				checked := f.IsChecked(values, i)
+				if !checked && option.Required {
+					return "", ErrFieldRequired{Label: option.Label}
+				}
+				mark := " "
+				if checked {
+					mark = "x"
+				}
+				lines = append(lines, "- ["+mark+"] "+option.Label)
+			}
+			answer = strings.Join(lines, "\n")
+		}
+		if answer == "" {
+			answer = "_No response_"
+		}
+		buf.WriteString("### " + f.Attributes.Label + "\n\n" + answer + "\n\n")
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package issuetemplate
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsTemplateFile(t *testing.T) {
+	assert.True(t, IsTemplateFile("bug.md"))
+	assert.True(t, IsTemplateFile("feature.YAML"))
+	assert.True(t, IsTemplateFile("feature.yml"))
+	assert.False(t, IsTemplateFile("config.json"))
+	assert.False(t, IsTemplateFile("README"))
+}
+
+func TestUnmarshalMarkdown(t *testing.T) {
+	tmpl, err := Unmarshal("bug.md", []byte("\xef\xbb\xbf---\r\nname: Bug\r\nabout: Report a bug\r\ntitle: \"[Bug] \"\r\nlabels: bug, needs triage\r\nassignees: [user2]\r\n---\r\n\r\nSteps to reproduce\r\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Bug", tmpl.Name)
+	assert.Equal(t, "Report a bug", tmpl.About)
+	assert.Equal(t, "[Bug] ", tmpl.Title)
+	assert.EqualValues(t, []string{"bug", "needs triage"}, tmpl.Labels)
+	assert.EqualValues(t, []string{"user2"}, tmpl.Assignees)
+	assert.Equal(t, "Steps to reproduce\n", tmpl.Content)
+	assert.Equal(t, "bug.md", tmpl.FileName)
+	assert.False(t, tmpl.IsForm())
+
+	_, err = Unmarshal("plain.md", []byte("Steps to reproduce\n"))
+	assert.Equal(t, ErrNoName, err)
+
+	_, err = Unmarshal("config.json", []byte("{}"))
+	assert.Error(t, err)
+}
+
+func TestUnmarshalForm(t *testing.T) {
+	tmpl, err := Unmarshal("feature.yaml", []byte(`name: Feature
+labels: [enhancement]
+body:
+- type: markdown
+  attributes:
+    value: Thanks!
+- type: textarea
+  id: description
+  attributes:
+    label: Description
+- type: dropdown
+  attributes:
+    label: Platform
+    options: [Linux, Windows]
+`))
+	assert.NoError(t, err)
+	assert.True(t, tmpl.IsForm())
+	assert.Len(t, tmpl.Fields, 3)
+	assert.Equal(t, "0", tmpl.Fields[0].ID)
+	assert.Equal(t, "description", tmpl.Fields[1].ID)
+	assert.Equal(t, "form-field-description", tmpl.Fields[1].InputName())
+	assert.Equal(t, []Option{{Label: "Linux"}, {Label: "Windows"}}, tmpl.Fields[2].Attributes.Options)
+
+	for name, content := range map[string]string{
+		"no body":       "name: Feature\n",
+		"no name":       "body:\n- type: input\n  attributes:\n    label: Version\n",
+		"duplicated id": "name: Feature\nbody:\n- type: input\n  id: a\n  attributes:\n    label: A\n- type: input\n  id: a\n  attributes:\n    label: B\n",
+		"invalid id":    "name: Feature\nbody:\n- type: input\n  id: a b\n  attributes:\n    label: A\n",
+		"unknown type":  "name: Feature\nbody:\n- type: radio\n  attributes:\n    label: A\n",
+		"no label":      "name: Feature\nbody:\n- type: textarea\n",
+		"no options":    "name: Feature\nbody:\n- type: dropdown\n  attributes:\n    label: A\n",
+		"no value":      "name: Feature\nbody:\n- type: markdown\n",
+	} {
+		_, err := Unmarshal("feature.yml", []byte(content))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestRenderToMarkdown(t *testing.T) {
+	tmpl, err := Unmarshal("feature.yaml", []byte(`name: Feature
+body:
+- type: markdown
+  attributes:
+    value: Thanks!
+- type: textarea
+  id: logs
+  attributes:
+    label: Logs
+    render: shell
+- type: input
+  id: version
+  attributes:
+    label: Version
+  validations:
+    required: true
+- type: dropdown
+  id: platform
+  attributes:
+    label: Platform
+    multiple: true
+    options: [Linux, macOS, Windows]
+- type: checkboxes
+  id: terms
+  attributes:
+    label: Checks
+    options:
+    - label: Searched
+      required: true
+    - label: Optional
+`))
+	assert.NoError(t, err)
+
+	values := url.Values{
+		"form-field-logs":     {"panic"},
+		"form-field-version":  {" 1.11 "},
+		"form-field-platform": {"Windows", "Linux"},
+		"form-field-terms":    {"0"},
+	}
+	content, err := tmpl.RenderToMarkdown(values)
+	assert.NoError(t, err)
+	assert.Equal(t, "### Logs\n\n```shell\npanic\n```\n\n"+
+		"### Version\n\n1.11\n\n"+
+		"### Platform\n\nLinux, Windows\n\n"+
+		"### Checks\n\n- [x] Searched\n- [ ] Optional", content)
+
+	values.Del("form-field-logs")
+	values.Del("form-field-platform")
+	content, err = tmpl.RenderToMarkdown(values)
+	assert.NoError(t, err)
+	assert.Contains(t, content, "### Logs\n\n_No response_")
+	assert.Contains(t, content, "### Platform\n\n_No response_")
+
+	values.Del("form-field-version")
+	_, err = tmpl.RenderToMarkdown(values)
+	assert.True(t, IsErrFieldRequired(err))
+	assert.Equal(t, ErrFieldRequired{Label: "Version"}, err)
+
+	values.Set("form-field-version", "1.11")
+	values.Del("form-field-terms")
+	_, err = tmpl.RenderToMarkdown(values)
+	assert.Equal(t, ErrFieldRequired{Label: "Searched"}, err)
+}
@@ -0,0 +1,325 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var dollars = []byte("$$")
+
+// KindMath is a NodeKind of the Math node.
+var KindMath = ast.NewNodeKind("Math")
+
+// Math is an inline formula, written as $...$, or as $$...$$ to display it on its own line.
+type Math struct {
+	ast.BaseInline
+	Value   []byte
+	Display bool
+}
+
+// Dump implements Node.Dump.
+func (n *Math) Dump(source []byte, level int) {
+	m := map[string]string{}
+	m["Value"] = string(n.Value)
+	ast.DumpHelper(n, source, level, m, nil)
+}
+
+// Kind implements Node.Kind.
+func (n *Math) Kind() ast.NodeKind {
+	return KindMath
+}
+
+// NewMath returns a new Math node.
+func NewMath(value []byte, display bool) *Math {
+	return &Math{
+		Value:   value,
+		Display: display,
+	}
+}
+
+// KindMathBlock is a NodeKind of the MathBlock node.
+var KindMathBlock = ast.NewNodeKind("MathBlock")
+
+// MathBlock is a displayed formula, written between lines starting with $$ or in a math
+// fenced code block.
+type MathBlock struct {
+	ast.BaseBlock
+	closed bool
+}
+
+// Dump implements Node.Dump.
+func (n *MathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// Kind implements Node.Kind.
+func (n *MathBlock) Kind() ast.NodeKind {
+	return KindMathBlock
+}
+
+// IsRaw implements Node.IsRaw.
+func (n *MathBlock) IsRaw() bool {
+	return true
+}
+
+// NewMathBlock returns a new MathBlock node.
+func NewMathBlock() *MathBlock {
+	return &MathBlock{}
+}
+
+type mathBlockParser struct {
+}
+
+var defaultMathBlockParser = &mathBlockParser{}
+
+// NewMathBlockParser returns a new parser.BlockParser that can parse
+// formulas between $$ lines.
+func NewMathBlockParser() parser.BlockParser {
+	return defaultMathBlockParser
+}
+
+func (b *mathBlockParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (b *mathBlockParser) Open(parent ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, segment := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], dollars) {
+		return nil, parser.NoChildren
+	}
+	node := NewMathBlock()
+	start := segment.Start - segment.Padding + pos + len(dollars)
+	rest := util.TrimRightSpace(line[pos+len(dollars):])
+	if len(rest) >= len(dollars) && bytes.HasSuffix(rest, dollars) {
+		// the whole formula is on this line
+		rest = rest[:len(rest)-len(dollars)]
+		node.closed = true
+	}
+	if !util.IsBlank(rest) {
+		node.Lines().Append(text.NewSegment(start, start+len(rest)))
+	}
+	advanceLine(reader, line, segment)
+	return node, parser.NoChildren
+}
+
+func (b *mathBlockParser) Continue(node ast.Node, reader text.Reader, pc parser.Context) parser.State {
+	n := node.(*MathBlock)
+	if n.closed {
+		return parser.Close
+	}
+	line, segment := reader.PeekLine()
+	if trimmed := util.TrimRightSpace(line); bytes.HasSuffix(trimmed, dollars) {
+		value := trimmed[:len(trimmed)-len(dollars)]
+		if !util.IsBlank(value) {
+			n.Lines().Append(text.NewSegmentPadding(segment.Start, segment.Start+len(value)-segment.Padding, segment.Padding))
+		}
+		advanceLine(reader, line, segment)
+		return parser.Close
+	}
+	n.Lines().Append(segment)
+	advanceLine(reader, line, segment)
+	return parser.Continue | parser.NoChildren
+}
+
+// advanceLine advances the reader to the end of the current line.
+func advanceLine(reader text.Reader, line []byte, segment text.Segment) {
+	newline := 0
+	if len(line) > 0 && line[len(line)-1] == '\n' {
+		newline = 1
+	}
+	reader.Advance(segment.Len() - newline)
+}
+
+func (b *mathBlockParser) Close(node ast.Node, reader text.Reader, pc parser.Context) {
+}
+
+func (b *mathBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (b *mathBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+type mathParser struct {
+}
+
+var defaultMathParser = &mathParser{}
+
+// NewMathParser returns a new parser.InlineParser that can parse
+// $...$ and $$...$$ formulas.
+func NewMathParser() parser.InlineParser {
+	return defaultMathParser
+}
+
+func (s *mathParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (s *mathParser) Parse(parent ast.Node, block text.Reader, pc parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if bytes.HasPrefix(line, dollars) {
+		end := bytes.Index(line[len(dollars):], dollars)
+		if end <= 0 {
+			return nil
+		}
+		value := line[len(dollars) : len(dollars)+end]
+		if util.IsBlank(value) {
+			return nil
+		}
+		block.Advance(end + 2*len(dollars))
+		return NewMath(append([]byte{}, value...), true)
+	}
+
+	// Like pandoc, the opening $ must be followed and the closing $ preceded by a non space
+	// character, and the closing $ must not be followed by a digit, so that amounts of
+	// money aren't taken as formulas. Code spans take precedence over formulas.
+	if len(line) < 3 || util.IsSpace(line[1]) {
+		return nil
+	}
+	for i := 1; i < len(line); i++ {
+		if line[i] == '`' {
+			return nil
+		}
+		if i < 2 || line[i] != '$' {
+			continue
+		}
+		if line[i-1] == '\\' {
+			continue
+		}
+		if util.IsSpace(line[i-1]) || (i+1 < len(line) && line[i+1] >= '0' && line[i+1] <= '9') {
+			return nil
+		}
+		value := line[1:i]
+		block.Advance(i + 1)
+		return NewMath(append([]byte{}, value...), false)
+	}
+	return nil
+}
+
+// mathASTTransformer turns math fenced code blocks into MathBlock nodes.
+type mathASTTransformer struct {
+}
+
+var defaultMathASTTransformer = &mathASTTransformer{}
+
+// NewMathASTTransformer returns a new parser.ASTTransformer that turns
+// ```math fenced code blocks into formulas.
+func NewMathASTTransformer() parser.ASTTransformer {
+	return defaultMathASTTransformer
+}
+
+func (a *mathASTTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	for _, block := range fencedCodeBlocksByLanguage(node, reader.Source(), "math") {
+		math := NewMathBlock()
+		math.SetLines(block.Lines())
+		block.Parent().ReplaceChild(block.Parent(), block, math)
+	}
+}
+
+// fencedCodeBlocksByLanguage returns the fenced code blocks of a document in the given language.
+func fencedCodeBlocksByLanguage(node *ast.Document, source []byte, language string) []*ast.FencedCodeBlock {
+	var blocks []*ast.FencedCodeBlock
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if block, ok := n.(*ast.FencedCodeBlock); ok && bytes.Equal(block.Language(source), []byte(language)) {
+			blocks = append(blocks, block)
+		}
+		return ast.WalkContinue, nil
+	})
+	return blocks
+}
+
+// MathHTMLRenderer is a renderer.NodeRenderer implementation that
+// renders formulas as TeX which is typeset in the browser.
+type MathHTMLRenderer struct {
+	html.Config
+}
+
+// NewMathHTMLRenderer returns a new MathHTMLRenderer.
+func NewMathHTMLRenderer(opts ...html.Option) renderer.NodeRenderer {
+	r := &MathHTMLRenderer{
+		Config: html.NewConfig(),
+	}
+	for _, opt := range opts {
+		opt.SetHTMLOption(&r.Config)
+	}
+	return r
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *MathHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindMath, r.renderMath)
+	reg.Register(KindMathBlock, r.renderMathBlock)
+}
+
+func (r *MathHTMLRenderer) renderMath(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*Math)
+	if n.Display {
+		_, _ = w.WriteString(`<code class="math-display">`)
+	} else {
+		_, _ = w.WriteString(`<code class="math-inline">`)
+	}
+	_, _ = w.Write(util.EscapeHTML(n.Value))
+	_, _ = w.WriteString(`</code>`)
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *MathHTMLRenderer) renderMathBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString(`<pre class="math-display">`)
+		writeRawLines(w, source, node)
+	} else {
+		_, _ = w.WriteString("</pre>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+// writeRawLines writes the escaped lines of a raw block.
+func writeRawLines(w util.BufWriter, source []byte, node ast.Node) {
+	l := node.Lines().Len()
+	for i := 0; i < l; i++ {
+		line := node.Lines().At(i)
+		_, _ = w.Write(util.EscapeHTML(line.Value(source)))
+	}
+}
+
+type mathExtension struct{}
+
+// MathExtension represents the Gitea Math extension
+var MathExtension = &mathExtension{}
+
+// Extend extends the markdown converter with the Gitea Math parsers
+func (e *mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(
+			util.Prioritized(NewMathBlockParser(), 701),
+		),
+		parser.WithInlineParsers(
+			util.Prioritized(NewMathParser(), 501),
+		),
+		parser.WithASTTransformers(
+			util.Prioritized(NewMathASTTransformer(), 999),
+		),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(NewMathHTMLRenderer(), 500),
+	))
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package common
+
+import (
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// KindMermaid is a NodeKind of the Mermaid node.
+var KindMermaid = ast.NewNodeKind("Mermaid")
+
+// Mermaid is a diagram written in a mermaid fenced code block.
+type Mermaid struct {
+	ast.BaseBlock
+}
+
+// Dump implements Node.Dump.
+func (n *Mermaid) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, nil, nil)
+}
+
+// Kind implements Node.Kind.
+func (n *Mermaid) Kind() ast.NodeKind {
+	return KindMermaid
+}
+
+// IsRaw implements Node.IsRaw.
+func (n *Mermaid) IsRaw() bool {
+	return true
+}
+
+// NewMermaid returns a new Mermaid node.
+func NewMermaid() *Mermaid {
+	return &Mermaid{}
+}
+
+type mermaidASTTransformer struct {
+}
+
+var defaultMermaidASTTransformer = &mermaidASTTransformer{}
+
+// NewMermaidASTTransformer returns a new parser.ASTTransformer that turns
+// ```mermaid fenced code blocks into diagrams.
+func NewMermaidASTTransformer() parser.ASTTransformer {
+	return defaultMermaidASTTransformer
+}
+
+func (a *mermaidASTTransformer) Transform(node *ast.Document, reader text.Reader, pc parser.Context) {
+	for _, block := range fencedCodeBlocksByLanguage(node, reader.Source(), "mermaid") {
+		diagram := NewMermaid()
+		diagram.SetLines(block.Lines())
+		block.Parent().ReplaceChild(block.Parent(), block, diagram)
+	}
+}
+
+// MermaidHTMLRenderer is a renderer.NodeRenderer implementation that
+// renders the source of diagrams which are drawn in the browser.
+type MermaidHTMLRenderer struct {
+	html.Config
+}
+
+// NewMermaidHTMLRenderer returns a new MermaidHTMLRenderer.
+func NewMermaidHTMLRenderer(opts ...html.Option) renderer.NodeRenderer {
+	r := &MermaidHTMLRenderer{
+		Config: html.NewConfig(),
+	}
+	for _, opt := range opts {
+		opt.SetHTMLOption(&r.Config)
+	}
+	return r
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (r *MermaidHTMLRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(KindMermaid, r.renderMermaid)
+}
+
+func (r *MermaidHTMLRenderer) renderMermaid(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString(`<pre class="mermaid">`)
+		writeRawLines(w, source, node)
+	} else {
+		_, _ = w.WriteString("</pre>\n")
+	}
+	return ast.WalkContinue, nil
+}
+
+type mermaidExtension struct{}
+
+// MermaidExtension represents the Gitea Mermaid extension
+var MermaidExtension = &mermaidExtension{}
+
+// Extend extends the markdown converter with the Gitea Mermaid diagrams
+func (e *mermaidExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithASTTransformers(
+			util.Prioritized(NewMermaidASTTransformer(), 999),
+		),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(NewMermaidHTMLRenderer(), 500),
+	))
+}
@@ -0,0 +1,273 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package jupyter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/highlight"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/markup"
+	"code.gitea.io/gitea/modules/markup/markdown"
+)
+
+func init() {
+	markup.RegisterParser(Parser{})
+}
+
+// Parser implements markup.Parser for Jupyter notebooks
+type Parser struct{}
+
+// Name implements markup.Parser
+func (Parser) Name() string {
+	return "jupyter"
+}
+
+// Extensions implements markup.Parser
+func (Parser) Extensions() []string {
+	return []string{".ipynb"}
+}
+
+// EmbedsDataURIImages implements markup.DataURIImagesParser, images of outputs and
+// attachments are embedded in notebooks
+func (Parser) EmbedsDataURIImages() bool {
+	return true
+}
+
+// multilineString is a string which notebooks store either as a string or as a list of lines.
+type multilineString string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (s *multilineString) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*s = multilineString(strings.Join(lines, ""))
+		return nil
+	}
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	*s = multilineString(str)
+	return nil
+}
+
+// Notebook is a Jupyter notebook in nbformat 4
+type Notebook struct {
+	Metadata struct {
+		KernelSpec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+	NBFormat int     `json:"nbformat"`
+	Cells    []*Cell `json:"cells"`
+}
+
+// Language returns the language of the code cells
+func (nb *Notebook) Language() string {
+	if len(nb.Metadata.LanguageInfo.Name) > 0 {
+		return nb.Metadata.LanguageInfo.Name
+	}
+	if len(nb.Metadata.KernelSpec.Language) > 0 {
+		return nb.Metadata.KernelSpec.Language
+	}
+	return "python"
+}
+
+// Cell is a markdown, code or raw cell of a notebook
+type Cell struct {
+	CellType       string                                `json:"cell_type"`
+	Source         multilineString                       `json:"source"`
+	ExecutionCount *int                                  `json:"execution_count"`
+	Outputs        []*Output                             `json:"outputs"`
+	Attachments    map[string]map[string]multilineString `json:"attachments"`
+}
+
+// Output is an output of a code cell
+type Output struct {
+	OutputType     string                     `json:"output_type"`
+	Name           string                     `json:"name"`
+	Text           multilineString            `json:"text"`
+	Data           map[string]json.RawMessage `json:"data"`
+	ExecutionCount *int                       `json:"execution_count"`
+	EName          string                     `json:"ename"`
+	EValue         string                     `json:"evalue"`
+	Traceback      []string                   `json:"traceback"`
+}
+
+// imageTypes are the image formats of outputs and attachments which can be shown inline
+var imageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// ansiEscape matches the colors of tracebacks
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+type renderer struct {
+	buf       bytes.Buffer
+	language  string
+	urlPrefix string
+	isWiki    bool
+}
+
+// Render implements markup.Parser
+func (Parser) Render(rawBytes []byte, urlPrefix string, metas map[string]string, isWiki bool) []byte {
+	var nb Notebook
+	if err := json.Unmarshal(rawBytes, &nb); err != nil || nb.NBFormat < 4 {
+		if err != nil {
+			log.Warn("Unable to parse notebook: %v", err)
+		}
+		return []byte("<pre>" + html.EscapeString(string(rawBytes)) + "</pre>")
+	}
+
+	r := &renderer{
+		language:  nb.Language(),
+		urlPrefix: urlPrefix,
+		isWiki:    isWiki,
+	}
+	r.buf.WriteString(`<div class="jupyter-notebook">`)
+	for _, cell := range nb.Cells {
+		r.renderCell(cell)
+	}
+	r.buf.WriteString(`</div>`)
+	return r.buf.Bytes()
+}
+
+func (r *renderer) renderCell(cell *Cell) {
+	source := string(cell.Source)
+	switch cell.CellType {
+	case "markdown":
+		r.buf.WriteString(`<div class="jupyter-markdown">`)
+		r.renderMarkdown(attachmentsToDataURIs(source, cell.Attachments))
+		r.buf.WriteString(`</div>`)
+	case "code":
+		r.buf.WriteString(`<div class="jupyter-code"><div class="jupyter-input">`)
+		r.renderPrompt("In", cell.ExecutionCount)
+		r.buf.WriteString(`<pre class="chroma">`)
+		r.buf.WriteString(highlight.Code("", r.language, source))
+		r.buf.WriteString(`</pre></div>`)
+		for _, output := range cell.Outputs {
+			r.renderOutput(output)
+		}
+		r.buf.WriteString(`</div>`)
+	default:
+		r.buf.WriteString(`<div class="jupyter-raw"><pre>`)
+		r.buf.WriteString(html.EscapeString(source))
+		r.buf.WriteString(`</pre></div>`)
+	}
+}
+
+func (r *renderer) renderPrompt(prefix string, executionCount *int) {
+	r.buf.WriteString(`<div class="jupyter-prompt">`)
+	if executionCount != nil {
+		r.buf.WriteString(fmt.Sprintf("%s [%d]:", prefix, *executionCount))
+	} else if prefix == "In" {
+		r.buf.WriteString("In [ ]:")
+	}
+	r.buf.WriteString(`</div>`)
+}
+
+// renderMarkdown renders a markdown cell or output, it is sanitized with the rest of the notebook
+func (r *renderer) renderMarkdown(source string) {
+	r.buf.Write(markdown.RenderRawUnsanitized([]byte(source), r.urlPrefix, r.isWiki))
+}
+
+func (r *renderer) renderOutput(output *Output) {
+	r.buf.WriteString(`<div class="jupyter-output">`)
+	switch output.OutputType {
+	case "stream":
+		r.renderPrompt("", nil)
+		class := "jupyter-stream"
+		if output.Name == "stderr" {
+			class = "jupyter-stderr"
+		}
+		r.buf.WriteString(`<div class="` + class + `"><pre>`)
+		r.buf.WriteString(html.EscapeString(string(output.Text)))
+		r.buf.WriteString(`</pre></div>`)
+	case "execute_result", "display_data":
+		if output.OutputType == "execute_result" {
+			r.renderPrompt("Out", output.ExecutionCount)
+		} else {
+			r.renderPrompt("", nil)
+		}
+		r.renderData(output.Data)
+	case "error":
+		r.renderPrompt("", nil)
+		traceback := strings.Join(output.Traceback, "\n")
+		if len(traceback) == 0 {
+			traceback = output.EName + ": " + output.EValue
+		}
+		r.buf.WriteString(`<div class="jupyter-error"><pre>`)
+		r.buf.WriteString(html.EscapeString(ansiEscape.ReplaceAllString(traceback, "")))
+		r.buf.WriteString(`</pre></div>`)
+	}
+	r.buf.WriteString(`</div>`)
+}
+
+// renderData renders the richest representation of an output which can be shown.
+func (r *renderer) renderData(data map[string]json.RawMessage) {
+	if value, ok := dataString(data, "text/html"); ok {
+		r.buf.WriteString(`<div class="jupyter-html">`)
+		r.buf.WriteString(value)
+		r.buf.WriteString(`</div>`)
+		return
+	}
+	for _, tp := range imageTypes {
+		if value, ok := dataString(data, tp); ok {
+			r.buf.WriteString(`<img src="`)
+			r.buf.WriteString(dataURI(tp, value))
+			r.buf.WriteString(`" alt="">`)
+			return
+		}
+	}
+	if value, ok := dataString(data, "text/markdown"); ok {
+		r.buf.WriteString(`<div class="jupyter-markdown">`)
+		r.renderMarkdown(value)
+		r.buf.WriteString(`</div>`)
+		return
+	}
+	if value, ok := dataString(data, "text/plain"); ok {
+		r.buf.WriteString(`<div class="jupyter-stream"><pre>`)
+		r.buf.WriteString(html.EscapeString(value))
+		r.buf.WriteString(`</pre></div>`)
+	}
+}
+
+func dataString(data map[string]json.RawMessage, tp string) (string, bool) {
+	raw, ok := data[tp]
+	if !ok {
+		return "", false
+	}
+	var value multilineString
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", false
+	}
+	return string(value), true
+}
+
+func dataURI(tp, base64 string) string {
+	// base64 data is often split into lines
+	return "data:" + tp + ";base64," + strings.Join(strings.Fields(base64), "")
+}
+
+// attachmentsToDataURIs replaces the references to images attached to a markdown cell
+// by their content.
+func attachmentsToDataURIs(source string, attachments map[string]map[string]multilineString) string {
+	for name, data := range attachments {
+		for _, tp := range imageTypes {
+			if value, ok := data[tp]; ok {
+				source = strings.Replace(source, "attachment:"+name, dataURI(tp, string(value)), -1)
+				break
+			}
+		}
+	}
+	return source
+}
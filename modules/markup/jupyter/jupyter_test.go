@@ -0,0 +1,82 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package jupyter
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/markup"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const png = "iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg=="
+
+const notebook = `{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "metadata": {},
+   "source": ["# Title\n", "\n", "Some *text* ![plot](attachment:plot.png)"],
+   "attachments": {"plot.png": {"image/png": "` + png + `"}}
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 1,
+   "metadata": {},
+   "source": "import os\nprint(\"<b>\")",
+   "outputs": [
+    {"output_type": "stream", "name": "stdout", "text": ["<b>\n"]},
+    {"output_type": "execute_result", "execution_count": 1, "metadata": {},
+     "data": {"text/plain": ["'a'"], "text/html": ["<table><tr><td onclick=\"alert(1)\">a</td></tr></table>"]}},
+    {"output_type": "display_data", "metadata": {}, "data": {"text/plain": ["<Figure>"], "image/png": "` + png + `\n"}},
+    {"output_type": "error", "ename": "ValueError", "evalue": "bad", "traceback": ["\u001b[0;31mValueError\u001b[0m: bad"]}
+   ]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": null,
+   "metadata": {},
+   "source": [],
+   "outputs": []
+  }
+ ],
+ "metadata": {"kernelspec": {"language": "python", "name": "python3"}, "language_info": {"name": "python"}},
+ "nbformat": 4,
+ "nbformat_minor": 2
+}`
+
+func TestRender(t *testing.T) {
+	markup.Init()
+	res := markup.Render("test.ipynb", []byte(notebook), "/user2/repo1/src/branch/master", nil)
+	rendered := string(res)
+
+	// markdown cells
+	assert.Contains(t, rendered, `<div class="jupyter-markdown"><h1 id="user-content-title">Title</h1>`)
+	assert.Contains(t, rendered, `<em>text</em> <a href="data:image/png;base64,`+png+`" rel="nofollow"><img src="data:image/png;base64,`+png+`" alt="plot"/></a>`)
+
+	// code cells are highlighted
+	assert.Contains(t, rendered, `<div class="jupyter-prompt">In [1]:</div><pre class="chroma"><span class="kn">import</span> <span class="nn">os</span>`)
+	assert.Contains(t, rendered, `<span class="s2">&#34;&lt;b&gt;&#34;</span>`)
+	assert.Contains(t, rendered, `<div class="jupyter-prompt">In [ ]:</div>`)
+
+	// outputs
+	assert.Contains(t, rendered, `<div class="jupyter-stream"><pre>&lt;b&gt;`)
+	assert.Contains(t, rendered, `<div class="jupyter-prompt">Out [1]:</div><div class="jupyter-html"><table><tbody><tr><td>a</td></tr></tbody></table></div>`)
+	assert.Contains(t, rendered, `<div class="jupyter-output"><div class="jupyter-prompt"></div><img src="data:image/png;base64,`+png+`" alt=""/></div>`)
+	assert.Contains(t, rendered, `<div class="jupyter-error"><pre>ValueError: bad</pre></div>`)
+	assert.NotContains(t, rendered, "onclick")
+	assert.NotContains(t, rendered, "&lt;Figure&gt;")
+
+	// data URIs are only kept in the output of notebooks
+	res = markup.Render("test.md", []byte("![plot](data:image/png;base64,"+png+")"), "/user2/repo1/src/branch/master", nil)
+	assert.NotContains(t, string(res), "data:")
+}
+
+func TestRender_Invalid(t *testing.T) {
+	var parser Parser
+	assert.Equal(t, "<pre>{&#34;cells&#34;: &lt;</pre>", string(parser.Render([]byte(`{"cells": <`), "", nil, false)))
+	assert.Equal(t, "<pre>{&#34;nbformat&#34;: 3}</pre>", string(parser.Render([]byte(`{"nbformat": 3}`), "", nil, false)))
+}
@@ -39,6 +39,12 @@ func NewGiteaParseContext(urlPrefix string, isWiki bool) parser.Context {
 
 // RenderRaw renders Markdown to HTML without handling special links.
 func RenderRaw(body []byte, urlPrefix string, wikiMarkdown bool) []byte {
+	return markup.SanitizeBytes(RenderRawUnsanitized(body, urlPrefix, wikiMarkdown))
+}
+
+// RenderRawUnsanitized renders Markdown to HTML like RenderRaw but does not sanitize the result,
+// it is meant for parsers which embed Markdown in their output and sanitize the whole output.
+func RenderRawUnsanitized(body []byte, urlPrefix string, wikiMarkdown bool) []byte {
 	once.Do(func() {
 		converter = goldmark.New(
 			goldmark.WithExtensions(extension.Table,
@@ -46,6 +52,8 @@ func RenderRaw(body []byte, urlPrefix string, wikiMarkdown bool) []byte {
 				extension.TaskList,
 				extension.DefinitionList,
 				common.FootnoteExtension,
+				common.MathExtension,
+				common.MermaidExtension,
 				extension.NewTypographer(
 					extension.WithTypographicSubstitutions(extension.TypographicSubstitutions{
 						extension.EnDash: nil,
@@ -83,7 +91,7 @@ func RenderRaw(body []byte, urlPrefix string, wikiMarkdown bool) []byte {
 		log.Error("Unable to render: %v", err)
 	}
 
-	return markup.SanitizeReader(&buf).Bytes()
+	return buf.Bytes()
 }
 
 var (
@@ -296,3 +296,42 @@ func TestRender_RenderParagraphs(t *testing.T) {
 	test(t, "A\n\nB\nC\n", 2)
 	test(t, "A\n\n\nB\nC\n", 2)
 }
+
+func TestRender_Math(t *testing.T) {
+	setting.AppURL = AppURL
+	setting.AppSubURL = AppSubURL
+
+	test := func(input, expected string) {
+		buffer := RenderString(input, setting.AppSubURL, localMetas)
+		assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(buffer))
+		bufferWiki := RenderWiki([]byte(input), setting.AppSubURL, localMetas)
+		assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(bufferWiki))
+	}
+
+	// inline
+	test(`$x^2 < 1$ and $$\frac{1}{2}$$`,
+		`<p><code class="math-inline">x^2 &lt; 1</code> and <code class="math-display">\frac{1}{2}</code></p>`)
+	test(`see issue $#1$`, `<p>see issue <code class="math-inline">#1</code></p>`)
+	test("between $5 and $6, `$x$` or \\$y$", "<p>between $5 and $6, <code>$x$</code> or $y$</p>")
+	test("$ x$ and $x $", "<p>$ x$ and $x $</p>")
+
+	// blocks
+	test("$$\n\\sum_{i=0}^n i\n<script>\n$$\nafter", "<pre class=\"math-display\">\\sum_{i=0}^n i\n&lt;script&gt;\n</pre>\n<p>after</p>")
+	test("before\n$$ a^2 + b^2 $$", "<p>before</p>\n<pre class=\"math-display\"> a^2 + b^2 </pre>")
+	test("```math\ne^{i\\pi} + 1 = 0\n```", "<pre class=\"math-display\">e^{i\\pi} + 1 = 0\n</pre>")
+	test("> $$\n> q\n> $$", "<blockquote>\n<pre class=\"math-display\">q\n</pre>\n</blockquote>")
+}
+
+func TestRender_Mermaid(t *testing.T) {
+	setting.AppURL = AppURL
+	setting.AppSubURL = AppSubURL
+
+	test := func(input, expected string) {
+		buffer := RenderString(input, setting.AppSubURL, localMetas)
+		assert.Equal(t, strings.TrimSpace(expected), strings.TrimSpace(buffer))
+	}
+
+	test("```mermaid\ngraph TD;\n  A-->B;\n  B-->C[#1 <b>];\n```",
+		"<pre class=\"mermaid\">graph TD;\n  A--&gt;B;\n  B--&gt;C[#1 &lt;b&gt;];\n</pre>")
+	test("```go\nfunc main()\n```", "<pre><code class=\"language-go\">func main()\n</code></pre>")
+}
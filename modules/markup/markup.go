@@ -36,6 +36,13 @@ type Parser interface {
 	Render(rawBytes []byte, urlPrefix string, metas map[string]string, isWiki bool) []byte
 }
 
+// DataURIImagesParser is implemented by parsers which embed images as base64 encoded data URIs
+// in their output, e.g. the plots of Jupyter notebooks. Data URIs are removed from the output
+// of all other parsers.
+type DataURIImagesParser interface {
+	EmbedsDataURIImages() bool
+}
+
 var (
 	extParsers = make(map[string]Parser)
 	parsers    = make(map[string]Parser)
@@ -88,6 +95,9 @@ func render(parser Parser, rawBytes []byte, urlPrefix string, metas map[string]s
 	if err != nil {
 		log.Error("PostProcess: %v", err)
 	}
+	if p, ok := parser.(DataURIImagesParser); ok && p.EmbedsDataURIImages() {
+		return sanitizeBytesWithDataURIImages(result)
+	}
 	return SanitizeBytes(result)
 }
 
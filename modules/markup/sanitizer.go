@@ -9,8 +9,10 @@ import (
 	"bytes"
 	"io"
 	"regexp"
+	"strings"
 	"sync"
 
+	"code.gitea.io/gitea/modules/highlight"
 	"code.gitea.io/gitea/modules/setting"
 
 	"github.com/microcosm-cc/bluemonday"
@@ -19,8 +21,9 @@ import (
 // Sanitizer is a protection wrapper of *bluemonday.Policy which does not allow
 // any modification to the underlying policies once it's been created.
 type Sanitizer struct {
-	policy *bluemonday.Policy
-	init   sync.Once
+	policy              *bluemonday.Policy
+	dataURIImagesPolicy *bluemonday.Policy
+	init                sync.Once
 }
 
 var sanitizer = &Sanitizer{}
@@ -36,31 +39,47 @@ func NewSanitizer() {
 
 // ReplaceSanitizer replaces the current sanitizer to account for changes in settings
 func ReplaceSanitizer() {
-	sanitizer.policy = bluemonday.UGCPolicy()
-	// We only want to allow HighlightJS specific classes for code blocks
-	sanitizer.policy.AllowAttrs("class").Matching(regexp.MustCompile(`^language-\w+$`)).OnElements("code")
+	sanitizer.policy = createPolicy()
+
+	// Images embedded in notebooks, e.g. plots, are base64 encoded. Data URIs are only
+	// kept in the output of the parsers which embed images themselves.
+	sanitizer.dataURIImagesPolicy = createPolicy()
+	sanitizer.dataURIImagesPolicy.AllowDataURIImages()
+}
+
+func createPolicy() *bluemonday.Policy {
+	policy := bluemonday.UGCPolicy()
+	// We only want to allow HighlightJS specific classes for code blocks, and formulas
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^(language-\w+|math-inline|math-display)$`)).OnElements("code")
+
+	// Code highlighted on the server, and formulas and diagrams rendered in the browser
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^(chroma|math-display|mermaid)$`)).OnElements("pre")
 
 	// Checkboxes
-	sanitizer.policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
-	sanitizer.policy.AllowAttrs("checked", "disabled").OnElements("input")
+	policy.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	policy.AllowAttrs("checked", "disabled").OnElements("input")
 
 	// Custom URL-Schemes
-	sanitizer.policy.AllowURLSchemes(setting.Markdown.CustomURLSchemes...)
+	policy.AllowURLSchemes(setting.Markdown.CustomURLSchemes...)
+
+	// Allow keyword markup and tokens highlighted on the server
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^(` + keywordClass + `|` + strings.Join(highlight.TokenClasses(), "|") + `)$`)).OnElements("span")
 
-	// Allow keyword markup
-	sanitizer.policy.AllowAttrs("class").Matching(regexp.MustCompile(`^` + keywordClass + `$`)).OnElements("span")
+	// Jupyter notebook cells and outputs
+	policy.AllowAttrs("class").Matching(regexp.MustCompile(`^jupyter-[a-z]+$`)).OnElements("div")
 
 	// Allow <kbd> tags for keyboard shortcut styling
-	sanitizer.policy.AllowElements("kbd")
+	policy.AllowElements("kbd")
 
 	// Custom keyword markup
 	for _, rule := range setting.ExternalSanitizerRules {
 		if rule.Regexp != nil {
-			sanitizer.policy.AllowAttrs(rule.AllowAttr).Matching(rule.Regexp).OnElements(rule.Element)
+			policy.AllowAttrs(rule.AllowAttr).Matching(rule.Regexp).OnElements(rule.Element)
 		} else {
-			sanitizer.policy.AllowAttrs(rule.AllowAttr).OnElements(rule.Element)
+			policy.AllowAttrs(rule.AllowAttr).OnElements(rule.Element)
 		}
 	}
+	return policy
 }
 
 // Sanitize takes a string that contains a HTML fragment or document and applies policy whitelist.
@@ -84,3 +103,12 @@ func SanitizeBytes(b []byte) []byte {
 	NewSanitizer()
 	return sanitizer.policy.SanitizeBytes(b)
 }
+
+// sanitizeBytesWithDataURIImages is SanitizeBytes but keeps images embedded as base64 encoded data URIs.
+func sanitizeBytesWithDataURIImages(b []byte) []byte {
+	if len(b) == 0 {
+		return b
+	}
+	NewSanitizer()
+	return sanitizer.dataURIImagesPolicy.SanitizeBytes(b)
+}
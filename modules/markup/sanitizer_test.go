@@ -38,6 +38,25 @@ func Test_Sanitizer(t *testing.T) {
 
 		// <kbd> tags
 		`<kbd>Ctrl + C</kbd>`, `<kbd>Ctrl + C</kbd>`,
+
+		// Code highlighted on the server
+		`<pre class="chroma"><span class="kn">package</span> <span class="ui">main</span></pre>`, `<pre class="chroma"><span class="kn">package</span> <span>main</span></pre>`,
+		`<pre class="ui chroma"></pre>`, `<pre></pre>`,
+
+		// Formulas and diagrams
+		`<code class="math-inline">x</code>`, `<code class="math-inline">x</code>`,
+		`<code class="math-inline ui">x</code>`, `<code>x</code>`,
+		`<pre class="math-display">x</pre>`, `<pre class="math-display">x</pre>`,
+		`<pre class="mermaid">graph TD;</pre>`, `<pre class="mermaid">graph TD;</pre>`,
+		`<div class="mermaid">graph TD;</div>`, `<div>graph TD;</div>`,
+
+		// Jupyter notebooks
+		`<div class="jupyter-output"></div>`, `<div class="jupyter-output"></div>`,
+		`<div class="jupyter-output ui"></div>`, `<div></div>`,
+
+		// Data URIs are only kept for parsers which embed images
+		`<img src="data:image/png;base64,iVBORw0KGgo=">`, ``,
+		`<a href="data:image/png;base64,iVBORw0KGgo=">x</a>`, `x`,
 	}
 
 	for i := 0; i < len(testCases); i += 2 {
@@ -45,3 +64,18 @@ func Test_Sanitizer(t *testing.T) {
 		assert.Equal(t, testCases[i+1], string(SanitizeBytes([]byte(testCases[i]))))
 	}
 }
+
+func Test_SanitizerWithDataURIImages(t *testing.T) {
+	NewSanitizer()
+	testCases := []string{
+		`<img src="data:image/png;base64,iVBORw0KGgo=">`, `<img src="data:image/png;base64,iVBORw0KGgo=">`,
+		`<img src="data:image/svg+xml;base64,PHN2Zz4=">`, ``,
+		`<img src="data:text/html;base64,PHNjcmlwdD4=">`, ``,
+		`<div class="jupyter-output"></div>`, `<div class="jupyter-output"></div>`,
+		`<a onblur="alert(secret)" href="http://www.google.com">Google</a>`, `<a href="http://www.google.com" rel="nofollow">Google</a>`,
+	}
+
+	for i := 0; i < len(testCases); i += 2 {
+		assert.Equal(t, testCases[i+1], string(sanitizeBytesWithDataURIImages([]byte(testCases[i]))))
+	}
+}
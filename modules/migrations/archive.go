@@ -0,0 +1,152 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+)
+
+// packDirectory writes all regular files below dir into a zip archive
+func packDirectory(w io.Writer, dir string) error {
+	zw := zip.NewWriter(w)
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." || !(info.IsDir() || info.Mode().IsRegular()) {
+			return nil
+		}
+
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+			_, err = zw.CreateHeader(header)
+			return err
+		}
+		header.Method = zip.Deflate
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(fw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// extractArchive extracts a zip archive written by packDirectory into dir
+func extractArchive(archivePath, dir string) error {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		p := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if rel, err := filepath.Rel(dir, p); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid file name %q in archive", f.Name)
+		}
+
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(p, os.ModePerm); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+			return err
+		}
+		if err := extractFile(f, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(f *zip.File, p string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	fw, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.Mode().Perm()|0600)
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+
+	_, err = io.Copy(fw, rc)
+	return err
+}
+
+// DumpRepositoryArchive dumps a repository and writes it as a zip archive to w
+func DumpRepositoryArchive(ctx context.Context, repo *models.Repository, w io.Writer) error {
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "gitea-dump-repo-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			log.Error("RemoveAll %s: %v", tmpDir, err)
+		}
+	}()
+
+	baseDir := filepath.Join(tmpDir, "dump")
+	if err := DumpRepository(ctx, repo, baseDir); err != nil {
+		return err
+	}
+	return packDirectory(w, baseDir)
+}
+
+// RestoreRepositoryArchive restores a repository from a zip archive written by DumpRepositoryArchive
+func RestoreRepositoryArchive(ctx context.Context, doer *models.User, ownerName, repoName, archivePath string) (*models.Repository, error) {
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "gitea-restore-repo-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			log.Error("RemoveAll %s: %v", tmpDir, err)
+		}
+	}()
+
+	if err := extractArchive(archivePath, tmpDir); err != nil {
+		return nil, err
+	}
+	return RestoreRepository(ctx, doer, ownerName, repoName, tmpDir)
+}
@@ -23,6 +23,7 @@ type Downloader interface {
 	GetIssues(page, perPage int) ([]*Issue, bool, error)
 	GetComments(issueNumber int64) ([]*Comment, error)
 	GetPullRequests(page, perPage int) ([]*PullRequest, error)
+	GetReviews(pullRequestNumber int64) ([]*Review, error)
 }
 
 // DownloaderFactory defines an interface to match a downloader implementation and create a downloader
@@ -185,3 +186,19 @@ func (d *RetryDownloader) GetPullRequests(page, perPage int) ([]*PullRequest, er
 	}
 	return nil, err
 }
+
+// GetReviews returns pull requests reviews with retry
+func (d *RetryDownloader) GetReviews(pullRequestNumber int64) ([]*Review, error) {
+	var (
+		times   = d.RetryTimes
+		reviews []*Review
+		err     error
+	)
+	for ; times > 0; times-- {
+		if reviews, err = d.Downloader.GetReviews(pullRequestNumber); err == nil {
+			return reviews, nil
+		}
+		time.Sleep(time.Second * time.Duration(d.RetryDelay))
+	}
+	return nil, err
+}
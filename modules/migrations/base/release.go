@@ -4,31 +4,37 @@
 
 package base
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 // ReleaseAsset represents a release asset
 type ReleaseAsset struct {
-	URL           string
-	Name          string
-	ContentType   *string
-	Size          *int
-	DownloadCount *int
-	Created       time.Time
-	Updated       time.Time
+	URL           string    `yaml:"url"`
+	Name          string    `yaml:"name"`
+	ContentType   *string   `yaml:"content_type"`
+	Size          *int      `yaml:"size"`
+	DownloadCount *int      `yaml:"download_count"`
+	Created       time.Time `yaml:"created"`
+	Updated       time.Time `yaml:"updated"`
+
+	// DownloadFunc is used instead of downloading the URL if it is set
+	DownloadFunc func() (io.ReadCloser, error) `yaml:"-"`
 }
 
 // Release represents a release
 type Release struct {
-	TagName         string
-	TargetCommitish string
-	Name            string
-	Body            string
-	Draft           bool
-	Prerelease      bool
-	PublisherID     int64
-	PublisherName   string
-	PublisherEmail  string
-	Assets          []ReleaseAsset
-	Created         time.Time
-	Published       time.Time
+	TagName         string         `yaml:"tag_name"`
+	TargetCommitish string         `yaml:"target_commitish"`
+	Name            string         `yaml:"name"`
+	Body            string         `yaml:"body"`
+	Draft           bool           `yaml:"draft"`
+	Prerelease      bool           `yaml:"prerelease"`
+	PublisherID     int64          `yaml:"publisher_id"`
+	PublisherName   string         `yaml:"publisher_name"`
+	PublisherEmail  string         `yaml:"publisher_email"`
+	Assets          []ReleaseAsset `yaml:"assets"`
+	Created         time.Time      `yaml:"created"`
+	Published       time.Time      `yaml:"published"`
 }
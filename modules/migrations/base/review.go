@@ -0,0 +1,41 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import "time"
+
+// enumerate all review states
+const (
+	ReviewStatePending          = "PENDING"
+	ReviewStateApproved         = "APPROVED"
+	ReviewStateChangesRequested = "CHANGES_REQUESTED"
+	ReviewStateCommented        = "COMMENTED"
+)
+
+// Review is a standard review information
+type Review struct {
+	IssueIndex   int64            `yaml:"issue_index"`
+	ReviewerID   int64            `yaml:"reviewer_id"`
+	ReviewerName string           `yaml:"reviewer_name"`
+	Official     bool             `yaml:"official"`
+	CommitID     string           `yaml:"commit_id"`
+	Content      string           `yaml:"content"`
+	Created      time.Time        `yaml:"created"`
+	State        string           `yaml:"state"` // PENDING, APPROVED, CHANGES_REQUESTED or COMMENTED
+	Comments     []*ReviewComment `yaml:"comments"`
+}
+
+// ReviewComment represents a code comment of a review
+type ReviewComment struct {
+	Content    string     `yaml:"content"`
+	TreePath   string     `yaml:"tree_path"`
+	DiffHunk   string     `yaml:"diff_hunk"`
+	Line       int64      `yaml:"line"` // - previous line / + proposed line
+	CommitID   string     `yaml:"commit_id"`
+	PosterID   int64      `yaml:"poster_id"`
+	PosterName string     `yaml:"poster_name"`
+	Reactions  *Reactions `yaml:"reactions"`
+	Created    time.Time  `yaml:"created"`
+}
@@ -0,0 +1,322 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/structs"
+
+	gouuid "github.com/satori/go.uuid"
+	"gopkg.in/yaml.v2"
+)
+
+// RepositoryDumpVersion is the version of the repository dump format, it has to be
+// increased whenever the format changes in a way older restorers cannot read
+const RepositoryDumpVersion = 1
+
+var (
+	_ base.Uploader = &RepositoryDumper{}
+)
+
+// RepositoryManifest describes a repository dump and is stored as repo.yml
+type RepositoryManifest struct {
+	Version        int                    `yaml:"version"`
+	GitServiceType structs.GitServiceType `yaml:"git_service_type"`
+	Created        time.Time              `yaml:"created"`
+	Repository     *base.Repository       `yaml:"repository"`
+}
+
+// RepositoryDumper implements an Uploader which writes a repository and its issues,
+// pull requests, reviews, labels, milestones and releases to a directory
+type RepositoryDumper struct {
+	ctx            context.Context
+	baseDir        string
+	gitServiceType structs.GitServiceType
+	files          map[string]*os.File
+}
+
+// NewRepositoryDumper creates a dumper writing to baseDir
+func NewRepositoryDumper(ctx context.Context, baseDir string, gitServiceType structs.GitServiceType) (*RepositoryDumper, error) {
+	if err := os.MkdirAll(baseDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &RepositoryDumper{
+		ctx:            ctx,
+		baseDir:        baseDir,
+		gitServiceType: gitServiceType,
+		files:          make(map[string]*os.File),
+	}, nil
+}
+
+// MaxBatchInsertSize returns the table's max batch insert size
+func (g *RepositoryDumper) MaxBatchInsertSize(tp string) int {
+	return 100
+}
+
+func (g *RepositoryDumper) gitPath() string {
+	return filepath.Join(g.baseDir, "repo.git")
+}
+
+func (g *RepositoryDumper) wikiPath() string {
+	return filepath.Join(g.baseDir, "repo.wiki.git")
+}
+
+// appendYAML appends the items to a yaml list stored in the file with the given name,
+// two yaml lists written one after the other are read back as one list
+func (g *RepositoryDumper) appendYAML(name string, items interface{}) error {
+	f, ok := g.files[name]
+	if !ok {
+		var err error
+		p := filepath.Join(g.baseDir, filepath.FromSlash(name))
+		if err = os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+			return err
+		}
+		f, err = os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		g.files[name] = f
+	}
+
+	bs, err := yaml.Marshal(items)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(bs)
+	return err
+}
+
+// CreateRepo writes the manifest and clones the git data of the repository
+func (g *RepositoryDumper) CreateRepo(repo *base.Repository, opts base.MigrateOptions) error {
+	f, err := os.Create(filepath.Join(g.baseDir, "repo.yml"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err = yaml.NewEncoder(f).Encode(&RepositoryManifest{
+		Version:        RepositoryDumpVersion,
+		GitServiceType: g.gitServiceType,
+		Created:        time.Now().UTC(),
+		Repository:     repo,
+	}); err != nil {
+		return err
+	}
+
+	var remoteAddr = repo.CloneURL
+	if len(opts.AuthUsername) > 0 {
+		u, err := url.Parse(repo.CloneURL)
+		if err != nil {
+			return err
+		}
+		u.User = url.UserPassword(opts.AuthUsername, opts.AuthPassword)
+		remoteAddr = u.String()
+	}
+
+	migrateTimeout := time.Duration(setting.Git.Timeout.Migrate) * time.Second
+	if err = git.Clone(remoteAddr, g.gitPath(), git.CloneRepoOptions{
+		Mirror:  true,
+		Quiet:   true,
+		Timeout: migrateTimeout,
+	}); err != nil {
+		return fmt.Errorf("Clone: %v", err)
+	}
+
+	if opts.Wiki {
+		wikiRemotePath := strings.TrimSuffix(remoteAddr, ".git") + ".wiki.git"
+		if git.IsRepoURLAccessible(wikiRemotePath) {
+			if err = git.Clone(wikiRemotePath, g.wikiPath(), git.CloneRepoOptions{
+				Mirror:  true,
+				Quiet:   true,
+				Timeout: migrateTimeout,
+				Branch:  "master",
+			}); err != nil {
+				log.Warn("Clone wiki: %v", err)
+				if err := os.RemoveAll(g.wikiPath()); err != nil {
+					return fmt.Errorf("Failed to remove %s: %v", g.wikiPath(), err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Close closes this uploader
+func (g *RepositoryDumper) Close() {
+	for name, f := range g.files {
+		if err := f.Close(); err != nil {
+			log.Error("Close %s: %v", name, err)
+		}
+	}
+	g.files = make(map[string]*os.File)
+}
+
+// CreateTopics creates topics
+func (g *RepositoryDumper) CreateTopics(topics ...string) error {
+	return g.appendYAML("topics.yml", topics)
+}
+
+// CreateMilestones creates milestones
+func (g *RepositoryDumper) CreateMilestones(milestones ...*base.Milestone) error {
+	return g.appendYAML("milestones.yml", milestones)
+}
+
+// CreateLabels creates labels
+func (g *RepositoryDumper) CreateLabels(labels ...*base.Label) error {
+	return g.appendYAML("labels.yml", labels)
+}
+
+// CreateReleases creates releases, the release assets are stored in release_assets
+// and their URL is replaced by the path relative to the dump directory
+func (g *RepositoryDumper) CreateReleases(releases ...*base.Release) error {
+	for _, release := range releases {
+		for i, asset := range release.Assets {
+			assetPath := path.Join("release_assets", gouuid.NewV4().String())
+			if err := g.downloadAsset(asset, assetPath); err != nil {
+				return fmt.Errorf("download asset %s of release %s: %v", asset.Name, release.TagName, err)
+			}
+			release.Assets[i].URL = assetPath
+		}
+	}
+	return g.appendYAML("releases.yml", releases)
+}
+
+func (g *RepositoryDumper) downloadAsset(asset base.ReleaseAsset, assetPath string) error {
+	var rc io.ReadCloser
+	if asset.DownloadFunc != nil {
+		var err error
+		rc, err = asset.DownloadFunc()
+		if err != nil {
+			return err
+		}
+	} else {
+		resp, err := http.Get(asset.URL)
+		if err != nil {
+			return err
+		}
+		rc = resp.Body
+	}
+	defer rc.Close()
+
+	localPath := filepath.Join(g.baseDir, filepath.FromSlash(assetPath))
+	if err := os.MkdirAll(filepath.Dir(localPath), os.ModePerm); err != nil {
+		return err
+	}
+	fw, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	defer fw.Close()
+
+	_, err = io.Copy(fw, rc)
+	return err
+}
+
+// SyncTags syncs releases with tags, there is nothing to do for a dump
+func (g *RepositoryDumper) SyncTags() error {
+	return nil
+}
+
+// CreateIssues creates issues
+func (g *RepositoryDumper) CreateIssues(issues ...*base.Issue) error {
+	return g.appendYAML("issues.yml", issues)
+}
+
+// CreateComments creates comments of issues, they are grouped by issue in the comments directory
+func (g *RepositoryDumper) CreateComments(comments ...*base.Comment) error {
+	var commentsMap = make(map[int64][]*base.Comment, len(comments))
+	for _, comment := range comments {
+		commentsMap[comment.IssueIndex] = append(commentsMap[comment.IssueIndex], comment)
+	}
+	for issueIndex, cs := range commentsMap {
+		if err := g.appendYAML(fmt.Sprintf("comments/%d.yml", issueIndex), cs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreatePullRequests creates pull requests, the head commits are part of the git data
+// so the patch URL is not kept
+func (g *RepositoryDumper) CreatePullRequests(prs ...*base.PullRequest) error {
+	for _, pr := range prs {
+		pr.PatchURL = ""
+	}
+	return g.appendYAML("pull_requests.yml", prs)
+}
+
+// CreateReviews create pull request reviews, they are grouped by pull request in the reviews directory
+func (g *RepositoryDumper) CreateReviews(reviews ...*base.Review) error {
+	var reviewsMap = make(map[int64][]*base.Review, len(reviews))
+	for _, review := range reviews {
+		reviewsMap[review.IssueIndex] = append(reviewsMap[review.IssueIndex], review)
+	}
+	for issueIndex, rs := range reviewsMap {
+		if err := g.appendYAML(fmt.Sprintf("reviews/%d.yml", issueIndex), rs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback when dumping failed, this removes the dump directory
+func (g *RepositoryDumper) Rollback() error {
+	g.Close()
+	return os.RemoveAll(g.baseDir)
+}
+
+// DumpRepository dumps a repository of this instance with all its issues, pull requests,
+// reviews, labels, milestones, releases and wiki into baseDir
+func DumpRepository(ctx context.Context, repo *models.Repository, baseDir string) error {
+	gitServiceType := repo.OriginalServiceType
+	if gitServiceType == structs.NotMigrated {
+		gitServiceType = structs.GiteaService
+	}
+
+	uploader, err := NewRepositoryDumper(ctx, baseDir, gitServiceType)
+	if err != nil {
+		return err
+	}
+
+	downloader := NewGiteaLocalDownloader(repo)
+	downloader.SetContext(ctx)
+
+	opts := base.MigrateOptions{
+		RepoName:       repo.Name,
+		Description:    repo.Description,
+		Private:        repo.IsPrivate,
+		Wiki:           repo.HasWiki(),
+		Issues:         true,
+		Milestones:     true,
+		Labels:         true,
+		Releases:       true,
+		Comments:       true,
+		PullRequests:   true,
+		GitServiceType: gitServiceType,
+	}
+
+	if err := migrateRepository(downloader, uploader, opts); err != nil {
+		if err1 := uploader.Rollback(); err1 != nil {
+			log.Error("rollback failed: %v", err1)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/structs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpRestoreRepository(t *testing.T) {
+	models.PrepareTestEnv(t)
+
+	var (
+		ctx  = graceful.GetManager().HammerContext()
+		user = models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+		repo = models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	)
+
+	// the release asset of the fixtures has no file yet
+	attach := models.AssertExistsAndLoadBean(t, &models.Attachment{ID: 9}).(*models.Attachment)
+	_, err := storage.Attachments.Save(attach.RelativePath(), strings.NewReader("release asset"))
+	assert.NoError(t, err)
+
+	tmpDir, err := ioutil.TempDir("", "gitea-dump-repo-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	dumpDir := filepath.Join(tmpDir, "dump")
+	assert.NoError(t, DumpRepository(ctx, repo, dumpDir))
+	for _, name := range []string{"repo.yml", "repo.git", "repo.wiki.git", "labels.yml", "milestones.yml", "releases.yml", "issues.yml", "pull_requests.yml"} {
+		_, err := os.Stat(filepath.Join(dumpDir, name))
+		assert.NoError(t, err, name)
+	}
+
+	restorer, err := NewRepositoryRestorer(ctx, dumpDir)
+	assert.NoError(t, err)
+	assert.EqualValues(t, RepositoryDumpVersion, restorer.Manifest().Version)
+	assert.EqualValues(t, structs.GiteaService, restorer.Manifest().GitServiceType)
+	assert.EqualValues(t, "repo1", restorer.Manifest().Repository.Name)
+
+	issues, isEnd, err := restorer.GetIssues(1, 100)
+	assert.NoError(t, err)
+	assert.True(t, isEnd)
+	assert.Len(t, issues, repo.NumIssues)
+
+	prs, err := restorer.GetPullRequests(1, 100)
+	assert.NoError(t, err)
+	assert.Len(t, prs, repo.NumPulls)
+	for _, pr := range prs {
+		assert.Empty(t, pr.PatchURL)
+	}
+
+	// pending reviews are not dumped
+	reviews, err := restorer.GetReviews(2)
+	assert.NoError(t, err)
+	if assert.Len(t, reviews, 1) {
+		assert.EqualValues(t, base.ReviewStateApproved, reviews[0].State)
+		assert.EqualValues(t, "Demo Review", reviews[0].Content)
+	}
+
+	releases, err := restorer.GetReleases()
+	assert.NoError(t, err)
+	for _, release := range releases {
+		for _, asset := range release.Assets {
+			rc, err := asset.DownloadFunc()
+			assert.NoError(t, err)
+			bs, err := ioutil.ReadAll(rc)
+			rc.Close()
+			assert.NoError(t, err)
+			assert.EqualValues(t, "release asset", string(bs))
+		}
+	}
+
+	// restore the dump next to the original repository through a zip archive
+	var buf bytes.Buffer
+	assert.NoError(t, packDirectory(&buf, dumpDir))
+	archivePath := filepath.Join(tmpDir, "dump.zip")
+	assert.NoError(t, ioutil.WriteFile(archivePath, buf.Bytes(), 0644))
+
+	restored, err := RestoreRepositoryArchive(ctx, user, user.Name, "repo1-restored", archivePath)
+	assert.NoError(t, err)
+	assert.EqualValues(t, models.RepositoryReady, restored.Status)
+	assert.True(t, restored.HasWiki())
+
+	restored = models.AssertExistsAndLoadBean(t, &models.Repository{ID: restored.ID}).(*models.Repository)
+	assert.EqualValues(t, repo.NumIssues, restored.NumIssues)
+	assert.EqualValues(t, repo.NumPulls, restored.NumPulls)
+
+	labels, err := models.GetLabelsByRepoID(restored.ID, "")
+	assert.NoError(t, err)
+	assert.Len(t, labels, models.GetCount(t, &models.Label{RepoID: repo.ID}))
+
+	milestones, err := models.GetMilestonesByRepoID(restored.ID, structs.StateAll)
+	assert.NoError(t, err)
+	assert.Len(t, milestones, models.GetCount(t, &models.Milestone{RepoID: repo.ID}))
+
+	pr, err := models.GetIssueByIndex(restored.ID, 2)
+	assert.NoError(t, err)
+	assert.True(t, pr.IsPull)
+	restoredReviews, err := models.FindReviews(models.FindReviewOptions{
+		Type:    models.ReviewTypeUnknown,
+		IssueID: pr.ID,
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, restoredReviews, 1) {
+		assert.EqualValues(t, models.ReviewTypeApprove, restoredReviews[0].Type)
+		assert.EqualValues(t, "Demo Review", restoredReviews[0].Content)
+	}
+}
+
+func TestExtractArchiveRefusesEscapingPaths(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "gitea-dump-repo-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	srcDir := filepath.Join(tmpDir, "src")
+	assert.NoError(t, os.MkdirAll(filepath.Join(srcDir, "sub"), os.ModePerm))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(srcDir, "sub", "file.txt"), []byte("content"), 0644))
+
+	var buf bytes.Buffer
+	assert.NoError(t, packDirectory(&buf, srcDir))
+	archivePath := filepath.Join(tmpDir, "good.zip")
+	assert.NoError(t, ioutil.WriteFile(archivePath, buf.Bytes(), 0644))
+
+	dstDir := filepath.Join(tmpDir, "dst")
+	assert.NoError(t, extractArchive(archivePath, dstDir))
+	bs, err := ioutil.ReadFile(filepath.Join(dstDir, "sub", "file.txt"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "content", string(bs))
+
+	// an archive entry must not be written outside of the target directory
+	evilPath := filepath.Join(tmpDir, "evil.zip")
+	assert.NoError(t, ioutil.WriteFile(evilPath, bytes.Replace(buf.Bytes(), []byte("sub/file.txt"), []byte("../../x.txt!"), -1), 0644))
+	assert.Error(t, extractArchive(evilPath, filepath.Join(tmpDir, "dst2")))
+	_, err = os.Stat(filepath.Join(tmpDir, "x.txt!"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -12,7 +12,6 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -24,6 +23,7 @@ import (
 	"code.gitea.io/gitea/modules/migrations/base"
 	"code.gitea.io/gitea/modules/repository"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/timeutil"
 
@@ -77,6 +77,8 @@ func (g *GiteaLocalUploader) MaxBatchInsertSize(tp string) int {
 		return models.MaxBatchInsertSize(new(models.Release))
 	case "pullrequest":
 		return models.MaxBatchInsertSize(new(models.PullRequest))
+	case "review":
+		return models.MaxBatchInsertSize(new(models.Review))
 	}
 	return 10
 }
@@ -264,24 +266,22 @@ func (g *GiteaLocalUploader) CreateReleases(releases ...*base.Release) error {
 
 			// download attachment
 			err = func() error {
-				resp, err := http.Get(asset.URL)
-				if err != nil {
-					return err
-				}
-				defer resp.Body.Close()
-
-				localPath := attach.LocalPath()
-				if err = os.MkdirAll(path.Dir(localPath), os.ModePerm); err != nil {
-					return fmt.Errorf("MkdirAll: %v", err)
-				}
-
-				fw, err := os.Create(localPath)
-				if err != nil {
-					return fmt.Errorf("Create: %v", err)
+				var rc io.ReadCloser
+				if asset.DownloadFunc != nil {
+					rc, err = asset.DownloadFunc()
+					if err != nil {
+						return err
+					}
+				} else {
+					resp, err := http.Get(asset.URL)
+					if err != nil {
+						return err
+					}
+					rc = resp.Body
 				}
-				defer fw.Close()
+				defer rc.Close()
 
-				_, err = io.Copy(fw, resp.Body)
+				_, err = storage.Attachments.Save(attach.RelativePath(), rc)
 				return err
 			}()
 			if err != nil {
@@ -484,6 +484,10 @@ func (g *GiteaLocalUploader) newPullRequest(pr *base.PullRequest) (*models.PullR
 
 	// download patch file
 	err := func() error {
+		// dumped repositories have no patch url, the head commit is restored from the git data
+		if pr.PatchURL == "" {
+			return nil
+		}
 		resp, err := http.Get(pr.PatchURL)
 		if err != nil {
 			return err
@@ -506,18 +510,20 @@ func (g *GiteaLocalUploader) newPullRequest(pr *base.PullRequest) (*models.PullR
 	}
 
 	// set head information
-	pullHead := filepath.Join(g.repo.RepoPath(), "refs", "pull", fmt.Sprintf("%d", pr.Number))
-	if err := os.MkdirAll(pullHead, os.ModePerm); err != nil {
-		return nil, err
-	}
-	p, err := os.Create(filepath.Join(pullHead, "head"))
-	if err != nil {
-		return nil, err
-	}
-	_, err = p.WriteString(pr.Head.SHA)
-	p.Close()
-	if err != nil {
-		return nil, err
+	if pr.Head.SHA != "" {
+		pullHead := filepath.Join(g.repo.RepoPath(), "refs", "pull", fmt.Sprintf("%d", pr.Number))
+		if err := os.MkdirAll(pullHead, os.ModePerm); err != nil {
+			return nil, err
+		}
+		p, err := os.Create(filepath.Join(pullHead, "head"))
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.WriteString(pr.Head.SHA)
+		p.Close()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	var head = "unknown repository"
@@ -577,9 +583,10 @@ func (g *GiteaLocalUploader) newPullRequest(pr *base.PullRequest) (*models.PullR
 	}
 
 	userid, ok := g.userMap[pr.PosterID]
-	if !ok {
+	tp := g.gitServiceType.Name()
+	if !ok && tp != "" {
 		var err error
-		userid, err = models.GetUserIDByExternalUserID("github", fmt.Sprintf("%v", pr.PosterID))
+		userid, err = models.GetUserIDByExternalUserID(tp, fmt.Sprintf("%v", pr.PosterID))
 		if err != nil {
 			log.Error("GetUserIDByExternalUserID: %v", err)
 		}
@@ -623,6 +630,105 @@ func (g *GiteaLocalUploader) newPullRequest(pr *base.PullRequest) (*models.PullR
 	return &pullRequest, nil
 }
 
+func convertReviewState(state string) models.ReviewType {
+	switch state {
+	case base.ReviewStatePending:
+		return models.ReviewTypePending
+	case base.ReviewStateApproved:
+		return models.ReviewTypeApprove
+	case base.ReviewStateChangesRequested:
+		return models.ReviewTypeReject
+	case base.ReviewStateCommented:
+		return models.ReviewTypeComment
+	default:
+		return models.ReviewTypePending
+	}
+}
+
+// CreateReviews create pull request reviews
+func (g *GiteaLocalUploader) CreateReviews(reviews ...*base.Review) error {
+	var cms = make([]*models.Review, 0, len(reviews))
+	for _, review := range reviews {
+		// pending reviews are only visible to their author, so they are not migrated
+		reviewType := convertReviewState(review.State)
+		if reviewType == models.ReviewTypePending {
+			continue
+		}
+
+		var issueID int64
+		if issueIDStr, ok := g.issues.Load(review.IssueIndex); !ok {
+			issue, err := models.GetIssueByIndex(g.repo.ID, review.IssueIndex)
+			if err != nil {
+				return err
+			}
+			issueID = issue.ID
+			g.issues.Store(review.IssueIndex, issueID)
+		} else {
+			issueID = issueIDStr.(int64)
+		}
+
+		userid, ok := g.userMap[review.ReviewerID]
+		tp := g.gitServiceType.Name()
+		if !ok && tp != "" {
+			var err error
+			userid, err = models.GetUserIDByExternalUserID(tp, fmt.Sprintf("%v", review.ReviewerID))
+			if err != nil {
+				log.Error("GetUserIDByExternalUserID: %v", err)
+			}
+			if userid > 0 {
+				g.userMap[review.ReviewerID] = userid
+			}
+		}
+
+		var cm = models.Review{
+			Type:        reviewType,
+			IssueID:     issueID,
+			Content:     review.Content,
+			Official:    review.Official,
+			CommitID:    review.CommitID,
+			CreatedUnix: timeutil.TimeStamp(review.Created.Unix()),
+			UpdatedUnix: timeutil.TimeStamp(review.Created.Unix()),
+		}
+
+		if userid > 0 {
+			cm.ReviewerID = userid
+		} else {
+			cm.ReviewerID = g.doer.ID
+			cm.OriginalAuthor = review.ReviewerName
+			cm.OriginalAuthorID = review.ReviewerID
+		}
+
+		for _, comment := range review.Comments {
+			var c = models.Comment{
+				Type:        models.CommentTypeCode,
+				PosterID:    cm.ReviewerID,
+				IssueID:     issueID,
+				Content:     comment.Content,
+				Line:        comment.Line,
+				TreePath:    comment.TreePath,
+				Patch:       comment.DiffHunk,
+				CommitSHA:   comment.CommitID,
+				CreatedUnix: timeutil.TimeStamp(comment.Created.Unix()),
+				UpdatedUnix: timeutil.TimeStamp(comment.Created.Unix()),
+			}
+
+			if userid > 0 {
+				c.PosterID = userid
+			} else {
+				c.PosterID = g.doer.ID
+				c.OriginalAuthor = review.ReviewerName
+				c.OriginalAuthorID = review.ReviewerID
+			}
+
+			cm.Comments = append(cm.Comments, &c)
+		}
+
+		cms = append(cms, &cm)
+	}
+
+	return models.InsertReviews(cms)
+}
+
 // Rollback when migrating failed, this will rollback all the changes.
 func (g *GiteaLocalUploader) Rollback() error {
 	if g.repo != nil && g.repo.ID > 0 {
@@ -0,0 +1,507 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/util"
+)
+
+var (
+	_ base.Downloader = &GiteaLocalDownloader{}
+)
+
+// GiteaLocalDownloader implements a Downloader interface to get repository informations
+// from the database and the repositories of this gitea instance
+type GiteaLocalDownloader struct {
+	ctx  context.Context
+	repo *models.Repository
+}
+
+// NewGiteaLocalDownloader creates a gitea Downloader for a local repository
+func NewGiteaLocalDownloader(repo *models.Repository) *GiteaLocalDownloader {
+	return &GiteaLocalDownloader{
+		ctx:  context.Background(),
+		repo: repo,
+	}
+}
+
+// SetContext set context
+func (g *GiteaLocalDownloader) SetContext(ctx context.Context) {
+	g.ctx = ctx
+}
+
+// GetRepoInfo returns a repository information
+func (g *GiteaLocalDownloader) GetRepoInfo() (*base.Repository, error) {
+	if err := g.repo.GetOwner(); err != nil {
+		return nil, err
+	}
+	return &base.Repository{
+		Name:        g.repo.Name,
+		Owner:       g.repo.OwnerName,
+		IsPrivate:   g.repo.IsPrivate,
+		Description: g.repo.Description,
+		CloneURL:    g.repo.RepoPath(),
+		OriginalURL: g.repo.HTMLURL(),
+	}, nil
+}
+
+// GetTopics return repository topics
+func (g *GiteaLocalDownloader) GetTopics() ([]string, error) {
+	topics, err := models.FindTopics(&models.FindTopicOptions{
+		RepoID: g.repo.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var names = make([]string, 0, len(topics))
+	for _, topic := range topics {
+		names = append(names, topic.Name)
+	}
+	return names, nil
+}
+
+// GetMilestones returns milestones
+func (g *GiteaLocalDownloader) GetMilestones() ([]*base.Milestone, error) {
+	milestones, err := models.GetMilestonesByRepoID(g.repo.ID, structs.StateAll)
+	if err != nil {
+		return nil, err
+	}
+
+	var ms = make([]*base.Milestone, 0, len(milestones))
+	for _, m := range milestones {
+		var deadline, closed *time.Time
+		// milestones without a deadline are stored with a deadline in the year 9999
+		if m.DeadlineUnix > 0 && m.DeadlineUnix.Year() < 9999 {
+			t := m.DeadlineUnix.AsTime()
+			deadline = &t
+		}
+		if m.IsClosed && m.ClosedDateUnix > 0 {
+			t := m.ClosedDateUnix.AsTime()
+			closed = &t
+		}
+		var state = "open"
+		if m.IsClosed {
+			state = "closed"
+		}
+		ms = append(ms, &base.Milestone{
+			Title:       m.Name,
+			Description: m.Content,
+			Deadline:    deadline,
+			Closed:      closed,
+			State:       state,
+		})
+	}
+	return ms, nil
+}
+
+// GetLabels returns labels
+func (g *GiteaLocalDownloader) GetLabels() ([]*base.Label, error) {
+	labels, err := models.GetLabelsByRepoID(g.repo.ID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var ls = make([]*base.Label, 0, len(labels))
+	for _, l := range labels {
+		ls = append(ls, &base.Label{
+			Name:        l.Name,
+			Color:       strings.TrimPrefix(l.Color, "#"),
+			Description: l.Description,
+		})
+	}
+	return ls, nil
+}
+
+// GetReleases returns releases
+func (g *GiteaLocalDownloader) GetReleases() ([]*base.Release, error) {
+	releases, err := models.GetReleasesByRepoID(g.repo.ID, models.FindReleasesOptions{
+		IncludeDrafts: true,
+	}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err = models.GetReleaseAttachments(releases...); err != nil {
+		return nil, err
+	}
+
+	var rels = make([]*base.Release, 0, len(releases))
+	for _, rel := range releases {
+		publisherID, publisherName := rel.PublisherID, ""
+		if rel.OriginalAuthor != "" {
+			publisherID, publisherName = rel.OriginalAuthorID, rel.OriginalAuthor
+		} else if err := rel.LoadAttributes(); err == nil && rel.Publisher != nil {
+			publisherName = rel.Publisher.Name
+		}
+
+		var assets = make([]base.ReleaseAsset, 0, len(rel.Attachments))
+		for _, attach := range rel.Attachments {
+			if _, err := storage.Attachments.Stat(attach.RelativePath()); err != nil {
+				log.Warn("Skipping asset %s of release %s in %s: %v", attach.Name, rel.TagName, g.repo.FullName(), err)
+				continue
+			}
+			var (
+				size          = int(attach.Size)
+				downloadCount = int(attach.DownloadCount)
+				relativePath  = attach.RelativePath()
+			)
+			assets = append(assets, base.ReleaseAsset{
+				Name:          attach.Name,
+				Size:          &size,
+				DownloadCount: &downloadCount,
+				Created:       attach.CreatedUnix.AsTime(),
+				Updated:       attach.CreatedUnix.AsTime(),
+				DownloadFunc: func() (io.ReadCloser, error) {
+					return storage.Attachments.Open(relativePath)
+				},
+			})
+		}
+
+		rels = append(rels, &base.Release{
+			TagName:         rel.TagName,
+			TargetCommitish: rel.Target,
+			Name:            rel.Title,
+			Body:            rel.Note,
+			Draft:           rel.IsDraft,
+			Prerelease:      rel.IsPrerelease,
+			PublisherID:     publisherID,
+			PublisherName:   publisherName,
+			Assets:          assets,
+			Created:         rel.CreatedUnix.AsTime(),
+			Published:       rel.CreatedUnix.AsTime(),
+		})
+	}
+	return rels, nil
+}
+
+func convertGiteaReactions(reactions models.ReactionList) *base.Reactions {
+	var rs = &base.Reactions{}
+	for _, reaction := range reactions {
+		switch reaction.Type {
+		case "+1":
+			rs.PlusOne++
+		case "-1":
+			rs.MinusOne++
+		case "laugh":
+			rs.Laugh++
+		case "confused":
+			rs.Confused++
+		case "heart":
+			rs.Heart++
+		case "hooray":
+			rs.Hooray++
+		default:
+			continue
+		}
+		rs.TotalCount++
+	}
+	return rs
+}
+
+func (g *GiteaLocalDownloader) listIssues(page, perPage int, isPull bool) ([]*models.Issue, error) {
+	return models.Issues(&models.IssuesOptions{
+		RepoIDs:  []int64{g.repo.ID},
+		Page:     page,
+		PageSize: perPage,
+		IsPull:   util.OptionalBoolOf(isPull),
+		SortType: "oldest",
+	})
+}
+
+func (g *GiteaLocalDownloader) convertIssue(issue *models.Issue) (*base.Issue, error) {
+	if err := issue.LoadPoster(); err != nil {
+		return nil, err
+	}
+	reactions, err := models.FindIssueReactions(issue)
+	if err != nil {
+		return nil, err
+	}
+
+	posterID, posterName, posterEmail := issue.PosterID, issue.Poster.Name, issue.Poster.Email
+	if issue.OriginalAuthor != "" {
+		posterID, posterName, posterEmail = issue.OriginalAuthorID, issue.OriginalAuthor, ""
+	}
+
+	var milestone string
+	if issue.Milestone != nil {
+		milestone = issue.Milestone.Name
+	}
+	var labels = make([]*base.Label, 0, len(issue.Labels))
+	for _, l := range issue.Labels {
+		labels = append(labels, &base.Label{
+			Name:        l.Name,
+			Color:       strings.TrimPrefix(l.Color, "#"),
+			Description: l.Description,
+		})
+	}
+	var state = "open"
+	var closed *time.Time
+	if issue.IsClosed {
+		state = "closed"
+		t := issue.ClosedUnix.AsTime()
+		closed = &t
+	}
+
+	return &base.Issue{
+		Number:      issue.Index,
+		PosterID:    posterID,
+		PosterName:  posterName,
+		PosterEmail: posterEmail,
+		Title:       issue.Title,
+		Content:     issue.Content,
+		Milestone:   milestone,
+		State:       state,
+		Created:     issue.CreatedUnix.AsTime(),
+		Closed:      closed,
+		Labels:      labels,
+		Reactions:   convertGiteaReactions(reactions),
+		IsLocked:    issue.IsLocked,
+	}, nil
+}
+
+// GetIssues returns issues according start and limit
+func (g *GiteaLocalDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	issues, err := g.listIssues(page, perPage, false)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var allIssues = make([]*base.Issue, 0, len(issues))
+	for _, issue := range issues {
+		is, err := g.convertIssue(issue)
+		if err != nil {
+			return nil, false, err
+		}
+		allIssues = append(allIssues, is)
+	}
+	return allIssues, len(issues) < perPage, nil
+}
+
+// GetComments returns comments according issueNumber
+func (g *GiteaLocalDownloader) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	issue, err := models.GetIssueByIndex(g.repo.ID, issueNumber)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := models.FindComments(models.FindCommentsOptions{
+		IssueID: issue.ID,
+		Type:    models.CommentTypeComment,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var allComments = make([]*base.Comment, 0, len(comments))
+	for _, comment := range comments {
+		if err := comment.LoadPoster(); err != nil {
+			return nil, err
+		}
+		reactions, err := models.FindCommentReactions(comment)
+		if err != nil {
+			return nil, err
+		}
+
+		posterID, posterName, posterEmail := comment.PosterID, comment.Poster.Name, comment.Poster.Email
+		if comment.OriginalAuthor != "" {
+			posterID, posterName, posterEmail = comment.OriginalAuthorID, comment.OriginalAuthor, ""
+		}
+
+		allComments = append(allComments, &base.Comment{
+			IssueIndex:  issueNumber,
+			PosterID:    posterID,
+			PosterName:  posterName,
+			PosterEmail: posterEmail,
+			Created:     comment.CreatedUnix.AsTime(),
+			Content:     comment.Content,
+			Reactions:   convertGiteaReactions(reactions),
+		})
+	}
+	return allComments, nil
+}
+
+// GetPullRequests returns pull requests according page and perPage
+func (g *GiteaLocalDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, error) {
+	issues, err := g.listIssues(page, perPage, true)
+	if err != nil {
+		return nil, err
+	}
+
+	gitRepo, err := git.OpenRepository(g.repo.RepoPath())
+	if err != nil {
+		return nil, err
+	}
+	defer gitRepo.Close()
+
+	var allPRs = make([]*base.PullRequest, 0, len(issues))
+	for _, issue := range issues {
+		is, err := g.convertIssue(issue)
+		if err != nil {
+			return nil, err
+		}
+		if err := issue.LoadPullRequest(); err != nil {
+			return nil, err
+		}
+		pr := issue.PullRequest
+
+		// the head commit is kept in the pull ref, even if the head branch has been deleted
+		headSHA, err := gitRepo.GetRefCommitID(pr.GetGitRefName())
+		if err != nil && pr.HeadRepoID == pr.BaseRepoID {
+			headSHA, err = gitRepo.GetBranchCommitID(pr.HeadBranch)
+		}
+		if err != nil {
+			log.Warn("Unable to get head commit of pull request %d in %s: %v", pr.Index, g.repo.FullName(), err)
+			headSHA = ""
+		}
+
+		var (
+			headOwnerName = g.repo.OwnerName
+			headRepoName  = g.repo.Name
+			headCloneURL  = g.repo.RepoPath()
+		)
+		if pr.HeadRepoID != pr.BaseRepoID {
+			if err := pr.LoadHeadRepo(); err == nil && pr.HeadRepo != nil {
+				headOwnerName = pr.HeadRepo.MustOwnerName()
+				headRepoName = pr.HeadRepo.Name
+				headCloneURL = pr.HeadRepo.RepoPath()
+			}
+		}
+
+		var mergedTime *time.Time
+		if pr.HasMerged {
+			t := pr.MergedUnix.AsTime()
+			mergedTime = &t
+		}
+
+		allPRs = append(allPRs, &base.PullRequest{
+			Number:         is.Number,
+			Title:          is.Title,
+			PosterID:       is.PosterID,
+			PosterName:     is.PosterName,
+			PosterEmail:    is.PosterEmail,
+			Content:        is.Content,
+			Milestone:      is.Milestone,
+			State:          is.State,
+			Created:        is.Created,
+			Closed:         is.Closed,
+			Labels:         is.Labels,
+			Reactions:      is.Reactions,
+			IsLocked:       is.IsLocked,
+			Merged:         pr.HasMerged,
+			MergedTime:     mergedTime,
+			MergeCommitSHA: pr.MergedCommitID,
+			Head: base.PullRequestBranch{
+				Ref:       pr.HeadBranch,
+				SHA:       headSHA,
+				RepoName:  headRepoName,
+				OwnerName: headOwnerName,
+				CloneURL:  headCloneURL,
+			},
+			Base: base.PullRequestBranch{
+				Ref:       pr.BaseBranch,
+				SHA:       pr.MergeBase,
+				RepoName:  g.repo.Name,
+				OwnerName: g.repo.OwnerName,
+			},
+		})
+	}
+	return allPRs, nil
+}
+
+func convertGiteaReviewType(tp models.ReviewType) string {
+	switch tp {
+	case models.ReviewTypeApprove:
+		return base.ReviewStateApproved
+	case models.ReviewTypeReject:
+		return base.ReviewStateChangesRequested
+	case models.ReviewTypeComment:
+		return base.ReviewStateCommented
+	default:
+		return base.ReviewStatePending
+	}
+}
+
+// GetReviews returns pull requests reviews
+func (g *GiteaLocalDownloader) GetReviews(pullRequestNumber int64) ([]*base.Review, error) {
+	issue, err := models.GetIssueByIndex(g.repo.ID, pullRequestNumber)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := models.FindReviews(models.FindReviewOptions{
+		Type:    models.ReviewTypeUnknown,
+		IssueID: issue.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var allReviews = make([]*base.Review, 0, len(reviews))
+	for _, review := range reviews {
+		// pending reviews are only visible to their author
+		if review.Type == models.ReviewTypePending {
+			continue
+		}
+		if err := review.LoadReviewer(); err != nil && !models.IsErrUserNotExist(err) {
+			return nil, err
+		}
+
+		reviewerID, reviewerName := review.ReviewerID, ""
+		if review.OriginalAuthor != "" {
+			reviewerID, reviewerName = review.OriginalAuthorID, review.OriginalAuthor
+		} else if review.Reviewer != nil {
+			reviewerName = review.Reviewer.Name
+		}
+
+		comments, err := models.FindComments(models.FindCommentsOptions{
+			IssueID:  issue.ID,
+			ReviewID: review.ID,
+			Type:     models.CommentTypeCode,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var rcs = make([]*base.ReviewComment, 0, len(comments))
+		for _, comment := range comments {
+			reactions, err := models.FindCommentReactions(comment)
+			if err != nil {
+				return nil, err
+			}
+			rcs = append(rcs, &base.ReviewComment{
+				Content:    comment.Content,
+				TreePath:   comment.TreePath,
+				DiffHunk:   comment.Patch,
+				Line:       comment.Line,
+				CommitID:   comment.CommitSHA,
+				PosterID:   reviewerID,
+				PosterName: reviewerName,
+				Reactions:  convertGiteaReactions(reactions),
+				Created:    comment.CreatedUnix.AsTime(),
+			})
+		}
+
+		allReviews = append(allReviews, &base.Review{
+			IssueIndex:   pullRequestNumber,
+			ReviewerID:   reviewerID,
+			ReviewerName: reviewerName,
+			Official:     review.Official,
+			CommitID:     review.CommitID,
+			Content:      review.Content,
+			Created:      review.CreatedUnix.AsTime(),
+			State:        convertGiteaReviewType(review.Type),
+			Comments:     rcs,
+		})
+	}
+	return allReviews, nil
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -548,3 +549,110 @@ func (g *GithubDownloaderV3) GetPullRequests(page, perPage int) ([]*base.PullReq
 
 	return allPRs, nil
 }
+
+// lineFromDiffHunk returns the line a review comment is attached to, which is the last
+// line of its diff hunk: positive for the proposed file and negative for the previous one
+func lineFromDiffHunk(hunk string) int64 {
+	lines := strings.Split(strings.TrimRight(hunk, "\n"), "\n")
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "@@ -") {
+		return 0
+	}
+	fields := strings.Fields(lines[0])
+	if len(fields) < 3 {
+		return 0
+	}
+	parseStart := func(s string) int64 {
+		s = strings.SplitN(s[1:], ",", 2)[0]
+		n, _ := strconv.ParseInt(s, 10, 64)
+		return n
+	}
+	oldLine, newLine := parseStart(fields[1]), parseStart(fields[2])
+
+	var line int64
+	for _, l := range lines[1:] {
+		switch {
+		case strings.HasPrefix(l, "-"):
+			line = -oldLine
+			oldLine++
+		case strings.HasPrefix(l, "+"):
+			line = newLine
+			newLine++
+		default:
+			line = newLine
+			oldLine++
+			newLine++
+		}
+	}
+	return line
+}
+
+func (g *GithubDownloaderV3) convertGithubReviewComments(cs []*github.PullRequestComment) []*base.ReviewComment {
+	var rcs = make([]*base.ReviewComment, 0, len(cs))
+	for _, c := range cs {
+		rc := &base.ReviewComment{
+			Content:    c.GetBody(),
+			TreePath:   c.GetPath(),
+			DiffHunk:   c.GetDiffHunk(),
+			Line:       lineFromDiffHunk(c.GetDiffHunk()),
+			CommitID:   c.GetCommitID(),
+			PosterID:   c.GetUser().GetID(),
+			PosterName: c.GetUser().GetLogin(),
+			Created:    c.GetCreatedAt(),
+		}
+		if c.Reactions != nil {
+			rc.Reactions = convertGithubReactions(c.Reactions)
+		}
+		rcs = append(rcs, rc)
+	}
+	return rcs
+}
+
+// GetReviews returns pull requests review
+func (g *GithubDownloaderV3) GetReviews(pullRequestNumber int64) ([]*base.Review, error) {
+	var allReviews = make([]*base.Review, 0, 100)
+	opt := &github.ListOptions{
+		PerPage: 100,
+	}
+	for {
+		g.sleep()
+		reviews, resp, err := g.client.PullRequests.ListReviews(g.ctx, g.repoOwner, g.repoName, int(pullRequestNumber), opt)
+		if err != nil {
+			return nil, fmt.Errorf("error while listing repos: %v", err)
+		}
+		g.rate = &resp.Rate
+		for _, review := range reviews {
+			r := &base.Review{
+				IssueIndex:   pullRequestNumber,
+				ReviewerID:   review.GetUser().GetID(),
+				ReviewerName: review.GetUser().GetLogin(),
+				CommitID:     review.GetCommitID(),
+				Content:      review.GetBody(),
+				Created:      review.GetSubmittedAt(),
+				State:        review.GetState(),
+			}
+
+			commentOpt := &github.ListOptions{
+				PerPage: 100,
+			}
+			for {
+				g.sleep()
+				comments, resp, err := g.client.PullRequests.ListReviewComments(g.ctx, g.repoOwner, g.repoName, int(pullRequestNumber), review.GetID(), commentOpt)
+				if err != nil {
+					return nil, fmt.Errorf("error while listing repos: %v", err)
+				}
+				g.rate = &resp.Rate
+				r.Comments = append(r.Comments, g.convertGithubReviewComments(comments)...)
+				if resp.NextPage == 0 {
+					break
+				}
+				commentOpt.Page = resp.NextPage
+			}
+			allReviews = append(allReviews, r)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return allReviews, nil
+}
@@ -328,3 +328,10 @@ func TestGitHubDownloadRepo(t *testing.T) {
 		},
 	}, prs)
 }
+
+func TestLineFromDiffHunk(t *testing.T) {
+	assert.EqualValues(t, 12, lineFromDiffHunk("@@ -10,3 +10,3 @@ func main() {\n context\n-old\n+new\n context"))
+	assert.EqualValues(t, -11, lineFromDiffHunk("@@ -10,3 +10,2 @@\n context\n-old"))
+	assert.EqualValues(t, 1, lineFromDiffHunk("@@ -0,0 +1 @@\n+new"))
+	assert.EqualValues(t, 0, lineFromDiffHunk("no hunk"))
+}
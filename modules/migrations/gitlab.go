@@ -0,0 +1,685 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/structs"
+)
+
+var (
+	_ base.Downloader        = &GitlabDownloader{}
+	_ base.DownloaderFactory = &GitlabDownloaderFactory{}
+)
+
+func init() {
+	RegisterDownloaderFactory(&GitlabDownloaderFactory{})
+}
+
+// GitlabDownloaderFactory defines a gitlab downloader factory
+type GitlabDownloaderFactory struct {
+}
+
+// Match returns ture if the migration remote URL matched this downloader factory
+func (f *GitlabDownloaderFactory) Match(opts base.MigrateOptions) (bool, error) {
+	if opts.GitServiceType == structs.GitlabService {
+		return true, nil
+	}
+
+	u, err := url.Parse(opts.CloneAddr)
+	if err != nil {
+		return false, err
+	}
+
+	return strings.EqualFold(u.Host, "gitlab.com") && opts.AuthUsername != "", nil
+}
+
+// New returns a Downloader related to this factory according MigrateOptions
+func (f *GitlabDownloaderFactory) New(opts base.MigrateOptions) (base.Downloader, error) {
+	u, err := url.Parse(opts.CloneAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := u.Scheme + "://" + u.Host
+	repoPath := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+
+	// A personal access token is expected as password, the username alone is used as token like for github
+	token := opts.AuthPassword
+	if token == "" {
+		token = opts.AuthUsername
+	}
+
+	log.Trace("Create gitlab downloader: %s/%s", baseURL, repoPath)
+
+	return NewGitlabDownloader(baseURL, repoPath, token), nil
+}
+
+// GitServiceType returns the type of git service
+func (f *GitlabDownloaderFactory) GitServiceType() structs.GitServiceType {
+	return structs.GitlabService
+}
+
+type gitlabUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+type gitlabMilestone struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	State       string    `json:"state"`
+	DueDate     string    `json:"due_date"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type gitlabProject struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Path        string   `json:"path"`
+	Description string   `json:"description"`
+	Visibility  string   `json:"visibility"`
+	WebURL      string   `json:"web_url"`
+	HTTPURL     string   `json:"http_url_to_repo"`
+	TagList     []string `json:"tag_list"`
+	Topics      []string `json:"topics"`
+	Namespace   struct {
+		FullPath string `json:"full_path"`
+	} `json:"namespace"`
+}
+
+type gitlabLabel struct {
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+type gitlabRelease struct {
+	TagName     string      `json:"tag_name"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	CreatedAt   time.Time   `json:"created_at"`
+	ReleasedAt  *time.Time  `json:"released_at"`
+	Author      *gitlabUser `json:"author"`
+	Commit      struct {
+		ID string `json:"id"`
+	} `json:"commit"`
+	Assets struct {
+		Links []struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"links"`
+	} `json:"assets"`
+}
+
+type gitlabIssue struct {
+	IID              int64            `json:"iid"`
+	Title            string           `json:"title"`
+	Description      string           `json:"description"`
+	State            string           `json:"state"`
+	Labels           []string         `json:"labels"`
+	Milestone        *gitlabMilestone `json:"milestone"`
+	Author           *gitlabUser      `json:"author"`
+	CreatedAt        time.Time        `json:"created_at"`
+	ClosedAt         *time.Time       `json:"closed_at"`
+	DiscussionLocked *bool            `json:"discussion_locked"`
+}
+
+type gitlabMergeRequest struct {
+	gitlabIssue
+	Assignees       []*gitlabUser `json:"assignees"`
+	MergedAt        *time.Time    `json:"merged_at"`
+	SourceBranch    string        `json:"source_branch"`
+	TargetBranch    string        `json:"target_branch"`
+	SourceProjectID int64         `json:"source_project_id"`
+	TargetProjectID int64         `json:"target_project_id"`
+	SHA             string        `json:"sha"`
+	MergeCommitSHA  string        `json:"merge_commit_sha"`
+	WebURL          string        `json:"web_url"`
+	DiffRefs        *struct {
+		BaseSHA string `json:"base_sha"`
+	} `json:"diff_refs"`
+}
+
+type gitlabNote struct {
+	ID        int64       `json:"id"`
+	Body      string      `json:"body"`
+	Author    *gitlabUser `json:"author"`
+	System    bool        `json:"system"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type gitlabApprovals struct {
+	ApprovedBy []struct {
+		User *gitlabUser `json:"user"`
+	} `json:"approved_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type gitlabAwardEmoji struct {
+	Name string `json:"name"`
+}
+
+// GitlabDownloader implements a Downloader interface to get repository informations
+// from gitlab via APIv4
+type GitlabDownloader struct {
+	ctx      context.Context
+	client   *http.Client
+	baseURL  string
+	repoPath string
+	token    string
+	projects map[int64]*gitlabProject
+	// issues and merge requests have their own index on gitlab, so the merge
+	// requests are numbered after the greatest issue index
+	maxIssueIndex int64
+}
+
+// NewGitlabDownloader creates a gitlab Downloader via gitlab v4 API
+func NewGitlabDownloader(baseURL, repoPath, token string) *GitlabDownloader {
+	return &GitlabDownloader{
+		ctx:      context.Background(),
+		client:   http.DefaultClient,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		repoPath: repoPath,
+		token:    token,
+		projects: make(map[int64]*gitlabProject),
+	}
+}
+
+// SetContext set context
+func (g *GitlabDownloader) SetContext(ctx context.Context) {
+	g.ctx = ctx
+}
+
+// get requests the project API path and decodes the response into v. It returns
+// the next page reported by gitlab, 0 if this was the last page
+func (g *GitlabDownloader) get(apiPath string, query url.Values, v interface{}) (int, error) {
+	u := fmt.Sprintf("%s/api/v4/projects/%s%s", g.baseURL, url.PathEscape(g.repoPath), apiPath)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return g.getURL(u, v)
+}
+
+func (g *GitlabDownloader) getURL(u string, v interface{}) (int, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(g.ctx)
+	if g.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("GET %s: %s", req.URL.Path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return 0, fmt.Errorf("decode %s: %v", req.URL.Path, err)
+	}
+
+	var nextPage int
+	if next := resp.Header.Get("X-Next-Page"); next != "" {
+		if _, err := fmt.Sscanf(next, "%d", &nextPage); err != nil {
+			return 0, fmt.Errorf("invalid X-Next-Page header %q", next)
+		}
+	}
+	return nextPage, nil
+}
+
+func pageQuery(page, perPage int) url.Values {
+	return url.Values{
+		"page":     []string{fmt.Sprint(page)},
+		"per_page": []string{fmt.Sprint(perPage)},
+	}
+}
+
+// getProject returns a project by id, used for the source projects of merge requests
+func (g *GitlabDownloader) getProject(id int64) (*gitlabProject, error) {
+	if p, ok := g.projects[id]; ok {
+		return p, nil
+	}
+	var p gitlabProject
+	if _, err := g.getURL(fmt.Sprintf("%s/api/v4/projects/%d", g.baseURL, id), &p); err != nil {
+		return nil, err
+	}
+	g.projects[id] = &p
+	return &p, nil
+}
+
+// GetRepoInfo returns a repository information
+func (g *GitlabDownloader) GetRepoInfo() (*base.Repository, error) {
+	var p gitlabProject
+	if _, err := g.get("", nil, &p); err != nil {
+		return nil, err
+	}
+	g.projects[p.ID] = &p
+
+	// convert gitlab project to stand Repo
+	return &base.Repository{
+		Owner:       p.Namespace.FullPath,
+		Name:        p.Name,
+		IsPrivate:   p.Visibility != "public",
+		Description: p.Description,
+		OriginalURL: p.WebURL,
+		CloneURL:    p.HTTPURL,
+	}, nil
+}
+
+// GetTopics return gitlab topics
+func (g *GitlabDownloader) GetTopics() ([]string, error) {
+	var p gitlabProject
+	if _, err := g.get("", nil, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Topics) > 0 {
+		return p.Topics, nil
+	}
+	return p.TagList, nil
+}
+
+// GetMilestones returns milestones
+func (g *GitlabDownloader) GetMilestones() ([]*base.Milestone, error) {
+	var perPage = 100
+	var milestones = make([]*base.Milestone, 0, perPage)
+	for i := 1; i > 0; {
+		var ms []*gitlabMilestone
+		next, err := g.get("/milestones", pageQuery(i, perPage), &ms)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range ms {
+			var deadline *time.Time
+			if m.DueDate != "" {
+				tm, err := time.Parse("2006-01-02", m.DueDate)
+				if err != nil {
+					return nil, fmt.Errorf("invalid due date of milestone %s: %v", m.Title, err)
+				}
+				deadline = &tm
+			}
+			var state = "open"
+			var closed *time.Time
+			if m.State == "closed" {
+				state = "closed"
+				// gitlab does not report when a milestone was closed
+				closed = &m.UpdatedAt
+			}
+			updated := m.UpdatedAt
+			milestones = append(milestones, &base.Milestone{
+				Title:       m.Title,
+				Description: m.Description,
+				Deadline:    deadline,
+				State:       state,
+				Created:     m.CreatedAt,
+				Updated:     &updated,
+				Closed:      closed,
+			})
+		}
+		i = next
+	}
+	return milestones, nil
+}
+
+// GetLabels returns labels
+func (g *GitlabDownloader) GetLabels() ([]*base.Label, error) {
+	var perPage = 100
+	var labels = make([]*base.Label, 0, perPage)
+	for i := 1; i > 0; {
+		var ls []*gitlabLabel
+		next, err := g.get("/labels", pageQuery(i, perPage), &ls)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, label := range ls {
+			labels = append(labels, &base.Label{
+				Name:        label.Name,
+				Color:       strings.TrimPrefix(label.Color, "#"),
+				Description: label.Description,
+			})
+		}
+		i = next
+	}
+	return labels, nil
+}
+
+func (g *GitlabDownloader) convertGitlabRelease(rel *gitlabRelease) *base.Release {
+	published := rel.CreatedAt
+	if rel.ReleasedAt != nil {
+		published = *rel.ReleasedAt
+	}
+
+	r := &base.Release{
+		TagName:         rel.TagName,
+		TargetCommitish: rel.Commit.ID,
+		Name:            rel.Name,
+		Body:            rel.Description,
+		Created:         rel.CreatedAt,
+		Published:       published,
+	}
+	if rel.Author != nil {
+		r.PublisherID = rel.Author.ID
+		r.PublisherName = rel.Author.Username
+		r.PublisherEmail = rel.Author.Email
+	}
+
+	// only the links of a release are assets, the sources are generated from the tag
+	for _, link := range rel.Assets.Links {
+		var size, downloadCount int
+		r.Assets = append(r.Assets, base.ReleaseAsset{
+			URL:           link.URL,
+			Name:          link.Name,
+			Size:          &size,
+			DownloadCount: &downloadCount,
+			Created:       rel.CreatedAt,
+			Updated:       rel.CreatedAt,
+		})
+	}
+	return r
+}
+
+// GetReleases returns releases
+func (g *GitlabDownloader) GetReleases() ([]*base.Release, error) {
+	var perPage = 100
+	var releases = make([]*base.Release, 0, perPage)
+	for i := 1; i > 0; {
+		var ls []*gitlabRelease
+		next, err := g.get("/releases", pageQuery(i, perPage), &ls)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, release := range ls {
+			releases = append(releases, g.convertGitlabRelease(release))
+		}
+		i = next
+	}
+	return releases, nil
+}
+
+// getReactions returns the award emojis of an issue, merge request or note as reactions
+func (g *GitlabDownloader) getReactions(apiPath string) (*base.Reactions, error) {
+	var reactions = &base.Reactions{}
+	for i := 1; i > 0; {
+		var emojis []*gitlabAwardEmoji
+		next, err := g.get(apiPath+"/award_emoji", pageQuery(i, 100), &emojis)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, emoji := range emojis {
+			reactions.TotalCount++
+			switch emoji.Name {
+			case "thumbsup":
+				reactions.PlusOne++
+			case "thumbsdown":
+				reactions.MinusOne++
+			case "laughing":
+				reactions.Laugh++
+			case "confused":
+				reactions.Confused++
+			case "heart":
+				reactions.Heart++
+			case "tada":
+				reactions.Hooray++
+			}
+		}
+		i = next
+	}
+	return reactions, nil
+}
+
+func convertGitlabState(state string) string {
+	if state == "opened" || state == "locked" {
+		return "open"
+	}
+	return "closed"
+}
+
+// GetIssues returns issues according start and limit
+func (g *GitlabDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	query := pageQuery(page, perPage)
+	query.Set("state", "all")
+	query.Set("order_by", "created_at")
+	query.Set("sort", "asc")
+
+	var issues []*gitlabIssue
+	next, err := g.get("/issues", query, &issues)
+	if err != nil {
+		return nil, false, fmt.Errorf("error while listing issues: %v", err)
+	}
+
+	var allIssues = make([]*base.Issue, 0, perPage)
+	for _, issue := range issues {
+		if issue.IID > g.maxIssueIndex {
+			g.maxIssueIndex = issue.IID
+		}
+
+		var milestone string
+		if issue.Milestone != nil {
+			milestone = issue.Milestone.Title
+		}
+		var labels = make([]*base.Label, 0, len(issue.Labels))
+		for _, l := range issue.Labels {
+			labels = append(labels, &base.Label{Name: l})
+		}
+		reactions, err := g.getReactions(fmt.Sprintf("/issues/%d", issue.IID))
+		if err != nil {
+			return nil, false, err
+		}
+
+		allIssues = append(allIssues, &base.Issue{
+			Title:       issue.Title,
+			Number:      issue.IID,
+			PosterID:    issue.Author.ID,
+			PosterName:  issue.Author.Username,
+			PosterEmail: issue.Author.Email,
+			Content:     issue.Description,
+			Milestone:   milestone,
+			State:       convertGitlabState(issue.State),
+			Created:     issue.CreatedAt,
+			Labels:      labels,
+			Reactions:   reactions,
+			Closed:      issue.ClosedAt,
+			IsLocked:    issue.DiscussionLocked != nil && *issue.DiscussionLocked,
+		})
+	}
+
+	return allIssues, next == 0, nil
+}
+
+// GetComments returns comments according issueNumber
+func (g *GitlabDownloader) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	var apiPath string
+	if issueNumber > g.maxIssueIndex {
+		apiPath = fmt.Sprintf("/merge_requests/%d", issueNumber-g.maxIssueIndex)
+	} else {
+		apiPath = fmt.Sprintf("/issues/%d", issueNumber)
+	}
+
+	var allComments = make([]*base.Comment, 0, 100)
+	for i := 1; i > 0; {
+		query := pageQuery(i, 100)
+		query.Set("order_by", "created_at")
+		query.Set("sort", "asc")
+
+		var notes []*gitlabNote
+		next, err := g.get(apiPath+"/notes", query, &notes)
+		if err != nil {
+			return nil, fmt.Errorf("error while listing comments: %v", err)
+		}
+		for _, note := range notes {
+			// system notes are the events of the issue like closing or labeling it
+			if note.System {
+				continue
+			}
+			reactions, err := g.getReactions(fmt.Sprintf("%s/notes/%d", apiPath, note.ID))
+			if err != nil {
+				return nil, err
+			}
+			allComments = append(allComments, &base.Comment{
+				IssueIndex:  issueNumber,
+				PosterID:    note.Author.ID,
+				PosterName:  note.Author.Username,
+				PosterEmail: note.Author.Email,
+				Content:     note.Body,
+				Created:     note.CreatedAt,
+				Reactions:   reactions,
+			})
+		}
+		i = next
+	}
+	return allComments, nil
+}
+
+// GetPullRequests returns pull requests according page and perPage
+func (g *GitlabDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, error) {
+	query := pageQuery(page, perPage)
+	query.Set("state", "all")
+	query.Set("order_by", "created_at")
+	query.Set("sort", "asc")
+
+	var mrs []*gitlabMergeRequest
+	if _, err := g.get("/merge_requests", query, &mrs); err != nil {
+		return nil, fmt.Errorf("error while listing merge requests: %v", err)
+	}
+
+	var allPRs = make([]*base.PullRequest, 0, perPage)
+	for _, mr := range mrs {
+		pr, err := g.convertGitlabMergeRequest(mr)
+		if err != nil {
+			return nil, err
+		}
+		allPRs = append(allPRs, pr)
+	}
+	return allPRs, nil
+}
+
+func (g *GitlabDownloader) convertGitlabMergeRequest(mr *gitlabMergeRequest) (*base.PullRequest, error) {
+	// the list of merge requests does not contain the diff refs on all gitlab versions
+	if mr.DiffRefs == nil {
+		if _, err := g.get(fmt.Sprintf("/merge_requests/%d", mr.IID), nil, mr); err != nil {
+			return nil, err
+		}
+	}
+	var baseSHA string
+	if mr.DiffRefs != nil {
+		baseSHA = mr.DiffRefs.BaseSHA
+	}
+
+	target, err := g.getProject(mr.TargetProjectID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := g.getProject(mr.SourceProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var milestone string
+	if mr.Milestone != nil {
+		milestone = mr.Milestone.Title
+	}
+	var labels = make([]*base.Label, 0, len(mr.Labels))
+	for _, l := range mr.Labels {
+		labels = append(labels, &base.Label{Name: l})
+	}
+	var assignees = make([]string, 0, len(mr.Assignees))
+	for _, assignee := range mr.Assignees {
+		assignees = append(assignees, assignee.Username)
+	}
+	var assignee string
+	if len(assignees) > 0 {
+		assignee = assignees[0]
+	}
+
+	merged := mr.State == "merged"
+	closed := mr.ClosedAt
+	if merged {
+		closed = mr.MergedAt
+	}
+
+	reactions, err := g.getReactions(fmt.Sprintf("/merge_requests/%d", mr.IID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &base.PullRequest{
+		Title:          mr.Title,
+		Number:         g.maxIssueIndex + mr.IID,
+		PosterName:     mr.Author.Username,
+		PosterID:       mr.Author.ID,
+		PosterEmail:    mr.Author.Email,
+		Content:        mr.Description,
+		Milestone:      milestone,
+		State:          convertGitlabState(mr.State),
+		Created:        mr.CreatedAt,
+		Closed:         closed,
+		Labels:         labels,
+		Merged:         merged,
+		MergeCommitSHA: mr.MergeCommitSHA,
+		MergedTime:     mr.MergedAt,
+		IsLocked:       mr.DiscussionLocked != nil && *mr.DiscussionLocked,
+		Assignee:       assignee,
+		Assignees:      assignees,
+		Reactions:      reactions,
+		Head: base.PullRequestBranch{
+			Ref:       mr.SourceBranch,
+			SHA:       mr.SHA,
+			RepoName:  source.Path,
+			OwnerName: source.Namespace.FullPath,
+			CloneURL:  source.HTTPURL,
+		},
+		Base: base.PullRequestBranch{
+			Ref:       mr.TargetBranch,
+			SHA:       baseSHA,
+			RepoName:  target.Path,
+			OwnerName: target.Namespace.FullPath,
+		},
+		PatchURL: mr.WebURL + ".patch",
+	}, nil
+}
+
+// GetReviews returns the approvals of a merge request as reviews, gitlab has no
+// review objects which could be migrated
+func (g *GitlabDownloader) GetReviews(pullRequestNumber int64) ([]*base.Review, error) {
+	var approvals gitlabApprovals
+	if _, err := g.get(fmt.Sprintf("/merge_requests/%d/approvals", pullRequestNumber-g.maxIssueIndex), nil, &approvals); err != nil {
+		return nil, fmt.Errorf("error while listing merge request approvals: %v", err)
+	}
+
+	var reviews = make([]*base.Review, 0, len(approvals.ApprovedBy))
+	for _, approval := range approvals.ApprovedBy {
+		if approval.User == nil {
+			continue
+		}
+		reviews = append(reviews, &base.Review{
+			IssueIndex:   pullRequestNumber,
+			ReviewerID:   approval.User.ID,
+			ReviewerName: approval.User.Username,
+			Created:      approvals.UpdatedAt,
+			State:        base.ReviewStateApproved,
+		})
+	}
+	return reviews, nil
+}
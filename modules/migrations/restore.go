@@ -0,0 +1,234 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations/base"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	_ base.Downloader = &RepositoryRestorer{}
+)
+
+// RepositoryRestorer implements a Downloader reading a repository dump written by RepositoryDumper
+type RepositoryRestorer struct {
+	ctx          context.Context
+	baseDir      string
+	manifest     *RepositoryManifest
+	issues       []*base.Issue
+	pullRequests []*base.PullRequest
+}
+
+// NewRepositoryRestorer creates a restorer reading the dump in baseDir
+func NewRepositoryRestorer(ctx context.Context, baseDir string) (*RepositoryRestorer, error) {
+	baseDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest RepositoryManifest
+	if err := readYAML(filepath.Join(baseDir, "repo.yml"), &manifest); err != nil {
+		return nil, err
+	} else if manifest.Repository == nil {
+		return nil, fmt.Errorf("%s is not a repository dump", baseDir)
+	}
+	if manifest.Version > RepositoryDumpVersion {
+		return nil, fmt.Errorf("unsupported repository dump version %d, the highest known version is %d", manifest.Version, RepositoryDumpVersion)
+	}
+
+	return &RepositoryRestorer{
+		ctx:      ctx,
+		baseDir:  baseDir,
+		manifest: &manifest,
+	}, nil
+}
+
+// readYAML reads a yaml file, a missing file is treated like an empty one
+func readYAML(p string, v interface{}) error {
+	bs, err := ioutil.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return yaml.Unmarshal(bs, v)
+}
+
+// Manifest returns the manifest of the dump
+func (r *RepositoryRestorer) Manifest() *RepositoryManifest {
+	return r.manifest
+}
+
+// HasWiki returns true if the dump contains the wiki
+func (r *RepositoryRestorer) HasWiki() bool {
+	_, err := os.Stat(filepath.Join(r.baseDir, "repo.wiki.git"))
+	return err == nil
+}
+
+// SetContext set context
+func (r *RepositoryRestorer) SetContext(ctx context.Context) {
+	r.ctx = ctx
+}
+
+// GetRepoInfo returns a repository information, the git data is cloned from the dump
+// and the wiki is found next to it
+func (r *RepositoryRestorer) GetRepoInfo() (*base.Repository, error) {
+	repo := *r.manifest.Repository
+	repo.CloneURL = filepath.Join(r.baseDir, "repo.git")
+	return &repo, nil
+}
+
+// GetTopics return repository topics
+func (r *RepositoryRestorer) GetTopics() ([]string, error) {
+	var topics []string
+	return topics, readYAML(filepath.Join(r.baseDir, "topics.yml"), &topics)
+}
+
+// GetMilestones returns milestones
+func (r *RepositoryRestorer) GetMilestones() ([]*base.Milestone, error) {
+	var milestones []*base.Milestone
+	return milestones, readYAML(filepath.Join(r.baseDir, "milestones.yml"), &milestones)
+}
+
+// GetLabels returns labels
+func (r *RepositoryRestorer) GetLabels() ([]*base.Label, error) {
+	var labels []*base.Label
+	return labels, readYAML(filepath.Join(r.baseDir, "labels.yml"), &labels)
+}
+
+// GetReleases returns releases, their assets are read from the dump
+func (r *RepositoryRestorer) GetReleases() ([]*base.Release, error) {
+	var releases []*base.Release
+	if err := readYAML(filepath.Join(r.baseDir, "releases.yml"), &releases); err != nil {
+		return nil, err
+	}
+	for _, release := range releases {
+		for i, asset := range release.Assets {
+			assetPath, err := r.localPath(asset.URL)
+			if err != nil {
+				return nil, err
+			}
+			release.Assets[i].DownloadFunc = func() (io.ReadCloser, error) {
+				return os.Open(assetPath)
+			}
+		}
+	}
+	return releases, nil
+}
+
+// localPath returns the local path of a file of the dump, paths leaving the dump are refused
+func (r *RepositoryRestorer) localPath(p string) (string, error) {
+	localPath := filepath.Join(r.baseDir, filepath.FromSlash(p))
+	if rel, err := filepath.Rel(r.baseDir, localPath); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid path %q in repository dump", p)
+	}
+	return localPath, nil
+}
+
+func page(total, page, perPage int) (start, end int) {
+	if page < 1 {
+		page = 1
+	}
+	start = (page - 1) * perPage
+	if start > total {
+		start = total
+	}
+	end = start + perPage
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+// GetIssues returns issues according page and perPage
+func (r *RepositoryRestorer) GetIssues(pg, perPage int) ([]*base.Issue, bool, error) {
+	if r.issues == nil {
+		r.issues = make([]*base.Issue, 0, 10)
+		if err := readYAML(filepath.Join(r.baseDir, "issues.yml"), &r.issues); err != nil {
+			return nil, false, err
+		}
+	}
+	start, end := page(len(r.issues), pg, perPage)
+	return r.issues[start:end], end >= len(r.issues), nil
+}
+
+// GetComments returns comments according issueNumber
+func (r *RepositoryRestorer) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	var comments []*base.Comment
+	return comments, readYAML(filepath.Join(r.baseDir, "comments", strconv.FormatInt(issueNumber, 10)+".yml"), &comments)
+}
+
+// GetPullRequests returns pull requests according page and perPage
+func (r *RepositoryRestorer) GetPullRequests(pg, perPage int) ([]*base.PullRequest, error) {
+	if r.pullRequests == nil {
+		r.pullRequests = make([]*base.PullRequest, 0, 10)
+		if err := readYAML(filepath.Join(r.baseDir, "pull_requests.yml"), &r.pullRequests); err != nil {
+			return nil, err
+		}
+	}
+	start, end := page(len(r.pullRequests), pg, perPage)
+	return r.pullRequests[start:end], nil
+}
+
+// GetReviews returns pull requests reviews
+func (r *RepositoryRestorer) GetReviews(pullRequestNumber int64) ([]*base.Review, error) {
+	var reviews []*base.Review
+	return reviews, readYAML(filepath.Join(r.baseDir, "reviews", strconv.FormatInt(pullRequestNumber, 10)+".yml"), &reviews)
+}
+
+// RestoreRepository restores a repository dumped by DumpRepository from baseDir as ownerName/repoName
+func RestoreRepository(ctx context.Context, doer *models.User, ownerName, repoName, baseDir string) (*models.Repository, error) {
+	downloader, err := NewRepositoryRestorer(ctx, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	manifest := downloader.Manifest()
+
+	var uploader = NewGiteaLocalUploader(ctx, doer, ownerName, repoName)
+	uploader.gitServiceType = manifest.GitServiceType
+
+	opts := base.MigrateOptions{
+		RepoName:       repoName,
+		Description:    manifest.Repository.Description,
+		OriginalURL:    manifest.Repository.OriginalURL,
+		Private:        manifest.Repository.IsPrivate,
+		Wiki:           downloader.HasWiki(),
+		Issues:         true,
+		Milestones:     true,
+		Labels:         true,
+		Releases:       true,
+		Comments:       true,
+		PullRequests:   true,
+		GitServiceType: manifest.GitServiceType,
+	}
+
+	if err := migrateRepository(downloader, uploader, opts); err != nil {
+		if err1 := uploader.Rollback(); err1 != nil {
+			log.Error("rollback failed: %v", err1)
+		}
+		return nil, err
+	}
+
+	repo := uploader.repo
+	repo.Status = models.RepositoryReady
+	if err := models.UpdateRepositoryCols(repo, "status"); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
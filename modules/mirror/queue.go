@@ -0,0 +1,23 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mirror
+
+import (
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/sync"
+)
+
+// pushMirrorQueue holds an UniqueQueue object of the push mirrors to be synced
+var pushMirrorQueue = sync.NewUniqueQueue(setting.Repository.MirrorQueueLength)
+
+// PushMirrorQueue returns the queue of the push mirrors to be synced
+func PushMirrorQueue() *sync.UniqueQueue {
+	return pushMirrorQueue
+}
+
+// AddPushMirrorToQueue adds the push mirror to the queue to be synced
+func AddPushMirrorToQueue(mirrorID int64) {
+	go pushMirrorQueue.Add(mirrorID)
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification/base"
+	actions_service "code.gitea.io/gitea/services/actions"
+)
+
+type actionsNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &actionsNotifier{}
+)
+
+// NewNotifier create a new actionsNotifier notifier
+func NewNotifier() base.Notifier {
+	return &actionsNotifier{}
+}
+
+func (a *actionsNotifier) NotifyPushCommits(pusher *models.User, repo *models.Repository, refName, oldCommitID, newCommitID string, commits *models.PushCommits) {
+	if newCommitID == git.EmptySHA {
+		return
+	}
+	if !strings.HasPrefix(refName, git.BranchPrefix) && !strings.HasPrefix(refName, git.TagPrefix) {
+		return
+	}
+
+	actions_service.Enqueue(&actions_service.DetectOptions{
+		RepoID:    repo.ID,
+		DoerID:    pusher.ID,
+		Event:     actions_module.EventPush,
+		MatchRef:  refName,
+		Ref:       refName,
+		CommitSHA: newCommitID,
+	})
+}
+
+func (a *actionsNotifier) NotifyNewPullRequest(pr *models.PullRequest) {
+	if err := pr.LoadIssue(); err != nil {
+		log.Error("LoadIssue: %v", err)
+		return
+	}
+	a.notifyPullRequest(pr.Issue.PosterID, pr)
+}
+
+func (a *actionsNotifier) NotifyPullRequestSynchronized(doer *models.User, pr *models.PullRequest) {
+	a.notifyPullRequest(doer.ID, pr)
+}
+
+func (a *actionsNotifier) notifyPullRequest(doerID int64, pr *models.PullRequest) {
+	if err := pr.LoadHeadRepo(); err != nil {
+		log.Error("LoadHeadRepo: %v", err)
+		return
+	}
+	if pr.HeadRepo == nil {
+		return
+	}
+
+	// The pull request ref of the base repository may not be updated yet,
+	// so the commit is resolved in the head repository.
+	headRepo, err := git.OpenRepository(pr.HeadRepo.RepoPath())
+	if err != nil {
+		log.Error("OpenRepository: %v", err)
+		return
+	}
+	defer headRepo.Close()
+
+	commitID, err := headRepo.GetBranchCommitID(pr.HeadBranch)
+	if err != nil {
+		log.Error("GetBranchCommitID: %v", err)
+		return
+	}
+
+	actions_service.Enqueue(&actions_service.DetectOptions{
+		RepoID:       pr.BaseRepoID,
+		SourceRepoID: pr.HeadRepoID,
+		DoerID:       doerID,
+		Event:        actions_module.EventPullRequest,
+		MatchRef:     pr.BaseBranch,
+		Ref:          pr.GetGitRefName(),
+		CommitSHA:    commitID,
+	})
+}
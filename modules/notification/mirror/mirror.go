@@ -0,0 +1,44 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mirror
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	mirror_module "code.gitea.io/gitea/modules/mirror"
+	"code.gitea.io/gitea/modules/notification/base"
+)
+
+type mirrorNotifier struct {
+	base.NullNotifier
+}
+
+var (
+	_ base.Notifier = &mirrorNotifier{}
+)
+
+// NewNotifier create a new mirrorNotifier notifier
+func NewNotifier() base.Notifier {
+	return &mirrorNotifier{}
+}
+
+func (m *mirrorNotifier) NotifyPushCommits(pusher *models.User, repo *models.Repository, refName, oldCommitID, newCommitID string, commits *models.PushCommits) {
+	syncPushMirrorsOnCommit(repo)
+}
+
+func (m *mirrorNotifier) NotifySyncPushCommits(pusher *models.User, repo *models.Repository, refName, oldCommitID, newCommitID string, commits *models.PushCommits) {
+	syncPushMirrorsOnCommit(repo)
+}
+
+func syncPushMirrorsOnCommit(repo *models.Repository) {
+	mirrors, err := models.GetPushMirrorsSyncedOnCommit(repo.ID)
+	if err != nil {
+		log.Error("GetPushMirrorsSyncedOnCommit[%d]: %v", repo.ID, err)
+		return
+	}
+	for _, m := range mirrors {
+		mirror_module.AddPushMirrorToQueue(m.ID)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+)
+
+// Media types of the supported manifests
+const (
+	ContentTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	ContentTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	ContentTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	ContentTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+// ErrUnsupportedManifest indicates a manifest with an unsupported schema or media type
+var ErrUnsupportedManifest = errors.New("Manifest is not supported")
+
+var (
+	imageNamePattern = regexp.MustCompile(`\A[a-z0-9]+([._-][a-z0-9]+)*(/[a-z0-9]+([._-][a-z0-9]+)*)*\z`)
+	referencePattern = regexp.MustCompile(`\A[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}\z`)
+	digestPattern    = regexp.MustCompile(`\Asha256:[a-f0-9]{64}\z`)
+)
+
+// IsValidImageName checks if the name is a valid image name
+func IsValidImageName(name string) bool {
+	return len(name) <= 255 && imageNamePattern.MatchString(name)
+}
+
+// IsValidTag checks if the reference is a valid tag
+func IsValidTag(reference string) bool {
+	return referencePattern.MatchString(reference)
+}
+
+// IsValidDigest checks if the reference is a valid sha256 digest
+func IsValidDigest(reference string) bool {
+	return digestPattern.MatchString(reference)
+}
+
+// Platform describes the platform of an image referenced by an index
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// Descriptor references content by its digest
+type Descriptor struct {
+	MediaType string    `json:"mediaType"`
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	Platform  *Platform `json:"platform,omitempty"`
+}
+
+// Manifest is an image manifest or an image index
+type Manifest struct {
+	SchemaVersion int           `json:"schemaVersion"`
+	MediaType     string        `json:"mediaType,omitempty"`
+	Config        *Descriptor   `json:"config,omitempty"`
+	Layers        []*Descriptor `json:"layers,omitempty"`
+	Manifests     []*Descriptor `json:"manifests,omitempty"`
+}
+
+// IsIndex returns true if the manifest references other manifests
+func (m *Manifest) IsIndex() bool {
+	return m.MediaType == ContentTypeDockerManifestList || m.MediaType == ContentTypeOCIIndex
+}
+
+// ParseManifest parses a manifest. The media type of the request is used if the
+// manifest does not contain one.
+func ParseManifest(r io.Reader, contentType string) (*Manifest, error) {
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+	if m.SchemaVersion != 2 {
+		return nil, ErrUnsupportedManifest
+	}
+	if m.MediaType == "" {
+		m.MediaType = contentType
+	}
+
+	switch m.MediaType {
+	case ContentTypeDockerManifest, ContentTypeOCIManifest:
+		if m.Config == nil || !IsValidDigest(m.Config.Digest) {
+			return nil, ErrUnsupportedManifest
+		}
+		for _, l := range m.Layers {
+			if !IsValidDigest(l.Digest) {
+				return nil, ErrUnsupportedManifest
+			}
+		}
+	case ContentTypeDockerManifestList, ContentTypeOCIIndex:
+		for _, ref := range m.Manifests {
+			if !IsValidDigest(ref.Digest) {
+				return nil, ErrUnsupportedManifest
+			}
+		}
+	default:
+		return nil, ErrUnsupportedManifest
+	}
+	return &m, nil
+}
+
+// Metadata is the information stored for a container image version
+type Metadata struct {
+	MediaType string      `json:"media_type"`
+	Size      int64       `json:"size"`
+	Platforms []*Platform `json:"platforms,omitempty"`
+}
+
+// Metadata returns the metadata of the manifest
+func (m *Manifest) Metadata() *Metadata {
+	md := &Metadata{MediaType: m.MediaType}
+	if m.Config != nil {
+		md.Size += m.Config.Size
+	}
+	for _, l := range m.Layers {
+		md.Size += l.Size
+	}
+	for _, ref := range m.Manifests {
+		if ref.Platform != nil {
+			md.Platforms = append(md.Platforms, ref.Platform)
+		}
+	}
+	return md
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	digestA = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	digestB = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+)
+
+func TestParseManifest(t *testing.T) {
+	m, err := ParseManifest(strings.NewReader(`{
+		"schemaVersion": 2,
+		"mediaType": "`+ContentTypeDockerManifest+`",
+		"config": {"mediaType": "application/vnd.docker.container.image.v1+json", "digest": "`+digestA+`", "size": 10},
+		"layers": [{"mediaType": "application/vnd.docker.image.rootfs.diff.tar.gzip", "digest": "`+digestB+`", "size": 20}]
+	}`), "")
+	assert.NoError(t, err)
+	assert.False(t, m.IsIndex())
+	assert.Equal(t, &Metadata{MediaType: ContentTypeDockerManifest, Size: 30}, m.Metadata())
+
+	m, err = ParseManifest(strings.NewReader(`{
+		"schemaVersion": 2,
+		"manifests": [{"mediaType": "`+ContentTypeOCIManifest+`", "digest": "`+digestA+`", "size": 10, "platform": {"architecture": "amd64", "os": "linux"}}]
+	}`), ContentTypeOCIIndex)
+	assert.NoError(t, err)
+	assert.True(t, m.IsIndex())
+	assert.Equal(t, []*Platform{{Architecture: "amd64", OS: "linux"}}, m.Metadata().Platforms)
+
+	_, err = ParseManifest(strings.NewReader(`{"schemaVersion": 1}`), ContentTypeDockerManifest)
+	assert.Equal(t, ErrUnsupportedManifest, err)
+
+	_, err = ParseManifest(strings.NewReader(`{"schemaVersion": 2, "config": {"digest": "invalid"}}`), ContentTypeOCIManifest)
+	assert.Equal(t, ErrUnsupportedManifest, err)
+}
+
+func TestValidation(t *testing.T) {
+	assert.True(t, IsValidImageName("test"))
+	assert.True(t, IsValidImageName("test/sub-image_1.0"))
+	assert.False(t, IsValidImageName("Test"))
+	assert.False(t, IsValidImageName("test/"))
+
+	assert.True(t, IsValidTag("latest"))
+	assert.True(t, IsValidTag("1.0.0-rc1"))
+	assert.False(t, IsValidTag("-latest"))
+
+	assert.True(t, IsValidDigest(digestA))
+	assert.False(t, IsValidDigest("sha256:abc"))
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"code.gitea.io/gitea/modules/storage"
+)
+
+// ContentStore is a storage of package blobs on top of an ObjectStorage.
+// Blobs are addressed by their sha256 hash.
+type ContentStore struct {
+	storage.ObjectStorage
+}
+
+// NewContentStore returns the content store of the configured package storage
+func NewContentStore() *ContentStore {
+	return &ContentStore{ObjectStorage: storage.Packages}
+}
+
+// Get returns the content of the blob with the given hash
+func (s *ContentStore) Get(hashSHA256 string) (storage.Object, error) {
+	return s.Open(relativePath(hashSHA256))
+}
+
+// Save writes the content of the reader to the store
+func (s *ContentStore) Save(hashSHA256 string, r io.Reader) error {
+	_, err := s.ObjectStorage.Save(relativePath(hashSHA256), r)
+	return err
+}
+
+// Exists returns true if the blob exists in the store
+func (s *ContentStore) Exists(hashSHA256 string) bool {
+	_, err := s.Stat(relativePath(hashSHA256))
+	return err == nil
+}
+
+// Delete removes the blob from the store
+func (s *ContentStore) Delete(hashSHA256 string) error {
+	if err := s.ObjectStorage.Delete(relativePath(hashSHA256)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// relativePath returns the path of the blob in the storage
+func relativePath(hashSHA256 string) string {
+	key := strings.ToLower(hashSHA256)
+	if len(key) < 5 {
+		return key
+	}
+	return path.Join(key[0:2], key[2:4], key[4:])
+}
@@ -0,0 +1,44 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitea-packages-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	local, err := storage.NewLocalStorage(dir)
+	assert.NoError(t, err)
+	s := &ContentStore{ObjectStorage: local}
+
+	hash := "9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08"
+	assert.False(t, s.Exists(hash))
+	assert.NoError(t, s.Save(hash, strings.NewReader("test")))
+	assert.True(t, s.Exists(hash))
+	assert.FileExists(t, filepath.Join(dir, "9f", "86", "d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"))
+
+	f, err := s.Get(hash)
+	assert.NoError(t, err)
+	bs, err := ioutil.ReadAll(f)
+	f.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, "test", string(bs))
+
+	assert.NoError(t, s.Delete(hash))
+	assert.False(t, s.Exists(hash))
+	assert.NoError(t, s.Delete(hash))
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// ErrFileTooLarge is returned if the uploaded content exceeds the allowed size
+var ErrFileTooLarge = errors.New("File is too large")
+
+// HashedBuffer spools content to a temporary file and calculates the hashes
+// of the content on the way. It can be read multiple times by seeking back.
+type HashedBuffer struct {
+	file *os.File
+	size int64
+
+	HashMD5    string
+	HashSHA1   string
+	HashSHA256 string
+	HashSHA512 string
+}
+
+// NewHashedBuffer reads all content of r into a new HashedBuffer.
+// A maxSize greater than zero limits the number of accepted bytes.
+func NewHashedBuffer(r io.Reader, maxSize int64) (*HashedBuffer, error) {
+	file, err := ioutil.TempFile("", "gitea-package-")
+	if err != nil {
+		return nil, err
+	}
+	hb := &HashedBuffer{file: file}
+
+	if maxSize > 0 {
+		r = io.LimitReader(r, maxSize+1)
+	}
+
+	hashMD5, hashSHA1, hashSHA256, hashSHA512 := md5.New(), sha1.New(), sha256.New(), sha512.New()
+	hb.size, err = io.Copy(io.MultiWriter(file, hashMD5, hashSHA1, hashSHA256, hashSHA512), r)
+	if err == nil && maxSize > 0 && hb.size > maxSize {
+		err = ErrFileTooLarge
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		hb.Close()
+		return nil, err
+	}
+
+	hb.HashMD5 = hexSum(hashMD5)
+	hb.HashSHA1 = hexSum(hashSHA1)
+	hb.HashSHA256 = hexSum(hashSHA256)
+	hb.HashSHA512 = hexSum(hashSHA512)
+	return hb, nil
+}
+
+func hexSum(h hash.Hash) string {
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Size returns the number of bytes in the buffer
+func (hb *HashedBuffer) Size() int64 {
+	return hb.size
+}
+
+// Read implements io.Reader
+func (hb *HashedBuffer) Read(p []byte) (int, error) {
+	return hb.file.Read(p)
+}
+
+// Seek implements io.Seeker
+func (hb *HashedBuffer) Seek(offset int64, whence int) (int64, error) {
+	return hb.file.Seek(offset, whence)
+}
+
+// Close removes the temporary file of the buffer
+func (hb *HashedBuffer) Close() error {
+	hb.file.Close()
+	return os.Remove(hb.file.Name())
+}
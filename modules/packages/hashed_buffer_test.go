@@ -0,0 +1,37 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashedBuffer(t *testing.T) {
+	hb, err := NewHashedBuffer(strings.NewReader("test"), 0)
+	assert.NoError(t, err)
+	defer hb.Close()
+
+	assert.EqualValues(t, 4, hb.Size())
+	assert.Equal(t, "098f6bcd4621d373cade4e832627b4f6", hb.HashMD5)
+	assert.Equal(t, "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3", hb.HashSHA1)
+	assert.Equal(t, "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", hb.HashSHA256)
+	assert.Equal(t, "ee26b0dd4af7e749aa1a8ee3c10ae9923f618980772e473f8819a5d4940e0db27ac185f8a0e1d5f84f88bc887fd67b143732c304cc5fa9ad8e6f57f50028a8ff", hb.HashSHA512)
+
+	for i := 0; i < 2; i++ {
+		data, err := ioutil.ReadAll(hb)
+		assert.NoError(t, err)
+		assert.Equal(t, "test", string(data))
+		_, err = hb.Seek(0, io.SeekStart)
+		assert.NoError(t, err)
+	}
+
+	_, err = NewHashedBuffer(strings.NewReader("test"), 3)
+	assert.Equal(t, ErrFileTooLarge, err)
+}
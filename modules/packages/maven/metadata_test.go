@@ -0,0 +1,52 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package maven
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const pomContent = `<?xml version="1.0"?>
+<project xmlns="http://maven.apache.org/POM/4.0.0">
+  <parent>
+    <groupId>org.example</groupId>
+  </parent>
+  <artifactId>test-project</artifactId>
+  <version>1.0</version>
+  <name>Test Project</name>
+  <description>A test project</description>
+  <url>https://gitea.io</url>
+  <licenses>
+    <license>
+      <name>MIT</name>
+    </license>
+  </licenses>
+  <dependencies>
+    <dependency>
+      <groupId>junit</groupId>
+      <artifactId>junit</artifactId>
+      <version>4.12</version>
+    </dependency>
+  </dependencies>
+</project>`
+
+func TestParsePackageMetaData(t *testing.T) {
+	m, err := ParsePackageMetaData(strings.NewReader(pomContent))
+	assert.NoError(t, err)
+	assert.Equal(t, "org.example", m.GroupID)
+	assert.Equal(t, "test-project", m.ArtifactID)
+	assert.Equal(t, "Test Project", m.Name)
+	assert.Equal(t, "A test project", m.Description)
+	assert.Equal(t, "https://gitea.io", m.ProjectURL)
+	assert.Equal(t, []string{"MIT"}, m.Licenses)
+	assert.Len(t, m.Dependencies, 1)
+	assert.Equal(t, &Dependency{GroupID: "junit", ArtifactID: "junit", Version: "4.12"}, m.Dependencies[0])
+
+	_, err = ParsePackageMetaData(strings.NewReader("no xml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,230 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package npm
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidPackage indicates an invalid package
+	ErrInvalidPackage = errors.New("The package is invalid")
+	// ErrInvalidPackageName indicates an invalid name
+	ErrInvalidPackageName = errors.New("The package name is invalid")
+	// ErrInvalidPackageVersion indicates an invalid version
+	ErrInvalidPackageVersion = errors.New("The package version is invalid")
+	// ErrInvalidAttachment indicates a invalid attachment
+	ErrInvalidAttachment = errors.New("The package attachment is invalid")
+	// ErrInvalidIntegrity indicates an integrity validation error
+	ErrInvalidIntegrity = errors.New("Failed to validate integrity")
+)
+
+var (
+	nameRegex    = regexp.MustCompile(`\A((@[^\s\/~'!\(\)\*]+?)[\/])?([^_.][^\s\/~'!\(\)\*]+)\z`)
+	versionRegex = regexp.MustCompile(`\A(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(?:-[0-9A-Za-z\-\.]+)?(?:\+[0-9A-Za-z\-\.]+)?\z`)
+)
+
+// PackageMetadataVersion is the metadata of a single version as sent by the npm client
+type PackageMetadataVersion struct {
+	ID                   string              `json:"_id"`
+	Name                 string              `json:"name"`
+	Version              string              `json:"version"`
+	Description          string              `json:"description,omitempty"`
+	Author               json.RawMessage     `json:"author,omitempty"`
+	Homepage             string              `json:"homepage,omitempty"`
+	License              string              `json:"license,omitempty"`
+	Keywords             []string            `json:"keywords,omitempty"`
+	Dependencies         map[string]string   `json:"dependencies,omitempty"`
+	DevDependencies      map[string]string   `json:"devDependencies,omitempty"`
+	PeerDependencies     map[string]string   `json:"peerDependencies,omitempty"`
+	OptionalDependencies map[string]string   `json:"optionalDependencies,omitempty"`
+	Bin                  json.RawMessage     `json:"bin,omitempty"`
+	Main                 string              `json:"main,omitempty"`
+	Dist                 PackageDistribution `json:"dist"`
+}
+
+// PackageDistribution describes the tarball of a version
+type PackageDistribution struct {
+	Integrity string `json:"integrity"`
+	Shasum    string `json:"shasum"`
+	Tarball   string `json:"tarball"`
+}
+
+// PackageAttachment is an attachment of the publish request
+type PackageAttachment struct {
+	ContentType string `json:"content_type"`
+	Data        string `json:"data"`
+	Length      int    `json:"length"`
+}
+
+// publishRequest is the body of a publish request of the npm client
+type publishRequest struct {
+	ID          string                             `json:"_id"`
+	Name        string                             `json:"name"`
+	Description string                             `json:"description"`
+	DistTags    map[string]string                  `json:"dist-tags,omitempty"`
+	Versions    map[string]*PackageMetadataVersion `json:"versions"`
+	Readme      string                             `json:"readme,omitempty"`
+	Attachments map[string]*PackageAttachment      `json:"_attachments"`
+}
+
+// Package represents a parsed npm package
+type Package struct {
+	Name     string
+	Version  string
+	DistTags []string
+	Metadata *PackageMetadataVersion
+	Readme   string
+	Filename string
+	Data     []byte
+}
+
+// PackageNameWithoutScope returns the package name without the optional scope
+func (p *Package) PackageNameWithoutScope() string {
+	if idx := strings.Index(p.Name, "/"); idx != -1 {
+		return p.Name[idx+1:]
+	}
+	return p.Name
+}
+
+// IsValidPackageName checks if the name is a valid npm package name
+func IsValidPackageName(name string) bool {
+	return nameRegex.MatchString(name) && len(name) <= 214
+}
+
+// ParsePackage parses the content of a publish request
+func ParsePackage(r io.Reader) (*Package, error) {
+	var req publishRequest
+	if err := json.NewDecoder(r).Decode(&req); err != nil {
+		return nil, err
+	}
+
+	if !IsValidPackageName(req.Name) {
+		return nil, ErrInvalidPackageName
+	}
+
+	if len(req.Versions) != 1 || len(req.Attachments) != 1 {
+		return nil, ErrInvalidPackage
+	}
+
+	for _, meta := range req.Versions {
+		if meta.Name != req.Name {
+			return nil, ErrInvalidPackageName
+		}
+		if !versionRegex.MatchString(meta.Version) {
+			return nil, ErrInvalidPackageVersion
+		}
+
+		filename, attachment := singleAttachment(req.Attachments)
+		if attachment == nil || len(attachment.Data) == 0 {
+			return nil, ErrInvalidAttachment
+		}
+
+		data, err := base64.StdEncoding.DecodeString(attachment.Data)
+		if err != nil {
+			return nil, ErrInvalidAttachment
+		}
+		if !validateIntegrity(meta.Dist, data) {
+			return nil, ErrInvalidIntegrity
+		}
+
+		p := &Package{
+			Name:     req.Name,
+			Version:  meta.Version,
+			DistTags: make([]string, 0, 1),
+			Metadata: meta,
+			Readme:   req.Readme,
+			Filename: filename[strings.LastIndex(filename, "/")+1:],
+			Data:     data,
+		}
+		for tag, version := range req.DistTags {
+			if version == meta.Version {
+				p.DistTags = append(p.DistTags, tag)
+			}
+		}
+		return p, nil
+	}
+	return nil, ErrInvalidPackage
+}
+
+func singleAttachment(attachments map[string]*PackageAttachment) (string, *PackageAttachment) {
+	for filename, attachment := range attachments {
+		return filename, attachment
+	}
+	return "", nil
+}
+
+func validateIntegrity(dist PackageDistribution, data []byte) bool {
+	if dist.Shasum != "" {
+		sum := sha1.Sum(data)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), dist.Shasum) {
+			return false
+		}
+	}
+	if dist.Integrity != "" {
+		for _, part := range strings.Fields(dist.Integrity) {
+			if !strings.HasPrefix(part, "sha512-") {
+				continue
+			}
+			sum := sha512.Sum512(data)
+			if part[len("sha512-"):] != base64.StdEncoding.EncodeToString(sum[:]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// IntegrityFromSHA512 returns the subresource integrity string of the given sha512 hash
+func IntegrityFromSHA512(hashSHA512 string) string {
+	raw, err := hex.DecodeString(hashSHA512)
+	if err != nil {
+		return ""
+	}
+	return "sha512-" + base64.StdEncoding.EncodeToString(raw)
+}
+
+// TarballFilename returns the canonical file name of the tarball of the given package name and version
+func TarballFilename(name, version string) string {
+	if idx := strings.Index(name, "/"); idx != -1 {
+		name = name[idx+1:]
+	}
+	return fmt.Sprintf("%s-%s.tgz", name, version)
+}
+
+// NewReader returns a reader of the package data
+func (p *Package) NewReader() io.Reader {
+	return bytes.NewReader(p.Data)
+}
+
+// Metadata is the information stored for a npm package version
+type Metadata struct {
+	Version  *PackageMetadataVersion `json:"version"`
+	Readme   string                  `json:"readme,omitempty"`
+	DistTags []string                `json:"dist_tags,omitempty"`
+}
+
+// PackageMetadata is the response of the package metadata endpoint
+// https://github.com/npm/registry/blob/master/docs/responses/package-metadata.md
+type PackageMetadata struct {
+	ID          string                             `json:"_id"`
+	Name        string                             `json:"name"`
+	Description string                             `json:"description,omitempty"`
+	DistTags    map[string]string                  `json:"dist-tags"`
+	Versions    map[string]*PackageMetadataVersion `json:"versions"`
+	Time        map[string]time.Time               `json:"time"`
+	Readme      string                             `json:"readme,omitempty"`
+}
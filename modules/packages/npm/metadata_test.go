@@ -0,0 +1,84 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package npm
+
+import (
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func publishBody(name, version, shasum string, data []byte) string {
+	return fmt.Sprintf(`{
+		"_id": "%[1]s",
+		"name": "%[1]s",
+		"dist-tags": {"latest": "%[2]s"},
+		"versions": {
+			"%[2]s": {
+				"name": "%[1]s",
+				"version": "%[2]s",
+				"description": "Test package",
+				"dist": {"shasum": "%[3]s"}
+			}
+		},
+		"readme": "# Test",
+		"_attachments": {
+			"%[1]s-%[2]s.tgz": {"content_type": "application/octet-stream", "data": "%[4]s", "length": %[5]d}
+		}
+	}`, name, version, shasum, base64.StdEncoding.EncodeToString(data), len(data))
+}
+
+func TestParsePackage(t *testing.T) {
+	data := []byte("package content")
+	sum := sha1.Sum(data)
+	shasum := hex.EncodeToString(sum[:])
+
+	p, err := ParsePackage(strings.NewReader(publishBody("@scope/test-package", "1.0.1", shasum, data)))
+	assert.NoError(t, err)
+	assert.Equal(t, "@scope/test-package", p.Name)
+	assert.Equal(t, "test-package", p.PackageNameWithoutScope())
+	assert.Equal(t, "1.0.1", p.Version)
+	assert.Equal(t, []string{"latest"}, p.DistTags)
+	assert.Equal(t, "# Test", p.Readme)
+	assert.Equal(t, "test-package-1.0.1.tgz", p.Filename)
+	assert.Equal(t, data, p.Data)
+	assert.Equal(t, "Test package", p.Metadata.Description)
+
+	_, err = ParsePackage(strings.NewReader(publishBody("_invalid", "1.0.1", shasum, data)))
+	assert.Equal(t, ErrInvalidPackageName, err)
+
+	_, err = ParsePackage(strings.NewReader(publishBody("test-package", "1.0", shasum, data)))
+	assert.Equal(t, ErrInvalidPackageVersion, err)
+
+	_, err = ParsePackage(strings.NewReader(publishBody("test-package", "1.0.1", "0000", data)))
+	assert.Equal(t, ErrInvalidIntegrity, err)
+}
+
+func TestIsValidPackageName(t *testing.T) {
+	assert.True(t, IsValidPackageName("test"))
+	assert.True(t, IsValidPackageName("@scope/test"))
+	assert.True(t, IsValidPackageName("test.package-name_1"))
+	assert.False(t, IsValidPackageName(".test"))
+	assert.False(t, IsValidPackageName("_test"))
+	assert.False(t, IsValidPackageName("te st"))
+	assert.False(t, IsValidPackageName("@scope/"))
+}
+
+func TestIntegrityFromSHA512(t *testing.T) {
+	sum := sha512.Sum512([]byte("test"))
+	assert.Equal(t, "sha512-"+base64.StdEncoding.EncodeToString(sum[:]), IntegrityFromSHA512(hex.EncodeToString(sum[:])))
+	assert.Empty(t, IntegrityFromSHA512("invalid"))
+}
+
+func TestTarballFilename(t *testing.T) {
+	assert.Equal(t, "test-1.0.0.tgz", TarballFilename("test", "1.0.0"))
+	assert.Equal(t, "test-1.0.0.tgz", TarballFilename("@scope/test", "1.0.0"))
+}
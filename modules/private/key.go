@@ -52,3 +52,24 @@ func AuthorizedPublicKeyByContent(content string) (string, error) {
 
 	return string(bs), err
 }
+
+// AuthorizedPrincipalsByCertificate checks the provided SSH certificate and
+// returns the authorized principals line for the user it maps to.
+func AuthorizedPrincipalsByCertificate(content string) (string, error) {
+	reqURL := setting.LocalURL + "api/internal/ssh/authorized_principals"
+	req := newInternalRequest(reqURL, "POST")
+	req.Param("content", content)
+	resp, err := req.Response()
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Failed to check SSH certificate: %s", decodeJSONError(resp).Err)
+	}
+	bs, err := ioutil.ReadAll(resp.Body)
+
+	return string(bs), err
+}
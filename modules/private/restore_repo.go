@@ -0,0 +1,44 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package private
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// RestoreRepoOptions represents the options to restore a repository dump
+type RestoreRepoOptions struct {
+	File      string
+	OwnerName string
+	RepoName  string
+}
+
+// RestoreRepo asks the running gitea to restore a repository from a dump file
+func RestoreRepo(opts RestoreRepoOptions) (int, string) {
+	reqURL := setting.LocalURL + "api/internal/restore_repo"
+
+	req := newInternalRequest(reqURL, "POST")
+	req = req.Header("Content-Type", "application/json")
+	jsonBytes, _ := json.Marshal(opts)
+	req.Body(jsonBytes)
+	// restoring a big repository may take a long time
+	req.SetTimeout(60*time.Second, 2*time.Hour)
+	resp, err := req.Response()
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Sprintf("Unable to contact gitea: %v", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, decodeJSONError(resp).Err
+	}
+
+	return http.StatusOK, fmt.Sprintf("Restored %s/%s successfully", opts.OwnerName, opts.RepoName)
+}
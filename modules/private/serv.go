@@ -20,10 +20,11 @@ type KeyAndOwner struct {
 	Owner *models.User      `json:"user"`
 }
 
-// ServNoCommand returns information about the provided key
-func ServNoCommand(keyID int64) (*models.PublicKey, *models.User, error) {
-	reqURL := setting.LocalURL + fmt.Sprintf("api/internal/serv/none/%d",
-		keyID)
+// ServNoCommand returns information about the provided key, or about the
+// user when authenticated by SSH certificate in which case keyID is 0
+func ServNoCommand(keyID, userID int64) (*models.PublicKey, *models.User, error) {
+	reqURL := setting.LocalURL + fmt.Sprintf("api/internal/serv/none/%d?user_id=%d",
+		keyID, userID)
 	resp, err := newInternalRequest(reqURL, "GET").Response()
 	if err != nil {
 		return nil, nil, err
@@ -71,13 +72,15 @@ func IsErrServCommand(err error) bool {
 	return ok
 }
 
-// ServCommand preps for a serv call
-func ServCommand(keyID int64, ownerName, repoName string, mode models.AccessMode, verbs ...string) (*ServCommandResults, error) {
-	reqURL := setting.LocalURL + fmt.Sprintf("api/internal/serv/command/%d/%s/%s?mode=%d",
+// ServCommand preps for a serv call, userID is used instead of keyID for
+// users authenticated by SSH certificate
+func ServCommand(keyID, userID int64, ownerName, repoName string, mode models.AccessMode, verbs ...string) (*ServCommandResults, error) {
+	reqURL := setting.LocalURL + fmt.Sprintf("api/internal/serv/command/%d/%s/%s?mode=%d&user_id=%d",
 		keyID,
 		url.PathEscape(ownerName),
 		url.PathEscape(repoName),
-		mode)
+		mode,
+		userID)
 	for _, verb := range verbs {
 		if verb != "" {
 			reqURL += fmt.Sprintf("&verb=%s", url.QueryEscape(verb))
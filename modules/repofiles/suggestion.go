@@ -0,0 +1,227 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repofiles
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/lfs"
+	"code.gitea.io/gitea/modules/log"
+)
+
+// ApplySuggestionsOptions holds the options to apply suggestions of code comments
+type ApplySuggestionsOptions struct {
+	Message  string
+	Comments []*models.Comment
+}
+
+// ApplySuggestions commits the suggestions of the given code comments to the
+// head branch of the pull request and returns the ID of the new commit. The
+// posters of the suggestions are credited as co-authors of the commit.
+func ApplySuggestions(pr *models.PullRequest, doer *models.User, opts *ApplySuggestionsOptions) (string, error) {
+	if len(opts.Comments) == 0 {
+		return "", fmt.Errorf("no suggestions to apply")
+	}
+	if err := pr.LoadHeadRepo(); err != nil {
+		return "", err
+	}
+	repo := pr.HeadRepo
+
+	// Collect the suggestions by file and line, there may only be one per line
+	files := make(map[string]map[int64]*models.Comment)
+	for _, comment := range opts.Comments {
+		if comment.IssueID != pr.IssueID || comment.Type != models.CommentTypeCode {
+			return "", models.ErrSuggestionNotApplicable{CommentID: comment.ID, Reason: "not a code comment of the pull request"}
+		}
+		if err := comment.LoadReview(); err != nil && !models.IsErrReviewNotExist(err) {
+			return "", err
+		}
+		if !comment.IsReviewSubmitted() {
+			return "", models.ErrSuggestionNotApplicable{CommentID: comment.ID, Reason: "review is not submitted"}
+		}
+		if !comment.CanApplySuggestion() {
+			return "", models.ErrSuggestionNotApplicable{CommentID: comment.ID, Reason: "comment has no applicable suggestion"}
+		}
+		if files[comment.TreePath] == nil {
+			files[comment.TreePath] = make(map[int64]*models.Comment)
+		}
+		if _, ok := files[comment.TreePath][comment.Line]; ok {
+			return "", models.ErrSuggestionNotApplicable{CommentID: comment.ID, Reason: "another suggestion changes the same line"}
+		}
+		files[comment.TreePath][comment.Line] = comment
+	}
+
+	if protected, _ := repo.IsProtectedBranchForPush(pr.HeadBranch, doer); protected {
+		return "", models.ErrUserCannotCommit{UserName: doer.LowerName}
+	}
+
+	t, err := NewTemporaryUploadRepository(repo)
+	if err != nil {
+		return "", err
+	}
+	defer t.Close()
+	if err := t.Clone(pr.HeadBranch); err != nil {
+		return "", err
+	}
+	if err := t.SetDefaultIndex(); err != nil {
+		return "", err
+	}
+	commit, err := t.GetBranchCommit(pr.HeadBranch)
+	if err != nil {
+		return "", err
+	}
+
+	treePaths := make([]string, 0, len(files))
+	for treePath := range files {
+		treePaths = append(treePaths, treePath)
+	}
+	sort.Strings(treePaths)
+
+	for _, treePath := range treePaths {
+		entry, err := commit.GetTreeEntryByPath(treePath)
+		if err != nil {
+			if git.IsErrNotExist(err) {
+				return "", models.ErrSuggestionNotApplicable{CommentID: anyComment(files[treePath]).ID, Reason: "file does not exist anymore"}
+			}
+			return "", err
+		}
+		if !entry.IsRegular() && !entry.IsExecutable() {
+			return "", models.ErrSuggestionNotApplicable{CommentID: anyComment(files[treePath]).ID, Reason: "not a regular file"}
+		}
+
+		content, err := readEntryContent(entry)
+		if err != nil {
+			return "", err
+		}
+		if lfs.IsPointerFile(&content) != nil {
+			return "", models.ErrSuggestionNotApplicable{CommentID: anyComment(files[treePath]).ID, Reason: "file is stored in LFS"}
+		}
+
+		newContent, err := applySuggestionsToContent(string(content), files[treePath])
+		if err != nil {
+			return "", err
+		}
+
+		objectHash, err := t.HashObject(strings.NewReader(newContent))
+		if err != nil {
+			return "", err
+		}
+		mode := "100644"
+		if entry.IsExecutable() {
+			mode = "100755"
+		}
+		if err := t.AddObjectToIndex(mode, objectHash, treePath); err != nil {
+			return "", err
+		}
+	}
+
+	treeHash, err := t.WriteTree()
+	if err != nil {
+		return "", err
+	}
+
+	message := strings.TrimSpace(opts.Message)
+	if message == "" {
+		if len(opts.Comments) == 1 {
+			message = "Apply suggestion from code review"
+		} else {
+			message = "Apply suggestions from code review"
+		}
+	}
+	message += suggestionCoAuthors(doer, opts.Comments)
+
+	commitHash, err := t.CommitTree(doer, doer, treeHash, message)
+	if err != nil {
+		return "", err
+	}
+
+	// Record the commit before pushing, the push invalidates the comments and
+	// the invalidation check must not reset the applied state
+	if err := models.UpdateCommentsSuggestionCommit(opts.Comments, commitHash); err != nil {
+		return "", err
+	}
+	if err := t.Push(doer, commitHash, pr.HeadBranch); err != nil {
+		if resetErr := models.UpdateCommentsSuggestionCommit(opts.Comments, ""); resetErr != nil {
+			log.Error("Unable to reset applied suggestions of pull request %d: %v", pr.ID, resetErr)
+		}
+		return "", err
+	}
+	return commitHash, nil
+}
+
+func anyComment(comments map[int64]*models.Comment) *models.Comment {
+	for _, comment := range comments {
+		return comment
+	}
+	return nil
+}
+
+func readEntryContent(entry *git.TreeEntry) ([]byte, error) {
+	dataRc, err := entry.Blob().DataAsync()
+	if err != nil {
+		return nil, err
+	}
+	defer dataRc.Close()
+	return ioutil.ReadAll(dataRc)
+}
+
+// applySuggestionsToContent replaces the commented lines of a file by the
+// suggested lines, keeping the line endings of the file
+func applySuggestionsToContent(content string, comments map[int64]*models.Comment) (string, error) {
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	for line, comment := range comments {
+		if line > int64(len(lines)) {
+			return "", models.ErrSuggestionNotApplicable{CommentID: comment.ID, Reason: "line does not exist anymore"}
+		}
+		current := lines[line-1]
+		text := strings.TrimSuffix(strings.TrimSuffix(current, "\n"), "\r")
+		if original, ok := comment.CommentedLine(); ok && original != text {
+			return "", models.ErrSuggestionNotApplicable{CommentID: comment.ID, Reason: "line was changed"}
+		}
+
+		suggestion, _ := comment.Suggestion()
+		suggested := models.SuggestionLines(suggestion)
+		eol := current[len(text):]
+		separator := eol
+		if separator == "" {
+			separator = "\n"
+		}
+		replacement := strings.Join(suggested, separator)
+		if len(suggested) > 0 {
+			replacement += eol
+		}
+		lines[line-1] = replacement
+	}
+	return strings.Join(lines, ""), nil
+}
+
+// suggestionCoAuthors returns the trailers crediting the posters of the suggestions
+func suggestionCoAuthors(doer *models.User, comments []*models.Comment) string {
+	var trailers strings.Builder
+	seen := map[int64]bool{doer.ID: true}
+	for _, comment := range comments {
+		if seen[comment.PosterID] {
+			continue
+		}
+		seen[comment.PosterID] = true
+		if err := comment.LoadPoster(); err != nil || comment.Poster == nil || comment.Poster.ID <= 0 {
+			continue
+		}
+		trailers.WriteString("\nCo-authored-by: " + comment.Poster.NewGitSig().String())
+	}
+	if trailers.Len() == 0 {
+		return ""
+	}
+	return "\n" + trailers.String()
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repofiles
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplySuggestionsToContent(t *testing.T) {
+	codeComment := func(line int64, original, suggestion string) *models.Comment {
+		return &models.Comment{
+			ID:      line,
+			Type:    models.CommentTypeCode,
+			Line:    line,
+			Content: "```suggestion\n" + suggestion + "```",
+			Patch:   "@@ -1,1 +1,1 @@\n+" + original,
+		}
+	}
+
+	content, err := applySuggestionsToContent("a\r\nb\r\nc\r\n", map[int64]*models.Comment{
+		1: codeComment(1, "a", "x\ny\n"),
+		3: codeComment(3, "c", ""),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "x\r\ny\r\nb\r\n", content)
+
+	content, err = applySuggestionsToContent("a\nb", map[int64]*models.Comment{
+		2: codeComment(2, "b", "c\nd\n"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "a\nc\nd", content)
+
+	_, err = applySuggestionsToContent("a\nb\n", map[int64]*models.Comment{
+		2: codeComment(2, "changed", "c\n"),
+	})
+	assert.True(t, models.IsErrSuggestionNotApplicable(err))
+
+	_, err = applySuggestionsToContent("a\nb\n", map[int64]*models.Comment{
+		3: codeComment(3, "c", "d\n"),
+	})
+	assert.True(t, models.IsErrSuggestionNotApplicable(err))
+}
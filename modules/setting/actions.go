@@ -0,0 +1,30 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"path/filepath"
+)
+
+var (
+	// Actions settings
+	Actions = struct {
+		Enabled           bool
+		LogPath           string
+		RegistrationToken string
+	}{
+		Enabled: false,
+	}
+)
+
+func newActionsService() {
+	sec := Cfg.Section("actions")
+	Actions.Enabled = sec.Key("ENABLED").MustBool(Actions.Enabled)
+	Actions.LogPath = sec.Key("LOG_PATH").MustString(filepath.Join(AppDataPath, "actions_log"))
+	if !filepath.IsAbs(Actions.LogPath) {
+		Actions.LogPath = filepath.Join(AppWorkPath, Actions.LogPath)
+	}
+	Actions.RegistrationToken = sec.Key("RUNNER_REGISTRATION_TOKEN").String()
+}
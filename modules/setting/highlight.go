@@ -0,0 +1,24 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"code.gitea.io/gitea/modules/log"
+)
+
+var (
+	// Highlight settings
+	Highlight = struct {
+		MaxFileSize int64
+	}{
+		MaxFileSize: 1048576,
+	}
+)
+
+func newHighlight() {
+	if err := Cfg.Section("highlight").MapTo(&Highlight); err != nil {
+		log.Fatal("Failed to map Highlight settings: %v", err)
+	}
+}
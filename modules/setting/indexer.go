@@ -27,8 +27,13 @@ var (
 	Indexer = struct {
 		IssueType             string
 		IssuePath             string
+		IssueConnStr          string
+		IssueIndexerName      string
 		RepoIndexerEnabled    bool
+		RepoType              string
 		RepoPath              string
+		RepoConnStr           string
+		RepoIndexerName       string
 		UpdateQueueLength     int
 		MaxIndexerFileSize    int64
 		IssueQueueType        string
@@ -41,6 +46,11 @@ var (
 	}{
 		IssueType:             "bleve",
 		IssuePath:             "indexers/issues.bleve",
+		IssueConnStr:          "",
+		IssueIndexerName:      "gitea_issues",
+		RepoType:              "bleve",
+		RepoConnStr:           "",
+		RepoIndexerName:       "gitea_codes",
 		IssueQueueType:        LevelQueueType,
 		IssueQueueDir:         "indexers/issues.queue",
 		IssueQueueConnStr:     "",
@@ -57,11 +67,17 @@ func newIndexerService() {
 	if !filepath.IsAbs(Indexer.IssuePath) {
 		Indexer.IssuePath = path.Join(AppWorkPath, Indexer.IssuePath)
 	}
+	Indexer.IssueConnStr = sec.Key("ISSUE_INDEXER_CONN_STR").MustString("http://localhost:9200")
+	Indexer.IssueIndexerName = sec.Key("ISSUE_INDEXER_NAME").MustString("gitea_issues")
+
 	Indexer.RepoIndexerEnabled = sec.Key("REPO_INDEXER_ENABLED").MustBool(false)
+	Indexer.RepoType = sec.Key("REPO_INDEXER_TYPE").MustString("bleve")
 	Indexer.RepoPath = sec.Key("REPO_INDEXER_PATH").MustString(path.Join(AppDataPath, "indexers/repos.bleve"))
 	if !filepath.IsAbs(Indexer.RepoPath) {
 		Indexer.RepoPath = path.Join(AppWorkPath, Indexer.RepoPath)
 	}
+	Indexer.RepoConnStr = sec.Key("REPO_INDEXER_CONN_STR").MustString("http://localhost:9200")
+	Indexer.RepoIndexerName = sec.Key("REPO_INDEXER_NAME").MustString("gitea_codes")
 	Indexer.IncludePatterns = IndexerGlobFromString(sec.Key("REPO_INDEXER_INCLUDE").MustString(""))
 	Indexer.ExcludePatterns = IndexerGlobFromString(sec.Key("REPO_INDEXER_EXCLUDE").MustString(""))
 
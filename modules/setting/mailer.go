@@ -6,6 +6,9 @@ package setting
 
 import (
 	"net/mail"
+	"path"
+	"path/filepath"
+	"time"
 
 	"code.gitea.io/gitea/modules/log"
 
@@ -15,7 +18,6 @@ import (
 // Mailer represents mail service.
 type Mailer struct {
 	// Mailer
-	QueueLength     int
 	Name            string
 	From            string
 	FromName        string
@@ -24,6 +26,11 @@ type Mailer struct {
 	MailerType      string
 	SubjectPrefix   string
 
+	// Retries of failed mails
+	MaxAttempts     int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+
 	// SMTP sender
 	Host              string
 	User, Passwd      string
@@ -37,6 +44,9 @@ type Mailer struct {
 	// Sendmail sender
 	SendmailPath string
 	SendmailArgs []string
+
+	// File sender
+	FilePath string
 }
 
 var (
@@ -52,10 +62,12 @@ func newMailService() {
 	}
 
 	MailService = &Mailer{
-		QueueLength:     sec.Key("SEND_BUFFER_LEN").MustInt(100),
 		Name:            sec.Key("NAME").MustString(AppName),
 		SendAsPlainText: sec.Key("SEND_AS_PLAIN_TEXT").MustBool(false),
-		MailerType:      sec.Key("MAILER_TYPE").In("", []string{"smtp", "sendmail", "dummy"}),
+		MailerType:      sec.Key("MAILER_TYPE").In("", []string{"smtp", "sendmail", "dummy", "file"}),
+		MaxAttempts:     sec.Key("MAX_ATTEMPTS").MustInt(5),
+		RetryBackoff:    sec.Key("RETRY_BACKOFF").MustDuration(time.Minute),
+		MaxRetryBackoff: sec.Key("MAX_RETRY_BACKOFF").MustDuration(time.Hour),
 
 		Host:           sec.Key("HOST").String(),
 		User:           sec.Key("USER").String(),
@@ -70,6 +82,13 @@ func newMailService() {
 		SubjectPrefix:  sec.Key("SUBJECT_PREFIX").MustString(""),
 
 		SendmailPath: sec.Key("SENDMAIL_PATH").MustString("sendmail"),
+		FilePath:     sec.Key("FILE_PATH").MustString(path.Join(AppDataPath, "mail")),
+	}
+	if !filepath.IsAbs(MailService.FilePath) {
+		MailService.FilePath = path.Join(AppWorkPath, MailService.FilePath)
+	}
+	if MailService.MaxAttempts < 1 {
+		MailService.MaxAttempts = 1
 	}
 	MailService.From = sec.Key("FROM").MustString(MailService.User)
 
@@ -0,0 +1,37 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"path/filepath"
+)
+
+var (
+	// Packages settings
+	Packages = struct {
+		Enabled           bool
+		StoragePath       string
+		ChunkedUploadPath string
+		MaxFileSize       int64
+	}{
+		Enabled:     true,
+		MaxFileSize: 1024,
+	}
+)
+
+func newPackagesService() {
+	sec := Cfg.Section("packages")
+	Packages.Enabled = sec.Key("ENABLED").MustBool(Packages.Enabled)
+	Packages.StoragePath = sec.Key("PATH").MustString(filepath.Join(AppDataPath, "packages"))
+	if !filepath.IsAbs(Packages.StoragePath) {
+		Packages.StoragePath = filepath.Join(AppWorkPath, Packages.StoragePath)
+	}
+	PackageStorage = getStorage(sec, "", Packages.StoragePath, "packages/")
+	Packages.ChunkedUploadPath = sec.Key("CHUNKED_UPLOAD_PATH").MustString(filepath.Join(AppDataPath, "tmp/package-upload"))
+	if !filepath.IsAbs(Packages.ChunkedUploadPath) {
+		Packages.ChunkedUploadPath = filepath.Join(AppWorkPath, Packages.ChunkedUploadPath)
+	}
+	Packages.MaxFileSize = sec.Key("MAX_FILE_SIZE").MustInt64(Packages.MaxFileSize)
+}
@@ -101,8 +101,14 @@ func NewQueueService() {
 	Queue.BoostWorkers = sec.Key("BOOST_WORKERS").MustInt(5)
 	Queue.QueueName = sec.Key("QUEUE_NAME").MustString("_queue")
 
+	// The length of the mail queue defaults to the old mailer SEND_BUFFER_LEN
+	section := Cfg.Section("queue.mail")
+	if !section.HasKey("LENGTH") {
+		section.Key("LENGTH").SetValue(fmt.Sprintf("%d", Cfg.Section("mailer").Key("SEND_BUFFER_LEN").MustInt(100)))
+	}
+
 	// Now handle the old issue_indexer configuration
-	section := Cfg.Section("queue.issue_indexer")
+	section = Cfg.Section("queue.issue_indexer")
 	issueIndexerSectionMap := map[string]string{}
 	for _, key := range section.Keys() {
 		issueIndexerSectionMap[key.Name()] = key.Value()
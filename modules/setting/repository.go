@@ -8,6 +8,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"code.gitea.io/gitea/modules/log"
 
@@ -37,6 +38,7 @@ var (
 		DefaultCloseIssuesViaCommitsInAnyBranch bool
 		EnablePushCreateUser                    bool
 		EnablePushCreateOrg                     bool
+		ArchiveWaitTimeout                      time.Duration
 
 		// Repository editor settings
 		Editor struct {
@@ -98,6 +100,7 @@ var (
 		DefaultCloseIssuesViaCommitsInAnyBranch: false,
 		EnablePushCreateUser:                    false,
 		EnablePushCreateOrg:                     false,
+		ArchiveWaitTimeout:                      10 * time.Second,
 
 		// Repository editor settings
 		Editor: struct {
@@ -26,10 +26,10 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/user"
 
-	shellquote "github.com/kballard/go-shellquote"
 	version "github.com/mcuadros/go-version"
 	"github.com/unknwon/cae/zip"
 	"github.com/unknwon/com"
+	gossh "golang.org/x/crypto/ssh"
 	ini "gopkg.in/ini.v1"
 	"strk.kbt.io/projects/go/libravatar"
 )
@@ -104,24 +104,27 @@ var (
 	StaticURLPrefix      string
 
 	SSH = struct {
-		Disabled                 bool           `ini:"DISABLE_SSH"`
-		StartBuiltinServer       bool           `ini:"START_SSH_SERVER"`
-		BuiltinServerUser        string         `ini:"BUILTIN_SSH_SERVER_USER"`
-		Domain                   string         `ini:"SSH_DOMAIN"`
-		Port                     int            `ini:"SSH_PORT"`
-		ListenHost               string         `ini:"SSH_LISTEN_HOST"`
-		ListenPort               int            `ini:"SSH_LISTEN_PORT"`
-		RootPath                 string         `ini:"SSH_ROOT_PATH"`
-		ServerCiphers            []string       `ini:"SSH_SERVER_CIPHERS"`
-		ServerKeyExchanges       []string       `ini:"SSH_SERVER_KEY_EXCHANGES"`
-		ServerMACs               []string       `ini:"SSH_SERVER_MACS"`
-		KeyTestPath              string         `ini:"SSH_KEY_TEST_PATH"`
-		KeygenPath               string         `ini:"SSH_KEYGEN_PATH"`
-		AuthorizedKeysBackup     bool           `ini:"SSH_AUTHORIZED_KEYS_BACKUP"`
-		MinimumKeySizeCheck      bool           `ini:"-"`
-		MinimumKeySizes          map[string]int `ini:"-"`
-		CreateAuthorizedKeysFile bool           `ini:"SSH_CREATE_AUTHORIZED_KEYS_FILE"`
-		ExposeAnonymous          bool           `ini:"SSH_EXPOSE_ANONYMOUS"`
+		Disabled                 bool              `ini:"DISABLE_SSH"`
+		StartBuiltinServer       bool              `ini:"START_SSH_SERVER"`
+		BuiltinServerUser        string            `ini:"BUILTIN_SSH_SERVER_USER"`
+		Domain                   string            `ini:"SSH_DOMAIN"`
+		Port                     int               `ini:"SSH_PORT"`
+		ListenHost               string            `ini:"SSH_LISTEN_HOST"`
+		ListenPort               int               `ini:"SSH_LISTEN_PORT"`
+		RootPath                 string            `ini:"SSH_ROOT_PATH"`
+		ServerCiphers            []string          `ini:"SSH_SERVER_CIPHERS"`
+		ServerKeyExchanges       []string          `ini:"SSH_SERVER_KEY_EXCHANGES"`
+		ServerMACs               []string          `ini:"SSH_SERVER_MACS"`
+		KeyTestPath              string            `ini:"SSH_KEY_TEST_PATH"`
+		KeygenPath               string            `ini:"SSH_KEYGEN_PATH"`
+		AuthorizedKeysBackup     bool              `ini:"SSH_AUTHORIZED_KEYS_BACKUP"`
+		MinimumKeySizeCheck      bool              `ini:"-"`
+		MinimumKeySizes          map[string]int    `ini:"-"`
+		CreateAuthorizedKeysFile bool              `ini:"SSH_CREATE_AUTHORIZED_KEYS_FILE"`
+		ExposeAnonymous          bool              `ini:"SSH_EXPOSE_ANONYMOUS"`
+		TrustedUserCAKeys        []string          `ini:"-"`
+		TrustedUserCAKeysFile    string            `ini:"SSH_TRUSTED_USER_CA_KEYS_FILENAME"`
+		TrustedUserCAKeysParsed  []gossh.PublicKey `ini:"-"`
 	}{
 		Disabled:           false,
 		StartBuiltinServer: false,
@@ -328,9 +331,12 @@ var (
 		InvalidateRefreshTokens:    false,
 	}
 
-	U2F = struct {
-		AppID         string
-		TrustedFacets []string
+	// WebAuthn settings, the relying party is derived from the ROOT_URL
+	WebAuthn = struct {
+		RPID          string
+		RPDisplayName string
+		Origin        string
+		LegacyAppID   string
 	}{}
 
 	// Metrics settings
@@ -347,8 +353,6 @@ var (
 	Names     []string
 	dateLangs map[string]string
 
-	// Highlight settings are loaded in modules/template/highlight.go
-
 	// Other settings
 	ShowFooterBranding         bool
 	ShowFooterVersion          bool
@@ -704,6 +708,22 @@ func NewContext() {
 	SSH.CreateAuthorizedKeysFile = sec.Key("SSH_CREATE_AUTHORIZED_KEYS_FILE").MustBool(true)
 	SSH.ExposeAnonymous = sec.Key("SSH_EXPOSE_ANONYMOUS").MustBool(false)
 
+	SSH.TrustedUserCAKeys = sec.Key("SSH_TRUSTED_USER_CA_KEYS").Strings(",")
+	for _, caKey := range SSH.TrustedUserCAKeys {
+		pubKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(caKey))
+		if err != nil {
+			log.Fatal("Failed to parse trusted user CA key %q: %v", caKey, err)
+		}
+		SSH.TrustedUserCAKeysParsed = append(SSH.TrustedUserCAKeysParsed, pubKey)
+	}
+	SSH.TrustedUserCAKeysFile = sec.Key("SSH_TRUSTED_USER_CA_KEYS_FILENAME").MustString(filepath.Join(SSH.RootPath, "gitea-trusted-user-ca-keys.pem"))
+	if len(SSH.TrustedUserCAKeys) > 0 && !SSH.Disabled && !SSH.StartBuiltinServer {
+		// Keep the file OpenSSH reads with TrustedUserCAKeys in sync with the configuration
+		if err = ioutil.WriteFile(SSH.TrustedUserCAKeysFile, []byte(strings.Join(SSH.TrustedUserCAKeys, "\n")+"\n"), 0600); err != nil {
+			log.Fatal("Failed to create '%s': %v", SSH.TrustedUserCAKeysFile, err)
+		}
+	}
+
 	sec = Cfg.Section("server")
 	if err = sec.MapTo(&LFS); err != nil {
 		log.Fatal("Failed to map LFS settings: %v", err)
@@ -893,6 +913,7 @@ func NewContext() {
 	if !filepath.IsAbs(RepositoryAvatarUploadPath) {
 		RepositoryAvatarUploadPath = path.Join(AppWorkPath, RepositoryAvatarUploadPath)
 	}
+	newStorageService()
 	RepositoryAvatarFallback = sec.Key("REPOSITORY_AVATAR_FALLBACK").MustString("none")
 	RepositoryAvatarFallbackImage = sec.Key("REPOSITORY_AVATAR_FALLBACK_IMAGE").MustString("/img/repo_default.png")
 	AvatarMaxWidth = sec.Key("AVATAR_MAX_WIDTH").MustInt(4096)
@@ -995,10 +1016,13 @@ func NewContext() {
 	HasRobotsTxt = com.IsFile(path.Join(CustomPath, "robots.txt"))
 
 	newMarkup()
+	newHighlight()
 
-	sec = Cfg.Section("U2F")
-	U2F.TrustedFacets, _ = shellquote.Split(sec.Key("TRUSTED_FACETS").MustString(strings.TrimRight(AppURL, "/")))
-	U2F.AppID = sec.Key("APP_ID").MustString(strings.TrimRight(AppURL, "/"))
+	WebAuthn.RPID = appURL.Hostname()
+	WebAuthn.RPDisplayName = AppName
+	WebAuthn.Origin = appURL.Scheme + "://" + appURL.Host
+	// Security keys registered with U2F were scoped to the U2F App ID
+	WebAuthn.LegacyAppID = Cfg.Section("U2F").Key("APP_ID").MustString(strings.TrimRight(AppURL, "/"))
 
 	zip.Verbose = false
 
@@ -1094,4 +1118,6 @@ func NewServices() {
 	newIndexerService()
 	newTaskService()
 	NewQueueService()
+	newPackagesService()
+	newActionsService()
 }
@@ -0,0 +1,78 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	ini "gopkg.in/ini.v1"
+)
+
+// enumerates all the storage types
+const (
+	LocalStorageType = "local"
+	MinioStorageType = "minio"
+)
+
+// MinioStorageConfig represents the configuration of a MinIO or any other S3 compatible storage
+type MinioStorageConfig struct {
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	Bucket          string
+	Location        string
+	BasePath        string
+	UseSSL          bool
+}
+
+// Storage represents the configuration of the storage of one kind of data
+type Storage struct {
+	Type  string
+	Path  string
+	Minio MinioStorageConfig
+}
+
+var (
+	// LFSStorage is the storage of LFS objects
+	LFSStorage Storage
+	// AttachmentStorage is the storage of issue and release attachments
+	AttachmentStorage Storage
+	// AvatarStorage is the storage of user avatars
+	AvatarStorage Storage
+	// RepoAvatarStorage is the storage of repository avatars
+	RepoAvatarStorage Storage
+	// PackageStorage is the storage of package files
+	PackageStorage Storage
+)
+
+// getStorage reads the storage configuration from the keys of sec starting with prefix,
+// every key missing there falls back to the same key without prefix in the [storage] section
+func getStorage(sec *ini.Section, prefix, path, basePath string) Storage {
+	defaultSec := Cfg.Section("storage")
+	getString := func(name, defaultValue string) string {
+		return sec.Key(prefix + name).MustString(defaultSec.Key(name).MustString(defaultValue))
+	}
+
+	return Storage{
+		Type: getString("STORAGE_TYPE", LocalStorageType),
+		Path: path,
+		Minio: MinioStorageConfig{
+			Endpoint:        getString("MINIO_ENDPOINT", "localhost:9000"),
+			AccessKeyID:     getString("MINIO_ACCESS_KEY_ID", ""),
+			SecretAccessKey: getString("MINIO_SECRET_ACCESS_KEY", ""),
+			Bucket:          getString("MINIO_BUCKET", "gitea"),
+			Location:        getString("MINIO_LOCATION", "us-east-1"),
+			BasePath:        sec.Key(prefix + "MINIO_BASE_PATH").MustString(basePath),
+			UseSSL:          sec.Key(prefix + "MINIO_USE_SSL").MustBool(defaultSec.Key("MINIO_USE_SSL").MustBool(false)),
+		},
+	}
+}
+
+func newStorageService() {
+	LFSStorage = getStorage(Cfg.Section("lfs"), "", LFS.ContentPath, "lfs/")
+	AttachmentStorage = getStorage(Cfg.Section("attachment"), "", AttachmentPath, "attachments/")
+
+	sec := Cfg.Section("picture")
+	AvatarStorage = getStorage(sec, "AVATAR_", AvatarUploadPath, "avatars/")
+	RepoAvatarStorage = getStorage(sec, "REPOSITORY_AVATAR_", RepositoryAvatarUploadPath, "repo-avatars/")
+}
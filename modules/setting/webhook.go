@@ -6,6 +6,7 @@ package setting
 
 import (
 	"net/url"
+	"time"
 
 	"code.gitea.io/gitea/modules/log"
 )
@@ -21,6 +22,11 @@ var (
 		ProxyURL       string
 		ProxyURLFixed  *url.URL
 		ProxyHosts     []string
+
+		MaxAttempts          int
+		RetryBackoff         time.Duration
+		MaxRetryBackoff      time.Duration
+		DisableAfterFailures int
 	}{
 		QueueLength:    1000,
 		DeliverTimeout: 5,
@@ -28,6 +34,11 @@ var (
 		PagingNum:      10,
 		ProxyURL:       "",
 		ProxyHosts:     []string{},
+
+		MaxAttempts:          5,
+		RetryBackoff:         time.Minute,
+		MaxRetryBackoff:      time.Hour,
+		DisableAfterFailures: 10,
 	}
 )
 
@@ -38,6 +49,13 @@ func newWebhookService() {
 	Webhook.SkipTLSVerify = sec.Key("SKIP_TLS_VERIFY").MustBool()
 	Webhook.Types = []string{"gitea", "gogs", "slack", "discord", "dingtalk", "telegram", "msteams"}
 	Webhook.PagingNum = sec.Key("PAGING_NUM").MustInt(10)
+	Webhook.MaxAttempts = sec.Key("MAX_ATTEMPTS").MustInt(5)
+	if Webhook.MaxAttempts < 1 {
+		Webhook.MaxAttempts = 1
+	}
+	Webhook.RetryBackoff = sec.Key("RETRY_BACKOFF").MustDuration(time.Minute)
+	Webhook.MaxRetryBackoff = sec.Key("MAX_RETRY_BACKOFF").MustDuration(time.Hour)
+	Webhook.DisableAfterFailures = sec.Key("DISABLE_AFTER_FAILURES").MustInt(10)
 	Webhook.ProxyURL = sec.Key("PROXY_URL").MustString("")
 	if Webhook.ProxyURL != "" {
 		var err error
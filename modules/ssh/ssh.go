@@ -11,6 +11,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -27,9 +28,9 @@ import (
 	gossh "golang.org/x/crypto/ssh"
 )
 
-type contextKey string
-
-const giteaKeyID = contextKey("gitea-key-id")
+// giteaKeyArg is the permissions extension holding the argument which
+// identifies the authenticated key or user to the serv command.
+const giteaKeyArg = "gitea-key-arg"
 
 func getExitStatusFromError(err error) int {
 	if err == nil {
@@ -56,13 +57,20 @@ func getExitStatusFromError(err error) int {
 }
 
 func sessionHandler(session ssh.Session) {
-	keyID := session.Context().Value(giteaKeyID).(int64)
+	keyArg := sessionKeyArg(session)
+	if len(keyArg) == 0 {
+		log.Error("SSH: Session without an authenticated key")
+		if err := session.Exit(1); err != nil {
+			log.Error("Session failed to exit. %s", err)
+		}
+		return
+	}
 
 	command := session.RawCommand()
 
 	log.Trace("SSH: Payload: %v", command)
 
-	args := []string{"serv", "key-" + com.ToStr(keyID), "--config=" + setting.CustomConf}
+	args := []string{"serv", keyArg, "--config=" + setting.CustomConf}
 	log.Trace("SSH: Arguments: %v", args)
 	cmd := exec.Command(setting.AppPath, args...)
 	cmd.Env = append(
@@ -131,22 +139,106 @@ func sessionHandler(session ssh.Session) {
 	}
 }
 
+// sessionKeyArg returns the identity recorded by the public key callback for
+// the key the client actually signed with. Clients may offer several keys
+// before signing with one of them, and x/crypto keeps the permissions returned
+// for each offered key, so the identity must not be read from values shared
+// by the whole connection.
+func sessionKeyArg(session ssh.Session) string {
+	conn, ok := session.Context().Value(ssh.ContextKeyConn).(*gossh.ServerConn)
+	if !ok || conn.Permissions == nil {
+		return ""
+	}
+	return conn.Permissions.Extensions[giteaKeyArg]
+}
+
+// setKeyArg gives the key being checked its own permissions, which replace
+// the identity of any key offered before.
+func setKeyArg(ctx ssh.Context, keyArg string) {
+	ctx.Permissions().Permissions = &gossh.Permissions{
+		Extensions: map[string]string{giteaKeyArg: keyArg},
+	}
+}
+
 func publicKeyHandler(ctx ssh.Context, key ssh.PublicKey) bool {
+	setKeyArg(ctx, "")
+
 	if ctx.User() != setting.SSH.BuiltinServerUser {
 		return false
 	}
 
+	if cert, ok := key.(*gossh.Certificate); ok {
+		return certificateHandler(ctx, cert)
+	}
+
 	pkey, err := models.SearchPublicKeyByContent(strings.TrimSpace(string(gossh.MarshalAuthorizedKey(key))))
 	if err != nil {
 		log.Error("SearchPublicKeyByContent: %v", err)
 		return false
 	}
 
-	ctx.SetValue(giteaKeyID, pkey.ID)
+	setKeyArg(ctx, "key-"+com.ToStr(pkey.ID))
 
 	return true
 }
 
+// certificateHandler accepts user certificates issued by one of the trusted
+// certificate authorities whose principals name a Gitea user.
+func certificateHandler(ctx ssh.Context, cert *gossh.Certificate) bool {
+	if len(setting.SSH.TrustedUserCAKeysParsed) == 0 {
+		return false
+	}
+
+	if sourceAddress, ok := cert.CriticalOptions["source-address"]; ok {
+		if err := checkSourceAddress(ctx.RemoteAddr(), sourceAddress); err != nil {
+			log.Warn("SSH: Rejected certificate %q: %v", cert.KeyId, err)
+			return false
+		}
+	}
+
+	user, principal, err := models.GetUserBySSHCertificate(cert)
+	if err != nil {
+		if models.IsErrSSHCertificateNotTrusted(err) {
+			log.Warn("SSH: Rejected certificate: %v", err)
+		} else {
+			log.Error("GetUserBySSHCertificate: %v", err)
+		}
+		return false
+	}
+	log.Trace("SSH: Certificate %q accepted for principal %s", cert.KeyId, principal)
+
+	setKeyArg(ctx, "user-"+com.ToStr(user.ID))
+
+	return true
+}
+
+// checkSourceAddress checks the remote address against the comma separated
+// list of addresses and CIDR ranges of a certificate source-address option.
+func checkSourceAddress(addr net.Addr, sourceAddress string) error {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return fmt.Errorf("remote address %v is not a TCP address", addr)
+	}
+
+	for _, source := range strings.Split(sourceAddress, ",") {
+		source = strings.TrimSpace(source)
+		if allowedIP := net.ParseIP(source); allowedIP != nil {
+			if allowedIP.Equal(tcpAddr.IP) {
+				return nil
+			}
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(source)
+		if err != nil {
+			return fmt.Errorf("invalid source-address %q: %v", source, err)
+		}
+		if ipNet.Contains(tcpAddr.IP) {
+			return nil
+		}
+	}
+	return fmt.Errorf("remote address %v is not allowed by source-address %q", addr, sourceAddress)
+}
+
 // Listen starts a SSH server listens on given port.
 func Listen(host string, port int, ciphers []string, keyExchanges []string, macs []string) {
 	// TODO: Handle ciphers, keyExchanges, and macs
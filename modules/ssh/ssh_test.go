@@ -0,0 +1,141 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ssh
+
+import (
+	"crypto/rand"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/stretchr/testify/assert"
+	"github.com/unknwon/com"
+	"golang.org/x/crypto/ed25519"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func TestCheckSourceAddress(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.ParseIP("192.168.1.20"), Port: 50000}
+
+	assert.NoError(t, checkSourceAddress(addr, "192.168.1.20"))
+	assert.NoError(t, checkSourceAddress(addr, "10.0.0.1, 192.168.1.0/24"))
+	assert.Error(t, checkSourceAddress(addr, "10.0.0.0/8,192.168.1.21"))
+	assert.Error(t, checkSourceAddress(addr, "not-an-address"))
+	assert.Error(t, checkSourceAddress(&net.UnixAddr{Name: "/tmp/sock", Net: "unix"}, "192.168.1.20"))
+}
+
+func newTestSigner(t *testing.T) gossh.Signer {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	signer, err := gossh.NewSignerFromKey(priv)
+	assert.NoError(t, err)
+	return signer
+}
+
+// offeredCertSigner offers a certificate without holding its private key,
+// like a client which got hold of the certificate of someone else.
+type offeredCertSigner struct {
+	cert *gossh.Certificate
+}
+
+func (s offeredCertSigner) PublicKey() gossh.PublicKey {
+	return s.cert
+}
+
+func (s offeredCertSigner) Sign(rand io.Reader, data []byte) (*gossh.Signature, error) {
+	// rejected by the server without closing the connection
+	return &gossh.Signature{Format: "none"}, nil
+}
+
+// runTestServer serves the session key argument of every connection and
+// returns the address it listens on.
+func runTestServer(t *testing.T) (string, func()) {
+	srv := &ssh.Server{
+		PublicKeyHandler: publicKeyHandler,
+		Handler: func(session ssh.Session) {
+			_, _ = io.WriteString(session, sessionKeyArg(session))
+		},
+	}
+	srv.AddHostKey(newTestSigner(t))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	go func() {
+		_ = srv.Serve(l)
+	}()
+	return l.Addr().String(), func() {
+		_ = srv.Close()
+	}
+}
+
+func sshKeyArg(t *testing.T, addr string, signers ...gossh.Signer) string {
+	client, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+		User:            setting.SSH.BuiltinServerUser,
+		Auth:            []gossh.AuthMethod{gossh.PublicKeys(signers...)},
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+	})
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	assert.NoError(t, err)
+	defer session.Close()
+	output, err := session.Output("git-upload-pack 'user2/repo1.git'")
+	assert.NoError(t, err)
+	return string(output)
+}
+
+func TestPublicKeyHandler(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	ca := newTestSigner(t)
+	oldKeys, oldUser, oldBuiltin := setting.SSH.TrustedUserCAKeysParsed, setting.SSH.BuiltinServerUser, setting.SSH.StartBuiltinServer
+	setting.SSH.TrustedUserCAKeysParsed = []gossh.PublicKey{ca.PublicKey()}
+	setting.SSH.BuiltinServerUser = "git"
+	setting.SSH.StartBuiltinServer = true
+	defer func() {
+		setting.SSH.TrustedUserCAKeysParsed = oldKeys
+		setting.SSH.BuiltinServerUser = oldUser
+		setting.SSH.StartBuiltinServer = oldBuiltin
+	}()
+
+	// a certificate of user2 for a key only user2 holds
+	victim := newTestSigner(t)
+	now := time.Now()
+	cert := &gossh.Certificate{
+		Key:             victim.PublicKey(),
+		KeyId:           "user2",
+		CertType:        gossh.UserCert,
+		ValidPrincipals: []string{"user2"},
+		ValidAfter:      uint64(now.Add(-time.Hour).Unix()),
+		ValidBefore:     uint64(now.Add(time.Hour).Unix()),
+	}
+	assert.NoError(t, cert.SignCert(rand.Reader, ca))
+	certSigner, err := gossh.NewCertSigner(cert, victim)
+	assert.NoError(t, err)
+
+	// a key registered by user4
+	own := newTestSigner(t)
+	key, err := models.AddPublicKey(4, "ssh-test-key", strings.TrimSpace(string(gossh.MarshalAuthorizedKey(own.PublicKey()))), 0)
+	assert.NoError(t, err)
+
+	addr, stop := runTestServer(t)
+	defer stop()
+
+	assert.Equal(t, "user-2", sshKeyArg(t, addr, certSigner))
+	assert.Equal(t, "key-"+com.ToStr(key.ID), sshKeyArg(t, addr, own))
+
+	// the certificate of user2 is accepted when offered, but the client can only
+	// sign with its own key, which must be the identity of the session
+	assert.Equal(t, "key-"+com.ToStr(key.ID), sshKeyArg(t, addr, offeredCertSigner{cert}, own))
+}
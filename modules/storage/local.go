@@ -0,0 +1,103 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+var (
+	_ ObjectStorage = &LocalStorage{}
+)
+
+// LocalStorage represents a storage in a directory of the local file system
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocalStorage returns a local files storage in dir, the directory is created if needed
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{dir: dir}, nil
+}
+
+// localPath returns the file of the object, the cleaned path cannot leave the directory
+func (l *LocalStorage) localPath(p string) string {
+	return filepath.Join(l.dir, filepath.FromSlash(path.Clean("/"+p)))
+}
+
+// Open opens a file
+func (l *LocalStorage) Open(path string) (Object, error) {
+	return os.Open(l.localPath(path))
+}
+
+// Save saves a file, it is written to a temporary file first so readers never see a partial file
+func (l *LocalStorage) Save(path string, r io.Reader) (int64, error) {
+	p := l.localPath(path)
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(p), filepath.Base(p)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return 0, err
+	}
+	return n, os.Rename(tmp.Name(), p)
+}
+
+// Stat returns the info of the file
+func (l *LocalStorage) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(l.localPath(path))
+}
+
+// Delete deletes a file, deleting a missing file is not an error
+func (l *LocalStorage) Delete(path string) error {
+	if err := os.Remove(l.localPath(path)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// IterateObjects iterates across the objects in the local storage
+func (l *LocalStorage) IterateObjects(fn func(path string, obj Object) error) error {
+	return filepath.Walk(l.dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || filepath.Ext(p) == ".tmp" {
+			return nil
+		}
+		relPath, err := filepath.Rel(l.dir, p)
+		if err != nil {
+			return err
+		}
+
+		obj, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer obj.Close()
+		return fn(filepath.ToSlash(relPath), obj)
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testObjectStorage(t *testing.T, s ObjectStorage) {
+	n, err := s.Save("a/b/c.txt", strings.NewReader("hello world"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, n)
+	_, err = s.Save("d.txt", strings.NewReader("gitea"))
+	assert.NoError(t, err)
+
+	fi, err := s.Stat("a/b/c.txt")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, fi.Size())
+
+	obj, err := s.Open("a/b/c.txt")
+	assert.NoError(t, err)
+	_, err = obj.Seek(6, os.SEEK_SET)
+	assert.NoError(t, err)
+	bs, err := ioutil.ReadAll(obj)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "world", string(bs))
+	assert.NoError(t, obj.Close())
+
+	var paths []string
+	assert.NoError(t, s.IterateObjects(func(path string, obj Object) error {
+		bs, err := ioutil.ReadAll(obj)
+		assert.NoError(t, err)
+		paths = append(paths, path+":"+string(bs))
+		return nil
+	}))
+	sort.Strings(paths)
+	assert.EqualValues(t, []string{"a/b/c.txt:hello world", "d.txt:gitea"}, paths)
+
+	other, err := NewLocalStorage(filepath.Join(os.TempDir(), "gitea-storage-copy-test"))
+	assert.NoError(t, err)
+	defer os.RemoveAll(filepath.Join(os.TempDir(), "gitea-storage-copy-test"))
+	n, err = Copy(other, "copy.txt", s, "d.txt")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, n)
+
+	assert.NoError(t, s.Delete("a/b/c.txt"))
+	assert.NoError(t, s.Delete("a/b/c.txt"))
+	_, err = s.Stat("a/b/c.txt")
+	assert.True(t, os.IsNotExist(err))
+	_, err = s.Open("a/b/c.txt")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLocalStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gitea-storage-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := NewLocalStorage(filepath.Join(dir, "data"))
+	assert.NoError(t, err)
+	testObjectStorage(t, s)
+
+	// paths cannot leave the directory of the storage
+	_, err = s.Save("../../escaped.txt", strings.NewReader("content"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "data", "escaped.txt"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,157 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/minio/minio-go/v6"
+)
+
+var (
+	_ ObjectStorage = &MinioStorage{}
+)
+
+// MinioStorage represents a storage in a bucket of MinIO or any other S3 compatible server,
+// objects are stored below the base path of the bucket
+type MinioStorage struct {
+	client   *minio.Client
+	bucket   string
+	basePath string
+}
+
+// NewMinioStorage returns a minio storage, the bucket is created if it does not exist
+func NewMinioStorage(cfg setting.MinioStorageConfig) (*MinioStorage, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("minio: bucket name is empty")
+	}
+	if cfg.Location == "" {
+		cfg.Location = "us-east-1"
+	}
+
+	client, err := minio.NewWithRegion(cfg.Endpoint, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.UseSSL, cfg.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(cfg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := client.MakeBucket(cfg.Bucket, cfg.Location); err != nil {
+			return nil, err
+		}
+	}
+
+	return &MinioStorage{
+		client:   client,
+		bucket:   cfg.Bucket,
+		basePath: strings.Trim(cfg.BasePath, "/"),
+	}, nil
+}
+
+// objectKey returns the key of the object stored at p
+func (m *MinioStorage) objectKey(p string) string {
+	return strings.TrimPrefix(path.Join(m.basePath, path.Clean("/"+p)), "/")
+}
+
+// Open opens a file, the content is requested when it is read the first time
+func (m *MinioStorage) Open(path string) (Object, error) {
+	if _, err := m.Stat(path); err != nil {
+		return nil, err
+	}
+	return m.client.GetObject(m.bucket, m.objectKey(path), minio.GetObjectOptions{})
+}
+
+// Save saves a file, the content is buffered to a temporary file so its length is known
+// and large objects can be sent in parts of a multipart upload
+func (m *MinioStorage) Save(path string, r io.Reader) (int64, error) {
+	tmp, err := ioutil.TempFile("", "gitea-minio-")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	return m.client.PutObject(m.bucket, m.objectKey(path), tmp, n, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+	})
+}
+
+// Stat returns the info of the file
+func (m *MinioStorage) Stat(p string) (os.FileInfo, error) {
+	info, err := m.client.StatObject(m.bucket, m.objectKey(p), minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+		}
+		return nil, err
+	}
+	return &minioFileInfo{info}, nil
+}
+
+// Delete deletes a file, deleting a missing file is not an error
+func (m *MinioStorage) Delete(path string) error {
+	return m.client.RemoveObject(m.bucket, m.objectKey(path))
+}
+
+// IterateObjects iterates across the objects below the base path of the bucket
+func (m *MinioStorage) IterateObjects(fn func(path string, obj Object) error) error {
+	prefix := m.basePath
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	for info := range m.client.ListObjectsV2(m.bucket, prefix, true, doneCh) {
+		if info.Err != nil {
+			return info.Err
+		}
+		if strings.HasSuffix(info.Key, "/") {
+			continue
+		}
+		obj, err := m.client.GetObject(m.bucket, info.Key, minio.GetObjectOptions{})
+		if err != nil {
+			return err
+		}
+		err = fn(strings.TrimPrefix(info.Key, prefix), obj)
+		obj.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type minioFileInfo struct {
+	info minio.ObjectInfo
+}
+
+func (fi *minioFileInfo) Name() string       { return path.Base(fi.info.Key) }
+func (fi *minioFileInfo) Size() int64        { return fi.info.Size }
+func (fi *minioFileInfo) Mode() os.FileMode  { return 0644 }
+func (fi *minioFileInfo) ModTime() time.Time { return fi.info.LastModified }
+func (fi *minioFileInfo) IsDir() bool        { return false }
+func (fi *minioFileInfo) Sys() interface{}   { return nil }
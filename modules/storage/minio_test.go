@@ -0,0 +1,183 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a minimal in memory implementation of the S3 API used by MinioStorage,
+// listings return one object per page to exercise the continuation
+type fakeS3 struct {
+	sync.Mutex
+	buckets map[string]map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	bucket, ok := f.buckets[parts[0]]
+	if len(parts) == 1 || parts[1] == "" {
+		switch {
+		case r.Method == http.MethodPut:
+			f.buckets[parts[0]] = make(map[string][]byte)
+		case !ok:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodGet:
+			f.list(w, r, bucket)
+		}
+		return
+	} else if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	key := parts[1]
+	switch r.Method {
+	case http.MethodPut:
+		bs, err := readAWSChunked(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		bucket[key] = bs
+	case http.MethodDelete:
+		delete(bucket, key)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodHead, http.MethodGet:
+		bs, ok := bucket[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", `"etag"`)
+		status := http.StatusOK
+		if rng := r.Header.Get("Range"); rng != "" {
+			bounds := strings.SplitN(strings.TrimPrefix(rng, "bytes="), "-", 2)
+			start, _ := strconv.Atoi(bounds[0])
+			end := len(bs)
+			if bounds[1] != "" {
+				end, _ = strconv.Atoi(bounds[1])
+				end++
+			}
+			bs = bs[start:end]
+			status = http.StatusPartialContent
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(bs)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(bs)
+		}
+	}
+}
+
+// readAWSChunked reads the body of a request with a streaming signature,
+// every chunk is prefixed by its hexadecimal size and its signature
+func readAWSChunked(r *http.Request) ([]byte, error) {
+	if r.Header.Get("X-Amz-Content-Sha256") != "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		return ioutil.ReadAll(r.Body)
+	}
+
+	var buf bytes.Buffer
+	br := bufio.NewReader(r.Body)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.ParseInt(strings.SplitN(line, ";", 2)[0], 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.CopyN(&buf, br, size); err != nil {
+			return nil, err
+		}
+		if _, err := br.Discard(2); err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return buf.Bytes(), nil
+		}
+	}
+}
+
+type listBucketV2Result struct {
+	XMLName  xml.Name `xml:"ListBucketResult"`
+	Contents []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request, bucket map[string][]byte) {
+	var keys []string
+	for key := range bucket {
+		if strings.HasPrefix(key, r.URL.Query().Get("prefix")) && key > r.URL.Query().Get("continuation-token") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var result listBucketV2Result
+	if len(keys) > 0 {
+		result.Contents = append(result.Contents, struct {
+			Key          string
+			Size         int64
+			LastModified time.Time
+		}{keys[0], int64(len(bucket[keys[0]])), time.Now().UTC()})
+		result.IsTruncated = len(keys) > 1
+		result.NextContinuationToken = keys[0]
+	}
+	_ = xml.NewEncoder(w).Encode(&result)
+}
+
+func TestMinioStorage(t *testing.T) {
+	fake := &fakeS3{buckets: make(map[string]map[string][]byte)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s, err := NewMinioStorage(setting.MinioStorageConfig{
+		Endpoint:        strings.TrimPrefix(server.URL, "http://"),
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+		Bucket:          "gitea",
+		BasePath:        "attachments/",
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, fake.buckets, "gitea")
+
+	// objects of other base paths are not part of the storage
+	fake.buckets["gitea"]["lfs/object"] = []byte("lfs")
+
+	testObjectStorage(t, s)
+	assert.Contains(t, fake.buckets["gitea"], "attachments/d.txt")
+}
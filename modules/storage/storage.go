@@ -0,0 +1,87 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// Object represents a stored object which can be read from any position
+type Object interface {
+	io.ReadCloser
+	io.Seeker
+}
+
+// ObjectStorage represents an object storage to handle a bucket and files.
+// Paths are slash separated and relative to the root of the storage, errors
+// for missing objects satisfy os.IsNotExist.
+type ObjectStorage interface {
+	Open(path string) (Object, error)
+	Save(path string, r io.Reader) (int64, error)
+	Stat(path string) (os.FileInfo, error)
+	Delete(path string) error
+	IterateObjects(fn func(path string, obj Object) error) error
+}
+
+// Copy copies a file from the source ObjectStorage to the destination ObjectStorage
+func Copy(dstStorage ObjectStorage, dstPath string, srcStorage ObjectStorage, srcPath string) (int64, error) {
+	f, err := srcStorage.Open(srcPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return dstStorage.Save(dstPath, f)
+}
+
+var (
+	// LFS represents LFS storage
+	LFS ObjectStorage
+	// Attachments represents attachments storage
+	Attachments ObjectStorage
+	// Avatars represents user avatars storage
+	Avatars ObjectStorage
+	// RepoAvatars represents repository avatars storage
+	RepoAvatars ObjectStorage
+	// Packages represents package files storage
+	Packages ObjectStorage
+)
+
+// Init init the storages according to the settings
+func Init() error {
+	var err error
+	if LFS, err = NewStorage(setting.LFSStorage); err != nil {
+		return fmt.Errorf("LFS storage: %v", err)
+	}
+	if Attachments, err = NewStorage(setting.AttachmentStorage); err != nil {
+		return fmt.Errorf("attachment storage: %v", err)
+	}
+	if Avatars, err = NewStorage(setting.AvatarStorage); err != nil {
+		return fmt.Errorf("avatar storage: %v", err)
+	}
+	if RepoAvatars, err = NewStorage(setting.RepoAvatarStorage); err != nil {
+		return fmt.Errorf("repository avatar storage: %v", err)
+	}
+	if Packages, err = NewStorage(setting.PackageStorage); err != nil {
+		return fmt.Errorf("package storage: %v", err)
+	}
+	return nil
+}
+
+// NewStorage creates the ObjectStorage described by cfg
+func NewStorage(cfg setting.Storage) (ObjectStorage, error) {
+	switch cfg.Type {
+	case setting.LocalStorageType:
+		return NewLocalStorage(cfg.Path)
+	case setting.MinioStorageType:
+		return NewMinioStorage(cfg.Minio)
+	default:
+		return nil, fmt.Errorf("unsupported storage type %q", cfg.Type)
+	}
+}
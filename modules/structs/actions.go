@@ -0,0 +1,52 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+// RegisterActionRunnerOption options to register a runner
+type RegisterActionRunnerOption struct {
+	// the registration token of the instance
+	Token  string   `json:"token" binding:"Required"`
+	Name   string   `json:"name" binding:"Required;MaxSize(255)"`
+	Labels []string `json:"labels"`
+}
+
+// ActionRunner represents a registered runner
+type ActionRunner struct {
+	ID     int64    `json:"id"`
+	UUID   string   `json:"uuid"`
+	Name   string   `json:"name"`
+	Labels []string `json:"labels"`
+	// the token the runner has to use to authenticate, it is only returned on registration
+	Token string `json:"token,omitempty"`
+}
+
+// ActionJob represents a job which has been assigned to a runner
+type ActionJob struct {
+	ID         int64             `json:"id"`
+	RunID      int64             `json:"run_id"`
+	RunNumber  int64             `json:"run_number"`
+	Name       string            `json:"name"`
+	Repository string            `json:"repository"`
+	CloneURL   string            `json:"clone_url"`
+	Event      string            `json:"event"`
+	Ref        string            `json:"ref"`
+	CommitSHA  string            `json:"sha"`
+	Env        map[string]string `json:"env"`
+	Steps      []*ActionJobStep  `json:"steps"`
+}
+
+// ActionJobStep represents a command of a job
+type ActionJobStep struct {
+	Name             string            `json:"name"`
+	Run              string            `json:"run"`
+	WorkingDirectory string            `json:"working_directory,omitempty"`
+	Env              map[string]string `json:"env,omitempty"`
+}
+
+// FinishActionJobOption options to report the result of a job
+type FinishActionJobOption struct {
+	// enum: success,failure,cancelled
+	Status string `json:"status" binding:"Required;In(success,failure,cancelled)"`
+}
@@ -0,0 +1,26 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// AuditEvent represents a security relevant action recorded in the audit log
+type AuditEvent struct {
+	ID int64 `json:"id"`
+	// the kind of the action, e.g. `user_login` or `repo_delete`
+	Action    string `json:"action"`
+	ActorID   int64  `json:"actor_id"`
+	ActorName string `json:"actor_name"`
+	IPAddress string `json:"ip_address"`
+	// the kind of the target: `user`, `repository` or `team`
+	TargetType  string `json:"target_type"`
+	TargetID    int64  `json:"target_id"`
+	TargetName  string `json:"target_name"`
+	Description string `json:"description"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created"`
+}
@@ -34,6 +34,34 @@ type Hook struct {
 // HookList represents a list of API hook.
 type HookList []*Hook
 
+// HookDelivery represents a delivery of a payload to a web hook
+type HookDelivery struct {
+	ID        int64  `json:"id"`
+	UUID      string `json:"uuid"`
+	Event     string `json:"event"`
+	URL       string `json:"url"`
+	Delivered bool   `json:"delivered"`
+	Succeeded bool   `json:"succeeded"`
+	// number of attempts to deliver the payload
+	Attempts int `json:"attempts"`
+	// HTTP status code of the response to the last attempt
+	StatusCode int `json:"status_code"`
+	// swagger:strfmt date-time
+	LastAttempt *time.Time `json:"last_attempt_at"`
+	// swagger:strfmt date-time
+	NextAttempt *time.Time `json:"next_attempt_at"`
+}
+
+// ReplayHookDeliveriesOption options to deliver the payloads of a time range again
+type ReplayHookDeliveriesOption struct {
+	// required: true
+	// swagger:strfmt date-time
+	Since time.Time `json:"since" binding:"Required"`
+	// required: true
+	// swagger:strfmt date-time
+	Before time.Time `json:"before" binding:"Required"`
+}
+
 // CreateHookOptionConfig has all config options in it
 // required are "content_type" and "url" Required
 type CreateHookOptionConfig map[string]string
@@ -0,0 +1,81 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// Project represents a kanban board of a repository or an organization
+type Project struct {
+	ID           int64     `json:"id"`
+	Title        string    `json:"title"`
+	Description  string    `json:"description"`
+	State        StateType `json:"state"`
+	OpenIssues   int64     `json:"open_issues"`
+	ClosedIssues int64     `json:"closed_issues"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	Updated time.Time `json:"updated_at"`
+	// swagger:strfmt date-time
+	Closed *time.Time `json:"closed_at"`
+}
+
+// CreateProjectOption options for creating a project
+type CreateProjectOption struct {
+	// required:true
+	Title       string `json:"title" binding:"Required;MaxSize(255)"`
+	Description string `json:"description"`
+	// the boards the project is created with
+	// enum: none,basic_kanban
+	BoardTemplate string `json:"board_template"`
+}
+
+// EditProjectOption options for editing a project
+type EditProjectOption struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	// enum: open,closed
+	State *string `json:"state"`
+}
+
+// ProjectBoard represents a column of a project
+type ProjectBoard struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	// closed issues are moved to this board
+	IsDone bool `json:"is_done"`
+	// reopened issues are moved from the done board to this board
+	IsDefault bool `json:"is_default"`
+	Sorting   int  `json:"sorting"`
+}
+
+// CreateProjectBoardOption options for creating a project board
+type CreateProjectBoardOption struct {
+	// required:true
+	Title     string `json:"title" binding:"Required;MaxSize(255)"`
+	IsDone    bool   `json:"is_done"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// EditProjectBoardOption options for editing a project board
+type EditProjectBoardOption struct {
+	Title     *string `json:"title"`
+	IsDone    *bool   `json:"is_done"`
+	IsDefault *bool   `json:"is_default"`
+	Sorting   *int    `json:"sorting"`
+}
+
+// MoveProjectIssueOption options for adding an issue to a project or moving it to another board
+type MoveProjectIssueOption struct {
+	// id of the issue or pull request
+	// required:true
+	IssueID int64 `json:"issue_id" binding:"Required"`
+	// id of the board, 0 leaves the issue uncategorized
+	BoardID int64 `json:"board_id"`
+	// position of the issue on the board, starting at 0. The issue is appended if it is omitted.
+	Position *int `json:"position"`
+}
@@ -82,6 +82,7 @@ type Repository struct {
 	HasWiki                   bool             `json:"has_wiki"`
 	ExternalWiki              *ExternalWiki    `json:"external_wiki,omitempty"`
 	HasPullRequests           bool             `json:"has_pull_requests"`
+	HasProjects               bool             `json:"has_projects"`
 	IgnoreWhitespaceConflicts bool             `json:"ignore_whitespace_conflicts"`
 	AllowMerge                bool             `json:"allow_merge_commits"`
 	AllowRebase               bool             `json:"allow_rebase"`
@@ -140,6 +141,8 @@ type EditRepoOption struct {
 	HasWiki *bool `json:"has_wiki,omitempty"`
 	// set this structure to use external wiki instead of internal (requires has_wiki)
 	ExternalWiki *ExternalWiki `json:"external_wiki,omitempty"`
+	// either `true` to enable project boards for this repository or `false` to disable them.
+	HasProjects *bool `json:"has_projects,omitempty"`
 	// sets the default branch for this repository.
 	DefaultBranch *string `json:"default_branch,omitempty"`
 	// either `true` to allow pull requests, or `false` to prevent pull request.
@@ -192,6 +195,7 @@ var (
 	// TODO: add to this list after new git service added
 	SupportedFullGitService = []GitServiceType{
 		GithubService,
+		GitlabService,
 	}
 )
 
@@ -12,6 +12,7 @@ type Branch struct {
 	RequiredApprovals   int64          `json:"required_approvals"`
 	EnableStatusCheck   bool           `json:"enable_status_check"`
 	StatusCheckContexts []string       `json:"status_check_contexts"`
+	EnableMergeQueue    bool           `json:"enable_merge_queue"`
 	UserCanPush         bool           `json:"user_can_push"`
 	UserCanMerge        bool           `json:"user_can_merge"`
 }
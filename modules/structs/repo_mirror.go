@@ -0,0 +1,37 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package structs
+
+import (
+	"time"
+)
+
+// PushMirror represents a remote repository the repository is pushed to
+type PushMirror struct {
+	ID            int64  `json:"id"`
+	RemoteAddress string `json:"remote_address"`
+	// interval between the automatic synchronizations, empty if disabled
+	Interval     string `json:"interval"`
+	SyncOnCommit bool   `json:"sync_on_commit"`
+	// swagger:strfmt date-time
+	Created time.Time `json:"created_at"`
+	// swagger:strfmt date-time
+	LastUpdate *time.Time `json:"last_update,omitempty"`
+	LastError  string     `json:"last_error"`
+}
+
+// CreatePushMirrorOption options when creating a push mirror
+type CreatePushMirrorOption struct {
+	// HTTP(S) URL of the remote repository
+	//
+	// required: true
+	RemoteAddress  string `json:"remote_address" binding:"Required"`
+	RemoteUsername string `json:"remote_username"`
+	RemotePassword string `json:"remote_password"`
+	// interval between the automatic synchronizations, e.g. "8h", "0" disables them
+	Interval string `json:"interval"`
+	// push to the mirror when commits are pushed to the repository
+	SyncOnCommit bool `json:"sync_on_commit"`
+}
@@ -7,6 +7,7 @@ package structs
 
 import (
 	"encoding/base64"
+	"time"
 )
 
 // BasicAuthEncode generate base64 of basic auth head
@@ -21,6 +22,12 @@ type AccessToken struct {
 	Name           string `json:"name"`
 	Token          string `json:"sha1"`
 	TokenLastEight string `json:"token_last_eight"`
+	// the scopes granted to the token, "all" grants all rights of the user
+	Scopes []string `json:"scopes"`
+	// swagger:strfmt date-time
+	ExpiresAt *time.Time `json:"expires_at"`
+	// swagger:strfmt date-time
+	LastUsedAt *time.Time `json:"last_used_at"`
 }
 
 // AccessTokenList represents a list of API access token.
@@ -31,4 +38,9 @@ type AccessTokenList []*AccessToken
 // swagger:parameters userCreateToken
 type CreateAccessTokenOption struct {
 	Name string `json:"name" binding:"Required"`
+	// the scopes to grant, no scopes grant all rights of the user
+	Scopes []string `json:"scopes"`
+	// the token can not be used after this date, no date means the token does not expire
+	// swagger:strfmt date-time
+	ExpiresAt *time.Time `json:"expires_at"`
 }
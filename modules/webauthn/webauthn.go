@@ -0,0 +1,168 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package webauthn configures the relying party side of the Web Authentication
+// API, used for security keys and platform authenticators as a second factor.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+)
+
+// User is a user registering or using credentials, it implements webauthn.User
+type User struct {
+	ID          int64
+	Name        string
+	DisplayName string
+	Credentials []webauthn.Credential
+	// LegacyCredentials were registered with the U2F API, they are scoped to
+	// the U2F App ID rather than the relying party ID
+	LegacyCredentials []webauthn.Credential
+}
+
+// WebAuthnID implements webauthn.User
+func (u *User) WebAuthnID() []byte {
+	id := make([]byte, 8)
+	binary.BigEndian.PutUint64(id, uint64(u.ID))
+	return id
+}
+
+// WebAuthnName implements webauthn.User
+func (u *User) WebAuthnName() string {
+	return u.Name
+}
+
+// WebAuthnDisplayName implements webauthn.User
+func (u *User) WebAuthnDisplayName() string {
+	return u.DisplayName
+}
+
+// WebAuthnIcon implements webauthn.User
+func (u *User) WebAuthnIcon() string {
+	return ""
+}
+
+// WebAuthnCredentials implements webauthn.User
+func (u *User) WebAuthnCredentials() []webauthn.Credential {
+	creds := make([]webauthn.Credential, 0, len(u.Credentials)+len(u.LegacyCredentials))
+	creds = append(creds, u.Credentials...)
+	return append(creds, u.LegacyCredentials...)
+}
+
+func (u *User) isLegacyCredential(id []byte) bool {
+	for _, cred := range u.LegacyCredentials {
+		if bytes.Equal(cred.ID, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// relyingParty returns the relying party with the given ID. The user is not
+// required to verify itself, the password was checked already.
+func relyingParty(rpID string) (*webauthn.WebAuthn, error) {
+	return webauthn.New(&webauthn.Config{
+		RPDisplayName: setting.WebAuthn.RPDisplayName,
+		RPID:          rpID,
+		RPOrigin:      setting.WebAuthn.Origin,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			RequireResidentKey: protocol.ResidentKeyUnrequired(),
+			UserVerification:   protocol.VerificationDiscouraged,
+		},
+		AttestationPreference: protocol.PreferNoAttestation,
+	})
+}
+
+func descriptors(creds []webauthn.Credential) []protocol.CredentialDescriptor {
+	list := make([]protocol.CredentialDescriptor, 0, len(creds))
+	for _, cred := range creds {
+		list = append(list, protocol.CredentialDescriptor{
+			Type:         protocol.PublicKeyCredentialType,
+			CredentialID: cred.ID,
+		})
+	}
+	return list
+}
+
+// BeginRegistration returns the options to create a new credential for the
+// user, excluding the credentials the user already registered.
+func BeginRegistration(user *User) (*protocol.CredentialCreation, *webauthn.SessionData, error) {
+	rp, err := relyingParty(setting.WebAuthn.RPID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rp.BeginRegistration(user,
+		webauthn.WithAuthenticatorSelection(rp.Config.AuthenticatorSelection),
+		webauthn.WithExclusions(descriptors(user.WebAuthnCredentials())))
+}
+
+// FinishRegistration verifies the credential created by the browser and
+// returns it.
+func FinishRegistration(user *User, session *webauthn.SessionData, body io.Reader) (*webauthn.Credential, error) {
+	response, err := protocol.ParseCredentialCreationResponseBody(body)
+	if err != nil {
+		return nil, err
+	}
+	rp, err := relyingParty(setting.WebAuthn.RPID)
+	if err != nil {
+		return nil, err
+	}
+	return rp.CreateCredential(user, *session, response)
+}
+
+// BeginLogin returns the options to authenticate with one of the credentials
+// of the user.
+func BeginLogin(user *User) (*protocol.CredentialAssertion, *webauthn.SessionData, error) {
+	rp, err := relyingParty(setting.WebAuthn.RPID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var opts []webauthn.LoginOption
+	if len(user.LegacyCredentials) > 0 {
+		opts = append(opts, webauthn.WithAssertionExtensions(protocol.AuthenticationExtensions{
+			"appid": setting.WebAuthn.LegacyAppID,
+		}))
+	}
+	return rp.BeginLogin(user, opts...)
+}
+
+// FinishLogin verifies the assertion made by the browser against the
+// credentials of the user. It returns the credential that was used with its
+// signature counter updated.
+func FinishLogin(user *User, session *webauthn.SessionData, body io.Reader) (*webauthn.Credential, error) {
+	response, err := protocol.ParseCredentialRequestResponseBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Browsers sign with the App ID of credentials registered with the U2F API
+	// when the appid extension was requested.
+	rpID := setting.WebAuthn.RPID
+	legacyRPIDHash := sha256.Sum256([]byte(setting.WebAuthn.LegacyAppID))
+	if user.isLegacyCredential(response.RawID) && bytes.Equal(response.Response.AuthenticatorData.RPIDHash, legacyRPIDHash[:]) {
+		rpID = setting.WebAuthn.LegacyAppID
+	}
+	rp, err := relyingParty(rpID)
+	if err != nil {
+		return nil, err
+	}
+
+	cred, err := rp.ValidateLogin(user, *session, response)
+	if err != nil {
+		return nil, err
+	}
+	if cred.Authenticator.CloneWarning {
+		return nil, errors.New("signature counter did not increase, the authenticator may have been cloned")
+	}
+	return cred, nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package webauthn_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+	wa "code.gitea.io/gitea/modules/webauthn"
+	"code.gitea.io/gitea/modules/webauthn/webauthntest"
+
+	"github.com/duo-labs/webauthn/protocol/webauthncose"
+	"github.com/duo-labs/webauthn/webauthn"
+	"github.com/stretchr/testify/assert"
+)
+
+const origin = "https://try.gitea.io"
+
+func init() {
+	setting.WebAuthn.RPID = "try.gitea.io"
+	setting.WebAuthn.RPDisplayName = "Gitea"
+	setting.WebAuthn.Origin = origin
+	setting.WebAuthn.LegacyAppID = "https://try.gitea.io"
+}
+
+// body encodes a response of the authenticator like the browser sends it
+func body(t *testing.T, resp interface{}) io.Reader {
+	data, err := json.Marshal(resp)
+	assert.NoError(t, err)
+	return bytes.NewReader(data)
+}
+
+func newUser(creds ...webauthn.Credential) *wa.User {
+	return &wa.User{ID: 1, Name: "user1", DisplayName: "User One", Credentials: creds}
+}
+
+func register(t *testing.T, authenticator *webauthntest.Authenticator) *webauthn.Credential {
+	options, session, err := wa.BeginRegistration(newUser())
+	assert.NoError(t, err)
+	resp, err := authenticator.Register(options, origin)
+	assert.NoError(t, err)
+	cred, err := wa.FinishRegistration(newUser(), session, body(t, resp))
+	assert.NoError(t, err)
+	return cred
+}
+
+func TestRegistration(t *testing.T) {
+	authenticator, err := webauthntest.NewAuthenticator()
+	assert.NoError(t, err)
+
+	cred := register(t, authenticator)
+	assert.Equal(t, authenticator.CredentialID, cred.ID)
+	expectedKey, err := webauthncose.ParsePublicKey(authenticator.PublicKeyCOSE())
+	assert.NoError(t, err)
+	key, err := webauthncose.ParsePublicKey(cred.PublicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedKey, key)
+	assert.Equal(t, "none", cred.AttestationType)
+
+	// registering an already registered authenticator is refused by it
+	options, _, err := wa.BeginRegistration(newUser(*cred))
+	assert.NoError(t, err)
+	_, err = authenticator.Register(options, origin)
+	assert.Error(t, err)
+
+	// the response must come from our origin and answer our challenge
+	options, session, err := wa.BeginRegistration(newUser())
+	assert.NoError(t, err)
+	resp, err := authenticator.Register(options, "https://evil.example.com")
+	assert.NoError(t, err)
+	_, err = wa.FinishRegistration(newUser(), session, body(t, resp))
+	assert.Error(t, err)
+
+	resp, err = authenticator.Register(options, origin)
+	assert.NoError(t, err)
+	_, otherSession, err := wa.BeginRegistration(newUser())
+	assert.NoError(t, err)
+	_, err = wa.FinishRegistration(newUser(), otherSession, body(t, resp))
+	assert.Error(t, err)
+
+	// the session belongs to another user
+	other := newUser()
+	other.ID = 2
+	_, err = wa.FinishRegistration(other, session, body(t, resp))
+	assert.Error(t, err)
+}
+
+func TestLogin(t *testing.T) {
+	authenticator, err := webauthntest.NewAuthenticator()
+	assert.NoError(t, err)
+	cred := register(t, authenticator)
+
+	options, session, err := wa.BeginLogin(newUser(*cred))
+	assert.NoError(t, err)
+	assert.Empty(t, options.Response.Extensions)
+	resp, err := authenticator.Login(options, origin)
+	assert.NoError(t, err)
+	updated, err := wa.FinishLogin(newUser(*cred), session, body(t, resp))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, updated.Authenticator.SignCount)
+
+	// a replayed assertion does not increase the counter
+	_, err = wa.FinishLogin(newUser(*updated), session, body(t, resp))
+	assert.Error(t, err)
+
+	// the signature must match the stored public key
+	other, err := webauthntest.NewAuthenticator()
+	assert.NoError(t, err)
+	other.CredentialID = authenticator.CredentialID
+	options, session, err = wa.BeginLogin(newUser(*updated))
+	assert.NoError(t, err)
+	resp, err = other.Login(options, origin)
+	assert.NoError(t, err)
+	_, err = wa.FinishLogin(newUser(*updated), session, body(t, resp))
+	assert.Error(t, err)
+
+	// wrong origin
+	options, session, err = wa.BeginLogin(newUser(*updated))
+	assert.NoError(t, err)
+	resp, err = authenticator.Login(options, "https://evil.example.com")
+	assert.NoError(t, err)
+	_, err = wa.FinishLogin(newUser(*updated), session, body(t, resp))
+	assert.Error(t, err)
+
+	_, _, err = wa.BeginLogin(newUser())
+	assert.Error(t, err)
+}
+
+func TestLoginLegacyU2F(t *testing.T) {
+	authenticator, err := webauthntest.NewAuthenticator()
+	assert.NoError(t, err)
+	authenticator.AppID = setting.WebAuthn.LegacyAppID
+	cred := webauthn.Credential{
+		ID:        authenticator.CredentialID,
+		PublicKey: authenticator.PublicKeyCOSE(),
+	}
+	user := newUser()
+	user.LegacyCredentials = []webauthn.Credential{cred}
+
+	options, session, err := wa.BeginLogin(user)
+	assert.NoError(t, err)
+	assert.Equal(t, setting.WebAuthn.LegacyAppID, options.Response.Extensions["appid"])
+	resp, err := authenticator.Login(options, origin)
+	assert.NoError(t, err)
+	_, err = wa.FinishLogin(user, session, body(t, resp))
+	assert.NoError(t, err)
+
+	// credentials not registered with U2F are bound to the relying party ID
+	options, session, err = wa.BeginLogin(newUser(cred))
+	assert.NoError(t, err)
+	options.Response.Extensions = map[string]interface{}{"appid": setting.WebAuthn.LegacyAppID}
+	resp, err = authenticator.Login(options, origin)
+	assert.NoError(t, err)
+	_, err = wa.FinishLogin(newUser(cred), session, body(t, resp))
+	assert.Error(t, err)
+}
@@ -0,0 +1,185 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package webauthntest provides a software authenticator to test the
+// WebAuthn ceremonies without a browser or a security key.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/protocol/webauthncose"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Authenticator is a software authenticator holding a single ES256 credential
+type Authenticator struct {
+	CredentialID []byte
+	PrivateKey   *ecdsa.PrivateKey
+	SignCount    uint32
+	// AppID is set for credentials created with the U2F API, the
+	// authenticator then answers requests using the appid extension.
+	AppID string
+}
+
+// NewAuthenticator creates an authenticator with a fresh key pair
+func NewAuthenticator() (*Authenticator, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return &Authenticator{CredentialID: id, PrivateKey: key}, nil
+}
+
+// PublicKeyCOSE returns the COSE_Key encoding of the credential public key
+func (a *Authenticator) PublicKeyCOSE() []byte {
+	return encodeCBOR(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  1, // P-256
+		XCoord: padTo32(a.PrivateKey.X.Bytes()),
+		YCoord: padTo32(a.PrivateKey.Y.Bytes()),
+	})
+}
+
+// PublicKeyU2F returns the credential public key as uncompressed point, as
+// stored in U2F registrations
+func (a *Authenticator) PublicKeyU2F() []byte {
+	return elliptic.Marshal(elliptic.P256(), a.PrivateKey.X, a.PrivateKey.Y)
+}
+
+// Register answers a navigator.credentials.create call made from origin
+func (a *Authenticator) Register(options *protocol.CredentialCreation, origin string) (*protocol.CredentialCreationResponse, error) {
+	for _, excluded := range options.Response.CredentialExcludeList {
+		if string(excluded.CredentialID) == string(a.CredentialID) {
+			return nil, errors.New("credential already registered")
+		}
+	}
+
+	clientData, err := a.clientData("webauthn.create", options.Response.Challenge, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	authData := a.authenticatorData(options.Response.RelyingParty.ID, 0x41)
+	authData = append(authData, make([]byte, 16)...)
+	authData = append(authData, byte(len(a.CredentialID)>>8), byte(len(a.CredentialID)))
+	authData = append(authData, a.CredentialID...)
+	authData = append(authData, a.PublicKeyCOSE()...)
+
+	attestation := encodeCBOR(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+
+	resp := &protocol.CredentialCreationResponse{
+		PublicKeyCredential: a.publicKeyCredential(),
+	}
+	resp.AttestationResponse.ClientDataJSON = clientData
+	resp.AttestationResponse.AttestationObject = attestation
+	return resp, nil
+}
+
+// Login answers a navigator.credentials.get call made from origin
+func (a *Authenticator) Login(options *protocol.CredentialAssertion, origin string) (*protocol.CredentialAssertionResponse, error) {
+	allowed := false
+	for _, cred := range options.Response.AllowedCredentials {
+		allowed = allowed || string(cred.CredentialID) == string(a.CredentialID)
+	}
+	if !allowed {
+		return nil, errors.New("credential not allowed")
+	}
+
+	rpID := options.Response.RelyingPartyID
+	if appID, ok := options.Response.Extensions["appid"].(string); ok && a.AppID != "" && appID == a.AppID {
+		rpID = appID
+	}
+
+	clientData, err := a.clientData("webauthn.get", options.Response.Challenge, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	a.SignCount++
+	authData := a.authenticatorData(rpID, 0x01)
+	clientDataHash := sha256.Sum256(clientData)
+	hash := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	r, s, err := ecdsa.Sign(rand.Reader, a.PrivateKey, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	signature, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &protocol.CredentialAssertionResponse{
+		PublicKeyCredential: a.publicKeyCredential(),
+	}
+	resp.AssertionResponse.ClientDataJSON = clientData
+	resp.AssertionResponse.AuthenticatorData = authData
+	resp.AssertionResponse.Signature = signature
+	return resp, nil
+}
+
+func (a *Authenticator) publicKeyCredential() protocol.PublicKeyCredential {
+	return protocol.PublicKeyCredential{
+		Credential: protocol.Credential{
+			ID:   base64.RawURLEncoding.EncodeToString(a.CredentialID),
+			Type: string(protocol.PublicKeyCredentialType),
+		},
+		RawID: a.CredentialID,
+	}
+}
+
+func (a *Authenticator) clientData(ceremony string, challenge []byte, origin string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"type":      ceremony,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    origin,
+	})
+}
+
+func (a *Authenticator) authenticatorData(rpID string, flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, a.SignCount)
+	return append(data, counter...)
+}
+
+func padTo32(b []byte) []byte {
+	return append(make([]byte, 32-len(b)), b...)
+}
+
+// encodeCBOR encodes authenticator responses in the canonical CTAP2 encoding
+func encodeCBOR(value interface{}) []byte {
+	em, err := cbor.CTAP2EncOptions().EncMode()
+	if err != nil {
+		panic(err)
+	}
+	data, err := em.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -20,6 +20,8 @@ import (
 	"code.gitea.io/gitea/modules/graceful"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/services/mailer"
 	"github.com/gobwas/glob"
 	"github.com/unknwon/com"
 )
@@ -96,13 +98,24 @@ func Deliver(t *models.HookTask) error {
 
 	defer func() {
 		t.Delivered = time.Now().UnixNano()
+		t.Attempts++
 		if t.IsSucceed {
 			log.Trace("Hook delivered: %s", t.UUID)
+		} else if t.Attempts < setting.Webhook.MaxAttempts {
+			// Deliver the task again later
+			t.IsDelivered = false
+			t.NextAttemptUnix = timeutil.TimeStampNow().Add(int64(retryBackoff(t.Attempts) / time.Second))
+			log.Trace("Hook delivery failed: %s, attempting again at %v", t.UUID, t.NextAttemptUnix.AsTime())
 		} else {
 			log.Trace("Hook delivery failed: %s", t.UUID)
 		}
 
-		if err := models.UpdateHookTask(t); err != nil {
+		if err := models.UpdateHookTaskWithAttempt(t, &models.HookTaskAttempt{
+			Attempt:      t.Attempts,
+			IsSucceed:    t.IsSucceed,
+			ResponseInfo: t.ResponseInfo,
+			Delivered:    t.Delivered,
+		}); err != nil {
 			log.Error("UpdateHookTask [%d]: %v", t.ID, err)
 		}
 
@@ -121,6 +134,9 @@ func Deliver(t *models.HookTask) error {
 			log.Error("UpdateWebhookLastStatus: %v", err)
 			return
 		}
+		if t.IsDelivered {
+			updateWebhookFailureCount(w, t.IsSucceed)
+		}
 	}()
 
 	resp, err := webhookHTTPClient.Do(req)
@@ -146,6 +162,90 @@ func Deliver(t *models.HookTask) error {
 	return nil
 }
 
+// retryBackoff returns the time to wait before the next attempt to deliver a task, it doubles
+// with every failed attempt
+func retryBackoff(attempts int) time.Duration {
+	backoff := setting.Webhook.RetryBackoff << uint(attempts-1)
+	if backoff <= 0 || backoff > setting.Webhook.MaxRetryBackoff {
+		backoff = setting.Webhook.MaxRetryBackoff
+	}
+	return backoff
+}
+
+// updateWebhookFailureCount counts the consecutive tasks of the webhook which could not be
+// delivered, the webhook is deactivated and its owners are notified once there are too many
+func updateWebhookFailureCount(w *models.Webhook, succeeded bool) {
+	if succeeded {
+		if w.FailureCount == 0 {
+			return
+		}
+		w.FailureCount = 0
+	} else {
+		w.FailureCount++
+	}
+
+	disable := !succeeded && w.IsActive && setting.Webhook.DisableAfterFailures > 0 &&
+		w.FailureCount >= setting.Webhook.DisableAfterFailures
+	if disable {
+		w.IsActive = false
+	}
+	if err := models.UpdateWebhookFailureCount(w); err != nil {
+		log.Error("UpdateWebhookFailureCount: %v", err)
+		return
+	}
+	if disable {
+		log.Warn("Webhook %d has been deactivated after %d failed deliveries", w.ID, w.FailureCount)
+		if err := notifyWebhookDisabled(w); err != nil {
+			log.Error("notifyWebhookDisabled [%d]: %v", w.ID, err)
+		}
+	}
+}
+
+// notifyWebhookDisabled mails the owners of a webhook which has been deactivated
+func notifyWebhookDisabled(w *models.Webhook) error {
+	if setting.MailService == nil {
+		return nil
+	}
+
+	var owner *models.User
+	var link string
+	if w.RepoID > 0 {
+		repo, err := models.GetRepositoryByID(w.RepoID)
+		if err != nil {
+			return err
+		}
+		if err = repo.GetOwner(); err != nil {
+			return err
+		}
+		owner = repo.Owner
+		link = fmt.Sprintf("%s/settings/hooks/%d", repo.HTMLURL(), w.ID)
+	} else if w.OrgID > 0 {
+		org, err := models.GetUserByID(w.OrgID)
+		if err != nil {
+			return err
+		}
+		owner = org
+		link = fmt.Sprintf("%sorg/%s/settings/hooks/%d", setting.AppURL, url.PathEscape(org.Name), w.ID)
+	} else {
+		return nil
+	}
+
+	tos := []*models.User{owner}
+	if owner.IsOrganization() {
+		team, err := owner.GetOwnerTeam()
+		if err != nil {
+			return err
+		}
+		if err = team.GetMembers(); err != nil {
+			return err
+		}
+		tos = team.Members
+	}
+
+	mailer.SendWebhookDisabledMail(tos, w, owner.Name, link)
+	return nil
+}
+
 // DeliverHooks checks and delivers undelivered hooks.
 // FIXME: graceful: This would likely benefit from either a worker pool with dummy queue
 // or a full queue. Then more hooks could be sent at same time.
@@ -173,12 +273,36 @@ func DeliverHooks(ctx context.Context) {
 		}
 	}
 
+	// Check regularly for failed deliveries which are due to be attempted again
+	interval := time.Minute
+	if setting.Webhook.RetryBackoff > 0 && setting.Webhook.RetryBackoff < interval {
+		interval = setting.Webhook.RetryBackoff
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
 	// Start listening on new hook requests.
 	for {
 		select {
 		case <-ctx.Done():
 			hookQueue.Close()
 			return
+		case <-ticker.C:
+			tasks, err := models.FindUndeliveredHookTasks()
+			if err != nil {
+				log.Error("DeliverHooks: %v", err)
+				continue
+			}
+			for _, t := range tasks {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+				if err = Deliver(t); err != nil {
+					log.Error("deliver: %v", err)
+				}
+			}
 		case repoIDStr := <-hookQueue.Queue():
 			log.Trace("DeliverHooks [repo_id: %v]", repoIDStr)
 			hookQueue.Remove(repoIDStr)
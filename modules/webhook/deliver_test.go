@@ -6,10 +6,14 @@ package webhook
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
+	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,3 +41,90 @@ func TestWebhookProxy(t *testing.T) {
 		}
 	}
 }
+
+func TestRetryBackoff(t *testing.T) {
+	defer func(backoff, max time.Duration) {
+		setting.Webhook.RetryBackoff = backoff
+		setting.Webhook.MaxRetryBackoff = max
+	}(setting.Webhook.RetryBackoff, setting.Webhook.MaxRetryBackoff)
+	setting.Webhook.RetryBackoff = time.Minute
+	setting.Webhook.MaxRetryBackoff = 5 * time.Minute
+
+	assert.Equal(t, time.Minute, retryBackoff(1))
+	assert.Equal(t, 2*time.Minute, retryBackoff(2))
+	assert.Equal(t, 4*time.Minute, retryBackoff(3))
+	assert.Equal(t, 5*time.Minute, retryBackoff(4))
+	assert.Equal(t, 5*time.Minute, retryBackoff(100))
+}
+
+func TestDeliverRetries(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	defer func(attempts, failures int) {
+		setting.Webhook.MaxAttempts = attempts
+		setting.Webhook.DisableAfterFailures = failures
+	}(setting.Webhook.MaxAttempts, setting.Webhook.DisableAfterFailures)
+	setting.Webhook.MaxAttempts = 2
+	setting.Webhook.DisableAfterFailures = 1
+	webhookHTTPClient = http.DefaultClient
+
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	task := &models.HookTask{
+		RepoID:         1,
+		HookID:         1,
+		Type:           models.GITEA,
+		URL:            server.URL,
+		PayloadContent: "{}",
+		ContentType:    models.ContentTypeJSON,
+		EventType:      models.HookEventPush,
+	}
+	assert.NoError(t, models.CreateHookTask(task))
+
+	// The first failed attempt is retried later
+	assert.NoError(t, Deliver(task))
+	task = models.AssertExistsAndLoadBean(t, &models.HookTask{ID: task.ID}).(*models.HookTask)
+	assert.False(t, task.IsDelivered)
+	assert.False(t, task.IsSucceed)
+	assert.EqualValues(t, 1, task.Attempts)
+	assert.True(t, task.NextAttemptUnix > timeutil.TimeStampNow())
+	hook := models.AssertExistsAndLoadBean(t, &models.Webhook{ID: 1}).(*models.Webhook)
+	assert.EqualValues(t, models.HookStatusFail, hook.LastStatus)
+	assert.True(t, hook.IsActive)
+
+	// The last failed attempt deactivates the webhook
+	assert.NoError(t, Deliver(task))
+	task = models.AssertExistsAndLoadBean(t, &models.HookTask{ID: task.ID}).(*models.HookTask)
+	assert.True(t, task.IsDelivered)
+	assert.False(t, task.IsSucceed)
+	assert.EqualValues(t, 2, task.Attempts)
+	hook = models.AssertExistsAndLoadBean(t, &models.Webhook{ID: 1}).(*models.Webhook)
+	assert.EqualValues(t, 1, hook.FailureCount)
+	assert.False(t, hook.IsActive)
+
+	tasks := []*models.HookTask{task}
+	assert.NoError(t, models.LoadHookTaskAttempts(tasks))
+	if assert.Len(t, task.AttemptHistory, 2) {
+		assert.EqualValues(t, 1, task.AttemptHistory[0].Attempt)
+		assert.EqualValues(t, 2, task.AttemptHistory[1].Attempt)
+		assert.Equal(t, http.StatusInternalServerError, task.AttemptHistory[1].ResponseInfo.Status)
+	}
+
+	// A successful redelivery to the current URL of the webhook resets the failure count
+	status = http.StatusOK
+	hook.URL = server.URL
+	redelivered, err := RedeliverHookTask(hook, task)
+	assert.NoError(t, err)
+	assert.Equal(t, task.PayloadContent, redelivered.PayloadContent)
+	assert.NotEqual(t, task.UUID, redelivered.UUID)
+	assert.NoError(t, Deliver(redelivered))
+	redelivered = models.AssertExistsAndLoadBean(t, &models.HookTask{ID: redelivered.ID}).(*models.HookTask)
+	assert.True(t, redelivered.IsDelivered)
+	assert.True(t, redelivered.IsSucceed)
+	hook = models.AssertExistsAndLoadBean(t, &models.Webhook{ID: 1}).(*models.Webhook)
+	assert.EqualValues(t, 0, hook.FailureCount)
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/git"
@@ -125,12 +126,7 @@ func prepareWebhook(w *models.Webhook, repo *models.Repository, event models.Hoo
 		if err != nil {
 			log.Error("prepareWebhooks.JSONPayload: %v", err)
 		}
-		sig := hmac.New(sha256.New, []byte(w.Secret))
-		_, err = sig.Write(data)
-		if err != nil {
-			log.Error("prepareWebhooks.sigWrite: %v", err)
-		}
-		signature = hex.EncodeToString(sig.Sum(nil))
+		signature = signPayload(w.Secret, data)
 	}
 
 	if err = models.CreateHookTask(&models.HookTask{
@@ -150,6 +146,76 @@ func prepareWebhook(w *models.Webhook, repo *models.Repository, event models.Hoo
 	return nil
 }
 
+// signPayload returns the HMAC-SHA256 signature of the payload data
+func signPayload(secret string, data []byte) string {
+	sig := hmac.New(sha256.New, []byte(secret))
+	if _, err := sig.Write(data); err != nil {
+		log.Error("signPayload.sigWrite: %v", err)
+	}
+	return hex.EncodeToString(sig.Sum(nil))
+}
+
+// RedeliverHookTask adds a new task to the task queue which sends the payload of the given
+// task again, using the current settings of the webhook.
+func RedeliverHookTask(w *models.Webhook, t *models.HookTask) (*models.HookTask, error) {
+	task, err := redeliverHookTask(w, t)
+	if err != nil {
+		return nil, err
+	}
+
+	go hookQueue.Add(task.RepoID)
+	return task, nil
+}
+
+func redeliverHookTask(w *models.Webhook, t *models.HookTask) (*models.HookTask, error) {
+	var signature string
+	if len(w.Secret) > 0 {
+		signature = signPayload(w.Secret, []byte(t.PayloadContent))
+	}
+
+	task := &models.HookTask{
+		RepoID:         t.RepoID,
+		HookID:         w.ID,
+		Type:           w.HookTaskType,
+		URL:            w.URL,
+		Signature:      signature,
+		PayloadContent: t.PayloadContent,
+		HTTPMethod:     w.HTTPMethod,
+		ContentType:    w.ContentType,
+		EventType:      t.EventType,
+		IsSSL:          w.IsSSL,
+	}
+	if err := models.CreateHookTask(task); err != nil {
+		return nil, fmt.Errorf("CreateHookTask: %v", err)
+	}
+	return task, nil
+}
+
+// ReplayHookTasks redelivers all tasks of the webhook which have been delivered in the given
+// time range, the end is not included.
+func ReplayHookTasks(w *models.Webhook, since, before time.Time) ([]*models.HookTask, error) {
+	tasks, err := models.FindHookTasksDeliveredBetween(w.ID, since, before)
+	if err != nil {
+		return nil, fmt.Errorf("FindHookTasksDeliveredBetween: %v", err)
+	}
+
+	replayed := make([]*models.HookTask, 0, len(tasks))
+	repoIDs := make(map[int64]struct{})
+	for _, t := range tasks {
+		task, err := redeliverHookTask(w, t)
+		if err != nil {
+			return nil, err
+		}
+		replayed = append(replayed, task)
+		repoIDs[task.RepoID] = struct{}{}
+	}
+
+	for repoID := range repoIDs {
+		go hookQueue.Add(repoID)
+	}
+	return replayed, nil
+}
+
 // PrepareWebhooks adds new webhooks to task queue for given payload.
 func PrepareWebhooks(repo *models.Repository, event models.HookEventType, p api.Payloader) error {
 	if err := prepareWebhooks(repo, event, p); err != nil {
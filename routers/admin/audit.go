@@ -0,0 +1,75 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/audit"
+)
+
+const (
+	tplAuditLog base.TplName = "admin/audit"
+)
+
+// AuditLog shows the audit log
+func AuditLog(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.audit")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminAuditLog"] = true
+
+	ctx.Data["AuditActions"] = models.AllAuditActions
+	ctx.Data["AuditTargetTypes"] = models.AllAuditTargetTypes
+	for _, key := range []string{"action", "actor", "target_type", "target", "since", "until"} {
+		ctx.Data["Filter_"+key] = ctx.Query(key)
+	}
+
+	opts, err := audit.SearchOptionsFromQuery(ctx.Req.URL.Query())
+	if err != nil {
+		ctx.Flash.Error(ctx.Tr("admin.audit.invalid_filter", err.Error()), true)
+		opts = &models.SearchAuditEventsOptions{}
+	}
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+	opts.Page = page
+	opts.PageSize = setting.UI.Admin.NoticePagingNum
+
+	events, total, err := models.SearchAuditEvents(opts)
+	if err != nil {
+		ctx.ServerError("SearchAuditEvents", err)
+		return
+	}
+	ctx.Data["AuditEvents"] = events
+	ctx.Data["Total"] = total
+
+	pager := context.NewPagination(int(total), setting.UI.Admin.NoticePagingNum, page, 5)
+	for _, key := range []string{"action", "actor", "target_type", "target", "since", "until"} {
+		pager.AddParam(ctx, key, "Filter_"+key)
+	}
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplAuditLog)
+}
+
+// ExportAuditLog exports the audit events matching the filters as JSON lines
+func ExportAuditLog(ctx *context.Context) {
+	opts, err := audit.SearchOptionsFromQuery(ctx.Req.URL.Query())
+	if err != nil {
+		ctx.Error(400, err.Error())
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "application/x-ndjson")
+	ctx.Resp.Header().Set("Content-Disposition", `attachment; filename="audit-log.jsonl"`)
+	if err := audit.ExportJSONLines(ctx.Resp, opts); err != nil {
+		log.Error("ExportJSONLines: %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/mailer"
+
+	"github.com/unknwon/com"
+)
+
+const (
+	tplFailedMails base.TplName = "admin/mails"
+)
+
+// FailedMails shows the mails which could not be sent
+func FailedMails(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.mails")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminMails"] = true
+
+	total := models.CountFailedMails()
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	mails, err := models.FailedMails(page, setting.UI.Admin.NoticePagingNum)
+	if err != nil {
+		ctx.ServerError("FailedMails", err)
+		return
+	}
+	ctx.Data["Mails"] = mails
+
+	ctx.Data["Total"] = total
+
+	ctx.Data["Page"] = context.NewPagination(int(total), setting.UI.Admin.NoticePagingNum, page, 5)
+
+	ctx.HTML(200, tplFailedMails)
+}
+
+func queryIDs(ctx *context.Context) []int64 {
+	strs := ctx.QueryStrings("ids[]")
+	ids := make([]int64, 0, len(strs))
+	for i := range strs {
+		id := com.StrTo(strs[i]).MustInt64()
+		if id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// ResendFailedMails queues the selected failed mails again
+func ResendFailedMails(ctx *context.Context) {
+	if err := mailer.ResendFailedMails(queryIDs(ctx)); err != nil {
+		ctx.Flash.Error("ResendFailedMails: " + err.Error())
+		ctx.Status(500)
+		return
+	}
+
+	log.Trace("Failed mails resent by admin (%s)", ctx.User.Name)
+	ctx.Flash.Success(ctx.Tr("admin.mails.resend_success"))
+	ctx.Status(200)
+}
+
+// DeleteFailedMails deletes the selected failed mails
+func DeleteFailedMails(ctx *context.Context) {
+	if err := models.DeleteFailedMailsByIDs(queryIDs(ctx)); err != nil {
+		ctx.Flash.Error("DeleteFailedMailsByIDs: " + err.Error())
+		ctx.Status(500)
+		return
+	}
+
+	log.Trace("Failed mails deleted by admin (%s)", ctx.User.Name)
+	ctx.Flash.Success(ctx.Tr("admin.mails.delete_success"))
+	ctx.Status(200)
+}
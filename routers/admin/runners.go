@@ -0,0 +1,47 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplRunners base.TplName = "admin/runners"
+)
+
+// Runners shows the registered action runners
+func Runners(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("admin.runners")
+	ctx.Data["PageIsAdmin"] = true
+	ctx.Data["PageIsAdminRunners"] = true
+
+	runners, err := models.GetActionRunners()
+	if err != nil {
+		ctx.ServerError("GetActionRunners", err)
+		return
+	}
+	ctx.Data["Runners"] = runners
+
+	ctx.HTML(200, tplRunners)
+}
+
+// DeleteRunner removes a runner, its running jobs are returned to the queue
+func DeleteRunner(ctx *context.Context) {
+	if err := models.DeleteActionRunner(ctx.QueryInt64("id")); err != nil {
+		ctx.ServerError("DeleteActionRunner", err)
+		return
+	}
+	log.Trace("Runner deleted by admin (%s): %d", ctx.User.Name, ctx.QueryInt64("id"))
+
+	ctx.Flash.Success(ctx.Tr("admin.runners.deletion_success"))
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": setting.AppSubURL + "/admin/runners",
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"code.gitea.io/gitea/modules/password"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/routers"
+	"code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/mailer"
 
 	"github.com/unknwon/com"
@@ -232,6 +233,7 @@ func EditUserPost(ctx *context.Context, form auth.AdminEditUserForm) {
 	u.Location = form.Location
 	u.MaxRepoCreation = form.MaxRepoCreation
 	u.IsActive = form.Active
+	adminChanged := u.IsAdmin != form.Admin
 	u.IsAdmin = form.Admin
 	u.AllowGitHook = form.AllowGitHook
 	u.AllowImportLocal = form.AllowImportLocal
@@ -248,6 +250,9 @@ func EditUserPost(ctx *context.Context, form auth.AdminEditUserForm) {
 		return
 	}
 	log.Trace("Account profile updated by admin (%s): %s", ctx.User.Name, u.Name)
+	if adminChanged {
+		audit.Record(models.AuditActionUserSiteAdmin, ctx.User, ctx.RemoteAddr(), u, "Changed site administrator, admin: %t", u.IsAdmin)
+	}
 
 	ctx.Flash.Success(ctx.Tr("admin.users.update_profile_success"))
 	ctx.Redirect(setting.AppSubURL + "/admin/users/" + ctx.Params(":userid"))
@@ -273,6 +278,11 @@ func DeleteUser(ctx *context.Context) {
 			ctx.JSON(200, map[string]interface{}{
 				"redirect": setting.AppSubURL + "/admin/users/" + ctx.Params(":userid"),
 			})
+		case models.IsErrUserOwnPackages(err):
+			ctx.Flash.Error(ctx.Tr("admin.users.still_own_packages"))
+			ctx.JSON(200, map[string]interface{}{
+				"redirect": setting.AppSubURL + "/admin/users/" + ctx.Params(":userid"),
+			})
 		default:
 			ctx.ServerError("DeleteUser", err)
 		}
@@ -0,0 +1,169 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	api "code.gitea.io/gitea/modules/structs"
+	actions_service "code.gitea.io/gitea/services/actions"
+	pull_service "code.gitea.io/gitea/services/pull"
+
+	"gitea.com/macaron/binding"
+	"gitea.com/macaron/macaron"
+)
+
+const runnerKey = "ActionRunner"
+
+func apiError(ctx *context.Context, status int, obj interface{}) {
+	message := ""
+	if err, ok := obj.(error); ok {
+		message = err.Error()
+	} else if obj != nil {
+		message = obj.(string)
+	}
+	if status == http.StatusInternalServerError {
+		log.Error("Actions runner API error [%s]: %s", ctx.Req.URL.Path, message)
+		message = http.StatusText(status)
+	}
+	ctx.JSON(status, map[string]string{
+		"message": message,
+	})
+}
+
+// RegisterRoutes registers the routes of the runner API which are mounted at /api/actions
+func RegisterRoutes(m *macaron.Macaron) {
+	bind := binding.Bind
+
+	m.Post("/runners/register", bind(api.RegisterActionRunnerOption{}), RegisterRunner)
+	m.Group("", func() {
+		m.Post("/runners/fetch", FetchJob)
+		m.Group("/jobs/:id", func() {
+			m.Post("/logs", AppendLog)
+			m.Post("/finish", bind(api.FinishActionJobOption{}), FinishJob)
+		}, jobAssignment())
+	}, runnerAssignment())
+}
+
+// runnerAssignment authenticates the runner by the bearer token of the request
+func runnerAssignment() macaron.Handler {
+	return func(ctx *context.Context) {
+		auth := ctx.Req.Header.Get("Authorization")
+		fields := strings.Fields(auth)
+		if len(fields) != 2 || !strings.EqualFold(fields[0], "bearer") {
+			apiError(ctx, http.StatusUnauthorized, "missing runner token")
+			return
+		}
+
+		runner, err := models.GetActionRunnerByToken(fields[1])
+		if err != nil {
+			if models.IsErrActionRunnerNotExist(err) {
+				apiError(ctx, http.StatusUnauthorized, "invalid runner token")
+			} else {
+				apiError(ctx, http.StatusInternalServerError, err)
+			}
+			return
+		}
+		ctx.Data[runnerKey] = runner
+	}
+}
+
+func getRunner(ctx *context.Context) *models.ActionRunner {
+	return ctx.Data[runnerKey].(*models.ActionRunner)
+}
+
+// jobAssignment loads the job of the request which must be running on the requesting runner
+func jobAssignment() macaron.Handler {
+	return func(ctx *context.Context) {
+		job, err := models.GetActionRunJobByID(ctx.ParamsInt64(":id"))
+		if err != nil {
+			if models.IsErrActionRunJobNotExist(err) {
+				apiError(ctx, http.StatusNotFound, err)
+			} else {
+				apiError(ctx, http.StatusInternalServerError, err)
+			}
+			return
+		}
+		if job.RunnerID != getRunner(ctx).ID {
+			apiError(ctx, http.StatusNotFound, models.ErrActionRunJobNotExist{ID: job.ID})
+			return
+		}
+		if job.Status != models.ActionStatusRunning {
+			apiError(ctx, http.StatusConflict, "job is "+job.Status.String())
+			return
+		}
+		ctx.Data["ActionRunJob"] = job
+	}
+}
+
+func getJob(ctx *context.Context) *models.ActionRunJob {
+	return ctx.Data["ActionRunJob"].(*models.ActionRunJob)
+}
+
+// RegisterRunner registers a new runner and returns the token it has to use
+func RegisterRunner(ctx *context.Context, form api.RegisterActionRunnerOption) {
+	runner, err := actions_service.RegisterRunner(form.Token, form.Name, form.Labels)
+	if err != nil {
+		if err == actions_service.ErrInvalidRegistrationToken {
+			apiError(ctx, http.StatusForbidden, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, &api.ActionRunner{
+		ID:     runner.ID,
+		UUID:   runner.UUID,
+		Name:   runner.Name,
+		Labels: runner.Labels,
+		Token:  runner.Token,
+	})
+}
+
+// FetchJob assigns the next waiting job to the runner. It responds with 204 if there is no job.
+func FetchJob(ctx *context.Context) {
+	job, err := actions_service.PickJob(getRunner(ctx))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if job == nil {
+		ctx.Status(http.StatusNoContent)
+		return
+	}
+
+	apiJob, err := actions_service.ToActionJob(job)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, apiJob)
+}
+
+// AppendLog appends the request body to the log of the job
+func AppendLog(ctx *context.Context) {
+	if err := actions_service.AppendLog(getJob(ctx), ctx.Req.Request.Body); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// FinishJob stores the result of the job
+func FinishJob(ctx *context.Context, form api.FinishActionJobOption) {
+	job := getJob(ctx)
+	if err := actions_service.FinishJob(job, models.ParseActionStatus(form.Status)); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	pull_service.AddScheduledToAutoMergeQueue(job.RepoID)
+	pull_service.CheckMergeQueuesOfRepo(job.RepoID)
+	ctx.Status(http.StatusNoContent)
+}
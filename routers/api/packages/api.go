@@ -0,0 +1,85 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/routers/api/packages/generic"
+	"code.gitea.io/gitea/routers/api/packages/maven"
+	"code.gitea.io/gitea/routers/api/packages/npm"
+
+	"gitea.com/macaron/macaron"
+)
+
+func reqPackageAccess(accessMode models.AccessMode) macaron.Handler {
+	return func(ctx *context.Context) {
+		if ctx.Package.AccessMode >= accessMode {
+			return
+		}
+		if !ctx.IsSigned {
+			ctx.Resp.Header().Set("WWW-Authenticate", `Basic realm="Gitea Package Registry"`)
+			ctx.Error(http.StatusUnauthorized, "reqPackageAccess")
+			return
+		}
+		ctx.Error(http.StatusForbidden, "reqPackageAccess")
+	}
+}
+
+// RegisterRoutes registers the routes of the package registries which are mounted at /api/packages
+func RegisterRoutes(m *macaron.Macaron) {
+	m.Group("/:username", func() {
+		m.Group("/generic", func() {
+			m.Group("/:packagename/:packageversion", func() {
+				m.Delete("", reqPackageAccess(models.AccessModeWrite), generic.DeletePackage)
+				m.Group("/:filename", func() {
+					m.Get("", generic.DownloadPackageFile)
+					m.Put("", reqPackageAccess(models.AccessModeWrite), generic.UploadPackage)
+					m.Delete("", reqPackageAccess(models.AccessModeWrite), generic.DeletePackageFile)
+				})
+			})
+		})
+		m.Group("/maven", func() {
+			m.Put("/*", reqPackageAccess(models.AccessModeWrite), maven.UploadPackageFile)
+			m.Get("/*", maven.DownloadPackageFile)
+		})
+		m.Group("/npm", func() {
+			m.Group("/@:scope/:id", func() {
+				npmPackageRoutes(m)
+			})
+			m.Group("/:id", func() {
+				npmPackageRoutes(m)
+			})
+			m.Group("/-/package/@:scope/:id/dist-tags", func() {
+				npmDistTagRoutes(m)
+			})
+			m.Group("/-/package/:id/dist-tags", func() {
+				npmDistTagRoutes(m)
+			})
+		})
+	}, context.PackageAssignment(), reqPackageAccess(models.AccessModeRead))
+}
+
+func npmPackageRoutes(m *macaron.Macaron) {
+	m.Get("", npm.PackageMetadata)
+	m.Put("", reqPackageAccess(models.AccessModeWrite), npm.UploadPackage)
+	m.Group("/-/:version/:filename", func() {
+		m.Get("", npm.DownloadPackageFile)
+		m.Delete("/-rev/:revision", reqPackageAccess(models.AccessModeWrite), npm.DeletePackageVersion)
+	})
+	m.Group("/-rev/:revision", func() {
+		m.Put("", reqPackageAccess(models.AccessModeWrite), npm.DeletePreview)
+		m.Delete("", reqPackageAccess(models.AccessModeWrite), npm.DeletePackage)
+	})
+}
+
+func npmDistTagRoutes(m *macaron.Macaron) {
+	m.Get("", npm.ListPackageTags)
+	m.Combo("/:tag", reqPackageAccess(models.AccessModeWrite)).
+		Put(npm.AddPackageTag).
+		Delete(npm.DeletePackageTag)
+}
@@ -0,0 +1,163 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const tokenExpiry = time.Hour
+
+type tokenClaims struct {
+	jwt.StandardClaims
+	UserID int64
+}
+
+func newToken(userID int64) (string, error) {
+	now := time.Now()
+	claims := tokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			IssuedAt:  now.Unix(),
+			NotBefore: now.Unix(),
+			ExpiresAt: now.Add(tokenExpiry).Unix(),
+		},
+		UserID: userID,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(setting.SecretKey))
+}
+
+func parseToken(tokenString string) (int64, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &tokenClaims{}, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return []byte(setting.SecretKey), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	claims, ok := token.Claims.(*tokenClaims)
+	if !ok || !token.Valid {
+		return 0, fmt.Errorf("invalid token claims")
+	}
+	return claims.UserID, nil
+}
+
+// setAuthenticateChallenge asks the client to fetch a bearer token from the token endpoint
+func setAuthenticateChallenge(ctx *context.Context) {
+	ctx.Resp.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%sv2/token",service="container_registry",scope="*"`, setting.AppURL))
+}
+
+func authorizationScheme(ctx *context.Context) string {
+	fields := strings.Fields(ctx.Req.Header.Get("Authorization"))
+	if len(fields) != 2 {
+		return ""
+	}
+	return strings.ToLower(fields[0])
+}
+
+func apiUnauthorizedError(ctx *context.Context) {
+	setAuthenticateChallenge(ctx)
+	apiErrorDefined(ctx, errUnauthorized)
+}
+
+// VerifyToken signs in the user of a bearer token issued by Authenticate
+func VerifyToken() func(ctx *context.Context) {
+	return func(ctx *context.Context) {
+		if ctx.IsSigned {
+			return
+		}
+
+		if authorizationScheme(ctx) != "bearer" {
+			return
+		}
+
+		userID, err := parseToken(strings.Fields(ctx.Req.Header.Get("Authorization"))[1])
+		if err != nil {
+			apiUnauthorizedError(ctx)
+			return
+		}
+		if userID <= 0 {
+			// token of an anonymous user
+			return
+		}
+
+		u, err := models.GetUserByID(userID)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				apiUnauthorizedError(ctx)
+			} else {
+				apiError(ctx, http.StatusInternalServerError, err)
+			}
+			return
+		}
+		if !u.IsActive || u.ProhibitLogin {
+			apiUnauthorizedError(ctx)
+			return
+		}
+
+		ctx.User = u
+		ctx.IsSigned = true
+		ctx.Data["SignedUser"] = u
+		ctx.Data["SignedUserID"] = u.ID
+		ctx.Data["SignedUserName"] = u.Name
+	}
+}
+
+// ReqContainerAccess requires a signed in user or a verified anonymous token,
+// other clients are asked to fetch a token
+func ReqContainerAccess(ctx *context.Context) {
+	if !ctx.IsSigned && authorizationScheme(ctx) != "bearer" {
+		apiUnauthorizedError(ctx)
+	}
+}
+
+// DetermineSupport is used to test if the registry supports OCI
+// https://docs.docker.com/registry/spec/api/#api-version-check
+func DetermineSupport(ctx *context.Context) {
+	ctx.Resp.Header().Set("Docker-Distribution-Api-Version", "registry/2.0")
+	ctx.JSON(http.StatusOK, map[string]string{})
+}
+
+// Authenticate issues a bearer token for the user of the basic authentication.
+// Anonymous clients get a token without user which only allows public reads.
+// https://docs.docker.com/registry/spec/auth/token/
+func Authenticate(ctx *context.Context) {
+	if !ctx.IsSigned && authorizationScheme(ctx) == "basic" {
+		apiUnauthorizedError(ctx)
+		return
+	}
+	if !context.VerifyPackageSignIn(ctx) {
+		return
+	}
+
+	var userID int64
+	if ctx.IsSigned {
+		userID = ctx.User.ID
+	}
+
+	token, err := newToken(userID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"token":        token,
+		"access_token": token,
+		"expires_in":   int64(tokenExpiry.Seconds()),
+		"issued_at":    time.Now().UTC().Format(time.RFC3339),
+	})
+}
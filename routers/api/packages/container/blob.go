@@ -0,0 +1,394 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+const digestPrefix = "sha256:"
+
+func getImagePackage(ctx *context.Context, image string) (*models.Package, error) {
+	return models.GetPackageByName(ctx.Package.Owner.ID, models.PackageTypeContainer, image)
+}
+
+// getBlob returns a file of the image which has the content of the digest
+func getBlob(ctx *context.Context, image, digest string, leadOnly bool) (*models.PackageFile, error) {
+	p, err := getImagePackage(ctx, image)
+	if err != nil {
+		return nil, err
+	}
+	pf, err := models.GetPackageFileByBlobHash(p.ID, strings.TrimPrefix(digest, digestPrefix), leadOnly)
+	if err != nil {
+		return nil, err
+	}
+	return pf, pf.LoadBlob()
+}
+
+func internalVersionOptions(ctx *context.Context, image string) *models.PackageCreationOptions {
+	return &models.PackageCreationOptions{
+		Owner:      ctx.Package.Owner,
+		Creator:    ctx.User,
+		Type:       models.PackageTypeContainer,
+		Name:       image,
+		Version:    internalVersion,
+		IsInternal: true,
+	}
+}
+
+// saveBlob stores the content and links it to the internal version of the image
+func saveBlob(ctx *context.Context, image string, buf *packages_module.HashedBuffer) error {
+	_, _, err := packages_service.CreatePackageOrAddFileToExisting(
+		internalVersionOptions(ctx, image),
+		&packages_service.FileInfo{
+			Filename:          digestPrefix + buf.HashSHA256,
+			Data:              buf,
+			OverwriteExisting: true,
+		},
+	)
+	return err
+}
+
+func setUploadHeaders(ctx *context.Context, image string, pbu *models.PackageBlobUpload) {
+	ctx.Resp.Header().Set("Location", fmt.Sprintf("%s/blobs/uploads/%s", imageURL(ctx, image), pbu.ID))
+	ctx.Resp.Header().Set("Range", fmt.Sprintf("0-%d", max(pbu.BytesReceived-1, 0)))
+	ctx.Resp.Header().Set("Docker-Upload-UUID", pbu.ID)
+}
+
+func setBlobCreatedHeaders(ctx *context.Context, image, digest string) {
+	ctx.Resp.Header().Set("Location", fmt.Sprintf("%s/blobs/%s", imageURL(ctx, image), digest))
+	ctx.Resp.Header().Set("Docker-Content-Digest", digest)
+}
+
+func max(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// InitiateUploadBlob starts a blob upload, mounts an existing blob or uploads a blob in a single request
+// https://docs.docker.com/registry/spec/api/#initiate-blob-upload
+func InitiateUploadBlob(ctx *context.Context, image, _ string) {
+	if mount := ctx.Query("mount"); mount != "" {
+		if mountBlob(ctx, image, mount, ctx.Query("from")) || ctx.Written() {
+			return
+		}
+	}
+
+	if digest := ctx.Query("digest"); digest != "" {
+		if !container_module.IsValidDigest(digest) {
+			apiErrorDefined(ctx, errDigestInvalid)
+			return
+		}
+
+		buf, err := helper.ReadBody(ctx)
+		if err != nil {
+			if err == packages_module.ErrFileTooLarge {
+				apiErrorDefined(ctx, errSizeInvalid)
+			} else {
+				apiError(ctx, http.StatusInternalServerError, err)
+			}
+			return
+		}
+		defer buf.Close()
+
+		if digestPrefix+buf.HashSHA256 != digest {
+			apiErrorDefined(ctx, errDigestInvalid)
+			return
+		}
+		if err := saveBlob(ctx, image, buf); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+
+		setBlobCreatedHeaders(ctx, image, digest)
+		ctx.Status(http.StatusCreated)
+		return
+	}
+
+	pbu, err := models.CreatePackageBlobUpload()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if err := os.MkdirAll(setting.Packages.ChunkedUploadPath, os.ModePerm); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	f, err := os.Create(packages_service.BlobUploadPath(pbu.ID))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	f.Close()
+
+	setUploadHeaders(ctx, image, pbu)
+	ctx.Status(http.StatusAccepted)
+}
+
+// mountBlob links a blob of another image of the same owner to the image.
+// It returns false if the blob can't be mounted and has to be uploaded.
+func mountBlob(ctx *context.Context, image, digest, from string) bool {
+	if !container_module.IsValidDigest(digest) {
+		return false
+	}
+
+	parts := strings.SplitN(from, "/", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], ctx.Package.Owner.Name) {
+		return false
+	}
+
+	source, err := getBlob(ctx, parts[1], digest, false)
+	if err != nil {
+		if !models.IsErrPackageNotExist(err) && !models.IsErrPackageFileNotExist(err) {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return false
+	}
+
+	pv, _, err := models.GetOrCreatePackageVersion(internalVersionOptions(ctx, image))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return false
+	}
+	if _, err := models.AddPackageFile(pv.ID, source.BlobID, digest, false); err != nil && !models.IsErrPackageFileAlreadyExist(err) {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return false
+	}
+
+	setBlobCreatedHeaders(ctx, image, digest)
+	ctx.Status(http.StatusCreated)
+	return true
+}
+
+func getBlobUpload(ctx *context.Context, uuid string) *models.PackageBlobUpload {
+	pbu, err := models.GetPackageBlobUploadByID(uuid)
+	if err != nil {
+		if models.IsErrPackageBlobUploadNotExist(err) {
+			apiErrorDefined(ctx, errBlobUploadUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil
+	}
+	return pbu
+}
+
+// appendToBlobUpload appends the request body to the upload
+func appendToBlobUpload(ctx *context.Context, pbu *models.PackageBlobUpload) {
+	if contentRange := ctx.Req.Header.Get("Content-Range"); contentRange != "" {
+		begin := strings.SplitN(contentRange, "-", 2)[0]
+		if start, err := strconv.ParseInt(begin, 10, 64); err != nil || start != pbu.BytesReceived {
+			ctx.Resp.Header().Set("Range", fmt.Sprintf("0-%d", max(pbu.BytesReceived-1, 0)))
+			apiErrorResponseWithMessage(ctx, errBlobUploadInvalid, http.StatusRequestedRangeNotSatisfiable, "invalid content range")
+			return
+		}
+	}
+
+	f, err := os.OpenFile(packages_service.BlobUploadPath(pbu.ID), os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		if os.IsNotExist(err) {
+			apiErrorDefined(ctx, errBlobUploadUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	defer f.Close()
+
+	var r io.Reader = ctx.Req.Request.Body
+	maxSize := setting.Packages.MaxFileSize << 20
+	if maxSize > 0 {
+		r = io.LimitReader(r, maxSize-pbu.BytesReceived+1)
+	}
+	n, err := io.Copy(f, r)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	pbu.BytesReceived += n
+	if maxSize > 0 && pbu.BytesReceived > maxSize {
+		apiErrorDefined(ctx, errSizeInvalid)
+		return
+	}
+
+	if err := models.UpdatePackageBlobUpload(pbu); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+	}
+}
+
+// GetUploadBlob returns the status of an upload
+// https://docs.docker.com/registry/spec/api/#get-blob-upload
+func GetUploadBlob(ctx *context.Context, image, uuid string) {
+	pbu := getBlobUpload(ctx, uuid)
+	if ctx.Written() {
+		return
+	}
+
+	setUploadHeaders(ctx, image, pbu)
+	ctx.Status(http.StatusNoContent)
+}
+
+// UploadBlob appends a chunk to the upload
+// https://docs.docker.com/registry/spec/api/#upload-blob-chunk
+func UploadBlob(ctx *context.Context, image, uuid string) {
+	pbu := getBlobUpload(ctx, uuid)
+	if ctx.Written() {
+		return
+	}
+
+	appendToBlobUpload(ctx, pbu)
+	if ctx.Written() {
+		return
+	}
+
+	setUploadHeaders(ctx, image, pbu)
+	ctx.Status(http.StatusAccepted)
+}
+
+// EndUploadBlob completes the upload with the optional last chunk
+// https://docs.docker.com/registry/spec/api/#put-blob-upload
+func EndUploadBlob(ctx *context.Context, image, uuid string) {
+	digest := ctx.Query("digest")
+	if !container_module.IsValidDigest(digest) {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return
+	}
+
+	pbu := getBlobUpload(ctx, uuid)
+	if ctx.Written() {
+		return
+	}
+
+	appendToBlobUpload(ctx, pbu)
+	if ctx.Written() {
+		return
+	}
+
+	f, err := os.Open(packages_service.BlobUploadPath(pbu.ID))
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	buf, err := packages_module.NewHashedBuffer(f, 0)
+	f.Close()
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer buf.Close()
+
+	if digestPrefix+buf.HashSHA256 != digest {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return
+	}
+	if err := saveBlob(ctx, image, buf); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if err := packages_service.DeleteBlobUpload(pbu.ID); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	setBlobCreatedHeaders(ctx, image, digest)
+	ctx.Status(http.StatusCreated)
+}
+
+// CancelUploadBlob aborts the upload
+// https://docs.docker.com/registry/spec/api/#cancel-blob-upload
+func CancelUploadBlob(ctx *context.Context, image, uuid string) {
+	pbu := getBlobUpload(ctx, uuid)
+	if ctx.Written() {
+		return
+	}
+
+	if err := packages_service.DeleteBlobUpload(pbu.ID); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// GetBlob serves the content of a blob
+// https://docs.docker.com/registry/spec/api/#get-blob
+func GetBlob(ctx *context.Context, image, digest string) {
+	if !container_module.IsValidDigest(digest) {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return
+	}
+
+	pf, err := getBlob(ctx, image, digest, false)
+	if err != nil {
+		if models.IsErrPackageNotExist(err) || models.IsErrPackageFileNotExist(err) {
+			apiErrorDefined(ctx, errBlobUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	s, err := packages_module.NewContentStore().Get(pf.Blob.HashSHA256)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer s.Close()
+
+	ctx.Resp.Header().Set("Content-Type", "application/octet-stream")
+	ctx.Resp.Header().Set("Docker-Content-Digest", digest)
+	http.ServeContent(ctx.Resp, ctx.Req.Request, filepath.Base(digest), pf.CreatedUnix.AsTime(), s)
+}
+
+// DeleteBlob removes the blob from the image. Blobs referenced by manifests remain available.
+// https://docs.docker.com/registry/spec/api/#delete-blob
+func DeleteBlob(ctx *context.Context, image, digest string) {
+	if !container_module.IsValidDigest(digest) {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return
+	}
+
+	p, err := getImagePackage(ctx, image)
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			apiErrorDefined(ctx, errBlobUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	pv, err := models.GetPackageVersionByName(p.ID, internalVersion)
+	if err == nil {
+		var pf *models.PackageFile
+		if pf, err = models.GetPackageFileByName(pv.ID, digest); err == nil {
+			err = packages_service.DeletePackageFile(pf)
+		}
+	}
+	if err != nil {
+		if models.IsErrPackageVersionNotExist(err) || models.IsErrPackageFileNotExist(err) {
+			apiErrorDefined(ctx, errBlobUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
@@ -0,0 +1,185 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+
+	"gitea.com/macaron/macaron"
+)
+
+// internalVersion is the name of the version which holds all uploaded blobs and the
+// manifests pushed by digest. It can't be used as tag.
+const internalVersion = "@internal"
+
+// apiErrorCode represents an error of the registry API
+// https://docs.docker.com/registry/spec/api/#errors-2
+type apiErrorCode struct {
+	Code       string
+	StatusCode int
+}
+
+var (
+	errBlobUnknown         = &apiErrorCode{"BLOB_UNKNOWN", http.StatusNotFound}
+	errBlobUploadInvalid   = &apiErrorCode{"BLOB_UPLOAD_INVALID", http.StatusBadRequest}
+	errBlobUploadUnknown   = &apiErrorCode{"BLOB_UPLOAD_UNKNOWN", http.StatusNotFound}
+	errDenied              = &apiErrorCode{"DENIED", http.StatusForbidden}
+	errDigestInvalid       = &apiErrorCode{"DIGEST_INVALID", http.StatusBadRequest}
+	errManifestBlobUnknown = &apiErrorCode{"MANIFEST_BLOB_UNKNOWN", http.StatusNotFound}
+	errManifestInvalid     = &apiErrorCode{"MANIFEST_INVALID", http.StatusBadRequest}
+	errManifestUnknown     = &apiErrorCode{"MANIFEST_UNKNOWN", http.StatusNotFound}
+	errNameInvalid         = &apiErrorCode{"NAME_INVALID", http.StatusBadRequest}
+	errNameUnknown         = &apiErrorCode{"NAME_UNKNOWN", http.StatusNotFound}
+	errSizeInvalid         = &apiErrorCode{"SIZE_INVALID", http.StatusBadRequest}
+	errUnauthorized        = &apiErrorCode{"UNAUTHORIZED", http.StatusUnauthorized}
+	errUnsupported         = &apiErrorCode{"UNSUPPORTED", http.StatusNotImplemented}
+)
+
+type apiErrorResponse struct {
+	Errors []apiErrorItem `json:"errors"`
+}
+
+type apiErrorItem struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func apiErrorResponseWithMessage(ctx *context.Context, code *apiErrorCode, status int, message string) {
+	ctx.JSON(status, apiErrorResponse{
+		Errors: []apiErrorItem{
+			{Code: code.Code, Message: message},
+		},
+	})
+}
+
+func apiErrorDefined(ctx *context.Context, code *apiErrorCode) {
+	apiErrorResponseWithMessage(ctx, code, code.StatusCode, strings.ToLower(strings.Replace(code.Code, "_", " ", -1)))
+}
+
+func apiError(ctx *context.Context, status int, obj interface{}) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		apiErrorResponseWithMessage(ctx, &apiErrorCode{"UNKNOWN", status}, status, message)
+	})
+}
+
+// RegisterRoutes registers the routes of the registry which must be mounted at /v2
+func RegisterRoutes(m *macaron.Macaron) {
+	m.Get("", ReqContainerAccess, DetermineSupport)
+	m.Get("/token", Authenticate)
+	m.Any("/*", dispatch)
+}
+
+var (
+	routeBlobUploads = regexp.MustCompile(`\A(.+)/blobs/uploads/?\z`)
+	routeBlobUpload  = regexp.MustCompile(`\A(.+)/blobs/uploads/([a-zA-Z0-9-]+)\z`)
+	routeBlob        = regexp.MustCompile(`\A(.+)/blobs/([^/]+)\z`)
+	routeManifest    = regexp.MustCompile(`\A(.+)/manifests/([^/]+)\z`)
+	routeTagsList    = regexp.MustCompile(`\A(.+)/tags/list\z`)
+)
+
+// dispatch routes the request manually because image names can contain slashes
+func dispatch(ctx *context.Context) {
+	path := ctx.Params("*")
+	method := ctx.Req.Method
+
+	var handler func(ctx *context.Context, image, reference string)
+	var name, reference string
+	if m := routeBlobUploads.FindStringSubmatch(path); m != nil && method == "POST" {
+		name, handler = m[1], InitiateUploadBlob
+	} else if m := routeBlobUpload.FindStringSubmatch(path); m != nil {
+		name, reference = m[1], m[2]
+		switch method {
+		case "GET", "HEAD":
+			handler = GetUploadBlob
+		case "PATCH":
+			handler = UploadBlob
+		case "PUT":
+			handler = EndUploadBlob
+		case "DELETE":
+			handler = CancelUploadBlob
+		}
+	} else if m := routeBlob.FindStringSubmatch(path); m != nil {
+		name, reference = m[1], m[2]
+		switch method {
+		case "GET", "HEAD":
+			handler = GetBlob
+		case "DELETE":
+			handler = DeleteBlob
+		}
+	} else if m := routeManifest.FindStringSubmatch(path); m != nil {
+		name, reference = m[1], m[2]
+		switch method {
+		case "GET", "HEAD":
+			handler = GetManifest
+		case "PUT":
+			handler = UploadManifest
+		case "DELETE":
+			handler = DeleteManifest
+		}
+	} else if m := routeTagsList.FindStringSubmatch(path); m != nil && (method == "GET" || method == "HEAD") {
+		name, handler = m[1], GetTagsList
+	}
+
+	if handler == nil {
+		apiErrorDefined(ctx, errUnsupported)
+		return
+	}
+
+	imageName := assignImageOwner(ctx, name)
+	if ctx.Written() {
+		return
+	}
+
+	requiredMode := models.AccessModeRead
+	if method != "GET" && method != "HEAD" {
+		requiredMode = models.AccessModeWrite
+	}
+	if ctx.Package.AccessMode < requiredMode {
+		if ctx.IsSigned {
+			apiErrorDefined(ctx, errDenied)
+		} else {
+			apiUnauthorizedError(ctx)
+		}
+		return
+	}
+
+	ctx.Resp.Header().Set("Docker-Distribution-Api-Version", "registry/2.0")
+	handler(ctx, imageName, reference)
+}
+
+// assignImageOwner splits "owner/image" and loads the owner of the image
+func assignImageOwner(ctx *context.Context, name string) string {
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) != 2 || !container_module.IsValidImageName(parts[1]) {
+		apiErrorDefined(ctx, errNameInvalid)
+		return ""
+	}
+
+	owner, err := models.GetUserByName(parts[0])
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			apiErrorDefined(ctx, errNameUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return ""
+	}
+
+	context.AssignPackageOwner(ctx, owner)
+	return parts[1]
+}
+
+func imageURL(ctx *context.Context, image string) string {
+	return fmt.Sprintf("%s/v2/%s/%s", setting.AppSubURL, ctx.Package.Owner.LowerName, image)
+}
@@ -0,0 +1,335 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package container
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	container_module "code.gitea.io/gitea/modules/packages/container"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+// UploadManifest stores a manifest by tag or by digest
+// https://docs.docker.com/registry/spec/api/#put-manifest
+func UploadManifest(ctx *context.Context, image, reference string) {
+	isTag := container_module.IsValidTag(reference)
+	if !isTag && !container_module.IsValidDigest(reference) {
+		apiErrorDefined(ctx, errManifestInvalid)
+		return
+	}
+
+	buf, err := helper.ReadBody(ctx)
+	if err != nil {
+		if err == packages_module.ErrFileTooLarge {
+			apiErrorDefined(ctx, errSizeInvalid)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	defer buf.Close()
+
+	manifest, err := container_module.ParseManifest(buf, ctx.Req.Header.Get("Content-Type"))
+	if err != nil {
+		apiErrorDefined(ctx, errManifestInvalid)
+		return
+	}
+
+	digest := digestPrefix + buf.HashSHA256
+	if !isTag && reference != digest {
+		apiErrorDefined(ctx, errDigestInvalid)
+		return
+	}
+
+	p, err := getImagePackage(ctx, image)
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			apiErrorDefined(ctx, errManifestBlobUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	// all referenced content has to be pushed before the manifest
+	references := make([]*container_module.Descriptor, 0, len(manifest.Layers)+1)
+	if manifest.Config != nil {
+		references = append(references, manifest.Config)
+	}
+	references = append(references, manifest.Layers...)
+	for _, d := range references {
+		if _, err := models.GetPackageFileByBlobHash(p.ID, strings.TrimPrefix(d.Digest, digestPrefix), false); err != nil {
+			if models.IsErrPackageFileNotExist(err) {
+				apiErrorDefined(ctx, errManifestBlobUnknown)
+			} else {
+				apiError(ctx, http.StatusInternalServerError, err)
+			}
+			return
+		}
+	}
+	for _, d := range manifest.Manifests {
+		if _, err := models.GetPackageFileByBlobHash(p.ID, strings.TrimPrefix(d.Digest, digestPrefix), true); err != nil {
+			if models.IsErrPackageFileNotExist(err) {
+				apiErrorDefined(ctx, errManifestBlobUnknown)
+			} else {
+				apiError(ctx, http.StatusInternalServerError, err)
+			}
+			return
+		}
+	}
+
+	fi := &packages_service.FileInfo{
+		Filename: digest,
+		Data:     buf,
+		IsLead:   true,
+	}
+	if isTag {
+		// pushing an existing tag replaces the image
+		pv, err := models.GetPackageVersionByName(p.ID, reference)
+		if err == nil {
+			err = packages_service.DeletePackageVersion(pv)
+		} else if models.IsErrPackageVersionNotExist(err) {
+			err = nil
+		}
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+
+		metadataJSON, err := packages_service.MarshalMetadata(manifest.Metadata())
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		_, _, err = packages_service.CreatePackageAndAddFile(&models.PackageCreationOptions{
+			Owner:        ctx.Package.Owner,
+			Creator:      ctx.User,
+			Type:         models.PackageTypeContainer,
+			Name:         image,
+			Version:      reference,
+			MetadataJSON: metadataJSON,
+		}, fi)
+	} else {
+		fi.OverwriteExisting = true
+		_, _, err = packages_service.CreatePackageOrAddFileToExisting(internalVersionOptions(ctx, image), fi)
+	}
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Resp.Header().Set("Location", fmt.Sprintf("%s/manifests/%s", imageURL(ctx, image), digest))
+	ctx.Resp.Header().Set("Docker-Content-Digest", digest)
+	ctx.Status(http.StatusCreated)
+}
+
+// getManifestFile returns the lead file of the tag or the manifest with the digest
+func getManifestFile(ctx *context.Context, image, reference string) (*models.PackageVersion, *models.PackageFile, error) {
+	if container_module.IsValidDigest(reference) {
+		pf, err := getBlob(ctx, image, reference, true)
+		return nil, pf, err
+	}
+	if !container_module.IsValidTag(reference) {
+		return nil, nil, models.ErrPackageVersionNotExist{Version: reference}
+	}
+
+	p, err := getImagePackage(ctx, image)
+	if err != nil {
+		return nil, nil, err
+	}
+	pv, err := models.GetPackageVersionByName(p.ID, reference)
+	if err != nil {
+		return nil, nil, err
+	}
+	pfs, err := models.GetPackageFilesByVersionID(pv.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, pf := range pfs {
+		if pf.IsLead {
+			return pv, pf, pf.LoadBlob()
+		}
+	}
+	return nil, nil, models.ErrPackageFileNotExist{VersionID: pv.ID}
+}
+
+// manifestMediaType returns the media type of a stored manifest
+func manifestMediaType(data []byte) string {
+	var m struct {
+		MediaType string          `json:"mediaType"`
+		Manifests json.RawMessage `json:"manifests"`
+	}
+	if err := json.Unmarshal(data, &m); err == nil && m.MediaType != "" {
+		return m.MediaType
+	}
+	if len(m.Manifests) > 0 {
+		return container_module.ContentTypeOCIIndex
+	}
+	return container_module.ContentTypeOCIManifest
+}
+
+// GetManifest serves the manifest of a tag or digest
+// https://docs.docker.com/registry/spec/api/#get-manifest
+func GetManifest(ctx *context.Context, image, reference string) {
+	pv, pf, err := getManifestFile(ctx, image, reference)
+	if err != nil {
+		if models.IsErrPackageNotExist(err) || models.IsErrPackageVersionNotExist(err) || models.IsErrPackageFileNotExist(err) {
+			apiErrorDefined(ctx, errManifestUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	s, err := packages_service.OpenPackageFile(pf)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	defer s.Close()
+
+	data, err := ioutil.ReadAll(s)
+	if err == nil {
+		_, err = s.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if pv != nil && ctx.Req.Method == "GET" {
+		if err := models.IncreasePackageVersionDownloadCount(pv.ID); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	ctx.Resp.Header().Set("Content-Type", manifestMediaType(data))
+	ctx.Resp.Header().Set("Docker-Content-Digest", digestPrefix+pf.Blob.HashSHA256)
+	http.ServeContent(ctx.Resp, ctx.Req.Request, "", pf.CreatedUnix.AsTime(), s)
+}
+
+// DeleteManifest removes a tag or all tags which reference the manifest digest
+// https://docs.docker.com/registry/spec/api/#delete-manifest
+func DeleteManifest(ctx *context.Context, image, reference string) {
+	pv, pf, err := getManifestFile(ctx, image, reference)
+	if err != nil {
+		if models.IsErrPackageNotExist(err) || models.IsErrPackageVersionNotExist(err) || models.IsErrPackageFileNotExist(err) {
+			apiErrorDefined(ctx, errManifestUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if pv != nil {
+		err = packages_service.DeletePackageVersion(pv)
+	} else {
+		err = deleteManifestByDigest(ctx, image, pf)
+	}
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+func deleteManifestByDigest(ctx *context.Context, image string, manifest *models.PackageFile) error {
+	p, err := getImagePackage(ctx, image)
+	if err != nil {
+		return err
+	}
+
+	pvs, err := models.GetPackageVersions(p.ID)
+	if err != nil {
+		return err
+	}
+	for _, pv := range pvs {
+		pfs, err := models.GetPackageFilesByVersionID(pv.ID)
+		if err != nil {
+			return err
+		}
+		for _, pf := range pfs {
+			if pf.IsLead && pf.BlobID == manifest.BlobID {
+				if err := models.DeletePackageVersion(pv); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+
+	if pv, err := models.GetPackageVersionByName(p.ID, internalVersion); err == nil {
+		if pf, err := models.GetPackageFileByName(pv.ID, digestPrefix+manifest.Blob.HashSHA256); err == nil {
+			if err := models.DeletePackageFile(pf); err != nil {
+				return err
+			}
+		} else if !models.IsErrPackageFileNotExist(err) {
+			return err
+		}
+	} else if !models.IsErrPackageVersionNotExist(err) {
+		return err
+	}
+
+	return packages_service.DeleteUnreferencedBlobs()
+}
+
+// GetTagsList returns the tags of the image
+// https://docs.docker.com/registry/spec/api/#listing-image-tags
+func GetTagsList(ctx *context.Context, image, _ string) {
+	p, err := getImagePackage(ctx, image)
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			apiErrorDefined(ctx, errNameUnknown)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	pvs, err := models.GetPackageVersions(p.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	tags := make([]string, 0, len(pvs))
+	for _, pv := range pvs {
+		tags = append(tags, pv.Version)
+	}
+	sort.Strings(tags)
+
+	if last := ctx.Query("last"); last != "" {
+		idx := sort.SearchStrings(tags, last)
+		if idx < len(tags) && tags[idx] == last {
+			idx++
+		}
+		tags = tags[idx:]
+	}
+	if n, err := strconv.Atoi(ctx.Query("n")); err == nil && n >= 0 && n < len(tags) {
+		tags = tags[:n]
+		if n > 0 {
+			ctx.Resp.Header().Set("Link", fmt.Sprintf(`<%s/tags/list?n=%d&last=%s>; rel="next"`, imageURL(ctx, image), n, url.QueryEscape(tags[n-1])))
+		}
+	}
+
+	ctx.JSON(http.StatusOK, map[string]interface{}{
+		"name": strings.ToLower(ctx.Package.Owner.Name + "/" + image),
+		"tags": tags,
+	})
+}
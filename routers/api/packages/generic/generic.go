@@ -0,0 +1,171 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package generic
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+var (
+	packageNameRegex = regexp.MustCompile(`\A[A-Za-z0-9\.\_\-\+]+\z`)
+	filenameRegex    = packageNameRegex
+)
+
+func apiError(ctx *context.Context, status int, obj interface{}) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.PlainText(status, []byte(message))
+	})
+}
+
+func getPackageVersion(ctx *context.Context) *models.PackageVersion {
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageTypeGeneric, ctx.Params(":packagename"))
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil
+	}
+	pv, err := models.GetPackageVersionByName(p.ID, ctx.Params(":packageversion"))
+	if err != nil {
+		if models.IsErrPackageVersionNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil
+	}
+	pv.Package = p
+	return pv
+}
+
+// DownloadPackageFile serves the specific generic package file.
+func DownloadPackageFile(ctx *context.Context) {
+	pv := getPackageVersion(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	s, pf, err := packages_service.GetFileStreamByPackageVersion(pv, ctx.Params(":filename"))
+	if err != nil {
+		if models.IsErrPackageFileNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	helper.ServePackageFile(ctx, s, pf)
+}
+
+// UploadPackage uploads the specific generic package file.
+// Multiple files can be uploaded to the same version, but existing files are never replaced.
+func UploadPackage(ctx *context.Context) {
+	packageName := ctx.Params(":packagename")
+	packageVersion := strings.TrimSpace(ctx.Params(":packageversion"))
+	filename := ctx.Params(":filename")
+
+	if !packageNameRegex.MatchString(packageName) || !filenameRegex.MatchString(filename) || packageVersion == "" || packageVersion != ctx.Params(":packageversion") {
+		apiError(ctx, http.StatusBadRequest, "Invalid package name, version or filename")
+		return
+	}
+
+	buf, err := helper.ReadBody(ctx)
+	if err != nil {
+		if err == packages_module.ErrFileTooLarge {
+			apiError(ctx, http.StatusRequestEntityTooLarge, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	defer buf.Close()
+
+	_, _, err = packages_service.CreatePackageOrAddFileToExisting(
+		&models.PackageCreationOptions{
+			Owner:   ctx.Package.Owner,
+			Creator: ctx.User,
+			Type:    models.PackageTypeGeneric,
+			Name:    packageName,
+			Version: packageVersion,
+		},
+		&packages_service.FileInfo{
+			Filename: filename,
+			Data:     buf,
+			IsLead:   true,
+		},
+	)
+	if err != nil {
+		if models.IsErrPackageFileAlreadyExist(err) {
+			apiError(ctx, http.StatusConflict, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// DeletePackage deletes the specific generic package version with all of its files.
+func DeletePackage(ctx *context.Context) {
+	pv := getPackageVersion(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := packages_service.DeletePackageVersion(pv); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// DeletePackageFile deletes the specific file of a generic package version.
+// The version is removed as well if it was its last file.
+func DeletePackageFile(ctx *context.Context) {
+	pv := getPackageVersion(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	pf, err := models.GetPackageFileByName(pv.ID, ctx.Params(":filename"))
+	if err != nil {
+		if models.IsErrPackageFileNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	pfs, err := models.GetPackageFilesByVersionID(pv.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	if len(pfs) == 1 {
+		err = packages_service.DeletePackageVersion(pv)
+	} else {
+		err = packages_service.DeletePackageFile(pf)
+	}
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package helper
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+)
+
+// LogAndProcessError logs an error and calls the callback with the message to send to the client.
+// The details of server errors are only logged.
+func LogAndProcessError(ctx *context.Context, status int, obj interface{}, cb func(string)) {
+	message := ""
+	if err, ok := obj.(error); ok {
+		message = err.Error()
+	} else if obj != nil {
+		message = fmt.Sprintf("%s", obj)
+	}
+	if status == http.StatusInternalServerError {
+		log.Error("Package registry error [%s]: %s", ctx.Req.URL.Path, message)
+		message = http.StatusText(status)
+	}
+	if cb != nil {
+		cb(message)
+	}
+}
+
+// ReadBody spools the request body into a hashed buffer, respecting the configured size limit
+func ReadBody(ctx *context.Context) (*packages_module.HashedBuffer, error) {
+	return packages_module.NewHashedBuffer(ctx.Req.Request.Body, setting.Packages.MaxFileSize<<20)
+}
+
+// ServePackageFile serves the content of a package file and closes the stream
+func ServePackageFile(ctx *context.Context, s storage.Object, pf *models.PackageFile) {
+	defer s.Close()
+	ctx.ServeContent(pf.Name, s, pf.CreatedUnix.AsTime())
+}
@@ -0,0 +1,380 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package maven
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	maven_module "code.gitea.io/gitea/modules/packages/maven"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+const (
+	mavenMetadataFile = "maven-metadata.xml"
+	extensionMD5      = ".md5"
+	extensionSHA1     = ".sha1"
+	extensionSHA256   = ".sha256"
+	extensionSHA512   = ".sha512"
+)
+
+var (
+	errInvalidParameters = errors.New("request parameters are invalid")
+	errChecksumMismatch  = errors.New("checksum mismatch")
+
+	illegalCharacters = regexp.MustCompile(`[\\/:"<>|?\*]`)
+)
+
+func apiError(ctx *context.Context, status int, obj interface{}) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.PlainText(status, []byte(message))
+	})
+}
+
+// pathParameters are the parts of a Maven repository path:
+// /{groupId as path}/{artifactId}/{version}/{filename} or
+// /{groupId as path}/{artifactId}/maven-metadata.xml
+type pathParameters struct {
+	GroupID    string
+	ArtifactID string
+	Version    string
+	Filename   string
+}
+
+func (p *pathParameters) packageName() string {
+	return p.GroupID + ":" + p.ArtifactID
+}
+
+func isChecksumExtension(ext string) bool {
+	return ext == extensionMD5 || ext == extensionSHA1 || ext == extensionSHA256 || ext == extensionSHA512
+}
+
+// splitChecksum splits a checksum file name into the name of the checksummed file and the checksum extension
+func splitChecksum(filename string) (string, string) {
+	ext := filepath.Ext(filename)
+	if isChecksumExtension(ext) {
+		return strings.TrimSuffix(filename, ext), ext
+	}
+	return filename, ""
+}
+
+func extractPathParameters(ctx *context.Context) (*pathParameters, error) {
+	parts := strings.Split(ctx.Params("*"), "/")
+	if len(parts) < 3 {
+		return nil, errInvalidParameters
+	}
+
+	p := &pathParameters{
+		Filename: parts[len(parts)-1],
+	}
+
+	base, _ := splitChecksum(p.Filename)
+	if base == mavenMetadataFile && !strings.HasSuffix(parts[len(parts)-2], "-SNAPSHOT") {
+		p.ArtifactID = parts[len(parts)-2]
+		p.GroupID = strings.Join(parts[:len(parts)-2], ".")
+	} else {
+		if len(parts) < 4 {
+			return nil, errInvalidParameters
+		}
+		p.Version = parts[len(parts)-2]
+		p.ArtifactID = parts[len(parts)-3]
+		p.GroupID = strings.Join(parts[:len(parts)-3], ".")
+	}
+
+	if p.GroupID == "" || p.ArtifactID == "" || illegalCharacters.MatchString(p.GroupID) ||
+		illegalCharacters.MatchString(p.ArtifactID) || illegalCharacters.MatchString(p.Version) {
+		return nil, errInvalidParameters
+	}
+	return p, nil
+}
+
+func checksum(ext string, data []byte) string {
+	switch ext {
+	case extensionMD5:
+		sum := md5.Sum(data)
+		return hex.EncodeToString(sum[:])
+	case extensionSHA1:
+		sum := sha1.Sum(data)
+		return hex.EncodeToString(sum[:])
+	case extensionSHA256:
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	case extensionSHA512:
+		sum := sha512.Sum512(data)
+		return hex.EncodeToString(sum[:])
+	}
+	return ""
+}
+
+func blobChecksum(ext string, pb *models.PackageBlob) string {
+	switch ext {
+	case extensionMD5:
+		return pb.HashMD5
+	case extensionSHA1:
+		return pb.HashSHA1
+	case extensionSHA256:
+		return pb.HashSHA256
+	case extensionSHA512:
+		return pb.HashSHA512
+	}
+	return ""
+}
+
+// mavenMetadata is the content of the generated maven-metadata.xml of an artifact
+type mavenMetadata struct {
+	XMLName     xml.Name `xml:"metadata"`
+	GroupID     string   `xml:"groupId"`
+	ArtifactID  string   `xml:"artifactId"`
+	Latest      string   `xml:"versioning>latest"`
+	Release     string   `xml:"versioning>release,omitempty"`
+	Versions    []string `xml:"versioning>versions>version"`
+	LastUpdated string   `xml:"versioning>lastUpdated"`
+}
+
+// DownloadPackageFile serves the content of a package file or the generated artifact metadata
+func DownloadPackageFile(ctx *context.Context) {
+	params, err := extractPathParameters(ctx)
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageTypeMaven, params.packageName())
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	if params.Version == "" {
+		serveMavenMetadata(ctx, p, params)
+		return
+	}
+
+	pv, err := models.GetPackageVersionByName(p.ID, params.Version)
+	if err != nil {
+		if models.IsErrPackageVersionNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	filename, ext := splitChecksum(params.Filename)
+	if ext != "" {
+		pf, err := models.GetPackageFileByName(pv.ID, filename)
+		if err == nil {
+			err = pf.LoadBlob()
+		}
+		if err != nil {
+			if models.IsErrPackageFileNotExist(err) {
+				apiError(ctx, http.StatusNotFound, err)
+			} else {
+				apiError(ctx, http.StatusInternalServerError, err)
+			}
+			return
+		}
+		ctx.PlainText(http.StatusOK, []byte(blobChecksum(ext, pf.Blob)))
+		return
+	}
+
+	s, pf, err := packages_service.GetFileStreamByPackageVersion(pv, params.Filename)
+	if err != nil {
+		if models.IsErrPackageFileNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	helper.ServePackageFile(ctx, s, pf)
+}
+
+func serveMavenMetadata(ctx *context.Context, p *models.Package, params *pathParameters) {
+	pvs, err := models.GetPackageVersions(p.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pvs) == 0 {
+		apiError(ctx, http.StatusNotFound, models.ErrPackageNotExist{Name: p.Name})
+		return
+	}
+
+	metadata := &mavenMetadata{
+		GroupID:     params.GroupID,
+		ArtifactID:  params.ArtifactID,
+		Versions:    make([]string, 0, len(pvs)),
+		LastUpdated: p.UpdatedUnix.AsTime().UTC().Format("20060102150405"),
+	}
+	for _, pv := range pvs {
+		metadata.Versions = append(metadata.Versions, pv.Version)
+		metadata.Latest = pv.Version
+		if !strings.HasSuffix(pv.Version, "-SNAPSHOT") {
+			metadata.Release = pv.Version
+		}
+	}
+
+	data, err := xml.Marshal(metadata)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	data = append([]byte(xml.Header), data...)
+
+	if _, ext := splitChecksum(params.Filename); ext != "" {
+		ctx.PlainText(http.StatusOK, []byte(checksum(ext, data)))
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "text/xml")
+	ctx.Resp.WriteHeader(http.StatusOK)
+	_, _ = ctx.Resp.Write(data)
+}
+
+// UploadPackageFile adds a file to the package version. Checksum files are only
+// validated against the stored file and the artifact metadata is always generated.
+func UploadPackageFile(ctx *context.Context) {
+	params, err := extractPathParameters(ctx)
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if params.Version == "" {
+		// maven-metadata.xml of the artifact is generated on demand
+		ctx.Status(http.StatusOK)
+		return
+	}
+
+	buf, err := helper.ReadBody(ctx)
+	if err != nil {
+		if err == packages_module.ErrFileTooLarge {
+			apiError(ctx, http.StatusRequestEntityTooLarge, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	defer buf.Close()
+
+	filename, ext := splitChecksum(params.Filename)
+	if ext != "" {
+		validateChecksum(ctx, params, filename, ext, buf)
+		return
+	}
+
+	opts := &models.PackageCreationOptions{
+		Owner:   ctx.Package.Owner,
+		Creator: ctx.User,
+		Type:    models.PackageTypeMaven,
+		Name:    params.packageName(),
+		Version: params.Version,
+	}
+
+	var metadata *maven_module.Metadata
+	if filepath.Ext(params.Filename) == ".pom" {
+		metadata, err = maven_module.ParsePackageMetaData(buf)
+		if err != nil {
+			apiError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		if opts.MetadataJSON, err = packages_service.MarshalMetadata(metadata); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	pv, _, err := packages_service.CreatePackageOrAddFileToExisting(opts, &packages_service.FileInfo{
+		Filename:          params.Filename,
+		Data:              buf,
+		IsLead:            metadata != nil,
+		OverwriteExisting: params.Filename == mavenMetadataFile,
+	})
+	if err != nil {
+		if models.IsErrPackageFileAlreadyExist(err) {
+			apiError(ctx, http.StatusConflict, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	// The version may have been created by an earlier file without metadata.
+	if metadata != nil && pv.MetadataJSON != opts.MetadataJSON {
+		pv.MetadataJSON = opts.MetadataJSON
+		if err := models.UpdatePackageVersionMetadata(pv); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+func validateChecksum(ctx *context.Context, params *pathParameters, filename, ext string, buf *packages_module.HashedBuffer) {
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageTypeMaven, params.packageName())
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	pv, err := models.GetPackageVersionByName(p.ID, params.Version)
+	if err != nil {
+		if models.IsErrPackageVersionNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	pf, err := models.GetPackageFileByName(pv.ID, filename)
+	if err == nil {
+		err = pf.LoadBlob()
+	}
+	if err != nil {
+		if models.IsErrPackageFileNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	data, err := ioutil.ReadAll(buf)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	// some clients append the file name to the checksum
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 || !strings.EqualFold(fields[0], blobChecksum(ext, pf.Blob)) {
+		apiError(ctx, http.StatusBadRequest, errChecksumMismatch)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
@@ -0,0 +1,402 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package npm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	npm_module "code.gitea.io/gitea/modules/packages/npm"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/routers/api/packages/helper"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+var tagVersionRegex = regexp.MustCompile(`\A[vV]?\d`)
+
+func apiError(ctx *context.Context, status int, obj interface{}) {
+	helper.LogAndProcessError(ctx, status, obj, func(message string) {
+		ctx.JSON(status, map[string]string{
+			"error": message,
+		})
+	})
+}
+
+// packageNameFromParams gets the package name from the url parameters
+// Variations: /name/, /@scope/name/, /@scope%2Fname/
+func packageNameFromParams(ctx *context.Context) string {
+	id := ctx.Params(":id")
+	// an escaped scoped name is matched as a whole by ":id", a failed match of the
+	// "@:scope" route may still have left a value in ":scope"
+	if strings.HasPrefix(id, "@") {
+		return id
+	}
+	if scope := ctx.Params(":scope"); scope != "" {
+		return "@" + scope + "/" + id
+	}
+	return id
+}
+
+func registryURL(ctx *context.Context) string {
+	return setting.AppURL + "api/packages/" + ctx.Package.Owner.Name + "/npm"
+}
+
+func getPackage(ctx *context.Context, name string) *models.Package {
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, models.PackageTypeNpm, name)
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil
+	}
+	return p
+}
+
+func getPackageVersion(ctx *context.Context, name, version string) *models.PackageVersion {
+	p := getPackage(ctx, name)
+	if ctx.Written() {
+		return nil
+	}
+	pv, err := models.GetPackageVersionByName(p.ID, version)
+	if err != nil {
+		if models.IsErrPackageVersionNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return nil
+	}
+	pv.Package = p
+	return pv
+}
+
+func parseMetadata(pv *models.PackageVersion) (*npm_module.Metadata, error) {
+	var metadata npm_module.Metadata
+	if err := json.Unmarshal([]byte(pv.MetadataJSON), &metadata); err != nil {
+		return nil, fmt.Errorf("unable to parse metadata of version %d: %v", pv.ID, err)
+	}
+	return &metadata, nil
+}
+
+// PackageMetadata returns the metadata of all versions of a package
+func PackageMetadata(ctx *context.Context) {
+	packageName := packageNameFromParams(ctx)
+
+	p := getPackage(ctx, packageName)
+	if ctx.Written() {
+		return
+	}
+
+	pvs, err := models.GetPackageVersions(p.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if len(pvs) == 0 {
+		apiError(ctx, http.StatusNotFound, models.ErrPackageNotExist{Name: packageName})
+		return
+	}
+
+	resp := &npm_module.PackageMetadata{
+		ID:       p.Name,
+		Name:     p.Name,
+		DistTags: make(map[string]string),
+		Versions: make(map[string]*npm_module.PackageMetadataVersion, len(pvs)),
+		Time:     make(map[string]time.Time, len(pvs)),
+	}
+	baseURL := registryURL(ctx)
+	for _, pv := range pvs {
+		metadata, err := parseMetadata(pv)
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+
+		pfs, err := models.GetPackageFilesByVersionID(pv.ID)
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		if len(pfs) == 0 {
+			continue
+		}
+		pf := pfs[0]
+		if err := pf.LoadBlob(); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+
+		v := metadata.Version
+		v.ID = p.Name + "@" + pv.Version
+		v.Dist = npm_module.PackageDistribution{
+			Integrity: npm_module.IntegrityFromSHA512(pf.Blob.HashSHA512),
+			Shasum:    pf.Blob.HashSHA1,
+			Tarball:   fmt.Sprintf("%s/%s/-/%s/%s", baseURL, p.Name, pv.Version, pf.Name),
+		}
+		resp.Versions[pv.Version] = v
+		resp.Time[pv.Version] = pv.CreatedUnix.AsTime()
+		for _, tag := range metadata.DistTags {
+			resp.DistTags[tag] = pv.Version
+		}
+
+		// versions are sorted oldest first, so the newest description wins
+		resp.Description = v.Description
+		resp.Readme = metadata.Readme
+	}
+	resp.Time["created"] = p.CreatedUnix.AsTime()
+	resp.Time["modified"] = p.UpdatedUnix.AsTime()
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// DownloadPackageFile serves the content of a package
+func DownloadPackageFile(ctx *context.Context) {
+	pv := getPackageVersion(ctx, packageNameFromParams(ctx), ctx.Params(":version"))
+	if ctx.Written() {
+		return
+	}
+
+	s, pf, err := packages_service.GetFileStreamByPackageVersion(pv, ctx.Params(":filename"))
+	if err != nil {
+		if models.IsErrPackageFileNotExist(err) {
+			apiError(ctx, http.StatusNotFound, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	helper.ServePackageFile(ctx, s, pf)
+}
+
+// UploadPackage creates a new package version from the publish request of the npm client
+func UploadPackage(ctx *context.Context) {
+	npmPackage, err := npm_module.ParsePackage(ctx.Req.Request.Body)
+	if err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if npmPackage.Name != packageNameFromParams(ctx) {
+		apiError(ctx, http.StatusBadRequest, npm_module.ErrInvalidPackageName)
+		return
+	}
+
+	metadataJSON, err := packages_service.MarshalMetadata(&npm_module.Metadata{
+		Version: npmPackage.Metadata,
+		Readme:  npmPackage.Readme,
+	})
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	buf, err := packages_module.NewHashedBuffer(bytes.NewReader(npmPackage.Data), setting.Packages.MaxFileSize<<20)
+	if err != nil {
+		if err == packages_module.ErrFileTooLarge {
+			apiError(ctx, http.StatusRequestEntityTooLarge, err)
+		} else {
+			apiError(ctx, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	defer buf.Close()
+
+	pv, _, err := packages_service.CreatePackageAndAddFile(
+		&models.PackageCreationOptions{
+			Owner:        ctx.Package.Owner,
+			Creator:      ctx.User,
+			Type:         models.PackageTypeNpm,
+			Name:         npmPackage.Name,
+			Version:      npmPackage.Version,
+			MetadataJSON: metadataJSON,
+		},
+		&packages_service.FileInfo{
+			Filename: npmPackage.Filename,
+			Data:     buf,
+			IsLead:   true,
+		},
+	)
+	if err != nil {
+		if models.IsErrPackageVersionAlreadyExist(err) {
+			apiError(ctx, http.StatusBadRequest, err)
+			return
+		}
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	for _, tag := range npmPackage.DistTags {
+		if err := setPackageTag(pv.PackageID, tag, pv.ID); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// DeletePreview is the first step of "npm unpublish" which sends the modified package
+// metadata. Nothing is changed until the client deletes the tarball or the package.
+func DeletePreview(ctx *context.Context) {
+	ctx.Status(http.StatusOK)
+}
+
+// DeletePackageVersion deletes the package version of the tarball
+func DeletePackageVersion(ctx *context.Context) {
+	pv := getPackageVersion(ctx, packageNameFromParams(ctx), ctx.Params(":version"))
+	if ctx.Written() {
+		return
+	}
+
+	if err := packages_service.DeletePackageVersion(pv); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// DeletePackage deletes all versions of the package
+func DeletePackage(ctx *context.Context) {
+	p := getPackage(ctx, packageNameFromParams(ctx))
+	if ctx.Written() {
+		return
+	}
+
+	pvs, err := models.GetPackageVersions(p.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	for _, pv := range pvs {
+		if err := packages_service.DeletePackageVersion(pv); err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// ListPackageTags returns all dist-tags of the package
+func ListPackageTags(ctx *context.Context) {
+	p := getPackage(ctx, packageNameFromParams(ctx))
+	if ctx.Written() {
+		return
+	}
+
+	pvs, err := models.GetPackageVersions(p.ID)
+	if err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	tags := make(map[string]string)
+	for _, pv := range pvs {
+		metadata, err := parseMetadata(pv)
+		if err != nil {
+			apiError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+		for _, tag := range metadata.DistTags {
+			tags[tag] = pv.Version
+		}
+	}
+	ctx.JSON(http.StatusOK, tags)
+}
+
+// AddPackageTag adds or moves a dist-tag to the version of the request body
+func AddPackageTag(ctx *context.Context) {
+	// tags which look like a version would be ambiguous for the client
+	if tagVersionRegex.MatchString(ctx.Params(":tag")) {
+		apiError(ctx, http.StatusBadRequest, "Tags can't look like a version")
+		return
+	}
+
+	var version string
+	if err := json.NewDecoder(ctx.Req.Request.Body).Decode(&version); err != nil {
+		apiError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	pv := getPackageVersion(ctx, packageNameFromParams(ctx), version)
+	if ctx.Written() {
+		return
+	}
+
+	if err := setPackageTag(pv.PackageID, ctx.Params(":tag"), pv.ID); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// DeletePackageTag removes a dist-tag from the package
+func DeletePackageTag(ctx *context.Context) {
+	p := getPackage(ctx, packageNameFromParams(ctx))
+	if ctx.Written() {
+		return
+	}
+
+	if err := setPackageTag(p.ID, ctx.Params(":tag"), 0); err != nil {
+		apiError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// setPackageTag assigns the tag to the version with the given id and removes it from all
+// other versions of the package. A version id of 0 removes the tag from the package.
+func setPackageTag(packageID int64, tag string, versionID int64) error {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return nil
+	}
+
+	pvs, err := models.GetPackageVersions(packageID)
+	if err != nil {
+		return err
+	}
+
+	for _, pv := range pvs {
+		metadata, err := parseMetadata(pv)
+		if err != nil {
+			return err
+		}
+
+		tags := make([]string, 0, len(metadata.DistTags)+1)
+		hasTag := false
+		for _, t := range metadata.DistTags {
+			if t == tag {
+				hasTag = true
+				continue
+			}
+			tags = append(tags, t)
+		}
+		isKept := pv.ID == versionID
+		if isKept {
+			tags = append(tags, tag)
+		}
+		if hasTag == isKept {
+			continue
+		}
+
+		metadata.DistTags = tags
+		if pv.MetadataJSON, err = packages_service.MarshalMetadata(metadata); err != nil {
+			return err
+		}
+		if err := models.UpdatePackageVersionMetadata(pv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/services/audit"
+)
+
+// ListAuditEvents API for searching the audit log
+func ListAuditEvents(ctx *context.APIContext) {
+	// swagger:operation GET /admin/audit admin adminListAuditEvents
+	// ---
+	// summary: Search the audit log, the newest events first
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: action
+	//   in: query
+	//   description: kind of the audited action, e.g. `user_login`
+	//   type: string
+	// - name: actor
+	//   in: query
+	//   description: name of the user who performed the action
+	//   type: string
+	// - name: target_type
+	//   in: query
+	//   description: kind of the target
+	//   type: string
+	//   enum: [user, repository, team]
+	// - name: target
+	//   in: query
+	//   description: name of the target, repositories and teams are named `owner/name`
+	//   type: string
+	// - name: since
+	//   in: query
+	//   description: only events on or after this date (YYYY-MM-DD)
+	//   type: string
+	//   format: date
+	// - name: until
+	//   in: query
+	//   description: only events on or before this date (YYYY-MM-DD)
+	//   type: string
+	//   format: date
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/AuditEventList"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	opts, err := audit.SearchOptionsFromQuery(ctx.Req.URL.Query())
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "", err)
+		return
+	}
+	opts.Page = ctx.QueryInt("page")
+	opts.PageSize = convert.ToCorrectPageSize(ctx.QueryInt("limit"))
+
+	events, _, err := models.SearchAuditEvents(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "SearchAuditEvents", err)
+		return
+	}
+
+	apiEvents := make([]*api.AuditEvent, len(events))
+	for i := range events {
+		apiEvents[i] = convert.ToAuditEvent(events[i])
+	}
+	ctx.JSON(http.StatusOK, &apiEvents)
+}
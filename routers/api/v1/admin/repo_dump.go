@@ -0,0 +1,172 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations"
+	"code.gitea.io/gitea/routers/api/v1/user"
+)
+
+// ExportRepo api for dumping a repository with its issues, pull requests, releases and wiki
+func ExportRepo(ctx *context.APIContext) {
+	// swagger:operation GET /admin/repos/{owner}/{repo}/export admin adminExportRepo
+	// ---
+	// summary: Export a repository with its issues, pull requests, releases and wiki
+	// produces:
+	// - application/zip
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     description: success
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	repo, err := models.GetRepositoryByOwnerAndName(ctx.Params(":owner"), ctx.Params(":repo"))
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetRepositoryByOwnerAndName", err)
+		}
+		return
+	}
+
+	f, err := ioutil.TempFile(os.TempDir(), "gitea-dump-repo-*.zip")
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "TempFile", err)
+		return
+	}
+	defer func() {
+		if err := os.Remove(f.Name()); err != nil {
+			log.Error("Remove %s: %v", f.Name(), err)
+		}
+	}()
+
+	err = migrations.DumpRepositoryArchive(graceful.GetManager().HammerContext(), repo, f)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "DumpRepositoryArchive", err)
+		return
+	}
+
+	ctx.ServeFile(f.Name(), repo.OwnerName+"-"+repo.Name+".zip")
+}
+
+// ImportRepo api for restoring a repository exported by ExportRepo
+func ImportRepo(ctx *context.APIContext) {
+	// swagger:operation POST /admin/users/{username}/repos/import admin adminImportRepo
+	// ---
+	// summary: Import a repository exported by adminExportRepo on behalf a user
+	// consumes:
+	// - multipart/form-data
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: username
+	//   in: path
+	//   description: username of the user. This user will own the imported repository
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: query
+	//   description: name of the imported repository
+	//   type: string
+	//   required: true
+	// - name: archive
+	//   in: formData
+	//   description: the exported repository
+	//   type: file
+	//   required: true
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/Repository"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	owner := user.GetUserByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	repoName := ctx.Query("name")
+	if err := models.IsUsableRepoName(repoName); err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "IsUsableRepoName", err)
+		return
+	}
+	if has, err := models.IsRepositoryExist(owner, repoName); err != nil {
+		ctx.Error(http.StatusInternalServerError, "IsRepositoryExist", err)
+		return
+	} else if has {
+		ctx.Error(http.StatusConflict, "", "The repository with the same name already exists.")
+		return
+	}
+
+	file, _, err := ctx.GetFile("archive")
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "GetFile", err)
+		return
+	}
+	defer file.Close()
+
+	f, err := ioutil.TempFile(os.TempDir(), "gitea-restore-repo-*.zip")
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "TempFile", err)
+		return
+	}
+	defer func() {
+		if err := os.Remove(f.Name()); err != nil {
+			log.Error("Remove %s: %v", f.Name(), err)
+		}
+	}()
+	_, err = io.Copy(f, file)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "Copy", err)
+		return
+	}
+
+	repo, err := migrations.RestoreRepositoryArchive(graceful.GetManager().HammerContext(), ctx.User, owner.Name, repoName, f.Name())
+	if err != nil {
+		if models.IsErrRepoAlreadyExist(err) {
+			ctx.Error(http.StatusConflict, "", "The repository with the same name already exists.")
+		} else {
+			ctx.Error(http.StatusInternalServerError, "RestoreRepositoryArchive", err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, repo.APIFormat(models.AccessModeAdmin))
+}
@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/modules/password"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/api/v1/user"
+	"code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/mailer"
 )
 
@@ -168,7 +169,9 @@ func EditUser(ctx *context.APIContext, form api.EditUserOption) {
 	if form.Active != nil {
 		u.IsActive = *form.Active
 	}
+	adminChanged := false
 	if form.Admin != nil {
+		adminChanged = u.IsAdmin != *form.Admin
 		u.IsAdmin = *form.Admin
 	}
 	if form.AllowGitHook != nil {
@@ -196,6 +199,9 @@ func EditUser(ctx *context.APIContext, form api.EditUserOption) {
 		return
 	}
 	log.Trace("Account profile updated by admin (%s): %s", ctx.User.Name, u.Name)
+	if adminChanged {
+		audit.Record(models.AuditActionUserSiteAdmin, ctx.User, ctx.RemoteAddr(), u, "Changed site administrator, admin: %t", u.IsAdmin)
+	}
 
 	ctx.JSON(http.StatusOK, convert.ToUser(u, ctx.IsSigned, ctx.User.IsAdmin))
 }
@@ -228,7 +234,8 @@ func DeleteUser(ctx *context.APIContext) {
 
 	if err := models.DeleteUser(u); err != nil {
 		if models.IsErrUserOwnRepos(err) ||
-			models.IsErrUserHasOrgs(err) {
+			models.IsErrUserHasOrgs(err) ||
+			models.IsErrUserOwnPackages(err) {
 			ctx.Error(http.StatusUnprocessableEntity, "", err)
 		} else {
 			ctx.Error(http.StatusInternalServerError, "DeleteUser", err)
@@ -317,6 +324,7 @@ func DeleteUserPublicKey(ctx *context.APIContext) {
 		return
 	}
 	log.Trace("Key deleted by admin(%s): %s", ctx.User.Name, u.Name)
+	audit.Record(models.AuditActionPublicKeyDelete, ctx.User, ctx.RemoteAddr(), u, "Deleted SSH key %d", ctx.ParamsInt64(":id"))
 
 	ctx.Status(http.StatusNoContent)
 }
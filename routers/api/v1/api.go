@@ -72,6 +72,7 @@ import (
 	"code.gitea.io/gitea/routers/api/v1/misc"
 	"code.gitea.io/gitea/routers/api/v1/notify"
 	"code.gitea.io/gitea/routers/api/v1/org"
+	"code.gitea.io/gitea/routers/api/v1/packages"
 	"code.gitea.io/gitea/routers/api/v1/repo"
 	_ "code.gitea.io/gitea/routers/api/v1/swagger" // for swagger generation
 	"code.gitea.io/gitea/routers/api/v1/user"
@@ -88,7 +89,7 @@ func sudo() macaron.Handler {
 		}
 
 		if len(sudo) > 0 {
-			if ctx.IsSigned && ctx.User.IsAdmin {
+			if ctx.IsUserSiteAdmin() {
 				user, err := models.GetUserByName(sudo)
 				if err != nil {
 					if models.IsErrUserNotExist(err) {
@@ -157,7 +158,7 @@ func repoAssignment() macaron.Handler {
 		repo.Owner = owner
 		ctx.Repo.Repository = repo
 
-		ctx.Repo.Permission, err = models.GetUserRepoPermission(repo, ctx.User)
+		ctx.Repo.Permission, err = models.GetUserRepoPermission(repo, ctx.PermissionDoer())
 		if err != nil {
 			ctx.Error(http.StatusInternalServerError, "GetUserRepoPermission", err)
 			return
@@ -408,6 +409,42 @@ func orgAssignment(args ...bool) macaron.Handler {
 	}
 }
 
+func packageAssignment() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		owner, err := models.GetUserByName(ctx.Params(":username"))
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				ctx.NotFound()
+			} else {
+				ctx.Error(http.StatusInternalServerError, "GetUserByName", err)
+			}
+			return
+		}
+
+		ctx.Package = &context.Package{
+			Owner: owner,
+		}
+		ctx.Package.AccessMode, err = models.GetPackageAccessMode(owner, ctx.PermissionDoer())
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetPackageAccessMode", err)
+			return
+		}
+	}
+}
+
+func reqPackageAccess(accessMode models.AccessMode) macaron.Handler {
+	return func(ctx *context.APIContext) {
+		if ctx.Package.AccessMode < models.AccessModeRead {
+			ctx.NotFound()
+			return
+		}
+		if ctx.Package.AccessMode < accessMode {
+			ctx.Error(http.StatusForbidden, "reqPackageAccess", "user should have specific permission or be a site admin")
+			return
+		}
+	}
+}
+
 func mustEnableIssues(ctx *context.APIContext) {
 	if !ctx.Repo.CanRead(models.UnitTypeIssues) {
 		if log.IsTrace() {
@@ -487,6 +524,13 @@ func mustEnableUserHeatmap(ctx *context.APIContext) {
 	}
 }
 
+func mustEnablePackages(ctx *context.APIContext) {
+	if !setting.Packages.Enabled {
+		ctx.NotFound()
+		return
+	}
+}
+
 func mustNotBeArchived(ctx *context.APIContext) {
 	if ctx.Repo.Repository.IsArchived {
 		ctx.NotFound()
@@ -632,6 +676,11 @@ func RegisterRoutes(m *macaron.Macaron) {
 							Patch(bind(api.EditHookOption{}), repo.EditHook).
 							Delete(repo.DeleteHook)
 						m.Post("/tests", context.RepoRef(), repo.TestHook)
+						m.Group("/deliveries", func() {
+							m.Get("", repo.ListHookDeliveries)
+							m.Post("/replay", bind(api.ReplayHookDeliveriesOption{}), repo.ReplayHookDeliveries)
+							m.Post("/:delivery/redeliver", repo.RedeliverHookDelivery)
+						})
 					})
 					m.Group("/git", func() {
 						m.Combo("").Get(repo.ListGitHooks)
@@ -741,6 +790,24 @@ func RegisterRoutes(m *macaron.Macaron) {
 						Patch(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.EditMilestoneOption{}), repo.EditMilestone).
 						Delete(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), repo.DeleteMilestone)
 				})
+				m.Group("/projects", func() {
+					m.Combo("").Get(repo.ListProjects).
+						Post(reqToken(), reqRepoWriter(models.UnitTypeProjects), bind(api.CreateProjectOption{}), repo.CreateProject)
+					m.Group("/:id", func() {
+						m.Combo("").Get(repo.GetProject).
+							Patch(reqToken(), reqRepoWriter(models.UnitTypeProjects), bind(api.EditProjectOption{}), repo.EditProject).
+							Delete(reqToken(), reqRepoWriter(models.UnitTypeProjects), repo.DeleteProject)
+						m.Combo("/boards").Get(repo.ListProjectBoards).
+							Post(reqToken(), reqRepoWriter(models.UnitTypeProjects), bind(api.CreateProjectBoardOption{}), repo.CreateProjectBoard)
+						m.Group("/boards/:boardid", func() {
+							m.Combo("").Patch(reqToken(), reqRepoWriter(models.UnitTypeProjects), bind(api.EditProjectBoardOption{}), repo.EditProjectBoard).
+								Delete(reqToken(), reqRepoWriter(models.UnitTypeProjects), repo.DeleteProjectBoard)
+							m.Get("/issues", repo.ListProjectBoardIssues)
+						})
+						m.Post("/issues", reqToken(), reqRepoWriter(models.UnitTypeProjects), bind(api.MoveProjectIssueOption{}), repo.MoveProjectIssue)
+						m.Delete("/issues/:issueid", reqToken(), reqRepoWriter(models.UnitTypeProjects), repo.RemoveProjectIssue)
+					})
+				}, reqRepoReader(models.UnitTypeProjects))
 				m.Get("/stargazers", repo.ListStargazers)
 				m.Get("/subscribers", repo.ListSubscribers)
 				m.Group("/subscription", func() {
@@ -765,6 +832,14 @@ func RegisterRoutes(m *macaron.Macaron) {
 					})
 				}, reqRepoReader(models.UnitTypeReleases))
 				m.Post("/mirror-sync", reqToken(), reqRepoWriter(models.UnitTypeCode), repo.MirrorSync)
+				m.Group("/push_mirrors", func() {
+					m.Combo("").Get(repo.ListPushMirrors).
+						Post(bind(api.CreatePushMirrorOption{}), repo.CreatePushMirror)
+					m.Combo("/:id").Get(repo.GetPushMirror).
+						Delete(repo.DeletePushMirror)
+					m.Post("/:id/sync", repo.SyncPushMirror)
+				}, reqToken(), reqAdmin())
+				m.Post("/push_mirrors-sync", reqToken(), reqAdmin(), repo.SyncPushMirrors)
 				m.Get("/editorconfig/:filename", context.RepoRef(), reqRepoReader(models.UnitTypeCode), repo.GetEditorconfig)
 				m.Group("/pulls", func() {
 					m.Combo("").Get(bind(api.ListPullRequestsOptions{}), repo.ListPullRequests).
@@ -773,7 +848,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 						m.Combo("").Get(repo.GetPullRequest).
 							Patch(reqToken(), reqRepoWriter(models.UnitTypePullRequests), bind(api.EditPullRequestOption{}), repo.EditPullRequest)
 						m.Combo("/merge").Get(repo.IsPullRequestMerged).
-							Post(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypePullRequests), bind(auth.MergePullRequestForm{}), repo.MergePullRequest)
+							Post(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypePullRequests), bind(auth.MergePullRequestForm{}), repo.MergePullRequest).
+							Delete(reqToken(), mustNotBeArchived, reqRepoWriter(models.UnitTypePullRequests), repo.CancelScheduledAutoMerge)
 					})
 				}, mustAllowPulls, reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo(false))
 				m.Group("/statuses", func() {
@@ -844,12 +920,44 @@ func RegisterRoutes(m *macaron.Macaron) {
 					Post(reqOrgOwnership(), bind(api.CreateTeamOption{}), org.CreateTeam)
 				m.Get("/search", org.SearchTeam)
 			}, reqOrgMembership())
+			m.Group("/projects", func() {
+				m.Combo("").Get(org.ListProjects).
+					Post(reqToken(), reqOrgMembership(), bind(api.CreateProjectOption{}), org.CreateProject)
+				m.Group("/:id", func() {
+					m.Combo("").Get(org.GetProject).
+						Patch(reqToken(), reqOrgMembership(), bind(api.EditProjectOption{}), org.EditProject).
+						Delete(reqToken(), reqOrgMembership(), org.DeleteProject)
+					m.Combo("/boards").Get(org.ListProjectBoards).
+						Post(reqToken(), reqOrgMembership(), bind(api.CreateProjectBoardOption{}), org.CreateProjectBoard)
+					m.Group("/boards/:boardid", func() {
+						m.Combo("").Patch(reqToken(), reqOrgMembership(), bind(api.EditProjectBoardOption{}), org.EditProjectBoard).
+							Delete(reqToken(), reqOrgMembership(), org.DeleteProjectBoard)
+						m.Get("/issues", org.ListProjectBoardIssues)
+					})
+					m.Post("/issues", reqToken(), reqOrgMembership(), bind(api.MoveProjectIssueOption{}), org.MoveProjectIssue)
+					m.Delete("/issues/:issueid", reqToken(), reqOrgMembership(), org.RemoveProjectIssue)
+				})
+			})
+			m.Group("/labels", func() {
+				m.Combo("").Get(org.ListLabels).
+					Post(reqToken(), reqOrgOwnership(), bind(api.CreateLabelOption{}), org.CreateLabel)
+				m.Combo("/:id").Get(org.GetLabel).
+					Patch(reqToken(), reqOrgOwnership(), bind(api.EditLabelOption{}), org.EditLabel).
+					Delete(reqToken(), reqOrgOwnership(), org.DeleteLabel)
+			})
 			m.Group("/hooks", func() {
 				m.Combo("").Get(org.ListHooks).
 					Post(bind(api.CreateHookOption{}), org.CreateHook)
-				m.Combo("/:id").Get(org.GetHook).
-					Patch(bind(api.EditHookOption{}), org.EditHook).
-					Delete(org.DeleteHook)
+				m.Group("/:id", func() {
+					m.Combo("").Get(org.GetHook).
+						Patch(bind(api.EditHookOption{}), org.EditHook).
+						Delete(org.DeleteHook)
+					m.Group("/deliveries", func() {
+						m.Get("", org.ListHookDeliveries)
+						m.Post("/replay", bind(api.ReplayHookDeliveriesOption{}), org.ReplayHookDeliveries)
+						m.Post("/:delivery/redeliver", org.RedeliverHookDelivery)
+					})
+				})
 			}, reqToken(), reqOrgOwnership())
 		}, orgAssignment(true))
 		m.Group("/teams/:teamid", func() {
@@ -871,12 +979,30 @@ func RegisterRoutes(m *macaron.Macaron) {
 			})
 		}, orgAssignment(false, true), reqToken(), reqTeamMembership())
 
+		// Packages
+		m.Group("/packages/:username", func() {
+			m.Get("", packages.ListPackages)
+			m.Group("/:type/:name", func() {
+				m.Group("/:version", func() {
+					m.Combo("").Get(packages.GetPackage).
+						Delete(reqToken(), reqPackageAccess(models.AccessModeWrite), packages.DeletePackage)
+					m.Get("/files", packages.ListPackageFiles)
+				})
+				m.Group("/-", func() {
+					m.Post("/link/:reponame", packages.LinkPackage)
+					m.Post("/unlink", packages.UnlinkPackage)
+				}, reqToken(), reqPackageAccess(models.AccessModeWrite))
+			})
+		}, mustEnablePackages, packageAssignment(), reqPackageAccess(models.AccessModeRead))
+
 		m.Any("/*", func(ctx *context.APIContext) {
 			ctx.NotFound()
 		})
 
 		m.Group("/admin", func() {
 			m.Get("/orgs", admin.GetAllOrgs)
+			m.Get("/audit", admin.ListAuditEvents)
+			m.Get("/repos/:owner/:repo/export", admin.ExportRepo)
 			m.Group("/users", func() {
 				m.Get("", admin.GetAllUsers)
 				m.Post("", bind(api.CreateUserOption{}), admin.CreateUser)
@@ -890,6 +1016,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Get("/orgs", org.ListUserOrgs)
 					m.Post("/orgs", bind(api.CreateOrgOption{}), admin.CreateOrg)
 					m.Post("/repos", bind(api.CreateRepoOption{}), admin.CreateRepo)
+					m.Post("/repos/import", admin.ImportRepo)
 				})
 			})
 		}, reqToken(), reqSiteAdmin())
@@ -897,7 +1024,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Group("/topics", func() {
 			m.Get("/search", repo.TopicSearch)
 		})
-	}, securityHeaders(), context.APIContexter(), sudo())
+	}, securityHeaders(), context.APIContexter(), sudo(), reqTokenScope())
 }
 
 func securityHeaders() macaron.Handler {
@@ -102,6 +102,16 @@ Here are some links to the most important topics. You can find the full list of
 		`[[Name|Link]]`,
 		// rendered
 		`<p><a href="` + AppSubURL + `wiki/Link" rel="nofollow">Name</a></p>
+`,
+		// formulas and diagrams
+		"Euler: $e^{i\\pi} = -1$\n$$\n\\int_0^1 x\\,dx\n$$\n```mermaid\ngraph LR;\n  A-->B;\n```",
+		// rendered
+		`<p>Euler: <code class="math-inline">e^{i\pi} = -1</code></p>
+<pre class="math-display">\int_0^1 x\,dx
+</pre>
+<pre class="mermaid">graph LR;
+  A--&gt;B;
+</pre>
 `,
 		// empty
 		``,
@@ -14,6 +14,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/api/v1/user"
+	"code.gitea.io/gitea/services/audit"
 )
 
 // listMembers list an organization's members
@@ -279,6 +280,8 @@ func DeleteMember(ctx *context.APIContext) {
 	}
 	if err := ctx.Org.Organization.RemoveMember(member.ID); err != nil {
 		ctx.Error(http.StatusInternalServerError, "RemoveMember", err)
+		return
 	}
+	audit.Record(models.AuditActionOrgMemberRemove, ctx.User, ctx.RemoteAddr(), member, "Removed from organization %s", ctx.Org.Organization.Name)
 	ctx.Status(http.StatusNoContent)
 }
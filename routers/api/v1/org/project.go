@@ -0,0 +1,457 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package org
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// getOrgProject get the project of the organization from the path. If there is
+// an error, write to `ctx` accordingly
+func getOrgProject(ctx *context.APIContext) *models.Project {
+	if !models.HasOrgVisible(ctx.Org.Organization, ctx.User) {
+		ctx.NotFound("HasOrgVisible", nil)
+		return nil
+	}
+	p, err := models.GetProjectByOwnerID(ctx.Org.Organization.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrProjectNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetProjectByOwnerID", err)
+		}
+		return nil
+	}
+	return p
+}
+
+// ListProjects list the projects of an organization
+func ListProjects(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/projects organization orgListProjects
+	// ---
+	// summary: List an organization's projects
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: state
+	//   in: query
+	//   description: Project state, Recognised values are open, closed and all. Defaults to "open"
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectList"
+
+	if !models.HasOrgVisible(ctx.Org.Organization, ctx.User) {
+		ctx.NotFound("HasOrgVisible", nil)
+		return
+	}
+	utils.ListProjects(ctx, &models.FindProjectsOptions{
+		OwnerID: ctx.Org.Organization.ID,
+		Type:    models.ProjectTypeOrganization,
+	})
+}
+
+// CreateProject create a project for an organization
+func CreateProject(ctx *context.APIContext, form api.CreateProjectOption) {
+	// swagger:operation POST /orgs/{org}/projects organization orgCreateProject
+	// ---
+	// summary: Create a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/Project"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	utils.CreateProject(ctx, &form, &models.Project{
+		OwnerID: ctx.Org.Organization.ID,
+		Type:    models.ProjectTypeOrganization,
+	})
+}
+
+// GetProject get a project
+func GetProject(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/projects/{id} organization orgGetProject
+	// ---
+	// summary: Get a project
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+
+	p := getOrgProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	if apiProject := utils.ToAPIProject(ctx, p); apiProject != nil {
+		ctx.JSON(http.StatusOK, apiProject)
+	}
+}
+
+// EditProject modify a project
+func EditProject(ctx *context.APIContext, form api.EditProjectOption) {
+	// swagger:operation PATCH /orgs/{org}/projects/{id} organization orgEditProject
+	// ---
+	// summary: Update a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Project"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	p := getOrgProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	utils.EditProject(ctx, &form, p)
+}
+
+// DeleteProject delete a project
+func DeleteProject(ctx *context.APIContext) {
+	// swagger:operation DELETE /orgs/{org}/projects/{id} organization orgDeleteProject
+	// ---
+	// summary: Delete a project
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+
+	p := getOrgProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := models.DeleteProjectByID(p.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteProjectByID", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListProjectBoards list the boards of a project
+func ListProjectBoards(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/projects/{id}/boards organization orgListProjectBoards
+	// ---
+	// summary: List a project's boards
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectBoardList"
+
+	p := getOrgProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	utils.ListProjectBoards(ctx, p)
+}
+
+// CreateProjectBoard add a board to a project
+func CreateProjectBoard(ctx *context.APIContext, form api.CreateProjectBoardOption) {
+	// swagger:operation POST /orgs/{org}/projects/{id}/boards organization orgCreateProjectBoard
+	// ---
+	// summary: Add a board to a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectBoardOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ProjectBoard"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	p := getOrgProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	utils.CreateProjectBoard(ctx, &form, p)
+}
+
+// EditProjectBoard modify a board of a project
+func EditProjectBoard(ctx *context.APIContext, form api.EditProjectBoardOption) {
+	// swagger:operation PATCH /orgs/{org}/projects/{id}/boards/{boardid} organization orgEditProjectBoard
+	// ---
+	// summary: Update a board of a project
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: boardid
+	//   in: path
+	//   description: id of the board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectBoardOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectBoard"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	p := getOrgProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	utils.EditProjectBoard(ctx, &form, p, ctx.ParamsInt64(":boardid"))
+}
+
+// DeleteProjectBoard delete a board of a project
+func DeleteProjectBoard(ctx *context.APIContext) {
+	// swagger:operation DELETE /orgs/{org}/projects/{id}/boards/{boardid} organization orgDeleteProjectBoard
+	// ---
+	// summary: Delete a board of a project, its issues become uncategorized
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: boardid
+	//   in: path
+	//   description: id of the board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+
+	p := getOrgProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	utils.DeleteProjectBoard(ctx, p, ctx.ParamsInt64(":boardid"))
+}
+
+// ListProjectBoardIssues list the issues of a board
+func ListProjectBoardIssues(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/projects/{id}/boards/{boardid}/issues organization orgListProjectBoardIssues
+	// ---
+	// summary: List the issues of a project's board in their order, the board id 0 lists the uncategorized issues
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: boardid
+	//   in: path
+	//   description: id of the board, 0 for the uncategorized issues
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueList"
+
+	p := getOrgProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	utils.ListProjectBoardIssues(ctx, p, ctx.ParamsInt64(":boardid"))
+}
+
+// MoveProjectIssue add an issue to a project or move it on the project
+func MoveProjectIssue(ctx *context.APIContext, form api.MoveProjectIssueOption) {
+	// swagger:operation POST /orgs/{org}/projects/{id}/issues organization orgMoveProjectIssue
+	// ---
+	// summary: Add an issue or a pull request to a project or move it to another board or position
+	// consumes:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/MoveProjectIssueOption"
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	p := getOrgProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	utils.MoveProjectIssue(ctx, &form, p)
+}
+
+// RemoveProjectIssue remove an issue from a project
+func RemoveProjectIssue(ctx *context.APIContext) {
+	// swagger:operation DELETE /orgs/{org}/projects/{id}/issues/{issue_id} organization orgRemoveProjectIssue
+	// ---
+	// summary: Remove an issue or a pull request from a project
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: issue_id
+	//   in: path
+	//   description: id of the issue
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+
+	p := getOrgProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	utils.RemoveProjectIssue(ctx, p, ctx.ParamsInt64(":issueid"))
+}
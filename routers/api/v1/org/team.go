@@ -15,6 +15,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/api/v1/user"
+	"code.gitea.io/gitea/services/audit"
 )
 
 // ListTeams list all the teams of an organization
@@ -244,6 +245,7 @@ func EditTeam(ctx *context.APIContext, form api.EditTeamOption) {
 		ctx.Error(http.StatusInternalServerError, "EditTeam", err)
 		return
 	}
+	audit.Record(models.AuditActionTeamUpdate, ctx.User, ctx.RemoteAddr(), team, "Updated team, permission: %s, all repositories: %t", team.Authorize, team.IncludesAllRepositories)
 	ctx.JSON(http.StatusOK, convert.ToTeam(team))
 }
 
@@ -267,6 +269,7 @@ func DeleteTeam(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteTeam", err)
 		return
 	}
+	audit.Record(models.AuditActionTeamDelete, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, "Deleted team")
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -382,6 +385,7 @@ func AddTeamMember(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "AddMember", err)
 		return
 	}
+	audit.Record(models.AuditActionTeamMemberAdd, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, "Member %s (add)", u.Name)
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -419,6 +423,7 @@ func RemoveTeamMember(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "RemoveMember", err)
 		return
 	}
+	audit.Record(models.AuditActionTeamMemberRemove, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, "Member %s (remove)", u.Name)
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -515,6 +520,7 @@ func AddTeamRepository(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "AddRepository", err)
 		return
 	}
+	audit.Record(models.AuditActionRepoTeamAdd, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, "Added repository %s", repo.FullName())
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -565,6 +571,7 @@ func RemoveTeamRepository(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "RemoveRepository", err)
 		return
 	}
+	audit.Record(models.AuditActionRepoTeamRemove, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, "Removed repository %s", repo.FullName())
 	ctx.Status(http.StatusNoContent)
 }
 
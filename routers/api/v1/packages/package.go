@@ -0,0 +1,397 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	api "code.gitea.io/gitea/modules/structs"
+	packages_service "code.gitea.io/gitea/services/packages"
+)
+
+// ListPackages gets all packages of an owner
+func ListPackages(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner} package listPackages
+	// ---
+	// summary: Gets all packages of an owner
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the packages
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: query
+	//   description: package type filter
+	//   type: string
+	//   enum: [generic, npm, maven, container]
+	// - name: q
+	//   in: query
+	//   description: name filter
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PackageList"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	opts := &models.PackageSearchOptions{
+		OwnerID:  ctx.Package.Owner.ID,
+		Query:    ctx.Query("q"),
+		Page:     ctx.QueryInt("page"),
+		PageSize: convert.ToCorrectPageSize(ctx.QueryInt("limit")),
+	}
+	if packageType := ctx.Query("type"); packageType != "" {
+		opts.Type = models.ParsePackageType(packageType)
+		if opts.Type == 0 {
+			ctx.Error(http.StatusUnprocessableEntity, "", fmt.Errorf("Invalid package type: %q", packageType))
+			return
+		}
+	}
+
+	pvs, count, err := models.SearchPackageVersions(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "SearchPackageVersions", err)
+		return
+	}
+
+	apiPackages := make([]*api.Package, 0, len(pvs))
+	for _, pv := range pvs {
+		if err := pv.LoadAttributes(); err != nil {
+			ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+			return
+		}
+		apiPackages = append(apiPackages, pv.APIFormat(ctx.User))
+	}
+
+	ctx.SetLinkHeader(int(count), opts.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
+	ctx.JSON(http.StatusOK, apiPackages)
+}
+
+// getPackageVersion loads the package version of the path parameters
+func getPackageVersion(ctx *context.APIContext) *models.PackageVersion {
+	packageType := models.ParsePackageType(ctx.Params(":type"))
+	if packageType == 0 {
+		ctx.NotFound()
+		return nil
+	}
+
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, packageType, ctx.Params(":name"))
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageByName", err)
+		}
+		return nil
+	}
+
+	pv, err := models.GetPackageVersionByName(p.ID, ctx.Params(":version"))
+	if err != nil || pv.IsInternal {
+		if err == nil || models.IsErrPackageVersionNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageVersionByName", err)
+		}
+		return nil
+	}
+	pv.Package = p
+
+	if err := pv.LoadAttributes(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadAttributes", err)
+		return nil
+	}
+	return pv
+}
+
+// GetPackage gets a package
+func GetPackage(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner}/{type}/{name}/{version} package getPackage
+	// ---
+	// summary: Gets a package
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/Package"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	pv := getPackageVersion(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pv.APIFormat(ctx.User))
+}
+
+// DeletePackage deletes a package
+func DeletePackage(ctx *context.APIContext) {
+	// swagger:operation DELETE /packages/{owner}/{type}/{name}/{version} package deletePackage
+	// ---
+	// summary: Delete a package
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	pv := getPackageVersion(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := packages_service.DeletePackageVersion(pv); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeletePackageVersion", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListPackageFiles gets all files of a package
+func ListPackageFiles(ctx *context.APIContext) {
+	// swagger:operation GET /packages/{owner}/{type}/{name}/{version}/files package listPackageFiles
+	// ---
+	// summary: Gets all files of a package
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: version
+	//   in: path
+	//   description: version of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PackageFileList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	pv := getPackageVersion(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	pfs, err := models.GetPackageFilesByVersionID(pv.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPackageFilesByVersionID", err)
+		return
+	}
+
+	apiFiles := make([]*api.PackageFile, 0, len(pfs))
+	for _, pf := range pfs {
+		if err := pf.LoadBlob(); err != nil {
+			ctx.Error(http.StatusInternalServerError, "LoadBlob", err)
+			return
+		}
+		apiFiles = append(apiFiles, pf.APIFormat())
+	}
+
+	ctx.JSON(http.StatusOK, apiFiles)
+}
+
+// getPackage loads the package of the path parameters
+func getPackage(ctx *context.APIContext) *models.Package {
+	packageType := models.ParsePackageType(ctx.Params(":type"))
+	if packageType == 0 {
+		ctx.NotFound()
+		return nil
+	}
+
+	p, err := models.GetPackageByName(ctx.Package.Owner.ID, packageType, ctx.Params(":name"))
+	if err != nil {
+		if models.IsErrPackageNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPackageByName", err)
+		}
+		return nil
+	}
+	return p
+}
+
+// LinkPackage links a package to a repository
+func LinkPackage(ctx *context.APIContext) {
+	// swagger:operation POST /packages/{owner}/{type}/{name}/-/link/{repo_name} package linkPackage
+	// ---
+	// summary: Link a package to a repository of the same owner
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package and the repository
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// - name: repo_name
+	//   in: path
+	//   description: name of the repository to link
+	//   type: string
+	//   required: true
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := getPackage(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	repo, err := models.GetRepositoryByName(ctx.Package.Owner.ID, ctx.Params(":reponame"))
+	if err != nil {
+		if models.IsErrRepoNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetRepositoryByName", err)
+		}
+		return
+	}
+
+	perm, err := models.GetUserRepoPermission(repo, ctx.User)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetUserRepoPermission", err)
+		return
+	}
+	if !perm.HasAccess() {
+		ctx.NotFound()
+		return
+	}
+	if !perm.IsAdmin() {
+		ctx.Error(http.StatusForbidden, "", "Only repository admins can link packages")
+		return
+	}
+
+	if err := models.SetPackageRepositoryLink(p.ID, repo.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "SetPackageRepositoryLink", err)
+		return
+	}
+
+	ctx.Status(http.StatusCreated)
+}
+
+// UnlinkPackage removes the link between a package and its repository
+func UnlinkPackage(ctx *context.APIContext) {
+	// swagger:operation POST /packages/{owner}/{type}/{name}/-/unlink package unlinkPackage
+	// ---
+	// summary: Unlink a package from its repository
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the package
+	//   type: string
+	//   required: true
+	// - name: type
+	//   in: path
+	//   description: type of the package
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of the package
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	p := getPackage(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.SetPackageRepositoryLink(p.ID, 0); err != nil {
+		ctx.Error(http.StatusInternalServerError, "SetPackageRepositoryLink", err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
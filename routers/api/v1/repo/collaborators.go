@@ -13,6 +13,7 @@ import (
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/services/audit"
 )
 
 // ListCollaborators list a repository's collaborators
@@ -153,12 +154,15 @@ func AddCollaborator(ctx *context.APIContext, form api.AddCollaboratorOption) {
 		ctx.Error(http.StatusInternalServerError, "AddCollaborator", err)
 		return
 	}
+	audit.Record(models.AuditActionRepoCollaboratorAdd, ctx.User, ctx.RemoteAddr(), ctx.Repo.Repository, "Added collaborator %s", collaborator.Name)
 
 	if form.Permission != nil {
-		if err := ctx.Repo.Repository.ChangeCollaborationAccessMode(collaborator.ID, models.ParseAccessMode(*form.Permission)); err != nil {
+		mode := models.ParseAccessMode(*form.Permission)
+		if err := ctx.Repo.Repository.ChangeCollaborationAccessMode(collaborator.ID, mode); err != nil {
 			ctx.Error(http.StatusInternalServerError, "ChangeCollaborationAccessMode", err)
 			return
 		}
+		audit.Record(models.AuditActionRepoCollaboratorAccess, ctx.User, ctx.RemoteAddr(), ctx.Repo.Repository, "Changed access of collaborator %s to %s", collaborator.Name, mode)
 	}
 
 	ctx.Status(http.StatusNoContent)
@@ -207,5 +211,6 @@ func DeleteCollaborator(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteCollaboration", err)
 		return
 	}
+	audit.Record(models.AuditActionRepoCollaboratorRemove, ctx.User, ctx.RemoteAddr(), ctx.Repo.Repository, "Removed collaborator %s", collaborator.Name)
 	ctx.Status(http.StatusNoContent)
 }
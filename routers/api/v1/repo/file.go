@@ -92,6 +92,8 @@ func GetArchive(ctx *context.APIContext) {
 	// responses:
 	//   200:
 	//     description: success
+	//   202:
+	//     description: the archive is being generated, retry after the number of seconds in the Retry-After header
 	//   "404":
 	//     "$ref": "#/responses/notFound"
 
@@ -216,9 +216,9 @@ func ListIssues(ctx *context.APIContext) {
 	}
 
 	if splitted := strings.Split(ctx.Query("labels"), ","); len(splitted) > 0 {
-		labelIDs, err = models.GetLabelIDsInRepoByNames(ctx.Repo.Repository.ID, splitted)
+		labelIDs, err = models.GetLabelIDsInRepoOrOrgByNames(ctx.Repo.Repository, splitted)
 		if err != nil {
-			ctx.Error(http.StatusInternalServerError, "GetLabelIDsInRepoByNames", err)
+			ctx.Error(http.StatusInternalServerError, "GetLabelIDsInRepoOrOrgByNames", err)
 			return
 		}
 	}
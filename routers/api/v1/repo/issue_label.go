@@ -117,9 +117,9 @@ func AddIssueLabels(ctx *context.APIContext, form api.IssueLabelsOption) {
 		return
 	}
 
-	labels, err := models.GetLabelsInRepoByIDs(ctx.Repo.Repository.ID, form.Labels)
+	labels, err := models.GetLabelsInRepoOrOrgByIDs(ctx.Repo.Repository, form.Labels)
 	if err != nil {
-		ctx.Error(http.StatusInternalServerError, "GetLabelsInRepoByIDs", err)
+		ctx.Error(http.StatusInternalServerError, "GetLabelsInRepoOrOrgByIDs", err)
 		return
 	}
 
@@ -194,15 +194,19 @@ func DeleteIssueLabel(ctx *context.APIContext) {
 		return
 	}
 
-	label, err := models.GetLabelInRepoByID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	label, err := models.GetLabelByID(ctx.ParamsInt64(":id"))
 	if err != nil {
 		if models.IsErrLabelNotExist(err) {
 			ctx.Error(http.StatusUnprocessableEntity, "", err)
 		} else {
-			ctx.Error(http.StatusInternalServerError, "GetLabelInRepoByID", err)
+			ctx.Error(http.StatusInternalServerError, "GetLabelByID", err)
 		}
 		return
 	}
+	if !label.CanBeUsedInRepo(ctx.Repo.Repository) {
+		ctx.Error(http.StatusUnprocessableEntity, "", models.ErrLabelNotExist{LabelID: label.ID, RepoID: ctx.Repo.Repository.ID})
+		return
+	}
 
 	if err := models.DeleteIssueLabel(issue, label, ctx.User); err != nil {
 		ctx.Error(http.StatusInternalServerError, "DeleteIssueLabel", err)
@@ -263,9 +267,9 @@ func ReplaceIssueLabels(ctx *context.APIContext, form api.IssueLabelsOption) {
 		return
 	}
 
-	labels, err := models.GetLabelsInRepoByIDs(ctx.Repo.Repository.ID, form.Labels)
+	labels, err := models.GetLabelsInRepoOrOrgByIDs(ctx.Repo.Repository, form.Labels)
 	if err != nil {
-		ctx.Error(http.StatusInternalServerError, "GetLabelsInRepoByIDs", err)
+		ctx.Error(http.StatusInternalServerError, "GetLabelsInRepoOrOrgByIDs", err)
 		return
 	}
 
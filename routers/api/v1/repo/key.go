@@ -13,6 +13,7 @@ import (
 	"code.gitea.io/gitea/modules/convert"
 	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/services/audit"
 )
 
 // appendPrivateInformation appends the owner and key type information to api.PublicKey
@@ -213,6 +214,7 @@ func CreateDeployKey(ctx *context.APIContext, form api.CreateKeyOption) {
 		HandleAddKeyError(ctx, err)
 		return
 	}
+	audit.Record(models.AuditActionDeployKeyAdd, ctx.User, ctx.RemoteAddr(), ctx.Repo.Repository, "Added deploy key %s", key.Name)
 
 	key.Content = content
 	apiLink := composeDeployKeysAPILink(ctx.Repo.Owner.Name + "/" + ctx.Repo.Repository.Name)
@@ -247,7 +249,17 @@ func DeleteDeploykey(ctx *context.APIContext) {
 	//   "403":
 	//     "$ref": "#/responses/forbidden"
 
-	if err := models.DeleteDeployKey(ctx.User, ctx.ParamsInt64(":id")); err != nil {
+	key, err := models.GetDeployKeyByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrDeployKeyNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetDeployKeyByID", err)
+		}
+		return
+	}
+
+	if err := models.DeleteDeployKey(ctx.User, key.ID); err != nil {
 		if models.IsErrKeyAccessDenied(err) {
 			ctx.Error(http.StatusForbidden, "", "You do not have access to this key")
 		} else {
@@ -255,6 +267,7 @@ func DeleteDeploykey(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit.Record(models.AuditActionDeployKeyDelete, ctx.User, ctx.RemoteAddr(), ctx.Repo.Repository, "Deleted deploy key %s", key.Name)
 
 	ctx.Status(http.StatusNoContent)
 }
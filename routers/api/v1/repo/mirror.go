@@ -0,0 +1,266 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+	mirror_service "code.gitea.io/gitea/services/mirror"
+)
+
+// ListPushMirrors list the push mirrors of a repository
+func ListPushMirrors(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/push_mirrors repository repoListPushMirrors
+	// ---
+	// summary: List a repository's push mirrors
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PushMirrorList"
+
+	mirrors, err := models.GetPushMirrorsByRepoID(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetPushMirrorsByRepoID", err)
+		return
+	}
+
+	apiMirrors := make([]*api.PushMirror, len(mirrors))
+	for i := range mirrors {
+		apiMirrors[i] = convert.ToPushMirror(mirrors[i], mirror_service.PushMirrorAddress(mirrors[i]))
+	}
+	ctx.JSON(http.StatusOK, &apiMirrors)
+}
+
+// GetPushMirror get a push mirror of a repository by id
+func GetPushMirror(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/push_mirrors/{id} repository repoGetPushMirror
+	// ---
+	// summary: Get a repository's push mirror by id
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the push mirror to get
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PushMirror"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	m := getPushMirrorByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(http.StatusOK, convert.ToPushMirror(m, mirror_service.PushMirrorAddress(m)))
+}
+
+// CreatePushMirror add a push mirror to a repository
+func CreatePushMirror(ctx *context.APIContext, form api.CreatePushMirrorOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/push_mirrors repository repoCreatePushMirror
+	// ---
+	// summary: Add a push mirror to a repository
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreatePushMirrorOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/PushMirror"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	var interval time.Duration
+	if len(form.Interval) > 0 {
+		var err error
+		interval, err = time.ParseDuration(form.Interval)
+		if err != nil || (interval != 0 && interval < setting.Mirror.MinInterval) {
+			ctx.Error(http.StatusUnprocessableEntity, "Interval", fmt.Errorf("invalid interval, it must be 0 or at least %s", setting.Mirror.MinInterval))
+			return
+		}
+	}
+
+	address, err := mirror_service.ParsePushMirrorAddress(form.RemoteAddress, form.RemoteUsername, form.RemotePassword)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "RemoteAddress", err)
+		return
+	}
+
+	m, err := mirror_service.AddPushMirror(ctx.Repo.Repository, address, interval, form.SyncOnCommit)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "AddPushMirror", err)
+		return
+	}
+	mirror_service.StartToPushMirror(m)
+
+	ctx.JSON(http.StatusCreated, convert.ToPushMirror(m, mirror_service.PushMirrorAddress(m)))
+}
+
+// DeletePushMirror delete a push mirror from a repository
+func DeletePushMirror(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/push_mirrors/{id} repository repoDeletePushMirror
+	// ---
+	// summary: Delete a push mirror from a repository
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the push mirror to delete
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	m := getPushMirrorByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := mirror_service.RemovePushMirror(m); err != nil {
+		ctx.Error(http.StatusInternalServerError, "RemovePushMirror", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// SyncPushMirror push a repository to one of its push mirrors
+func SyncPushMirror(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/push_mirrors/{id}/sync repository repoSyncPushMirror
+	// ---
+	// summary: Push a repository to one of its push mirrors
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the push mirror to sync
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	m := getPushMirrorByParams(ctx)
+	if ctx.Written() {
+		return
+	}
+	mirror_service.StartToPushMirror(m)
+	ctx.Status(http.StatusOK)
+}
+
+// SyncPushMirrors push a repository to all of its push mirrors
+func SyncPushMirrors(ctx *context.APIContext) {
+	// swagger:operation POST /repos/{owner}/{repo}/push_mirrors-sync repository repoSyncPushMirrors
+	// ---
+	// summary: Push a repository to all of its push mirrors
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/empty"
+
+	if err := mirror_service.StartToPushMirrors(ctx.Repo.Repository.ID); err != nil {
+		ctx.Error(http.StatusInternalServerError, "StartToPushMirrors", err)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// getPushMirrorByParams returns the push mirror of the repository with the id in the path
+func getPushMirrorByParams(ctx *context.APIContext) *models.PushMirror {
+	m, err := models.GetPushMirrorByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if err == models.ErrPushMirrorNotExist {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPushMirrorByID", err)
+		}
+		return nil
+	}
+	if m.RepoID != ctx.Repo.Repository.ID {
+		ctx.NotFound()
+		return nil
+	}
+	return m
+}
@@ -227,9 +227,9 @@ func CreatePullRequest(ctx *context.APIContext, form api.CreatePullRequestOption
 	}
 
 	if len(form.Labels) > 0 {
-		labels, err := models.GetLabelsInRepoByIDs(ctx.Repo.Repository.ID, form.Labels)
+		labels, err := models.GetLabelsInRepoOrOrgByIDs(ctx.Repo.Repository, form.Labels)
 		if err != nil {
-			ctx.Error(http.StatusInternalServerError, "GetLabelsInRepoByIDs", err)
+			ctx.Error(http.StatusInternalServerError, "GetLabelsInRepoOrOrgByIDs", err)
 			return
 		}
 
@@ -441,9 +441,9 @@ func EditPullRequest(ctx *context.APIContext, form api.EditPullRequestOption) {
 	}
 
 	if ctx.Repo.CanWrite(models.UnitTypePullRequests) && form.Labels != nil {
-		labels, err := models.GetLabelsInRepoByIDs(ctx.Repo.Repository.ID, form.Labels)
+		labels, err := models.GetLabelsInRepoOrOrgByIDs(ctx.Repo.Repository, form.Labels)
 		if err != nil {
-			ctx.Error(http.StatusInternalServerError, "GetLabelsInRepoByIDsError", err)
+			ctx.Error(http.StatusInternalServerError, "GetLabelsInRepoOrOrgByIDs", err)
 			return
 		}
 		if err = issue.ReplaceLabels(labels, ctx.User); err != nil {
@@ -559,6 +559,10 @@ func MergePullRequest(ctx *context.APIContext, form auth.MergePullRequestForm) {
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/empty"
+	//   "201":
+	//     "$ref": "#/responses/empty"
+	//   "202":
+	//     "$ref": "#/responses/empty"
 	//   "405":
 	//     "$ref": "#/responses/empty"
 	//   "409":
@@ -610,15 +614,30 @@ func MergePullRequest(ctx *context.APIContext, form auth.MergePullRequestForm) {
 		return
 	}
 
+	if len(form.Do) == 0 {
+		form.Do = string(models.MergeStyleMerge)
+	}
+
+	if form.MergeWhenChecksSucceed {
+		allowed := true
+		if err := pr.CheckUserAllowedToMerge(ctx.User); err != nil {
+			if !models.IsErrNotAllowedToMerge(err) {
+				ctx.Error(http.StatusInternalServerError, "CheckUserAllowedToMerge", err)
+				return
+			}
+			allowed = false
+		}
+		if !isPass || !allowed {
+			scheduleAutoMerge(ctx, pr, form)
+			return
+		}
+	}
+
 	if !isPass && !ctx.IsUserRepoAdmin() {
 		ctx.Status(http.StatusMethodNotAllowed)
 		return
 	}
 
-	if len(form.Do) == 0 {
-		form.Do = string(models.MergeStyleMerge)
-	}
-
 	message := strings.TrimSpace(form.MergeTitleField)
 	if len(message) == 0 {
 		if models.MergeStyle(form.Do) == models.MergeStyleMerge {
@@ -634,6 +653,15 @@ func MergePullRequest(ctx *context.APIContext, form auth.MergePullRequestForm) {
 		message += "\n\n" + form.MergeMessageField
 	}
 
+	if err := pr.LoadProtectedBranch(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadProtectedBranch", err)
+		return
+	}
+	if pr.ProtectedBranch != nil && pr.ProtectedBranch.EnableMergeQueue {
+		addToMergeQueue(ctx, pr, models.MergeStyle(form.Do), message)
+		return
+	}
+
 	if err := pull_service.Merge(pr, ctx.User, ctx.Repo.GitRepo, models.MergeStyle(form.Do), message); err != nil {
 		if models.IsErrInvalidMergeStyle(err) {
 			ctx.Status(http.StatusMethodNotAllowed)
@@ -659,6 +687,124 @@ func MergePullRequest(ctx *context.APIContext, form auth.MergePullRequestForm) {
 	ctx.Status(http.StatusOK)
 }
 
+// scheduleAutoMerge schedules the pull request to be merged once its status checks and approvals succeed
+func scheduleAutoMerge(ctx *context.APIContext, pr *models.PullRequest, form auth.MergePullRequestForm) {
+	if err := pr.LoadProtectedBranch(); err != nil {
+		ctx.Error(http.StatusInternalServerError, "LoadProtectedBranch", err)
+		return
+	}
+	if pr.ProtectedBranch != nil && !pr.ProtectedBranch.CanUserMerge(ctx.User.ID) {
+		ctx.Status(http.StatusMethodNotAllowed)
+		return
+	}
+
+	// the default merge message is generated when the pull request is merged
+	var message string
+	if title := strings.TrimSpace(form.MergeTitleField); len(title) > 0 {
+		message = title
+		if body := strings.TrimSpace(form.MergeMessageField); len(body) > 0 {
+			message += "\n\n" + body
+		}
+	}
+
+	if err := pull_service.ScheduleAutoMerge(ctx.User, pr, models.MergeStyle(form.Do), message); err != nil {
+		if models.IsErrInvalidMergeStyle(err) {
+			ctx.Status(http.StatusMethodNotAllowed)
+		} else if models.IsErrPullRequestAlreadyScheduledToAutoMerge(err) {
+			ctx.Error(http.StatusConflict, "ScheduleAutoMerge", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "ScheduleAutoMerge", err)
+		}
+		return
+	}
+
+	log.Trace("Pull request scheduled to be merged: %d", pr.ID)
+	ctx.Status(http.StatusCreated)
+}
+
+// addToMergeQueue adds the pull request to the merge queue of its target branch
+func addToMergeQueue(ctx *context.APIContext, pr *models.PullRequest, style models.MergeStyle, message string) {
+	if err := pr.CheckUserAllowedToMerge(ctx.User); err != nil {
+		if !models.IsErrNotAllowedToMerge(err) {
+			ctx.Error(http.StatusInternalServerError, "CheckUserAllowedToMerge", err)
+			return
+		}
+		ctx.Status(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := pull_service.AddToMergeQueue(ctx.User, pr, style, message); err != nil {
+		if models.IsErrInvalidMergeStyle(err) {
+			ctx.Status(http.StatusMethodNotAllowed)
+		} else if models.IsErrPullRequestAlreadyInMergeQueue(err) {
+			ctx.Error(http.StatusConflict, "AddToMergeQueue", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "AddToMergeQueue", err)
+		}
+		return
+	}
+
+	log.Trace("Pull request added to the merge queue: %d", pr.ID)
+	ctx.Status(http.StatusAccepted)
+}
+
+// CancelScheduledAutoMerge cancels the scheduled merge of a pull request
+func CancelScheduledAutoMerge(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/pulls/{index}/merge repository repoCancelScheduledAutoMerge
+	// ---
+	// summary: Cancel the scheduled merge of a pull request when all checks succeed or its entry in the merge queue
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+
+	pr, err := models.GetPullRequestByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrPullRequestNotExist(err) {
+			ctx.NotFound("GetPullRequestByIndex", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetPullRequestByIndex", err)
+		}
+		return
+	}
+
+	removed, err := models.RemoveScheduledAutoMerge(ctx.User, pr)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "RemoveScheduledAutoMerge", err)
+		return
+	}
+	dequeued, err := pull_service.RemoveFromMergeQueue(ctx.User, pr)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "RemoveFromMergeQueue", err)
+		return
+	}
+	if !removed && !dequeued {
+		ctx.NotFound()
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
 func parseCompareInfo(ctx *context.APIContext, form api.CreatePullRequestOption) (*models.User, *models.Repository, *git.Repository, *git.CompareInfo, string, string) {
 	baseRepo := ctx.Repo.Repository
 
@@ -27,6 +27,7 @@ import (
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/modules/util"
 	"code.gitea.io/gitea/modules/validation"
+	"code.gitea.io/gitea/services/audit"
 	mirror_service "code.gitea.io/gitea/services/mirror"
 	repo_service "code.gitea.io/gitea/services/repository"
 )
@@ -422,8 +423,11 @@ func Migrate(ctx *context.APIContext, form auth.MigrateRepoForm) {
 
 	var gitServiceType = structs.PlainGitService
 	u, err := url.Parse(remoteAddr)
-	if err == nil && strings.EqualFold(u.Host, "github.com") {
+	switch {
+	case form.Service == structs.GithubService.Name() || (err == nil && strings.EqualFold(u.Host, "github.com")):
 		gitServiceType = structs.GithubService
+	case form.Service == structs.GitlabService.Name() || (err == nil && strings.EqualFold(u.Host, "gitlab.com")):
+		gitServiceType = structs.GitlabService
 	}
 
 	var opts = migrations.MigrateOptions{
@@ -715,6 +719,9 @@ func updateBasicProperties(ctx *context.APIContext, opts api.EditRepoOption) err
 		ctx.Error(http.StatusInternalServerError, "UpdateRepository", err)
 		return err
 	}
+	if visibilityChanged {
+		audit.Record(models.AuditActionRepoVisibility, ctx.User, ctx.RemoteAddr(), repo, "Changed visibility, private: %t", repo.IsPrivate)
+	}
 
 	log.Trace("Repository basic settings updated: %s/%s", owner.Name, repo.Name)
 	return nil
@@ -876,6 +883,19 @@ func updateRepoUnits(ctx *context.APIContext, opts api.EditRepoOption) error {
 		})
 	}
 
+	if opts.HasProjects == nil {
+		// If HasProjects setting not touched, rewrite existing repo unit
+		if unit, err := repo.GetUnit(models.UnitTypeProjects); err == nil {
+			units = append(units, *unit)
+		}
+	} else if *opts.HasProjects {
+		units = append(units, models.RepoUnit{
+			RepoID: repo.ID,
+			Type:   models.UnitTypeProjects,
+			Config: &models.UnitConfig{},
+		})
+	}
+
 	if err := models.UpdateRepositoryUnits(repo, units); err != nil {
 		ctx.Error(http.StatusInternalServerError, "UpdateRepositoryUnits", err)
 		return err
@@ -954,6 +974,7 @@ func Delete(ctx *context.APIContext) {
 		ctx.Error(http.StatusInternalServerError, "DeleteRepository", err)
 		return
 	}
+	audit.Record(models.AuditActionRepoDelete, ctx.User, ctx.RemoteAddr(), repo, "Deleted repository")
 
 	log.Trace("Repository deleted: %s/%s", owner.Name, repo.Name)
 	ctx.Status(http.StatusNoContent)
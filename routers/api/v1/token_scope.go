@@ -0,0 +1,88 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+
+	"gitea.com/macaron/macaron"
+)
+
+// reqTokenScope requires the access token of the request to have the scope needed for the requested path,
+// requests which are not authenticated by an access token are not restricted
+func reqTokenScope() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		if _, ok := ctx.Data["ApiTokenScope"]; !ok {
+			return
+		}
+
+		path := ctx.Req.URL.Path
+		if idx := strings.Index(path, "/api/v1/"); idx >= 0 {
+			path = path[idx+len("/api/v1/"):]
+		}
+		scope := requiredTokenScope(ctx.Req.Method, path)
+		if len(scope) > 0 && !ctx.TokenHasScope(scope) {
+			ctx.Error(http.StatusForbidden, "reqTokenScope", fmt.Errorf("the access token does not have the required scope: %s", scope))
+		}
+	}
+}
+
+// requiredTokenScope returns the scope an access token needs to request the path relative to /api/v1/
+// with the method, the scope is empty if every access token is allowed
+func requiredTokenScope(method, path string) models.AccessTokenScope {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	repoScope := models.AccessTokenScopeRepoWrite
+	if method == http.MethodGet || method == http.MethodHead {
+		repoScope = models.AccessTokenScopeRepoRead
+	}
+
+	switch segments[0] {
+	case "admin":
+		return models.AccessTokenScopeAdmin
+	case "packages":
+		return models.AccessTokenScopePackage
+	case "notifications":
+		return models.AccessTokenScopeIssue
+	case "repos":
+		// /repos/issues/search
+		if len(segments) > 1 && segments[1] == "issues" {
+			return models.AccessTokenScopeIssue
+		}
+		// /repos/{owner}/{repo}/...
+		if len(segments) > 3 {
+			switch segments[3] {
+			case "issues", "labels", "milestones", "times", "notifications":
+				return models.AccessTokenScopeIssue
+			}
+		}
+		return repoScope
+	case "repositories", "org", "orgs", "teams":
+		return repoScope
+	case "user":
+		// the authenticated user itself can be requested with every token
+		if len(segments) == 1 {
+			return ""
+		}
+		switch segments[1] {
+		case "repos", "orgs":
+			return repoScope
+		case "times", "stopwatches":
+			return models.AccessTokenScopeIssue
+		}
+		return models.AccessTokenScopeUser
+	case "users":
+		if len(segments) > 2 && (segments[2] == "repos" || segments[2] == "orgs") {
+			return repoScope
+		}
+		return models.AccessTokenScopeUser
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package v1
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequiredTokenScope(t *testing.T) {
+	kases := []struct {
+		method string
+		path   string
+		scope  models.AccessTokenScope
+	}{
+		{http.MethodGet, "version", ""},
+		{http.MethodPost, "markdown", ""},
+		{http.MethodGet, "user", ""},
+		{http.MethodGet, "user/emails", models.AccessTokenScopeUser},
+		{http.MethodPost, "user/keys", models.AccessTokenScopeUser},
+		{http.MethodGet, "user/repos", models.AccessTokenScopeRepoRead},
+		{http.MethodPost, "user/repos", models.AccessTokenScopeRepoWrite},
+		{http.MethodGet, "user/stopwatches", models.AccessTokenScopeIssue},
+		{http.MethodGet, "users/user2/repos", models.AccessTokenScopeRepoRead},
+		{http.MethodGet, "users/user2/followers", models.AccessTokenScopeUser},
+		{http.MethodGet, "repos/search", models.AccessTokenScopeRepoRead},
+		{http.MethodGet, "repos/issues/search", models.AccessTokenScopeIssue},
+		{http.MethodPost, "repos/migrate", models.AccessTokenScopeRepoWrite},
+		{http.MethodGet, "repos/user2/repo1", models.AccessTokenScopeRepoRead},
+		{http.MethodHead, "repos/user2/repo1/raw/README.md", models.AccessTokenScopeRepoRead},
+		{http.MethodPatch, "repos/user2/repo1", models.AccessTokenScopeRepoWrite},
+		{http.MethodPost, "repos/user2/repo1/pulls", models.AccessTokenScopeRepoWrite},
+		{http.MethodGet, "repos/user2/repo1/issues/1", models.AccessTokenScopeIssue},
+		{http.MethodPost, "repos/user2/repo1/labels", models.AccessTokenScopeIssue},
+		{http.MethodDelete, "orgs/user3", models.AccessTokenScopeRepoWrite},
+		{http.MethodGet, "notifications", models.AccessTokenScopeIssue},
+		{http.MethodPut, "packages/user2/generic/test/1.0", models.AccessTokenScopePackage},
+		{http.MethodGet, "admin/users", models.AccessTokenScopeAdmin},
+	}
+	for _, kase := range kases {
+		assert.Equal(t, kase.scope, requiredTokenScope(kase.method, kase.path), "%s %s", kase.method, kase.path)
+	}
+}
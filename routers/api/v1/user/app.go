@@ -6,11 +6,16 @@
 package user
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/convert"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/services/audit"
 )
 
 // ListAccessTokens list all the access tokens
@@ -38,11 +43,7 @@ func ListAccessTokens(ctx *context.APIContext) {
 
 	apiTokens := make([]*api.AccessToken, len(tokens))
 	for i := range tokens {
-		apiTokens[i] = &api.AccessToken{
-			ID:             tokens[i].ID,
-			Name:           tokens[i].Name,
-			TokenLastEight: tokens[i].TokenLastEight,
-		}
+		apiTokens[i] = convert.ToAccessToken(tokens[i])
 	}
 	ctx.JSON(http.StatusOK, &apiTokens)
 }
@@ -71,24 +72,54 @@ func CreateAccessToken(ctx *context.APIContext, form api.CreateAccessTokenOption
 	//     properties:
 	//       name:
 	//         type: string
+	//       scopes:
+	//         type: array
+	//         items:
+	//           type: string
+	//           enum: [all, repo:read, repo:write, issue, package, user, admin]
+	//       expires_at:
+	//         type: string
+	//         format: date-time
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/AccessToken"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+
+	scope, err := models.ParseAccessTokenScope(form.Scopes)
+	if err != nil {
+		ctx.Error(http.StatusUnprocessableEntity, "ParseAccessTokenScope", err)
+		return
+	}
+	// a token can not create a token with more rights than itself
+	if tokenScope, ok := ctx.Data["ApiTokenScope"].(models.AccessTokenScope); ok && !tokenScope.Covers(scope) {
+		ctx.Error(http.StatusForbidden, "CreateAccessToken", fmt.Errorf("the access token of the request does not cover the scope %s", scope))
+		return
+	}
 
 	t := &models.AccessToken{
-		UID:  ctx.User.ID,
-		Name: form.Name,
+		UID:   ctx.User.ID,
+		Name:  form.Name,
+		Scope: scope,
+	}
+	if form.ExpiresAt != nil {
+		if !form.ExpiresAt.After(time.Now()) {
+			ctx.Error(http.StatusUnprocessableEntity, "ExpiresAt", fmt.Errorf("the expiry date must be in the future"))
+			return
+		}
+		t.ExpiresUnix = timeutil.TimeStamp(form.ExpiresAt.Unix())
 	}
 	if err := models.NewAccessToken(t); err != nil {
 		ctx.Error(http.StatusInternalServerError, "NewAccessToken", err)
 		return
 	}
-	ctx.JSON(http.StatusCreated, &api.AccessToken{
-		Name:           t.Name,
-		Token:          t.Token,
-		ID:             t.ID,
-		TokenLastEight: t.TokenLastEight,
-	})
+	audit.Record(models.AuditActionAccessTokenCreate, ctx.User, ctx.RemoteAddr(), ctx.User, "Created access token %s with scope %s", t.Name, t.Scope)
+
+	apiToken := convert.ToAccessToken(t)
+	apiToken.Token = t.Token
+	ctx.JSON(http.StatusCreated, apiToken)
 }
 
 // DeleteAccessToken delete access tokens
@@ -123,6 +154,7 @@ func DeleteAccessToken(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit.Record(models.AuditActionAccessTokenDelete, ctx.User, ctx.RemoteAddr(), ctx.User, "Deleted access token %d", tokenID)
 
 	ctx.Status(http.StatusNoContent)
 }
@@ -11,6 +11,7 @@ import (
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
 	api "code.gitea.io/gitea/modules/structs"
+	"code.gitea.io/gitea/services/audit"
 )
 
 func listGPGKeys(ctx *context.APIContext, uid int64) {
@@ -105,6 +106,7 @@ func CreateUserGPGKey(ctx *context.APIContext, form api.CreateGPGKeyOption, uid
 		HandleAddGPGKeyError(ctx, err)
 		return
 	}
+	recordKeyEvent(ctx, models.AuditActionGPGKeyAdd, uid, "Added GPG key %s", key.KeyID)
 	ctx.JSON(http.StatusCreated, convert.ToGPGKey(key))
 }
 
@@ -160,6 +162,7 @@ func DeleteGPGKey(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit.Record(models.AuditActionGPGKeyDelete, ctx.User, ctx.RemoteAddr(), ctx.User, "Deleted GPG key %d", ctx.ParamsInt64(":id"))
 
 	ctx.Status(http.StatusNoContent)
 }
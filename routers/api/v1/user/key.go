@@ -10,9 +10,11 @@ import (
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
 	api "code.gitea.io/gitea/modules/structs"
 	"code.gitea.io/gitea/routers/api/v1/repo"
+	"code.gitea.io/gitea/services/audit"
 )
 
 // appendPrivateInformation appends the owner and key type information to api.PublicKey
@@ -197,6 +199,7 @@ func CreateUserPublicKey(ctx *context.APIContext, form api.CreateKeyOption, uid
 		repo.HandleAddKeyError(ctx, err)
 		return
 	}
+	recordKeyEvent(ctx, models.AuditActionPublicKeyAdd, uid, "Added SSH key %s", key.Name)
 	apiLink := composePublicKeysAPILink()
 	apiKey := convert.ToPublicKey(apiLink, key)
 	if ctx.User.IsAdmin || ctx.User.ID == key.OwnerID {
@@ -260,6 +263,21 @@ func DeletePublicKey(ctx *context.APIContext) {
 		}
 		return
 	}
+	audit.Record(models.AuditActionPublicKeyDelete, ctx.User, ctx.RemoteAddr(), ctx.User, "Deleted SSH key %d", ctx.ParamsInt64(":id"))
 
 	ctx.Status(http.StatusNoContent)
 }
+
+// recordKeyEvent adds an audit event for a key of the user with the given ID,
+// which is not necessarily the doer when a site administrator manages the keys
+func recordKeyEvent(ctx *context.APIContext, action models.AuditAction, uid int64, format string, v ...interface{}) {
+	owner := ctx.User
+	if uid != ctx.User.ID {
+		var err error
+		if owner, err = models.GetUserByID(uid); err != nil {
+			log.Error("GetUserByID: %v", err)
+			return
+		}
+	}
+	audit.Record(action, ctx.User, ctx.RemoteAddr(), owner, format, v...)
+}
@@ -255,3 +255,64 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *models.Webho
 	}
 	return true
 }
+
+// ListHookDeliveries lists the recent deliveries of a webhook. Writes to `ctx` accordingly
+func ListHookDeliveries(ctx *context.APIContext, w *models.Webhook) {
+	page := ctx.QueryInt("page")
+	if page <= 0 {
+		page = 1
+	}
+	tasks, err := models.HookTasks(w.ID, page)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "HookTasks", err)
+		return
+	}
+
+	deliveries := make([]*api.HookDelivery, len(tasks))
+	for i, t := range tasks {
+		deliveries[i] = convert.ToHookDelivery(t)
+	}
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+// RedeliverHookDelivery delivers the payload of a past delivery of a webhook again.
+// Writes to `ctx` accordingly
+func RedeliverHookDelivery(ctx *context.APIContext, w *models.Webhook) {
+	t, err := models.GetHookTaskByHookID(w.ID, ctx.ParamsInt64(":delivery"))
+	if err != nil {
+		if models.IsErrHookTaskNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetHookTaskByHookID", err)
+		}
+		return
+	}
+
+	task, err := webhook.RedeliverHookTask(w, t)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "RedeliverHookTask", err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, convert.ToHookDelivery(task))
+}
+
+// ReplayHookDeliveries delivers the payloads of all deliveries of a webhook in a time range
+// again. Writes to `ctx` accordingly
+func ReplayHookDeliveries(ctx *context.APIContext, w *models.Webhook, form *api.ReplayHookDeliveriesOption) {
+	if !form.Since.Before(form.Before) {
+		ctx.Error(http.StatusUnprocessableEntity, "", "since must be earlier than before")
+		return
+	}
+
+	tasks, err := webhook.ReplayHookTasks(w, form.Since, form.Before)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "ReplayHookTasks", err)
+		return
+	}
+
+	deliveries := make([]*api.HookDelivery, len(tasks))
+	for i, t := range tasks {
+		deliveries[i] = convert.ToHookDelivery(t)
+	}
+	ctx.JSON(http.StatusAccepted, deliveries)
+}
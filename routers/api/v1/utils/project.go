@@ -0,0 +1,301 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ToAPIProject converts a project to its API format including the issue counts.
+// If there is an error, write to `ctx` accordingly and return nil
+func ToAPIProject(ctx *context.APIContext, p *models.Project) *api.Project {
+	open, closed, err := models.CountProjectIssues(p.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "CountProjectIssues", err)
+		return nil
+	}
+	apiProject := p.APIFormat()
+	apiProject.OpenIssues = open
+	apiProject.ClosedIssues = closed
+	return apiProject
+}
+
+// ListProjects write the projects matching the options and the state query to `ctx`
+func ListProjects(ctx *context.APIContext, opts *models.FindProjectsOptions) {
+	switch api.StateType(ctx.Query("state")) {
+	case api.StateClosed:
+		isClosed := true
+		opts.IsClosed = &isClosed
+	case api.StateAll:
+	default:
+		isClosed := false
+		opts.IsClosed = &isClosed
+	}
+	opts.Page = ctx.QueryInt("page")
+	opts.PageSize = ctx.QueryInt("limit")
+	if opts.PageSize <= 0 {
+		opts.PageSize = setting.API.DefaultPagingNum
+	} else if opts.PageSize > setting.API.MaxResponseItems {
+		opts.PageSize = setting.API.MaxResponseItems
+	}
+
+	projects, _, err := models.FindProjects(opts)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "FindProjects", err)
+		return
+	}
+
+	apiProjects := make([]*api.Project, 0, len(projects))
+	for _, p := range projects {
+		apiProject := ToAPIProject(ctx, p)
+		if apiProject == nil {
+			return
+		}
+		apiProjects = append(apiProjects, apiProject)
+	}
+	ctx.JSON(http.StatusOK, &apiProjects)
+}
+
+// CreateProject creates the project with the fields of the form and writes it to `ctx`.
+// The owner of the project has to be set by the caller
+func CreateProject(ctx *context.APIContext, form *api.CreateProjectOption, p *models.Project) {
+	template := models.ProjectBoardTemplate(form.BoardTemplate)
+	if !template.IsValid() {
+		ctx.Error(http.StatusUnprocessableEntity, "", "Invalid board template")
+		return
+	}
+
+	p.Title = form.Title
+	p.Description = form.Description
+	p.CreatorID = ctx.User.ID
+	if err := models.NewProject(p, template); err != nil {
+		ctx.Error(http.StatusInternalServerError, "NewProject", err)
+		return
+	}
+	if apiProject := ToAPIProject(ctx, p); apiProject != nil {
+		ctx.JSON(http.StatusCreated, apiProject)
+	}
+}
+
+// EditProject updates the project with the fields of the form and writes it to `ctx`
+func EditProject(ctx *context.APIContext, form *api.EditProjectOption, p *models.Project) {
+	if form.Title != nil {
+		if len(*form.Title) == 0 {
+			ctx.Error(http.StatusUnprocessableEntity, "", "Title must not be empty")
+			return
+		}
+		p.Title = *form.Title
+	}
+	if form.Description != nil {
+		p.Description = *form.Description
+	}
+	if err := models.UpdateProject(p); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateProject", err)
+		return
+	}
+
+	if form.State != nil {
+		isClosed := api.StateType(*form.State) == api.StateClosed
+		if isClosed != p.IsClosed {
+			if err := models.ChangeProjectStatus(p, isClosed); err != nil {
+				ctx.Error(http.StatusInternalServerError, "ChangeProjectStatus", err)
+				return
+			}
+		}
+	}
+
+	if apiProject := ToAPIProject(ctx, p); apiProject != nil {
+		ctx.JSON(http.StatusOK, apiProject)
+	}
+}
+
+// ListProjectBoards writes the boards of the project to `ctx`
+func ListProjectBoards(ctx *context.APIContext, p *models.Project) {
+	boards, err := models.GetProjectBoards(p.ID)
+	if err != nil {
+		ctx.Error(http.StatusInternalServerError, "GetProjectBoards", err)
+		return
+	}
+	apiBoards := make([]*api.ProjectBoard, len(boards))
+	for i := range boards {
+		apiBoards[i] = boards[i].APIFormat()
+	}
+	ctx.JSON(http.StatusOK, &apiBoards)
+}
+
+// CreateProjectBoard adds a board to the project and writes it to `ctx`
+func CreateProjectBoard(ctx *context.APIContext, form *api.CreateProjectBoardOption, p *models.Project) {
+	board := &models.ProjectBoard{
+		ProjectID: p.ID,
+		Title:     form.Title,
+		IsDone:    form.IsDone,
+		IsDefault: form.IsDefault,
+	}
+	if err := models.NewProjectBoard(board); err != nil {
+		ctx.Error(http.StatusInternalServerError, "NewProjectBoard", err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, board.APIFormat())
+}
+
+// GetProjectBoard get a board of the project. If there is an error, write to
+// `ctx` accordingly and return the error
+func GetProjectBoard(ctx *context.APIContext, p *models.Project, boardID int64) (*models.ProjectBoard, error) {
+	board, err := models.GetProjectBoard(p.ID, boardID)
+	if err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetProjectBoard", err)
+		}
+		return nil, err
+	}
+	return board, nil
+}
+
+// EditProjectBoard updates the board of the project and writes it to `ctx`
+func EditProjectBoard(ctx *context.APIContext, form *api.EditProjectBoardOption, p *models.Project, boardID int64) {
+	board, err := GetProjectBoard(ctx, p, boardID)
+	if err != nil {
+		return
+	}
+
+	if form.Title != nil {
+		if len(*form.Title) == 0 {
+			ctx.Error(http.StatusUnprocessableEntity, "", "Title must not be empty")
+			return
+		}
+		board.Title = *form.Title
+	}
+	if form.IsDone != nil {
+		board.IsDone = *form.IsDone
+	}
+	if form.IsDefault != nil {
+		board.IsDefault = *form.IsDefault
+	}
+	if form.Sorting != nil {
+		board.Sorting = *form.Sorting
+	}
+
+	if err := models.UpdateProjectBoard(board); err != nil {
+		ctx.Error(http.StatusInternalServerError, "UpdateProjectBoard", err)
+		return
+	}
+	ctx.JSON(http.StatusOK, board.APIFormat())
+}
+
+// DeleteProjectBoard removes the board from the project
+func DeleteProjectBoard(ctx *context.APIContext, p *models.Project, boardID int64) {
+	board, err := GetProjectBoard(ctx, p, boardID)
+	if err != nil {
+		return
+	}
+	if err := models.DeleteProjectBoard(board); err != nil {
+		ctx.Error(http.StatusInternalServerError, "DeleteProjectBoard", err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// ListProjectBoardIssues writes the issues of the board the doer can read to `ctx`.
+// The board id 0 lists the uncategorized issues of the project
+func ListProjectBoardIssues(ctx *context.APIContext, p *models.Project, boardID int64) {
+	var issues []*models.Issue
+	if boardID == 0 {
+		var err error
+		if issues, err = models.GetUncategorizedProjectIssues(p.ID); err != nil {
+			ctx.Error(http.StatusInternalServerError, "GetUncategorizedProjectIssues", err)
+			return
+		}
+	} else {
+		board, err := GetProjectBoard(ctx, p, boardID)
+		if err != nil {
+			return
+		}
+		if err := board.LoadIssues(); err != nil {
+			ctx.Error(http.StatusInternalServerError, "LoadIssues", err)
+			return
+		}
+		issues = board.Issues
+	}
+
+	apiIssues := make([]*api.Issue, 0, len(issues))
+	for _, issue := range issues {
+		canRead, err := canReadIssue(ctx, issue)
+		if err != nil {
+			ctx.Error(http.StatusInternalServerError, "canReadIssue", err)
+			return
+		}
+		if canRead {
+			apiIssues = append(apiIssues, issue.APIFormat())
+		}
+	}
+	ctx.JSON(http.StatusOK, &apiIssues)
+}
+
+// canReadIssue checks if the doer can read the issue, issues of organization
+// projects may belong to repositories the doer has no access to
+func canReadIssue(ctx *context.APIContext, issue *models.Issue) (bool, error) {
+	if err := issue.LoadRepo(); err != nil {
+		return false, err
+	}
+	perm, err := models.GetUserRepoPermission(issue.Repo, ctx.User)
+	if err != nil {
+		return false, err
+	}
+	return perm.CanReadIssuesOrPulls(issue.IsPull), nil
+}
+
+// MoveProjectIssue adds the issue of the form to the project or moves it to another board
+func MoveProjectIssue(ctx *context.APIContext, form *api.MoveProjectIssueOption, p *models.Project) {
+	issue, err := models.GetIssueByID(form.IssueID)
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "GetIssueByID", err)
+		}
+		return
+	}
+	if canRead, err := canReadIssue(ctx, issue); err != nil {
+		ctx.Error(http.StatusInternalServerError, "canReadIssue", err)
+		return
+	} else if !canRead {
+		ctx.Error(http.StatusUnprocessableEntity, "", models.ErrIssueNotExist{ID: form.IssueID})
+		return
+	}
+
+	position := -1
+	if form.Position != nil {
+		position = *form.Position
+	}
+	if err := models.MoveProjectIssue(p, issue, form.BoardID, position); err != nil {
+		if models.IsErrProjectIssueNotAllowed(err) || models.IsErrProjectBoardNotExist(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(http.StatusInternalServerError, "MoveProjectIssue", err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// RemoveProjectIssue removes the issue from the project
+func RemoveProjectIssue(ctx *context.APIContext, p *models.Project, issueID int64) {
+	if err := models.RemoveIssueFromProject(p.ID, issueID); err != nil {
+		if models.IsErrProjectIssueNotExist(err) {
+			ctx.NotFound()
+		} else {
+			ctx.Error(http.StatusInternalServerError, "RemoveIssueFromProject", err)
+		}
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
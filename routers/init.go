@@ -25,8 +25,11 @@ import (
 	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/ssh"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/task"
 	"code.gitea.io/gitea/modules/webhook"
+	actions_service "code.gitea.io/gitea/services/actions"
+	archiver_service "code.gitea.io/gitea/services/archiver"
 	"code.gitea.io/gitea/services/mailer"
 	mirror_service "code.gitea.io/gitea/services/mirror"
 	pull_service "code.gitea.io/gitea/services/pull"
@@ -95,6 +98,9 @@ func GlobalInit(ctx context.Context) {
 		highlight.NewContext()
 		external.RegisterParsers()
 		markup.Init()
+		if err := storage.Init(); err != nil {
+			log.Fatal("Failed to initialize storages: %v", err)
+		}
 		if err := initDBEngine(ctx); err == nil {
 			log.Info("ORM engine initialization successful!")
 		} else {
@@ -117,6 +123,12 @@ func GlobalInit(ctx context.Context) {
 		if err := task.Init(); err != nil {
 			log.Fatal("Failed to initialize task scheduler: %v", err)
 		}
+		if err := actions_service.Init(); err != nil {
+			log.Fatal("Failed to initialize actions: %v", err)
+		}
+		if err := archiver_service.Init(); err != nil {
+			log.Fatal("Failed to initialize repository archiver: %v", err)
+		}
 	}
 	if setting.EnableSQLite3 {
 		log.Info("SQLite3 Supported")
@@ -10,6 +10,7 @@ import (
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/audit"
 
 	"github.com/unknwon/com"
 )
@@ -95,7 +96,13 @@ func MembersAction(ctx *context.Context) {
 			ctx.Error(404)
 			return
 		}
-		err = org.RemoveMember(uid)
+		var member *models.User
+		if member, err = models.GetUserByID(uid); err == nil {
+			err = org.RemoveMember(uid)
+			if err == nil {
+				audit.Record(models.AuditActionOrgMemberRemove, ctx.User, ctx.RemoteAddr(), member, "Removed from organization %s", org.Name)
+			}
+		}
 		if models.IsErrLastOrgOwner(err) {
 			ctx.Flash.Error(ctx.Tr("form.last_org_owner"))
 			ctx.Redirect(ctx.Org.OrgLink + "/members")
@@ -103,6 +110,9 @@ func MembersAction(ctx *context.Context) {
 		}
 	case "leave":
 		err = org.RemoveMember(ctx.User.ID)
+		if err == nil {
+			audit.Record(models.AuditActionOrgMemberRemove, ctx.User, ctx.RemoteAddr(), ctx.User, "Left organization %s", org.Name)
+		}
 		if models.IsErrLastOrgOwner(err) {
 			ctx.Flash.Error(ctx.Tr("form.last_org_owner"))
 			ctx.Redirect(ctx.Org.OrgLink + "/members")
@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/routers/utils"
+	"code.gitea.io/gitea/services/audit"
 
 	"github.com/unknwon/com"
 )
@@ -58,6 +59,8 @@ func TeamsAction(ctx *context.Context) {
 
 	page := ctx.Query("page")
 	var err error
+	var auditAction models.AuditAction
+	member := ctx.User
 	switch ctx.Params(":action") {
 	case "join":
 		if !ctx.Org.IsOwner {
@@ -65,14 +68,20 @@ func TeamsAction(ctx *context.Context) {
 			return
 		}
 		err = ctx.Org.Team.AddMember(ctx.User.ID)
+		auditAction = models.AuditActionTeamMemberAdd
 	case "leave":
 		err = ctx.Org.Team.RemoveMember(ctx.User.ID)
+		auditAction = models.AuditActionTeamMemberRemove
 	case "remove":
 		if !ctx.Org.IsOwner {
 			ctx.Error(404)
 			return
 		}
-		err = ctx.Org.Team.RemoveMember(uid)
+		member, err = models.GetUserByID(uid)
+		if err == nil {
+			err = ctx.Org.Team.RemoveMember(uid)
+		}
+		auditAction = models.AuditActionTeamMemberRemove
 		page = "team"
 	case "add":
 		if !ctx.Org.IsOwner {
@@ -102,6 +111,8 @@ func TeamsAction(ctx *context.Context) {
 			ctx.Flash.Error(ctx.Tr("org.teams.add_duplicate_users"))
 		} else {
 			err = ctx.Org.Team.AddMember(u.ID)
+			auditAction = models.AuditActionTeamMemberAdd
+			member = u
 		}
 
 		page = "team"
@@ -118,6 +129,8 @@ func TeamsAction(ctx *context.Context) {
 			})
 			return
 		}
+	} else if len(auditAction) > 0 {
+		audit.Record(auditAction, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, "Member %s (%s)", member.Name, ctx.Params(":action"))
 	}
 
 	switch page {
@@ -138,10 +151,11 @@ func TeamsRepoAction(ctx *context.Context) {
 	}
 
 	var err error
+	var repoName string
 	action := ctx.Params(":action")
 	switch action {
 	case "add":
-		repoName := path.Base(ctx.Query("repo_name"))
+		repoName = path.Base(ctx.Query("repo_name"))
 		var repo *models.Repository
 		repo, err = models.GetRepositoryByName(ctx.Org.Organization.ID, repoName)
 		if err != nil {
@@ -155,7 +169,12 @@ func TeamsRepoAction(ctx *context.Context) {
 		}
 		err = ctx.Org.Team.AddRepository(repo)
 	case "remove":
-		err = ctx.Org.Team.RemoveRepository(com.StrTo(ctx.Query("repoid")).MustInt64())
+		var repo *models.Repository
+		repo, err = models.GetRepositoryByID(com.StrTo(ctx.Query("repoid")).MustInt64())
+		if err == nil {
+			repoName = repo.Name
+			err = ctx.Org.Team.RemoveRepository(repo.ID)
+		}
 	case "addall":
 		err = ctx.Org.Team.AddAllRepositories()
 	case "removeall":
@@ -167,6 +186,16 @@ func TeamsRepoAction(ctx *context.Context) {
 		ctx.ServerError("TeamsRepoAction", err)
 		return
 	}
+	switch action {
+	case "add":
+		audit.Record(models.AuditActionRepoTeamAdd, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, "Added repository %s/%s", ctx.Org.Organization.Name, repoName)
+	case "remove":
+		audit.Record(models.AuditActionRepoTeamRemove, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, "Removed repository %s/%s", ctx.Org.Organization.Name, repoName)
+	case "addall":
+		audit.Record(models.AuditActionRepoTeamAdd, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, "Added all repositories")
+	case "removeall":
+		audit.Record(models.AuditActionRepoTeamRemove, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, "Removed all repositories")
+	}
 
 	if action == "addall" || action == "removeall" {
 		ctx.JSON(200, map[string]interface{}{
@@ -339,6 +368,7 @@ func EditTeamPost(ctx *context.Context, form auth.CreateTeamForm) {
 		}
 		return
 	}
+	audit.Record(models.AuditActionTeamUpdate, ctx.User, ctx.RemoteAddr(), t, "Updated team, permission: %s, all repositories: %t", t.Authorize, t.IncludesAllRepositories)
 	ctx.Redirect(ctx.Org.OrgLink + "/teams/" + t.LowerName)
 }
 
@@ -347,6 +377,7 @@ func DeleteTeam(ctx *context.Context) {
 	if err := models.DeleteTeam(ctx.Org.Team); err != nil {
 		ctx.Flash.Error("DeleteTeam: " + err.Error())
 	} else {
+		audit.Record(models.AuditActionTeamDelete, ctx.User, ctx.RemoteAddr(), ctx.Org.Team, "Deleted team")
 		ctx.Flash.Success(ctx.Tr("org.teams.delete_team_success"))
 	}
 
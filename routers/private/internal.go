@@ -83,11 +83,13 @@ func RegisterRoutes(m *macaron.Macaron) {
 
 	m.Group("/", func() {
 		m.Post("/ssh/authorized_keys", AuthorizedPublicKeyByContent)
+		m.Post("/ssh/authorized_principals", AuthorizedPrincipalsByCertificate)
 		m.Post("/ssh/:id/update/:repoid", UpdatePublicKeyInRepo)
 		m.Post("/hook/pre-receive/:owner/:repo", bind(private.HookOptions{}), HookPreReceive)
 		m.Post("/hook/post-receive/:owner/:repo", bind(private.HookOptions{}), HookPostReceive)
 		m.Post("/hook/set-default-branch/:owner/:repo/:branch", SetDefaultBranch)
 		m.Get("/serv/none/:keyid", ServNoCommand)
 		m.Get("/serv/command/:keyid/:owner/:repo", ServCommand)
+		m.Post("/restore_repo", bind(private.RestoreRepoOptions{}), RestoreRepo)
 	}, CheckInternalToken)
 }
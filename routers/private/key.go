@@ -6,12 +6,14 @@
 package private
 
 import (
+	"fmt"
 	"net/http"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/timeutil"
 
 	"gitea.com/macaron/macaron"
+	"golang.org/x/crypto/ssh"
 )
 
 // UpdatePublicKeyInRepo update public key and deploy key updates
@@ -61,3 +63,40 @@ func AuthorizedPublicKeyByContent(ctx *macaron.Context) {
 	}
 	ctx.PlainText(http.StatusOK, []byte(publicKey.AuthorizedString()))
 }
+
+// AuthorizedPrincipalsByCertificate checks the SSH certificate in content
+// against the trusted user certificate authorities and returns the
+// authorized principals line for the user it maps to.
+func AuthorizedPrincipalsByCertificate(ctx *macaron.Context) {
+	content := ctx.Query("content")
+
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(content))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{
+			"err": fmt.Sprintf("Unable to parse key: %v", err),
+		})
+		return
+	}
+	cert, ok := pubKey.(*ssh.Certificate)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, map[string]interface{}{
+			"err": "Key is not a certificate",
+		})
+		return
+	}
+
+	user, principal, err := models.GetUserBySSHCertificate(cert)
+	if err != nil {
+		if models.IsErrSSHCertificateNotTrusted(err) {
+			ctx.JSON(http.StatusUnauthorized, map[string]interface{}{
+				"err": err.Error(),
+			})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"err": err.Error(),
+		})
+		return
+	}
+	ctx.PlainText(http.StatusOK, []byte(models.AuthorizedPrincipalString(user, principal)))
+}
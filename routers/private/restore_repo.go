@@ -0,0 +1,60 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package private
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations"
+	"code.gitea.io/gitea/modules/private"
+
+	"gitea.com/macaron/macaron"
+)
+
+// RestoreRepo restores a repository from a dump file
+func RestoreRepo(ctx *macaron.Context, opts private.RestoreRepoOptions) {
+	owner, err := models.GetUserByName(opts.OwnerName)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if models.IsErrUserNotExist(err) {
+			status = http.StatusNotFound
+		}
+		ctx.JSON(status, map[string]interface{}{
+			"err": err.Error(),
+		})
+		return
+	}
+
+	// the repository is created by the owner or, for organizations, by one of its owners
+	doer := owner
+	if owner.IsOrganization() {
+		var members []*models.User
+		t, err := owner.GetOwnerTeam()
+		if err == nil {
+			err = t.GetMembers()
+			members = t.Members
+		}
+		if err != nil || len(members) == 0 {
+			log.Error("Unable to find an owner of %s: %v", owner.Name, err)
+			ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+				"err": "unable to find an owner of the organization",
+			})
+			return
+		}
+		doer = members[0]
+	}
+
+	if _, err := migrations.RestoreRepositoryArchive(graceful.GetManager().HammerContext(), doer, opts.OwnerName, opts.RepoName, opts.File); err != nil {
+		log.Error("Unable to restore %s/%s from %s: %v", opts.OwnerName, opts.RepoName, opts.File, err)
+		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+			"err": err.Error(),
+		})
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
@@ -23,13 +23,36 @@ import (
 // ServNoCommand returns information about the provided keyid
 func ServNoCommand(ctx *macaron.Context) {
 	keyID := ctx.ParamsInt64(":keyid")
-	if keyID <= 0 {
+	userID := ctx.QueryInt64("user_id")
+	if keyID <= 0 && userID <= 0 {
 		ctx.JSON(http.StatusBadRequest, map[string]interface{}{
 			"err": fmt.Sprintf("Bad key id: %d", keyID),
 		})
+		return
 	}
 	results := private.KeyAndOwner{}
 
+	// Users authenticated by SSH certificate have no key
+	if keyID <= 0 {
+		user, err := models.GetUserByID(userID)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				ctx.JSON(http.StatusUnauthorized, map[string]interface{}{
+					"err": fmt.Sprintf("Cannot find user with id: %d", userID),
+				})
+				return
+			}
+			log.Error("Unable to get user with id: %d Error: %v", userID, err)
+			ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
+				"err": err.Error(),
+			})
+			return
+		}
+		results.Owner = user
+		ctx.JSON(http.StatusOK, &results)
+		return
+	}
+
 	key, err := models.GetPublicKeyByID(keyID)
 	if err != nil {
 		if models.IsErrKeyNotExist(err) {
@@ -73,6 +96,7 @@ func ServCommand(ctx *macaron.Context) {
 	ownerName := ctx.Params(":owner")
 	repoName := ctx.Params(":repo")
 	mode := models.AccessMode(ctx.QueryInt("mode"))
+	userID := ctx.QueryInt64("user_id")
 
 	// Set the basic parts of the results to return
 	results := private.ServCommandResults{
@@ -140,31 +164,39 @@ func ServCommand(ctx *macaron.Context) {
 		}
 	}
 
-	// Get the Public Key represented by the keyID
-	key, err := models.GetPublicKeyByID(keyID)
-	if err != nil {
-		if models.IsErrKeyNotExist(err) {
-			ctx.JSON(http.StatusUnauthorized, map[string]interface{}{
+	// Get the Public Key represented by the keyID, users authenticated by
+	// SSH certificate have no key and are passed by their ID instead
+	var key *models.PublicKey
+	ownerID := userID
+	authDesc := "SSH certificate"
+	if keyID > 0 {
+		key, err = models.GetPublicKeyByID(keyID)
+		if err != nil {
+			if models.IsErrKeyNotExist(err) {
+				ctx.JSON(http.StatusUnauthorized, map[string]interface{}{
+					"results": results,
+					"type":    "ErrKeyNotExist",
+					"err":     fmt.Sprintf("Cannot find key: %d", keyID),
+				})
+				return
+			}
+			log.Error("Unable to get public key: %d Error: %v", keyID, err)
+			ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
 				"results": results,
-				"type":    "ErrKeyNotExist",
-				"err":     fmt.Sprintf("Cannot find key: %d", keyID),
+				"type":    "InternalServerError",
+				"err":     fmt.Sprintf("Unable to get key: %d  Error: %v", keyID, err),
 			})
 			return
 		}
-		log.Error("Unable to get public key: %d Error: %v", keyID, err)
-		ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"results": results,
-			"type":    "InternalServerError",
-			"err":     fmt.Sprintf("Unable to get key: %d  Error: %v", keyID, err),
-		})
-		return
+		results.KeyName = key.Name
+		results.KeyID = key.ID
+		ownerID = key.OwnerID
+		authDesc = fmt.Sprintf("Key: %d:%s", key.ID, key.Name)
 	}
-	results.KeyName = key.Name
-	results.KeyID = key.ID
-	results.UserID = key.OwnerID
+	results.UserID = ownerID
 
 	// If repo doesn't exist, deploy key doesn't make sense
-	if !repoExist && key.Type == models.KeyTypeDeploy {
+	if !repoExist && key != nil && key.Type == models.KeyTypeDeploy {
 		ctx.JSON(http.StatusNotFound, map[string]interface{}{
 			"results": results,
 			"type":    "ErrRepoNotExist",
@@ -178,7 +210,7 @@ func ServCommand(ctx *macaron.Context) {
 	// We'll keep hold of the deploy key here for permissions checking
 	var deployKey *models.DeployKey
 	var user *models.User
-	if key.Type == models.KeyTypeDeploy {
+	if key != nil && key.Type == models.KeyTypeDeploy {
 		results.IsDeployKey = true
 
 		var err error
@@ -208,23 +240,23 @@ func ServCommand(ctx *macaron.Context) {
 		results.UserName = results.OwnerName
 		results.UserID = repo.OwnerID
 	} else {
-		// Get the user represented by the Key
+		// Get the user represented by the Key or certificate
 		var err error
-		user, err = models.GetUserByID(key.OwnerID)
+		user, err = models.GetUserByID(ownerID)
 		if err != nil {
 			if models.IsErrUserNotExist(err) {
 				ctx.JSON(http.StatusUnauthorized, map[string]interface{}{
 					"results": results,
 					"type":    "ErrUserNotExist",
-					"err":     fmt.Sprintf("Public Key: %d:%s owner %d does not exist.", key.ID, key.Name, key.OwnerID),
+					"err":     fmt.Sprintf("Public %s owner %d does not exist.", authDesc, ownerID),
 				})
 				return
 			}
-			log.Error("Unable to get owner: %d for public key: %d:%s Error: %v", key.OwnerID, key.ID, key.Name, err)
+			log.Error("Unable to get owner: %d for %s Error: %v", ownerID, authDesc, err)
 			ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
 				"results": results,
 				"type":    "InternalServerError",
-				"err":     fmt.Sprintf("Unable to get Owner: %d for Deploy Key: %d:%s in %s/%s.", key.OwnerID, key.ID, key.Name, ownerName, repoName),
+				"err":     fmt.Sprintf("Unable to get Owner: %d for %s in %s/%s.", ownerID, authDesc, ownerName, repoName),
 			})
 			return
 		}
@@ -243,7 +275,7 @@ func ServCommand(ctx *macaron.Context) {
 
 	// Permissions checking:
 	if repoExist && (mode > models.AccessModeRead || repo.IsPrivate || setting.Service.RequireSignInView) {
-		if key.Type == models.KeyTypeDeploy {
+		if key != nil && key.Type == models.KeyTypeDeploy {
 			if deployKey.Mode < mode {
 				ctx.JSON(http.StatusUnauthorized, map[string]interface{}{
 					"results": results,
@@ -255,11 +287,11 @@ func ServCommand(ctx *macaron.Context) {
 		} else {
 			perm, err := models.GetUserRepoPermission(repo, user)
 			if err != nil {
-				log.Error("Unable to get permissions for %-v with key %d in %-v Error: %v", user, key.ID, repo, err)
+				log.Error("Unable to get permissions for %-v with %s in %-v Error: %v", user, authDesc, repo, err)
 				ctx.JSON(http.StatusInternalServerError, map[string]interface{}{
 					"results": results,
 					"type":    "InternalServerError",
-					"err":     fmt.Sprintf("Unable to get permissions for user %d:%s with key %d in %s/%s Error: %v", user.ID, user.Name, key.ID, results.OwnerName, results.RepoName, err),
+					"err":     fmt.Sprintf("Unable to get permissions for user %d:%s with %s in %s/%s Error: %v", user.ID, user.Name, authDesc, results.OwnerName, results.RepoName, err),
 				})
 				return
 			}
@@ -270,7 +302,7 @@ func ServCommand(ctx *macaron.Context) {
 				ctx.JSON(http.StatusUnauthorized, map[string]interface{}{
 					"results": results,
 					"type":    "ErrUnauthorized",
-					"err":     fmt.Sprintf("User: %d:%s with Key: %d:%s is not authorized to %s %s/%s.", user.ID, user.Name, key.ID, key.Name, modeString, ownerName, repoName),
+					"err":     fmt.Sprintf("User: %d:%s with %s is not authorized to %s %s/%s.", user.ID, user.Name, authDesc, modeString, ownerName, repoName),
 				})
 				return
 			}
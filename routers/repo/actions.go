@@ -0,0 +1,151 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"io"
+	"os"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+	actions_service "code.gitea.io/gitea/services/actions"
+)
+
+const (
+	tplActions   base.TplName = "repo/actions/list"
+	tplActionRun base.TplName = "repo/actions/view"
+)
+
+// MustEnableActions checks if actions are enabled on this instance
+func MustEnableActions(ctx *context.Context) {
+	if !setting.Actions.Enabled {
+		ctx.NotFound("MustEnableActions", nil)
+	}
+}
+
+// Actions renders the list of workflow runs of the repository
+func Actions(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.actions")
+	ctx.Data["PageIsActions"] = true
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	runs, count, err := models.FindActionRuns(&models.FindActionRunOptions{
+		RepoID:   ctx.Repo.Repository.ID,
+		Page:     page,
+		PageSize: setting.UI.IssuePagingNum,
+	})
+	if err != nil {
+		ctx.ServerError("FindActionRuns", err)
+		return
+	}
+	for _, run := range runs {
+		run.Repo = ctx.Repo.Repository
+		if err := run.LoadAttributes(); err != nil {
+			ctx.ServerError("LoadAttributes", err)
+			return
+		}
+	}
+	ctx.Data["Runs"] = runs
+
+	pager := context.NewPagination(int(count), setting.UI.IssuePagingNum, page, 5)
+	pager.SetDefaultParams(ctx)
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplActions)
+}
+
+func getActionRun(ctx *context.Context) *models.ActionRun {
+	run, err := models.GetActionRunByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		ctx.NotFoundOrServerError("GetActionRunByIndex", models.IsErrActionRunNotExist, err)
+		return nil
+	}
+	run.Repo = ctx.Repo.Repository
+	if err := run.LoadAttributes(); err != nil {
+		ctx.ServerError("LoadAttributes", err)
+		return nil
+	}
+	return run
+}
+
+// ViewActionRun renders a workflow run with its jobs
+func ViewActionRun(ctx *context.Context) {
+	run := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	jobs, err := models.GetActionRunJobs(run.ID)
+	if err != nil {
+		ctx.ServerError("GetActionRunJobs", err)
+		return
+	}
+
+	ctx.Data["Title"] = run.Title
+	ctx.Data["PageIsActions"] = true
+	ctx.Data["Run"] = run
+	ctx.Data["Jobs"] = jobs
+	ctx.Data["CanCancel"] = !run.Status.IsDone() && ctx.Repo.CanWrite(models.UnitTypeCode)
+
+	ctx.HTML(200, tplActionRun)
+}
+
+// CancelActionRun cancels all unfinished jobs of a workflow run
+func CancelActionRun(ctx *context.Context) {
+	run := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := actions_service.CancelRun(run); err != nil {
+		ctx.ServerError("CancelRun", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.actions.cancel_success"))
+	ctx.Redirect(run.Link())
+}
+
+// ActionJobLogs serves the raw log of a job
+func ActionJobLogs(ctx *context.Context) {
+	run := getActionRun(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	job, err := models.GetActionRunJobByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		ctx.NotFoundOrServerError("GetActionRunJobByID", models.IsErrActionRunJobNotExist, err)
+		return
+	}
+	if job.RunID != run.ID {
+		ctx.NotFound("ActionJobLogs", nil)
+		return
+	}
+
+	ctx.Resp.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	f, err := actions_service.OpenLog(job)
+	if err != nil {
+		if os.IsNotExist(err) {
+			ctx.Status(200)
+			return
+		}
+		ctx.ServerError("OpenLog", err)
+		return
+	}
+	defer f.Close()
+
+	ctx.Status(200)
+	if _, err := io.Copy(ctx.Resp, f); err != nil {
+		ctx.ServerError("Copy", err)
+	}
+}
@@ -19,7 +19,6 @@ import (
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/highlight"
 	"code.gitea.io/gitea/modules/log"
-	"code.gitea.io/gitea/modules/markup"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
 )
@@ -109,10 +108,6 @@ func RefBlame(ctx *context.Context) {
 	ctx.Data["TreeLink"] = treeLink
 	ctx.Data["TreeNames"] = treeNames
 	ctx.Data["BranchLink"] = branchLink
-	ctx.Data["HighlightClass"] = highlight.FileNameToHighlightClass(entry.Name())
-	if !markup.IsReadmeFile(blob.Name()) {
-		ctx.Data["RequireHighlightJS"] = true
-	}
 	ctx.Data["RawFileLink"] = rawLink + "/" + ctx.Repo.TreePath
 	ctx.Data["PageIsViewCode"] = true
 
@@ -194,12 +189,24 @@ func RefBlame(ctx *context.Context) {
 		commitNames[c.ID.String()] = c
 	}
 
-	renderBlame(ctx, blameParts, commitNames)
+	renderBlame(ctx, blameParts, commitNames, highlight.File(blob.Name(), linguistLanguage(ctx), blameContent(blameParts)))
 
 	ctx.HTML(200, tplBlame)
 }
 
-func renderBlame(ctx *context.Context, blameParts []git.BlamePart, commitNames map[string]models.UserCommit) {
+// blameContent joins the lines of all parts of a blame to highlight them as a whole.
+func blameContent(blameParts []git.BlamePart) []byte {
+	var content bytes.Buffer
+	for _, part := range blameParts {
+		for _, line := range part.Lines {
+			content.WriteString(line)
+			content.WriteByte('\n')
+		}
+	}
+	return content.Bytes()
+}
+
+func renderBlame(ctx *context.Context, blameParts []git.BlamePart, commitNames map[string]models.UserCommit, highlighted []string) {
 	repoLink := ctx.Repo.RepoLink
 
 	var lines = make([]string, 0)
@@ -245,7 +252,7 @@ func renderBlame(ctx *context.Context, blameParts []git.BlamePart, commitNames m
 			}
 
 			//Code line
-			line = gotemplate.HTMLEscapeString(line)
+			line = highlighted[i-1]
 			if i != len(lines)-1 {
 				line += "\n"
 			}
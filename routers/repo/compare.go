@@ -8,16 +8,19 @@ import (
 	"bufio"
 	"fmt"
 	"html"
+	"html/template"
+	"io/ioutil"
 	"path"
-	"path/filepath"
 	"strings"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/charset"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/git"
-	"code.gitea.io/gitea/modules/highlight"
+	"code.gitea.io/gitea/modules/issuetemplate"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/markup"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/services/gitdiff"
 )
@@ -66,6 +69,55 @@ func setImageCompareContext(ctx *context.Context, base *git.Commit, head *git.Co
 	}
 }
 
+// setMarkupCompareContext sets context data that is required by the rich diff of markup
+// files, it must be called after setPathsCompareContext.
+func setMarkupCompareContext(ctx *context.Context, base *git.Commit, head *git.Commit) {
+	ctx.Data["IsRenderableFile"] = func(name string) bool {
+		return markup.Type(name) != ""
+	}
+	ctx.Data["RenderMarkupInBase"] = func(name string) template.HTML {
+		sourcePath, _ := ctx.Data["BeforeSourcePath"].(string)
+		return renderMarkupInCommit(ctx, base, name, sourcePath)
+	}
+	ctx.Data["RenderMarkupInHead"] = func(name string) template.HTML {
+		sourcePath, _ := ctx.Data["SourcePath"].(string)
+		return renderMarkupInCommit(ctx, head, name, sourcePath)
+	}
+}
+
+// renderMarkupInCommit renders a markup file as it is in the given commit, links are
+// relative to the source path of the commit.
+func renderMarkupInCommit(ctx *context.Context, commit *git.Commit, treePath, sourcePath string) template.HTML {
+	if commit == nil {
+		return ""
+	}
+	entry, err := commit.GetTreeEntryByPath(treePath)
+	if err != nil {
+		if !git.IsErrNotExist(err) {
+			log.Error("GetTreeEntryByPath(%s): %v", treePath, err)
+		}
+		return ""
+	}
+	blob := entry.Blob()
+	if blob.Size() >= setting.UI.MaxDisplayFileSize {
+		return ""
+	}
+	dataRc, err := blob.DataAsync()
+	if err != nil {
+		log.Error("DataAsync: %v", err)
+		return ""
+	}
+	defer dataRc.Close()
+	buf, err := ioutil.ReadAll(dataRc)
+	if err != nil {
+		log.Error("ReadAll: %v", err)
+		return ""
+	}
+
+	urlPrefix := path.Join(sourcePath, path.Dir(treePath))
+	return template.HTML(markup.Render(treePath, charset.ToUTF8WithFallback(buf), urlPrefix, ctx.Repo.Repository.ComposeMetas()))
+}
+
 // ParseCompareInfo parse compare info between two commit for preparing comparing references
 func ParseCompareInfo(ctx *context.Context) (*models.User, *models.Repository, *git.Repository, *git.CompareInfo, string, string) {
 	baseRepo := ctx.Repo.Repository
@@ -347,6 +399,7 @@ func PrepareCompareDiff(
 	setImageCompareContext(ctx, baseCommit, headCommit)
 	headTarget := path.Join(headUser.Name, repo.Name)
 	setPathsCompareContext(ctx, baseCommit, headCommit, headTarget)
+	setMarkupCompareContext(ctx, baseCommit, headCommit)
 
 	return false
 }
@@ -435,7 +488,19 @@ func CompareDiff(ctx *context.Context) {
 	ctx.Data["RequireTribute"] = true
 	ctx.Data["RequireSimpleMDE"] = true
 	ctx.Data["PullRequestWorkInProgressPrefixes"] = setting.Repository.PullRequest.WorkInProgressPrefixes
-	setTemplateIfExists(ctx, pullRequestTemplateKey, pullRequestTemplateCandidates)
+	var t *issuetemplate.Template
+	if ctx.Data["PageIsComparePull"] == true && !nothingToCompare {
+		templates := getTemplatesFromDefaultBranch(ctx, pullRequestTemplateDirCandidates)
+		ctx.Data["PullRequestTemplates"] = templateChoices(ctx, templates)
+		if templateFile := ctx.Query("template"); templateFile != "" {
+			t = findTemplate(templates, templateFile)
+		}
+	}
+	if t != nil {
+		setTemplateData(ctx, pullRequestTemplateKey, t)
+	} else {
+		setTemplateIfExists(ctx, pullRequestTemplateKey, pullRequestTemplateCandidates)
+	}
 	renderAttachmentSettings(ctx)
 
 	ctx.HTML(200, tplCompare)
@@ -461,7 +526,13 @@ func ExcerptBlob(ctx *context.Context) {
 		return
 	}
 	section := &gitdiff.DiffSection{
-		Name: filePath,
+		FileName: filePath,
+		Name:     filePath,
+	}
+	if languages, err := gitRepo.LinguistLanguages(commitID, filePath); err != nil {
+		log.Error("LinguistLanguages(%s): %v", filePath, err)
+	} else {
+		section.Language = languages[filePath]
 	}
 	if direction == "up" && (idxLeft-lastLeft) > chunkSize {
 		idxLeft -= chunkSize
@@ -510,7 +581,6 @@ func ExcerptBlob(ctx *context.Context) {
 	}
 	ctx.Data["section"] = section
 	ctx.Data["fileName"] = filePath
-	ctx.Data["highlightClass"] = highlight.FileNameToHighlightClass(filepath.Base(filePath))
 	ctx.Data["AfterCommitID"] = commitID
 	ctx.Data["Anchor"] = anchor
 	ctx.HTML(200, tplBlobExcerpt)
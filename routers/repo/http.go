@@ -27,7 +27,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/process"
 	"code.gitea.io/gitea/modules/setting"
-	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/services/audit"
 	repo_service "code.gitea.io/gitea/services/repository"
 )
 
@@ -206,11 +206,18 @@ func HTTP(ctx *context.Context) {
 						return
 					}
 				}
-				token.UpdatedUnix = timeutil.TimeStampNow()
-				if err = models.UpdateAccessToken(token); err != nil {
-					ctx.ServerError("UpdateAccessToken", err)
+				requiredScope := models.AccessTokenScopeRepoWrite
+				if isPull {
+					requiredScope = models.AccessTokenScopeRepoRead
 				}
-			} else if !models.IsErrAccessTokenNotExist(err) && !models.IsErrAccessTokenEmpty(err) {
+				if !token.Scope.Has(requiredScope) {
+					ctx.HandleText(http.StatusForbidden, "The access token does not have the required scope: "+string(requiredScope))
+					return
+				}
+				if err = models.UpdateAccessTokenLastUsed(token); err != nil {
+					ctx.ServerError("UpdateAccessTokenLastUsed", err)
+				}
+			} else if !models.IsErrAccessTokenNotExist(err) && !models.IsErrAccessTokenEmpty(err) && !models.IsErrAccessTokenExpired(err) {
 				log.Error("GetAccessTokenBySha: %v", err)
 			}
 
@@ -218,6 +225,12 @@ func HTTP(ctx *context.Context) {
 				// Check username and password
 				authUser, err = models.UserSignIn(authUsername, authPasswd)
 				if err != nil {
+					if isUsernameToken {
+						// the username may be an invalid access token, it must not be stored in the audit log
+						audit.Record(models.AuditActionUserLoginFailed, nil, ctx.RemoteAddr(), nil, "Failed basic authentication with an access token")
+					} else {
+						audit.Record(models.AuditActionUserLoginFailed, nil, ctx.RemoteAddr(), authUsername, "Failed basic authentication: %v", err)
+					}
 					if models.IsErrUserProhibitLogin(err) {
 						ctx.HandleText(http.StatusForbidden, "User is not permitted to login")
 						return
@@ -234,6 +247,7 @@ func HTTP(ctx *context.Context) {
 
 				_, err = models.GetTwoFactorByUID(authUser.ID)
 				if err == nil {
+					audit.Record(models.AuditActionUserLoginFailed, nil, ctx.RemoteAddr(), authUser, "Failed basic authentication: two-factor authentication is enabled")
 					// TODO: This response should be changed to "invalid credentials" for security reasons once the expectation behind it (creating an app token to authenticate) is properly documented
 					ctx.HandleText(http.StatusUnauthorized, "Users with two-factor authentication enabled cannot perform HTTP/HTTPS operations via plain username and password. Please create and use a personal access token on the user settings page")
 					return
@@ -241,6 +255,7 @@ func HTTP(ctx *context.Context) {
 					ctx.ServerError("IsErrTwoFactorNotEnrolled", err)
 					return
 				}
+				audit.Record(models.AuditActionUserLogin, authUser, ctx.RemoteAddr(), authUser, "Signed in with basic authentication")
 			}
 		}
 
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"html/template"
 	"io/ioutil"
 	"net/http"
 	"strconv"
@@ -20,6 +21,7 @@ import (
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/git"
 	issue_indexer "code.gitea.io/gitea/modules/indexer/issues"
+	"code.gitea.io/gitea/modules/issuetemplate"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/markup"
 	"code.gitea.io/gitea/modules/markup/markdown"
@@ -36,9 +38,10 @@ import (
 const (
 	tplAttachment base.TplName = "repo/issue/view_content/attachments"
 
-	tplIssues    base.TplName = "repo/issue/list"
-	tplIssueNew  base.TplName = "repo/issue/new"
-	tplIssueView base.TplName = "repo/issue/view"
+	tplIssues      base.TplName = "repo/issue/list"
+	tplIssueNew    base.TplName = "repo/issue/new"
+	tplIssueChoose base.TplName = "repo/issue/choose"
+	tplIssueView   base.TplName = "repo/issue/view"
 
 	tplReactions base.TplName = "repo/issue/view_content/reactions"
 
@@ -57,6 +60,13 @@ var (
 		".github/ISSUE_TEMPLATE.md",
 		".github/issue_template.md",
 	}
+	// IssueTemplateDirCandidates directories holding multiple issue templates
+	IssueTemplateDirCandidates = []string{
+		".gitea/ISSUE_TEMPLATE",
+		".gitea/issue_template",
+		".github/ISSUE_TEMPLATE",
+		".github/issue_template",
+	}
 )
 
 // MustAllowUserComment checks to make sure if an issue is locked.
@@ -247,9 +257,9 @@ func issues(ctx *context.Context, milestoneID int64, isPullOption util.OptionalB
 		return
 	}
 
-	labels, err := models.GetLabelsByRepoID(repo.ID, "")
+	labels, err := models.GetLabelsUsableInRepo(repo)
 	if err != nil {
-		ctx.ServerError("GetLabelsByRepoID", err)
+		ctx.ServerError("GetLabelsUsableInRepo", err)
 		return
 	}
 	for _, l := range labels {
@@ -345,6 +355,7 @@ func RetrieveRepoMilestonesAndAssignees(ctx *context.Context, repo *models.Repos
 		ctx.ServerError("GetAssignees", err)
 		return
 	}
+	ctx.Data["SelectedAssignees"] = map[int64]bool{}
 }
 
 // RetrieveRepoMetas find all the meta information of a repository
@@ -353,9 +364,9 @@ func RetrieveRepoMetas(ctx *context.Context, repo *models.Repository) []*models.
 		return nil
 	}
 
-	labels, err := models.GetLabelsByRepoID(repo.ID, "")
+	labels, err := models.GetLabelsUsableInRepo(repo)
 	if err != nil {
-		ctx.ServerError("GetLabelsByRepoID", err)
+		ctx.ServerError("GetLabelsUsableInRepo", err)
 		return nil
 	}
 	ctx.Data["Labels"] = labels
@@ -378,34 +389,209 @@ func RetrieveRepoMetas(ctx *context.Context, repo *models.Repository) []*models.
 	return labels
 }
 
-func getFileContentFromDefaultBranch(ctx *context.Context, filename string) (string, bool) {
-	var bytes []byte
-
+func loadDefaultBranchCommit(ctx *context.Context) bool {
 	if ctx.Repo.Commit == nil {
 		var err error
 		ctx.Repo.Commit, err = ctx.Repo.GitRepo.GetBranchCommit(ctx.Repo.Repository.DefaultBranch)
 		if err != nil {
-			return "", false
+			return false
 		}
 	}
+	return true
+}
 
-	entry, err := ctx.Repo.Commit.GetTreeEntryByPath(filename)
+func readTemplateBlob(blob *git.Blob) ([]byte, bool) {
+	if blob.Size() >= setting.UI.MaxDisplayFileSize {
+		return nil, false
+	}
+	r, err := blob.DataAsync()
 	if err != nil {
-		return "", false
+		return nil, false
 	}
-	if entry.Blob().Size() >= setting.UI.MaxDisplayFileSize {
+	defer r.Close()
+	bytes, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, false
+	}
+	return bytes, true
+}
+
+func getFileContentFromDefaultBranch(ctx *context.Context, filename string) (string, bool) {
+	if !loadDefaultBranchCommit(ctx) {
 		return "", false
 	}
-	r, err := entry.Blob().DataAsync()
+
+	entry, err := ctx.Repo.Commit.GetTreeEntryByPath(filename)
 	if err != nil {
 		return "", false
 	}
-	defer r.Close()
-	bytes, err = ioutil.ReadAll(r)
+	bytes, found := readTemplateBlob(entry.Blob())
+	return string(bytes), found
+}
+
+// getTemplatesFromDefaultBranch returns the templates of the first template
+// directory which exists on the default branch, invalid templates are skipped
+func getTemplatesFromDefaultBranch(ctx *context.Context, possibleDirs []string) []*issuetemplate.Template {
+	if !loadDefaultBranchCommit(ctx) {
+		return nil
+	}
+
+	for _, dir := range possibleDirs {
+		tree, err := ctx.Repo.Commit.SubTree(dir)
+		if err != nil {
+			continue
+		}
+		entries, err := tree.ListEntries()
+		if err != nil {
+			log.Error("ListEntries: %v", err)
+			return nil
+		}
+
+		templates := make([]*issuetemplate.Template, 0, len(entries))
+		for _, entry := range entries {
+			if !(entry.IsRegular() || entry.IsExecutable()) || !issuetemplate.IsTemplateFile(entry.Name()) {
+				continue
+			}
+			content, found := readTemplateBlob(entry.Blob())
+			if !found {
+				continue
+			}
+			t, err := issuetemplate.Unmarshal(entry.Name(), content)
+			if err != nil {
+				log.Warn("Invalid template %s/%s in %s: %v", dir, entry.Name(), ctx.Repo.Repository.FullName(), err)
+				continue
+			}
+			templates = append(templates, t)
+		}
+		return templates
+	}
+	return nil
+}
+
+func findTemplate(templates []*issuetemplate.Template, filename string) *issuetemplate.Template {
+	for _, t := range templates {
+		if t.FileName == filename {
+			return t
+		}
+	}
+	return nil
+}
+
+func getTemplateFromDefaultBranch(ctx *context.Context, possibleDirs []string, filename string) *issuetemplate.Template {
+	return findTemplate(getTemplatesFromDefaultBranch(ctx, possibleDirs), filename)
+}
+
+// issueTemplateChoice is a template offered by the template chooser
+type issueTemplateChoice struct {
+	*issuetemplate.Template
+	Link string
+}
+
+// templateChoices returns the templates with links to the current page using them
+func templateChoices(ctx *context.Context, templates []*issuetemplate.Template) []issueTemplateChoice {
+	choices := make([]issueTemplateChoice, 0, len(templates))
+	for _, t := range templates {
+		query := ctx.Req.URL.Query()
+		query.Del("blank")
+		query.Set("template", t.FileName)
+		choices = append(choices, issueTemplateChoice{
+			Template: t,
+			Link:     ctx.Link + "?" + query.Encode(),
+		})
+	}
+	return choices
+}
+
+func setTemplateForm(ctx *context.Context, t *issuetemplate.Template) {
+	ctx.Data["TemplateFile"] = t.FileName
+	ctx.Data["IssueForm"] = t
+	markdowns := make(map[string]template.HTML)
+	for _, f := range t.Fields {
+		if f.Type == issuetemplate.FieldTypeMarkdown {
+			markdowns[f.ID] = template.HTML(markdown.RenderString(f.Attributes.Value, ctx.Repo.RepoLink, ctx.Repo.Repository.ComposeMetas()))
+		}
+	}
+	ctx.Data["IssueFormMarkdowns"] = markdowns
+}
+
+// setTemplateData fills the new issue or pull request form from a template,
+// it has to be called after RetrieveRepoMetas
+func setTemplateData(ctx *context.Context, ctxDataKey string, t *issuetemplate.Template) {
+	if t.IsForm() {
+		setTemplateForm(ctx, t)
+	} else {
+		ctx.Data["TemplateFile"] = t.FileName
+		ctx.Data[ctxDataKey] = t.Content
+	}
+
+	title, _ := ctx.Data["title"].(string)
+	if !strings.HasPrefix(title, t.Title) {
+		ctx.Data["title"] = t.Title + title
+	}
+
+	if len(t.Labels) > 0 {
+		labelIDs, err := models.GetLabelIDsInRepoOrOrgByNames(ctx.Repo.Repository, t.Labels)
+		if err != nil {
+			log.Error("GetLabelIDsInRepoOrOrgByNames: %v", err)
+		}
+		checked := base.Int64sToMap(labelIDs)
+		if labels, ok := ctx.Data["Labels"].([]*models.Label); ok {
+			for _, label := range labels {
+				label.IsChecked = checked[label.ID]
+			}
+		}
+		ctx.Data["HasSelectedLabel"] = len(labelIDs) > 0
+		ctx.Data["label_ids"] = joinInt64s(labelIDs)
+	}
+
+	if len(t.Assignees) > 0 {
+		assignees, err := ctx.Repo.Repository.GetAssignees()
+		if err != nil {
+			log.Error("GetAssignees: %v", err)
+		}
+		selected := make(map[int64]bool, len(t.Assignees))
+		assigneeIDs := make([]int64, 0, len(t.Assignees))
+		for _, name := range t.Assignees {
+			for _, assignee := range assignees {
+				if strings.EqualFold(assignee.Name, name) && !selected[assignee.ID] {
+					selected[assignee.ID] = true
+					assigneeIDs = append(assigneeIDs, assignee.ID)
+				}
+			}
+		}
+		ctx.Data["SelectedAssignees"] = selected
+		ctx.Data["HasSelectedAssignee"] = len(assigneeIDs) > 0
+		ctx.Data["assignee_ids"] = joinInt64s(assigneeIDs)
+	}
+}
+
+func joinInt64s(ids []int64) string {
+	strs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strs = append(strs, com.ToStr(id))
+	}
+	return strings.Join(strs, ",")
+}
+
+// renderTemplateForm turns the answers of an issue form into the content of
+// the issue. When a required answer is missing, the form is prepared to be
+// shown again and ErrFieldRequired is returned.
+func renderTemplateForm(ctx *context.Context, possibleDirs []string, form *auth.CreateIssueForm) error {
+	if form.TemplateFile == "" {
+		return nil
+	}
+	t := getTemplateFromDefaultBranch(ctx, possibleDirs, form.TemplateFile)
+	if t == nil || !t.IsForm() {
+		return nil
+	}
+	content, err := t.RenderToMarkdown(ctx.Req.Form)
 	if err != nil {
-		return "", false
+		setTemplateForm(ctx, t)
+		ctx.Data["IssueFormValues"] = ctx.Req.Form
+		return err
 	}
-	return string(bytes), true
+	form.Content = content
+	return nil
 }
 
 func setTemplateIfExists(ctx *context.Context, ctxDataKey string, possibleFiles []string) {
@@ -440,7 +626,18 @@ func NewIssue(ctx *context.Context) {
 		}
 	}
 
-	setTemplateIfExists(ctx, issueTemplateKey, IssueTemplateCandidates)
+	templateFile := ctx.Query("template")
+	if templateFile == "" && len(body) == 0 && !ctx.QueryBool("blank") {
+		if templates := getTemplatesFromDefaultBranch(ctx, IssueTemplateDirCandidates); len(templates) > 0 {
+			query := ctx.Req.URL.Query()
+			query.Set("blank", "true")
+			ctx.Data["IssueTemplates"] = templateChoices(ctx, templates)
+			ctx.Data["BlankIssueLink"] = ctx.Link + "?" + query.Encode()
+			ctx.HTML(200, tplIssueChoose)
+			return
+		}
+	}
+
 	renderAttachmentSettings(ctx)
 
 	RetrieveRepoMetas(ctx, ctx.Repo.Repository)
@@ -448,6 +645,16 @@ func NewIssue(ctx *context.Context) {
 		return
 	}
 
+	var t *issuetemplate.Template
+	if templateFile != "" {
+		t = getTemplateFromDefaultBranch(ctx, IssueTemplateDirCandidates, templateFile)
+	}
+	if t != nil {
+		setTemplateData(ctx, issueTemplateKey, t)
+	} else {
+		setTemplateIfExists(ctx, issueTemplateKey, IssueTemplateCandidates)
+	}
+
 	ctx.HTML(200, tplIssueNew)
 }
 
@@ -566,6 +773,15 @@ func NewIssuePost(ctx *context.Context, form auth.CreateIssueForm) {
 		return
 	}
 
+	if err := renderTemplateForm(ctx, IssueTemplateDirCandidates, &form); err != nil {
+		if issuetemplate.IsErrFieldRequired(err) {
+			ctx.RenderWithErr(ctx.Tr("repo.issues.new.form_field_required", err.(issuetemplate.ErrFieldRequired).Label), tplIssueNew, form)
+			return
+		}
+		ctx.ServerError("RenderToMarkdown", err)
+		return
+	}
+
 	issue := &models.Issue{
 		RepoID:      repo.ID,
 		Title:       form.Title,
@@ -731,9 +947,9 @@ func ViewIssue(ctx *context.Context) {
 	for i := range issue.Labels {
 		labelIDMark[issue.Labels[i].ID] = true
 	}
-	labels, err := models.GetLabelsByRepoID(repo.ID, "")
+	labels, err := models.GetLabelsUsableInRepo(repo)
 	if err != nil {
-		ctx.ServerError("GetLabelsByRepoID", err)
+		ctx.ServerError("GetLabelsUsableInRepo", err)
 		return
 	}
 	hasSelected := false
@@ -870,6 +1086,15 @@ func ViewIssue(ctx *context.Context) {
 				ctx.ServerError("LoadAssigneeUser", err)
 				return
 			}
+		} else if comment.Type == models.CommentTypeReviewRequest {
+			if err = comment.LoadAssigneeUser(); err != nil {
+				ctx.ServerError("LoadAssigneeUser", err)
+				return
+			}
+			if err = comment.LoadAssigneeTeam(); err != nil {
+				ctx.ServerError("LoadAssigneeTeam", err)
+				return
+			}
 		} else if comment.Type == models.CommentTypeRemoveDependency || comment.Type == models.CommentTypeAddDependency {
 			if err = comment.LoadDepIssueDetails(); err != nil {
 				ctx.ServerError("LoadDepIssueDetails", err)
@@ -964,8 +1189,30 @@ func ViewIssue(ctx *context.Context) {
 			cnt := pull.ProtectedBranch.GetGrantedApprovalsCount(pull)
 			ctx.Data["IsBlockedByApprovals"] = !pull.ProtectedBranch.HasEnoughApprovals(pull)
 			ctx.Data["IsBlockedByRejection"] = pull.ProtectedBranch.MergeBlockedByRejectedReview(pull)
+			ctx.Data["IsBlockedByCodeOwners"] = pull.ProtectedBranch.MergeBlockedByCodeOwners(pull)
 			ctx.Data["GrantedApprovals"] = cnt
 		}
+		ctx.Data["CanScheduleAutoMerge"] = ctx.IsSigned && ctx.Repo.CanWrite(models.UnitTypeCode) &&
+			(pull.ProtectedBranch == nil || pull.ProtectedBranch.CanUserMerge(ctx.User.ID))
+		ctx.Data["AutoMergeScheduled"], ctx.Data["AutoMerge"], err = models.GetScheduledAutoMergeByPullID(pull.ID)
+		if err != nil {
+			ctx.ServerError("GetScheduledAutoMergeByPullID", err)
+			return
+		}
+		ctx.Data["EnableMergeQueue"] = pull.ProtectedBranch != nil && pull.ProtectedBranch.EnableMergeQueue
+		inMergeQueue, mergeQueueEntry, err := models.GetMergeQueueEntryByPullID(pull.ID)
+		if err != nil {
+			ctx.ServerError("GetMergeQueueEntryByPullID", err)
+			return
+		}
+		if inMergeQueue {
+			ctx.Data["MergeQueueEntry"] = mergeQueueEntry
+			ctx.Data["MergeQueuePosition"], err = models.GetMergeQueuePosition(mergeQueueEntry)
+			if err != nil {
+				ctx.ServerError("GetMergeQueuePosition", err)
+				return
+			}
+		}
 		ctx.Data["IsPullBranchDeletable"] = canDelete &&
 			pull.HeadRepo != nil &&
 			git.IsBranchExist(pull.HeadRepo.RepoPath(), pull.HeadBranch) &&
@@ -976,6 +1223,11 @@ func ViewIssue(ctx *context.Context) {
 			ctx.ServerError("GetReviewersByIssueID", err)
 			return
 		}
+		ctx.Data["PullTeamReviewRequests"], err = models.GetTeamReviewRequestsByIssueID(issue.ID)
+		if err != nil {
+			ctx.ServerError("GetTeamReviewRequestsByIssueID", err)
+			return
+		}
 	}
 
 	// Get Dependencies
@@ -1406,7 +1658,7 @@ func UpdateCommentContent(ctx *context.Context) {
 	}
 
 	ctx.JSON(200, map[string]interface{}{
-		"content":     string(markdown.Render([]byte(comment.Content), ctx.Query("context"), ctx.Repo.Repository.ComposeMetas())),
+		"content":     string(markdown.Render([]byte(comment.ContentWithSuggestionDiffs()), ctx.Query("context"), ctx.Repo.Repository.ComposeMetas())),
 		"attachments": attachmentsHTML(ctx, comment.Attachments),
 	})
 }
@@ -10,6 +10,7 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
 	issue_service "code.gitea.io/gitea/services/issue"
 )
 
@@ -35,7 +36,7 @@ func InitializeLabels(ctx *context.Context, form auth.InitializeLabelsForm) {
 		return
 	}
 
-	if err := models.InitalizeLabels(ctx.Repo.Repository.ID, form.TemplateName); err != nil {
+	if err := models.InitalizeLabels(ctx.Repo.Repository.ID, form.TemplateName, false); err != nil {
 		if models.IsErrIssueLabelTemplateLoad(err) {
 			originalErr := err.(models.ErrIssueLabelTemplateLoad).OriginalError
 			ctx.Flash.Error(ctx.Tr("repo.issues.label_templates.fail_to_load_file", form.TemplateName, originalErr))
@@ -48,7 +49,7 @@ func InitializeLabels(ctx *context.Context, form auth.InitializeLabelsForm) {
 	ctx.Redirect(ctx.Repo.RepoLink + "/labels")
 }
 
-// RetrieveLabels find all the labels of a repository
+// RetrieveLabels find all the labels of a repository and its organization
 func RetrieveLabels(ctx *context.Context) {
 	labels, err := models.GetLabelsByRepoID(ctx.Repo.Repository.ID, ctx.Query("sort"))
 	if err != nil {
@@ -59,6 +60,30 @@ func RetrieveLabels(ctx *context.Context) {
 		l.CalOpenIssues()
 	}
 	ctx.Data["Labels"] = labels
+
+	if ctx.Repo.Owner.IsOrganization() {
+		orgLabels, err := models.GetLabelsByOrgID(ctx.Repo.Owner.ID, ctx.Query("sort"))
+		if err != nil {
+			ctx.ServerError("GetLabelsByOrgID", err)
+			return
+		}
+		if err = models.CalOrgLabelsRepoIssues(ctx.Repo.Repository.ID, orgLabels); err != nil {
+			ctx.ServerError("CalOrgLabelsRepoIssues", err)
+			return
+		}
+		ctx.Data["OrgLabels"] = orgLabels
+
+		if ctx.User != nil {
+			isOwner, err := ctx.Repo.Owner.IsOwnedBy(ctx.User.ID)
+			if err != nil {
+				ctx.ServerError("IsOwnedBy", err)
+				return
+			}
+			ctx.Data["IsOrganizationOwner"] = isOwner || ctx.User.IsAdmin
+			ctx.Data["OrganizationLink"] = setting.AppSubURL + "/org/" + ctx.Repo.Owner.Name
+		}
+		labels = append(labels, orgLabels...)
+	}
 	ctx.Data["NumLabels"] = len(labels)
 	ctx.Data["SortType"] = ctx.Query("sort")
 }
@@ -89,7 +114,7 @@ func NewLabel(ctx *context.Context, form auth.CreateLabelForm) {
 
 // UpdateLabel update a label's name and color
 func UpdateLabel(ctx *context.Context, form auth.CreateLabelForm) {
-	l, err := models.GetLabelByID(form.ID)
+	l, err := models.GetLabelInRepoByID(ctx.Repo.Repository.ID, form.ID)
 	if err != nil {
 		switch {
 		case models.IsErrLabelNotExist(err):
@@ -112,7 +137,7 @@ func UpdateLabel(ctx *context.Context, form auth.CreateLabelForm) {
 
 // DeleteLabel delete a label
 func DeleteLabel(ctx *context.Context) {
-	if err := models.DeleteLabel(ctx.Repo.Repository.ID, ctx.QueryInt64("id")); err != nil {
+	if err := models.DeleteRepoLabel(ctx.Repo.Repository.ID, ctx.QueryInt64("id")); err != nil {
 		ctx.Flash.Error("DeleteLabel: " + err.Error())
 	} else {
 		ctx.Flash.Success(ctx.Tr("repo.issues.label_deletion_success"))
@@ -148,6 +173,10 @@ func UpdateIssueLabel(ctx *context.Context) {
 			}
 			return
 		}
+		if !label.CanBeUsedInRepo(ctx.Repo.Repository) {
+			ctx.Error(404, "GetLabelByID")
+			return
+		}
 
 		if action == "toggle" {
 			// detach if any issues already have label, otherwise attach
@@ -52,6 +52,7 @@ func TestRetrieveLabels(t *testing.T) {
 		{1, "", []int64{1, 2}},
 		{1, "leastissues", []int64{2, 1}},
 		{2, "", []int64{}},
+		{3, "", []int64{3, 4}},
 	} {
 		ctx := test.MockContext(t, "user/repo/issues")
 		test.LoadUser(t, ctx, 2)
@@ -61,6 +62,9 @@ func TestRetrieveLabels(t *testing.T) {
 		assert.False(t, ctx.Written())
 		labels, ok := ctx.Data["Labels"].([]*models.Label)
 		assert.True(t, ok)
+		if orgLabels, ok := ctx.Data["OrgLabels"].([]*models.Label); ok {
+			labels = append(labels, orgLabels...)
+		}
 		if assert.Len(t, labels, len(testCase.ExpectedLabelIDs)) {
 			for i, label := range labels {
 				assert.EqualValues(t, testCase.ExpectedLabelIDs[i], label.ID)
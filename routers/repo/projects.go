@@ -0,0 +1,412 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/markup/markdown"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplProjects    base.TplName = "repo/projects/list"
+	tplProjectsNew base.TplName = "repo/projects/new"
+	tplProjectView base.TplName = "repo/projects/view"
+)
+
+// Projects renders the projects page of the repository
+func Projects(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects")
+	ctx.Data["PageIsProjects"] = true
+
+	isShowClosed := ctx.Query("state") == "closed"
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	projects, count, err := models.FindProjects(&models.FindProjectsOptions{
+		RepoID:   ctx.Repo.Repository.ID,
+		Type:     models.ProjectTypeRepository,
+		IsClosed: &isShowClosed,
+		Page:     page,
+		PageSize: setting.UI.IssuePagingNum,
+	})
+	if err != nil {
+		ctx.ServerError("FindProjects", err)
+		return
+	}
+
+	isOpen := !isShowClosed
+	otherCount, err := models.CountProjects(&models.FindProjectsOptions{
+		RepoID:   ctx.Repo.Repository.ID,
+		Type:     models.ProjectTypeRepository,
+		IsClosed: &isOpen,
+	})
+	if err != nil {
+		ctx.ServerError("CountProjects", err)
+		return
+	}
+	if isShowClosed {
+		ctx.Data["OpenCount"], ctx.Data["ClosedCount"] = otherCount, count
+		ctx.Data["State"] = "closed"
+	} else {
+		ctx.Data["OpenCount"], ctx.Data["ClosedCount"] = count, otherCount
+		ctx.Data["State"] = "open"
+	}
+
+	for _, p := range projects {
+		p.RenderedContent = string(markdown.Render([]byte(p.Description), ctx.Repo.RepoLink, ctx.Repo.Repository.ComposeMetas()))
+	}
+	ctx.Data["Projects"] = projects
+	ctx.Data["IsShowClosed"] = isShowClosed
+	ctx.Data["CanWriteProjects"] = ctx.Repo.CanWrite(models.UnitTypeProjects)
+
+	pager := context.NewPagination(int(count), setting.UI.IssuePagingNum, page, 5)
+	pager.AddParam(ctx, "state", "State")
+	ctx.Data["Page"] = pager
+
+	ctx.HTML(200, tplProjects)
+}
+
+// NewProject renders the page to create a project
+func NewProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["BoardTemplates"] = []models.ProjectBoardTemplate{models.ProjectBoardTemplateBasicKanban, models.ProjectBoardTemplateNone}
+	ctx.HTML(200, tplProjectsNew)
+}
+
+// NewProjectPost creates a new project
+func NewProjectPost(ctx *context.Context, form auth.CreateProjectForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["BoardTemplates"] = []models.ProjectBoardTemplate{models.ProjectBoardTemplateBasicKanban, models.ProjectBoardTemplateNone}
+
+	if ctx.HasError() {
+		ctx.HTML(200, tplProjectsNew)
+		return
+	}
+
+	template := models.ProjectBoardTemplate(form.BoardTemplate)
+	if !template.IsValid() {
+		ctx.Data["Err_BoardTemplate"] = true
+		ctx.RenderWithErr(ctx.Tr("repo.projects.template.invalid"), tplProjectsNew, &form)
+		return
+	}
+
+	if err := models.NewProject(&models.Project{
+		RepoID:      ctx.Repo.Repository.ID,
+		Title:       form.Title,
+		Description: form.Content,
+		CreatorID:   ctx.User.ID,
+		Type:        models.ProjectTypeRepository,
+	}, template); err != nil {
+		ctx.ServerError("NewProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.create_success", form.Title))
+	ctx.Redirect(ctx.Repo.RepoLink + "/projects")
+}
+
+// getProject returns the project of the repository from the path, the error is written to ctx
+func getProject(ctx *context.Context) *models.Project {
+	p, err := models.GetProjectByRepoID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		ctx.NotFoundOrServerError("GetProjectByRepoID", models.IsErrProjectNotExist, err)
+		return nil
+	}
+	p.Repo = ctx.Repo.Repository
+	return p
+}
+
+// EditProject renders the page to edit a project
+func EditProject(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["PageIsEditProject"] = true
+
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["title"] = p.Title
+	ctx.Data["content"] = p.Description
+	ctx.HTML(200, tplProjectsNew)
+}
+
+// EditProjectPost updates a project
+func EditProjectPost(ctx *context.Context, form auth.CreateProjectForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["PageIsEditProject"] = true
+
+	if ctx.HasError() {
+		ctx.HTML(200, tplProjectsNew)
+		return
+	}
+
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+	p.Title = form.Title
+	p.Description = form.Content
+	if err := models.UpdateProject(p); err != nil {
+		ctx.ServerError("UpdateProject", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.edit_success", p.Title))
+	ctx.Redirect(ctx.Repo.RepoLink + "/projects")
+}
+
+// ChangeProjectStatus closes or reopens a project
+func ChangeProjectStatus(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	var isClosed bool
+	switch ctx.Params(":action") {
+	case "open":
+		isClosed = false
+	case "close":
+		isClosed = true
+	default:
+		ctx.Redirect(ctx.Repo.RepoLink + "/projects")
+		return
+	}
+
+	if p.IsClosed != isClosed {
+		if err := models.ChangeProjectStatus(p, isClosed); err != nil {
+			ctx.ServerError("ChangeProjectStatus", err)
+			return
+		}
+	}
+	ctx.Redirect(ctx.Repo.RepoLink + "/projects?state=" + ctx.Params(":action"))
+}
+
+// DeleteProject deletes a project
+func DeleteProject(ctx *context.Context) {
+	p, err := models.GetProjectByRepoID(ctx.Repo.Repository.ID, ctx.QueryInt64("id"))
+	if err != nil {
+		ctx.Flash.Error("GetProjectByRepoID: " + err.Error())
+	} else if err = models.DeleteProjectByID(p.ID); err != nil {
+		ctx.Flash.Error("DeleteProjectByID: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": ctx.Repo.RepoLink + "/projects",
+	})
+}
+
+// ViewProject renders the boards of a project
+func ViewProject(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	boards, err := models.GetProjectBoards(p.ID)
+	if err != nil {
+		ctx.ServerError("GetProjectBoards", err)
+		return
+	}
+	for _, b := range boards {
+		if err := b.LoadIssues(); err != nil {
+			ctx.ServerError("LoadIssues", err)
+			return
+		}
+	}
+
+	uncategorized := &models.ProjectBoard{
+		ProjectID: p.ID,
+		Title:     ctx.Tr("repo.projects.board.uncategorized"),
+	}
+	if uncategorized.Issues, err = models.GetUncategorizedProjectIssues(p.ID); err != nil {
+		ctx.ServerError("GetUncategorizedProjectIssues", err)
+		return
+	}
+
+	p.RenderedContent = string(markdown.Render([]byte(p.Description), ctx.Repo.RepoLink, ctx.Repo.Repository.ComposeMetas()))
+
+	ctx.Data["Title"] = p.Title
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["Project"] = p
+	ctx.Data["Boards"] = append([]*models.ProjectBoard{uncategorized}, boards...)
+	ctx.Data["CanWriteProjects"] = ctx.Repo.CanWrite(models.UnitTypeProjects) && !ctx.Repo.Repository.IsArchived
+
+	ctx.HTML(200, tplProjectView)
+}
+
+// AddBoardToProjectPost adds a board to a project
+func AddBoardToProjectPost(ctx *context.Context, form auth.EditProjectBoardForm) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+	} else if err := models.NewProjectBoard(&models.ProjectBoard{
+		ProjectID: p.ID,
+		Title:     form.Title,
+		IsDefault: form.IsDefault,
+		IsDone:    form.IsDone,
+	}); err != nil {
+		ctx.ServerError("NewProjectBoard", err)
+		return
+	}
+	ctx.Redirect(p.Link())
+}
+
+// EditProjectBoardPost updates the title and the flags of a board
+func EditProjectBoardPost(ctx *context.Context, form auth.EditProjectBoardForm) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	b, err := models.GetProjectBoard(p.ID, ctx.ParamsInt64(":boardid"))
+	if err != nil {
+		ctx.NotFoundOrServerError("GetProjectBoard", models.IsErrProjectBoardNotExist, err)
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(p.Link())
+		return
+	}
+
+	b.Title = form.Title
+	b.IsDefault = form.IsDefault
+	b.IsDone = form.IsDone
+	if err := models.UpdateProjectBoard(b); err != nil {
+		ctx.ServerError("UpdateProjectBoard", err)
+		return
+	}
+	ctx.Redirect(p.Link())
+}
+
+// DeleteProjectBoard deletes a board, its issues become uncategorized
+func DeleteProjectBoard(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	b, err := models.GetProjectBoard(p.ID, ctx.QueryInt64("id"))
+	if err != nil {
+		ctx.Flash.Error("GetProjectBoard: " + err.Error())
+	} else if err = models.DeleteProjectBoard(b); err != nil {
+		ctx.Flash.Error("DeleteProjectBoard: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.board.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": p.Link(),
+	})
+}
+
+// AddIssueToProjectPost adds an issue or a pull request of the repository to a board of the project
+func AddIssueToProjectPost(ctx *context.Context, form auth.AddProjectIssueForm) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(p.Link())
+		return
+	}
+
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.ID, form.Index)
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.Flash.Error(ctx.Tr("repo.projects.issue.not_exist", form.Index))
+			ctx.Redirect(p.Link())
+		} else {
+			ctx.ServerError("GetIssueByIndex", err)
+		}
+		return
+	}
+	if !ctx.Repo.CanReadIssuesOrPulls(issue.IsPull) {
+		ctx.Flash.Error(ctx.Tr("repo.projects.issue.not_exist", form.Index))
+		ctx.Redirect(p.Link())
+		return
+	}
+
+	if err := models.MoveProjectIssue(p, issue, form.BoardID, -1); err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound("MoveProjectIssue", err)
+		} else {
+			ctx.ServerError("MoveProjectIssue", err)
+		}
+		return
+	}
+	ctx.Redirect(p.Link())
+}
+
+// MoveProjectIssue moves an issue of the project to the position of a board, it is called by the
+// drag and drop of the board view
+func MoveProjectIssue(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	issueID := ctx.QueryInt64("issue_id")
+	issue, err := models.GetIssueByID(issueID)
+	if err != nil {
+		ctx.NotFoundOrServerError("GetIssueByID", models.IsErrIssueNotExist, err)
+		return
+	}
+	if issue.RepoID != ctx.Repo.Repository.ID {
+		ctx.NotFound("MoveProjectIssue", fmt.Errorf("issue %d is not part of the repository", issueID))
+		return
+	}
+
+	if err := models.MoveProjectIssue(p, issue, ctx.QueryInt64("board_id"), ctx.QueryInt("position")); err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound("MoveProjectIssue", err)
+		} else {
+			ctx.ServerError("MoveProjectIssue", err)
+		}
+		return
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// RemoveIssueFromProject removes an issue from the project
+func RemoveIssueFromProject(ctx *context.Context) {
+	p := getProject(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.RemoveIssueFromProject(p.ID, ctx.QueryInt64("id")); err != nil {
+		ctx.Flash.Error("RemoveIssueFromProject: " + err.Error())
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": p.Link(),
+	})
+}
@@ -20,6 +20,7 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/issuetemplate"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/repofiles"
@@ -50,6 +51,12 @@ var (
 		".github/PULL_REQUEST_TEMPLATE.md",
 		".github/pull_request_template.md",
 	}
+	pullRequestTemplateDirCandidates = []string{
+		".gitea/PULL_REQUEST_TEMPLATE",
+		".gitea/pull_request_template",
+		".github/PULL_REQUEST_TEMPLATE",
+		".github/pull_request_template",
+	}
 )
 
 func getRepository(ctx *context.Context, repoID int64) *models.Repository {
@@ -570,6 +577,7 @@ func ViewPullFiles(ctx *context.Context) {
 
 	setImageCompareContext(ctx, baseCommit, commit)
 	setPathsCompareContext(ctx, baseCommit, commit, headTarget)
+	setMarkupCompareContext(ctx, baseCommit, commit)
 
 	ctx.Data["RequireHighlightJS"] = true
 	ctx.Data["RequireSimpleMDE"] = true
@@ -583,6 +591,10 @@ func ViewPullFiles(ctx *context.Context) {
 		ctx.ServerError("GetCurrentReview", err)
 		return
 	}
+	if ctx.Data["CanApplySuggestions"], err = canApplySuggestions(ctx.User, issue); err != nil {
+		ctx.ServerError("canApplySuggestions", err)
+		return
+	}
 	getBranchData(ctx, issue)
 	ctx.HTML(200, tplPullFiles)
 }
@@ -616,14 +628,30 @@ func MergePullRequest(ctx *context.Context, form auth.MergePullRequestForm) {
 		ctx.ServerError("IsPullCommitStatusPass", err)
 		return
 	}
-	if !isPass && !ctx.IsUserRepoAdmin() {
-		ctx.Flash.Error(ctx.Tr("repo.pulls.no_merge_status_check"))
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
 		ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
 		return
 	}
 
-	if ctx.HasError() {
-		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+	if form.MergeWhenChecksSucceed {
+		allowed := true
+		if err := pr.CheckUserAllowedToMerge(ctx.User); err != nil {
+			if !models.IsErrNotAllowedToMerge(err) {
+				ctx.ServerError("CheckUserAllowedToMerge", err)
+				return
+			}
+			allowed = false
+		}
+		if !isPass || !allowed {
+			scheduleAutoMerge(ctx, pr, form)
+			return
+		}
+	}
+
+	if !isPass && !ctx.IsUserRepoAdmin() {
+		ctx.Flash.Error(ctx.Tr("repo.pulls.no_merge_status_check"))
 		ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
 		return
 	}
@@ -660,6 +688,15 @@ func MergePullRequest(ctx *context.Context, form auth.MergePullRequestForm) {
 		return
 	}
 
+	if err := pr.LoadProtectedBranch(); err != nil {
+		ctx.ServerError("LoadProtectedBranch", err)
+		return
+	}
+	if pr.ProtectedBranch != nil && pr.ProtectedBranch.EnableMergeQueue {
+		addToMergeQueue(ctx, pr, models.MergeStyle(form.Do), message)
+		return
+	}
+
 	if err = pull_service.Merge(pr, ctx.User, ctx.Repo.GitRepo, models.MergeStyle(form.Do), message); err != nil {
 		sanitize := func(x string) string {
 			runes := []rune(x)
@@ -708,6 +745,109 @@ func MergePullRequest(ctx *context.Context, form auth.MergePullRequestForm) {
 	ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
 }
 
+// scheduleAutoMerge schedules the pull request to be merged once its status checks and approvals succeed
+func scheduleAutoMerge(ctx *context.Context, pr *models.PullRequest, form auth.MergePullRequestForm) {
+	if err := pr.LoadProtectedBranch(); err != nil {
+		ctx.ServerError("LoadProtectedBranch", err)
+		return
+	}
+	if pr.ProtectedBranch != nil && !pr.ProtectedBranch.CanUserMerge(ctx.User.ID) {
+		ctx.Flash.Error(ctx.Tr("repo.pulls.auto_merge_not_allowed"))
+		ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+		return
+	}
+
+	// the default merge message is generated when the pull request is merged
+	var message string
+	if title := strings.TrimSpace(form.MergeTitleField); len(title) > 0 {
+		message = title
+		if body := strings.TrimSpace(form.MergeMessageField); len(body) > 0 {
+			message += "\n\n" + body
+		}
+	}
+
+	if err := pull_service.ScheduleAutoMerge(ctx.User, pr, models.MergeStyle(form.Do), message); err != nil {
+		if models.IsErrInvalidMergeStyle(err) {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.invalid_merge_option"))
+			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+			return
+		} else if models.IsErrPullRequestAlreadyScheduledToAutoMerge(err) {
+			ctx.Flash.Info(ctx.Tr("repo.pulls.auto_merge_already_scheduled"))
+			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+			return
+		}
+		ctx.ServerError("ScheduleAutoMerge", err)
+		return
+	}
+
+	log.Trace("Pull request scheduled to be merged: %d", pr.ID)
+	ctx.Flash.Success(ctx.Tr("repo.pulls.auto_merge_newly_scheduled"))
+	ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+}
+
+// addToMergeQueue adds the pull request to the merge queue of its target branch
+func addToMergeQueue(ctx *context.Context, pr *models.PullRequest, style models.MergeStyle, message string) {
+	if err := pr.CheckUserAllowedToMerge(ctx.User); err != nil {
+		if !models.IsErrNotAllowedToMerge(err) {
+			ctx.ServerError("CheckUserAllowedToMerge", err)
+			return
+		}
+		ctx.Flash.Error(ctx.Tr("repo.pulls.merge_queue.not_allowed"))
+		ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+		return
+	}
+
+	if err := pull_service.AddToMergeQueue(ctx.User, pr, style, message); err != nil {
+		if models.IsErrInvalidMergeStyle(err) {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.invalid_merge_option"))
+			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+			return
+		} else if models.IsErrPullRequestAlreadyInMergeQueue(err) {
+			ctx.Flash.Info(ctx.Tr("repo.pulls.merge_queue.already_added"))
+			ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+			return
+		}
+		ctx.ServerError("AddToMergeQueue", err)
+		return
+	}
+
+	log.Trace("Pull request added to the merge queue: %d", pr.ID)
+	ctx.Flash.Success(ctx.Tr("repo.pulls.merge_queue.added"))
+	ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+}
+
+// RemoveFromMergeQueue removes a pull request from the merge queue of its target branch
+func RemoveFromMergeQueue(ctx *context.Context) {
+	issue := checkPullInfo(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if _, err := pull_service.RemoveFromMergeQueue(ctx.User, issue.PullRequest); err != nil {
+		ctx.ServerError("RemoveFromMergeQueue", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.pulls.merge_queue.removed"))
+	ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(issue.Index))
+}
+
+// CancelAutoMergePullRequest cancels the scheduled merge of a pull request
+func CancelAutoMergePullRequest(ctx *context.Context) {
+	issue := checkPullInfo(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := pull_service.RemoveScheduledAutoMerge(ctx.User, issue.PullRequest); err != nil {
+		ctx.ServerError("RemoveScheduledAutoMerge", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.pulls.auto_merge_canceled_schedule"))
+	ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(issue.Index))
+}
+
 func stopTimerIfAvailable(user *models.User, issue *models.Issue) error {
 
 	if models.StopwatchExists(user.ID, issue.ID) {
@@ -772,6 +912,20 @@ func CompareAndPullRequestPost(ctx *context.Context, form auth.CreateIssueForm)
 		return
 	}
 
+	if err := renderTemplateForm(ctx, pullRequestTemplateDirCandidates, &form); err != nil {
+		if !issuetemplate.IsErrFieldRequired(err) {
+			ctx.ServerError("RenderToMarkdown", err)
+			return
+		}
+		PrepareCompareDiff(ctx, headUser, headRepo, headGitRepo, prInfo, baseBranch, headBranch)
+		if ctx.Written() {
+			return
+		}
+
+		ctx.RenderWithErr(ctx.Tr("repo.issues.new.form_field_required", err.(issuetemplate.ErrFieldRequired).Label), tplCompareDiff, form)
+		return
+	}
+
 	pullIssue := &models.Issue{
 		RepoID:      repo.ID,
 		Title:       form.Title,
@@ -9,8 +9,10 @@ import (
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/repofiles"
 	pull_service "code.gitea.io/gitea/services/pull"
 )
 
@@ -60,6 +62,95 @@ func CreateCodeComment(ctx *context.Context, form auth.CodeCommentForm) {
 	}
 }
 
+// canApplySuggestions returns true if the user may commit suggestions of code
+// comments to the head branch of the pull request
+func canApplySuggestions(user *models.User, issue *models.Issue) (bool, error) {
+	pr := issue.PullRequest
+	if user == nil || issue.IsClosed || pr.HasMerged || pr.HeadRepoID == 0 {
+		return false, nil
+	}
+	if err := pr.LoadHeadRepo(); err != nil {
+		if models.IsErrRepoNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if pr.HeadRepo.IsArchived {
+		return false, nil
+	}
+	perm, err := models.GetUserRepoPermission(pr.HeadRepo, user)
+	if err != nil {
+		return false, err
+	}
+	return perm.CanWrite(models.UnitTypeCode), nil
+}
+
+// ApplySuggestions commits the selected suggestions of code comments to the head branch of the pull request
+func ApplySuggestions(ctx *context.Context, form auth.ApplySuggestionsForm) {
+	issue := GetActionIssue(ctx)
+	if ctx.Written() {
+		return
+	}
+	if !issue.IsPull {
+		ctx.NotFound("ApplySuggestions", nil)
+		return
+	}
+	redirect := fmt.Sprintf("%s/pulls/%d/files", ctx.Repo.RepoLink, issue.Index)
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(redirect)
+		return
+	}
+
+	if err := issue.LoadPullRequest(); err != nil {
+		ctx.ServerError("LoadPullRequest", err)
+		return
+	}
+	if allowed, err := canApplySuggestions(ctx.User, issue); err != nil {
+		ctx.ServerError("canApplySuggestions", err)
+		return
+	} else if !allowed {
+		ctx.NotFound("ApplySuggestions", nil)
+		return
+	}
+
+	comments := make([]*models.Comment, 0, len(form.CommentIDs))
+	for _, id := range form.CommentIDs {
+		comment, err := models.GetCommentByID(id)
+		if err != nil {
+			if models.IsErrCommentNotExist(err) {
+				ctx.NotFound("GetCommentByID", err)
+			} else {
+				ctx.ServerError("GetCommentByID", err)
+			}
+			return
+		}
+		comments = append(comments, comment)
+	}
+
+	commitID, err := repofiles.ApplySuggestions(issue.PullRequest, ctx.User, &repofiles.ApplySuggestionsOptions{
+		Message:  form.Message,
+		Comments: comments,
+	})
+	if err != nil {
+		if models.IsErrSuggestionNotApplicable(err) {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.suggestion.not_applicable"))
+			ctx.Redirect(redirect)
+			return
+		} else if models.IsErrUserCannotCommit(err) {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.suggestion.protected_branch", issue.PullRequest.HeadBranch))
+			ctx.Redirect(redirect)
+			return
+		}
+		ctx.ServerError("ApplySuggestions", err)
+		return
+	}
+
+	log.Trace("Suggestions applied to pull request %d: %s", issue.PullRequest.ID, commitID)
+	ctx.Flash.Success(ctx.Tr("repo.pulls.suggestion.applied", len(comments), base.ShortSha(commitID)))
+	ctx.Redirect(redirect)
+}
+
 // SubmitReview creates a review out of the existing pending review or creates a new one if no pending review exist
 func SubmitReview(ctx *context.Context, form auth.SubmitReviewForm) {
 	issue := GetActionIssue(ctx)
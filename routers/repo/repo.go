@@ -6,8 +6,7 @@ package repo
 
 import (
 	"fmt"
-	"os"
-	"path"
+	"net/http"
 	"strings"
 
 	"code.gitea.io/gitea/models"
@@ -20,9 +19,8 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/task"
 	"code.gitea.io/gitea/modules/util"
+	archiver_service "code.gitea.io/gitea/services/archiver"
 	repo_service "code.gitea.io/gitea/services/repository"
-
-	"github.com/unknwon/com"
 )
 
 const (
@@ -436,36 +434,12 @@ func RedirectDownload(ctx *context.Context) {
 
 // Download download an archive of a repository
 func Download(ctx *context.Context) {
-	var (
-		uri         = ctx.Params("*")
-		refName     string
-		ext         string
-		archivePath string
-		archiveType git.ArchiveType
-	)
-
-	switch {
-	case strings.HasSuffix(uri, ".zip"):
-		ext = ".zip"
-		archivePath = path.Join(ctx.Repo.GitRepo.Path, "archives/zip")
-		archiveType = git.ZIP
-	case strings.HasSuffix(uri, ".tar.gz"):
-		ext = ".tar.gz"
-		archivePath = path.Join(ctx.Repo.GitRepo.Path, "archives/targz")
-		archiveType = git.TARGZ
-	default:
-		log.Trace("Unknown format: %s", uri)
+	refName, archiveType, ok := git.ParseArchiveName(ctx.Params("*"))
+	if !ok || !archiveType.IsSupported() {
+		log.Trace("Unknown format: %s", ctx.Params("*"))
 		ctx.Error(404)
 		return
 	}
-	refName = strings.TrimSuffix(uri, ext)
-
-	if !com.IsDir(archivePath) {
-		if err := os.MkdirAll(archivePath, os.ModePerm); err != nil {
-			ctx.ServerError("Download -> os.MkdirAll(archivePath)", err)
-			return
-		}
-	}
 
 	// Get corresponding commit.
 	var (
@@ -496,15 +470,20 @@ func Download(ctx *context.Context) {
 		return
 	}
 
-	archivePath = path.Join(archivePath, base.ShortSha(commit.ID.String())+ext)
-	if !com.IsFile(archivePath) {
-		if err := commit.CreateArchive(archivePath, archiveType); err != nil {
-			ctx.ServerError("Download -> CreateArchive "+archivePath, err)
-			return
-		}
+	archivePath, err := archiver_service.GetArchive(ctx.Repo.Repository, commit.ID.String(), archiveType, setting.Repository.ArchiveWaitTimeout)
+	if err != nil {
+		ctx.ServerError("GetArchive", err)
+		return
+	}
+	if len(archivePath) == 0 {
+		// The archive is still being created, let the client retry
+		ctx.Resp.Header().Set("Retry-After", "5")
+		ctx.Resp.Header().Set("Refresh", "5")
+		ctx.PlainText(http.StatusAccepted, []byte(ctx.Tr("repo.archive_generating")))
+		return
 	}
 
-	ctx.ServeFile(archivePath, ctx.Repo.Repository.Name+"-"+refName+ext)
+	ctx.ServeFile(archivePath, ctx.Repo.Repository.Name+"-"+refName+"."+archiveType.String())
 }
 
 // Status returns repository's status
@@ -22,14 +22,15 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/repository"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/validation"
 	"code.gitea.io/gitea/routers/utils"
+	"code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/mailer"
 	mirror_service "code.gitea.io/gitea/services/mirror"
 	repo_service "code.gitea.io/gitea/services/repository"
 
-	"github.com/unknwon/com"
 	"mvdan.cc/xurls/v2"
 )
 
@@ -50,6 +51,19 @@ func Settings(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("repo.settings")
 	ctx.Data["PageIsSettingsOptions"] = true
 	ctx.Data["ForcePrivate"] = setting.Repository.ForcePrivate
+	ctx.Data["DefaultMirrorInterval"] = setting.Mirror.DefaultInterval
+
+	pushMirrors, err := models.GetPushMirrorsByRepoID(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("GetPushMirrorsByRepoID", err)
+		return
+	}
+	if err := models.LoadPushMirrorSyncHistory(pushMirrors); err != nil {
+		ctx.ServerError("LoadPushMirrorSyncHistory", err)
+		return
+	}
+	ctx.Data["PushMirrors"] = pushMirrors
+
 	ctx.HTML(200, tplSettingsOptions)
 }
 
@@ -57,9 +71,21 @@ func Settings(ctx *context.Context) {
 func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 	ctx.Data["Title"] = ctx.Tr("repo.settings")
 	ctx.Data["PageIsSettingsOptions"] = true
+	ctx.Data["DefaultMirrorInterval"] = setting.Mirror.DefaultInterval
 
 	repo := ctx.Repo.Repository
 
+	pushMirrors, err := models.GetPushMirrorsByRepoID(repo.ID)
+	if err != nil {
+		ctx.ServerError("GetPushMirrorsByRepoID", err)
+		return
+	}
+	if err := models.LoadPushMirrorSyncHistory(pushMirrors); err != nil {
+		ctx.ServerError("LoadPushMirrorSyncHistory", err)
+		return
+	}
+	ctx.Data["PushMirrors"] = pushMirrors
+
 	switch ctx.Query("action") {
 	case "update":
 		if ctx.HasError() {
@@ -116,6 +142,9 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 			ctx.ServerError("UpdateRepository", err)
 			return
 		}
+		if visibilityChanged {
+			audit.Record(models.AuditActionRepoVisibility, ctx.User, ctx.RemoteAddr(), repo, "Changed visibility, private: %t", repo.IsPrivate)
+		}
 		log.Trace("Repository basic settings updated: %s/%s", ctx.Repo.Owner.Name, repo.Name)
 
 		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
@@ -203,6 +232,62 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 		ctx.Flash.Info(ctx.Tr("repo.settings.mirror_sync_in_progress"))
 		ctx.Redirect(repo.Link() + "/settings")
 
+	case "push-mirror-add":
+		// This section doesn't require repo_name/RepoName to be set in the form, don't show it
+		// as an error on the UI for this action
+		ctx.Data["Err_RepoName"] = nil
+
+		interval, err := time.ParseDuration(form.PushMirrorInterval)
+		if err != nil || (interval != 0 && interval < setting.Mirror.MinInterval) {
+			ctx.Data["Err_PushMirrorInterval"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.mirror_interval_invalid"), tplSettingsOptions, &form)
+			return
+		}
+
+		address, err := mirror_service.ParsePushMirrorAddress(form.PushMirrorAddress, form.PushMirrorUsername, form.PushMirrorPassword)
+		if err != nil {
+			ctx.Data["Err_PushMirrorAddress"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.mirror_address_url_invalid"), tplSettingsOptions, &form)
+			return
+		}
+
+		m, err := mirror_service.AddPushMirror(repo, address, interval, form.PushMirrorSyncOnCommit)
+		if err != nil {
+			ctx.ServerError("AddPushMirror", err)
+			return
+		}
+		mirror_service.StartToPushMirror(m)
+
+		ctx.Flash.Success(ctx.Tr("repo.settings.push_mirror_add_success"))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "push-mirror-remove":
+		m := selectPushMirrorByForm(form, pushMirrors)
+		if m == nil {
+			ctx.NotFound("", nil)
+			return
+		}
+
+		if err := mirror_service.RemovePushMirror(m); err != nil {
+			ctx.ServerError("RemovePushMirror", err)
+			return
+		}
+
+		ctx.Flash.Success(ctx.Tr("repo.settings.push_mirror_remove_success"))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "push-mirror-sync":
+		m := selectPushMirrorByForm(form, pushMirrors)
+		if m == nil {
+			ctx.NotFound("", nil)
+			return
+		}
+
+		mirror_service.StartToPushMirror(m)
+
+		ctx.Flash.Info(ctx.Tr("repo.settings.push_mirror_sync_in_progress", mirror_service.PushMirrorAddress(m)))
+		ctx.Redirect(repo.Link() + "/settings")
+
 	case "advanced":
 		var units []models.RepoUnit
 
@@ -290,6 +375,14 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 			})
 		}
 
+		if form.EnableProjects {
+			units = append(units, models.RepoUnit{
+				RepoID: repo.ID,
+				Type:   models.UnitTypeProjects,
+				Config: new(models.UnitConfig),
+			})
+		}
+
 		if err := models.UpdateRepositoryUnits(repo, units); err != nil {
 			ctx.ServerError("UpdateRepositoryUnits", err)
 			return
@@ -375,6 +468,7 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 			ctx.Repo.GitRepo.Close()
 			ctx.Repo.GitRepo = nil
 		}
+		oldFullName := repo.FullName()
 		if err = repo_service.TransferOwnership(ctx.User, newOwner, repo); err != nil {
 			if models.IsErrRepoAlreadyExist(err) {
 				ctx.RenderWithErr(ctx.Tr("repo.settings.new_owner_has_same_repo"), tplSettingsOptions, nil)
@@ -383,6 +477,7 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 			}
 			return
 		}
+		audit.Record(models.AuditActionRepoTransfer, ctx.User, ctx.RemoteAddr(), repo, "Transferred %s to %s", oldFullName, newOwner)
 
 		log.Trace("Repository transferred: %s/%s -> %s", ctx.Repo.Owner.Name, repo.Name, newOwner)
 		ctx.Flash.Success(ctx.Tr("repo.settings.transfer_succeed"))
@@ -402,6 +497,7 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 			ctx.ServerError("DeleteRepository", err)
 			return
 		}
+		audit.Record(models.AuditActionRepoDelete, ctx.User, ctx.RemoteAddr(), repo, "Deleted repository")
 		log.Trace("Repository deleted: %s/%s", ctx.Repo.Owner.Name, repo.Name)
 
 		ctx.Flash.Success(ctx.Tr("repo.settings.deletion_success"))
@@ -541,6 +637,7 @@ func CollaborationPost(ctx *context.Context) {
 		ctx.ServerError("AddCollaborator", err)
 		return
 	}
+	audit.Record(models.AuditActionRepoCollaboratorAdd, ctx.User, ctx.RemoteAddr(), ctx.Repo.Repository, "Added collaborator %s", u.Name)
 
 	if setting.Service.EnableNotifyMail {
 		mailer.SendCollaboratorMail(u, ctx.User, ctx.Repo.Repository)
@@ -552,18 +649,26 @@ func CollaborationPost(ctx *context.Context) {
 
 // ChangeCollaborationAccessMode response for changing access of a collaboration
 func ChangeCollaborationAccessMode(ctx *context.Context) {
-	if err := ctx.Repo.Repository.ChangeCollaborationAccessMode(
-		ctx.QueryInt64("uid"),
-		models.AccessMode(ctx.QueryInt("mode"))); err != nil {
+	uid := ctx.QueryInt64("uid")
+	mode := models.AccessMode(ctx.QueryInt("mode"))
+	if err := ctx.Repo.Repository.ChangeCollaborationAccessMode(uid, mode); err != nil {
 		log.Error("ChangeCollaborationAccessMode: %v", err)
+		return
+	}
+	if u, err := models.GetUserByID(uid); err == nil {
+		audit.Record(models.AuditActionRepoCollaboratorAccess, ctx.User, ctx.RemoteAddr(), ctx.Repo.Repository, "Changed access of collaborator %s to %s", u.Name, mode)
 	}
 }
 
 // DeleteCollaboration delete a collaboration for a repository
 func DeleteCollaboration(ctx *context.Context) {
-	if err := ctx.Repo.Repository.DeleteCollaboration(ctx.QueryInt64("id")); err != nil {
+	uid := ctx.QueryInt64("id")
+	if err := ctx.Repo.Repository.DeleteCollaboration(uid); err != nil {
 		ctx.Flash.Error("DeleteCollaboration: " + err.Error())
 	} else {
+		if u, err := models.GetUserByID(uid); err == nil {
+			audit.Record(models.AuditActionRepoCollaboratorRemove, ctx.User, ctx.RemoteAddr(), ctx.Repo.Repository, "Removed collaborator %s", u.Name)
+		}
 		ctx.Flash.Success(ctx.Tr("repo.settings.remove_collaborator_success"))
 	}
 
@@ -613,6 +718,7 @@ func AddTeamPost(ctx *context.Context) {
 		ctx.ServerError("team.AddRepository", err)
 		return
 	}
+	audit.Record(models.AuditActionRepoTeamAdd, ctx.User, ctx.RemoteAddr(), team, "Added repository %s", ctx.Repo.Repository.FullName())
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_team_success"))
 	ctx.Redirect(ctx.Repo.RepoLink + "/settings/collaboration")
@@ -636,6 +742,7 @@ func DeleteTeam(ctx *context.Context) {
 		ctx.ServerError("team.RemoveRepositorys", err)
 		return
 	}
+	audit.Record(models.AuditActionRepoTeamRemove, ctx.User, ctx.RemoteAddr(), team, "Removed repository %s", ctx.Repo.Repository.FullName())
 
 	ctx.Flash.Success(ctx.Tr("repo.settings.remove_team_success"))
 	ctx.JSON(200, map[string]interface{}{
@@ -791,15 +898,20 @@ func DeployKeysPost(ctx *context.Context, form auth.AddKeyForm) {
 	}
 
 	log.Trace("Deploy key added: %d", ctx.Repo.Repository.ID)
+	audit.Record(models.AuditActionDeployKeyAdd, ctx.User, ctx.RemoteAddr(), ctx.Repo.Repository, "Added deploy key %s", key.Name)
 	ctx.Flash.Success(ctx.Tr("repo.settings.add_key_success", key.Name))
 	ctx.Redirect(ctx.Repo.RepoLink + "/settings/keys")
 }
 
 // DeleteDeployKey response for deleting a deploy key
 func DeleteDeployKey(ctx *context.Context) {
-	if err := models.DeleteDeployKey(ctx.User, ctx.QueryInt64("id")); err != nil {
+	key, err := models.GetDeployKeyByID(ctx.QueryInt64("id"))
+	if err != nil {
+		ctx.Flash.Error("GetDeployKeyByID: " + err.Error())
+	} else if err = models.DeleteDeployKey(ctx.User, key.ID); err != nil {
 		ctx.Flash.Error("DeleteDeployKey: " + err.Error())
 	} else {
+		audit.Record(models.AuditActionDeployKeyDelete, ctx.User, ctx.RemoteAddr(), ctx.Repo.Repository, "Deleted deploy key %s", key.Name)
 		ctx.Flash.Success(ctx.Tr("repo.settings.deploy_key_deletion_success"))
 	}
 
@@ -824,7 +936,7 @@ func UpdateAvatarSetting(ctx *context.Context, form auth.AvatarForm) error {
 		// No avatar is uploaded and we not removing it here.
 		// No random avatar generated here.
 		// Just exit, no action.
-		if !com.IsFile(ctxRepo.CustomAvatarPath()) {
+		if _, err := storage.RepoAvatars.Stat(ctxRepo.CustomAvatarRelativePath()); err != nil {
 			log.Trace("No avatar was uploaded for repo: %d. Default icon will appear instead.", ctxRepo.ID)
 		}
 		return nil
@@ -871,3 +983,12 @@ func SettingsDeleteAvatar(ctx *context.Context) {
 	}
 	ctx.Redirect(ctx.Repo.RepoLink + "/settings")
 }
+
+func selectPushMirrorByForm(form auth.RepoSettingForm, pushMirrors []*models.PushMirror) *models.PushMirror {
+	for _, m := range pushMirrors {
+		if m.ID == form.PushMirrorID {
+			return m
+		}
+	}
+	return nil
+}
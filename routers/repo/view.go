@@ -198,6 +198,16 @@ func renderDirectory(ctx *context.Context, treeLink string) {
 	}
 }
 
+// linguistLanguage returns the language that .gitattributes sets for the viewed file, if any.
+func linguistLanguage(ctx *context.Context) string {
+	languages, err := ctx.Repo.GitRepo.LinguistLanguages(ctx.Repo.CommitID, ctx.Repo.TreePath)
+	if err != nil {
+		log.Error("LinguistLanguages(%s): %v", ctx.Repo.TreePath, err)
+		return ""
+	}
+	return languages[ctx.Repo.TreePath]
+}
+
 func renderFile(ctx *context.Context, entry *git.TreeEntry, treeLink, rawLink string) {
 	ctx.Data["IsViewFile"] = true
 
@@ -214,7 +224,6 @@ func renderFile(ctx *context.Context, entry *git.TreeEntry, treeLink, rawLink st
 	fileSize := blob.Size()
 	ctx.Data["FileSize"] = fileSize
 	ctx.Data["FileName"] = blob.Name()
-	ctx.Data["HighlightClass"] = highlight.FileNameToHighlightClass(blob.Name())
 	ctx.Data["RawFileLink"] = rawLink + "/" + ctx.Repo.TreePath
 
 	buf := make([]byte, 1024)
@@ -328,8 +337,9 @@ func renderFile(ctx *context.Context, entry *git.TreeEntry, treeLink, rawLink st
 			if len(lines) > 0 && lines[len(lines)-1] == "" {
 				lines = lines[:len(lines)-1]
 			}
-			for index, line := range lines {
-				line = gotemplate.HTMLEscapeString(line)
+			highlighted := highlight.File(blob.Name(), linguistLanguage(ctx), []byte(fileContent))
+			for index := range lines {
+				line := highlighted[index]
 				if index != len(lines)-1 {
 					line += "\n"
 				}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"path"
 	"strings"
+	"time"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
@@ -522,6 +523,9 @@ func checkWebhook(ctx *context.Context) (*orgRepoCtx, *models.Webhook) {
 		ctx.Data["TelegramHook"] = webhook.GetTelegramHook(w)
 	}
 
+	// Default webhooks are copied to new repositories and never deliver anything themselves
+	ctx.Data["CanRedeliver"] = orCtx.RepoID > 0 || orCtx.OrgID > 0
+
 	ctx.Data["History"], err = w.History(1)
 	if err != nil {
 		ctx.ServerError("History", err)
@@ -874,6 +878,64 @@ func TestWebhook(ctx *context.Context) {
 	}
 }
 
+// RedeliverWebhookTask adds the payload of a past delivery to the delivery queue again
+func RedeliverWebhookTask(ctx *context.Context) {
+	_, w := checkWebhook(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	t, err := models.GetHookTaskByHookID(w.ID, ctx.ParamsInt64(":task"))
+	if err != nil {
+		if models.IsErrHookTaskNotExist(err) {
+			ctx.NotFound("GetHookTaskByHookID", nil)
+		} else {
+			ctx.ServerError("GetHookTaskByHookID", err)
+		}
+		return
+	}
+
+	if _, err = webhook.RedeliverHookTask(w, t); err != nil {
+		ctx.Flash.Error("RedeliverHookTask: " + err.Error())
+		ctx.Status(500)
+	} else {
+		ctx.Flash.Info(ctx.Tr("repo.settings.webhook.redeliver_success"))
+		ctx.Status(200)
+	}
+}
+
+// ReplayWebhookDeliveries adds the payloads of all deliveries in a time range to the delivery queue again
+func ReplayWebhookDeliveries(ctx *context.Context, form auth.ReplayWebhookDeliveriesForm) {
+	orCtx, w := checkWebhook(ctx)
+	if ctx.Written() {
+		return
+	}
+	redirect := fmt.Sprintf("%s/%d", orCtx.Link, w.ID)
+
+	since, err := time.ParseInLocation("2006-01-02", form.Since, time.Local)
+	if err != nil {
+		ctx.Flash.Error(ctx.Tr("repo.settings.webhook.replay_invalid_range"))
+		ctx.Redirect(redirect)
+		return
+	}
+	until, err := time.ParseInLocation("2006-01-02", form.Until, time.Local)
+	if err != nil || until.Before(since) {
+		ctx.Flash.Error(ctx.Tr("repo.settings.webhook.replay_invalid_range"))
+		ctx.Redirect(redirect)
+		return
+	}
+
+	// The last day of the range is included
+	tasks, err := webhook.ReplayHookTasks(w, since, until.AddDate(0, 0, 1))
+	if err != nil {
+		ctx.ServerError("ReplayHookTasks", err)
+		return
+	}
+
+	ctx.Flash.Info(ctx.Tr("repo.settings.webhook.replay_success", len(tasks)))
+	ctx.Redirect(redirect)
+}
+
 // DeleteWebhook delete a webhook
 func DeleteWebhook(ctx *context.Context) {
 	if err := models.DeleteWebhookByRepoID(ctx.Repo.Repository.ID, ctx.QueryInt64("id")); err != nil {
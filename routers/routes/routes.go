@@ -8,7 +8,10 @@ import (
 	"bytes"
 	"encoding/gob"
 	"net/http"
+	"os"
 	"path"
+	"strconv"
+	"strings"
 	"text/template"
 	"time"
 
@@ -21,10 +24,14 @@ import (
 	"code.gitea.io/gitea/modules/options"
 	"code.gitea.io/gitea/modules/public"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/templates"
 	"code.gitea.io/gitea/modules/validation"
 	"code.gitea.io/gitea/routers"
 	"code.gitea.io/gitea/routers/admin"
+	"code.gitea.io/gitea/routers/api/actions"
+	"code.gitea.io/gitea/routers/api/packages"
+	"code.gitea.io/gitea/routers/api/packages/container"
 	apiv1 "code.gitea.io/gitea/routers/api/v1"
 	"code.gitea.io/gitea/routers/dev"
 	"code.gitea.io/gitea/routers/org"
@@ -47,8 +54,8 @@ import (
 	"gitea.com/macaron/macaron"
 	"gitea.com/macaron/session"
 	"gitea.com/macaron/toolbox"
+	"github.com/duo-labs/webauthn/webauthn"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/tstranex/u2f"
 )
 
 type routerLoggerOptions struct {
@@ -106,9 +113,63 @@ func RouterHandler(level log.Level) func(ctx *macaron.Context) {
 	}
 }
 
+// storageHandler serves the files of the object storage returned by getStorage below /prefix,
+// like public.StaticHandler does for a directory
+func storageHandler(prefix string, getStorage func() storage.ObjectStorage) macaron.Handler {
+	return func(ctx *macaron.Context) {
+		if ctx.Req.Method != "GET" && ctx.Req.Method != "HEAD" {
+			return
+		}
+		if !strings.HasPrefix(ctx.Req.URL.Path, "/"+prefix+"/") {
+			return
+		}
+		objStore := getStorage()
+		if objStore == nil {
+			return
+		}
+
+		rPath := strings.TrimPrefix(ctx.Req.URL.Path, "/"+prefix+"/")
+		fi, err := objStore.Stat(rPath)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.Error("Error whilst getting the info of %s: %v", ctx.Req.URL.Path, err)
+			}
+			return
+		}
+		if fi.IsDir() {
+			ctx.Resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fr, err := objStore.Open(rPath)
+		if err != nil {
+			log.Error("Error whilst opening %s: %v", ctx.Req.URL.Path, err)
+			ctx.Error(http.StatusInternalServerError)
+			return
+		}
+		defer fr.Close()
+
+		if !setting.DisableRouterLog {
+			log.Trace("[Storage] Serving %s", ctx.Req.URL.Path)
+		}
+
+		// Add an Expires header to the static content
+		if setting.StaticCacheTime > 0 {
+			ctx.Resp.Header().Set("Expires", time.Now().Add(setting.StaticCacheTime).UTC().Format(http.TimeFormat))
+			tag := public.GenerateETag(strconv.FormatInt(fi.Size(), 10), fi.Name(), fi.ModTime().UTC().Format(http.TimeFormat))
+			ctx.Resp.Header().Set("ETag", tag)
+			if ctx.Req.Header.Get("If-None-Match") == tag {
+				ctx.Resp.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		http.ServeContent(ctx.Resp, ctx.Req.Request, fi.Name(), fi.ModTime(), fr)
+	}
+}
+
 // NewMacaron initializes Macaron instance.
 func NewMacaron() *macaron.Macaron {
-	gob.Register(&u2f.Challenge{})
+	gob.Register(&webauthn.SessionData{})
 	var m *macaron.Macaron
 	if setting.RedirectMacaronLog {
 		loggerAsWriter := log.NewLoggerAsWriter("INFO", log.GetLogger("macaron"))
@@ -148,22 +209,8 @@ func NewMacaron() *macaron.Macaron {
 			ExpiresAfter: setting.StaticCacheTime,
 		},
 	))
-	m.Use(public.StaticHandler(
-		setting.AvatarUploadPath,
-		&public.Options{
-			Prefix:       "avatars",
-			SkipLogging:  setting.DisableRouterLog,
-			ExpiresAfter: setting.StaticCacheTime,
-		},
-	))
-	m.Use(public.StaticHandler(
-		setting.RepositoryAvatarUploadPath,
-		&public.Options{
-			Prefix:       "repo-avatars",
-			SkipLogging:  setting.DisableRouterLog,
-			ExpiresAfter: setting.StaticCacheTime,
-		},
-	))
+	m.Use(storageHandler("avatars", func() storage.ObjectStorage { return storage.Avatars }))
+	m.Use(storageHandler("repo-avatars", func() storage.ObjectStorage { return storage.RepoAvatars }))
 
 	m.Use(templates.HTMLRenderer())
 	mailer.InitMailRender(templates.Mailer())
@@ -318,10 +365,10 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/scratch", user.TwoFactorScratch)
 			m.Post("/scratch", bindIgnErr(auth.TwoFactorScratchAuthForm{}), user.TwoFactorScratchPost)
 		})
-		m.Group("/u2f", func() {
-			m.Get("", user.U2F)
-			m.Get("/challenge", user.U2FChallenge)
-			m.Post("/sign", bindIgnErr(u2f.SignResponse{}), user.U2FSign)
+		m.Group("/webauthn", func() {
+			m.Get("", user.WebAuthn)
+			m.Get("/assertion", user.WebAuthnAssertion)
+			m.Post("/assertion", user.WebAuthnAssertionPost)
 
 		})
 	}, reqSignOut)
@@ -333,6 +380,9 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Post("/authorize", bindIgnErr(auth.AuthorizationForm{}), user.AuthorizeOAuth)
 	}, ignSignInAndCsrf, reqSignIn)
 	m.Post("/login/oauth/access_token", bindIgnErr(auth.AccessTokenForm{}), ignSignInAndCsrf, user.AccessTokenOAuth)
+	m.Combo("/login/oauth/userinfo", ignSignInAndCsrf).Get(user.InfoOAuth).Post(user.InfoOAuth)
+	m.Get("/login/oauth/keys", ignSignInAndCsrf, user.OIDCKeys)
+	m.Get("/.well-known/openid-configuration", ignSignInAndCsrf, user.OIDCWellKnown)
 
 	m.Group("/user/settings", func() {
 		m.Get("", userSetting.Profile)
@@ -356,10 +406,10 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Get("/enroll", userSetting.EnrollTwoFactor)
 				m.Post("/enroll", bindIgnErr(auth.TwoFactorAuthForm{}), userSetting.EnrollTwoFactorPost)
 			})
-			m.Group("/u2f", func() {
-				m.Post("/request_register", bindIgnErr(auth.U2FRegistrationForm{}), userSetting.U2FRegister)
-				m.Post("/register", bindIgnErr(u2f.RegisterResponse{}), userSetting.U2FRegisterPost)
-				m.Post("/delete", bindIgnErr(auth.U2FDeleteForm{}), userSetting.U2FDelete)
+			m.Group("/webauthn", func() {
+				m.Post("/request_register", bindIgnErr(auth.WebAuthnRegistrationForm{}), userSetting.WebAuthnRegister)
+				m.Post("/register", userSetting.WebAuthnRegisterPost)
+				m.Post("/delete", bindIgnErr(auth.WebAuthnDeleteForm{}), userSetting.WebAuthnDelete)
 			})
 			m.Group("/openid", func() {
 				m.Post("", bindIgnErr(auth.AddOpenIDForm{}), userSetting.OpenIDPost)
@@ -471,6 +521,22 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Post("/delete", admin.DeleteNotices)
 			m.Get("/empty", admin.EmptyNotices)
 		})
+
+		m.Group("/mails", func() {
+			m.Get("", admin.FailedMails)
+			m.Post("/resend", admin.ResendFailedMails)
+			m.Post("/delete", admin.DeleteFailedMails)
+		})
+
+		m.Group("/audit", func() {
+			m.Get("", admin.AuditLog)
+			m.Get("/export", admin.ExportAuditLog)
+		})
+
+		m.Group("/runners", func() {
+			m.Get("", admin.Runners)
+			m.Post("/delete", admin.DeleteRunner)
+		}, repo.MustEnableActions)
 	}, adminReq)
 	// ***** END: Admin *****
 
@@ -503,6 +569,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 	reqRepoReleaseWriter := context.RequireRepoWriter(models.UnitTypeReleases)
 	reqRepoReleaseReader := context.RequireRepoReader(models.UnitTypeReleases)
 	reqRepoWikiWriter := context.RequireRepoWriter(models.UnitTypeWiki)
+	reqRepoProjectsReader := context.RequireRepoReader(models.UnitTypeProjects)
+	reqRepoProjectsWriter := context.RequireRepoWriter(models.UnitTypeProjects)
 	reqRepoIssueReader := context.RequireRepoReader(models.UnitTypeIssues)
 	reqRepoPullsWriter := context.RequireRepoWriter(models.UnitTypePullRequests)
 	reqRepoPullsReader := context.RequireRepoReader(models.UnitTypePullRequests)
@@ -553,6 +621,14 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Post("/avatar", binding.MultipartForm(auth.AvatarForm{}), org.SettingsAvatar)
 				m.Post("/avatar/delete", org.SettingsDeleteAvatar)
 
+				m.Group("/labels", func() {
+					m.Get("", org.RetrieveLabels, org.Labels)
+					m.Post("/new", bindIgnErr(auth.CreateLabelForm{}), org.NewLabel)
+					m.Post("/edit", bindIgnErr(auth.CreateLabelForm{}), org.UpdateLabel)
+					m.Post("/delete", org.DeleteLabel)
+					m.Post("/initialize", bindIgnErr(auth.InitializeLabelsForm{}), org.InitializeLabels)
+				})
+
 				m.Group("/hooks", func() {
 					m.Get("", org.Webhooks)
 					m.Post("/delete", org.DeleteWebhook)
@@ -564,6 +640,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Post("/dingtalk/new", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksNewPost)
 					m.Post("/telegram/new", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksNewPost)
 					m.Get("/:id", repo.WebHooksEdit)
+					m.Post("/:id/deliveries/:task/redeliver", repo.RedeliverWebhookTask)
+					m.Post("/:id/replay", bindIgnErr(auth.ReplayWebhookDeliveriesForm{}), repo.ReplayWebhookDeliveries)
 					m.Post("/gitea/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
 					m.Post("/gogs/:id", bindIgnErr(auth.NewGogshookForm{}), repo.GogsHooksEditPost)
 					m.Post("/slack/:id", bindIgnErr(auth.NewSlackHookForm{}), repo.SlackHooksEditPost)
@@ -628,6 +706,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Post("/msteams/new", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksNewPost)
 				m.Get("/:id", repo.WebHooksEdit)
 				m.Post("/:id/test", repo.TestWebhook)
+				m.Post("/:id/deliveries/:task/redeliver", repo.RedeliverWebhookTask)
+				m.Post("/:id/replay", bindIgnErr(auth.ReplayWebhookDeliveriesForm{}), repo.ReplayWebhookDeliveries)
 				m.Post("/gitea/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
 				m.Post("/gogs/:id", bindIgnErr(auth.NewGogshookForm{}), repo.GogsHooksEditPost)
 				m.Post("/slack/:id", bindIgnErr(auth.NewSlackHookForm{}), repo.SlackHooksEditPost)
@@ -836,6 +916,36 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/:period", repo.ActivityAuthors)
 		}, context.RepoRef(), repo.MustBeNotEmpty, context.RequireRepoReaderOr(models.UnitTypeCode))
 
+		m.Group("/actions", func() {
+			m.Get("", repo.Actions)
+			m.Group("/runs/:index", func() {
+				m.Get("", repo.ViewActionRun)
+				m.Post("/cancel", reqSignIn, reqRepoCodeWriter, repo.CancelActionRun)
+				m.Get("/jobs/:id/logs", repo.ActionJobLogs)
+			})
+		}, repo.MustEnableActions, reqRepoCodeReader)
+
+		m.Group("/projects", func() {
+			m.Get("", repo.Projects)
+			m.Get("/:id", repo.ViewProject)
+			m.Group("", func() {
+				m.Combo("/new").Get(repo.NewProject).
+					Post(bindIgnErr(auth.CreateProjectForm{}), repo.NewProjectPost)
+				m.Post("/delete", repo.DeleteProject)
+				m.Group("/:id", func() {
+					m.Combo("/edit").Get(repo.EditProject).
+						Post(bindIgnErr(auth.CreateProjectForm{}), repo.EditProjectPost)
+					m.Post("/boards", bindIgnErr(auth.EditProjectBoardForm{}), repo.AddBoardToProjectPost)
+					m.Post("/boards/delete", repo.DeleteProjectBoard)
+					m.Post("/boards/:boardid", bindIgnErr(auth.EditProjectBoardForm{}), repo.EditProjectBoardPost)
+					m.Post("/issues", bindIgnErr(auth.AddProjectIssueForm{}), repo.AddIssueToProjectPost)
+					m.Post("/issues/move", repo.MoveProjectIssue)
+					m.Post("/issues/remove", repo.RemoveIssueFromProject)
+					m.Get("/:action", repo.ChangeProjectStatus)
+				})
+			}, reqSignIn, reqRepoProjectsWriter, context.RepoMustNotBeArchived())
+		}, reqRepoProjectsReader)
+
 		m.Get("/archive/*", repo.MustBeNotEmpty, reqRepoCodeReader, repo.Download)
 
 		m.Get("/status", reqRepoCodeReader, repo.Status)
@@ -853,6 +963,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get(".patch", repo.DownloadPullPatch)
 			m.Get("/commits", context.RepoRef(), repo.ViewPullCommits)
 			m.Post("/merge", context.RepoMustNotBeArchived(), reqRepoPullsWriter, bindIgnErr(auth.MergePullRequestForm{}), repo.MergePullRequest)
+			m.Post("/cancel_auto_merge", context.RepoMustNotBeArchived(), reqRepoPullsWriter, repo.CancelAutoMergePullRequest)
+			m.Post("/dequeue", context.RepoMustNotBeArchived(), reqRepoPullsWriter, repo.RemoveFromMergeQueue)
 			m.Post("/cleanup", context.RepoMustNotBeArchived(), context.RepoRef(), repo.CleanUpPullRequest)
 			m.Group("/files", func() {
 				m.Get("", context.RepoRef(), repo.SetEditorconfigIfExists, repo.SetDiffViewStyle, repo.SetWhitespaceBehavior, repo.ViewPullFiles)
@@ -860,6 +972,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Post("/comments", bindIgnErr(auth.CodeCommentForm{}), repo.CreateCodeComment)
 					m.Post("/submit", bindIgnErr(auth.SubmitReviewForm{}), repo.SubmitReview)
 				}, context.RepoMustNotBeArchived())
+				m.Post("/suggestions", context.RepoMustNotBeArchived(), bindIgnErr(auth.ApplySuggestionsForm{}), repo.ApplySuggestions)
 			})
 		}, repo.MustAllowPulls)
 
@@ -973,6 +1086,22 @@ func RegisterRoutes(m *macaron.Macaron) {
 		private.RegisterRoutes(m)
 	})
 
+	if setting.Packages.Enabled {
+		m.Group("/api/packages", func() {
+			packages.RegisterRoutes(m)
+		}, ignSignInAndCsrf)
+		// The container registry has to be mounted at /v2 as required by the protocol.
+		m.Group("/v2", func() {
+			container.RegisterRoutes(m)
+		}, ignSignInAndCsrf, container.VerifyToken())
+	}
+
+	if setting.Actions.Enabled {
+		m.Group("/api/actions", func() {
+			actions.RegisterRoutes(m)
+		}, ignSignInAndCsrf)
+	}
+
 	// robots.txt
 	m.Get("/robots.txt", func(ctx *context.Context) {
 		if setting.HasRobotsTxt {
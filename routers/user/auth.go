@@ -22,12 +22,14 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/timeutil"
 	"code.gitea.io/gitea/modules/util"
+	wa "code.gitea.io/gitea/modules/webauthn"
+	"code.gitea.io/gitea/services/audit"
 	"code.gitea.io/gitea/services/externalaccount"
 	"code.gitea.io/gitea/services/mailer"
 
 	"gitea.com/macaron/captcha"
+	"github.com/duo-labs/webauthn/webauthn"
 	"github.com/markbates/goth"
-	"github.com/tstranex/u2f"
 )
 
 const (
@@ -44,7 +46,7 @@ const (
 	tplTwofa          base.TplName = "user/auth/twofa"
 	tplTwofaScratch   base.TplName = "user/auth/twofa_scratch"
 	tplLinkAccount    base.TplName = "user/auth/link_account"
-	tplU2F            base.TplName = "user/auth/u2f"
+	tplWebAuthn       base.TplName = "user/auth/webauthn"
 )
 
 // AutoSignIn reads cookie and try to auto-login.
@@ -166,6 +168,9 @@ func SignInPost(ctx *context.Context, form auth.SignInForm) {
 
 	u, err := models.UserSignIn(form.UserName, form.Password)
 	if err != nil {
+		if !models.IsErrUserInactive(err) || !setting.Service.RegisterEmailConfirm {
+			audit.Record(models.AuditActionUserLoginFailed, nil, ctx.RemoteAddr(), form.UserName, "Failed login: %v", err)
+		}
 		if models.IsErrUserNotExist(err) {
 			ctx.RenderWithErr(ctx.Tr("form.username_password_incorrect"), tplSignIn, &form)
 			log.Info("Failed authentication attempt for %s from %s", form.UserName, ctx.RemoteAddr())
@@ -214,9 +219,8 @@ func SignInPost(ctx *context.Context, form auth.SignInForm) {
 		return
 	}
 
-	regs, err := models.GetU2FRegistrationsByUID(u.ID)
-	if err == nil && len(regs) > 0 {
-		ctx.Redirect(setting.AppSubURL + "/user/u2f")
+	if has, err := models.HasWebAuthnCredentialsByUID(u.ID); err == nil && has {
+		ctx.Redirect(setting.AppSubURL + "/user/webauthn")
 		return
 	}
 
@@ -298,6 +302,10 @@ func TwoFactorPost(ctx *context.Context, form auth.TwoFactorAuthForm) {
 		return
 	}
 
+	if u, err := models.GetUserByID(id); err == nil {
+		audit.Record(models.AuditActionUserLoginFailed, nil, ctx.RemoteAddr(), u, "Invalid two-factor authentication passcode")
+	}
+
 	ctx.RenderWithErr(ctx.Tr("auth.twofa_passcode_incorrect"), tplTwofa, auth.TwoFactorAuthForm{})
 }
 
@@ -363,13 +371,15 @@ func TwoFactorScratchPost(ctx *context.Context, form auth.TwoFactorScratchAuthFo
 		return
 	}
 
+	if u, err := models.GetUserByID(id); err == nil {
+		audit.Record(models.AuditActionUserLoginFailed, nil, ctx.RemoteAddr(), u, "Invalid two-factor authentication scratch token")
+	}
 	ctx.RenderWithErr(ctx.Tr("auth.twofa_scratch_token_incorrect"), tplTwofaScratch, auth.TwoFactorScratchAuthForm{})
 }
 
-// U2F shows the U2F login page
-func U2F(ctx *context.Context) {
+// WebAuthn shows the WebAuthn login page
+func WebAuthn(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("twofa")
-	ctx.Data["RequireU2F"] = true
 	// Check auto-login.
 	if checkAutoLogin(ctx) {
 		return
@@ -377,100 +387,102 @@ func U2F(ctx *context.Context) {
 
 	// Ensure user is in a 2FA session.
 	if ctx.Session.Get("twofaUid") == nil {
-		ctx.ServerError("UserSignIn", errors.New("not in U2F session"))
+		ctx.ServerError("UserSignIn", errors.New("not in WebAuthn session"))
 		return
 	}
 
-	ctx.HTML(200, tplU2F)
+	ctx.HTML(200, tplWebAuthn)
 }
 
-// U2FChallenge submits a sign challenge to the browser
-func U2FChallenge(ctx *context.Context) {
-	// Ensure user is in a U2F session.
+// WebAuthnAssertion submits the assertion options to the browser
+func WebAuthnAssertion(ctx *context.Context) {
+	// Ensure user is in a 2FA session.
 	idSess := ctx.Session.Get("twofaUid")
 	if idSess == nil {
-		ctx.ServerError("UserSignIn", errors.New("not in U2F session"))
+		ctx.ServerError("UserSignIn", errors.New("not in WebAuthn session"))
 		return
 	}
 	id := idSess.(int64)
-	regs, err := models.GetU2FRegistrationsByUID(id)
+	creds, err := models.GetWebAuthnCredentialsByUID(id)
 	if err != nil {
 		ctx.ServerError("UserSignIn", err)
 		return
 	}
-	if len(regs) == 0 {
+	if len(creds) == 0 {
 		ctx.ServerError("UserSignIn", errors.New("no device registered"))
 		return
 	}
-	challenge, err := u2f.NewChallenge(setting.U2F.AppID, setting.U2F.TrustedFacets)
+	user, err := models.GetUserByID(id)
 	if err != nil {
-		ctx.ServerError("u2f.NewChallenge", err)
+		ctx.ServerError("UserSignIn", err)
 		return
 	}
-	if err = ctx.Session.Set("u2fChallenge", challenge); err != nil {
+	options, sessionData, err := wa.BeginLogin(creds.ToWebAuthnUser(user))
+	if err != nil {
+		ctx.ServerError("webauthn.BeginLogin", err)
+		return
+	}
+	if err = ctx.Session.Set("webauthnAssertion", sessionData); err != nil {
 		ctx.ServerError("UserSignIn", err)
 		return
 	}
-	ctx.JSON(200, challenge.SignRequest(regs.ToRegistrations()))
+	ctx.JSON(200, options)
 }
 
-// U2FSign authenticates the user by signResp
-func U2FSign(ctx *context.Context, signResp u2f.SignResponse) {
-	challSess := ctx.Session.Get("u2fChallenge")
+// WebAuthnAssertionPost authenticates the user by the assertion of the browser
+func WebAuthnAssertionPost(ctx *context.Context) {
+	sessionData, ok := ctx.Session.Get("webauthnAssertion").(*webauthn.SessionData)
 	idSess := ctx.Session.Get("twofaUid")
-	if challSess == nil || idSess == nil {
-		ctx.ServerError("UserSignIn", errors.New("not in U2F session"))
+	if !ok || idSess == nil {
+		ctx.ServerError("UserSignIn", errors.New("not in WebAuthn session"))
 		return
 	}
-	challenge := challSess.(*u2f.Challenge)
 	id := idSess.(int64)
-	regs, err := models.GetU2FRegistrationsByUID(id)
+	user, err := models.GetUserByID(id)
 	if err != nil {
 		ctx.ServerError("UserSignIn", err)
 		return
 	}
-	for _, reg := range regs {
-		r, err := reg.Parse()
-		if err != nil {
-			log.Fatal("parsing u2f registration: %v", err)
-			continue
-		}
-		newCounter, authErr := r.Authenticate(signResp, *challenge, reg.Counter)
-		if authErr == nil {
-			reg.Counter = newCounter
-			user, err := models.GetUserByID(id)
-			if err != nil {
-				ctx.ServerError("UserSignIn", err)
-				return
-			}
-			remember := ctx.Session.Get("twofaRemember").(bool)
-			if err := reg.UpdateCounter(); err != nil {
-				ctx.ServerError("UserSignIn", err)
-				return
-			}
+	creds, err := models.GetWebAuthnCredentialsByUID(id)
+	if err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	cred, err := wa.FinishLogin(creds.ToWebAuthnUser(user), sessionData, ctx.Req.Request.Body)
+	if err != nil {
+		audit.Record(models.AuditActionUserLoginFailed, nil, ctx.RemoteAddr(), user, "Invalid WebAuthn assertion: %v", err)
+		ctx.Error(401)
+		return
+	}
+	// The challenge is only good for a single assertion
+	_ = ctx.Session.Delete("webauthnAssertion")
 
-			if ctx.Session.Get("linkAccount") != nil {
-				gothUser := ctx.Session.Get("linkAccountGothUser")
-				if gothUser == nil {
-					ctx.ServerError("UserSignIn", errors.New("not in LinkAccount session"))
-					return
-				}
+	dbCred := creds.ByCredentialID(cred.ID)
+	dbCred.SignCount = cred.Authenticator.SignCount
+	if err := dbCred.UpdateSignCount(); err != nil {
+		ctx.ServerError("UserSignIn", err)
+		return
+	}
+	remember := ctx.Session.Get("twofaRemember").(bool)
 
-				err = externalaccount.LinkAccountToUser(user, gothUser.(goth.User))
-				if err != nil {
-					ctx.ServerError("UserSignIn", err)
-					return
-				}
-			}
-			redirect := handleSignInFull(ctx, user, remember, false)
-			if redirect == "" {
-				redirect = setting.AppSubURL + "/"
-			}
-			ctx.PlainText(200, []byte(redirect))
+	if ctx.Session.Get("linkAccount") != nil {
+		gothUser := ctx.Session.Get("linkAccountGothUser")
+		if gothUser == nil {
+			ctx.ServerError("UserSignIn", errors.New("not in LinkAccount session"))
 			return
 		}
+
+		err = externalaccount.LinkAccountToUser(user, gothUser.(goth.User))
+		if err != nil {
+			ctx.ServerError("UserSignIn", err)
+			return
+		}
+	}
+	redirect := handleSignInFull(ctx, user, remember, false)
+	if redirect == "" {
+		redirect = setting.AppSubURL + "/"
 	}
-	ctx.Error(401)
+	ctx.PlainText(200, []byte(redirect))
 }
 
 // This handles the final part of the sign-in process of the user.
@@ -492,7 +504,7 @@ func handleSignInFull(ctx *context.Context, u *models.User, remember bool, obeyR
 	_ = ctx.Session.Delete("openid_determined_username")
 	_ = ctx.Session.Delete("twofaUid")
 	_ = ctx.Session.Delete("twofaRemember")
-	_ = ctx.Session.Delete("u2fChallenge")
+	_ = ctx.Session.Delete("webauthnAssertion")
 	_ = ctx.Session.Delete("linkAccount")
 	err := ctx.Session.Set("uid", u.ID)
 	if err != nil {
@@ -524,6 +536,7 @@ func handleSignInFull(ctx *context.Context, u *models.User, remember bool, obeyR
 		ctx.ServerError("UpdateUserCols", err)
 		return setting.AppSubURL + "/"
 	}
+	audit.Record(models.AuditActionUserLogin, u, ctx.RemoteAddr(), u, "Signed in")
 
 	if redirectTo := ctx.GetCookie("redirect_to"); len(redirectTo) > 0 && !util.IsExternalURL(redirectTo) {
 		ctx.SetCookie("redirect_to", "", -1, setting.AppSubURL, "", setting.SessionConfig.Secure, true)
@@ -628,6 +641,7 @@ func handleOAuth2SignIn(u *models.User, gothUser goth.User, ctx *context.Context
 			ctx.ServerError("UpdateUserCols", err)
 			return
 		}
+		audit.Record(models.AuditActionUserLogin, u, ctx.RemoteAddr(), u, "Signed in with %s", gothUser.Provider)
 
 		// update external user information
 		if err := models.UpdateExternalUser(u, gothUser); err != nil {
@@ -654,10 +668,9 @@ func handleOAuth2SignIn(u *models.User, gothUser goth.User, ctx *context.Context
 		log.Error(fmt.Sprintf("Error setting session: %v", err))
 	}
 
-	// If U2F is enrolled -> Redirect to U2F instead
-	regs, err := models.GetU2FRegistrationsByUID(u.ID)
-	if err == nil && len(regs) > 0 {
-		ctx.Redirect(setting.AppSubURL + "/user/u2f")
+	// If WebAuthn is enrolled -> Redirect to WebAuthn instead
+	if has, err := models.HasWebAuthnCredentialsByUID(u.ID); err == nil && has {
+		ctx.Redirect(setting.AppSubURL + "/user/webauthn")
 		return
 	}
 
@@ -829,10 +842,9 @@ func LinkAccountPostSignIn(ctx *context.Context, signInForm auth.SignInForm) {
 		log.Error(fmt.Sprintf("Error setting session: %v", err))
 	}
 
-	// If U2F is enrolled -> Redirect to U2F instead
-	regs, err := models.GetU2FRegistrationsByUID(u.ID)
-	if err == nil && len(regs) > 0 {
-		ctx.Redirect(setting.AppSubURL + "/user/u2f")
+	// If WebAuthn is enrolled -> Redirect to WebAuthn instead
+	if has, err := models.HasWebAuthnCredentialsByUID(u.ID); err == nil && has {
+		ctx.Redirect(setting.AppSubURL + "/user/webauthn")
 		return
 	}
 
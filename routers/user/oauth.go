@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
@@ -106,9 +107,12 @@ type AccessTokenResponse struct {
 	TokenType    TokenType `json:"token_type"`
 	ExpiresIn    int64     `json:"expires_in"`
 	RefreshToken string    `json:"refresh_token"`
+	IDToken      string    `json:"id_token,omitempty"`
 }
 
-func newAccessTokenResponse(grant *models.OAuth2Grant) (*AccessTokenResponse, *AccessTokenError) {
+// newAccessTokenResponse generates new tokens for the grant, the nonce is only
+// set when the tokens are issued for an authorization code, never on refresh
+func newAccessTokenResponse(grant *models.OAuth2Grant, nonce string) (*AccessTokenResponse, *AccessTokenError) {
 	if setting.OAuth2.InvalidateRefreshTokens {
 		if err := grant.IncreaseCounter(); err != nil {
 			return nil, &AccessTokenError{
@@ -152,14 +156,198 @@ func newAccessTokenResponse(grant *models.OAuth2Grant) (*AccessTokenResponse, *A
 		}
 	}
 
+	// generate OpenID Connect id_token
+	signedIDToken := ""
+	if grant.ScopeContains("openid") {
+		app, err := models.GetOAuth2ApplicationByID(grant.ApplicationID)
+		if err != nil {
+			return nil, &AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+				ErrorDescription: "cannot find application",
+			}
+		}
+		user, err := models.GetUserByID(grant.UserID)
+		if err != nil {
+			return nil, &AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+				ErrorDescription: "cannot find user",
+			}
+		}
+		idToken, err := newOIDCToken(grant, user)
+		if err != nil {
+			log.Error("newOIDCToken: %v", err)
+			return nil, &AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+				ErrorDescription: "cannot load user claims",
+			}
+		}
+		idToken.Audience = app.ClientID
+		idToken.ExpiresAt = expirationDate.AsTime().Unix()
+		idToken.IssuedAt = time.Now().Unix()
+		idToken.Issuer = setting.AppURL
+		idToken.Nonce = nonce
+
+		signedIDToken, err = idToken.SignToken()
+		if err != nil {
+			log.Error("SignToken: %v", err)
+			return nil, &AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+				ErrorDescription: "cannot sign token",
+			}
+		}
+	}
+
 	return &AccessTokenResponse{
 		AccessToken:  signedAccessToken,
 		TokenType:    TokenTypeBearer,
 		ExpiresIn:    setting.OAuth2.AccessTokenExpirationTime,
 		RefreshToken: signedRefreshToken,
+		IDToken:      signedIDToken,
 	}, nil
 }
 
+// newOIDCToken returns the claims about the user which are allowed by the scopes of the grant
+func newOIDCToken(grant *models.OAuth2Grant, user *models.User) (*models.OIDCToken, error) {
+	token := &models.OIDCToken{
+		StandardClaims: jwt.StandardClaims{
+			Subject: fmt.Sprint(user.ID),
+		},
+	}
+	if grant.ScopeContains("profile") {
+		token.Name = user.FullName
+		token.PreferredUsername = user.Name
+		token.Profile = user.HTMLURL()
+		token.Picture = user.AvatarLink()
+		token.Website = user.Website
+		token.Locale = user.Language
+		token.UpdatedAt = user.UpdatedUnix.AsTime().Unix()
+	}
+	if grant.ScopeContains("email") {
+		token.Email = user.Email
+		token.EmailVerified = user.IsActive
+	}
+	if grant.ScopeContains("groups") {
+		groups, err := getOAuthGroupsForUser(user)
+		if err != nil {
+			return nil, err
+		}
+		token.Groups = groups
+	}
+	return token, nil
+}
+
+// getOAuthGroupsForUser returns the names of the organizations of the user
+// and the teams of the user in the form "organization:team"
+func getOAuthGroupsForUser(user *models.User) ([]string, error) {
+	orgs, err := models.GetOrgsByUserID(user.ID, true)
+	if err != nil {
+		return nil, fmt.Errorf("GetOrgsByUserID: %v", err)
+	}
+	orgNames := make(map[int64]string, len(orgs))
+	groups := make([]string, 0, len(orgs))
+	for _, org := range orgs {
+		orgNames[org.ID] = org.Name
+		groups = append(groups, org.Name)
+	}
+
+	teams, err := models.GetUserTeams(user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("GetUserTeams: %v", err)
+	}
+	for _, team := range teams {
+		if orgName, ok := orgNames[team.OrgID]; ok {
+			groups = append(groups, orgName+":"+team.LowerName)
+		}
+	}
+	return groups, nil
+}
+
+// InfoOAuth returns the claims about the user of the access token (OpenID Connect userinfo endpoint)
+func InfoOAuth(ctx *context.Context) {
+	authContent := strings.SplitN(ctx.Req.Header.Get("Authorization"), " ", 2)
+	if len(authContent) != 2 || !strings.EqualFold(authContent[0], "Bearer") {
+		handleBearerTokenError(ctx, "invalid_request", "no access token")
+		return
+	}
+	token, err := models.ParseOAuth2Token(authContent[1])
+	if err != nil || token.Type != models.TypeAccessToken {
+		handleBearerTokenError(ctx, "invalid_token", "invalid access token")
+		return
+	}
+	grant, err := models.GetOAuth2GrantByID(token.GrantID)
+	if err != nil || grant == nil {
+		handleBearerTokenError(ctx, "invalid_token", "grant does not exist")
+		return
+	}
+	if !grant.ScopeContains("openid") {
+		handleBearerTokenError(ctx, "insufficient_scope", "the openid scope is not granted")
+		return
+	}
+	user, err := models.GetUserByID(grant.UserID)
+	if err != nil {
+		handleBearerTokenError(ctx, "invalid_token", "user does not exist")
+		return
+	}
+
+	claims, err := newOIDCToken(grant, user)
+	if err != nil {
+		ctx.ServerError("newOIDCToken", err)
+		return
+	}
+	ctx.JSON(200, claims)
+}
+
+// OIDCKeys returns the public keys to verify the id_tokens as JSON Web Key Set (RFC 7517)
+func OIDCKeys(ctx *context.Context) {
+	// make sure there is a key to publish before the first id_token is signed
+	if _, err := models.GetCurrentOAuth2SigningKey(); err != nil {
+		ctx.ServerError("GetCurrentOAuth2SigningKey", err)
+		return
+	}
+	keys, err := models.GetOAuth2SigningKeys()
+	if err != nil {
+		ctx.ServerError("GetOAuth2SigningKeys", err)
+		return
+	}
+
+	jwks := make([]map[string]string, 0, len(keys))
+	for _, key := range keys {
+		jwk, err := key.JWK()
+		if err != nil {
+			ctx.ServerError("JWK", err)
+			return
+		}
+		jwks = append(jwks, jwk)
+	}
+	ctx.JSON(200, map[string]interface{}{
+		"keys": jwks,
+	})
+}
+
+// OIDCWellKnown returns the OpenID Connect discovery document
+func OIDCWellKnown(ctx *context.Context) {
+	ctx.JSON(200, map[string]interface{}{
+		"issuer":                                setting.AppURL,
+		"authorization_endpoint":                setting.AppURL + "login/oauth/authorize",
+		"token_endpoint":                        setting.AppURL + "login/oauth/access_token",
+		"userinfo_endpoint":                     setting.AppURL + "login/oauth/userinfo",
+		"jwks_uri":                              setting.AppURL + "login/oauth/keys",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{jwt.SigningMethodRS256.Alg()},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post"},
+		"code_challenge_methods_supported":      []string{"plain", "S256"},
+		"scopes_supported":                      []string{"openid", "profile", "email", "groups"},
+		"claims_supported": []string{
+			"aud", "exp", "iat", "iss", "sub", "nonce",
+			"name", "preferred_username", "profile", "picture", "website", "locale", "updated_at",
+			"email", "email_verified",
+			"groups",
+		},
+	})
+}
+
 // AuthorizeOAuth manages authorize requests
 func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
 	errs := binding.Errors{}
@@ -246,9 +434,9 @@ func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
 		return
 	}
 
-	// Redirect if user already granted access
-	if grant != nil {
-		code, err := grant.GenerateNewAuthorizationCode(form.RedirectURI, form.CodeChallenge, form.CodeChallengeMethod)
+	// Redirect if user already granted access to all requested scopes
+	if grant != nil && grant.ScopeContainsAll(form.Scope) {
+		code, err := grant.GenerateNewAuthorizationCode(form.RedirectURI, form.CodeChallenge, form.CodeChallengeMethod, form.Nonce)
 		if err != nil {
 			handleServerError(ctx, form.State, form.RedirectURI)
 			return
@@ -266,6 +454,8 @@ func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
 	ctx.Data["Application"] = app
 	ctx.Data["RedirectURI"] = form.RedirectURI
 	ctx.Data["State"] = form.State
+	ctx.Data["Scope"] = form.Scope
+	ctx.Data["Nonce"] = form.Nonce
 	ctx.Data["ApplicationUserLink"] = "<a href=\"" + setting.AppURL + app.User.LowerName + "\">@" + app.User.Name + "</a>"
 	ctx.Data["ApplicationRedirectDomainHTML"] = "<strong>" + form.RedirectURI + "</strong>"
 	// TODO document SESSION <=> FORM
@@ -302,13 +492,23 @@ func GrantApplicationOAuth(ctx *context.Context, form auth.GrantApplicationForm)
 		ctx.ServerError("GetOAuth2ApplicationByClientID", err)
 		return
 	}
-	grant, err := app.CreateGrant(ctx.User.ID)
+	grant, err := app.GetGrantByUserID(ctx.User.ID)
 	if err != nil {
-		handleAuthorizeError(ctx, AuthorizeError{
-			State:            form.State,
-			ErrorDescription: "cannot create grant for user",
-			ErrorCode:        ErrorCodeServerError,
-		}, form.RedirectURI)
+		handleServerError(ctx, form.State, form.RedirectURI)
+		return
+	}
+	if grant == nil {
+		grant, err = app.CreateGrant(ctx.User.ID, form.Scope)
+		if err != nil {
+			handleAuthorizeError(ctx, AuthorizeError{
+				State:            form.State,
+				ErrorDescription: "cannot create grant for user",
+				ErrorCode:        ErrorCodeServerError,
+			}, form.RedirectURI)
+			return
+		}
+	} else if err := grant.UpdateScope(form.Scope); err != nil {
+		handleServerError(ctx, form.State, form.RedirectURI)
 		return
 	}
 
@@ -316,7 +516,7 @@ func GrantApplicationOAuth(ctx *context.Context, form auth.GrantApplicationForm)
 	codeChallenge, _ = ctx.Session.Get("CodeChallenge").(string)
 	codeChallengeMethod, _ = ctx.Session.Get("CodeChallengeMethod").(string)
 
-	code, err := grant.GenerateNewAuthorizationCode(form.RedirectURI, codeChallenge, codeChallengeMethod)
+	code, err := grant.GenerateNewAuthorizationCode(form.RedirectURI, codeChallenge, codeChallengeMethod, form.Nonce)
 	if err != nil {
 		handleServerError(ctx, form.State, form.RedirectURI)
 		return
@@ -398,7 +598,7 @@ func handleRefreshToken(ctx *context.Context, form auth.AccessTokenForm) {
 		log.Warn("A client tried to use a refresh token for grant_id = %d was used twice!", grant.ID)
 		return
 	}
-	accessToken, tokenErr := newAccessTokenResponse(grant)
+	accessToken, tokenErr := newAccessTokenResponse(grant, "")
 	if tokenErr != nil {
 		handleAccessTokenError(ctx, *tokenErr)
 		return
@@ -460,7 +660,7 @@ func handleAuthorizationCode(ctx *context.Context, form auth.AccessTokenForm) {
 			ErrorDescription: "cannot proceed your request",
 		})
 	}
-	resp, tokenErr := newAccessTokenResponse(authorizationCode.Grant)
+	resp, tokenErr := newAccessTokenResponse(authorizationCode.Grant, authorizationCode.Nonce)
 	if tokenErr != nil {
 		handleAccessTokenError(ctx, *tokenErr)
 		return
@@ -473,6 +673,22 @@ func handleAccessTokenError(ctx *context.Context, acErr AccessTokenError) {
 	ctx.JSON(400, acErr)
 }
 
+// handleBearerTokenError responds with an error specified in RFC 6750
+func handleBearerTokenError(ctx *context.Context, errorCode, description string) {
+	ctx.Resp.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer error=%q, error_description=%q", errorCode, description))
+	status := 401
+	switch errorCode {
+	case "invalid_request":
+		status = 400
+	case "insufficient_scope":
+		status = 403
+	}
+	ctx.JSON(status, map[string]string{
+		"error":             errorCode,
+		"error_description": description,
+	})
+}
+
 func handleServerError(ctx *context.Context, state string, redirectURI string) {
 	handleAuthorizeError(ctx, AuthorizeError{
 		ErrorCode:        ErrorCodeServerError,
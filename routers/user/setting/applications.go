@@ -6,11 +6,15 @@
 package setting
 
 import (
+	"time"
+
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/services/audit"
 )
 
 const (
@@ -39,14 +43,38 @@ func ApplicationsPost(ctx *context.Context, form auth.NewAccessTokenForm) {
 		return
 	}
 
+	scope, err := models.ParseAccessTokenScope(form.Scopes)
+	if err != nil {
+		ctx.Flash.Error(ctx.Tr("settings.token_scope_invalid"))
+		ctx.Redirect(setting.AppSubURL + "/user/settings/applications")
+		return
+	}
+
 	t := &models.AccessToken{
-		UID:  ctx.User.ID,
-		Name: form.Name,
+		UID:   ctx.User.ID,
+		Name:  form.Name,
+		Scope: scope,
+	}
+	if len(form.ExpiresAt) > 0 {
+		expiresAt, err := time.ParseInLocation("2006-01-02", form.ExpiresAt, time.Local)
+		if err != nil {
+			ctx.Flash.Error(ctx.Tr("settings.token_expires_invalid"))
+			ctx.Redirect(setting.AppSubURL + "/user/settings/applications")
+			return
+		}
+		expiresAt = time.Date(expiresAt.Year(), expiresAt.Month(), expiresAt.Day(), 23, 59, 59, 0, expiresAt.Location())
+		if !expiresAt.After(time.Now()) {
+			ctx.Flash.Error(ctx.Tr("settings.token_expires_invalid"))
+			ctx.Redirect(setting.AppSubURL + "/user/settings/applications")
+			return
+		}
+		t.ExpiresUnix = timeutil.TimeStamp(expiresAt.Unix())
 	}
 	if err := models.NewAccessToken(t); err != nil {
 		ctx.ServerError("NewAccessToken", err)
 		return
 	}
+	audit.Record(models.AuditActionAccessTokenCreate, ctx.User, ctx.RemoteAddr(), ctx.User, "Created access token %s with scope %s", t.Name, t.Scope)
 
 	ctx.Flash.Success(ctx.Tr("settings.generate_token_success"))
 	ctx.Flash.Info(t.Token)
@@ -59,6 +87,7 @@ func DeleteApplication(ctx *context.Context) {
 	if err := models.DeleteAccessTokenByID(ctx.QueryInt64("id"), ctx.User.ID); err != nil {
 		ctx.Flash.Error("DeleteAccessTokenByID: " + err.Error())
 	} else {
+		audit.Record(models.AuditActionAccessTokenDelete, ctx.User, ctx.RemoteAddr(), ctx.User, "Deleted access token %d", ctx.QueryInt64("id"))
 		ctx.Flash.Success(ctx.Tr("settings.delete_token_success"))
 	}
 
@@ -74,6 +103,7 @@ func loadApplicationsData(ctx *context.Context) {
 		return
 	}
 	ctx.Data["Tokens"] = tokens
+	ctx.Data["AccessTokenScopes"] = models.AllAccessTokenScopes
 	ctx.Data["EnableOAuth2"] = setting.OAuth2.Enable
 	if setting.OAuth2.Enable {
 		ctx.Data["Applications"], err = models.GetOAuth2ApplicationsByUserID(ctx.User.ID)
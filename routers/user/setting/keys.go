@@ -11,6 +11,7 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/audit"
 )
 
 const (
@@ -63,6 +64,7 @@ func KeysPost(ctx *context.Context, form auth.AddKeyForm) {
 			}
 			return
 		}
+		audit.Record(models.AuditActionGPGKeyAdd, ctx.User, ctx.RemoteAddr(), ctx.User, "Added GPG key %s", key.KeyID)
 		ctx.Flash.Success(ctx.Tr("settings.add_gpg_key_success", key.KeyID))
 		ctx.Redirect(setting.AppSubURL + "/user/settings/keys")
 	case "ssh":
@@ -97,6 +99,7 @@ func KeysPost(ctx *context.Context, form auth.AddKeyForm) {
 			}
 			return
 		}
+		audit.Record(models.AuditActionPublicKeyAdd, ctx.User, ctx.RemoteAddr(), ctx.User, "Added SSH key %s", form.Title)
 		ctx.Flash.Success(ctx.Tr("settings.add_key_success", form.Title))
 		ctx.Redirect(setting.AppSubURL + "/user/settings/keys")
 
@@ -115,12 +118,14 @@ func DeleteKey(ctx *context.Context) {
 		if err := models.DeleteGPGKey(ctx.User, ctx.QueryInt64("id")); err != nil {
 			ctx.Flash.Error("DeleteGPGKey: " + err.Error())
 		} else {
+			audit.Record(models.AuditActionGPGKeyDelete, ctx.User, ctx.RemoteAddr(), ctx.User, "Deleted GPG key %d", ctx.QueryInt64("id"))
 			ctx.Flash.Success(ctx.Tr("settings.gpg_key_deletion_success"))
 		}
 	case "ssh":
 		if err := models.DeletePublicKey(ctx.User, ctx.QueryInt64("id")); err != nil {
 			ctx.Flash.Error("DeletePublicKey: " + err.Error())
 		} else {
+			audit.Record(models.AuditActionPublicKeyDelete, ctx.User, ctx.RemoteAddr(), ctx.User, "Deleted SSH key %d", ctx.QueryInt64("id"))
 			ctx.Flash.Success(ctx.Tr("settings.ssh_key_deletion_success"))
 		}
 	default:
@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/services/audit"
 
 	"github.com/pquerna/otp"
 	"github.com/pquerna/otp/totp"
@@ -62,6 +63,7 @@ func DisableTwoFactor(ctx *context.Context) {
 		ctx.ServerError("SettingsTwoFactor", err)
 		return
 	}
+	audit.Record(models.AuditActionTwoFactorDisable, ctx.User, ctx.RemoteAddr(), ctx.User, "Disabled TOTP two-factor authentication")
 
 	ctx.Flash.Success(ctx.Tr("settings.twofa_disabled"))
 	ctx.Redirect(setting.AppSubURL + "/user/settings/security")
@@ -194,6 +196,7 @@ func EnrollTwoFactorPost(ctx *context.Context, form auth.TwoFactorAuthForm) {
 		ctx.ServerError("SettingsTwoFactor", err)
 		return
 	}
+	audit.Record(models.AuditActionTwoFactorEnable, ctx.User, ctx.RemoteAddr(), ctx.User, "Enabled TOTP two-factor authentication")
 
 	err = ctx.Session.Delete("twofaSecret")
 	if err != nil {
@@ -0,0 +1,108 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"errors"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	wa "code.gitea.io/gitea/modules/webauthn"
+	"code.gitea.io/gitea/services/audit"
+
+	"github.com/duo-labs/webauthn/webauthn"
+)
+
+// WebAuthnRegister initializes the WebAuthn registration procedure
+func WebAuthnRegister(ctx *context.Context, form auth.WebAuthnRegistrationForm) {
+	if form.Name == "" {
+		ctx.Error(409)
+		return
+	}
+	creds, err := models.GetWebAuthnCredentialsByUID(ctx.User.ID)
+	if err != nil {
+		ctx.ServerError("GetWebAuthnCredentialsByUID", err)
+		return
+	}
+	for _, cred := range creds {
+		if cred.Name == form.Name {
+			ctx.Error(409, "Name already taken")
+			return
+		}
+	}
+
+	options, sessionData, err := wa.BeginRegistration(creds.ToWebAuthnUser(ctx.User))
+	if err != nil {
+		ctx.ServerError("webauthn.BeginRegistration", err)
+		return
+	}
+	if err = ctx.Session.Set("webauthnRegistration", sessionData); err != nil {
+		ctx.ServerError("Session.Set", err)
+		return
+	}
+	if err = ctx.Session.Set("webauthnName", form.Name); err != nil {
+		ctx.ServerError("Session.Set", err)
+		return
+	}
+	ctx.JSON(200, options)
+}
+
+// WebAuthnRegisterPost receives the response of the authenticator
+func WebAuthnRegisterPost(ctx *context.Context) {
+	sessionData, ok := ctx.Session.Get("webauthnRegistration").(*webauthn.SessionData)
+	name, hasName := ctx.Session.Get("webauthnName").(string)
+	if !ok || !hasName {
+		ctx.ServerError("WebAuthnRegisterPost", errors.New("not in WebAuthn session"))
+		return
+	}
+	_ = ctx.Session.Delete("webauthnRegistration")
+	_ = ctx.Session.Delete("webauthnName")
+
+	creds, err := models.GetWebAuthnCredentialsByUID(ctx.User.ID)
+	if err != nil {
+		ctx.ServerError("GetWebAuthnCredentialsByUID", err)
+		return
+	}
+	cred, err := wa.FinishRegistration(creds.ToWebAuthnUser(ctx.User), sessionData, ctx.Req.Request.Body)
+	if err != nil {
+		log.Warn("WebAuthn registration of user %d failed: %v", ctx.User.ID, err)
+		ctx.Error(400)
+		return
+	}
+	if _, err = models.CreateWebAuthnCredential(ctx.User, name, cred); err != nil {
+		ctx.ServerError("CreateWebAuthnCredential", err)
+		return
+	}
+	audit.Record(models.AuditActionTwoFactorEnable, ctx.User, ctx.RemoteAddr(), ctx.User, "Added WebAuthn security key %s", name)
+	ctx.Status(200)
+}
+
+// WebAuthnDelete deletes a WebAuthn credential by id
+func WebAuthnDelete(ctx *context.Context, form auth.WebAuthnDeleteForm) {
+	cred, err := models.GetWebAuthnCredentialByID(form.ID)
+	if err != nil {
+		if models.IsErrWebAuthnCredentialNotExist(err) {
+			ctx.Status(200)
+			return
+		}
+		ctx.ServerError("GetWebAuthnCredentialByID", err)
+		return
+	}
+	if cred.UserID != ctx.User.ID {
+		ctx.Status(401)
+		return
+	}
+	if err := models.DeleteWebAuthnCredential(cred); err != nil {
+		ctx.ServerError("DeleteWebAuthnCredential", err)
+		return
+	}
+	audit.Record(models.AuditActionTwoFactorDisable, ctx.User, ctx.RemoteAddr(), ctx.User, "Deleted WebAuthn security key %s", cred.Name)
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": setting.AppSubURL + "/user/settings/security",
+	})
+}
@@ -0,0 +1,349 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package actions
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"code.gitea.io/gitea/models"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/setting"
+	api "code.gitea.io/gitea/modules/structs"
+)
+
+// ErrInvalidRegistrationToken is returned if a runner tries to register with a wrong token
+var ErrInvalidRegistrationToken = errors.New("invalid registration token")
+
+// detectQueue holds the events for which the workflow files have to be evaluated
+var detectQueue queue.Queue
+
+// DetectOptions describes an event which may trigger workflows
+type DetectOptions struct {
+	// RepoID is the repository the runs and commit statuses belong to
+	RepoID int64
+	// SourceRepoID is the repository the workflow files are read from, defaults to RepoID
+	SourceRepoID int64
+	DoerID       int64
+	Event        actions_module.Event
+	// MatchRef is the ref the event filters are applied to, the full ref name for pushes
+	// and the base branch for pull requests
+	MatchRef string
+	// Ref is the ref the runner has to fetch
+	Ref       string
+	CommitSHA string
+}
+
+// Init starts the queue which detects the workflows triggered by events
+func Init() error {
+	if !setting.Actions.Enabled {
+		return nil
+	}
+
+	detectQueue = queue.CreateQueue("actions_detect", handle, &DetectOptions{})
+	if detectQueue == nil {
+		return fmt.Errorf("Unable to create actions detect queue")
+	}
+
+	go graceful.GetManager().RunWithShutdownFns(detectQueue.Run)
+
+	return nil
+}
+
+func handle(data ...queue.Data) {
+	for _, datum := range data {
+		opts := datum.(*DetectOptions)
+		if err := DetectWorkflows(opts); err != nil {
+			log.Error("DetectWorkflows [repo_id: %d, sha: %s]: %v", opts.RepoID, opts.CommitSHA, err)
+		}
+	}
+}
+
+// Enqueue adds an event to the detect queue
+func Enqueue(opts *DetectOptions) {
+	if detectQueue == nil {
+		return
+	}
+	if err := detectQueue.Push(opts); err != nil {
+		log.Error("Unable to push to the actions detect queue: %v", err)
+	}
+}
+
+// DetectWorkflows reads the workflow files of the commit and creates a run for every workflow
+// which is triggered by the event
+func DetectWorkflows(opts *DetectOptions) error {
+	repo, err := models.GetRepositoryByID(opts.RepoID)
+	if err != nil {
+		return err
+	}
+	doer, err := models.GetUserByID(opts.DoerID)
+	if err != nil {
+		return err
+	}
+
+	sourceRepo := repo
+	if opts.SourceRepoID > 0 && opts.SourceRepoID != repo.ID {
+		if sourceRepo, err = models.GetRepositoryByID(opts.SourceRepoID); err != nil {
+			return err
+		}
+	}
+
+	gitRepo, err := git.OpenRepository(sourceRepo.RepoPath())
+	if err != nil {
+		return err
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetCommit(opts.CommitSHA)
+	if err != nil {
+		return err
+	}
+
+	entries, err := actions_module.ListWorkflows(commit)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		content, err := entry.Blob().GetBlobContent()
+		if err != nil {
+			return err
+		}
+
+		workflow, err := actions_module.ParseWorkflow([]byte(content))
+		if err != nil {
+			log.Warn("Invalid workflow %s in repository %s at %s: %v", entry.Name(), repo.FullName(), opts.CommitSHA, err)
+			continue
+		}
+		if !workflow.Matches(opts.Event, opts.MatchRef) {
+			continue
+		}
+
+		if err := createRun(repo, doer, opts, entry.Name(), workflow); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func createRun(repo *models.Repository, doer *models.User, opts *DetectOptions, workflowID string, workflow *actions_module.Workflow) error {
+	title := workflow.Name
+	if title == "" {
+		title = workflowID
+	}
+
+	run := &models.ActionRun{
+		RepoID:        repo.ID,
+		Repo:          repo,
+		Title:         title,
+		WorkflowID:    workflowID,
+		TriggerUserID: doer.ID,
+		TriggerUser:   doer,
+		Event:         string(opts.Event),
+		Ref:           opts.Ref,
+		CommitSHA:     opts.CommitSHA,
+	}
+
+	jobs := make([]*models.ActionRunJob, 0, len(workflow.Jobs))
+	for _, job := range workflow.Jobs {
+		payload, err := json.Marshal(job)
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, &models.ActionRunJob{
+			JobID:   job.ID,
+			Name:    job.Name,
+			RunsOn:  job.RunsOn,
+			Payload: string(payload),
+		})
+	}
+
+	if err := models.CreateActionRun(run, jobs); err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if err := createCommitStatus(run, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createCommitStatus reports the status of the job as commit status, the run must be loaded
+func createCommitStatus(run *models.ActionRun, job *models.ActionRunJob) error {
+	var description string
+	switch job.Status {
+	case models.ActionStatusWaiting:
+		description = "Waiting for a runner"
+	case models.ActionStatusRunning:
+		description = "Running"
+	case models.ActionStatusSuccess:
+		description = "Successful in " + job.Duration().String()
+	case models.ActionStatusFailure:
+		description = "Failed after " + job.Duration().String()
+	case models.ActionStatusCancelled:
+		description = "Cancelled"
+	}
+
+	return models.NewCommitStatus(models.NewCommitStatusOptions{
+		Repo:    run.Repo,
+		Creator: run.TriggerUser,
+		SHA:     run.CommitSHA,
+		CommitStatus: &models.CommitStatus{
+			State:       job.Status.CommitStatus(),
+			TargetURL:   run.HTMLURL(),
+			Description: description,
+			Context:     fmt.Sprintf("%s / %s (%s)", run.Title, job.Name, run.Event),
+		},
+	})
+}
+
+// RegisterRunner registers a new runner if the registration token matches the configured one
+func RegisterRunner(token, name string, labels []string) (*models.ActionRunner, error) {
+	expected := setting.Actions.RegistrationToken
+	if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		return nil, ErrInvalidRegistrationToken
+	}
+
+	runner := &models.ActionRunner{
+		Name:   name,
+		Labels: labels,
+	}
+	if err := models.NewActionRunner(runner); err != nil {
+		return nil, err
+	}
+	return runner, nil
+}
+
+// ToActionJob converts a job which is assigned to a runner into the format sent to the runner
+func ToActionJob(job *models.ActionRunJob) (*api.ActionJob, error) {
+	if err := job.LoadRun(); err != nil {
+		return nil, err
+	}
+
+	var definition actions_module.Job
+	if err := json.Unmarshal([]byte(job.Payload), &definition); err != nil {
+		return nil, err
+	}
+
+	run := job.Run
+	env := map[string]string{
+		"CI":                 "true",
+		"GITEA_ACTIONS":      "true",
+		"GITEA_SERVER_URL":   setting.AppURL,
+		"GITEA_REPOSITORY":   run.Repo.FullName(),
+		"GITEA_EVENT_NAME":   run.Event,
+		"GITEA_REF":          run.Ref,
+		"GITEA_SHA":          run.CommitSHA,
+		"GITEA_WORKFLOW":     run.Title,
+		"GITEA_JOB":          job.JobID,
+		"GITEA_RUN_NUMBER":   fmt.Sprint(run.Index),
+		"GITEA_TRIGGERED_BY": run.TriggerUser.Name,
+	}
+	for k, v := range definition.Env {
+		env[k] = v
+	}
+
+	steps := make([]*api.ActionJobStep, 0, len(definition.Steps))
+	for _, step := range definition.Steps {
+		steps = append(steps, &api.ActionJobStep{
+			Name:             step.Name,
+			Run:              step.Run,
+			WorkingDirectory: step.WorkingDirectory,
+			Env:              step.Env,
+		})
+	}
+
+	return &api.ActionJob{
+		ID:         job.ID,
+		RunID:      run.ID,
+		RunNumber:  run.Index,
+		Name:       job.Name,
+		Repository: run.Repo.FullName(),
+		CloneURL:   run.Repo.CloneLink().HTTPS,
+		Event:      run.Event,
+		Ref:        run.Ref,
+		CommitSHA:  run.CommitSHA,
+		Env:        env,
+		Steps:      steps,
+	}, nil
+}
+
+// PickJob assigns the next job the runner can execute to it. It returns nil if there is no job.
+func PickJob(runner *models.ActionRunner) (*models.ActionRunJob, error) {
+	if err := models.UpdateActionRunnerLastOnline(runner); err != nil {
+		return nil, err
+	}
+
+	job, err := models.PickActionRunJob(runner)
+	if err != nil || job == nil {
+		return nil, err
+	}
+
+	if err := job.LoadRun(); err != nil {
+		return nil, err
+	}
+	if err := createCommitStatus(job.Run, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// AppendLog appends the output of the runner to the log of the job
+func AppendLog(job *models.ActionRunJob, r io.Reader) error {
+	p := job.LogPath()
+	if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
+
+// OpenLog opens the log of the job. It returns an os.IsNotExist error if the job has no output yet.
+func OpenLog(job *models.ActionRunJob) (*os.File, error) {
+	return os.Open(job.LogPath())
+}
+
+// FinishJob stores the result reported by the runner
+func FinishJob(job *models.ActionRunJob, status models.ActionStatus) error {
+	if err := models.FinishActionRunJob(job, status); err != nil {
+		return err
+	}
+	if err := job.LoadRun(); err != nil {
+		return err
+	}
+	return createCommitStatus(job.Run, job)
+}
+
+// CancelRun cancels all unfinished jobs of the run, the run must be loaded
+func CancelRun(run *models.ActionRun) error {
+	jobs, err := models.CancelActionRun(run)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if err := createCommitStatus(run, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
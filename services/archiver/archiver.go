@@ -0,0 +1,184 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package archiver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/graceful"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/queue"
+)
+
+// ArchiveRequest describes an archive of a commit of a repository
+type ArchiveRequest struct {
+	RepoID   int64
+	CommitID string
+	Type     git.ArchiveType
+}
+
+func (r *ArchiveRequest) key() string {
+	return fmt.Sprintf("%d-%s-%d", r.RepoID, r.CommitID, r.Type)
+}
+
+// archiveResult is shared by all requests waiting for the same archive
+type archiveResult struct {
+	done chan struct{}
+	err  error
+}
+
+var (
+	archiveQueue queue.Queue
+
+	pendingLock sync.Mutex
+	pending     = make(map[string]*archiveResult)
+)
+
+// Init starts the queue which creates the repository archives
+func Init() error {
+	archiveQueue = queue.CreateQueue("repo_archive", handle, &ArchiveRequest{})
+	if archiveQueue == nil {
+		return fmt.Errorf("Unable to create repository archive queue")
+	}
+
+	go graceful.GetManager().RunWithShutdownFns(archiveQueue.Run)
+
+	return nil
+}
+
+func handle(data ...queue.Data) {
+	for _, datum := range data {
+		req := datum.(*ArchiveRequest)
+		err := createArchive(req)
+		if err != nil {
+			log.Error("CreateArchive [repo_id: %d, commit: %s, type: %s]: %v", req.RepoID, req.CommitID, req.Type, err)
+		}
+
+		pendingLock.Lock()
+		if result, ok := pending[req.key()]; ok {
+			result.err = err
+			close(result.done)
+			delete(pending, req.key())
+		}
+		pendingLock.Unlock()
+	}
+}
+
+// ArchivePath returns the path the archive is cached at
+func ArchivePath(repo *models.Repository, commitID string, archiveType git.ArchiveType) string {
+	return filepath.Join(repo.RepoPath(), "archives",
+		strings.Replace(archiveType.String(), ".", "", -1),
+		commitID+"."+archiveType.String())
+}
+
+func createArchive(req *ArchiveRequest) error {
+	repo, err := models.GetRepositoryByID(req.RepoID)
+	if err != nil {
+		return err
+	}
+
+	target := ArchivePath(repo, req.CommitID, req.Type)
+	if _, err := os.Stat(target); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return err
+	}
+	defer gitRepo.Close()
+
+	commit, err := gitRepo.GetCommit(req.CommitID)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so an archive is never served while it is still being written
+	tmpFile, err := ioutil.TempFile(filepath.Dir(target), "tmp-*."+req.Type.String())
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+	defer os.Remove(tmpPath)
+
+	if err := commit.CreateArchive(tmpPath, req.Type); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, target)
+}
+
+// enqueue requests the creation of the archive, requests for the same archive share the result
+func enqueue(req *ArchiveRequest) (*archiveResult, error) {
+	if archiveQueue == nil {
+		return nil, fmt.Errorf("repository archive queue is not running")
+	}
+
+	pendingLock.Lock()
+	if result, ok := pending[req.key()]; ok {
+		pendingLock.Unlock()
+		return result, nil
+	}
+	result := &archiveResult{done: make(chan struct{})}
+	pending[req.key()] = result
+	pendingLock.Unlock()
+
+	// Push may block until a worker is free, which then needs the lock to finish its request
+	if err := archiveQueue.Push(req); err != nil {
+		pendingLock.Lock()
+		delete(pending, req.key())
+		pendingLock.Unlock()
+		result.err = err
+		close(result.done)
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetArchive returns the path of the cached archive, creating it if necessary. It waits at most timeout
+// for the archive and returns an empty path if it is not ready yet, the archive is still created in the background.
+func GetArchive(repo *models.Repository, commitID string, archiveType git.ArchiveType, timeout time.Duration) (string, error) {
+	archivePath := ArchivePath(repo, commitID, archiveType)
+	if info, err := os.Stat(archivePath); err == nil {
+		// Keep archives which are downloaded regularly in the cache
+		if time.Since(info.ModTime()) > time.Hour {
+			now := time.Now()
+			if err := os.Chtimes(archivePath, now, now); err != nil {
+				log.Warn("Unable to update the modification time of %s: %v", archivePath, err)
+			}
+		}
+		return archivePath, nil
+	}
+
+	result, err := enqueue(&ArchiveRequest{
+		RepoID:   repo.ID,
+		CommitID: commitID,
+		Type:     archiveType,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	select {
+	case <-result.done:
+		if result.err != nil {
+			return "", result.err
+		}
+		return archivePath, nil
+	case <-time.After(timeout):
+		return "", nil
+	}
+}
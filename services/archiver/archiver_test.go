@@ -0,0 +1,78 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package archiver
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/queue"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetArchive(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+
+	handled := make(chan *ArchiveRequest, 10)
+	q, err := queue.NewChannelQueue(func(data ...queue.Data) {
+		for _, datum := range data {
+			handled <- datum.(*ArchiveRequest)
+		}
+		handle(data...)
+	}, queue.ChannelQueueConfiguration{
+		QueueLength:  10,
+		Workers:      1,
+		MaxWorkers:   1,
+		BlockTimeout: time.Second,
+		BoostTimeout: time.Minute,
+	}, &ArchiveRequest{})
+	assert.NoError(t, err)
+	archiveQueue = q
+	defer func() {
+		archiveQueue = nil
+	}()
+
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	commitID := "65f1bf27bc3bf70f64657658635e66094edbcb4d"
+	req := &ArchiveRequest{RepoID: repo.ID, CommitID: commitID, Type: git.ZIP}
+
+	// requests for the same archive are merged while it is pending
+	result1, err := enqueue(req)
+	assert.NoError(t, err)
+	result2, err := enqueue(req)
+	assert.NoError(t, err)
+	assert.True(t, result1 == result2)
+
+	nilFn := func(_ context.Context, _ func()) {}
+	go q.Run(nilFn, nilFn)
+
+	select {
+	case <-result1.done:
+		assert.NoError(t, result1.err)
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "Timeout: the archive has not been created")
+	}
+	assert.Len(t, handled, 1)
+
+	archivePath, err := GetArchive(repo, commitID, git.ZIP, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, ArchivePath(repo, commitID, git.ZIP), archivePath)
+	info, err := os.Stat(archivePath)
+	if assert.NoError(t, err) {
+		assert.True(t, info.Size() > 0)
+	}
+	// the cached archive is served without creating it again
+	assert.Len(t, handled, 1)
+
+	archivePath, err = GetArchive(repo, commitID, git.TARGZ, 10*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, ArchivePath(repo, commitID, git.TARGZ), archivePath)
+	assert.Len(t, handled, 2)
+}
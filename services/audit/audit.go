@@ -0,0 +1,52 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+)
+
+// Record adds an event to the audit log. The target can be a *models.User, a *models.Repository,
+// a *models.Team or, for users which do not exist, the name of the user.
+// Failing to store the event is logged but does not abort the audited action.
+func Record(action models.AuditAction, doer *models.User, ipAddress string, target interface{}, format string, v ...interface{}) {
+	event := &models.AuditEvent{
+		Action:      action,
+		IPAddress:   ipAddress,
+		Description: fmt.Sprintf(format, v...),
+	}
+	if doer != nil {
+		event.ActorID = doer.ID
+		event.ActorName = doer.Name
+	}
+
+	switch t := target.(type) {
+	case *models.User:
+		event.TargetType = models.AuditTargetUser
+		event.TargetID = t.ID
+		event.TargetName = t.Name
+	case string:
+		event.TargetType = models.AuditTargetUser
+		event.TargetName = t
+	case *models.Repository:
+		event.TargetType = models.AuditTargetRepository
+		event.TargetID = t.ID
+		event.TargetName = t.FullName()
+	case *models.Team:
+		event.TargetType = models.AuditTargetTeam
+		event.TargetID = t.ID
+		event.TargetName = t.Name
+		if org, err := models.GetUserByID(t.OrgID); err == nil {
+			event.TargetName = org.Name + "/" + t.Name
+		}
+	}
+
+	if err := models.CreateAuditEvent(event); err != nil {
+		log.Error("CreateAuditEvent [action: %s, target: %s]: %v", action, event.TargetName, err)
+	}
+}
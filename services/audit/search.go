@@ -0,0 +1,77 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/convert"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// SearchOptionsFromQuery parses the audit log filters of a request: action, actor, target_type, target,
+// and since and until as dates in the form YYYY-MM-DD, both inclusive
+func SearchOptionsFromQuery(query url.Values) (*models.SearchAuditEventsOptions, error) {
+	opts := &models.SearchAuditEventsOptions{
+		Action:     models.AuditAction(query.Get("action")),
+		ActorName:  query.Get("actor"),
+		TargetType: models.AuditTargetType(query.Get("target_type")),
+		TargetName: query.Get("target"),
+	}
+
+	if len(opts.Action) > 0 && !isValidAction(opts.Action) {
+		return nil, fmt.Errorf("unknown action: %s", opts.Action)
+	}
+	if len(opts.TargetType) > 0 && !isValidTargetType(opts.TargetType) {
+		return nil, fmt.Errorf("unknown target type: %s", opts.TargetType)
+	}
+
+	if since := query.Get("since"); len(since) > 0 {
+		t, err := time.ParseInLocation("2006-01-02", since, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid since date: %s", since)
+		}
+		opts.Since = timeutil.TimeStamp(t.Unix())
+	}
+	if until := query.Get("until"); len(until) > 0 {
+		t, err := time.ParseInLocation("2006-01-02", until, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid until date: %s", until)
+		}
+		opts.Before = timeutil.TimeStamp(t.AddDate(0, 0, 1).Unix())
+	}
+	return opts, nil
+}
+
+func isValidAction(action models.AuditAction) bool {
+	for _, a := range models.AllAuditActions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidTargetType(targetType models.AuditTargetType) bool {
+	for _, t := range models.AllAuditTargetTypes {
+		if t == targetType {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportJSONLines writes the audit events matching the options to w, one JSON object per line
+func ExportJSONLines(w io.Writer, opts *models.SearchAuditEventsOptions) error {
+	enc := json.NewEncoder(w)
+	return models.IterateAuditEvents(opts, func(e *models.AuditEvent) error {
+		return enc.Encode(convert.ToAuditEvent(e))
+	})
+}
@@ -185,45 +185,90 @@ func getDiffLineSectionInfo(curFile *DiffFile, line string, lastLeftIdx, lastRig
 	}
 }
 
-// escape a line's content or return <br> needed for copy/paste purposes
-func getLineContent(content string) string {
+// highlight a line's content or return <br> needed for copy/paste purposes
+func getLineContent(fileName, language, content string) string {
 	if len(content) > 0 {
-		return html.EscapeString(content)
+		return highlight.Code(fileName, language, content)
 	}
 	return "<br>"
 }
 
 // DiffSection represents a section of a DiffFile.
 type DiffSection struct {
-	Name  string
-	Lines []*DiffLine
+	FileName string
+	// Language overrides the language the lines are highlighted in, which is guessed from FileName otherwise
+	Language string
+	Name     string
+	Lines    []*DiffLine
 }
 
-var (
-	addedCodePrefix   = []byte(`<span class="added-code">`)
-	removedCodePrefix = []byte(`<span class="removed-code">`)
-	codeTagSuffix     = []byte(`</span>`)
+const (
+	addedCodePrefix   = `<span class="added-code">`
+	removedCodePrefix = `<span class="removed-code">`
+	codeTagSuffix     = `</span>`
 )
 
-func diffToHTML(diffs []diffmatchpatch.Diff, lineType DiffLineType) template.HTML {
-	buf := bytes.NewBuffer(nil)
+// diffToHTML highlights the line of lineType that diffs was computed for and marks
+// the code that was added to or removed from it. The line is highlighted as a whole
+// and its tokens are split where a change begins or ends.
+func diffToHTML(fileName, language string, diffs []diffmatchpatch.Diff, lineType DiffLineType) template.HTML {
+	diffs = lineDiffs(diffs, lineType)
 
+	var content strings.Builder
 	for i := range diffs {
-		switch {
-		case diffs[i].Type == diffmatchpatch.DiffInsert && lineType == DiffLineAdd:
-			buf.Write(addedCodePrefix)
-			buf.WriteString(getLineContent(diffs[i].Text))
-			buf.Write(codeTagSuffix)
-		case diffs[i].Type == diffmatchpatch.DiffDelete && lineType == DiffLineDel:
-			buf.Write(removedCodePrefix)
-			buf.WriteString(getLineContent(diffs[i].Text))
-			buf.Write(codeTagSuffix)
-		case diffs[i].Type == diffmatchpatch.DiffEqual:
-			buf.WriteString(getLineContent(diffs[i].Text))
+		content.WriteString(diffs[i].Text)
+	}
+	if content.Len() == 0 {
+		return template.HTML("<br>")
+	}
+	tokens := highlight.Tokens(fileName, language, content.String())
+
+	var buf strings.Builder
+	var token, offset int
+	for i := range diffs {
+		switch diffs[i].Type {
+		case diffmatchpatch.DiffInsert:
+			buf.WriteString(addedCodePrefix)
+		case diffmatchpatch.DiffDelete:
+			buf.WriteString(removedCodePrefix)
+		}
+
+		text := diffs[i].Text
+		for len(text) > 0 {
+			value := tokens[token].Value[offset:]
+			if len(value) > len(text) {
+				value = value[:len(text)]
+			}
+			highlight.WriteToken(&buf, tokens[token].Class, value)
+
+			text = text[len(value):]
+			offset += len(value)
+			if offset == len(tokens[token].Value) {
+				token++
+				offset = 0
+			}
+		}
+
+		if diffs[i].Type != diffmatchpatch.DiffEqual {
+			buf.WriteString(codeTagSuffix)
 		}
 	}
 
-	return template.HTML(buf.Bytes())
+	return template.HTML(buf.String())
+}
+
+// lineDiffs returns the parts of diffs that make up the line of lineType.
+func lineDiffs(diffs []diffmatchpatch.Diff, lineType DiffLineType) []diffmatchpatch.Diff {
+	line := make([]diffmatchpatch.Diff, 0, len(diffs))
+	for i := range diffs {
+		switch {
+		case diffs[i].Type == diffmatchpatch.DiffInsert && lineType == DiffLineAdd,
+			diffs[i].Type == diffmatchpatch.DiffDelete && lineType == DiffLineDel,
+			diffs[i].Type == diffmatchpatch.DiffEqual:
+			line = append(line, diffs[i])
+		}
+	}
+	return line
 }
 
 // GetLine gets a specific line by type (add or del) and file line number
@@ -278,7 +323,7 @@ func init() {
 // GetComputedInlineDiffFor computes inline diff for the given line.
 func (diffSection *DiffSection) GetComputedInlineDiffFor(diffLine *DiffLine) template.HTML {
 	if setting.Git.DisableDiffHighlight {
-		return template.HTML(getLineContent(diffLine.Content[1:]))
+		return template.HTML(getLineContent(diffSection.FileName, diffSection.Language, diffLine.Content[1:]))
 	}
 	var (
 		compareDiffLine *DiffLine
@@ -291,28 +336,28 @@ func (diffSection *DiffSection) GetComputedInlineDiffFor(diffLine *DiffLine) tem
 	case DiffLineAdd:
 		compareDiffLine = diffSection.GetLine(DiffLineDel, diffLine.RightIdx)
 		if compareDiffLine == nil {
-			return template.HTML(getLineContent(diffLine.Content[1:]))
+			return template.HTML(getLineContent(diffSection.FileName, diffSection.Language, diffLine.Content[1:]))
 		}
 		diff1 = compareDiffLine.Content
 		diff2 = diffLine.Content
 	case DiffLineDel:
 		compareDiffLine = diffSection.GetLine(DiffLineAdd, diffLine.LeftIdx)
 		if compareDiffLine == nil {
-			return template.HTML(getLineContent(diffLine.Content[1:]))
+			return template.HTML(getLineContent(diffSection.FileName, diffSection.Language, diffLine.Content[1:]))
 		}
 		diff1 = diffLine.Content
 		diff2 = compareDiffLine.Content
 	default:
 		if strings.IndexByte(" +-", diffLine.Content[0]) > -1 {
-			return template.HTML(getLineContent(diffLine.Content[1:]))
+			return template.HTML(getLineContent(diffSection.FileName, diffSection.Language, diffLine.Content[1:]))
 		}
-		return template.HTML(getLineContent(diffLine.Content))
+		return template.HTML(html.EscapeString(diffLine.Content))
 	}
 
 	diffRecord := diffMatchPatch.DiffMain(diff1[1:], diff2[1:], true)
 	diffRecord = diffMatchPatch.DiffCleanupEfficiency(diffRecord)
 
-	return diffToHTML(diffRecord, diffLine.Type)
+	return diffToHTML(diffSection.FileName, diffSection.Language, diffRecord, diffLine.Type)
 }
 
 // DiffFile represents a file diff.
@@ -330,6 +375,8 @@ type DiffFile struct {
 	IsSubmodule        bool
 	Sections           []*DiffSection
 	IsIncomplete       bool
+	// Language is the language .gitattributes sets for the file, if any
+	Language string
 }
 
 // GetType returns type of diff file.
@@ -337,11 +384,6 @@ func (diffFile *DiffFile) GetType() int {
 	return int(diffFile.Type)
 }
 
-// GetHighlightClass returns highlight class for a filename.
-func (diffFile *DiffFile) GetHighlightClass() string {
-	return highlight.FileNameToHighlightClass(diffFile.Name)
-}
-
 // GetTailSection creates a fake DiffLineSection if the last section is not the end of the file
 func (diffFile *DiffFile) GetTailSection(gitRepo *git.Repository, leftCommitID, rightCommitID string) *DiffSection {
 	if diffFile.Type != DiffFileChange || diffFile.IsBin || diffFile.IsLFSFile {
@@ -372,7 +414,7 @@ func (diffFile *DiffFile) GetTailSection(gitRepo *git.Repository, leftCommitID,
 			LeftIdx:      leftLineCount,
 			RightIdx:     rightLineCount,
 		}}
-	tailSection := &DiffSection{Lines: []*DiffLine{tailDiffLine}}
+	tailSection := &DiffSection{FileName: diffFile.Name, Lines: []*DiffLine{tailDiffLine}}
 	return tailSection
 
 }
@@ -422,6 +464,29 @@ func (diff *Diff) LoadComments(issue *models.Issue, currentUser *models.User) er
 	return nil
 }
 
+// setLanguages applies the languages that .gitattributes at commitID sets for the files
+// of the diff to the highlighting of their lines.
+func (diff *Diff) setLanguages(gitRepo *git.Repository, commitID string) {
+	if len(diff.Files) == 0 {
+		return
+	}
+	filenames := make([]string, 0, len(diff.Files))
+	for _, diffFile := range diff.Files {
+		filenames = append(filenames, diffFile.Name)
+	}
+	languages, err := gitRepo.LinguistLanguages(commitID, filenames...)
+	if err != nil {
+		log.Error("LinguistLanguages(%s): %v", commitID, err)
+		return
+	}
+	for _, diffFile := range diff.Files {
+		diffFile.Language = languages[diffFile.Name]
+		for _, section := range diffFile.Sections {
+			section.Language = diffFile.Language
+		}
+	}
+}
+
 // NumFiles returns number of files changes in a diff.
 func (diff *Diff) NumFiles() int {
 	return len(diff.Files)
@@ -512,6 +577,8 @@ func CutDiffAroundLine(originalDiff io.Reader, line int64, old bool, numbersOfLi
 				} else {
 					otherLine++
 				}
+			case '\\':
+				// "\ No newline at end of file" is not a line of either side
 			default:
 				currentLine++
 				otherLine++
@@ -551,6 +618,8 @@ func CutDiffAroundLine(originalDiff io.Reader, line int64, old bool, numbersOfLi
 		case '-':
 			oldBegin--
 			oldNumOfLines++
+		case '\\':
+			// not a line of either side
 		default:
 			oldBegin--
 			newBegin--
@@ -649,7 +718,7 @@ func ParsePatch(maxLines, maxLineCharacters, maxFiles int, reader io.Reader) (*D
 			curSection.Lines = append(curSection.Lines, diffLine)
 			continue
 		case line[0] == '@':
-			curSection = &DiffSection{}
+			curSection = &DiffSection{FileName: curFile.Name}
 			curFile.Sections = append(curFile.Sections, curSection)
 			lineSectionInfo := getDiffLineSectionInfo(curFile, line, leftLine-1, rightLine-1)
 			diffLine := &DiffLine{
@@ -872,6 +941,7 @@ func GetDiffRangeWithWhitespaceBehavior(repoPath, beforeCommitID, afterCommitID
 			diffFile.Sections = append(diffFile.Sections, tailSection)
 		}
 	}
+	diff.setLanguages(gitRepo, afterCommitID)
 
 	if err = cmd.Wait(); err != nil {
 		return nil, fmt.Errorf("Wait: %v", err)
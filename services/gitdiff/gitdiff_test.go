@@ -26,19 +26,38 @@ func assertEqual(t *testing.T, s1 string, s2 template.HTML) {
 }
 
 func TestDiffToHTML(t *testing.T) {
-	assertEqual(t, "foo <span class=\"added-code\">bar</span> biz", diffToHTML([]dmp.Diff{
+	assertEqual(t, "foo <span class=\"added-code\">bar</span> biz", diffToHTML("", "", []dmp.Diff{
 		{Type: dmp.DiffEqual, Text: "foo "},
 		{Type: dmp.DiffInsert, Text: "bar"},
 		{Type: dmp.DiffDelete, Text: " baz"},
 		{Type: dmp.DiffEqual, Text: " biz"},
 	}, DiffLineAdd))
 
-	assertEqual(t, "foo <span class=\"removed-code\">bar</span> biz", diffToHTML([]dmp.Diff{
+	assertEqual(t, "foo <span class=\"removed-code\">bar</span> biz", diffToHTML("", "", []dmp.Diff{
 		{Type: dmp.DiffEqual, Text: "foo "},
 		{Type: dmp.DiffDelete, Text: "bar"},
 		{Type: dmp.DiffInsert, Text: " baz"},
 		{Type: dmp.DiffEqual, Text: " biz"},
 	}, DiffLineDel))
+
+	assertEqual(t, "<span class=\"nx\">foo</span> <span class=\"o\">:=</span> <span class=\"added-code\"><span class=\"s\">&#34;bar&#34;</span></span>", diffToHTML("main.go", "", []dmp.Diff{
+		{Type: dmp.DiffEqual, Text: "foo := "},
+		{Type: dmp.DiffDelete, Text: "bar"},
+		{Type: dmp.DiffInsert, Text: "\"bar\""},
+	}, DiffLineAdd))
+
+	// a change in the middle of a token splits it
+	assertEqual(t, "<span class=\"nx\">foo</span> <span class=\"o\">:=</span> <span class=\"s\">&#34;b</span><span class=\"removed-code\"><span class=\"s\">a</span></span><span class=\"s\">r&#34;</span>", diffToHTML("main.go", "", []dmp.Diff{
+		{Type: dmp.DiffEqual, Text: "foo := \"b"},
+		{Type: dmp.DiffDelete, Text: "a"},
+		{Type: dmp.DiffInsert, Text: "i"},
+		{Type: dmp.DiffEqual, Text: "r\""},
+	}, DiffLineDel))
+
+	// the language set in .gitattributes takes precedence over the file name
+	assertEqual(t, "<span class=\"c1\"># foo</span>", diffToHTML("foo.txt", "Python", []dmp.Diff{
+		{Type: dmp.DiffEqual, Text: "# foo"},
+	}, DiffLinePlain))
 }
 
 const exampleDiff = `diff --git a/README.md b/README.md
@@ -79,6 +98,19 @@ func TestCutDiffAroundLine(t *testing.T) {
 	// Line is out of scope
 	emptyResult = CutDiffAroundLine(strings.NewReader(exampleDiff), 434, false, 0)
 	assert.Empty(t, emptyResult)
+
+	// "\ No newline at end of file" is no line of the file
+	const noNewlineDiff = `diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1 +1,2 @@
+-Description
+\ No newline at end of file
++Hello
++World
+`
+	result = CutDiffAroundLine(strings.NewReader(noNewlineDiff), 1, false, 3)
+	assert.True(t, strings.HasSuffix(result, "\n+Hello"), result)
 }
 
 func BenchmarkCutDiffAroundLine(b *testing.B) {
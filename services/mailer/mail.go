@@ -14,6 +14,7 @@ import (
 	"regexp"
 	"strings"
 	texttmpl "text/template"
+	"time"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/base"
@@ -32,7 +33,8 @@ const (
 	mailAuthResetPassword  base.TplName = "auth/reset_passwd"
 	mailAuthRegisterNotify base.TplName = "auth/register_notify"
 
-	mailNotifyCollaborator base.TplName = "notify/collaborator"
+	mailNotifyCollaborator    base.TplName = "notify/collaborator"
+	mailNotifyWebhookDisabled base.TplName = "notify/webhook_disabled"
 
 	// There's no actual limit for subject in RFC 5322
 	mailMaxSubjectRunes = 256
@@ -52,7 +54,7 @@ func InitMailRender(subjectTpl *texttmpl.Template, bodyTpl *template.Template) {
 
 // SendTestMail sends a test mail
 func SendTestMail(email string) error {
-	return gomail.Send(Sender, NewMessage([]string{email}, "Gitea Test Email!", "Gitea Test Email!").Message)
+	return gomail.Send(Sender, NewMessage([]string{email}, "Gitea Test Email!", "Gitea Test Email!").ToMessage())
 }
 
 // SendUserMail sends a mail to the user
@@ -164,6 +166,37 @@ func SendCollaboratorMail(u, doer *models.User, repo *models.Repository) {
 	SendAsync(msg)
 }
 
+// SendWebhookDisabledMail notifies the owners of a webhook that it has been deactivated
+// because its deliveries kept failing.
+func SendWebhookDisabledMail(tos []*models.User, w *models.Webhook, ownerName, link string) {
+	subject := fmt.Sprintf("Webhook for %s has been deactivated", ownerName)
+
+	data := map[string]interface{}{
+		"Subject":      subject,
+		"OwnerName":    ownerName,
+		"URL":          w.URL,
+		"FailureCount": w.FailureCount,
+		"Link":         link,
+	}
+
+	var content bytes.Buffer
+
+	if err := bodyTemplates.ExecuteTemplate(&content, string(mailNotifyWebhookDisabled), data); err != nil {
+		log.Error("Template: %v", err)
+		return
+	}
+
+	for _, u := range tos {
+		if !u.IsActive || u.ProhibitLogin {
+			continue
+		}
+		msg := NewMessage([]string{u.Email}, subject, content.String())
+		msg.Info = fmt.Sprintf("UID: %d, webhook %d deactivated", u.ID, w.ID)
+
+		SendAsync(msg)
+	}
+}
+
 func composeIssueCommentMessages(ctx *mailCommentContext, tos []string, fromMention bool, info string) []*Message {
 
 	var (
@@ -252,15 +285,40 @@ func composeIssueCommentMessages(ctx *mailCommentContext, tos []string, fromMent
 		if actName == "new" {
 			msg.SetHeader("Message-ID", "<"+ctx.Issue.ReplyReference()+">")
 		} else {
+			msg.SetHeader("Message-ID", generateMessageIDForIssue(ctx.Issue, ctx.Comment, actName))
 			msg.SetHeader("In-Reply-To", "<"+ctx.Issue.ReplyReference()+">")
 			msg.SetHeader("References", "<"+ctx.Issue.ReplyReference()+">")
 		}
+		for key, value := range generateAdditionalHeaders(ctx.Issue.Repo) {
+			msg.SetHeader(key, value)
+		}
 		msgs = append(msgs, msg)
 	}
 
 	return msgs
 }
 
+// generateMessageIDForIssue returns the Message-ID of a mail about a comment or another change of
+// the issue, the mails of all recipients share it
+func generateMessageIDForIssue(issue *models.Issue, comment *models.Comment, actName string) string {
+	reference := strings.TrimSuffix(issue.ReplyReference(), "@"+setting.Domain)
+	if comment != nil {
+		return fmt.Sprintf("<%s/comment/%d@%s>", reference, comment.ID, setting.Domain)
+	}
+	return fmt.Sprintf("<%s/%s/%d@%s>", reference, actName, time.Now().UnixNano(), setting.Domain)
+}
+
+// generateAdditionalHeaders returns the headers which allow mail clients to filter the mails
+// of a repository
+func generateAdditionalHeaders(repo *models.Repository) map[string]string {
+	return map[string]string{
+		// https://tools.ietf.org/html/rfc2919
+		"List-ID": fmt.Sprintf("%s <%s.%s.%s>", repo.FullName(), repo.Name, repo.OwnerName, setting.Domain),
+		// https://tools.ietf.org/html/rfc2369
+		"List-Archive": fmt.Sprintf("<%s>", repo.HTMLURL()),
+	}
+}
+
 func sanitizeSubject(subject string) string {
 	runes := []rune(strings.TrimSpace(subjectRemoveSpaces.ReplaceAllLiteralString(subject, " ")))
 	if len(runes) > mailMaxSubjectRunes {
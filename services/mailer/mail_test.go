@@ -62,16 +62,19 @@ func TestComposeIssueCommentMessage(t *testing.T) {
 		Content: "test body", Comment: comment}, tos, false, "issue comment")
 	assert.Len(t, msgs, 2)
 
-	mailto := msgs[0].GetHeader("To")
-	subject := msgs[0].GetHeader("Subject")
-	inreplyTo := msgs[0].GetHeader("In-Reply-To")
-	references := msgs[0].GetHeader("References")
+	mailto := msgs[0].ToMessage().GetHeader("To")
+	subject := msgs[0].ToMessage().GetHeader("Subject")
+	inreplyTo := msgs[0].ToMessage().GetHeader("In-Reply-To")
+	references := msgs[0].ToMessage().GetHeader("References")
 
 	assert.Len(t, mailto, 1, "exactly one recipient is expected in the To field")
 	assert.Equal(t, "Re: ", subject[0][:4], "Comment reply subject should contain Re:")
 	assert.Equal(t, "Re: [user2/repo1] @user2 #1 - issue1", subject[0])
 	assert.Equal(t, inreplyTo[0], "<user2/repo1/issues/1@localhost>", "In-Reply-To header doesn't match")
 	assert.Equal(t, references[0], "<user2/repo1/issues/1@localhost>", "References header doesn't match")
+	assert.Equal(t, []string{"<user2/repo1/issues/1/comment/2@localhost>"}, msgs[0].ToMessage().GetHeader("Message-ID"))
+	assert.Equal(t, []string{"user2/repo1 <repo1.user2.localhost>"}, msgs[0].ToMessage().GetHeader("List-ID"))
+	assert.Equal(t, []string{"<" + repo.HTMLURL() + ">"}, msgs[0].ToMessage().GetHeader("List-Archive"))
 }
 
 func TestComposeIssueMessage(t *testing.T) {
@@ -96,14 +99,14 @@ func TestComposeIssueMessage(t *testing.T) {
 		Content: "test body"}, tos, false, "issue create")
 	assert.Len(t, msgs, 2)
 
-	mailto := msgs[0].GetHeader("To")
-	subject := msgs[0].GetHeader("Subject")
-	messageID := msgs[0].GetHeader("Message-ID")
+	mailto := msgs[0].ToMessage().GetHeader("To")
+	subject := msgs[0].ToMessage().GetHeader("Subject")
+	messageID := msgs[0].ToMessage().GetHeader("Message-ID")
 
 	assert.Len(t, mailto, 1, "exactly one recipient is expected in the To field")
 	assert.Equal(t, "[user2/repo1] @user2 #1 - issue1", subject[0])
-	assert.Nil(t, msgs[0].GetHeader("In-Reply-To"))
-	assert.Nil(t, msgs[0].GetHeader("References"))
+	assert.Nil(t, msgs[0].ToMessage().GetHeader("In-Reply-To"))
+	assert.Nil(t, msgs[0].ToMessage().GetHeader("References"))
 	assert.Equal(t, messageID[0], "<user2/repo1/issues/1@localhost>", "Message-ID header doesn't match")
 }
 
@@ -134,9 +137,9 @@ func TestTemplateSelection(t *testing.T) {
 	InitMailRender(stpl, btpl)
 
 	expect := func(t *testing.T, msg *Message, expSubject, expBody string) {
-		subject := msg.GetHeader("Subject")
+		subject := msg.ToMessage().GetHeader("Subject")
 		msgbuf := new(bytes.Buffer)
-		_, _ = msg.WriteTo(msgbuf)
+		_, _ = msg.ToMessage().WriteTo(msgbuf)
 		wholemsg := msgbuf.String()
 		assert.Equal(t, []string{expSubject}, subject)
 		assert.Contains(t, wholemsg, expBody)
@@ -188,9 +191,9 @@ func TestTemplateServices(t *testing.T) {
 		msg := testComposeIssueCommentMessage(t, &mailCommentContext{Issue: issue, Doer: doer, ActionType: actionType,
 			Content: "test body", Comment: comment}, tos, fromMention, "TestTemplateServices")
 
-		subject := msg.GetHeader("Subject")
+		subject := msg.ToMessage().GetHeader("Subject")
 		msgbuf := new(bytes.Buffer)
-		_, _ = msg.WriteTo(msgbuf)
+		_, _ = msg.ToMessage().WriteTo(msgbuf)
 		wholemsg := msgbuf.String()
 
 		assert.Equal(t, []string{expSubject}, subject)
@@ -8,8 +8,11 @@ package mailer
 import (
 	"bytes"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"math/rand"
 	"net"
 	"net/smtp"
 	"os"
@@ -17,8 +20,11 @@ import (
 	"strings"
 	"time"
 
+	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/graceful"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/queue"
 	"code.gitea.io/gitea/modules/setting"
 
 	"github.com/jaytaylor/html2text"
@@ -27,38 +33,68 @@ import (
 
 // Message mail body and log info
 type Message struct {
-	Info string // Message information for log purpose.
-	*gomail.Message
+	Info            string // Message information for log purpose.
+	FromAddress     string
+	FromDisplayName string
+	To              []string
+	Subject         string
+	Date            time.Time
+	Body            string
+	Headers         map[string][]string
+	// Attempts is the number of failed attempts to send the message
+	Attempts int
 }
 
-// NewMessageFrom creates new mail message object with custom From header.
-func NewMessageFrom(to []string, fromDisplayName, fromAddress, subject, body string) *Message {
-	log.Trace("NewMessageFrom (body):\n%s", body)
-
+// ToMessage converts a Message to gomail.Message
+func (m *Message) ToMessage() *gomail.Message {
 	msg := gomail.NewMessage()
-	msg.SetAddressHeader("From", fromAddress, fromDisplayName)
-	msg.SetHeader("To", to...)
+	msg.SetAddressHeader("From", m.FromAddress, m.FromDisplayName)
+	msg.SetHeader("To", m.To...)
+	for header := range m.Headers {
+		msg.SetHeader(header, m.Headers[header]...)
+	}
+
 	if len(setting.MailService.SubjectPrefix) > 0 {
-		msg.SetHeader("Subject", setting.MailService.SubjectPrefix+" "+subject)
+		msg.SetHeader("Subject", setting.MailService.SubjectPrefix+" "+m.Subject)
 	} else {
-		msg.SetHeader("Subject", subject)
+		msg.SetHeader("Subject", m.Subject)
 	}
-	msg.SetDateHeader("Date", time.Now())
+	msg.SetDateHeader("Date", m.Date)
 	msg.SetHeader("X-Auto-Response-Suppress", "All")
 
-	plainBody, err := html2text.FromString(body)
+	plainBody, err := html2text.FromString(m.Body)
 	if err != nil || setting.MailService.SendAsPlainText {
-		if strings.Contains(base.TruncateString(body, 100), "<html>") {
+		if strings.Contains(base.TruncateString(m.Body, 100), "<html>") {
 			log.Warn("Mail contains HTML but configured to send as plain text.")
 		}
 		msg.SetBody("text/plain", plainBody)
 	} else {
 		msg.SetBody("text/plain", plainBody)
-		msg.AddAlternative("text/html", body)
+		msg.AddAlternative("text/html", m.Body)
+	}
+	return msg
+}
+
+// SetHeader adds additional headers to a message
+func (m *Message) SetHeader(field string, value ...string) {
+	if m.Headers == nil {
+		m.Headers = make(map[string][]string)
 	}
+	m.Headers[field] = value
+}
+
+// NewMessageFrom creates new mail message object with custom From header.
+func NewMessageFrom(to []string, fromDisplayName, fromAddress, subject, body string) *Message {
+	log.Trace("NewMessageFrom (body):\n%s", body)
 
 	return &Message{
-		Message: msg,
+		FromAddress:     fromAddress,
+		FromDisplayName: fromDisplayName,
+		To:              to,
+		Subject:         subject,
+		Date:            time.Now(),
+		Body:            body,
+		Headers:         map[string][]string{"Message-ID": {generateMessageID()}},
 	}
 }
 
@@ -67,6 +103,11 @@ func NewMessage(to []string, subject, body string) *Message {
 	return NewMessageFrom(to, setting.MailService.FromName, setting.MailService.FromEmail, subject, body)
 }
 
+// generateMessageID returns a new unique Message-ID, it is kept when the message is sent again
+func generateMessageID() string {
+	return fmt.Sprintf("<%d.%d@%s>", time.Now().UnixNano(), rand.Int63(), setting.Domain)
+}
+
 type loginAuth struct {
 	username, password string
 }
@@ -257,18 +298,113 @@ func (s *dummySender) Send(from string, to []string, msg io.WriterTo) error {
 	return nil
 }
 
-func processMailQueue() {
-	for msg := range mailQueue {
-		log.Trace("New e-mail sending request %s: %s", msg.GetHeader("To"), msg.Info)
-		if err := gomail.Send(Sender, msg.Message); err != nil {
-			log.Error("Failed to send emails %s: %s - %v", msg.GetHeader("To"), msg.Info, err)
+// fileSender writes every mail as .eml file to a directory
+type fileSender struct {
+}
+
+// Send send email
+func (s *fileSender) Send(from string, to []string, msg io.WriterTo) error {
+	if err := os.MkdirAll(setting.MailService.FilePath, os.ModePerm); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(setting.MailService.FilePath, time.Now().Format("20060102150405")+"-*.eml")
+	if err != nil {
+		return err
+	}
+	if _, err = msg.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	log.Trace("Mail From: %s To: %v written to %s", from, to, f.Name())
+	return f.Close()
+}
+
+func handle(data ...queue.Data) {
+	for _, datum := range data {
+		msg := datum.(*Message)
+		log.Trace("New e-mail sending request %s: %s", msg.To, msg.Info)
+		if err := gomail.Send(Sender, msg.ToMessage()); err != nil {
+			handleSendError(msg, err)
 		} else {
-			log.Trace("E-mails sent %s: %s", msg.GetHeader("To"), msg.Info)
+			log.Trace("E-mails sent %s: %s", msg.To, msg.Info)
+		}
+	}
+}
+
+// handleSendError sends the message again after a backoff which doubles with every failed attempt.
+// Once all attempts failed the message is stored, so an administrator can send it again.
+func handleSendError(msg *Message, err error) {
+	msg.Attempts++
+	if msg.Attempts >= setting.MailService.MaxAttempts {
+		log.Error("Failed to send emails %s: %s - %v", msg.To, msg.Info, err)
+		storeFailedMail(msg, err)
+		return
+	}
+
+	backoff := setting.MailService.RetryBackoff << uint(msg.Attempts-1)
+	if backoff <= 0 || backoff > setting.MailService.MaxRetryBackoff {
+		backoff = setting.MailService.MaxRetryBackoff
+	}
+	log.Warn("Failed to send emails %s: %s - %v, retrying in %v", msg.To, msg.Info, err, backoff)
+
+	go func() {
+		// The queue persists the message if it is pushed while shutting down
+		select {
+		case <-time.After(backoff):
+		case <-graceful.GetManager().IsShutdown():
 		}
+		if err := mailQueue.Push(msg); err != nil {
+			log.Error("Unable to push e-mail %s: %s to the queue: %v", msg.To, msg.Info, err)
+			storeFailedMail(msg, err)
+		}
+	}()
+}
+
+func storeFailedMail(msg *Message, sendErr error) {
+	content, err := json.Marshal(msg)
+	if err != nil {
+		log.Error("Unable to marshal failed e-mail %s: %s - %v", msg.To, msg.Info, err)
+		return
+	}
+	if err := models.CreateFailedMail(&models.FailedMail{
+		Recipients: strings.Join(msg.To, ", "),
+		Subject:    msg.Subject,
+		Info:       msg.Info,
+		Attempts:   msg.Attempts,
+		LastError:  sendErr.Error(),
+		Content:    string(content),
+	}); err != nil {
+		log.Error("CreateFailedMail: %v", err)
 	}
 }
 
-var mailQueue chan *Message
+// ResendFailedMails queues the failed mails with the given ids again and removes them from
+// the failed mails
+func ResendFailedMails(ids []int64) error {
+	if mailQueue == nil {
+		return fmt.Errorf("mail service is not enabled")
+	}
+	mails, err := models.GetFailedMailsByIDs(ids)
+	if err != nil {
+		return err
+	}
+	for _, mail := range mails {
+		msg := &Message{}
+		if err := json.Unmarshal([]byte(mail.Content), msg); err != nil {
+			return fmt.Errorf("unable to unmarshal failed mail %d: %v", mail.ID, err)
+		}
+		msg.Attempts = 0
+		if err := mailQueue.Push(msg); err != nil {
+			return err
+		}
+		if err := models.DeleteFailedMailsByIDs([]int64{mail.ID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var mailQueue queue.Queue
 
 // Sender sender for sending mail synchronously
 var Sender gomail.Sender
@@ -289,24 +425,35 @@ func NewContext() {
 		Sender = &sendmailSender{}
 	case "dummy":
 		Sender = &dummySender{}
+	case "file":
+		Sender = &fileSender{}
 	}
 
-	mailQueue = make(chan *Message, setting.MailService.QueueLength)
-	go processMailQueue()
+	mailQueue = queue.CreateQueue("mail", handle, &Message{})
+	if mailQueue == nil {
+		log.Fatal("Unable to create mail queue")
+	}
+
+	go graceful.GetManager().RunWithShutdownFns(mailQueue.Run)
 }
 
 // SendAsync send mail asynchronously
 func SendAsync(msg *Message) {
-	go func() {
-		mailQueue <- msg
-	}()
+	SendAsyncs([]*Message{msg})
 }
 
 // SendAsyncs send mails asynchronously
 func SendAsyncs(msgs []*Message) {
-	go func() {
-		for _, msg := range msgs {
-			mailQueue <- msg
-		}
-	}()
+	if mailQueue == nil {
+		log.Error("Mailer: SendAsyncs is being invoked but mail service hasn't been initialized")
+		return
+	}
+
+	for _, msg := range msgs {
+		go func(msg *Message) {
+			if err := mailQueue.Push(msg); err != nil {
+				log.Error("Unable to push e-mail %s: %s to the queue: %v", msg.To, msg.Info, err)
+			}
+		}(msg)
+	}
 }
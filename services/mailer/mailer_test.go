@@ -0,0 +1,78 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mailer
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/gomail.v2"
+)
+
+func TestMessageSerialization(t *testing.T) {
+	setting.MailService = &setting.Mailer{SubjectPrefix: "[Gitea]"}
+	setting.Domain = "localhost"
+
+	msg := NewMessageFrom([]string{"test@gitea.com"}, "Gitea", "gitea@gitea.com", "subject", "<p>body</p>")
+	msg.SetHeader("In-Reply-To", "<user2/repo1/issues/1@localhost>")
+	assert.Regexp(t, `^<\d+\.\d+@localhost>$`, msg.Headers["Message-ID"][0])
+
+	content, err := json.Marshal(msg)
+	assert.NoError(t, err)
+	restored := &Message{}
+	assert.NoError(t, json.Unmarshal(content, restored))
+
+	gomailMsg := restored.ToMessage()
+	assert.Equal(t, []string{"test@gitea.com"}, gomailMsg.GetHeader("To"))
+	assert.Equal(t, []string{"[Gitea] subject"}, gomailMsg.GetHeader("Subject"))
+	assert.Equal(t, msg.Headers["Message-ID"], gomailMsg.GetHeader("Message-ID"))
+	assert.Equal(t, []string{"<user2/repo1/issues/1@localhost>"}, gomailMsg.GetHeader("In-Reply-To"))
+	assert.Equal(t, []string{gomailMsg.FormatDate(msg.Date)}, gomailMsg.GetHeader("Date"))
+}
+
+func TestFileSender(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mail")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	setting.MailService = &setting.Mailer{FilePath: filepath.Join(dir, "mail")}
+
+	msg := NewMessageFrom([]string{"test@gitea.com"}, "Gitea", "gitea@gitea.com", "subject", "body")
+	assert.NoError(t, gomail.Send(&fileSender{}, msg.ToMessage()))
+
+	files, err := filepath.Glob(filepath.Join(dir, "mail", "*.eml"))
+	assert.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		content, err := ioutil.ReadFile(files[0])
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "To: test@gitea.com\r\n")
+		assert.Contains(t, string(content), "Subject: subject\r\n")
+	}
+}
+
+func TestHandleSendError(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	setting.MailService = &setting.Mailer{MaxAttempts: 2}
+
+	msg := NewMessageFrom([]string{"test@gitea.com"}, "Gitea", "gitea@gitea.com", "subject", "body")
+	msg.Attempts = 1
+	handleSendError(msg, errors.New("connection refused"))
+
+	mail := models.AssertExistsAndLoadBean(t, &models.FailedMail{Subject: "subject"}).(*models.FailedMail)
+	assert.EqualValues(t, "test@gitea.com", mail.Recipients)
+	assert.EqualValues(t, 2, mail.Attempts)
+	assert.EqualValues(t, "connection refused", mail.LastError)
+
+	stored := &Message{}
+	assert.NoError(t, json.Unmarshal([]byte(mail.Content), stored))
+	assert.Equal(t, msg.Headers, stored.Headers)
+}
@@ -17,6 +17,7 @@ import (
 	"code.gitea.io/gitea/modules/cache"
 	"code.gitea.io/gitea/modules/git"
 	"code.gitea.io/gitea/modules/log"
+	mirror_module "code.gitea.io/gitea/modules/mirror"
 	"code.gitea.io/gitea/modules/notification"
 	"code.gitea.io/gitea/modules/repository"
 	"code.gitea.io/gitea/modules/setting"
@@ -36,22 +37,22 @@ func readAddress(m *models.Mirror) {
 		return
 	}
 	var err error
-	m.Address, err = remoteAddress(m.Repo.RepoPath())
+	m.Address, err = remoteAddress(m.Repo.RepoPath(), "origin")
 	if err != nil {
 		log.Error("remoteAddress: %v", err)
 	}
 }
 
-func remoteAddress(repoPath string) (string, error) {
+func remoteAddress(repoPath, remoteName string) (string, error) {
 	var cmd *git.Command
 	binVersion, err := git.BinVersion()
 	if err != nil {
 		return "", err
 	}
 	if version.Compare(binVersion, "2.7", ">=") {
-		cmd = git.NewCommand("remote", "get-url", "origin")
+		cmd = git.NewCommand("remote", "get-url", remoteName)
 	} else {
-		cmd = git.NewCommand("config", "--get", "remote.origin.url")
+		cmd = git.NewCommand("config", "--get", "remote."+remoteName+".url")
 	}
 
 	result, err := cmd.RunInDir(repoPath)
@@ -70,7 +71,7 @@ func remoteAddress(repoPath string) (string, error) {
 // sanitizeOutput sanitizes output of a command, replacing occurrences of the
 // repository's remote address with a sanitized version.
 func sanitizeOutput(output, repoPath string) (string, error) {
-	remoteAddr, err := remoteAddress(repoPath)
+	remoteAddr, err := remoteAddress(repoPath, "origin")
 	if err != nil {
 		// if we're unable to load the remote address, then we're unable to
 		// sanitize.
@@ -174,6 +175,8 @@ func runSync(m *models.Mirror) ([]*mirrorSyncResult, bool) {
 	if m.EnablePrune {
 		gitArgs = append(gitArgs, "--prune")
 	}
+	// only the origin remote is fetched, other remotes are push mirrors
+	gitArgs = append(gitArgs, "origin")
 
 	stdoutBuilder := strings.Builder{}
 	stderrBuilder := strings.Builder{}
@@ -296,7 +299,7 @@ func Password(m *models.Mirror) string {
 	return password
 }
 
-// Update checks and updates mirror repositories.
+// Update checks and updates mirror repositories and push mirrors.
 func Update(ctx context.Context) {
 	log.Trace("Doing: Update")
 	if err := models.MirrorsIterate(func(idx int, bean interface{}) error {
@@ -315,9 +318,22 @@ func Update(ctx context.Context) {
 	}); err != nil {
 		log.Error("Update: %v", err)
 	}
+
+	if err := models.PushMirrorsIterate(func(idx int, bean interface{}) error {
+		m := bean.(*models.PushMirror)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Aborted due to shutdown")
+		default:
+			mirror_module.PushMirrorQueue().Add(m.ID)
+			return nil
+		}
+	}); err != nil {
+		log.Error("Update: %v", err)
+	}
 }
 
-// SyncMirrors checks and syncs mirrors.
+// SyncMirrors checks and syncs mirrors and push mirrors.
 // FIXME: graceful: this should be a persistable queue
 func SyncMirrors(ctx context.Context) {
 	// Start listening on new sync requests.
@@ -325,9 +341,12 @@ func SyncMirrors(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			mirrorQueue.Close()
+			mirror_module.PushMirrorQueue().Close()
 			return
 		case repoID := <-mirrorQueue.Queue():
 			syncMirror(repoID)
+		case mirrorID := <-mirror_module.PushMirrorQueue().Queue():
+			syncPushMirror(mirrorID)
 		}
 	}
 }
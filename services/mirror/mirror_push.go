@@ -0,0 +1,173 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package mirror
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	mirror_module "code.gitea.io/gitea/modules/mirror"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/timeutil"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/unknwon/com"
+)
+
+// ParsePushMirrorAddress checks if the address of a push mirror is a valid HTTP(S) URL
+// and returns it composed with the username and password of the remote.
+func ParsePushMirrorAddress(addr, username, password string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(addr))
+	if err != nil || u.Opaque != "" || !u.IsAbs() || u.Host == "" ||
+		!(u.Scheme == "http" || u.Scheme == "https") {
+		return "", models.ErrInvalidCloneAddr{IsURLError: true}
+	}
+	if len(username)+len(password) > 0 {
+		u.User = url.UserPassword(username, password)
+	}
+	return u.String(), nil
+}
+
+// AddPushMirror adds a push mirror of the repository to the remote address
+func AddPushMirror(repo *models.Repository, addr string, interval time.Duration, syncOnCommit bool) (*models.PushMirror, error) {
+	remoteSuffix, err := generate.GetRandomString(10)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &models.PushMirror{
+		RepoID:       repo.ID,
+		Repo:         repo,
+		RemoteName:   "remote_mirror_" + remoteSuffix,
+		Interval:     interval,
+		SyncOnCommit: syncOnCommit,
+	}
+
+	repoPath := repo.RepoPath()
+	if _, err := git.NewCommand("remote", "add", m.RemoteName, addr).RunInDir(repoPath); err != nil {
+		return nil, err
+	}
+	// only branches and tags are pushed, not the internal refs like refs/pull/*
+	for _, refspec := range []string{"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"} {
+		if _, err := git.NewCommand("config", "--add", "remote."+m.RemoteName+".push", refspec).RunInDir(repoPath); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := models.InsertPushMirror(m); err != nil {
+		if _, errRm := git.NewCommand("remote", "rm", m.RemoteName).RunInDir(repoPath); errRm != nil {
+			log.Error("Unable to remove remote %s of %s: %v", m.RemoteName, repo.FullName(), errRm)
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// RemovePushMirror removes the push mirror and its remote from the repository
+func RemovePushMirror(m *models.PushMirror) error {
+	_, err := git.NewCommand("remote", "rm", m.RemoteName).RunInDir(m.Repo.RepoPath())
+	if err != nil && !strings.HasPrefix(err.Error(), "exit status 128 - fatal: No such remote") &&
+		!strings.HasPrefix(err.Error(), "exit status 2 - error: No such remote") {
+		return err
+	}
+	return models.DeletePushMirrorByID(m.ID)
+}
+
+// PushMirrorAddress returns the address of the push mirror without credentials
+func PushMirrorAddress(m *models.PushMirror) string {
+	addr, err := remoteAddress(m.Repo.RepoPath(), m.RemoteName)
+	if err != nil {
+		log.Error("remoteAddress: %v", err)
+		return ""
+	}
+	return util.SanitizeURLCredentials(addr, false)
+}
+
+// StartToPushMirror adds the push mirror to the queue to be synced
+func StartToPushMirror(m *models.PushMirror) {
+	mirror_module.AddPushMirrorToQueue(m.ID)
+}
+
+// StartToPushMirrors adds all push mirrors of the repository to the queue to be synced
+func StartToPushMirrors(repoID int64) error {
+	mirrors, err := models.GetPushMirrorsByRepoID(repoID)
+	if err != nil {
+		return err
+	}
+	for _, m := range mirrors {
+		StartToPushMirror(m)
+	}
+	return nil
+}
+
+func syncPushMirror(mirrorID string) {
+	log.Trace("SyncPushMirrors [mirror_id: %v]", mirrorID)
+	mirror_module.PushMirrorQueue().Remove(mirrorID)
+
+	m, err := models.GetPushMirrorByID(com.StrTo(mirrorID).MustInt64())
+	if err != nil {
+		if err != models.ErrPushMirrorNotExist {
+			log.Error("GetPushMirrorByID [%s]: %v", mirrorID, err)
+		}
+		return
+	}
+	if m.Repo == nil {
+		log.Error("Disconnected push mirror found: %d", m.ID)
+		return
+	}
+
+	m.LastError = ""
+	if err := runPushSync(m); err != nil {
+		m.LastError = err.Error()
+		desc := fmt.Sprintf("Failed to push mirror repository '%s': %s", m.Repo.RepoPath(), m.LastError)
+		if err = models.CreateRepositoryNotice(desc); err != nil {
+			log.Error("CreateRepositoryNotice: %v", err)
+		}
+	}
+	m.LastUpdateUnix = timeutil.TimeStampNow()
+
+	sync := &models.PushMirrorSync{
+		IsSucceed: m.LastError == "",
+		Error:     m.LastError,
+	}
+	if err := models.UpdatePushMirrorWithSync(m, sync); err != nil {
+		log.Error("UpdatePushMirrorWithSync [%s]: %v", mirrorID, err)
+	}
+}
+
+// runPushSync pushes all branches and tags of the repository to the remote of the push mirror
+func runPushSync(m *models.PushMirror) error {
+	repoPath := m.Repo.RepoPath()
+	timeout := time.Duration(setting.Git.Timeout.Mirror) * time.Second
+
+	stdoutBuilder := strings.Builder{}
+	stderrBuilder := strings.Builder{}
+	if err := git.NewCommand("push", "--force", "--prune", m.RemoteName).
+		SetDescription(fmt.Sprintf("PushMirror.runPushSync: %s", m.Repo.FullName())).
+		RunInDirTimeoutPipeline(timeout, repoPath, &stdoutBuilder, &stderrBuilder); err != nil {
+		// sanitize the output, since it may contain the remote address, which may
+		// contain a password
+		remoteAddr, addrErr := remoteAddress(repoPath, m.RemoteName)
+		if addrErr != nil {
+			log.Error("remoteAddress: %v", addrErr)
+			return fmt.Errorf("unable to push to the remote")
+		}
+		stderrMessage := util.SanitizeMessage(stderrBuilder.String(), remoteAddr)
+		errMessage := util.SanitizeMessage(err.Error(), remoteAddr)
+		log.Error("Failed to push mirror repository %v to %s:\nStderr: %s\nErr: %v", m.Repo, m.RemoteName, stderrMessage, errMessage)
+		// a push which timed out has no output, the sync must not look successful
+		if strings.TrimSpace(stderrMessage) == "" {
+			return fmt.Errorf("%s", errMessage)
+		}
+		return fmt.Errorf("%s", strings.TrimSpace(stderrMessage))
+	}
+	return nil
+}
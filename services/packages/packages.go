@@ -0,0 +1,240 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packages
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	packages_module "code.gitea.io/gitea/modules/packages"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/timeutil"
+)
+
+// FileInfo describes a file to add to a package version
+type FileInfo struct {
+	Filename string
+	Data     *packages_module.HashedBuffer
+	IsLead   bool
+	// OverwriteExisting replaces an existing file with the same name
+	OverwriteExisting bool
+}
+
+// MarshalMetadata converts the metadata of a package version to JSON
+func MarshalMetadata(metadata interface{}) (string, error) {
+	if metadata == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// CreatePackageAndAddFile creates a new package version with a single file.
+// models.ErrPackageVersionAlreadyExist is returned if the version exists already.
+func CreatePackageAndAddFile(opts *models.PackageCreationOptions, fi *FileInfo) (*models.PackageVersion, *models.PackageFile, error) {
+	pv, err := models.CreatePackageVersion(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pf, err := AddFileToPackageVersion(pv, fi)
+	if err != nil {
+		if errDelete := models.DeletePackageVersion(pv); errDelete != nil {
+			log.Error("DeletePackageVersion: %v", errDelete)
+		}
+		return nil, nil, err
+	}
+	return pv, pf, nil
+}
+
+// CreatePackageOrAddFileToExisting adds the file to the package version and creates the
+// version if it does not exist yet.
+func CreatePackageOrAddFileToExisting(opts *models.PackageCreationOptions, fi *FileInfo) (*models.PackageVersion, *models.PackageFile, error) {
+	pv, created, err := models.GetOrCreatePackageVersion(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pf, err := AddFileToPackageVersion(pv, fi)
+	if err != nil {
+		if created {
+			if errDelete := models.DeletePackageVersion(pv); errDelete != nil {
+				log.Error("DeletePackageVersion: %v", errDelete)
+			}
+		}
+		return nil, nil, err
+	}
+	return pv, pf, nil
+}
+
+// AddFileToPackageVersion stores the content of the file and adds it to the version
+func AddFileToPackageVersion(pv *models.PackageVersion, fi *FileInfo) (*models.PackageFile, error) {
+	pb, err := SaveBlob(fi.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	pf, err := models.AddPackageFile(pv.ID, pb.ID, fi.Filename, fi.IsLead)
+	if models.IsErrPackageFileAlreadyExist(err) && fi.OverwriteExisting {
+		var old *models.PackageFile
+		if old, err = models.GetPackageFileByName(pv.ID, fi.Filename); err != nil {
+			return nil, err
+		}
+		if err = models.DeletePackageFile(old); err != nil {
+			return nil, err
+		}
+		pf, err = models.AddPackageFile(pv.ID, pb.ID, fi.Filename, fi.IsLead)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pf.Blob = pb
+	return pf, nil
+}
+
+// SaveBlob stores the content of the buffer, content which exists already is reused
+func SaveBlob(hb *packages_module.HashedBuffer) (*models.PackageBlob, error) {
+	pb, exists, err := models.GetOrInsertPackageBlob(&models.PackageBlob{
+		Size:       hb.Size(),
+		HashMD5:    hb.HashMD5,
+		HashSHA1:   hb.HashSHA1,
+		HashSHA256: hb.HashSHA256,
+		HashSHA512: hb.HashSHA512,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	contentStore := packages_module.NewContentStore()
+	if exists && contentStore.Exists(pb.HashSHA256) {
+		return pb, nil
+	}
+
+	if _, err = hb.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err = contentStore.Save(pb.HashSHA256, hb); err != nil {
+		if !exists {
+			if errDelete := models.DeletePackageBlobByID(pb.ID); errDelete != nil {
+				log.Error("DeletePackageBlobByID: %v", errDelete)
+			}
+		}
+		return nil, fmt.Errorf("Save: %v", err)
+	}
+	return pb, nil
+}
+
+// DeletePackageVersion removes the version with all of its files
+func DeletePackageVersion(pv *models.PackageVersion) error {
+	if err := models.DeletePackageVersion(pv); err != nil {
+		return err
+	}
+	return DeleteUnreferencedBlobs()
+}
+
+// DeletePackageFile removes a single file of a version
+func DeletePackageFile(pf *models.PackageFile) error {
+	if err := models.DeletePackageFile(pf); err != nil {
+		return err
+	}
+	return DeleteUnreferencedBlobs()
+}
+
+// DeleteUnreferencedBlobs removes all blobs which are not used by any file
+func DeleteUnreferencedBlobs() error {
+	pbs, err := models.GetUnreferencedPackageBlobs()
+	if err != nil {
+		return err
+	}
+
+	contentStore := packages_module.NewContentStore()
+	for _, pb := range pbs {
+		if err := models.DeletePackageBlobByID(pb.ID); err != nil {
+			return err
+		}
+		if err := contentStore.Delete(pb.HashSHA256); err != nil {
+			log.Error("Unable to remove package blob %s: %v", pb.HashSHA256, err)
+		}
+	}
+	return nil
+}
+
+// OpenPackageFile returns the content of the file
+func OpenPackageFile(pf *models.PackageFile) (storage.Object, error) {
+	if err := pf.LoadBlob(); err != nil {
+		return nil, err
+	}
+	return packages_module.NewContentStore().Get(pf.Blob.HashSHA256)
+}
+
+// GetFileStreamByPackageVersion returns the content of the named file of the version.
+// The download counter of the version is increased.
+func GetFileStreamByPackageVersion(pv *models.PackageVersion, filename string) (storage.Object, *models.PackageFile, error) {
+	pf, err := models.GetPackageFileByName(pv.ID, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s, err := OpenPackageFile(pf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := models.IncreasePackageVersionDownloadCount(pv.ID); err != nil {
+		log.Error("IncreasePackageVersionDownloadCount: %v", err)
+	}
+	return s, pf, nil
+}
+
+// BlobUploadPath returns the path of the temporary file of a chunked upload
+func BlobUploadPath(id string) string {
+	return filepath.Join(setting.Packages.ChunkedUploadPath, filepath.Base(id))
+}
+
+// DeleteBlobUpload removes the chunked upload and its temporary file
+func DeleteBlobUpload(id string) error {
+	if err := os.Remove(BlobUploadPath(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return models.DeletePackageBlobUploadByID(id)
+}
+
+// Cleanup removes stale chunked uploads and unreferenced blobs
+func Cleanup(ctx context.Context) {
+	log.Trace("Doing: CleanupPackages")
+
+	olderThan := timeutil.TimeStamp(time.Now().Add(-setting.Cron.CleanupPackages.OlderThan).Unix())
+	pbus, err := models.GetPackageBlobUploadsOlderThan(olderThan)
+	if err != nil {
+		log.Error("GetPackageBlobUploadsOlderThan: %v", err)
+		return
+	}
+	for _, pbu := range pbus {
+		select {
+		case <-ctx.Done():
+			log.Warn("CleanupPackages: Aborted due to shutdown")
+			return
+		default:
+		}
+		if err := DeleteBlobUpload(pbu.ID); err != nil {
+			log.Error("DeleteBlobUpload [%s]: %v", pbu.ID, err)
+		}
+	}
+
+	if err := DeleteUnreferencedBlobs(); err != nil {
+		log.Error("DeleteUnreferencedBlobs: %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"context"
+	"os"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/sync"
+
+	"github.com/unknwon/com"
+)
+
+// autoMergeQueue represents a queue of pull requests scheduled to be merged whose checks have to be evaluated
+var autoMergeQueue = sync.NewUniqueQueue(setting.Repository.PullRequestQueueLength)
+
+// ScheduleAutoMerge schedules the pull request to be merged by doer with the merge style once all
+// required status checks and approvals are satisfied
+func ScheduleAutoMerge(doer *models.User, pr *models.PullRequest, style models.MergeStyle, message string) error {
+	if err := pr.GetBaseRepo(); err != nil {
+		return err
+	}
+	prUnit, err := pr.BaseRepo.GetUnit(models.UnitTypePullRequests)
+	if err != nil {
+		return err
+	}
+	if !prUnit.PullRequestsConfig().IsMergeStyleAllowed(style) {
+		return models.ErrInvalidMergeStyle{ID: pr.BaseRepo.ID, Style: style}
+	}
+
+	if err := models.ScheduleAutoMerge(doer, pr, style, message); err != nil {
+		return err
+	}
+	AddToAutoMergeQueue(pr.ID)
+	return nil
+}
+
+// RemoveScheduledAutoMerge cancels the scheduled merge of the pull request
+func RemoveScheduledAutoMerge(doer *models.User, pr *models.PullRequest) error {
+	_, err := models.RemoveScheduledAutoMerge(doer, pr)
+	return err
+}
+
+// AddToAutoMergeQueue adds the pull request to the queue to be merged if it is scheduled
+// to be merged and all checks succeed
+func AddToAutoMergeQueue(pullID int64) {
+	go autoMergeQueue.Add(pullID)
+}
+
+// AddScheduledToAutoMergeQueue adds all pull requests into the repository which are scheduled
+// to be merged to the queue, it has to be called when a commit status of the repository is created
+func AddScheduledToAutoMergeQueue(repoID int64) {
+	ids, err := models.GetScheduledAutoMergePullIDsByBaseRepo(repoID)
+	if err != nil {
+		log.Error("GetScheduledAutoMergePullIDsByBaseRepo[%d]: %v", repoID, err)
+		return
+	}
+	for _, id := range ids {
+		AddToAutoMergeQueue(id)
+	}
+}
+
+// cancelAutoMergeOnPush cancels the scheduled merge of a pull request when new commits are pushed
+func cancelAutoMergeOnPush(doer *models.User, pr *models.PullRequest) {
+	removed, err := models.RemoveScheduledAutoMerge(doer, pr)
+	if err != nil {
+		log.Error("RemoveScheduledAutoMerge[%d]: %v", pr.ID, err)
+	} else if removed {
+		log.Trace("Scheduled merge of pull request %d cancelled by push of %s", pr.ID, doer.Name)
+	}
+}
+
+// handleAutoMerge merges the pull request if it is scheduled to be merged and all checks succeed
+func handleAutoMerge(pullID int64) {
+	scheduled, autoMerge, err := models.GetScheduledAutoMergeByPullID(pullID)
+	if err != nil {
+		log.Error("GetScheduledAutoMergeByPullID[%d]: %v", pullID, err)
+		return
+	} else if !scheduled {
+		return
+	}
+
+	pr, err := models.GetPullRequestByID(pullID)
+	if err != nil {
+		log.Error("GetPullRequestByID[%d]: %v", pullID, err)
+		return
+	}
+	if err = pr.LoadIssue(); err != nil {
+		log.Error("LoadIssue[%d]: %v", pullID, err)
+		return
+	} else if err = pr.Issue.LoadPoster(); err != nil {
+		log.Error("LoadPoster[%d]: %v", pullID, err)
+		return
+	}
+	if pr.HasMerged || pr.Issue.IsClosed {
+		if _, err := models.RemoveScheduledAutoMerge(nil, pr); err != nil {
+			log.Error("RemoveScheduledAutoMerge[%d]: %v", pullID, err)
+		}
+		return
+	}
+	if !pr.CanAutoMerge() || pr.IsWorkInProgress() {
+		return
+	}
+
+	if err = pr.GetHeadRepo(); err != nil {
+		log.Error("GetHeadRepo[%d]: %v", pullID, err)
+		return
+	} else if err = pr.GetBaseRepo(); err != nil {
+		log.Error("GetBaseRepo[%d]: %v", pullID, err)
+		return
+	}
+	pr.Issue.Repo = pr.BaseRepo
+
+	// the scheduler may have lost the write access since the merge was scheduled
+	perm, err := models.GetUserRepoPermission(pr.BaseRepo, autoMerge.Doer)
+	if err != nil {
+		log.Error("GetUserRepoPermission[%d]: %v", pullID, err)
+		return
+	}
+	if !perm.CanWrite(models.UnitTypeCode) {
+		if _, err := models.RemoveScheduledAutoMerge(autoMerge.Doer, pr); err != nil {
+			log.Error("RemoveScheduledAutoMerge[%d]: %v", pullID, err)
+		}
+		log.Trace("Scheduled merge of pull request %d cancelled, %s has no write access anymore", pullID, autoMerge.Doer.Name)
+		return
+	}
+
+	if passed, err := IsPullCommitStatusPass(pr); err != nil {
+		log.Error("IsPullCommitStatusPass[%d]: %v", pullID, err)
+		return
+	} else if !passed {
+		return
+	}
+
+	// wait for the approvals required by the protected branch
+	if err := pr.CheckUserAllowedToMerge(autoMerge.Doer); err != nil {
+		if !models.IsErrNotAllowedToMerge(err) {
+			log.Error("CheckUserAllowedToMerge[%d]: %v", pullID, err)
+		}
+		return
+	}
+
+	if noDeps, err := models.IssueNoDependenciesLeft(pr.Issue); err != nil {
+		log.Error("IssueNoDependenciesLeft[%d]: %v", pullID, err)
+		return
+	} else if !noDeps {
+		return
+	}
+
+	// the merge queue merges the pull request once the checks of its speculative merge succeed
+	if pr.ProtectedBranch != nil && pr.ProtectedBranch.EnableMergeQueue {
+		if err := AddToMergeQueue(autoMerge.Doer, pr, autoMerge.MergeStyle, autoMerge.Message); err != nil && !models.IsErrPullRequestAlreadyInMergeQueue(err) {
+			log.Error("AddToMergeQueue[%d]: %v", pullID, err)
+			return
+		}
+		if _, err := models.RemoveScheduledAutoMerge(nil, pr); err != nil {
+			log.Error("RemoveScheduledAutoMerge[%d]: %v", pullID, err)
+		}
+		return
+	}
+
+	baseGitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		log.Error("OpenRepository[%s]: %v", pr.BaseRepo.RepoPath(), err)
+		return
+	}
+	defer baseGitRepo.Close()
+
+	message := autoMerge.Message
+	if len(message) == 0 {
+		if autoMerge.MergeStyle == models.MergeStyleSquash {
+			message = pr.GetDefaultSquashMessage()
+		} else {
+			message = pr.GetDefaultMergeMessage()
+		}
+	}
+
+	if err := Merge(pr, autoMerge.Doer, baseGitRepo, autoMerge.MergeStyle, message); err != nil {
+		log.Error("Scheduled merge of pull request %d by %s failed: %v", pullID, autoMerge.Doer.Name, err)
+		return
+	}
+	if _, err := models.RemoveScheduledAutoMerge(nil, pr); err != nil {
+		log.Error("RemoveScheduledAutoMerge[%d]: %v", pullID, err)
+	}
+	log.Trace("Pull request %d merged as scheduled by %s", pullID, autoMerge.Doer.Name)
+}
+
+// handleAutoMerges merges the pull requests added to the queue once their checks succeed
+func handleAutoMerges(ctx context.Context) {
+	go func() {
+		ids, err := models.GetScheduledAutoMergePullIDs()
+		if err != nil {
+			log.Error("GetScheduledAutoMergePullIDs: %v", err)
+			return
+		}
+		for _, id := range ids {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				autoMergeQueue.Add(id)
+			}
+		}
+	}()
+
+	for {
+		select {
+		case pullID := <-autoMergeQueue.Queue():
+			autoMergeQueue.Remove(pullID)
+			handleAutoMerge(com.StrTo(pullID).MustInt64())
+		case <-ctx.Done():
+			autoMergeQueue.Close()
+			log.Info("PID: %d Pull Request auto merge shutdown", os.Getpid())
+			return
+		}
+	}
+}
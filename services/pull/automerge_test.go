@@ -0,0 +1,28 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleAutoMergeWithoutWriteAccess(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: 2}).(*models.PullRequest)
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 4}).(*models.User)
+
+	// user4 can read the base repository but is not allowed to push to it
+	assert.NoError(t, models.ScheduleAutoMerge(doer, pr, models.MergeStyleMerge, ""))
+	handleAutoMerge(pr.ID)
+
+	models.AssertNotExistsBean(t, &models.PullAutoMerge{PullID: pr.ID})
+	models.AssertExistsAndLoadBean(t, &models.Comment{Type: models.CommentTypePRUnScheduledToAutoMerge, IssueID: pr.IssueID, PosterID: doer.ID})
+	pr = models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: 2}).(*models.PullRequest)
+	assert.False(t, pr.HasMerged)
+}
@@ -212,4 +212,6 @@ func TestPullRequests(ctx context.Context) {
 // Init runs the task queue to test all the checking status pull requests
 func Init() {
 	go graceful.GetManager().RunWithShutdownContext(TestPullRequests)
+	go graceful.GetManager().RunWithShutdownContext(handleAutoMerges)
+	go graceful.GetManager().RunWithShutdownContext(handleMergeQueues)
 }
@@ -0,0 +1,58 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+)
+
+// RequestCodeOwnerReviews requests reviews from the code owners of the files changed by the
+// pull request as doer. Owners who are already requested or have reviewed are skipped,
+// as is the poster of the pull request.
+func RequestCodeOwnerReviews(pr *models.PullRequest, doer *models.User) error {
+	if err := pr.LoadIssue(); err != nil {
+		return err
+	}
+	if err := pr.Issue.LoadRepo(); err != nil {
+		return err
+	}
+
+	owners, err := pr.GetCodeOwners()
+	if err != nil {
+		return err
+	}
+
+	requestedUsers := make(map[int64]bool)
+	requestedTeams := make(map[int64]bool)
+	for _, fileOwners := range owners {
+		for _, user := range fileOwners.Users {
+			if requestedUsers[user.ID] || user.ID == pr.Issue.PosterID {
+				continue
+			}
+			requestedUsers[user.ID] = true
+			if _, err := models.AddReviewRequest(pr.Issue, user, doer); err != nil {
+				return err
+			}
+		}
+		for _, team := range fileOwners.Teams {
+			if requestedTeams[team.ID] {
+				continue
+			}
+			requestedTeams[team.ID] = true
+			if _, err := models.AddTeamReviewRequest(pr.Issue, team, doer); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// requestCodeOwnerReviews requests reviews from the code owners and logs failures
+func requestCodeOwnerReviews(pr *models.PullRequest, doer *models.User) {
+	if err := RequestCodeOwnerReviews(pr, doer); err != nil {
+		log.Error("RequestCodeOwnerReviews[%d]: %v", pr.ID, err)
+	}
+}
@@ -41,6 +41,51 @@ func IsCommitStatusContextSuccess(commitStatuses []*models.CommitStatus, require
 	return true
 }
 
+// GetCommitStatusContextsState returns success if all required status check contexts succeed,
+// failure if one of them failed and pending if some of them are still missing or running.
+func GetCommitStatusContextsState(commitStatuses []*models.CommitStatus, requiredContexts []string) models.CommitStatusState {
+	// If no specific context is required, all latest commit statuses have to succeed
+	if len(requiredContexts) == 0 {
+		if len(commitStatuses) == 0 {
+			return models.CommitStatusPending
+		}
+		state := models.CommitStatusSuccess
+		for _, commitStatus := range commitStatuses {
+			switch commitStatus.State {
+			case models.CommitStatusSuccess:
+			case models.CommitStatusPending:
+				state = models.CommitStatusPending
+			default:
+				return models.CommitStatusFailure
+			}
+		}
+		return state
+	}
+
+	state := models.CommitStatusSuccess
+	for _, ctx := range requiredContexts {
+		var found bool
+		for _, commitStatus := range commitStatuses {
+			if commitStatus.Context == ctx {
+				switch commitStatus.State {
+				case models.CommitStatusSuccess:
+				case models.CommitStatusPending:
+					state = models.CommitStatusPending
+				default:
+					return models.CommitStatusFailure
+				}
+
+				found = true
+				break
+			}
+		}
+		if !found {
+			state = models.CommitStatusPending
+		}
+	}
+	return state
+}
+
 // IsPullCommitStatusPass returns if all required status checks PASS
 func IsPullCommitStatusPass(pr *models.PullRequest) (bool, error) {
 	if err := pr.LoadProtectedBranch(); err != nil {
@@ -0,0 +1,31 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCommitStatusContextsState(t *testing.T) {
+	statuses := []*models.CommitStatus{
+		{ID: 1, Context: "ci/build", State: models.CommitStatusSuccess},
+		{ID: 2, Context: "ci/test", State: models.CommitStatusPending},
+		{ID: 3, Context: "ci/lint", State: models.CommitStatusFailure},
+	}
+
+	assert.Equal(t, models.CommitStatusSuccess, GetCommitStatusContextsState(statuses, []string{"ci/build"}))
+	assert.Equal(t, models.CommitStatusPending, GetCommitStatusContextsState(statuses, []string{"ci/build", "ci/test"}))
+	assert.Equal(t, models.CommitStatusPending, GetCommitStatusContextsState(statuses, []string{"ci/build", "ci/deploy"}))
+	assert.Equal(t, models.CommitStatusFailure, GetCommitStatusContextsState(statuses, []string{"ci/test", "ci/lint"}))
+
+	assert.Equal(t, models.CommitStatusFailure, GetCommitStatusContextsState(statuses, nil))
+	assert.Equal(t, models.CommitStatusPending, GetCommitStatusContextsState(statuses[:2], nil))
+	assert.Equal(t, models.CommitStatusSuccess, GetCommitStatusContextsState(statuses[:1], nil))
+	assert.Equal(t, models.CommitStatusPending, GetCommitStatusContextsState(nil, nil))
+}
@@ -32,12 +32,6 @@ import (
 // Merge merges pull request to base repository.
 // FIXME: add repoWorkingPull make sure two merges does not happen at same time.
 func Merge(pr *models.PullRequest, doer *models.User, baseGitRepo *git.Repository, mergeStyle models.MergeStyle, message string) (err error) {
-	binVersion, err := git.BinVersion()
-	if err != nil {
-		log.Error("git.BinVersion: %v", err)
-		return fmt.Errorf("Unable to get git version: %v", err)
-	}
-
 	if err = pr.GetHeadRepo(); err != nil {
 		log.Error("GetHeadRepo: %v", err)
 		return fmt.Errorf("GetHeadRepo: %v", err)
@@ -79,6 +73,68 @@ func Merge(pr *models.PullRequest, doer *models.User, baseGitRepo *git.Repositor
 		}
 	}()
 
+	if err := rawMerge(pr, doer, mergeStyle, message, tmpBasePath); err != nil {
+		return err
+	}
+
+	baseBranch := "base"
+	var outbuf, errbuf strings.Builder
+
+	// OK we should cache our current head and origin/headbranch
+	mergeHeadSHA, err := git.GetFullCommitID(tmpBasePath, "HEAD")
+	if err != nil {
+		return fmt.Errorf("Failed to get full commit id for HEAD: %v", err)
+	}
+	mergeBaseSHA, err := git.GetFullCommitID(tmpBasePath, "original_"+baseBranch)
+	if err != nil {
+		return fmt.Errorf("Failed to get full commit id for origin/%s: %v", pr.BaseBranch, err)
+	}
+
+	// Now it's questionable about where this should go - either after or before the push
+	// I think in the interests of data safety - failures to push to the lfs should prevent
+	// the merge as you can always remerge.
+	if setting.LFS.StartServer {
+		if err := LFSPush(tmpBasePath, mergeHeadSHA, mergeBaseSHA, pr); err != nil {
+			return err
+		}
+	}
+
+	env, err := mergePushingEnvironment(pr, doer)
+	if err != nil {
+		return err
+	}
+
+	// Push back to upstream.
+	if err := git.NewCommand("push", "origin", baseBranch+":"+pr.BaseBranch).RunInDirTimeoutEnvPipeline(env, -1, tmpBasePath, &outbuf, &errbuf); err != nil {
+		if strings.Contains(errbuf.String(), "non-fast-forward") {
+			return models.ErrMergePushOutOfDate{
+				Style:  mergeStyle,
+				StdOut: outbuf.String(),
+				StdErr: errbuf.String(),
+				Err:    err,
+			}
+		}
+		return fmt.Errorf("git push: %s", errbuf.String())
+	}
+	outbuf.Reset()
+	errbuf.Reset()
+
+	mergedCommitID, err := baseGitRepo.GetBranchCommitID(pr.BaseBranch)
+	if err != nil {
+		return fmt.Errorf("GetBranchCommit: %v", err)
+	}
+	return finalizeMerge(pr, doer, baseGitRepo, mergedCommitID)
+}
+
+// rawMerge merges the tracking branch of the temporary repository created by createTemporaryRepo
+// into its base branch with the merge style, it leaves the merged base branch checked out.
+func rawMerge(pr *models.PullRequest, doer *models.User, mergeStyle models.MergeStyle, message, tmpBasePath string) error {
+	binVersion, err := git.BinVersion()
+	if err != nil {
+		log.Error("git.BinVersion: %v", err)
+		return fmt.Errorf("Unable to get git version: %v", err)
+	}
+
 	baseBranch := "base"
 	trackingBranch := "tracking"
 	stagingBranch := "staging"
@@ -285,32 +341,17 @@ func Merge(pr *models.PullRequest, doer *models.User, baseGitRepo *git.Repositor
 	default:
 		return models.ErrInvalidMergeStyle{ID: pr.BaseRepo.ID, Style: mergeStyle}
 	}
+	return nil
+}
 
-	// OK we should cache our current head and origin/headbranch
-	mergeHeadSHA, err := git.GetFullCommitID(tmpBasePath, "HEAD")
-	if err != nil {
-		return fmt.Errorf("Failed to get full commit id for HEAD: %v", err)
-	}
-	mergeBaseSHA, err := git.GetFullCommitID(tmpBasePath, "original_"+baseBranch)
-	if err != nil {
-		return fmt.Errorf("Failed to get full commit id for origin/%s: %v", pr.BaseBranch, err)
-	}
-
-	// Now it's questionable about where this should go - either after or before the push
-	// I think in the interests of data safety - failures to push to the lfs should prevent
-	// the merge as you can always remerge.
-	if setting.LFS.StartServer {
-		if err := LFSPush(tmpBasePath, mergeHeadSHA, mergeBaseSHA, pr); err != nil {
-			return err
-		}
-	}
-
+// mergePushingEnvironment returns the environment to push the merge of the pull request by doer
+// to the base repository
+func mergePushingEnvironment(pr *models.PullRequest, doer *models.User) ([]string, error) {
 	var headUser *models.User
-	err = pr.HeadRepo.GetOwner()
-	if err != nil {
+	if err := pr.HeadRepo.GetOwner(); err != nil {
 		if !models.IsErrUserNotExist(err) {
 			log.Error("Can't find user: %d for head repository - %v", pr.HeadRepo.OwnerID, err)
-			return err
+			return nil, err
 		}
 		log.Error("Can't find user: %d for head repository - defaulting to doer: %s - %v", pr.HeadRepo.OwnerID, doer.Name, err)
 		headUser = doer
@@ -318,34 +359,19 @@ func Merge(pr *models.PullRequest, doer *models.User, baseGitRepo *git.Repositor
 		headUser = pr.HeadRepo.Owner
 	}
 
-	env = models.FullPushingEnvironment(
+	return models.FullPushingEnvironment(
 		headUser,
 		doer,
 		pr.BaseRepo,
 		pr.BaseRepo.Name,
 		pr.ID,
-	)
-
-	// Push back to upstream.
-	if err := git.NewCommand("push", "origin", baseBranch+":"+pr.BaseBranch).RunInDirTimeoutEnvPipeline(env, -1, tmpBasePath, &outbuf, &errbuf); err != nil {
-		if strings.Contains(errbuf.String(), "non-fast-forward") {
-			return models.ErrMergePushOutOfDate{
-				Style:  mergeStyle,
-				StdOut: outbuf.String(),
-				StdErr: errbuf.String(),
-				Err:    err,
-			}
-		}
-		return fmt.Errorf("git push: %s", errbuf.String())
-	}
-	outbuf.Reset()
-	errbuf.Reset()
-
-	pr.MergedCommitID, err = baseGitRepo.GetBranchCommitID(pr.BaseBranch)
-	if err != nil {
-		return fmt.Errorf("GetBranchCommit: %v", err)
-	}
+	), nil
+}
 
+// finalizeMerge marks the pull request as merged as mergedCommitID once the merge was pushed
+// to the base branch, and resolves the references of the pull request
+func finalizeMerge(pr *models.PullRequest, doer *models.User, baseGitRepo *git.Repository, mergedCommitID string) (err error) {
+	pr.MergedCommitID = mergedCommitID
 	pr.MergedUnix = timeutil.TimeStampNow()
 	pr.Merger = doer
 	pr.MergerID = doer.ID
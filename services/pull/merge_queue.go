@@ -0,0 +1,395 @@
+// Copyright 2020 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package pull
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	actions_module "code.gitea.io/gitea/modules/actions"
+	"code.gitea.io/gitea/modules/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/sync"
+	actions_service "code.gitea.io/gitea/services/actions"
+
+	"github.com/unknwon/com"
+)
+
+// mergeQueue represents a queue of target branches whose merge queue has to be processed,
+// identified by "<repo id>:<branch>"
+var mergeQueue = sync.NewUniqueQueue(setting.Repository.PullRequestQueueLength)
+
+// AddToMergeQueue adds the pull request to the merge queue of its protected target branch, it will
+// be merged by doer with the merge style once the status checks of its speculative merge succeed
+func AddToMergeQueue(doer *models.User, pr *models.PullRequest, style models.MergeStyle, message string) error {
+	if err := pr.GetBaseRepo(); err != nil {
+		return err
+	}
+	if err := pr.LoadProtectedBranch(); err != nil {
+		return err
+	}
+	if pr.ProtectedBranch == nil || !pr.ProtectedBranch.EnableMergeQueue {
+		return models.ErrMergeQueueNotEnabled{RepoID: pr.BaseRepoID, Branch: pr.BaseBranch}
+	}
+	prUnit, err := pr.BaseRepo.GetUnit(models.UnitTypePullRequests)
+	if err != nil {
+		return err
+	}
+	if !prUnit.PullRequestsConfig().IsMergeStyleAllowed(style) {
+		return models.ErrInvalidMergeStyle{ID: pr.BaseRepo.ID, Style: style}
+	}
+
+	if err := models.AddToMergeQueue(doer, pr, style, message); err != nil {
+		return err
+	}
+	CheckMergeQueue(pr.BaseRepoID, pr.BaseBranch)
+	return nil
+}
+
+// RemoveFromMergeQueue removes the pull request from the merge queue of its target branch.
+// It returns false if the pull request was not in the merge queue.
+func RemoveFromMergeQueue(doer *models.User, pr *models.PullRequest) (bool, error) {
+	removed, err := models.RemoveFromMergeQueue(doer, pr, models.MergeQueueRemoveReasonDequeued)
+	if err != nil || !removed {
+		return removed, err
+	}
+	// the pull requests behind it have to be merged again without it
+	CheckMergeQueue(pr.BaseRepoID, pr.BaseBranch)
+	return true, nil
+}
+
+// CheckMergeQueue adds the merge queue of the branch to the queue to be processed
+func CheckMergeQueue(repoID int64, branch string) {
+	go mergeQueue.Add(fmt.Sprintf("%d:%s", repoID, branch))
+}
+
+// CheckMergeQueuesOfRepo adds all merge queues of the repository to the queue to be processed,
+// it has to be called when a commit status of the repository is created
+func CheckMergeQueuesOfRepo(repoID int64) {
+	branches, err := models.GetMergeQueueBranches(repoID)
+	if err != nil {
+		log.Error("GetMergeQueueBranches[%d]: %v", repoID, err)
+		return
+	}
+	for _, branch := range branches {
+		CheckMergeQueue(branch.RepoID, branch.BaseBranch)
+	}
+}
+
+// ejectFromMergeQueueOnPush removes a pull request from the merge queue when new commits are pushed
+func ejectFromMergeQueueOnPush(doer *models.User, pr *models.PullRequest) {
+	removed, err := models.RemoveFromMergeQueue(doer, pr, models.MergeQueueRemoveReasonNewCommits)
+	if err != nil {
+		log.Error("RemoveFromMergeQueue[%d]: %v", pr.ID, err)
+	} else if removed {
+		log.Trace("Pull request %d removed from the merge queue by push of %s", pr.ID, doer.Name)
+		CheckMergeQueue(pr.BaseRepoID, pr.BaseBranch)
+	}
+}
+
+// ejectFromMergeQueue removes the pull request of the entry from the merge queue for the reason
+func ejectFromMergeQueue(entry *models.PullMergeQueue, pr *models.PullRequest, reason models.MergeQueueRemoveReason) {
+	if _, err := models.RemoveFromMergeQueue(entry.Doer, pr, reason); err != nil {
+		log.Error("RemoveFromMergeQueue[%d]: %v", pr.ID, err)
+		return
+	}
+	removeMergeQueueRef(pr)
+	log.Trace("Pull request %d removed from the merge queue: %s", pr.ID, reason)
+}
+
+// removeMergeQueueRef deletes the speculative merge of the pull request from the base repository
+func removeMergeQueueRef(pr *models.PullRequest) {
+	if _, err := git.NewCommand("update-ref", "-d", pr.GetMergeQueueRefName()).RunInDir(pr.BaseRepo.RepoPath()); err != nil {
+		log.Error("Unable to remove %s from %s: %v", pr.GetMergeQueueRefName(), pr.BaseRepo.FullName(), err)
+	}
+}
+
+// speculativeMerge merges the pull request of the entry on top of parentCommitID in a temporary
+// repository and pushes the result to the merge queue ref of the pull request. It returns the
+// ID of the merge commit.
+func speculativeMerge(pr *models.PullRequest, entry *models.PullMergeQueue, parentCommitID string) (string, error) {
+	tmpBasePath, err := createTemporaryRepo(pr)
+	if err != nil {
+		log.Error("CreateTemporaryPath: %v", err)
+		return "", err
+	}
+	defer func() {
+		if err := models.RemoveTemporaryPath(tmpBasePath); err != nil {
+			log.Error("speculativeMerge: RemoveTemporaryPath: %s", err)
+		}
+	}()
+
+	// The speculative merges of the pull requests ahead are available through the
+	// objects of the base repository
+	var outbuf, errbuf strings.Builder
+	for _, branch := range []string{"base", "original_base"} {
+		if err := git.NewCommand("update-ref", git.BranchPrefix+branch, parentCommitID).RunInDirPipeline(tmpBasePath, &outbuf, &errbuf); err != nil {
+			log.Error("git update-ref %s %s: %v\n%s\n%s", branch, parentCommitID, err, outbuf.String(), errbuf.String())
+			return "", fmt.Errorf("git update-ref %s %s: %v\n%s\n%s", branch, parentCommitID, err, outbuf.String(), errbuf.String())
+		}
+		outbuf.Reset()
+		errbuf.Reset()
+	}
+
+	message := entry.Message
+	if len(message) == 0 {
+		if entry.MergeStyle == models.MergeStyleSquash {
+			message = pr.GetDefaultSquashMessage()
+		} else {
+			message = pr.GetDefaultMergeMessage()
+		}
+	}
+
+	if err := rawMerge(pr, entry.Doer, entry.MergeStyle, message, tmpBasePath); err != nil {
+		return "", err
+	}
+
+	mergeCommitID, err := git.GetFullCommitID(tmpBasePath, "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("Failed to get full commit id for HEAD: %v", err)
+	}
+
+	// The LFS objects have to be in the base repository before the target branch is fast-forwarded
+	if setting.LFS.StartServer {
+		if err := LFSPush(tmpBasePath, mergeCommitID, parentCommitID, pr); err != nil {
+			return "", err
+		}
+	}
+
+	// Use InternalPushingEnvironment here because the hooks must not run for the merge queue refs
+	if err := git.NewCommand("push", "-f", "origin", "HEAD:"+pr.GetMergeQueueRefName()).RunInDirTimeoutEnvPipeline(models.InternalPushingEnvironment(entry.Doer, pr.BaseRepo), -1, tmpBasePath, &outbuf, &errbuf); err != nil {
+		log.Error("git push %s: %v\n%s\n%s", pr.GetMergeQueueRefName(), err, outbuf.String(), errbuf.String())
+		return "", fmt.Errorf("git push %s: %v\n%s\n%s", pr.GetMergeQueueRefName(), err, outbuf.String(), errbuf.String())
+	}
+	return mergeCommitID, nil
+}
+
+// fastForwardMergeQueue fast-forwards the target branch to the speculative merge of the entry and
+// marks the pull request as merged
+func fastForwardMergeQueue(pr *models.PullRequest, entry *models.PullMergeQueue, baseGitRepo *git.Repository) error {
+	env, err := mergePushingEnvironment(pr, entry.Doer)
+	if err != nil {
+		return err
+	}
+
+	var outbuf, errbuf strings.Builder
+	if err := git.NewCommand("push", ".", entry.MergeCommitID+":"+git.BranchPrefix+pr.BaseBranch).RunInDirTimeoutEnvPipeline(env, -1, pr.BaseRepo.RepoPath(), &outbuf, &errbuf); err != nil {
+		if strings.Contains(errbuf.String(), "non-fast-forward") {
+			return models.ErrMergePushOutOfDate{
+				Style:  entry.MergeStyle,
+				StdOut: outbuf.String(),
+				StdErr: errbuf.String(),
+				Err:    err,
+			}
+		}
+		return fmt.Errorf("git push: %s", errbuf.String())
+	}
+
+	go AddTestPullRequestTask(entry.Doer, pr.BaseRepoID, pr.BaseBranch, false, "", "")
+
+	if err := finalizeMerge(pr, entry.Doer, baseGitRepo, entry.MergeCommitID); err != nil {
+		log.Error("finalizeMerge[%d]: %v", pr.ID, err)
+	}
+	if _, err := models.RemoveFromMergeQueue(nil, pr, ""); err != nil {
+		log.Error("RemoveFromMergeQueue[%d]: %v", pr.ID, err)
+	}
+	removeMergeQueueRef(pr)
+	return nil
+}
+
+// loadMergeQueuePull loads the pull request of the merge queue entry with the attributes needed to merge it
+func loadMergeQueuePull(entry *models.PullMergeQueue) (*models.PullRequest, error) {
+	pr, err := models.GetPullRequestByID(entry.PullID)
+	if err != nil {
+		return nil, err
+	}
+	if err = pr.LoadIssue(); err != nil {
+		return nil, err
+	} else if err = pr.Issue.LoadPoster(); err != nil {
+		return nil, err
+	} else if err = pr.GetBaseRepo(); err != nil {
+		return nil, err
+	} else if err = pr.GetHeadRepo(); err != nil {
+		return nil, err
+	}
+	pr.Issue.Repo = pr.BaseRepo
+	return pr, nil
+}
+
+// handleMergeQueue brings the merge queue of the branch up to date. Every pull request is merged
+// speculatively on top of the pull requests ahead of it. The first pull request is merged into
+// the branch once the required status checks of its speculative merge succeed, the pull requests
+// which conflict or whose checks fail are removed from the queue.
+func handleMergeQueue(repoID int64, branch string) {
+	entries, err := models.GetMergeQueue(repoID, branch)
+	if err != nil {
+		log.Error("GetMergeQueue[%d, %s]: %v", repoID, branch, err)
+		return
+	} else if len(entries) == 0 {
+		return
+	}
+
+	repo, err := models.GetRepositoryByID(repoID)
+	if err != nil {
+		log.Error("GetRepositoryByID[%d]: %v", repoID, err)
+		return
+	}
+	protectBranch, err := models.GetProtectedBranchBy(repoID, branch)
+	if err != nil {
+		log.Error("GetProtectedBranchBy[%d, %s]: %v", repoID, branch, err)
+		return
+	}
+	enabled := protectBranch != nil && protectBranch.EnableMergeQueue
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		log.Error("OpenRepository[%s]: %v", repo.RepoPath(), err)
+		return
+	}
+	defer gitRepo.Close()
+
+	parent, err := gitRepo.GetBranchCommitID(branch)
+	if err != nil {
+		log.Error("GetBranchCommitID[%s, %s]: %v", repo.FullName(), branch, err)
+		return
+	}
+
+	isHead := true
+	for _, entry := range entries {
+		pr, err := loadMergeQueuePull(entry)
+		if err != nil {
+			log.Error("loadMergeQueuePull[%d]: %v", entry.PullID, err)
+			return
+		}
+
+		if pr.HasMerged {
+			if _, err := models.RemoveFromMergeQueue(nil, pr, ""); err != nil {
+				log.Error("RemoveFromMergeQueue[%d]: %v", pr.ID, err)
+			}
+			removeMergeQueueRef(pr)
+			continue
+		}
+		if !enabled {
+			ejectFromMergeQueue(entry, pr, models.MergeQueueRemoveReasonDisabled)
+			continue
+		} else if pr.Issue.IsClosed {
+			ejectFromMergeQueue(entry, pr, models.MergeQueueRemoveReasonClosed)
+			continue
+		} else if pr.BaseBranch != entry.BaseBranch {
+			ejectFromMergeQueue(entry, pr, models.MergeQueueRemoveReasonTargetChanged)
+			continue
+		}
+
+		// Merge again whenever the branch or a pull request ahead has changed
+		if entry.ParentCommitID != parent || entry.MergeCommitID == "" {
+			mergeCommitID, err := speculativeMerge(pr, entry, parent)
+			if err != nil {
+				if models.IsErrMergeConflicts(err) || models.IsErrRebaseConflicts(err) || models.IsErrMergeUnrelatedHistories(err) {
+					ejectFromMergeQueue(entry, pr, models.MergeQueueRemoveReasonConflict)
+				} else {
+					log.Error("Speculative merge of pull request %d failed: %v", pr.ID, err)
+					ejectFromMergeQueue(entry, pr, models.MergeQueueRemoveReasonFailed)
+				}
+				continue
+			}
+			entry.ParentCommitID = parent
+			entry.MergeCommitID = mergeCommitID
+			if err := models.UpdateMergeQueueCommits(entry); err != nil {
+				log.Error("UpdateMergeQueueCommits[%d]: %v", pr.ID, err)
+				return
+			}
+
+			actions_service.Enqueue(&actions_service.DetectOptions{
+				RepoID:    repoID,
+				DoerID:    entry.DoerID,
+				Event:     actions_module.EventMergeGroup,
+				MatchRef:  branch,
+				Ref:       pr.GetMergeQueueRefName(),
+				CommitSHA: mergeCommitID,
+			})
+		}
+
+		state := models.CommitStatusSuccess
+		if protectBranch.EnableStatusCheck {
+			commitStatuses, err := models.GetLatestCommitStatus(repo, entry.MergeCommitID, 0)
+			if err != nil {
+				log.Error("GetLatestCommitStatus[%s, %s]: %v", repo.FullName(), entry.MergeCommitID, err)
+				return
+			}
+			state = GetCommitStatusContextsState(commitStatuses, protectBranch.StatusCheckContexts)
+		}
+		if state == models.CommitStatusFailure {
+			ejectFromMergeQueue(entry, pr, models.MergeQueueRemoveReasonChecksFailed)
+			continue
+		}
+
+		if isHead && state == models.CommitStatusSuccess {
+			if err := pr.CheckUserAllowedToMerge(entry.Doer); err != nil {
+				if !models.IsErrNotAllowedToMerge(err) {
+					log.Error("CheckUserAllowedToMerge[%d]: %v", pr.ID, err)
+					return
+				}
+				ejectFromMergeQueue(entry, pr, models.MergeQueueRemoveReasonNotAllowed)
+				continue
+			}
+			if err := fastForwardMergeQueue(pr, entry, gitRepo); err != nil {
+				if models.IsErrMergePushOutOfDate(err) {
+					// the branch was changed meanwhile, the queue is processed again for the push
+					log.Debug("Merge queue of %s:%s is out of date", repo.FullName(), branch)
+					return
+				}
+				log.Error("Merge of pull request %d from the merge queue failed: %v", pr.ID, err)
+				ejectFromMergeQueue(entry, pr, models.MergeQueueRemoveReasonFailed)
+				continue
+			}
+			log.Trace("Pull request %d merged from the merge queue by %s", pr.ID, entry.Doer.Name)
+			parent = entry.MergeCommitID
+			continue
+		}
+
+		isHead = false
+		parent = entry.MergeCommitID
+	}
+}
+
+// handleMergeQueues processes the merge queues added to the queue
+func handleMergeQueues(ctx context.Context) {
+	go func() {
+		branches, err := models.GetMergeQueueBranches(0)
+		if err != nil {
+			log.Error("GetMergeQueueBranches: %v", err)
+			return
+		}
+		for _, branch := range branches {
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				mergeQueue.Add(fmt.Sprintf("%d:%s", branch.RepoID, branch.BaseBranch))
+			}
+		}
+	}()
+
+	for {
+		select {
+		case id := <-mergeQueue.Queue():
+			mergeQueue.Remove(id)
+			fields := strings.SplitN(id, ":", 2)
+			if len(fields) != 2 {
+				log.Error("Invalid merge queue %q", id)
+				continue
+			}
+			handleMergeQueue(com.StrTo(fields[0]).MustInt64(), fields[1])
+		case <-ctx.Done():
+			mergeQueue.Close()
+			log.Info("PID: %d Pull Request merge queue shutdown", os.Getpid())
+			return
+		}
+	}
+}
@@ -49,6 +49,8 @@ func NewPullRequest(repo *models.Repository, pull *models.Issue, labelIDs []int6
 
 	notification.NotifyNewPullRequest(pr)
 
+	requestCodeOwnerReviews(pr, pull.Poster)
+
 	return nil
 }
 
@@ -198,6 +200,8 @@ func AddTestPullRequestTask(doer *models.User, repoID int64, branch string, isSy
 			if err == nil {
 				for _, pr := range prs {
 					if newCommitID != "" && newCommitID != git.EmptySHA {
+						cancelAutoMergeOnPush(doer, pr)
+						ejectFromMergeQueueOnPush(doer, pr)
 						changed, err := checkIfPRContentChanged(pr, oldCommitID, newCommitID)
 						if err != nil {
 							log.Error("checkIfPRContentChanged: %v", err)
@@ -220,6 +224,11 @@ func AddTestPullRequestTask(doer *models.User, repoID int64, branch string, isSy
 		}
 
 		addHeadRepoTasks(prs)
+		if isSync {
+			for _, pr := range prs {
+				requestCodeOwnerReviews(pr, doer)
+			}
+		}
 
 		log.Trace("AddTestPullRequestTask [base_repo_id: %d, base_branch: %s]: finding pull requests", repoID, branch)
 		prs, err = models.GetUnmergedPullRequestsByBaseInfo(repoID, branch)
@@ -230,6 +239,7 @@ func AddTestPullRequestTask(doer *models.User, repoID int64, branch string, isSy
 		for _, pr := range prs {
 			AddToTaskQueue(pr)
 		}
+		CheckMergeQueue(repoID, branch)
 	})
 }
 
@@ -192,5 +192,9 @@ func SubmitReview(doer *models.User, gitRepo *git.Repository, issue *models.Issu
 
 	notification.NotifyPullRequestReview(pr, review, comm)
 
+	if reviewType == models.ReviewTypeApprove {
+		AddToAutoMergeQueue(pr.ID)
+	}
+
 	return review, comm, nil
 }
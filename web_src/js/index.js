@@ -5,6 +5,7 @@
 import './publicPath.js';
 import './gitGraphLoader.js';
 import './semanticDropdown.js';
+import renderMarkupContent from './markupContent.js';
 
 function htmlEncode(text) {
   return jQuery('<div />').text(text).html();
@@ -39,6 +40,7 @@ function initCommentPreviewTab($form) {
       $('pre code', $previewPanel[0]).each(function () {
         hljs.highlightBlock(this);
       });
+      renderMarkupContent($previewPanel[0]);
     });
   });
 
@@ -65,6 +67,7 @@ function initEditPreviewTab($form) {
         $('pre code', $previewPanel[0]).each(function () {
           hljs.highlightBlock(this);
         });
+        renderMarkupContent($previewPanel[0]);
       });
     });
   }
@@ -686,40 +689,6 @@ function initRepository() {
     });
   }
 
-  // Labels
-  if ($('.repository.labels').length > 0) {
-    // Create label
-    const $newLabelPanel = $('.new-label.segment');
-    $('.new-label.button').click(() => {
-      $newLabelPanel.show();
-    });
-    $('.new-label.segment .cancel').click(() => {
-      $newLabelPanel.hide();
-    });
-
-    $('.color-picker').each(function () {
-      $(this).minicolors();
-    });
-    $('.precolors .color').click(function () {
-      const color_hex = $(this).data('color-hex');
-      $('.color-picker').val(color_hex);
-      $('.minicolors-swatch-color').css('background-color', color_hex);
-    });
-    $('.edit-label-button').click(function () {
-      $('#label-modal-id').val($(this).data('id'));
-      $('.edit-label .new-label-input').val($(this).data('title'));
-      $('.edit-label .new-label-desc-input').val($(this).data('description'));
-      $('.edit-label .color-picker').val($(this).data('color'));
-      $('.minicolors-swatch-color').css('background-color', $(this).data('color'));
-      $('.edit-label.modal').modal({
-        onApprove() {
-          $('.edit-label.form').submit();
-        }
-      }).modal('show');
-      return false;
-    });
-  }
-
   // Milestones
   if ($('.repository.new.milestone').length > 0) {
     const $datepicker = $('.milestone.datepicker');
@@ -968,6 +937,7 @@ function initRepository() {
               $('pre code', $renderContent[0]).each(function () {
                 hljs.highlightBlock(this);
               });
+              renderMarkupContent($renderContent[0]);
             }
             const $content = $segment.parent();
             if (!$content.find('.ui.small.images').length) {
@@ -1113,12 +1083,49 @@ function initRepository() {
   }
 }
 
+function initLabelEdit() {
+  if ($('.repository.labels').length === 0 && $('.organization.settings.labels').length === 0) {
+    return;
+  }
+
+  // Create label
+  const $newLabelPanel = $('.new-label.segment');
+  $('.new-label.button').click(() => {
+    $newLabelPanel.show();
+  });
+  $('.new-label.segment .cancel').click(() => {
+    $newLabelPanel.hide();
+  });
+
+  $('.color-picker').each(function () {
+    $(this).minicolors();
+  });
+  $('.precolors .color').click(function () {
+    const color_hex = $(this).data('color-hex');
+    $('.color-picker').val(color_hex);
+    $('.minicolors-swatch-color').css('background-color', color_hex);
+  });
+  $('.edit-label-button').click(function () {
+    $('#label-modal-id').val($(this).data('id'));
+    $('.edit-label .new-label-input').val($(this).data('title'));
+    $('.edit-label .new-label-desc-input').val($(this).data('description'));
+    $('.edit-label .color-picker').val($(this).data('color'));
+    $('.minicolors-swatch-color').css('background-color', $(this).data('color'));
+    $('.edit-label.modal').modal({
+      onApprove() {
+        $('.edit-label.form').submit();
+      }
+    }).modal('show');
+    return false;
+  });
+}
+
 function initMigration() {
   const toggleMigrations = function () {
     const authUserName = $('#auth_username').val();
     const cloneAddr = $('#clone_addr').val();
     if (!$('#mirror').is(':checked') && (authUserName && authUserName.length > 0)
-        && (cloneAddr !== undefined && (cloneAddr.startsWith('https://github.com') || cloneAddr.startsWith('http://github.com')))) {
+        && (cloneAddr !== undefined && /^https?:\/\/(github|gitlab)\.com\//.test(cloneAddr))) {
       $('#migrate_items').show();
     } else {
       $('#migrate_items').hide();
@@ -1215,9 +1222,20 @@ function initPullRequestReview() {
       td.find("input[name='line']").val(idx);
       td.find("input[name='side']").val(side === 'left' ? 'previous' : 'proposed');
       td.find("input[name='path']").val(path);
+      if (side === 'right') {
+        td.find('.btn-insert-suggestion').removeClass('hide').data('line', $(this).siblings('.mono').text());
+      }
     }
     commentCloud.find('textarea').focus();
   });
+
+  $(document).on('click', '.btn-insert-suggestion', function (e) {
+    e.preventDefault();
+    const $textarea = $(this).closest('form').find('textarea[name="content"]');
+    const content = $textarea.val();
+    const prefix = content && !content.endsWith('\n') ? '\n' : '';
+    $textarea.val(`${content}${prefix}\`\`\`suggestion\n${$(this).data('line')}\n\`\`\`\n`).focus();
+  });
 }
 
 function assingMenuAttributes(menu) {
@@ -1288,6 +1306,7 @@ function initWikiForm() {
               $(preview).find('pre code').each((_, e) => {
                 hljs.highlightBlock(e);
               });
+              renderMarkupContent(preview);
             });
           };
           if (!simplemde.isSideBySideActive()) {
@@ -1768,7 +1787,7 @@ function initWebhook() {
   });
 
   // Test delivery
-  $('#test-delivery').click(function () {
+  $('#test-delivery, .redeliver-delivery').click(function () {
     const $this = $(this);
     $this.addClass('loading disabled');
     $.post($this.data('link'), {
@@ -1971,7 +1990,7 @@ function initAdmin() {
           break;
       }
     });
-    $('#delete-selection').click(function () {
+    $('#delete-selection, #resend-selection').click(function () {
       const $this = $(this);
       $this.addClass('loading disabled');
       const ids = [];
@@ -2111,6 +2130,14 @@ function initCodeView() {
       });
     }
   });
+  $('.rich-diff-toggle').on('click', (e) => {
+    const $toggle = $(e.target);
+    const $segment = $toggle.parent().next();
+    const showRich = $segment.find('.rich-diff').hasClass('hide');
+    $segment.find('.code-diff').toggleClass('hide', showRich);
+    $segment.find('.rich-diff').toggleClass('hide', !showRich);
+    $toggle.text(showRich ? $toggle.data('source') : $toggle.data('rich'));
+  });
   function insertBlobExcerpt(e) {
     const $blob = $(e.target);
     const $row = $blob.parent().parent();
@@ -2123,123 +2150,150 @@ function initCodeView() {
   $('.ui.blob-excerpt').on('click', (e) => { insertBlobExcerpt(e); });
 }
 
-function initU2FAuth() {
+function decodeURLEncodedBase64(value) {
+  return Uint8Array.from(atob(value.replace(/-/g, '+').replace(/_/g, '/')), (c) => c.charCodeAt(0));
+}
+
+function encodeURLEncodedBase64(value) {
+  if (!value) {
+    return null;
+  }
+  return btoa(String.fromCharCode(...new Uint8Array(value)))
+    .replace(/\+/g, '-')
+    .replace(/\//g, '_')
+    .replace(/=/g, '');
+}
+
+function webAuthnSupported() {
+  return window.PublicKeyCredential !== undefined && navigator.credentials !== undefined;
+}
+
+function initWebAuthnAuth() {
   if ($('#wait-for-key').length === 0) {
     return;
   }
-  u2fApi.ensureSupport()
-    .then(() => {
-      $.getJSON(`${suburl}/user/u2f/challenge`).success((req) => {
-        u2fApi.sign(req.appId, req.challenge, req.registeredKeys, 30)
-          .then(u2fSigned)
-          .catch((err) => {
-            if (err === undefined) {
-              u2fError(1);
-              return;
-            }
-            u2fError(err.metaData.code);
-          });
-      });
-    }).catch(() => {
-      // Fallback in case browser do not support U2F
-      window.location.href = `${suburl}/user/two_factor`;
+  if (!webAuthnSupported()) {
+    // Fallback in case the browser does not support WebAuthn
+    window.location.href = `${suburl}/user/two_factor`;
+    return;
+  }
+  $.getJSON(`${suburl}/user/webauthn/assertion`).success((options) => {
+    options.publicKey.challenge = decodeURLEncodedBase64(options.publicKey.challenge);
+    options.publicKey.allowCredentials.forEach((cred) => {
+      cred.id = decodeURLEncodedBase64(cred.id);
     });
+    navigator.credentials.get(options)
+      .then(webAuthnAsserted)
+      .catch((err) => {
+        webAuthnError(err.name);
+      });
+  });
 }
-function u2fSigned(resp) {
+
+function webAuthnAsserted(credential) {
+  const response = {
+    clientDataJSON: encodeURLEncodedBase64(credential.response.clientDataJSON),
+    authenticatorData: encodeURLEncodedBase64(credential.response.authenticatorData),
+    signature: encodeURLEncodedBase64(credential.response.signature),
+  };
+  if (credential.response.userHandle) {
+    response.userHandle = encodeURLEncodedBase64(credential.response.userHandle);
+  }
   $.ajax({
-    url: `${suburl}/user/u2f/sign`,
+    url: `${suburl}/user/webauthn/assertion`,
     type: 'POST',
     headers: { 'X-Csrf-Token': csrf },
-    data: JSON.stringify(resp),
+    data: JSON.stringify({
+      id: credential.id,
+      rawId: encodeURLEncodedBase64(credential.rawId),
+      type: credential.type,
+      response,
+    }),
     contentType: 'application/json; charset=utf-8',
   }).done((res) => {
     window.location.replace(res);
   }).fail(() => {
-    u2fError(1);
+    webAuthnError('unknown');
   });
 }
 
-function u2fRegistered(resp) {
-  if (checkError(resp)) {
-    return;
-  }
+function webAuthnRegistered(credential) {
   $.ajax({
-    url: `${suburl}/user/settings/security/u2f/register`,
+    url: `${suburl}/user/settings/security/webauthn/register`,
     type: 'POST',
     headers: { 'X-Csrf-Token': csrf },
-    data: JSON.stringify(resp),
+    data: JSON.stringify({
+      id: credential.id,
+      rawId: encodeURLEncodedBase64(credential.rawId),
+      type: credential.type,
+      response: {
+        clientDataJSON: encodeURLEncodedBase64(credential.response.clientDataJSON),
+        attestationObject: encodeURLEncodedBase64(credential.response.attestationObject),
+      },
+    }),
     contentType: 'application/json; charset=utf-8',
     success() {
       reload();
     },
-    fail() {
-      u2fError(1);
+    error() {
+      webAuthnError('unknown');
     }
   });
 }
 
-function checkError(resp) {
-  if (!('errorCode' in resp)) {
-    return false;
-  }
-  if (resp.errorCode === 0) {
-    return false;
-  }
-  u2fError(resp.errorCode);
-  return true;
-}
-
-
-function u2fError(errorType) {
-  const u2fErrors = {
-    browser: $('#unsupported-browser'),
-    1: $('#u2f-error-1'),
-    2: $('#u2f-error-2'),
-    3: $('#u2f-error-3'),
-    4: $('#u2f-error-4'),
-    5: $('.u2f-error-5')
+function webAuthnError(errorType) {
+  const webAuthnErrors = {
+    browser: $('#webauthn-error-browser'),
+    unknown: $('#webauthn-error-unknown'),
+    SecurityError: $('#webauthn-error-insecure'),
+    InvalidStateError: $('#webauthn-error-registered'),
+    NotAllowedError: $('.webauthn-error-timeout')
   };
-  u2fErrors[errorType].removeClass('hide');
+  if (!(errorType in webAuthnErrors)) {
+    errorType = 'unknown';
+  }
+  webAuthnErrors[errorType].removeClass('hide');
 
-  Object.keys(u2fErrors).forEach((type) => {
+  Object.keys(webAuthnErrors).forEach((type) => {
     if (type !== errorType) {
-      u2fErrors[type].addClass('hide');
+      webAuthnErrors[type].addClass('hide');
     }
   });
-  $('#u2f-error').modal('show');
+  $('#webauthn-error').modal('show');
 }
 
-function initU2FRegister() {
+function initWebAuthnRegister() {
   $('#register-device').modal({ allowMultiple: false });
-  $('#u2f-error').modal({ allowMultiple: false });
+  $('#webauthn-error').modal({ allowMultiple: false });
   $('#register-security-key').on('click', (e) => {
     e.preventDefault();
-    u2fApi.ensureSupport()
-      .then(u2fRegisterRequest)
-      .catch(() => {
-        u2fError('browser');
-      });
+    if (!webAuthnSupported()) {
+      webAuthnError('browser');
+      return;
+    }
+    webAuthnRegisterRequest();
   });
 }
 
-function u2fRegisterRequest() {
-  $.post(`${suburl}/user/settings/security/u2f/request_register`, {
+function webAuthnRegisterRequest() {
+  $.post(`${suburl}/user/settings/security/webauthn/request_register`, {
     _csrf: csrf,
     name: $('#nickname').val()
-  }).success((req) => {
+  }).success((options) => {
     $('#nickname').closest('div.field').removeClass('error');
     $('#register-device').modal('show');
-    if (req.registeredKeys === null) {
-      req.registeredKeys = [];
-    }
-    u2fApi.register(req.appId, req.registerRequests, req.registeredKeys, 30)
-      .then(u2fRegistered)
-      .catch((reason) => {
-        if (reason === undefined) {
-          u2fError(1);
-          return;
-        }
-        u2fError(reason.metaData.code);
+    options.publicKey.challenge = decodeURLEncodedBase64(options.publicKey.challenge);
+    options.publicKey.user.id = decodeURLEncodedBase64(options.publicKey.user.id);
+    if (!options.publicKey.excludeCredentials) {
+      options.publicKey.excludeCredentials = [];
+    }
+    options.publicKey.excludeCredentials.forEach((cred) => {
+      cred.id = decodeURLEncodedBase64(cred.id);
+    });
+    navigator.credentials.create(options)
+      .then(webAuthnRegistered)
+      .catch((err) => {
+        webAuthnError(err.name);
       });
   }).fail((xhr) => {
     if (xhr.status === 409) {
@@ -2266,6 +2320,60 @@ function initWipTitle() {
   });
 }
 
+function initProjectBoards() {
+  const $boards = $('.project-boards');
+  if ($boards.length === 0) return;
+
+  const url = $boards.data('url');
+  let $dragged = null;
+
+  // Returns the card the dragged card has to be placed before, or null to append it
+  const cardAfter = function ($list, y) {
+    let after = null;
+    $list.children('.project-issue-card').not($dragged).each(function () {
+      const rect = this.getBoundingClientRect();
+      if (after === null && y < rect.top + rect.height / 2) {
+        after = this;
+      }
+    });
+    return after;
+  };
+
+  $boards.find('.project-issue-card[draggable]').on('dragstart', function (e) {
+    $dragged = $(this);
+    e.originalEvent.dataTransfer.effectAllowed = 'move';
+    e.originalEvent.dataTransfer.setData('text/plain', $dragged.data('issue-id'));
+    $dragged.addClass('dragging');
+  }).on('dragend', () => {
+    if ($dragged) $dragged.removeClass('dragging');
+    $dragged = null;
+  });
+
+  $boards.find('.project-board').on('dragover', function (e) {
+    if (!$dragged) return;
+    e.preventDefault();
+    const $list = $(this).find('.project-board-issues');
+    const after = cardAfter($list, e.originalEvent.clientY);
+    if (after === null) {
+      $list.append($dragged);
+    } else {
+      $dragged.insertBefore(after);
+    }
+  }).on('drop', function (e) {
+    if (!$dragged) return;
+    e.preventDefault();
+    const $board = $(this);
+    $.post(url, {
+      _csrf: csrf,
+      issue_id: $dragged.data('issue-id'),
+      board_id: $board.data('board-id'),
+      position: $board.find('.project-board-issues').children('.project-issue-card').index($dragged)
+    }).fail(() => {
+      window.location.reload();
+    });
+  });
+}
+
 function initTemplateSearch() {
   const $repoTemplate = $('#repo_template');
   const checkTemplate = function () {
@@ -2372,6 +2480,9 @@ $(document).ready(() => {
     }
   }
 
+  // Formulas and diagrams
+  renderMarkupContent(document);
+
   // Dropzone
   const $dropzone = $('#dropzone');
   if ($dropzone.length > 0) {
@@ -2528,6 +2639,7 @@ $(document).ready(() => {
   initCommentForm();
   initInstall();
   initRepository();
+  initLabelEdit();
   initMigration();
   initWikiForm();
   initEditForm();
@@ -2542,13 +2654,14 @@ $(document).ready(() => {
   initCtrlEnterSubmit();
   initNavbarContentToggle();
   initTopicbar();
-  initU2FAuth();
-  initU2FRegister();
+  initWebAuthnAuth();
+  initWebAuthnRegister();
   initIssueList();
   initWipTitle();
   initPullRequestReview();
   initRepoStatusChecker();
   initTemplateSearch();
+  initProjectBoards();
 
   // Repo clone url.
   if ($('#repo-clone-url').length > 0) {
@@ -0,0 +1,50 @@
+/* Formulas and diagrams are rendered on the server as their source, in elements
+   the sanitizer lets through. Their libraries are only loaded when a page
+   contains some. */
+
+async function renderMath(root) {
+  const elements = [].slice.call(root.querySelectorAll('code.math-inline, code.math-display, pre.math-display'));
+  if (!elements.length) return;
+
+  const [{ default: katex }] = await Promise.all([
+    import(/* webpackChunkName: "katex" */'katex'),
+    import(/* webpackChunkName: "katex" */'katex/dist/katex.css'),
+  ]);
+
+  elements.forEach((el) => {
+    const target = document.createElement(el.tagName === 'PRE' ? 'div' : 'span');
+    target.className = 'math';
+    katex.render(el.textContent, target, {
+      displayMode: el.classList.contains('math-display'),
+      throwOnError: false,
+    });
+    el.parentNode.replaceChild(target, el);
+  });
+}
+
+async function renderMermaid(root) {
+  const elements = [].slice.call(root.querySelectorAll('pre.mermaid:not([data-processed])'));
+  if (!elements.length) return;
+
+  const { default: mermaid } = await import(/* webpackChunkName: "mermaid" */'mermaid');
+  mermaid.initialize({
+    startOnLoad: false,
+    securityLevel: 'strict',
+    theme: 'neutral',
+  });
+
+  elements.forEach((el) => {
+    try {
+      mermaid.init(undefined, el);
+    } catch (err) {
+      el.classList.add('mermaid-error');
+      el.setAttribute('title', err.str || err.message || err);
+    }
+  });
+}
+
+export default function renderMarkupContent(root) {
+  if (!root) return;
+  renderMath(root);
+  renderMermaid(root);
+}